@@ -6,15 +6,31 @@ import (
 	"os/exec"
 
 	"github.com/Lattice-Automation/repp/internal/cmd"
+	"github.com/spf13/cobra"
 )
 
 func main() {
-	checkDependencies()
+	if !completing(os.Args[1:]) {
+		checkDependencies()
+	}
 	if err := cmd.RootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
+// completing returns whether repp is writing a completion script or completing
+// a command line for the shell, neither of which needs BLAST or Primer3
+func completing(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return false
+}
+
 func checkDependencies() {
 	if _, err := exec.LookPath(getExecutable("NCBITOOLS_HOME", "bin", "blastn")); err != nil {
 		log.Fatal(`No blastn found. Is BLAST installed? https://blast.ncbi.nlm.nih.gov/Blast.cgi`)
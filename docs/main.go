@@ -112,6 +112,14 @@ var metaMap = map[string]meta{
 		"make",
 		"repp",
 	},
+	"repp_make_ligation": {
+		grandchild,
+		"ligation",
+		3,
+		false,
+		"make",
+		"repp",
+	},
 	"repp_list": {
 		childParent,
 		"list",
@@ -224,6 +232,110 @@ var metaMap = map[string]meta{
 		"repp",
 		"",
 	},
+	"repp_inspect": {
+		child,
+		"inspect",
+		5,
+		false,
+		"repp",
+		"",
+	},
+	"repp_cache": {
+		childParent,
+		"cache",
+		6,
+		true,
+		"repp",
+		"",
+	},
+	"repp_cache_stats": {
+		grandchild,
+		"stats",
+		0,
+		false,
+		"cache",
+		"repp",
+	},
+	"repp_cache_purge": {
+		grandchild,
+		"purge",
+		1,
+		false,
+		"cache",
+		"repp",
+	},
+	"repp_completion": {
+		child,
+		"completion",
+		7,
+		false,
+		"repp",
+		"",
+	},
+	"repp_stats": {
+		childParent,
+		"stats",
+		8,
+		true,
+		"repp",
+		"",
+	},
+	"repp_stats_database": {
+		grandchild,
+		"database",
+		0,
+		false,
+		"stats",
+		"repp",
+	},
+	"repp_verify-output": {
+		child,
+		"verify-output",
+		9,
+		false,
+		"repp",
+		"",
+	},
+	"repp_verify": {
+		child,
+		"verify",
+		10,
+		false,
+		"repp",
+		"",
+	},
+	"repp_advise": {
+		child,
+		"advise",
+		13,
+		false,
+		"repp",
+		"",
+	},
+	"repp_history": {
+		childParent,
+		"history",
+		14,
+		true,
+		"repp",
+		"",
+	},
+	"repp_history_list": {
+		grandchild,
+		"list",
+		0,
+		false,
+		"history",
+		"repp",
+	},
+	"repp_history_show": {
+		grandchild,
+		"show",
+		1,
+		false,
+		"history",
+		"repp",
+	},
 }
 
 // makeDocs parses the custom commands and outputs Markdown documentation files
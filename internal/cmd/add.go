@@ -4,6 +4,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/Lattice-Automation/repp/internal/config"
 	"github.com/Lattice-Automation/repp/internal/repp"
 	"github.com/spf13/cobra"
 )
@@ -26,9 +27,53 @@ var databaseAddCmd = &cobra.Command{
 	Short:                      "Import a FASTA sequence database along with its cost.",
 	Run:                        runDatabaseAddCmd,
 	SuggestionsMinimumDistance: 2,
-	Long:                       "\nImport a new sequence database so its sequences are available to 'repp make'.",
-	Example:                    "  repp add database --name addgene --cost 65.0 ./addgene.fa",
-	Aliases:                    []string{"db"},
+	Long: `
+Import a new sequence database so its sequences are available to 'repp make'.
+The sequence files are parsed in parallel, on the CPUs of --max-cpus, and the
+progress of long imports, ex: of thousands of GenBank files, is logged.
+
+Entries marked do-not-PCR, all of the database's with --no-pcr or those listed
+in --no-pcr-entries, are only sequence references, ex: toxic or unstable
+templates. They're never PCR'ed, and solutions note the synthetic fragments
+that could otherwise have been PCR'ed from them.
+
+Entries are reverse complemented to the orientation of reference features with
+--orient-by, ex: '--orient-by ori,AmpR', if more of the features' 20bp k-mers
+are on their bottom strands than on their top strands, so their template
+coordinates are in a consistent orientation. The flipped entries are listed in
+the database's manifest and marked in the run metadata of outputs using them.
+
+The ends of the entries are screened for the sequencing adapters and vector
+contaminants of target-contaminants-db, the built-in adapters by default, so
+artifacts of cloning or sequencing aren't PCR'ed into new designs. Entries
+with a contaminant within 25bp of an end are flagged in the database's
+manifest, and in the warnings of solutions using them, or the contaminants
+are trimmed off with '--screen-contaminants trim'.
+
+Curated databases shipped with repp are installed with --builtin, ex: common
+cloning vectors with '--builtin backbones', so backbone-based designs can run
+without sourcing FASTA files. Their sequences are fetched from GenBank and
+verified, and the multiple cloning site of each backbone is printed with its
+unique restriction sites, for use with --backbone and --enzymes.`,
+	Example: `  repp add database --name addgene --cost 65.0 ./addgene.fa
+  repp add database --builtin backbones`,
+	Aliases: []string{"db"},
+}
+
+// aliasAddCmd is for adding a BLAST alias database spanning several sequence dbs
+var aliasAddCmd = &cobra.Command{
+	Use:                        "alias [name]",
+	Short:                      "Add a BLAST alias database spanning several sequence databases",
+	Run:                        runAliasAddCmd,
+	SuggestionsMinimumDistance: 2,
+	Long: `
+Build a BLAST alias database (with blastdb_aliastool) spanning several sequence
+databases. 'repp make' queries them with one BLAST run against the alias rather
+than one per database when all of them are used, or when the alias is passed to
+--dbs. Hits are attributed to the first database, in the alias's order, with the
+entry.`,
+	Example: "  repp add alias lab --dbs addgene,igem,dnasu",
+	Args:    cobra.ExactArgs(1),
 }
 
 // featureAddCmd is for adding a new feature to the features db
@@ -37,9 +82,14 @@ var featureAddCmd = &cobra.Command{
 	Short:                      "Add a feature to the features database",
 	Run:                        runFeaturesAddCmd,
 	SuggestionsMinimumDistance: 2,
-	Long:                       "\nAdd a feature in the features database so it can be use used in 'repp make features'",
-	Example:                    "  repp add feature \"custom terminator 3\" CTAGCATAACAAGCTTGGGCACCTGTAAACGGGTCTTGAGGGGTTCCATTTTG",
-	Args:                       cobra.ExactArgs(2),
+	Long: `
+Add a feature in the features database so it can be use used in 'repp make features'
+
+Features may have a type, the organism they're from, a reference and notes.
+'repp make features' warns about typed features in an order that doesn't make
+transcription units, ex: a CDS without a promoter upstream of it.`,
+	Example: "  repp add feature \"custom terminator 3\" CTAGCATAACAAGCTTGGGCACCTGTAAACGGGTCTTGAGGGGTTCCATTTTG --type terminator --organism \"E. coli\"",
+	Args:    cobra.ExactArgs(2),
 }
 
 // enzymeAddCmd is for adding a new feature to the features db
@@ -58,14 +108,30 @@ a cut site in the complement sequence: "_". Use 'repp ls enzyme' for examples`,
 }
 
 func init() {
-	databaseAddCmd.Flags().StringP("name", "n", "", "database name")
+	databaseAddCmd.Flags().StringP("name", "n", "", "database name (defaults to the name of the --builtin database)")
 	databaseAddCmd.Flags().Float64P("cost", "c", 0.0, "the cost per plasmid procurement (eg order + shipping fee)")
 	databaseAddCmd.Flags().Bool("prefixSeqIDs", true, "Prefix sequence IDs with filename")
 	databaseAddCmd.Flags().Bool("circularizeSequences", false, "Prefix sequence IDs with filename")
+	databaseAddCmd.Flags().Bool("mask-ambiguous", false, "Replace non-ACGT bases with N rather than stripping them, preserving the original coordinates")
+	databaseAddCmd.Flags().Bool("no-pcr", false, "use the database's entries only as sequence references, never as PCR templates, ex: for toxic or unstable plasmids")
+	databaseAddCmd.Flags().StringSlice("no-pcr-entries", nil, "comma separated IDs of the database's entries to use only as sequence references, never as PCR templates")
+	databaseAddCmd.Flags().StringSlice("orient-by", nil, "comma separated features, ex: ori,AmpR, to reverse complement entries to the orientation of")
+	databaseAddCmd.Flags().String("builtin", "", "install a curated database shipped with repp rather than sequence files, ex: backbones")
+	databaseAddCmd.Flags().String("screen-contaminants", "flag", "screen the ends of the entries for the sequencing adapters of target-contaminants-db, ex: left on reads, and flag or trim them; valid values [none, flag, trim]")
+	databaseAddCmd.Flags().String("max-file-sz", "", "max size of the database's BLAST volumes, ex: 1GB, at most 4GB (defaults to the size it was last built with, then blast-max-file-size in the config)")
+
+	featureAddCmd.Flags().String("type", "", "type of the feature; valid values [promoter, rbs, utr, cds, tag, linker, terminator, ori, marker, insulator, misc]")
+	featureAddCmd.Flags().String("organism", "", "organism the feature is from")
+	featureAddCmd.Flags().String("reference", "", "reference for the feature, ex: a DOI or GenBank accession")
+	featureAddCmd.Flags().String("notes", "", "notes about the feature")
+
+	aliasAddCmd.Flags().StringSlice("dbs", nil, "comma separated list of the databases the alias spans")
+	must(aliasAddCmd.MarkFlagRequired("dbs"))
 
-	must(databaseAddCmd.MarkFlagRequired("name"))
+	must(databaseAddCmd.RegisterFlagCompletionFunc("builtin", completeArgs(repp.BuiltinDatabases, 0)))
 
 	addCmd.AddCommand(databaseAddCmd)
+	addCmd.AddCommand(aliasAddCmd)
 	addCmd.AddCommand(featureAddCmd)
 	addCmd.AddCommand(enzymeAddCmd)
 
@@ -89,25 +155,97 @@ func runDatabaseAddCmd(cmd *cobra.Command, args []string) {
 	}
 	prefixSeqIDs, err := cmd.Flags().GetBool("prefixSeqIDs")
 	if err != nil {
-		log.Print("Error encountered reading prefiSeqIDs flag", err)
+		warnf("Error encountered reading prefiSeqIDs flag: %v", err)
 		prefixSeqIDs = false
 	}
 	circularizeSequences, err := cmd.Flags().GetBool("circularizeSequences")
 	if err != nil {
-		log.Print("Error encountered reading circularized flag", err)
+		warnf("Error encountered reading circularized flag: %v", err)
 		prefixSeqIDs = false
 	}
 
+	maskAmbiguous, err := cmd.Flags().GetBool("mask-ambiguous")
+	if err != nil {
+		warnf("Error encountered reading mask-ambiguous flag: %v", err)
+		maskAmbiguous = false
+	}
+
+	maxFileSize, err := cmd.Flags().GetString("max-file-sz")
+	if err != nil {
+		log.Fatal("Max file size must be a string", err)
+	}
+
+	noPCR, err := cmd.Flags().GetBool("no-pcr")
+	if err != nil {
+		log.Fatal("No PCR must be a boolean", err)
+	}
+	noPCREntries, err := cmd.Flags().GetStringSlice("no-pcr-entries")
+	if err != nil {
+		log.Fatal("No PCR entries must be a list of IDs", err)
+	}
+
+	orientBy, err := cmd.Flags().GetStringSlice("orient-by")
+	if err != nil {
+		log.Fatal("Orient by must be a list of features", err)
+	}
+
+	screen, err := cmd.Flags().GetString("screen-contaminants")
+	if err != nil {
+		log.Fatal("Screen contaminants must be a string", err)
+	}
+
+	conf := config.New()
+	builtin, err := cmd.Flags().GetString("builtin")
+	if err != nil {
+		log.Fatal("Builtin database must be a string", err)
+	}
+	if builtin != "" {
+		if dbName == "" {
+			dbName = builtin
+		}
+		if err = repp.AddBuiltinDatabase(builtin, dbName, cost, conf.Strict); err != nil {
+			log.Fatalf("Error creating database %s: %v", dbName, err)
+		}
+		return
+	}
+	if dbName == "" {
+		log.Fatal("A database name is required, pass it with --name")
+	}
+
 	seqFiles, err := repp.CollectFiles(args)
 	if err != nil {
 		log.Fatalf("Errors encountered collection sequence files from %v: %v", args, err)
 	}
 
-	if err = repp.AddDatabase(dbName, seqFiles, circularizeSequences, cost, prefixSeqIDs); err != nil {
+	opts := repp.ImportOptions{
+		Circularize:    circularizeSequences,
+		Cost:           cost,
+		PrefixSeqIDs:   prefixSeqIDs,
+		MaskAmbiguous:  maskAmbiguous,
+		MaxFileSize:    maxFileSize,
+		NoPCR:          noPCR,
+		NoPCREntries:   noPCREntries,
+		OrientBy:       orientBy,
+		Screen:         screen,
+		ContaminantsDB: conf.GetTargetContaminantsDB(),
+		Strict:         conf.Strict,
+	}
+	if err = repp.AddDatabase(dbName, seqFiles, opts); err != nil {
 		log.Fatalf("Error creating database %s: %v", dbName, err)
 	}
 }
 
+func runAliasAddCmd(cmd *cobra.Command, args []string) {
+	dbs, err := cmd.Flags().GetStringSlice("dbs")
+	if err != nil {
+		log.Fatal("Databases must be a comma separated list", err)
+	}
+
+	if err = repp.AddAlias(args[0], dbs); err != nil {
+		log.Fatalf("Error creating alias %s: %v", args[0], err)
+	}
+}
+
 func runFeaturesAddCmd(cmd *cobra.Command, args []string) {
 	var name, seq string
 
@@ -126,7 +264,13 @@ func runFeaturesAddCmd(cmd *cobra.Command, args []string) {
 
 	}
 
-	repp.AddFeatures(name, seq)
+	var meta repp.FeatureMeta
+	meta.Type, _ = cmd.Flags().GetString("type")
+	meta.Organism, _ = cmd.Flags().GetString("organism")
+	meta.Reference, _ = cmd.Flags().GetString("reference")
+	meta.Notes, _ = cmd.Flags().GetString("notes")
+
+	repp.AddFeatures(name, seq, meta)
 }
 
 func runEnzymesAddCmd(cmd *cobra.Command, args []string) {
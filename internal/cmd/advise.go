@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/Lattice-Automation/repp/internal/config"
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// adviseCmd is for recommending how to assemble a target before designing it.
+var adviseCmd = &cobra.Command{
+	Use:                        "advise",
+	Run:                        runAdviseCmd,
+	Short:                      "Recommend an assembly method for a target sequence",
+	SuggestionsMinimumDistance: 3,
+	Long: `Recommend how to assemble a target: as a single-fragment clone, by Gibson
+Assembly, by Golden Gate assembly or by synthesizing all of it. The target is
+BLASTed against the databases and planned roughly, from its longest match, with
+the fewest matches joined by primer tails and the gaps between them synthesized.
+
+A target that one database entry covers is cloned from it. One that's less
+than half in the databases, or cheaper to synthesize than to build from them,
+is synthesized. Otherwise it's built by Gibson Assembly, or by Golden Gate
+assembly if the plan has more than 6 fragments and the target has no BsaI or
+no BsmBI sites. The reasons for the recommendation are printed with the
+'repp make sequence' command that designs it.`,
+	Example: `repp advise -i target.fa --dbs addgene,igem`,
+}
+
+// set flags
+func init() {
+	adviseCmd.Flags().StringP("in", "i", "", "input file name (FASTA or Genbank)")
+	adviseCmd.Flags().StringP("dbs", "d", "", "list of sequence databases by name")
+	adviseCmd.Flags().StringP("exclude", "x", "", "keywords for excluding fragments")
+	adviseCmd.Flags().String("filter", "", filterHelp)
+	adviseCmd.Flags().IntP("identity", "p", 100, "%-identity threshold (see 'blastn -help')")
+	adviseCmd.Flags().String("topology", "auto", "target topology; valid values [auto, circular, linear]. auto uses the GenBank LOCUS line, defaulting to circular")
+
+	must(adviseCmd.MarkFlagRequired("in"))
+	must(adviseCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
+
+	RootCmd.AddCommand(adviseCmd)
+}
+
+func runAdviseCmd(cmd *cobra.Command, args []string) {
+	params := repp.MkAssemblyParams()
+	in, _ := cmd.Flags().GetString("in")
+	params.SetIn(in)
+	params.SetDbNames(extractDbNames(cmd))
+	params.SetFilters(extractExcludedValues(cmd))
+	filterExpr, _ := cmd.Flags().GetString("filter")
+	params.SetFilterExpr(filterExpr)
+	params.SetIdentity(extractIdentity(cmd, 100))
+	params.SetLeftMargin(extractLeftMargin(cmd, 200))
+	params.SetTopology(extractTopology(cmd))
+
+	repp.Advise(params, config.New())
+}
@@ -33,6 +33,7 @@ func init() {
 	annotateCmd.Flags().Bool("ungapped", false, "Ungapped alignment flag")
 	annotateCmd.Flags().BoolP("cull", "c", true, "remove features enclosed in others")
 	annotateCmd.Flags().BoolP("names", "n", false, "log feature names to the console")
+	must(annotateCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
 
 	RootCmd.AddCommand(annotateCmd)
 }
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd is for inspecting and purging the cache of BLAST and primer3 results
+var cacheCmd = &cobra.Command{
+	Use:                        "cache",
+	Short:                      "Inspect or purge the cache of BLAST and primer3 results",
+	SuggestionsMinimumDistance: 2,
+	Long: `Inspect or purge the cache of BLAST and primer3 results in the repp data directory.
+
+Results are cached by their inputs and reused by later runs. BLAST results are
+cached by database and purged when the database is rebuilt. The least recently
+used results are evicted once the cache is larger than 'cache-max-size-mb' in
+the config; set it to 0 to disable the cache.`,
+}
+
+// cacheStatsCmd is for printing the number and size of cached results
+var cacheStatsCmd = &cobra.Command{
+	Use:                        "stats",
+	Short:                      "Print the number and size of cached results",
+	Run:                        runCacheStatsCmd,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp cache stats",
+	Args:                       cobra.NoArgs,
+}
+
+// cachePurgeCmd is for removing cached results
+var cachePurgeCmd = &cobra.Command{
+	Use:                        "purge",
+	Short:                      "Remove cached results",
+	Run:                        runCachePurgeCmd,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp cache purge --db igem",
+	Long:                       `Remove the cached BLAST results against a database, or every cached result if no database is named.`,
+	Args:                       cobra.NoArgs,
+}
+
+// set flags
+func init() {
+	cachePurgeCmd.Flags().String("db", "", "only remove the cached BLAST results against this database")
+	must(cachePurgeCmd.RegisterFlagCompletionFunc("db", completeArgs(repp.DatabaseNames, 0)))
+
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePurgeCmd)
+
+	RootCmd.AddCommand(cacheCmd)
+}
+
+func runCacheStatsCmd(cmd *cobra.Command, args []string) {
+	repp.CacheStats(config.New())
+}
+
+func runCachePurgeCmd(cmd *cobra.Command, args []string) {
+	db, err := cmd.Flags().GetString("db")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repp.PurgeCache(db)
+}
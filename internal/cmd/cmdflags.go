@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Lattice-Automation/repp/internal/config"
 	"github.com/Lattice-Automation/repp/internal/repp"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slices"
@@ -17,6 +18,8 @@ func parseFeatureAssemblyParams(cmd *cobra.Command, args []string, strict bool)
 	extractCommonParams(cmd, args, params)
 	// extract filters
 	params.SetFilters(extractExcludedValues(cmd))
+	filterExpr, _ := cmd.Flags().GetString("filter")
+	params.SetFilterExpr(filterExpr)
 
 	return params
 }
@@ -28,6 +31,69 @@ func parseSequenceAssemblyParams(cmd *cobra.Command, args []string, strict bool)
 	extractCommonParams(cmd, args, params)
 	// extract filters
 	params.SetFilters(extractExcludedValues(cmd))
+	filterExpr, _ := cmd.Flags().GetString("filter")
+	params.SetFilterExpr(filterExpr)
+	params.SetTopology(extractTopology(cmd))
+
+	synthOnly, _ := cmd.Flags().GetBool("synth-only")
+	if synthOnly && params.GetBackboneName() != "" {
+		log.Fatal("--synth-only can't be used with --backbone, the backbone is PCR'ed from a database")
+	}
+	params.SetSynthOnly(synthOnly)
+
+	pareto, _ := cmd.Flags().GetBool("pareto")
+	if pareto && cmd.Flags().Changed("max-kept-solutions") {
+		log.Fatal("--pareto can't be used with --max-kept-solutions, one solution is kept per point of the pareto front")
+	}
+	params.SetPareto(pareto)
+
+	reuseFrom, _ := cmd.Flags().GetString("reuse-from")
+	if reuseFrom != "" && synthOnly {
+		log.Fatal("--reuse-from can't be used with --synth-only, only PCR fragments' primers are reused")
+	}
+	params.SetReuseFrom(reuseFrom)
+
+	diffAgainst, _ := cmd.Flags().GetString("diff-against")
+	if diffAgainst != "" && synthOnly {
+		log.Fatal("--diff-against can't be used with --synth-only, synthetic fragments aren't BLASTed")
+	}
+	if diffAgainst != "" && params.GetBackboneName() != "" {
+		log.Fatal("--diff-against can't be used with --backbone, the backbone changes the BLASTed target")
+	}
+	params.SetDiffAgainst(diffAgainst)
+
+	landingPads, _ := cmd.Flags().GetString("landing-pads")
+	if landingPads != "" && synthOnly {
+		log.Fatal("--landing-pads can't be used with --synth-only, synthetic fragments' junctions are picked by their homology")
+	}
+	params.SetLandingPads(splitStringOn(landingPads, []rune{' ', ','}))
+
+	fromGenbankFeatures, _ := cmd.Flags().GetBool("from-genbank-features")
+	if fromGenbankFeatures && synthOnly {
+		log.Fatal("--from-genbank-features can't be used with --synth-only, every feature is synthesized")
+	}
+	params.SetFromGenbankFeatures(fromGenbankFeatures)
+
+	monomer, _ := cmd.Flags().GetBool("monomer")
+	params.SetMonomer(monomer)
+
+	synthesize, _ := cmd.Flags().GetString("synthesize")
+	if synthesize != "" && synthOnly {
+		log.Fatal("--synthesize can't be used with --synth-only, the whole target is synthesized")
+	}
+	params.SetSynthesizeRegions(splitStringOn(synthesize, []rune{' ', ','}))
+
+	identityRegions, _ := cmd.Flags().GetString("identity-regions")
+	if identityRegions != "" && synthOnly {
+		log.Fatal("--identity-regions can't be used with --synth-only, synthetic fragments match the target")
+	}
+	params.SetIdentityRegions(identityRegions)
+
+	dumpMatches, _ := cmd.Flags().GetString("dump-matches")
+	if dumpMatches != "" && synthOnly {
+		log.Fatal("--dump-matches can't be used with --synth-only, the target isn't BLASTed")
+	}
+	params.SetDumpMatches(dumpMatches)
 	return params
 }
 
@@ -37,6 +103,18 @@ func parseFragmentsAssemblyParams(cmd *cobra.Command, args []string, strict bool
 
 	extractCommonParams(cmd, args, params)
 
+	inferOrder, _ := cmd.Flags().GetBool("infer-order")
+	params.SetInferOrder(inferOrder)
+
+	syntheticFragments, _ := cmd.Flags().GetString("synthetic")
+	params.SetSyntheticFragments(splitStringOn(syntheticFragments, []rune{' ', ','}))
+
+	chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+	if chunkSize < 0 {
+		log.Fatalf("invalid --chunk-size %d, it must be positive", chunkSize)
+	}
+	params.SetChunkSize(chunkSize)
+
 	return params
 }
 
@@ -52,6 +130,38 @@ func extractExcludedValues(cmd *cobra.Command) []string {
 	return splitStringOn(strings.ToUpper(excluded), []rune{' ', ','})
 }
 
+// extractTopology returns the target topology override, empty to infer it from the input
+func extractTopology(cmd *cobra.Command) string {
+	topology, err := cmd.Flags().GetString("topology")
+	if err != nil {
+		return ""
+	}
+
+	topology = strings.ToLower(topology)
+	switch topology {
+	case "circular", "linear":
+		return topology
+	case "", "auto":
+		return ""
+	}
+	log.Fatalf("unknown topology: %s; valid values [auto, circular, linear]", topology)
+	return ""
+}
+
+// extractPrimerAdditions returns the fwd and rev 5' additions of the "FWD,REV" primer-additions flag
+func extractPrimerAdditions(cmd *cobra.Command) (fwd, rev string) {
+	additions, err := cmd.Flags().GetString("primer-additions")
+	if err != nil || additions == "" {
+		return "", ""
+	}
+
+	parts := strings.Split(additions, ",")
+	if len(parts) != 2 {
+		log.Fatalf("invalid primer additions: %s; expected FWD,REV, either may be empty", additions)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
 func extractIdentity(cmd *cobra.Command, defaultValue int) int {
 	// get identity for blastn searching
 	identity, err := cmd.Flags().GetInt("identity")
@@ -66,13 +176,19 @@ func extractUngapped(cmd *cobra.Command) bool {
 	return ungapped
 }
 
+// extractLeftMargin returns the left margin for blastn searching: --left-margin if it's passed,
+// else the match-left-margin setting if it's set, else the flag's default or defaultValue
+// for commands without the flag
 func extractLeftMargin(cmd *cobra.Command, defaultValue int) int {
-	// get left margin for blastn searching
-	leftMargin, err := cmd.Flags().GetInt("left-margin")
-	if err != nil {
-		leftMargin = defaultValue
+	flag := cmd.Flags().Lookup("left-margin")
+	if flag != nil && flag.Changed {
+		leftMargin, _ := cmd.Flags().GetInt("left-margin")
+		return leftMargin
+	}
+	if flag != nil {
+		defaultValue, _ = cmd.Flags().GetInt("left-margin")
 	}
-	return leftMargin
+	return config.New().GetMatchLeftMargin(defaultValue)
 }
 
 func extractDbNames(cmd *cobra.Command) []string {
@@ -103,20 +219,38 @@ func extractOutputFormat(cmd *cobra.Command) string {
 		if helperr := cmd.Help(); helperr != nil {
 			log.Fatal(helperr)
 		}
-		log.Printf("failed to parse output format arg: %v - will use CSV", err)
+		warnf("failed to parse output format arg: %v - will use CSV", err)
 		outputFormat = "CSV"
 	} else {
 		outputFormat = strings.ToUpper(outputFormat)
 	}
 
-	if outputFormat == "JSON" || outputFormat == "CSV" {
+	if outputFormat == "JSON" || outputFormat == "CSV" || outputFormat == "XLSX" || outputFormat == "TSV-KV" || outputFormat == "JSON-V1" {
 		return outputFormat
 	} else {
-		log.Printf("unknown output format: %s - will use CSV", outputFormat)
+		warnf("unknown output format: %s - will use CSV", outputFormat)
 		return "CSV"
 	}
 }
 
+// extractTrackFormat returns the format of the per solution tracks, empty if none were requested
+func extractTrackFormat(cmd *cobra.Command) string {
+	trackFormat, err := cmd.Flags().GetString("track-fmt")
+	if err != nil {
+		return "" // the command has no tracks
+	}
+
+	trackFormat = strings.ToUpper(trackFormat)
+	if trackFormat == "GFF3" {
+		trackFormat = "GFF"
+	}
+	if trackFormat == "" || trackFormat == "BED" || trackFormat == "GFF" {
+		return trackFormat
+	}
+	warnf("unknown track format: %s - no tracks will be written", trackFormat)
+	return ""
+}
+
 func extractOligosDatabases(cmd *cobra.Command, argname string) []string {
 	dbNames, err := cmd.Flags().GetString(argname)
 	if err != nil {
@@ -148,6 +282,19 @@ func extractCommonParams(cmd *cobra.Command, args []string, params repp.Assembly
 	params.SetOut(outputFName)
 
 	params.SetOutputFormat(extractOutputFormat(cmd))
+	params.SetTrackFormat(extractTrackFormat(cmd))
+
+	emitOrderFiles, _ := cmd.Flags().GetBool("emit-order-files")
+	params.SetEmitOrderFiles(emitOrderFiles)
+
+	emitProtocol, _ := cmd.Flags().GetBool("emit-protocol")
+	params.SetEmitProtocol(emitProtocol)
+
+	redact, _ := cmd.Flags().GetBool("redact")
+	params.SetRedact(redact)
+
+	selfCheck, _ := cmd.Flags().GetBool("self-check")
+	params.SetSelfCheck(selfCheck)
 
 	// get identity for blastn searching
 	params.SetIdentity(extractIdentity(cmd, 100))
@@ -178,9 +325,14 @@ func guessOutput(in, format string) (out string) {
 	ext := filepath.Ext(in)
 	noExt := in[0 : len(in)-len(ext)]
 	var suffix string
-	if format == "CSV" {
+	switch format {
+	case "CSV":
 		suffix = ".output.csv"
-	} else {
+	case "XLSX":
+		suffix = ".output.xlsx"
+	case "TSV-KV":
+		suffix = ".output.tsv"
+	default:
 		suffix = ".output.json"
 	}
 	return noExt + suffix
@@ -191,9 +343,14 @@ func adjustOutput(name, format string) (newName string) {
 	if ext == "" {
 		noExt := name[0 : len(name)-len(ext)]
 		var suffix string
-		if format == "CSV" {
+		switch format {
+		case "CSV":
 			suffix = ".csv"
-		} else {
+		case "XLSX":
+			suffix = ".xlsx"
+		case "TSV-KV":
+			suffix = ".tsv"
+		default:
 			suffix = ".json"
 		}
 		return noExt + suffix
@@ -116,6 +116,14 @@ func Test_guessOutput(t *testing.T) {
 			},
 			"./test_file.output.csv",
 		},
+		{
+			"append xlsx suffix",
+			args{
+				in:           "./test_file.fa",
+				outputFormat: "XLSX",
+			},
+			"./test_file.output.xlsx",
+		},
 		{
 			"unknown format - use JSON",
 			args{
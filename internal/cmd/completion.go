@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// completionCmd is for writing shell completion scripts
+var completionCmd = &cobra.Command{
+	Use:                   "completion [bash|zsh|fish]",
+	Short:                 "Write a shell completion script",
+	Run:                   runCompletionCmd,
+	DisableFlagsInUseLine: true,
+	Example: `  source <(repp completion bash)
+  repp completion zsh > "${fpath[1]}/_repp"
+  repp completion fish > ~/.config/fish/completions/repp.fish`,
+	Long: `Write a script to stdout that completes repp's commands and flags in the shell.
+
+Database, enzyme and feature names are completed from the manifest and the
+enzymes and features databases when the shell asks for them.`,
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+}
+
+// set flags
+func init() {
+	RootCmd.CompletionOptions.DisableDefaultCmd = true
+	RootCmd.AddCommand(completionCmd)
+}
+
+func runCompletionCmd(cmd *cobra.Command, args []string) {
+	var err error
+	switch args[0] {
+	case "bash":
+		err = RootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		err = RootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		err = RootCmd.GenFishCompletion(os.Stdout, true)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// completeFunc is the signature of cobra's dynamic completion functions
+type completeFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+var (
+	// completeDBList completes a comma separated list of database names
+	completeDBList = completeList(repp.DatabaseNames)
+
+	// completeEnzymeList completes a comma separated list of enzyme names
+	completeEnzymeList = completeList(repp.EnzymeNames)
+
+	// completeFeatureList completes a comma separated list of feature names
+	completeFeatureList = completeList(repp.FeatureNames)
+)
+
+// completeList returns a completion function for the last name in a comma separated list.
+// Names already in the list aren't suggested again.
+func completeList(names func() []string) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return listCompletions(names(), toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+// listCompletions returns the completions of the last name in a comma separated list
+func listCompletions(names []string, toComplete string) (completions []string) {
+	listed := make(map[string]bool)
+	prefix, last := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix, last = toComplete[:i+1], toComplete[i+1:]
+		for _, name := range strings.Split(toComplete[:i], ",") {
+			listed[strings.TrimSpace(name)] = true
+		}
+	}
+
+	for _, name := range names {
+		if !listed[name] && strings.HasPrefix(name, last) {
+			completions = append(completions, prefix+name)
+		}
+	}
+	return completions
+}
+
+// completeArgs returns a completion function for up to maxArgs positional names
+func completeArgs(names func() []string, maxArgs int) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if maxArgs > 0 && len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var completions []string
+		for _, name := range names() {
+			if strings.HasPrefix(name, toComplete) {
+				completions = append(completions, name)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_listCompletions(t *testing.T) {
+	names := []string{"addgene", "dnasu", "igem"}
+
+	tests := []struct {
+		name       string
+		toComplete string
+		want       []string
+	}{
+		{"all names", "", []string{"addgene", "dnasu", "igem"}},
+		{"names with the prefix", "ig", []string{"igem"}},
+		{"last name in a list", "igem,d", []string{"igem,dnasu"}},
+		{"skips names already in the list", "igem,", []string{"igem,addgene", "igem,dnasu"}},
+		{"no matching names", "x", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listCompletions(names, tt.toComplete); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listCompletions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
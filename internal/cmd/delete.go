@@ -23,7 +23,7 @@ var databaseDeleteCmd = &cobra.Command{
 	Short:                      "Delete a sequence database",
 	Run:                        runDatabaseDeleteCmd,
 	SuggestionsMinimumDistance: 2,
-	Example:                    "  repp delete database \"igem\"",
+	Example:                    "  repp delete database \"igem\" --purge-cache",
 	Aliases:                    []string{"db"},
 	Args:                       cobra.ExactArgs(1),
 }
@@ -42,6 +42,10 @@ If no such feature name exists in the database, an error is logged to stderr.`,
 
 // set flags
 func init() {
+	databaseDeleteCmd.Flags().Bool("purge-cache", false, "remove the cached BLAST results against the database")
+	databaseDeleteCmd.ValidArgsFunction = completeArgs(repp.DatabaseNames, 1)
+	featuresDeleteCmd.ValidArgsFunction = completeArgs(repp.FeatureNames, 1)
+
 	deleteCmd.AddCommand(databaseDeleteCmd)
 	deleteCmd.AddCommand(featuresDeleteCmd)
 
@@ -57,7 +61,12 @@ func runDatabaseDeleteCmd(cmd *cobra.Command, args []string) {
 	}
 	db := args[0]
 
-	repp.DeleteDatabase(db)
+	purgeCache, err := cmd.Flags().GetBool("purge-cache")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repp.DeleteDatabase(db, purgeCache)
 }
 
 func runFeaturesDeleteCmd(cmd *cobra.Command, args []string) {
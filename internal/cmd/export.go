@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"github.com/Lattice-Automation/repp/internal/config"
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd is for exporting the solutions of an output to other tools
+var exportCmd = &cobra.Command{
+	Use:                        "export",
+	Short:                      "Export the solutions of a JSON output for other tools",
+	SuggestionsMinimumDistance: 2,
+	Long:                       "\nExport the solutions of a JSON output in the import formats of other tools, as CSVs, or its design history as provenance.",
+}
+
+// benchlingExportCmd is for exporting a solution as Benchling bulk import files
+var benchlingExportCmd = &cobra.Command{
+	Use:                        "benchling [output]",
+	Short:                      "Export a solution as Benchling bulk import files",
+	Run:                        runBenchlingExportCmd,
+	SuggestionsMinimumDistance: 2,
+	Long: `
+Export a solution of a JSON output as Benchling bulk import files, next to the
+output. The GenBank file has the target, annotated with the solution's
+fragments, primer binding sites and junctions, and a record of each fragment's
+product. The oligos CSV has the solution's primers, by name and bases, with
+their fragment, direction, Tm, GC%, priming region, plate, well and notes as
+extra columns to map to the fields of an oligo schema.
+
+Primers in the --primers-databases manifests keep their IDs and wells, new ones
+are named as in the reagents CSV.`,
+	Example: "  repp export benchling ./target_plasmid.output.json --solution 2",
+	Args:    cobra.ExactArgs(1),
+}
+
+// csvExportCmd is for regenerating the CSVs of a JSON output
+var csvExportCmd = &cobra.Command{
+	Use:                        "csv [output]",
+	Short:                      "Export the solutions of a JSON output as strategy and reagents CSVs",
+	Run:                        runCSVExportCmd,
+	SuggestionsMinimumDistance: 2,
+	Long: `
+Export the solutions of a JSON output as the strategy, reagents, junctions and
+pick list CSVs that a design with --out-fmt CSV writes, without redoing the
+design. Use it to regenerate them with other options, ex: with fragment
+locations or against other --primers-databases and --synth-frags-databases
+manifests.
+
+Fragment locations are found from the fragments' sequences. Template locations
+aren't in the JSON, and are N/A. JSON outputs can't be rebuilt from CSVs,
+which don't have the fragments' sequences.`,
+	Example: "  repp export csv ./target_plasmid.output.json --frag-locations -m primers.csv",
+	Args:    cobra.ExactArgs(1),
+}
+
+// provExportCmd is for exporting the design history of a JSON output as provenance
+var provExportCmd = &cobra.Command{
+	Use:                        "prov [output]",
+	Short:                      "Export the design history of a JSON output as PROV-O provenance",
+	Run:                        runProvExportCmd,
+	SuggestionsMinimumDistance: 2,
+	Long: `
+Export the design history of a JSON output as PROV-O provenance in JSON-LD, for
+audits. The design is a prov:Activity, associated with the repp version that
+ran it, that used the target, the config, the databases and the versions of
+the templates, and that generated the solutions. Each solution is a collection
+of its fragments, derived from their templates. Sequences are sbol:Sequence
+entities with their SHA256 checksums.
+
+Node IDs are URNs of the output's plan hash, so exports of the same plan have
+the same IDs. The databases and templates are only in outputs with run metadata.`,
+	Example: "  repp export prov ./target_plasmid.output.json -o target_plasmid.prov.jsonld",
+	Args:    cobra.ExactArgs(1),
+}
+
+// set flags
+func init() {
+	benchlingExportCmd.Flags().IntP("solution", "n", 1, "solution to export, 1 is the first")
+	benchlingExportCmd.Flags().StringP("primers-databases", "m", "", "Comma separated list of CSV or xlsx primers database files")
+	benchlingExportCmd.Flags().StringP("synth-frags-databases", "s", "", "Comma separated list of CSV or xlsx synthetic fragments database files")
+
+	csvExportCmd.Flags().StringP("out", "o", "", "CSV output file name, the strategy and reagents are written next to it (default the output with a .csv extension)")
+	csvExportCmd.Flags().Bool("frag-locations", false, "include the fragments' locations in the strategy (or set include-frag-location-in-strategy-output in the config)")
+	csvExportCmd.Flags().StringP("primers-databases", "m", "", "Comma separated list of CSV or xlsx primers database files")
+	csvExportCmd.Flags().StringP("synth-frags-databases", "s", "", "Comma separated list of CSV or xlsx synthetic fragments database files")
+
+	provExportCmd.Flags().StringP("out", "o", "", "provenance output file name (default the output with a .prov.jsonld extension)")
+
+	exportCmd.AddCommand(benchlingExportCmd)
+	exportCmd.AddCommand(csvExportCmd)
+	exportCmd.AddCommand(provExportCmd)
+
+	RootCmd.AddCommand(exportCmd)
+}
+
+func runBenchlingExportCmd(cmd *cobra.Command, args []string) {
+	solution, _ := cmd.Flags().GetInt("solution")
+	repp.ExportBenchling(args[0], solution, extractOligosDatabases(cmd, "primers-databases"), extractOligosDatabases(cmd, "synth-frags-databases"))
+}
+
+func runCSVExportCmd(cmd *cobra.Command, args []string) {
+	out, _ := cmd.Flags().GetString("out")
+	fragLocations := config.New().IncludeFragLocationInStrategyOutput
+	if cmd.Flags().Changed("frag-locations") {
+		fragLocations, _ = cmd.Flags().GetBool("frag-locations")
+	}
+	repp.ExportCSV(args[0], out, fragLocations, extractOligosDatabases(cmd, "primers-databases"), extractOligosDatabases(cmd, "synth-frags-databases"))
+}
+
+func runProvExportCmd(cmd *cobra.Command, args []string) {
+	out, _ := cmd.Flags().GetString("out")
+	repp.ExportProv(args[0], out)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd is for finding previous design runs in the local history
+var historyCmd = &cobra.Command{
+	Use:                        "history",
+	Short:                      "List or show previous design runs",
+	SuggestionsMinimumDistance: 2,
+	Long: `List or show the design runs in the local history, to find previous designs
+and the parameters they were made with.
+
+Each 'repp make' run is recorded in history.jsonl of the repp data directory
+when it finishes or fails: its command line, working directory, the paths and
+SHA-256s of its input files, the output path, wall time and the fragment count
+and cost of its first solution. Set history to false in the config to stop
+recording runs.`,
+}
+
+// listHistoryCmd is for listing the most recent runs in the history
+var listHistoryCmd = &cobra.Command{
+	Use:                        "list",
+	Short:                      "List the most recent design runs",
+	Run:                        runListHistoryCmd,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp history list --limit 50",
+	Long: `List the most recent design runs, newest first: their ID, time, command,
+status, target, result and output file. IDs are the runs' order in the
+history, so they don't change as runs are added.`,
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+}
+
+// showHistoryCmd is for showing everything recorded about a run
+var showHistoryCmd = &cobra.Command{
+	Use:                        "show [id]",
+	Short:                      "Show a design run's parameters, inputs and result",
+	Run:                        runShowHistoryCmd,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp history show 12",
+	Long: `Show everything recorded about a design run: its full command line, working
+directory, input files with their SHA-256, status, result, output file and wall
+time. Inputs that changed or were removed since the run are flagged. The latest
+run is shown if no ID is passed.`,
+	Args: cobra.MaximumNArgs(1),
+}
+
+// set flags
+func init() {
+	listHistoryCmd.Flags().IntP("limit", "n", 20, "most runs to list, all if 0")
+	listHistoryCmd.Flags().Bool("json", false, "print the history's entries as JSON, to export them")
+
+	historyCmd.AddCommand(listHistoryCmd)
+	historyCmd.AddCommand(showHistoryCmd)
+
+	RootCmd.AddCommand(historyCmd)
+}
+
+func runListHistoryCmd(cmd *cobra.Command, args []string) {
+	limit, _ := cmd.Flags().GetInt("limit")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	repp.ListHistory(limit, asJSON)
+}
+
+func runShowHistoryCmd(cmd *cobra.Command, args []string) {
+	id := 0
+	if len(args) > 0 {
+		var err error
+		if id, err = strconv.Atoi(args[0]); err != nil || id < 1 {
+			log.Fatalf("invalid run ID: %s; IDs are the numbers in 'repp history list'", args[0])
+		}
+	}
+	repp.ShowHistory(id)
+}
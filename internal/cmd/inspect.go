@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// inspectCmd is for printing the run metadata of an output.
+var inspectCmd = &cobra.Command{
+	Use:                        "inspect [output]",
+	Run:                        runInspectCmd,
+	Short:                      "Print the run metadata of a repp output",
+	SuggestionsMinimumDistance: 3,
+	Args:                       cobra.ExactArgs(1),
+	Long: `Print the metadata embedded in a repp output: the repp version and commit,
+the command line, hostname, checksums of the sequence databases, the full
+resolved config, and a hash of the assembly plan.
+
+The plan hash is the same for runs that produce the same plan, so it can be
+used to audit reproducibility or as a cache key. For CSV outputs, pass the
+'-metadata.json' file written next to the strategy and reagents files.`,
+	Example: `repp inspect ./target_plasmid.output.json`,
+}
+
+// set flags
+func init() {
+	RootCmd.AddCommand(inspectCmd)
+}
+
+func runInspectCmd(cmd *cobra.Command, args []string) {
+	repp.Inspect(args[0])
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/Lattice-Automation/repp/internal/config"
 	"github.com/Lattice-Automation/repp/internal/repp"
 
 	"github.com/spf13/cobra"
@@ -38,7 +39,8 @@ var featureListCmd = &cobra.Command{
 	SuggestionsMinimumDistance: 2,
 	Example:                    "  repp list feature terminator",
 	Long: `List features in the features database that are similar to [name].
-Writes each feature to the stdout with their name and sequence.
+Writes each feature to the stdout with their name, type and sequence, and the
+organism, reference and notes of an exact match.
 
 If multiple features contain the feature name sent, each are logged.
 Otherwise, all features with names similar to the feature name are writen to stdout`,
@@ -65,8 +67,9 @@ var fragmentListCmd = &cobra.Command{
 	Example:                    "  repp list fragment pSB1C3 --dbs igem",
 	Run:                        runFragmentListCmd,
 	SuggestionsMinimumDistance: 2,
-	Long:                       `List fragments with a passed name in the specified databases`,
-	Aliases:                    []string{"fragments"},
+	Long: `List fragments with a passed name in the specified databases.
+If no fragment has the name, the fragments whose IDs or titles contain its words are listed`,
+	Aliases: []string{"fragments"},
 }
 
 // sequenceListCmd is for finding a sequence in the dbs
@@ -88,7 +91,12 @@ func init() {
 	sequenceListCmd.Flags().StringP("exclude", "x", "", "keywords for excluding fragments")
 	sequenceListCmd.Flags().IntP("identity", "t", 100, "match %-identity threshold (see 'blastn -help')")
 	sequenceListCmd.Flags().Bool("ungapped", false, "Ungapped alignment flag")
-	sequenceListCmd.Flags().Int("left-margin", 100, "left margin for matches at the beginning of a circular genome")
+	sequenceListCmd.Flags().Int("left-margin", 100, "left margin for matches at the beginning of a circular genome (overrides match-left-margin in the config)")
+
+	must(fragmentListCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
+	must(sequenceListCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
+	featureListCmd.ValidArgsFunction = completeArgs(repp.FeatureNames, 1)
+	enzymeListCmd.ValidArgsFunction = completeArgs(repp.EnzymeNames, 0)
 
 	listCmd.AddCommand(databaseListCmd)
 	listCmd.AddCommand(featureListCmd)
@@ -137,7 +145,7 @@ func runFragmentListCmd(cmd *cobra.Command, args []string) {
 	name := args[0]
 	dbNames := extractDbNames(cmd)
 
-	repp.PrintFragment(name, dbNames)
+	repp.PrintFragment(name, dbNames, config.New().Strict)
 }
 
 func runSequenceListCmd(cmd *cobra.Command, args []string) {
@@ -154,5 +162,5 @@ func runSequenceListCmd(cmd *cobra.Command, args []string) {
 	leftMargin := extractLeftMargin(cmd, 100)
 	dbNames := extractDbNames(cmd)
 
-	repp.SequenceList(seq, filters, identity, ungapped, leftMargin, dbNames)
+	repp.SequenceList(seq, filters, identity, ungapped, leftMargin, dbNames, config.New().Strict)
 }
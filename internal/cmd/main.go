@@ -4,10 +4,12 @@ import (
 	_ "embed"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/Lattice-Automation/repp/internal/config"
 	"github.com/Lattice-Automation/repp/internal/repp"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 const releaseNumber = "1.2.2"
@@ -20,19 +22,94 @@ var RootCmd = &cobra.Command{
 	Use:   "repp",
 	Short: `repository-based plasmid design. Build cost-efficient plasmids`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		if cmd.Flag("verbose").Value.String() == "true" {
+		verbose := cmd.Flag("verbose").Value.String() == "true"
+		quiet := cmd.Flag("quiet").Value.String() == "true"
+		if verbose && quiet {
+			log.Fatal("--verbose and --quiet can't be used together")
+		}
+		if verbose {
 			repp.SetVerboseLogging()
 		}
+		if quiet {
+			repp.SetQuietLogging()
+		}
 		reppDataDir := cmd.Flag("repp-data-dir").Value.String()
 
 		config.Setup(reppDataDir)
+		repp.SetBuildInfo(releaseNumber, commit)
+		conf := config.New()
+
+		if cmd.Flag("offline").Value.String() == "true" || conf.Offline {
+			repp.SetOffline()
+		}
+
+		maxCPUs, _ := cmd.Flags().GetInt("max-cpus")
+		if maxCPUs <= 0 {
+			maxCPUs = conf.MaxCPUs
+		}
+		repp.SetMaxCPUs(maxCPUs)
+
+		maxRAM, _ := cmd.Flags().GetString("max-ram")
+		if maxRAM == "" {
+			maxRAM = conf.MaxRAM
+		}
+		if err := repp.SetMaxRAM(maxRAM); err != nil {
+			log.Fatal(err)
+		}
+
+		maxSubprocesses, _ := cmd.Flags().GetInt("max-subprocesses")
+		if maxSubprocesses <= 0 {
+			maxSubprocesses = conf.MaxSubprocesses
+		}
+		repp.SetMaxSubprocesses(maxSubprocesses)
+
+		// the notification hooks are only flags of the make commands
+		if notifyURL, notifyCmd := cmd.Flag("notify-url"), cmd.Flag("notify-cmd"); notifyURL != nil && notifyCmd != nil {
+			if notifyURL.Value.String() != "" && repp.IsOffline() {
+				log.Fatal("--notify-url can't be used offline")
+			}
+			repp.SetNotify(notifyURL.Value.String(), notifyCmd.Value.String())
+		}
+
+		// only design runs are recorded, if the config opts in
+		if cmd.HasParent() && cmd.Parent().Name() == "make" && conf.Metrics {
+			repp.SetMetrics(cmd.Parent().Name() + " " + cmd.Name())
+		}
+		if cmd.HasParent() && cmd.Parent().Name() == "make" && conf.History {
+			repp.SetHistory(cmd.Parent().Name()+" "+cmd.Name(), os.Args[1:], historyInputs(cmd))
+		}
 	},
 	Version: fmt.Sprintf("%s (%.11s)", releaseNumber, commit),
 }
 
 func init() {
 	RootCmd.PersistentFlags().BoolP("verbose", "v", false, "write DEBUG logs")
+	RootCmd.PersistentFlags().BoolP("quiet", "q", false, "only write errors and the output file's path")
 	RootCmd.PersistentFlags().String("repp-data-dir", "", "Default REPP data directory")
+	RootCmd.PersistentFlags().Bool("offline", false, "disable all network access (or set offline in the config)")
+	RootCmd.PersistentFlags().Bool("strict", false, "fail on data inconsistencies that are otherwise tolerated, ex: duplicate database IDs (or set strict in the config)")
+	RootCmd.PersistentFlags().Int("max-cpus", 0, "most CPUs a run uses, for BLAST threads and subprocesses (overrides max-cpus in the config, all if 0)")
+	RootCmd.PersistentFlags().String("max-ram", "", "memory limit of a run, ex: 4GB, bounding the assembly search (overrides max-ram in the config)")
+	RootCmd.PersistentFlags().Int("max-subprocesses", 0, "most external commands, ex: BLAST and primer3, run at once (overrides max-subprocesses in the config, the number of CPUs if 0)")
+	must(viper.BindPFlag("strict", RootCmd.PersistentFlags().Lookup("strict")))
+}
+
+// historyInputs returns the paths of the files a make command reads, for the history. Those
+// that aren't files, ex: a backbone that's a database entry, are left out when it's written
+func historyInputs(cmd *cobra.Command) (inputs []string) {
+	for _, name := range []string{"in", "backbone", "reuse-from", "diff-against", "primers-databases", "synth-frags-databases", "identity-regions"} {
+		if flag := cmd.Flag(name); flag != nil && flag.Value.String() != "" {
+			inputs = append(inputs, splitStringOn(flag.Value.String(), []rune{','})...)
+		}
+	}
+	return inputs
+}
+
+// warnf logs a warning about the command line, unless in quiet mode
+func warnf(format string, v ...interface{}) {
+	if !repp.IsQuietLogging() {
+		log.Printf(format, v...)
+	}
 }
 
 func must(err error) {
@@ -18,6 +18,9 @@ in one of the dbs or a file on the local filesystem.`
 	enzymeHelp = `comma separated list of enzymes to linearize the backbone with.
 The backbone must be specified. 'repp ls enzymes' prints a list of
 recognized enzymes.`
+
+	filterHelp = `expression of the database entries to keep, ex: "db!=igem AND length>3000 AND NOT title~'mutant'".
+Fields are title, entry, db, length, year and circular; ~ is "contains".`
 )
 
 // makeCmd is for finding building a plasmid from its fragments, features, or sequence
@@ -26,7 +29,47 @@ var makeCmd = &cobra.Command{
 	Short:                      "Make a plasmid from its expected sequence, features, or fragments",
 	SuggestionsMinimumDistance: 3,
 	Long: `Find fragments for assembling a plasmid via Gibson Assembly. Build the plasmid
-against a list of consituent fragment, feature, or a target sequence.`,
+against a list of consituent fragment, feature, or a target sequence.
+
+With --notify-url, a summary of the run is POSTed as JSON when it finishes or
+fails: its status, error, target, output file, solution count, and the
+fragment count and cost of the first solution. With --notify-cmd, the command
+is run by the shell with the summary on stdin, and REPP_STATUS and REPP_OUTPUT
+in its environment. --notify-url can't be used with --offline, which blocks all
+of repp's network access.
+
+Each run is recorded in the local history with its command line, inputs and
+output, unless history is false in the config. See 'repp history'.
+
+Fragments are named with the fragment-id-template setting or --frag-id-template,
+ex: "{project}_{target}_{index}_{type}". IDs that collide with the synthetic
+fragment manifest or another fragment of the solution get a numeric suffix.
+
+Where a template has a universal primer's binding site (M13F, M13R, T7 or SP6
+by default, from the universal-primers setting) where a designed primer could
+go, and no 5' tail is needed there, the stock primer is used instead. It's
+noted on the primer, listed by its name in the reagents rather than ordered,
+and logged per solution.
+
+Template IDs and reagent names in the strategy, reagents, pick list and order
+files are renamed by the naming-map setting, ex: "gnl|addgene|107006" to the
+lab's pAB107006, so they match the identifiers used at the bench. Each name is
+renamed by the first regular expression that matches all of it. The JSON
+output keeps the database IDs.
+
+Fixed 5' sequences can be added to the primers of selected PCR fragments after
+they're designed, ex: BsaI sites and spacers for a later Golden Gate assembly
+or sequencing adapters, with the primer-additions setting or --primer-additions
+FWD,REV for all PCR fragments. The extended primers are re-screened for
+hairpins, dimers and off-targets, and issues are reported as warnings.
+
+Outputs are written to temporary files and renamed into place once complete,
+so a failed run never leaves a partial output. The strategy and reagents CSVs
+end with a "# complete, sha256: <hex>" line, the SHA-256 of everything before it.
+
+PCR templates with 50bp windows over 72% or under 25% GC, repeats over 30bp, or
+hairpins where their primers bind are listed in the solutions' warnings. Set
+pcr-template-penalty in the config to rank solutions with them lower.`,
 	Aliases: []string{"assemble", "build"},
 }
 
@@ -38,7 +81,16 @@ var fragmentsCmd = &cobra.Command{
 	SuggestionsMinimumDistance: 3,
 	Long: `Prepare a list of fragments for assembly via Gibson Assembly. Fragments are
 checked for existing homology with their neighbors and are prepared for
-assembly with PCR.`,
+assembly with PCR.
+
+The fragments are assembled in the order of the input file. With --infer-order
+they're ordered and oriented by the homology between their ends instead, and
+it's an error if more than one order joins them, or if their ends join too
+many ways to search.
+
+Fragments named with --synthetic will be synthesized rather than amplified
+from a template. They're extended with their neighbors' ends where they lack
+homology, so the sequence to synthesize already includes the junctions.`,
 }
 
 // featuresCmd is for building a plasmid from its list of contained features
@@ -47,8 +99,25 @@ var featuresCmd = &cobra.Command{
 	Short:                      "Find or build a plasmid from its constituent features",
 	Run:                        runFeaturesCmd,
 	SuggestionsMinimumDistance: 3,
-	Example:                    `repp make features "BBa_R0062,BBa_B0034,BBa_C0040,BBa_B0010,BBa_B0012" --backbone pSB1C3 --enzymes "EcoRI,PstI" --dbs igem`,
-	Args:                       cobra.MinimumNArgs(1),
+	Long: `Build a plasmid from its features, in order. Features are passed by name, found
+in the features database or the sequence databases, and reversed with ":rev",
+ex: "p10 promoter,mEGFP:rev". They can also be read from a FASTA or GenBank
+file, where features annotated with complement() keep their orientation, or from
+a CSV with a header naming its "name", "sequence" and "orientation" (fwd or rev)
+columns. Rows without a sequence are found by name.
+
+Each solution lists how its features are made, in the "features" of the JSON
+output: the type of the fragment each one is in and, unless it's synthesized,
+the template it's PCR'ed from with its %-identity and coordinates there. The
+solution's "featureCoverage" is the fraction of the features' bp that come
+from templates rather than synthesis.
+
+Features with a type in the features database, see 'repp add feature --type',
+are checked to make transcription units on each strand. A warning is logged for
+a CDS without a promoter upstream of it, an RBS that isn't followed by a CDS, or
+a terminator without a CDS upstream of it.`,
+	Example: `repp make features "BBa_R0062,BBa_B0034,BBa_C0040,BBa_B0010,BBa_B0012" --backbone pSB1C3 --enzymes "EcoRI,PstI" --dbs igem`,
+	Args:    cobra.MinimumNArgs(1),
 }
 
 // sequenceCmd is for assembling a plasmid (single circular sequence) from its target sequence
@@ -60,63 +129,244 @@ var sequenceCmd = &cobra.Command{
 	Long: `Build up a plasmid from its target sequence using a combination of existing and
 synthesized fragments.
 
-Solutions have either a minimum fragment count or assembly cost (or both).`,
+Solutions have either a minimum fragment count or assembly cost (or both).
+
+With --synth-only the databases aren't searched. The target is split into the
+fewest synthetic fragments within the synthetic-max-length setting, with each
+junction moved to where its homology is unique, closest to 50% GC, and free of
+hairpins.
+
+Solutions on the pareto front of fragment count, synthetic fragment count and
+cost, where no other solution is at least as good in all three, are flagged in
+the output and charted in the logs. With --pareto, the best assembly of each
+fragment and synthetic fragment count is filled, and one solution is kept per
+point of the front of their filled costs. It can't be combined with
+--max-kept-solutions.
+
+Each solution has a predicted success score, from 0 to 1, next to its cost:
+a heuristic for how likely it is to assemble the first time given its
+fragment count, fragment lengths, junction Tm and GC%, primer penalties,
+off-targets near the max Tm, synthesis constraints and long-range PCRs.
+The weights of each are in the success-weights setting, they aren't fit to
+bench outcomes.
+
+--filter keeps only the database entries an expression is true for, ex:
+"db!=igem AND length>3000 AND NOT title~'mutant'". Entries are compared by
+title, entry, db, length, year (the first in the title) and circular with
+=, != and, for text, ~ (contains) and !~, or, for numbers, >, >=, < and <=.
+Comparisons combine with AND, OR, NOT and parentheses. Text comparisons
+ignore case, and quotes are needed around values with spaces.
+
+Synthetic fragments that are in a --synth-frags-databases manifest are reused
+by their ID. Those contained in a larger fragment of the manifest are PCR'ed
+out of it instead, with primers that trim it, if that costs less than
+synthesizing them again.
+
+Manifests may also list, after the plate and well, the date each oligo was
+ordered, whether it was validated (y/n) and where it's stored. Validated
+primers are reused before unvalidated copies, old unvalidated primers are
+warned about, and locations are written to the pick list and reagents.
+
+Primers and synthetic fragments manifests can also be xlsx workbooks. Their
+sheet and the columns of the IDs, sequences, plates, wells and inventory are
+set by the oligos-xlsx-sheet and oligos-xlsx-columns settings. With --out-fmt XLSX the
+strategy, reagents, junctions and pick list are written as sheets of one workbook.
+
+The junctions of each solution, the homology between each fragment and the next,
+with its length, Tm and coordinates on the target, are in the JSON output and,
+with --out-fmt CSV, the '-junctions.csv' file.
+
+With --out-fmt TSV-KV the solutions are written as a flat key-value TSV for
+LIMS ingestion: one row per solution, fragment and attribute, with stable
+attribute names. The solution's own attributes are in the rows of fragment 0.
+
+With --out-fmt JSON-V1 the JSON output also has the fields of the outputs of
+repp before the schema version was added (v0.x), ex: the URL of each fragment's
+Addgene, iGEM or DNASU entry, for readers that haven't moved to the current fields.
+
+With --reuse-from, a re-design of a changed target reuses the fragments and
+primers of a previous JSON output wherever they're still valid. Among equally
+good assemblies, those with the previous plan's templates are filled first, and
+the previous primers are used if they're still in the target and template and
+pass the off-target checks. New primers are designed for the rest.
+
+With --diff-against, only the region of the target that changed since a
+previous design of the old target is BLASTed, with 500bp on either side. The
+matches of the rest are reused from the old target's cached BLAST output. The
+whole target is BLASTed if the old target's output isn't cached or most of the
+target changed. It only limits BLAST: assemblies are still built and primers
+designed across the whole target. Combine it with --reuse-from to also reuse
+the previous plan's fragments and primers outside the changed region.
+
+With --landing-pads, fragments may only join at the named features or
+sequences, ex: the recombination sites or standard overhangs shared by the
+backbone and insert. Solutions with a junction elsewhere are discarded. Each
+landing pad must be in the target, on either strand.
+
+With --from-genbank-features, features of a GenBank target constrain where
+their sequence comes from. A feature with a /repp_synthesize qualifier is
+synthesized, and one with /repp_source="db1,db2" is PCR'ed from a template in
+one of those databases, which must be among those searched. Matches from other
+databases are trimmed off these features, and the rest of the target is
+designed as usual, from any database or by synthesis.
+
+With --synthesize, ranges of the target must be synthesized, ex: codon-optimized
+variants that no template should be PCR'ed for, however similar. Ranges are
+1-based and inclusive, like "120-480", and one that ends before it starts
+crosses the zero-index of a circular target. They combine with the
+/repp_synthesize features of --from-genbank-features.
+
+Targets are checked before they're designed. Warnings are logged for targets
+shorter or longer than the target-min-length and target-max-length settings,
+that are the same sequence repeated, or, if linear and CDS-like, that lack a
+start or stop codon or have an in-frame stop codon. Stretches of the target
+in a sequence of the target-contaminants-db FASTA, the built-in sequencing
+adapters by default or NCBI's UniVec, are also reported.
+
+Some tools export circular sequences doubled, and a pasted sequence may be a
+dimer. With --monomer, a target that's two or more tandem copies of a smaller
+sequence is designed as one copy, and only its features in the first copy are
+honored with --from-genbank-features.
+
+Before any output is written, each solution's fragments are joined at their
+junctions and checked against the target, including the backbone. Solutions
+that don't reconstruct the target, ex: from a fragment with shifted
+coordinates, are reported with a warning. With --self-check, repp fails
+instead, so the DNA isn't ordered.`,
 	Aliases: []string{"seq", "plasmid"},
 	Example: `repp make sequence -i "./target_plasmid.fa --dbs addgene`,
 }
 
+// ligationCmd is for cloning an insert into a backbone by ligation instead of Gibson Assembly
+var ligationCmd = &cobra.Command{
+	Use:                        "ligation",
+	Short:                      "Clone an insert into a backbone by sticky end, blunt end or TOPO ligation",
+	Run:                        runLigationCmd,
+	SuggestionsMinimumDistance: 3,
+	Long: `Plan a single fragment ligation of an insert into a backbone. The insert is
+prepared by PCR, with primers carrying the restriction sites of the digested
+backbone (sticky), no additions (blunt), or the CACC overhang of directional
+TOPO vectors (topo). No homology arms are designed.
+
+Non-directional designs, where the insert may be ligated in either orientation,
+are reported with a warning.`,
+	Example: `repp make ligation -i insert.fa --backbone pSB1C3 --enzymes "EcoRI,PstI" --dbs igem`,
+}
+
 // set flags
 func init() {
 	// Flags for specifying the paths to the input file, input fragment files, and output file
 	fragmentsCmd.Flags().StringP("in", "i", "", "input file name (FASTA or Genbank)")
 	fragmentsCmd.Flags().StringP("out", "o", "", "output file name")
+	fragmentsCmd.Flags().String("track-fmt", "", "write a BED or GFF3 track of the fragments, primers and junctions per solution; valid values [BED, GFF]")
+	fragmentsCmd.Flags().Bool("emit-order-files", false, "write a Name,Sequence CSV of the new primers and a FASTA of the new synthetic fragments per solution, for ordering")
+	fragmentsCmd.Flags().Bool("emit-protocol", false, "write a Markdown bench protocol per solution: PCR, DpnI digestion of plasmid templates, cleanup, assembly and transformation")
+	fragmentsCmd.Flags().Bool("self-check", false, "fail before writing any output if a solution's fragments, joined at their junctions, don't reconstruct the target")
+	fragmentsCmd.Flags().Bool("redact", false, "also write the output with database entry IDs and lab-specific identifiers replaced by anonymous tokens, for sharing")
+	fragmentsCmd.Flags().String("primer-additions", "", "fixed 5' sequences added to the forward and reverse primers of all PCR fragments, as FWD,REV (overrides primer-additions in the config)")
 	fragmentsCmd.Flags().StringP("dbs", "d", "", "comma separated list of sequence databases by name")
 	fragmentsCmd.Flags().StringP("backbone", "b", "", backboneHelp)
 	fragmentsCmd.Flags().StringP("enzymes", "e", "", enzymeHelp)
 	fragmentsCmd.Flags().Int("synthetic-frag-factor", 0, "Penalty for synthetic fragments")
+	fragmentsCmd.Flags().Bool("infer-order", false, "infer the order and orientation of the fragments from their end homology")
+	fragmentsCmd.Flags().Int("chunk-size", 0, "also write the fragments, with their primers, in chunks of `n` to <out>.chunk<i>.json (<out>.solution<s>.chunk<i>.json with several solutions), ex: 96 for a plate per chunk")
+	fragmentsCmd.Flags().String("synthetic", "", "comma separated list of the IDs of fragments that will be synthesized rather than PCR amplified")
 	must(fragmentsCmd.MarkFlagRequired("in"))
+	must(fragmentsCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
+	must(fragmentsCmd.RegisterFlagCompletionFunc("enzymes", completeEnzymeList))
 
 	// Flags for specifying the paths to the input file, input fragment files, and output file
 	featuresCmd.Flags().StringP("out", "o", "", "output file name")
+	featuresCmd.Flags().String("track-fmt", "", "write a BED or GFF3 track of the fragments, primers and junctions per solution; valid values [BED, GFF]")
+	featuresCmd.Flags().Bool("emit-order-files", false, "write a Name,Sequence CSV of the new primers and a FASTA of the new synthetic fragments per solution, for ordering")
+	featuresCmd.Flags().Bool("emit-protocol", false, "write a Markdown bench protocol per solution: PCR, DpnI digestion of plasmid templates, cleanup, assembly and transformation")
+	featuresCmd.Flags().Bool("self-check", false, "fail before writing any output if a solution's fragments, joined at their junctions, don't reconstruct the target")
+	featuresCmd.Flags().Bool("redact", false, "also write the output with database entry IDs and lab-specific identifiers replaced by anonymous tokens, for sharing")
+	featuresCmd.Flags().String("primer-additions", "", "fixed 5' sequences added to the forward and reverse primers of all PCR fragments, as FWD,REV (overrides primer-additions in the config)")
 	featuresCmd.Flags().StringP("dbs", "d", "", "comma separated list of sequence databases by name")
 	featuresCmd.Flags().StringP("backbone", "b", "", backboneHelp)
 	featuresCmd.Flags().StringP("enzymes", "e", "", enzymeHelp)
 	featuresCmd.Flags().StringP("exclude", "x", "", "keywords for excluding fragments")
+	featuresCmd.Flags().String("filter", "", filterHelp)
 	featuresCmd.Flags().IntP("identity", "p", 100, "%-identity threshold (see 'blastn -help')")
 	featuresCmd.Flags().Bool("ungapped", false, "Ungapped alignment flag")
-	featuresCmd.Flags().Int("left-margin", 100, "left margin for matches of the beginning of a circular genome")
+	featuresCmd.Flags().Int("left-margin", 100, "left margin for matches of the beginning of a circular genome (overrides match-left-margin in the config)")
 	featuresCmd.Flags().Int("synthetic-frag-factor", 0, "Penalty for synthetic fragments")
 	featuresCmd.Flags().IntP("max-kept-solutions", "n", 1, "Top solutions to keep")
 	must(featuresCmd.MarkFlagRequired("out"))
+	must(featuresCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
+	must(featuresCmd.RegisterFlagCompletionFunc("enzymes", completeEnzymeList))
+	featuresCmd.ValidArgsFunction = completeFeatureList
 
 	// Flags for specifying the paths to the input file, input fragment files, and output file
 	sequenceCmd.Flags().StringP("in", "i", "", "input file name (FASTA or Genbank)")
 	sequenceCmd.Flags().StringP("out", "o", "", "output file name")
-	sequenceCmd.Flags().StringP("out-fmt", "f", "CSV", "output file format; valid values [JSON, CSV]")
+	sequenceCmd.Flags().StringP("out-fmt", "f", "CSV", "output file format; valid values [JSON, CSV, XLSX, TSV-KV, JSON-V1]")
+	sequenceCmd.Flags().String("track-fmt", "", "write a BED or GFF3 track of the fragments, primers and junctions per solution; valid values [BED, GFF]")
+	sequenceCmd.Flags().Bool("emit-order-files", false, "write a Name,Sequence CSV of the new primers and a FASTA of the new synthetic fragments per solution, for ordering")
+	sequenceCmd.Flags().Bool("emit-protocol", false, "write a Markdown bench protocol per solution: PCR, DpnI digestion of plasmid templates, cleanup, assembly and transformation")
+	sequenceCmd.Flags().Bool("self-check", false, "fail before writing any output if a solution's fragments, joined at their junctions, don't reconstruct the target")
+	sequenceCmd.Flags().Bool("redact", false, "also write the output with database entry IDs and lab-specific identifiers replaced by anonymous tokens, for sharing")
+	sequenceCmd.Flags().String("primer-additions", "", "fixed 5' sequences added to the forward and reverse primers of all PCR fragments, as FWD,REV (overrides primer-additions in the config)")
 	sequenceCmd.Flags().StringP("dbs", "d", "", "list of sequence databases by name")
 	sequenceCmd.Flags().StringP("backbone", "b", "", backboneHelp)
 	sequenceCmd.Flags().StringP("enzymes", "e", "", enzymeHelp)
 	sequenceCmd.Flags().StringP("exclude", "x", "", "keywords for excluding fragments")
+	sequenceCmd.Flags().String("filter", "", filterHelp)
 	sequenceCmd.Flags().IntP("identity", "p", 100, "%-identity threshold (see 'blastn -help')")
 	sequenceCmd.Flags().Bool("ungapped", false, "Ungapped alignment flag")
-	sequenceCmd.Flags().Int("left-margin", 100, "left margin for matches of the beginning of a circular genome")
-	sequenceCmd.Flags().StringP("primers-databases", "m", "", "Comma separated list of CSV primers database files")
-	sequenceCmd.Flags().StringP("synth-frags-databases", "s", "", "Comma separated list of CSV synthetic fragments database files")
+	sequenceCmd.Flags().Int("left-margin", 100, "left margin for matches of the beginning of a circular genome (overrides match-left-margin in the config)")
+	sequenceCmd.Flags().String("topology", "auto", "target topology; valid values [auto, circular, linear]. auto uses the GenBank LOCUS line, defaulting to circular")
+	sequenceCmd.Flags().Bool("synth-only", false, "skip BLAST and split the target into synthetic fragments, ex: for novel sequences or a baseline cost")
+	sequenceCmd.Flags().StringP("primers-databases", "m", "", "Comma separated list of CSV or xlsx primers database files (id, sequence and optionally plate, well, ordered, validated, location)")
+	sequenceCmd.Flags().StringP("synth-frags-databases", "s", "", "Comma separated list of CSV or xlsx synthetic fragments database files")
 	sequenceCmd.Flags().Int("synthetic-frag-factor", 0, "Penalty for synthetic fragments")
 	sequenceCmd.Flags().IntP("max-kept-solutions", "n", 1, "Top solutions to keep")
+	sequenceCmd.Flags().Bool("pareto", false, "keep one solution per point of the pareto front of fragment count, synthetic fragment count and cost, instead of the top --max-kept-solutions")
+	sequenceCmd.Flags().String("reuse-from", "", "previous JSON output whose fragments and primers to reuse where they're still valid")
+	sequenceCmd.Flags().String("diff-against", "", "previous target (FASTA or Genbank) to BLAST only the changed region of the target against, see --reuse-from to reuse its plan")
+	sequenceCmd.Flags().String("landing-pads", "", "comma separated feature names or sequences of the only sites fragments may join at, ex: recombination sites")
+	sequenceCmd.Flags().Bool("from-genbank-features", false, "honor the /repp_synthesize and /repp_source=\"db1,db2\" qualifiers of the GenBank target's features")
+	sequenceCmd.Flags().Bool("monomer", false, "design one copy of a target that's a tandem repeat of a smaller sequence, ex: a plasmid exported or pasted twice")
+	sequenceCmd.Flags().String("synthesize", "", "comma separated 1-based ranges of the target that must be synthesized, never PCR'ed from a template, ex: \"120-480,900-1020\"")
+	sequenceCmd.Flags().String("identity-regions", "", "BED-like `file` of regions of the target with a higher %-identity threshold than --identity, as target, start, end, %-identity and an optional name per line, ex: \"target 120 840 100 GFP\"")
+	sequenceCmd.Flags().String("dump-matches", "", "write the BLAST matches considered to `prefix`.raw.tsv, with why each was kept or culled, and those left after culling to prefix.culled.tsv")
 
 	must(sequenceCmd.MarkFlagRequired("in"))
+	must(sequenceCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
+	must(sequenceCmd.RegisterFlagCompletionFunc("enzymes", completeEnzymeList))
+
+	ligationCmd.Flags().StringP("in", "i", "", "input file name with the insert (FASTA or Genbank)")
+	ligationCmd.Flags().StringP("out", "o", "", "output file name")
+	ligationCmd.Flags().StringP("out-fmt", "f", "CSV", "output file format; valid values [JSON, CSV, XLSX, TSV-KV, JSON-V1]")
+	ligationCmd.Flags().StringP("dbs", "d", "", "list of sequence databases by name")
+	ligationCmd.Flags().StringP("backbone", "b", "", backboneHelp)
+	ligationCmd.Flags().StringP("enzymes", "e", "", enzymeHelp)
+	ligationCmd.Flags().String("method", "sticky", "ligation method; valid values [sticky, blunt, topo]")
+	ligationCmd.Flags().StringP("primers-databases", "m", "", "Comma separated list of CSV or xlsx primers database files")
+	ligationCmd.Flags().StringP("synth-frags-databases", "s", "", "Comma separated list of CSV or xlsx synthetic fragments database files")
+	must(ligationCmd.MarkFlagRequired("in"))
+	must(ligationCmd.MarkFlagRequired("backbone"))
+	must(ligationCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
+	must(ligationCmd.RegisterFlagCompletionFunc("enzymes", completeEnzymeList))
 
 	makeCmd.AddCommand(fragmentsCmd)
 	makeCmd.AddCommand(featuresCmd)
 	makeCmd.AddCommand(sequenceCmd)
+	makeCmd.AddCommand(ligationCmd)
 
 	// config is an optional parameter for a settings file (that overrides defaults)
 	makeCmd.PersistentFlags().StringP("config", "c", "", "User defined config file that may override all or some default settings")
 	makeCmd.PersistentFlags().String("primer3-config", "", "primer3 config folder to be used instead of the default")
+	makeCmd.PersistentFlags().String("notify-url", "", "URL to POST the run summary JSON to when the run finishes or fails")
+	makeCmd.PersistentFlags().String("notify-cmd", "", "shell command to run with the run summary JSON on stdin when the run finishes or fails")
+	makeCmd.PersistentFlags().String("frag-id-template", "", "naming scheme of the fragments in the strategy, ex: \"{project}_{target}_{index}_{type}\" (overrides fragment-id-template in the config)")
+	makeCmd.PersistentFlags().String("project", "", "project name of the {project} placeholder of the fragment ID template")
 	if err := viper.BindPFlag("config", makeCmd.PersistentFlags().Lookup("config")); err != nil {
 		log.Fatal(err)
 	}
+	must(viper.BindPFlag("fragment-id-template", makeCmd.PersistentFlags().Lookup("frag-id-template")))
+	must(viper.BindPFlag("project", makeCmd.PersistentFlags().Lookup("project")))
 
 	RootCmd.AddCommand(makeCmd)
 }
@@ -130,12 +380,13 @@ func runFragmentsCmd(cmd *cobra.Command, args []string) {
 
 	syntheticFragmentFactor, err := cmd.Flags().GetInt("synthetic-frag-factor")
 	if err != nil {
-		log.Printf("Error trying to extract synthetic fragment penalty factor: %v\n", err)
+		warnf("Error trying to extract synthetic fragment penalty factor: %v\n", err)
 		syntheticFragmentFactor = 0
 	}
 
 	config := config.New().SetPrimer3ConfigDir(cmd.Flag("primer3-config").Value.String())
 	config.SetSyntheticFragmentFactor(syntheticFragmentFactor)
+	config.SetPrimerAdditions(extractPrimerAdditions(cmd))
 
 	repp.AssembleFragments(fragmentsInputParams, config)
 }
@@ -149,17 +400,18 @@ func runFeaturesCmd(cmd *cobra.Command, args []string) {
 
 	syntheticFragmentFactor, err := cmd.Flags().GetInt("synthetic-frag-factor")
 	if err != nil {
-		log.Printf("Error trying to extract synthetic fragment penalty factor: %v\n", err)
+		warnf("Error trying to extract synthetic fragment penalty factor: %v\n", err)
 		syntheticFragmentFactor = 0
 	}
 	maxKeptSolutions, err := cmd.Flags().GetInt("max-kept-solutions")
 	if err != nil {
-		log.Printf("Error trying to extract synthetic maximum solutions to keep: %v\n", err)
+		warnf("Error trying to extract synthetic maximum solutions to keep: %v\n", err)
 		maxKeptSolutions = 1
 	}
 
 	config := config.New().SetPrimer3ConfigDir(cmd.Flag("primer3-config").Value.String())
 	config.SetSyntheticFragmentFactor(syntheticFragmentFactor)
+	config.SetPrimerAdditions(extractPrimerAdditions(cmd))
 
 	repp.Features(featuresInputParams, maxKeptSolutions, config)
 }
@@ -183,16 +435,32 @@ func runSequenceCmd(cmd *cobra.Command, args []string) {
 
 	syntheticFragmentFactor, err := cmd.Flags().GetInt("synthetic-frag-factor")
 	if err != nil {
-		log.Printf("Error trying to extract synthetic fragment penalty factor: %v\n", err)
+		warnf("Error trying to extract synthetic fragment penalty factor: %v\n", err)
 		syntheticFragmentFactor = 0
 	}
 	maxKeptSolutions, err := cmd.Flags().GetInt("max-kept-solutions")
 	if err != nil {
-		log.Printf("Error trying to extract synthetic maximum solutions to keep: %v\n", err)
+		warnf("Error trying to extract synthetic maximum solutions to keep: %v\n", err)
 		maxKeptSolutions = 1
 	}
 
 	config := config.New().SetPrimer3ConfigDir(cmd.Flag("primer3-config").Value.String())
 	config.SetSyntheticFragmentFactor(syntheticFragmentFactor)
+	config.SetPrimerAdditions(extractPrimerAdditions(cmd))
 	repp.Sequence(assemblyInputParams, maxKeptSolutions, config)
 }
+
+func runLigationCmd(cmd *cobra.Command, args []string) {
+	ligationInputParams := parseFragmentsAssemblyParams(cmd, args, true)
+
+	if ligationInputParams.GetOut() == "" {
+		ligationInputParams.SetOut(guessOutput(ligationInputParams.GetIn(), ligationInputParams.GetOutputFormat()))
+	} else {
+		ligationInputParams.SetOut(adjustOutput(ligationInputParams.GetOut(), ligationInputParams.GetOutputFormat()))
+	}
+
+	method, _ := cmd.Flags().GetString("method")
+
+	config := config.New().SetPrimer3ConfigDir(cmd.Flag("primer3-config").Value.String())
+	repp.Ligate(ligationInputParams, method, config)
+}
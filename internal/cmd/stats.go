@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd is for summarizing the things that repp uses to build plasmids
+var statsCmd = &cobra.Command{
+	Use:                        "stats",
+	Short:                      "Print statistics of a sequence database or past runs",
+	SuggestionsMinimumDistance: 2,
+	Long:                       "Print statistics of the things that repp uses to build plasmids, and of its runs.",
+}
+
+// databaseStatsCmd is for summarizing the sequences of a database
+var databaseStatsCmd = &cobra.Command{
+	Use:                        "database [name]",
+	Short:                      "Print statistics of a sequence database",
+	Run:                        runDatabaseStatsCmd,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp stats database igem",
+	Long: `Print statistics of a sequence database: its number of sequences, circular
+and linear sequences, and duplicate sequences, the distributions of its sequences'
+lengths and GC content, and when it was last rebuilt.
+
+The statistics are computed when the database is added. Databases added before
+then have them computed from their sequence file.`,
+	Aliases: []string{"db"},
+	Args:    cobra.ExactArgs(1),
+}
+
+// runsStatsCmd is for summarizing the runs in the local metrics log
+var runsStatsCmd = &cobra.Command{
+	Use:                        "runs",
+	Short:                      "Print statistics of the design runs in the local metrics log",
+	Run:                        runRunsStatsCmd,
+	SuggestionsMinimumDistance: 2,
+	Example:                    "  repp stats runs",
+	Long: `Print statistics of the design runs in the local metrics log: how many
+succeeded, the stages the others failed at (input, search, assembly or
+output), the commands run and the distributions of the targets' lengths, the
+databases searched, the solutions' fragments and the runs' wall times.
+
+Runs are only recorded if metrics is true in the config, or REPP_METRICS=true.
+Records have no sequences, names or paths, and stay in metrics.jsonl of the
+repp data directory unless exported with --json.`,
+	Args: cobra.NoArgs,
+}
+
+// set flags
+func init() {
+	runsStatsCmd.Flags().Bool("json", false, "print the run records as JSON, to export them")
+
+	databaseStatsCmd.ValidArgsFunction = completeArgs(repp.DatabaseNames, 1)
+
+	statsCmd.AddCommand(databaseStatsCmd)
+	statsCmd.AddCommand(runsStatsCmd)
+
+	RootCmd.AddCommand(statsCmd)
+}
+
+func runDatabaseStatsCmd(cmd *cobra.Command, args []string) {
+	repp.DatabaseStats(args[0])
+}
+
+func runRunsStatsCmd(cmd *cobra.Command, args []string) {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	repp.RunStats(asJSON)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// suggestCmd is for finding what can be built from the sequence databases without synthesis.
+var suggestCmd = &cobra.Command{
+	Use:                        "suggest",
+	Run:                        runSuggestCmd,
+	Short:                      "Suggest what can be built from sequence databases without synthesis",
+	SuggestionsMinimumDistance: 3,
+	Long: `Suggest what can be built from the sequence databases alone, ex: a lab's
+freezer, without a target. The features of the feature database are searched
+for, on both strands, in every entry of the databases. Each one that's found
+can be PCR'ed from the listed entries, so any combination of them can be built
+without synthesis, ex: with 'repp make features', as Gibson Assembly puts the
+junctions' homology in the primers' tails.
+
+Modules, runs of features no more than 200bp apart in an entry, are PCR'ed as
+one fragment. The most common are listed in the syntax of 'repp make features',
+with ":rev" for features on the bottom strand.`,
+	Example: `repp suggest --dbs freezer`,
+}
+
+// set flags
+func init() {
+	suggestCmd.Flags().StringP("dbs", "d", "", "list of sequence databases by name")
+	suggestCmd.Flags().Int("min-length", 20, "shortest feature to search for; shorter ones are simply added by primer tails")
+	suggestCmd.Flags().Int("modules", 20, "most modules to list, all if negative")
+
+	must(suggestCmd.MarkFlagRequired("dbs"))
+	must(suggestCmd.RegisterFlagCompletionFunc("dbs", completeDBList))
+
+	RootCmd.AddCommand(suggestCmd)
+}
+
+func runSuggestCmd(cmd *cobra.Command, args []string) {
+	minLength, _ := cmd.Flags().GetInt("min-length")
+	modules, _ := cmd.Flags().GetInt("modules")
+	repp.Suggest(extractDbNames(cmd), minLength, modules)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// verifyOutputCmd is for checking an output's templates against the current databases.
+var verifyOutputCmd = &cobra.Command{
+	Use:                        "verify-output [output]",
+	Run:                        runVerifyOutputCmd,
+	Short:                      "Check whether the templates used by a repp output changed",
+	SuggestionsMinimumDistance: 3,
+	Args:                       cobra.ExactArgs(1),
+	Long: `Check whether the database entries used by a repp output's solutions changed
+sequence or were removed since the output was generated.
+
+Each database keeps the sequence checksums of its entries across rebuilds, and
+tombstones for the entries that were removed. Outputs record the version of
+each entry their solutions use. The status of each entry is printed, and the
+command fails if any changed or were removed. For CSV outputs, pass the
+'-metadata.json' file written next to the strategy and reagents files.`,
+	Example: `repp verify-output ./target_plasmid.output.json`,
+}
+
+// verifyCmd is for checking the sequencing of a build against its plan.
+var verifyCmd = &cobra.Command{
+	Use:                        "verify",
+	Run:                        runVerifyCmd,
+	Short:                      "Verify a build against its plan with Sanger reads or a plasmid consensus",
+	SuggestionsMinimumDistance: 3,
+	Long: `Verify a build against its plan with Sanger reads or a plasmid consensus.
+
+The reads in --traces, .ab1 traces or FASTA files, are aligned against the
+expected sequence of the JSON output's solutions. The ends of .ab1 traces are
+quality trimmed first. Reads that don't align are left out with a warning.
+
+--consensus is a FASTA of whole-plasmid consensus sequences, ex: from nanopore
+sequencing services. Each is rotated to the expected sequence's zero-index and
+strand, and aligned end to end against it.
+
+For each solution, the coverage of the expected sequence, the reads that span
+each of its junctions and the SNVs, insertions and deletions in the reads are
+printed. Each difference is flagged with the fragment and the region of the
+design it's in: a junction, a primer tail, a primer, a template or a synthetic
+fragment. A solution is verified if every junction is spanned by a read and
+there are no differences. The verification of each solution is written to the
+output, and the command fails if none were verified. Pass --solution to only
+verify the solution that was built.`,
+	Example: `repp verify --traces ./sanger --plan ./target_plasmid.output.json
+  repp verify --consensus ./consensus.fasta --plan ./target_plasmid.output.json`,
+}
+
+// set flags
+func init() {
+	verifyCmd.Flags().StringP("traces", "t", "", "directory of the build's Sanger reads (.ab1, .abi, .fasta, .fa or .seq)")
+	verifyCmd.Flags().StringP("consensus", "c", "", "FASTA of the build's whole-plasmid consensus sequences")
+	verifyCmd.Flags().StringP("plan", "p", "", "JSON output of the build's design")
+	verifyCmd.Flags().IntP("solution", "n", 0, "solution that was built, 1 is the first (default all)")
+
+	must(verifyCmd.MarkFlagRequired("plan"))
+	verifyCmd.MarkFlagsOneRequired("traces", "consensus")
+
+	RootCmd.AddCommand(verifyOutputCmd)
+	RootCmd.AddCommand(verifyCmd)
+}
+
+func runVerifyCmd(cmd *cobra.Command, args []string) {
+	traces, _ := cmd.Flags().GetString("traces")
+	consensus, _ := cmd.Flags().GetString("consensus")
+	plan, _ := cmd.Flags().GetString("plan")
+	solution, _ := cmd.Flags().GetInt("solution")
+	repp.VerifyBuild(traces, consensus, plan, solution)
+}
+
+func runVerifyOutputCmd(cmd *cobra.Command, args []string) {
+	repp.VerifyOutput(args[0])
+}
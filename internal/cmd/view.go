@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/Lattice-Automation/repp/internal/repp"
+	"github.com/spf13/cobra"
+)
+
+// viewCmd is for browsing the solutions of an output in the terminal.
+var viewCmd = &cobra.Command{
+	Use:                        "view [output]",
+	Run:                        runViewCmd,
+	Short:                      "Browse the solutions of a JSON output in the terminal",
+	SuggestionsMinimumDistance: 3,
+	Args:                       cobra.ExactArgs(1),
+	Long: `Browse the solutions of a JSON output in an interactive terminal UI.
+
+Solutions are listed with their fragment counts, costs and predicted success.
+Open a solution to see its fragments and expand them to see their primers, or
+switch to its junctions to see their length, Tm and GC%. The order files of the
+selected solution, its new primers as CSV and its new synthetic fragments as
+FASTA, are exported next to the output. Primers and synthetic fragments in the
+--primers-databases and --synth-frags-databases manifests aren't ordered again.`,
+	Example: `repp view ./target_plasmid.output.json`,
+}
+
+// set flags
+func init() {
+	viewCmd.Flags().StringP("primers-databases", "m", "", "Comma separated list of CSV or xlsx primers database files")
+	viewCmd.Flags().StringP("synth-frags-databases", "s", "", "Comma separated list of CSV or xlsx synthetic fragments database files")
+
+	RootCmd.AddCommand(viewCmd)
+}
+
+func runViewCmd(cmd *cobra.Command, args []string) {
+	repp.View(args[0], extractOligosDatabases(cmd, "primers-databases"), extractOligosDatabases(cmd, "synth-frags-databases"))
+}
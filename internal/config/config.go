@@ -18,10 +18,17 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// envPrefix is the prefix of the environment variables that override settings, ex: REPP_PCR_BP_COST
+const envPrefix = "REPP"
+
 var (
 	// reppDir is the root directory where repp settings and database files live
 	reppDir string
 
+	// quietLogging is whether informational messages, ex: about copying the default
+	// settings, are left out of the logs
+	quietLogging bool
+
 	// defaultConfigPath is the path to a local/default config file
 	defaultConfigPath string
 
@@ -35,11 +42,26 @@ var (
 	// EnzymeDB is the path to the enzymes file
 	EnzymeDB string
 
+	// ContaminantsDB is the path to the FASTA of the adapters that targets are screened for
+	ContaminantsDB string
+
 	// SeqDatabaseDir is the path to a directory of sequence databases.
 	SeqDatabaseDir string
 
 	// SeqDatabaseManifest is the path to the manifest file for the sequence databases.
 	SeqDatabaseManifest string
+
+	// CacheDir is the path to the directory of cached BLAST and primer3 results.
+	CacheDir string
+
+	// MetricsLog is the path to the local log of runs, written if metrics are on
+	MetricsLog string
+
+	// HistoryLog is the path to the local history of design runs, written if history is on
+	HistoryLog string
+
+	// RedactionMap is the path to the tokens of the identifiers in redacted outputs, kept locally
+	RedactionMap string
 )
 
 var (
@@ -56,6 +78,10 @@ var (
 	//go:embed features.json
 	embeddedFeaturesContent []byte
 
+	// embeddedContaminantsContent is the FASTA of sequencing adapters embedded with repp
+	//go:embed contaminants.fasta
+	embeddedContaminantsContent []byte
+
 	// embeddedPrimer3ThermodynamicParams is the FS of Primer3, needed to run primer3_core, etc
 	//go:embed primer3_config primer3_config/interpretations
 	embeddedPrimer3ThermodynamicParams embed.FS
@@ -72,6 +98,38 @@ type SynthCost struct {
 	Cost float64 `mapstructure:"cost"`
 }
 
+// NameMapping renames the names that match a regular expression, ex: template IDs
+type NameMapping struct {
+	// Pattern is the regular expression the names are matched against
+	Pattern string `mapstructure:"pattern"`
+
+	// Replacement of the matched names, with $1 style references to the pattern's groups
+	Replacement string `mapstructure:"replacement"`
+}
+
+// UniversalPrimer is a stock primer that labs keep on hand, ex: M13F
+type UniversalPrimer struct {
+	// name of the primer
+	Name string `mapstructure:"name"`
+
+	// Seq of the primer, 5' to 3'
+	Seq string `mapstructure:"seq"`
+}
+
+// PrimerAddition is a fixed sequence added to the 5' ends of the primers of selected PCR
+// fragments, ex: a BsaI site and spacer for a later Golden Gate assembly
+type PrimerAddition struct {
+	// Fragments is a regular expression of the template IDs of the PCR fragments whose
+	// primers get the additions. All PCR fragments if empty
+	Fragments string `mapstructure:"fragments"`
+
+	// Fwd is added to the 5' end of the forward primer
+	Fwd string `mapstructure:"fwd"`
+
+	// Rev is added to the 5' end of the reverse primer
+	Rev string `mapstructure:"rev"`
+}
+
 // Config is the Root-level settings struct and is a mix
 // of settings available in config.yaml and those
 // available from the command line
@@ -85,6 +143,27 @@ type Config struct {
 	// the cost of time for each Gibson Assembly
 	GibsonAssemblyTimeCost float64 `mapstructure:"gibson-assembly-time-cost"`
 
+	// the cost of each ligation reaction (sticky or blunt end)
+	LigationCost float64 `mapstructure:"ligation-cost"`
+
+	// the cost of each TOPO cloning reaction
+	TopoCloningCost float64 `mapstructure:"topo-cloning-cost"`
+
+	// the currency that solution costs are reported in
+	Currency string `mapstructure:"currency"`
+
+	// the exchange rate from the currency of the configured costs to the reported currency
+	CurrencyExchangeRate float64 `mapstructure:"currency-exchange-rate"`
+
+	// the shipping and handling cost of each order, by vendor
+	VendorShippingCosts map[string]float64 `mapstructure:"vendor-shipping-costs"`
+
+	// the multiplier applied to the purchases from a vendor, by vendor (eg 0.8 for a 20% discount)
+	VendorDiscounts map[string]float64 `mapstructure:"vendor-discounts"`
+
+	// the tax rate applied to purchases, including shipping
+	PurchaseTaxRate float64 `mapstructure:"purchase-tax-rate"`
+
 	// the cost per bp of synthesized DNA as a fragment (as a step function)
 	SyntheticFragmentCost map[int]SynthCost `mapstructure:"synthetic-fragment-cost"`
 
@@ -103,18 +182,110 @@ type Config struct {
 	// maximum allowable hairpin melting temperature (celcius)
 	FragmentsMaxHairpinMelt float64 `mapstructure:"fragments-max-junction-hairpin"`
 
+	// temperature of the assembly reaction (celcius) that junctions' hairpins are estimated at
+	AssemblyTemp float64 `mapstructure:"assembly-temp"`
+
+	// minimum %-identity of a fragment's template to the target in the final solutions. 0 doesn't enforce it
+	FragmentsMinIdentity float64 `mapstructure:"fragments-min-identity"`
+
+	// most fragments of a solution from one template. 0 doesn't enforce it
+	FragmentsMaxPerTemplate int `mapstructure:"fragments-max-per-template"`
+
+	// the shortest and longest targets designed without a warning
+	TargetMinLength int `mapstructure:"target-min-length"`
+	TargetMaxLength int `mapstructure:"target-max-length"`
+
+	// FASTA of the contaminants, ex: NCBI's UniVec, that targets and the ends of new database entries are screened for. The built-in adapters if empty
+	TargetContaminantsDB string `mapstructure:"target-contaminants-db"`
+
+	// the naming scheme of the fragments in the strategy, ex: "{project}_{target}_{index}_{type}"
+	FragmentIDTemplate string `mapstructure:"fragment-id-template"`
+
+	// the project name used by the {project} placeholder of the fragment ID template
+	Project string `mapstructure:"project"`
+
+	// what to do about reagent and fragment names that previous outputs in the output's
+	// directory use for something else (increment, error or ignore)
+	OutputNameCollisions string `mapstructure:"output-name-collisions"`
+
+	// renames of the template IDs and reagent names in the strategy, reagents and order files
+	NamingMap []NameMapping `mapstructure:"naming-map"`
+
+	// the sheet of the oligos in xlsx primer and synthetic fragment manifests, the first if empty
+	OligosXlsxSheet string `mapstructure:"oligos-xlsx-sheet"`
+
+	// the columns of the oligos' id, sequence, plate, well, date ordered, validation and location
+	// in xlsx manifests, as letters or header names
+	OligosXlsxColumns map[string]string `mapstructure:"oligos-xlsx-columns"`
+
+	// the weights of the success model's penalties, by factor. Unset factors use their defaults
+	SuccessWeights map[string]float64 `mapstructure:"success-weights"`
+
+	// the volumes, amounts and timings of the bench protocols, by parameter. Unset parameters use their defaults
+	Protocol map[string]float64 `mapstructure:"protocol"`
+
 	// the cost per bp of primer DNA
 	PcrBpCost float64 `mapstructure:"pcr-bp-cost"`
 
+	// the cost bonus for each primer of a solution that's already on a plate. 0, the default, for none
+	PrimerReuseBonus float64 `mapstructure:"primer-reuse-bonus"`
+
+	// the age, in days, past which reused primers that weren't validated are warned about. 0 to never warn
+	PrimerMaxUnvalidatedAgeDays int `mapstructure:"primer-max-unvalidated-age-days"`
+
+	// the stock primers that designed primers are swapped for where their binding sites allow
+	UniversalPrimers []UniversalPrimer `mapstructure:"universal-primers"`
+
+	// the fixed sequences added to the 5' ends of selected PCR fragments' primers after they're designed
+	PrimerAdditions []PrimerAddition `mapstructure:"primer-additions"`
+
 	// the cost of each PCR reaction
 	PcrRxnCost float64 `mapstructure:"pcr-rxn-cost"`
 
+	// the adjusted cost added to a PCR fragment for each issue of its template (extreme GC, long repeats)
+	PcrTemplatePenalty float64 `mapstructure:"pcr-template-penalty"`
+
 	// the cost of time for each PCR reaction
 	PcrTimeCost float64 `mapstructure:"pcr-time-cost"`
 
 	// PcrMinFragLength is the minimum size of a fragment (used to filter BLAST results)
 	PcrMinFragLength int `mapstructure:"pcr-min-length"`
 
+	// CullLimit is how many longer matches may engulf a match before it's culled
+	CullLimit int `mapstructure:"cull-limit"`
+
+	// CullMinLength is the length of the shortest match that isn't culled, pcr-min-length if 0
+	CullMinLength int `mapstructure:"cull-min-length"`
+
+	// CullGrouping is which matches may cull those they engulf: from the same database,
+	// the same template or any (database, template or none)
+	CullGrouping string `mapstructure:"cull-grouping"`
+
+	// MatchLeftMargin is the bp at the start of a circular target in which matches may be the
+	// end of a longer match across the zero-index. --left-margin overrides it. If it's unset
+	// each command's default is used
+	MatchLeftMargin *int `mapstructure:"match-left-margin"`
+
+	// MatchLeftMarginHandling is how the matches in the left margin are handled: merge or drop
+	MatchLeftMarginHandling string `mapstructure:"match-left-margin-handling"`
+
+	// PcrMaxFragLength is the maximum size of a standard PCR product. Longer products need
+	// long-range PCR. If 0 there's no limit
+	PcrMaxFragLength int `mapstructure:"pcr-max-length"`
+
+	// PcrLongRangeMaxFragLength is the maximum size of a long-range PCR product. Longer
+	// matches can't be amplified. If 0 long-range PCR isn't used
+	PcrLongRangeMaxFragLength int `mapstructure:"pcr-long-range-max-length"`
+
+	// the cost of each long-range PCR reaction
+	PcrLongRangeRxnCost float64 `mapstructure:"pcr-long-range-rxn-cost"`
+
+	// the cost of time for long-range PCR, on top of pcr-time-cost
+	PcrLongRangeTimeCost float64 `mapstructure:"pcr-long-range-time-cost"`
+
+	// the probability that a long-range PCR reaction succeeds, from 0 to 1
+	PcrLongRangeSuccessRate float64 `mapstructure:"pcr-long-range-success-rate"`
+
 	// the maximum primer3 score allowable
 	PcrPrimerMaxPairPenalty float64 `mapstructure:"pcr-primer-max-pair-penalty"`
 
@@ -144,9 +315,22 @@ type Config struct {
 	PcrPrimerMaxTm float64 `mapstructure:"pcr-primer-max-tm"`
 
 	// Max allowed difference in primer annealing temperatures (Tm)
+	// Primers further apart are resized at their 3' ends to balance them
 	// If <0 the difference is not checked
 	PcrMaxFwdRevPrimerTmDiff float64 `mapstructure:"pcr-max-fwd-rev-primer-tm-diff"`
 
+	// Width of the annealing temperature window shared by a solution's PCR fragments
+	// If 0 the PCR fragments aren't constrained to a shared window
+	PcrAnnealingWindow float64 `mapstructure:"pcr-annealing-window"`
+
+	// Whether to assign Golden Gate overhangs to the junctions of each solution
+	// and report the predicted ligation fidelity of the set
+	GoldenGateOverhangs bool `mapstructure:"golden-gate-overhangs"`
+
+	// Which qPCR assays, primer pairs and hydrolysis probes, to design to verify each
+	// solution: none, one per junction or one per construct
+	QPCRAssays string `mapstructure:"qpcr-assays"`
+
 	// Max homopolymer length allowed for primer design
 	PcrMaxHomopolymerLength int `mapstructure:"pcr-max-homopolymer-length"`
 
@@ -156,6 +340,10 @@ type Config struct {
 	// Flag to tell primer3 whether to pick a primer only if all constraints are met
 	PcrPrimerUseStrictConstraints bool `mapstructure:"pcr-use-strict-constraints"`
 
+	// keep primer3 from designing primers in stretches of the PCR template that repeat in the
+	// target or the template's parent sequence
+	PcrMaskTemplateRepeats bool `mapstructure:"pcr-mask-template-repeats"`
+
 	// minimum length of a synthesized piece of DNA
 	SyntheticMinLength int `mapstructure:"synthetic-min-length"`
 
@@ -165,9 +353,46 @@ type Config struct {
 	// configurable penalty for synthetic fragments
 	SyntheticFragmentFactor int `mapstructure:"synthetic-fragment-factor"`
 
+	// move the junctions of synthetic fragments out of unstable motifs, ex: poly-A tracts
+	SyntheticAvoidUnstable bool `mapstructure:"synthetic-avoid-unstable"`
+
 	// include fragment location in strategy output
 	IncludeFragLocationInStrategyOutput bool `mapstructure:"include-frag-location-in-strategy-output"`
 
+	// skip BLAST when exact matches against the databases already cover the target
+	ExactMatchFastPath bool `mapstructure:"exact-match-fast-path"`
+
+	// the maximum size of the BLAST and primer3 results cache in MB. 0 disables the cache
+	CacheMaxSizeMB int `mapstructure:"cache-max-size-mb"`
+
+	// the max size of each BLAST database volume (makeblastdb's -max_file_sz), ex: 1GB
+	BlastMaxFileSize string `mapstructure:"blast-max-file-size"`
+
+	// databases up to this size in KB are searched in-process rather than with BLAST. 0 always uses BLAST
+	NativeSearchMaxDBSizeKB int `mapstructure:"native-search-max-db-size-kb"`
+
+	// disable all network access, ex: the notification URL
+	Offline bool `mapstructure:"offline"`
+
+	// fail runs on data inconsistencies that are otherwise tolerated, ex: duplicate database IDs.
+	// The --strict flag sets it too
+	Strict bool `mapstructure:"strict"`
+
+	// the most external commands, ex: BLAST and primer3, run at once. The number of CPUs if 0
+	MaxSubprocesses int `mapstructure:"max-subprocesses"`
+
+	// the most CPUs a run uses, ex: on a shared server. All of them if 0
+	MaxCPUs int `mapstructure:"max-cpus"`
+
+	// the memory limit of a run, ex: 4GB. None if empty
+	MaxRAM string `mapstructure:"max-ram"`
+
+	// record anonymous statistics of each design run in the local metrics log
+	Metrics bool `mapstructure:"metrics"`
+
+	// record each design run, with its command line, inputs and output, in the local history
+	History bool `mapstructure:"history"`
+
 	// user provided path to primer3 config dir
 	p3ConfigDir string
 }
@@ -194,12 +419,29 @@ func initDataPaths(providedReppDir string) (err error) {
 	defaultPrimer3ConfigDir = filepath.Join(reppDir, "primer3_config") + string(os.PathSeparator)
 	FeatureDB = filepath.Join(reppDir, "features.json")
 	EnzymeDB = filepath.Join(reppDir, "enzymes.json")
+	ContaminantsDB = filepath.Join(reppDir, "contaminants.fasta")
 	SeqDatabaseDir = filepath.Join(reppDir, "dbs")
 	SeqDatabaseManifest = filepath.Join(SeqDatabaseDir, "manifest.json")
+	CacheDir = filepath.Join(reppDir, "cache")
+	MetricsLog = filepath.Join(reppDir, "metrics.jsonl")
+	HistoryLog = filepath.Join(reppDir, "history.jsonl")
+	RedactionMap = filepath.Join(reppDir, "redactions.csv")
 
 	return err
 }
 
+// SetQuietLogging only logs errors, not informational messages
+func SetQuietLogging() {
+	quietLogging = true
+}
+
+// infof logs an informational message, unless in quiet mode
+func infof(format string, args ...interface{}) {
+	if !quietLogging {
+		log.Printf(format, args...)
+	}
+}
+
 // Setup checks that the REPP data directory exists.
 // It creates one and writes default config files to it otherwise.
 func Setup(providedReppDir string) {
@@ -234,7 +476,7 @@ func Setup(providedReppDir string) {
 	// only copy default config file
 	// if it does not exist
 	if isConfigFileNeeded(defaultConfigPath) {
-		log.Printf("Copy default config to %s\n", defaultConfigPath)
+		infof("Copy default config to %s\n", defaultConfigPath)
 		if err = os.WriteFile(defaultConfigPath, embeddedConfigContent, 0644); err != nil {
 			log.Fatal(err)
 		}
@@ -242,7 +484,7 @@ func Setup(providedReppDir string) {
 
 	// features DB
 	if isConfigFileNeeded(FeatureDB) {
-		log.Printf("Copy feature database to %s\n", FeatureDB)
+		infof("Copy feature database to %s\n", FeatureDB)
 		if err = os.WriteFile(FeatureDB, embeddedFeaturesContent, 0644); err != nil {
 			log.Fatal(err)
 		}
@@ -250,15 +492,23 @@ func Setup(providedReppDir string) {
 
 	// enzymes DB
 	if isConfigFileNeeded(EnzymeDB) {
-		log.Printf("Copy enzyme database to %s\n", EnzymeDB)
+		infof("Copy enzyme database to %s\n", EnzymeDB)
 		if err = os.WriteFile(EnzymeDB, embeddedEnzymesContent, 0644); err != nil {
 			log.Fatal(err)
 		}
 	}
 
+	// contaminants DB
+	if isConfigFileNeeded(ContaminantsDB) {
+		infof("Copy contaminants database to %s\n", ContaminantsDB)
+		if err = os.WriteFile(ContaminantsDB, embeddedContaminantsContent, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// primer3 config directory
 	if isConfigFileNeeded(defaultPrimer3ConfigDir) {
-		log.Printf("Copy primer3 thermodynamic params to %s\n", defaultPrimer3ConfigDir)
+		infof("Copy primer3 thermodynamic params to %s\n", defaultPrimer3ConfigDir)
 		copyEmbeddedDir(embeddedPrimer3ThermodynamicParams, "primer3_config", defaultPrimer3ConfigDir)
 	}
 }
@@ -322,7 +572,11 @@ func copyEmbeddedFile(fs embed.FS, from, to string) {
 
 // New returns a new Config struct populated by settings from
 // config.yaml, in the repo, or some other settings file the user
-// points to with the "--config" command
+// points to with the "--config" command. Any setting can be overridden
+// by a REPP_ environment variable, its key in upper case with dashes
+// and dots as underscores, ex: REPP_FRAGMENTS_MAX_COUNT=4. Environment
+// variables take precedence over both settings files. Settings aren't
+// watched for changes, commands are short-lived and read them as they start
 //
 // TODO: check for and error out on nonsense config values
 // TODO: add back the config file path setting
@@ -330,6 +584,13 @@ func New() *Config {
 	// read in the default settings first
 	viper.SetConfigType("yaml")
 	viper.SetConfigFile(defaultConfigPath)
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	viper.AutomaticEnv()
+	// settings that are unset by default are only read from the environment if they're bound
+	if err := viper.BindEnv("match-left-margin"); err != nil {
+		log.Fatal(err)
+	}
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatal(err)
 	}
@@ -354,7 +615,7 @@ func New() *Config {
 
 	config := &Config{}
 	if err := viper.Unmarshal(&config); err != nil {
-		log.Fatalf("failed to decode settings file %s: %v", viper.ConfigFileUsed(), err)
+		log.Fatalf("failed to decode settings file %s or the %s_ environment variables: %v", viper.ConfigFileUsed(), envPrefix, err)
 	}
 	return config
 }
@@ -395,6 +656,15 @@ func (c *Config) GetSyntheticFragmentFactor() int {
 	}
 }
 
+// SetPrimerAdditions replaces the primer-additions setting with the addition of fwd and rev
+// to the primers of all PCR fragments. The setting is unchanged if both are empty
+func (c *Config) SetPrimerAdditions(fwd, rev string) *Config {
+	if fwd != "" || rev != "" {
+		c.PrimerAdditions = []PrimerAddition{{Fwd: fwd, Rev: rev}}
+	}
+	return c
+}
+
 // SynthFragmentCost returns the cost of synthesizing a linear stretch of DNA
 func (c *Config) SynthFragmentCost(fragLength int) float64 {
 	// by default, we try to synthesize the whole thing in one piece
@@ -420,6 +690,228 @@ func (c *Config) SynthPlasmidCost(insertLength int) float64 {
 	return float64(insertLength) * cost.Cost
 }
 
+// VendorDiscount returns the multiplier applied to purchases from the vendor, 1 if it has no discount
+func (c *Config) VendorDiscount(vendor string) float64 {
+	if discount, ok := c.VendorDiscounts[strings.ToLower(vendor)]; ok && discount > 0 {
+		return discount
+	}
+	return 1
+}
+
+// defaultSuccessWeights are the weights of the success model's factors missing from a config
+var defaultSuccessWeights = map[string]float64{
+	"fragments":         0.05,
+	"junction-tm":       0.02,
+	"junction-gc":       0.02,
+	"fragment-length":   0.5,
+	"primers":           0.1,
+	"off-target-margin": 0.1,
+	"synthesis":         0.02,
+}
+
+// SuccessWeight returns the weight of a factor of the success model
+func (c *Config) SuccessWeight(factor string) float64 {
+	if weight, ok := c.SuccessWeights[factor]; ok && weight >= 0 {
+		return weight
+	}
+	return defaultSuccessWeights[factor]
+}
+
+// defaultProtocol are the bench protocol parameters missing from a config
+var defaultProtocol = map[string]float64{
+	"pcr-volume":                   50,
+	"pcr-template-ng":              1,
+	"pcr-cycles":                   30,
+	"pcr-extension-seconds-per-kb": 30,
+	"dpni-volume":                  1,
+	"dpni-minutes":                 60,
+	"cleanup-elution-volume":       20,
+	"assembly-volume":              20,
+	"assembly-vector-ng":           50,
+	"assembly-insert-ratio":        2,
+	"assembly-minutes":             60,
+	"transformation-volume":        2,
+	"recovery-minutes":             60,
+}
+
+// ProtocolParameter returns a volume, amount or timing of the bench protocols
+func (c *Config) ProtocolParameter(name string) float64 {
+	if value, ok := c.Protocol[name]; ok && value > 0 {
+		return value
+	}
+	return defaultProtocol[name]
+}
+
+// defaultAssemblyTemp is the temperature of a Gibson Assembly
+const defaultAssemblyTemp = 50.0
+
+// GetAssemblyTemp returns the temperature of the assembly reaction, a Gibson
+// Assembly's if the config has none
+func (c *Config) GetAssemblyTemp() float64 {
+	if c.AssemblyTemp <= 0 {
+		return defaultAssemblyTemp
+	}
+	return c.AssemblyTemp
+}
+
+// JunctionMaxHairpinMelt returns the max melting temperature of a hairpin in a junction.
+// fragments-max-junction-hairpin is relative to a 50C Gibson Assembly, so it's shifted
+// by the difference of the assembly temperature: a hairpin that's stable 3C below the
+// reaction is as much of a problem at 42C as at 50C
+func (c *Config) JunctionMaxHairpinMelt() float64 {
+	return c.FragmentsMaxHairpinMelt + c.GetAssemblyTemp() - defaultAssemblyTemp
+}
+
+// GetCullLimit returns how many longer matches may engulf a match before it's culled
+func (c *Config) GetCullLimit() int {
+	if c.CullLimit <= 0 {
+		return 1
+	}
+	return c.CullLimit
+}
+
+// GetCullMinLength returns the length of the shortest match that isn't culled
+func (c *Config) GetCullMinLength() int {
+	if c.CullMinLength <= 0 {
+		return c.PcrMinFragLength
+	}
+	return c.CullMinLength
+}
+
+// GetCullGrouping returns which matches may cull those they engulf, by database if empty
+func (c *Config) GetCullGrouping() string {
+	if c.CullGrouping == "" {
+		return "database"
+	}
+	return strings.ToLower(c.CullGrouping)
+}
+
+// GetMatchLeftMargin returns the left margin of matches of circular targets, defaultMargin if it's not set
+func (c *Config) GetMatchLeftMargin(defaultMargin int) int {
+	if c.MatchLeftMargin == nil {
+		return defaultMargin
+	}
+	return *c.MatchLeftMargin
+}
+
+// GetMatchLeftMarginHandling returns how matches in the left margin are handled, merged if it's not set
+func (c *Config) GetMatchLeftMarginHandling() string {
+	if c.MatchLeftMarginHandling == "" {
+		return "merge"
+	}
+	return strings.ToLower(c.MatchLeftMarginHandling)
+}
+
+// GetQPCRAssays returns which qPCR assays to design, none if the config has nothing
+func (c *Config) GetQPCRAssays() string {
+	if c.QPCRAssays == "" {
+		return "none"
+	}
+	return strings.ToLower(c.QPCRAssays)
+}
+
+// GetOutputNameCollisions returns what to do about names that collide with previous outputs,
+// increment if the config has nothing
+func (c *Config) GetOutputNameCollisions() string {
+	if c.OutputNameCollisions == "" {
+		return "increment"
+	}
+	return strings.ToLower(c.OutputNameCollisions)
+}
+
+// defaultBlastMaxFileSize is makeblastdb's own default volume size
+const defaultBlastMaxFileSize = "1GB"
+
+// GetBlastMaxFileSize returns the max size of BLAST database volumes,
+// makeblastdb's default if the config has none
+func (c *Config) GetBlastMaxFileSize() string {
+	if c.BlastMaxFileSize == "" {
+		return defaultBlastMaxFileSize
+	}
+	return c.BlastMaxFileSize
+}
+
+// defaultTargetMinLength and defaultTargetMaxLength bound the lengths of targets
+// designed without a warning, if a config has no bounds
+const (
+	defaultTargetMinLength = 200
+	defaultTargetMaxLength = 50000
+)
+
+// GetTargetMinLength returns the shortest target designed without a warning
+func (c *Config) GetTargetMinLength() int {
+	if c.TargetMinLength <= 0 {
+		return defaultTargetMinLength
+	}
+	return c.TargetMinLength
+}
+
+// GetTargetMaxLength returns the longest target designed without a warning
+func (c *Config) GetTargetMaxLength() int {
+	if c.TargetMaxLength <= 0 {
+		return defaultTargetMaxLength
+	}
+	return c.TargetMaxLength
+}
+
+// GetTargetContaminantsDB returns the FASTA of the contaminants targets are screened
+// for, the built-in sequencing adapters if the config has none
+func (c *Config) GetTargetContaminantsDB() string {
+	if c.TargetContaminantsDB == "" {
+		return ContaminantsDB
+	}
+	return c.TargetContaminantsDB
+}
+
+// defaultUniversalPrimers are the universal primers if a config has none
+var defaultUniversalPrimers = []UniversalPrimer{
+	{Name: "M13F", Seq: "GTAAAACGACGGCCAGT"},
+	{Name: "M13R", Seq: "CAGGAAACAGCTATGAC"},
+	{Name: "T7", Seq: "TAATACGACTCACTATAGGG"},
+	{Name: "SP6", Seq: "ATTTAGGTGACACTATAG"},
+}
+
+// GetUniversalPrimers returns the universal primers. Configs without the setting, from
+// before it was added, get the defaults. An empty list turns them off
+func (c *Config) GetUniversalPrimers() []UniversalPrimer {
+	if c.UniversalPrimers == nil {
+		return defaultUniversalPrimers
+	}
+	return c.UniversalPrimers
+}
+
+// defaultOligosXlsxColumns are the columns of the oligos in xlsx manifests missing from a config
+var defaultOligosXlsxColumns = map[string]string{
+	"id":        "A",
+	"sequence":  "B",
+	"plate":     "C",
+	"well":      "D",
+	"ordered":   "E",
+	"validated": "F",
+	"location":  "G",
+}
+
+// OligosXlsxColumn returns the column of a field of the oligos in xlsx manifests, as letters or a header name
+func (c *Config) OligosXlsxColumn(field string) string {
+	if column := strings.TrimSpace(c.OligosXlsxColumns[field]); column != "" {
+		return column
+	}
+	return defaultOligosXlsxColumns[field]
+}
+
+// VendorShippingCost returns the shipping and handling cost of an order from the vendor
+func (c *Config) VendorShippingCost(vendor string) float64 {
+	return c.VendorShippingCosts[strings.ToLower(vendor)]
+}
+
+// ToCurrency converts a cost from the currency of the config to the reported currency
+func (c *Config) ToCurrency(cost float64) float64 {
+	if c.CurrencyExchangeRate <= 0 {
+		return cost
+	}
+	return cost * c.CurrencyExchangeRate
+}
+
 func (c *Config) EstimatePCRPrimersLength(defaultValue int) int {
 	medPcrPrimerLength := (c.PcrPrimerMinLength + c.PcrPrimerMaxLength) / 2
 	if medPcrPrimerLength > 0 {
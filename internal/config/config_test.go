@@ -1,11 +1,15 @@
 package config
 
 import (
+	"bytes"
+	"log"
+	"os"
 	"testing"
 )
 
 func TestMain(m *testing.M) {
 	Setup("")
+	os.Exit(m.Run())
 }
 
 func TestConfig_SynthCost(t *testing.T) {
@@ -78,3 +82,90 @@ func TestConfig_SynthCost(t *testing.T) {
 		})
 	}
 }
+
+func TestNew_environment(t *testing.T) {
+	t.Setenv("REPP_FRAGMENTS_MAX_COUNT", "3")
+	t.Setenv("REPP_PCR_BP_COST", "0.45")
+	t.Setenv("REPP_OLIGOS_XLSX_COLUMNS_ID", "H")
+
+	c := New()
+	if c.FragmentsMaxCount != 3 || c.PcrBpCost != 0.45 || c.OligosXlsxColumn("id") != "H" {
+		t.Errorf("New() = fragments-max-count %d, pcr-bp-cost %v, id column %s, want 3, 0.45, H",
+			c.FragmentsMaxCount, c.PcrBpCost, c.OligosXlsxColumn("id"))
+	}
+	if c.OligosXlsxColumn("sequence") != "B" {
+		t.Errorf("New() sequence column = %s, want the default B", c.OligosXlsxColumn("sequence"))
+	}
+}
+
+func TestConfig_JunctionMaxHairpinMelt(t *testing.T) {
+	tests := []struct {
+		name         string
+		assemblyTemp float64
+		want         float64
+	}{
+		{"gibson by default", 0, 47},
+		{"gibson", 50, 47},
+		{"isothermal", 42, 39},
+		{"ligation", 37, 34},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{FragmentsMaxHairpinMelt: 47, AssemblyTemp: tt.assemblyTemp}
+			if got := c.JunctionMaxHairpinMelt(); got != tt.want {
+				t.Errorf("Config.JunctionMaxHairpinMelt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_GetMatchLeftMargin(t *testing.T) {
+	zero, margin := 0, 150
+	tests := []struct {
+		name            string
+		matchLeftMargin *int
+		want            int
+	}{
+		{"unset", nil, 200},
+		{"zero", &zero, 0},
+		{"set", &margin, 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{MatchLeftMargin: tt.matchLeftMargin}
+			if got := c.GetMatchLeftMargin(200); got != tt.want {
+				t.Errorf("Config.GetMatchLeftMargin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_matchLeftMargin(t *testing.T) {
+	if c := New(); c.MatchLeftMargin != nil {
+		t.Errorf("New() match-left-margin = %d, want it unset", *c.MatchLeftMargin)
+	}
+
+	t.Setenv("REPP_MATCH_LEFT_MARGIN", "0")
+	if c := New(); c.MatchLeftMargin == nil || *c.MatchLeftMargin != 0 {
+		t.Errorf("New() match-left-margin = %v, want 0 from the environment", c.MatchLeftMargin)
+	}
+}
+
+func Test_infof(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	defer func() { quietLogging = false }()
+
+	infof("Copy default config to %s", "here")
+	if logged.Len() == 0 {
+		t.Error("infof() didn't log")
+	}
+
+	logged.Reset()
+	SetQuietLogging()
+	infof("Copy default config to %s", "here")
+	if logged.Len() != 0 {
+		t.Errorf("infof() logged %q in quiet mode", logged.String())
+	}
+}
@@ -0,0 +1,110 @@
+package repp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// abifEntrySize is the size (bytes) of an entry of an ABIF file's directory
+const abifEntrySize = 28
+
+// abifEntry is an entry of the directory of an ABIF file, ex: the base calls of a trace
+type abifEntry struct {
+	name   string
+	number int32
+	count  int32
+	size   int32
+	offset int32
+
+	// inline is the data of entries of 4 bytes or less, stored in place of their offset
+	inline []byte
+}
+
+// readAB1 reads the base calls and their quality values from an ABIF (.ab1) Sanger trace.
+// The edited base calls (PBAS 2) are preferred over the basecaller's (PBAS 1). The quality
+// values are nil if the trace doesn't have them
+func readAB1(path string) (seq string, quals []int, err error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(contents) < 6+abifEntrySize || string(contents[:4]) != "ABIF" {
+		return "", nil, fmt.Errorf("%s is not an ABIF trace file", path)
+	}
+
+	root := parseABIFEntry(contents[6 : 6+abifEntrySize])
+	entries := make(map[string]abifEntry)
+	for i := 0; i < int(root.count); i++ {
+		start := int(root.offset) + i*abifEntrySize
+		if start < 0 || start+abifEntrySize > len(contents) {
+			return "", nil, fmt.Errorf("%s has a truncated ABIF directory", path)
+		}
+		e := parseABIFEntry(contents[start : start+abifEntrySize])
+		entries[fmt.Sprintf("%s%d", e.name, e.number)] = e
+	}
+
+	data := func(key string) ([]byte, bool) {
+		e, ok := entries[key]
+		if !ok {
+			return nil, false
+		}
+		if e.size <= 4 {
+			return e.inline[:e.size], true
+		}
+		if e.offset < 0 || int(e.offset)+int(e.size) > len(contents) {
+			return nil, false
+		}
+		return contents[e.offset : e.offset+e.size], true
+	}
+
+	bases, ok := data("PBAS2")
+	if !ok {
+		if bases, ok = data("PBAS1"); !ok {
+			return "", nil, fmt.Errorf("%s has no base calls", path)
+		}
+	}
+	if qv, ok := data("PCON2"); ok && len(qv) == len(bases) {
+		for _, q := range qv {
+			quals = append(quals, int(int8(q)))
+		}
+	}
+	return string(bases), quals, nil
+}
+
+// parseABIFEntry parses a big-endian directory entry of an ABIF file
+func parseABIFEntry(b []byte) abifEntry {
+	return abifEntry{
+		name:   string(b[0:4]),
+		number: int32(binary.BigEndian.Uint32(b[4:8])),
+		count:  int32(binary.BigEndian.Uint32(b[12:16])),
+		size:   int32(binary.BigEndian.Uint32(b[16:20])),
+		offset: int32(binary.BigEndian.Uint32(b[20:24])),
+		inline: b[20:24],
+	}
+}
+
+// mottTrimCutoff is the error probability of the modified Mott algorithm's quality trimming,
+// as in phred and Geneious: bases less likely than it to be wrong add to a read's kept stretch
+const mottTrimCutoff = 0.05
+
+// mottTrim returns the stretch [start, end) of a read with the highest sum of the
+// differences between mottTrimCutoff and its bases' error probabilities: the read
+// without its low quality ends. The whole read if there aren't quality values
+func mottTrim(quals []int, length int) (start, end int) {
+	if len(quals) != length {
+		return 0, length
+	}
+	sum, bestSum, from := 0.0, 0.0, 0
+	for i, q := range quals {
+		sum += mottTrimCutoff - math.Pow(10, -float64(q)/10)
+		if sum < 0 {
+			sum, from = 0, i+1
+		}
+		if sum > bestSum {
+			bestSum, start, end = sum, from, i+1
+		}
+	}
+	return start, end
+}
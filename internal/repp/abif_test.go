@@ -0,0 +1,102 @@
+package repp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTestAB1 writes an ABIF trace with base calls and, if there are any, quality values
+func writeTestAB1(t *testing.T, path, bases string, quals []int) {
+	type entry struct {
+		name   string
+		number int32
+		data   []byte
+	}
+	entries := []entry{{"PBAS", 2, []byte(bases)}}
+	if len(quals) > 0 {
+		var qv []byte
+		for _, q := range quals {
+			qv = append(qv, byte(q))
+		}
+		entries = append(entries, entry{"PCON", 2, qv})
+	}
+
+	var data bytes.Buffer
+	offsets := make([]int32, len(entries))
+	headerSize := 128
+	for i, e := range entries {
+		offsets[i] = int32(headerSize + data.Len())
+		data.Write(e.data)
+	}
+	dirOffset := int32(headerSize + data.Len())
+
+	writeEntry := func(w *bytes.Buffer, name string, number int32, elementType, elementSize int16, count, size, offset int32) {
+		w.WriteString(name)
+		for _, v := range []interface{}{number, elementType, elementSize, count, size, offset, int32(0)} {
+			if err := binary.Write(w, binary.BigEndian, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString("ABIF")
+	if err := binary.Write(&file, binary.BigEndian, int16(101)); err != nil {
+		t.Fatal(err)
+	}
+	writeEntry(&file, "tdir", 1, 1023, abifEntrySize, int32(len(entries)), int32(len(entries)*abifEntrySize), dirOffset)
+	file.Write(make([]byte, headerSize-file.Len()))
+	file.Write(data.Bytes())
+	for i, e := range entries {
+		writeEntry(&file, e.name, e.number, 2, 1, int32(len(e.data)), int32(len(e.data)), offsets[i])
+	}
+	if err := os.WriteFile(path, file.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_readAB1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "read.ab1")
+	quals := []int{5, 40, 40, 40, 40, 10}
+	writeTestAB1(t, path, "NACGTA", quals)
+
+	seq, gotQuals, err := readAB1(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq != "NACGTA" || !reflect.DeepEqual(gotQuals, quals) {
+		t.Errorf("readAB1() = %s %v, want NACGTA %v", seq, gotQuals, quals)
+	}
+
+	notABIF := filepath.Join(t.TempDir(), "read.fasta")
+	if err = os.WriteFile(notABIF, []byte(">read\nACGT\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = readAB1(notABIF); err == nil {
+		t.Errorf("readAB1() read a FASTA file")
+	}
+}
+
+func Test_mottTrim(t *testing.T) {
+	tests := []struct {
+		name       string
+		quals      []int
+		length     int
+		start, end int
+	}{
+		{"low quality ends", []int{5, 8, 30, 40, 40, 30, 10, 3}, 8, 2, 6},
+		{"no quality values", nil, 8, 0, 8},
+		{"all low quality", []int{2, 3, 2}, 3, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if start, end := mottTrim(tt.quals, tt.length); start != tt.start || end != tt.end {
+				t.Errorf("mottTrim() = %d, %d, want %d, %d", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
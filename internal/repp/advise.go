@@ -0,0 +1,334 @@
+package repp
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// assembly methods that are recommended for a target
+const (
+	singleFragmentMethod = "single-fragment clone"
+	gibsonMethod         = "Gibson Assembly"
+	goldenGateMethod     = "Golden Gate assembly"
+	synthesisMethod      = "full synthesis"
+)
+
+// adviseGibsonMaxFragments is the most fragments Gibson Assembly is recommended for.
+// Its efficiency falls off past five or six fragments, where Golden Gate assembly holds up
+const adviseGibsonMaxFragments = 6
+
+// adviseMinCoverage is the least fraction of a target that has to be in the databases
+// for it to be PCR'ed from them rather than synthesized
+const adviseMinCoverage = 0.5
+
+// goldenGateEnzymes are the Type IIS enzymes a Golden Gate assembly's fragments are digested with
+var goldenGateEnzymes = []string{"BsaI", "BsmBI"}
+
+// adviseSpan is a match's stretch [start, end) of the target and what it costs to PCR
+type adviseSpan struct {
+	start, end int
+	cost       float64
+}
+
+// coverageEstimate is how much of a target is in the databases, and a rough plan to build
+// it: the fewest matches that cover it, bridged by primer tails, with the rest synthesized
+type coverageEstimate struct {
+	// length of the target
+	length int
+
+	// covered is the bp of the target in at least one match
+	covered int
+
+	// longest match against the target
+	longest match
+
+	// pcrFrags is the number of fragments of the plan that are PCR'ed
+	pcrFrags int
+
+	// synthFrags is the number of fragments of the plan that are synthesized
+	synthFrags int
+
+	// cost of the plan
+	cost float64
+}
+
+// longestCovered returns the bp of the target in its longest match
+func (est coverageEstimate) longestCovered() int {
+	if l := est.longest.length(); l < est.length {
+		return l
+	}
+	return est.length
+}
+
+// advice is the assembly method recommended for a target and the reasons for it
+type advice struct {
+	method  string
+	reasons []string
+	command string
+}
+
+// Advise recommends an assembly method for a target: cloning it from a single fragment, Gibson
+// Assembly, Golden Gate assembly or synthesizing all of it. It's picked by how much of the target
+// is in the databases, the fragments and cost of a rough plan, and the target's Type IIS sites
+func Advise(assemblyParams AssemblyParams, conf *config.Config) {
+	fragments, err := read(assemblyParams.GetIn(), false, false)
+	if err != nil {
+		rlog.Fatalf("failed to read target sequence from %s: %v", assemblyParams.GetIn(), err)
+	}
+	target := fragments[0]
+	circularTarget := isCircularTarget(target, assemblyParams.GetTopology())
+	filter, err := parseFilter(assemblyParams.GetFilterExpr())
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	dbs, err := assemblyParams.getDBs()
+	if err != nil {
+		rlog.Fatal(err)
+	}
+
+	var matches []match
+	if len(dbs) > 0 {
+		if matches, err = blast(
+			target.ID,
+			target.Seq,
+			circularTarget,
+			assemblyParams.GetLeftMargin(),
+			dbs,
+			assemblyParams.GetFilters(),
+			filter,
+			assemblyParams.GetIdentity(),
+			assemblyParams.GetUngapped(),
+			conf,
+		); err != nil {
+			rlog.Fatalf("failed to blast %s against the dbs %s: %v", target.ID, strings.Join(dbNames(dbs), ", "), err)
+		}
+		if err = extendIdenticalEnds(matches, target.Seq, circularTarget, assemblyParams.GetLeftMargin()); err != nil {
+			rlog.Fatalf("failed to extend matches for %s: %v", target.ID, err)
+		}
+		matches, _ = splitNoPCR(matches)
+		matches = amplifiableMatches(matches, len(target.Seq), conf)
+	}
+
+	enzymes, err := getValidEnzymes(goldenGateEnzymes)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	est := estimateCoverage(matches, len(target.Seq), circularTarget, conf)
+	a := adviseMethod(est, typeIISSites(target.Seq, enzymes), conf)
+	a.command = adviseCommand(a.method, assemblyParams.GetIn(), dbNames(dbs))
+
+	if err = a.print(os.Stdout, target, circularTarget, dbNames(dbs), est); err != nil {
+		rlog.Fatal(err)
+	}
+}
+
+// estimateCoverage finds how much of a target the matches cover and plans it greedily from
+// the longest match: each step PCRs the match that reaches furthest while starting close
+// enough to be joined by primer tails, and gaps no match reaches are synthesized
+func estimateCoverage(matches []match, length int, circular bool, conf *config.Config) (est coverageEstimate) {
+	est.length = length
+	if length == 0 {
+		return est
+	}
+
+	primersCost := 2 * float64(conf.EstimatePCRPrimersLength(24)) * conf.PcrBpCost
+	covered := make([]bool, length)
+	var spans []adviseSpan
+	for _, m := range matches {
+		if m.length() < conf.PcrMinFragLength {
+			continue
+		}
+		if m.length() > est.longest.length() {
+			est.longest = m
+		}
+		start := m.queryStart % length
+		end := start + m.queryEnd - m.queryStart + 1
+		if end-start > length {
+			end = start + length
+		}
+		for i := start; i < end; i++ {
+			covered[i%length] = true
+		}
+		span := adviseSpan{start: start, end: end, cost: m.db.Cost + primersCost + conf.PcrRxnCost}
+		spans = append(spans, span)
+		if circular {
+			spans = append(spans, adviseSpan{start: start + length, end: end + length, cost: span.cost})
+		}
+	}
+	for _, c := range covered {
+		if c {
+			est.covered++
+		}
+	}
+
+	origin := 0
+	if circular && est.longest.entry != "" {
+		origin = est.longest.queryStart % length
+	}
+	maxGap := 2*conf.PcrPrimerMaxEmbedLength - conf.FragmentsMinHomology
+	pos, goal := origin, origin+length
+	for pos < goal {
+		reach, next := adviseSpan{end: pos}, -1
+		for _, s := range spans {
+			if s.start <= pos+maxGap && s.end > reach.end {
+				reach = s
+			}
+			if s.start > pos && (next < 0 || s.start < next) {
+				next = s.start
+			}
+		}
+		if reach.end > pos {
+			est.pcrFrags++
+			est.cost += reach.cost
+			pos = reach.end
+			continue
+		}
+
+		// no match is close enough to join, synthesize up to the next one
+		gapEnd := goal
+		if next >= 0 && next < goal {
+			gapEnd = next
+		}
+		gap := gapEnd - pos
+		if conf.SyntheticMaxLength > 0 {
+			est.synthFrags += int(math.Ceil(float64(gap) / float64(conf.SyntheticMaxLength)))
+		} else {
+			est.synthFrags++
+		}
+		est.cost += conf.SynthFragmentCost(gap)
+		pos = gapEnd
+	}
+	return est
+}
+
+// typeIISSites returns the number of sites of each enzyme in a sequence, on either strand
+func typeIISSites(seq string, enzymes []enzyme) map[string]int {
+	sites := make(map[string]int)
+	for _, e := range enzymes {
+		sites[e.name] = 0
+	}
+	cuts, _ := cutsites(strings.ToUpper(seq), enzymes)
+	for _, c := range cuts {
+		sites[c.enzyme.name]++
+	}
+	return sites
+}
+
+// adviseMethod recommends an assembly method given the target's coverage by the databases,
+// and the Type IIS sites that rule out digesting its fragments for Golden Gate assembly
+func adviseMethod(est coverageEstimate, sites map[string]int, conf *config.Config) (a advice) {
+	coverage := 0.0
+	if est.length > 0 {
+		coverage = float64(est.covered) / float64(est.length)
+	}
+	synthesisCost := conf.SynthFragmentCost(est.length)
+	fragCount := est.pcrFrags + est.synthFrags
+	currency := conf.Currency
+
+	if est.pcrFrags == 1 && est.synthFrags == 0 {
+		a.method = singleFragmentMethod
+		a.reasons = append(a.reasons, fmt.Sprintf("%s in %s covers %dbp of the %dbp target", est.longest.entry, est.longest.db.Name, est.longestCovered(), est.length))
+		if est.longest.length() < est.length {
+			a.reasons = append(a.reasons, "the rest of the target is added by the primers' tails")
+		}
+		a.reasons = append(a.reasons, fmt.Sprintf("a single PCR costs an estimated %.2f %s", conf.ToCurrency(est.cost), currency))
+		return a
+	}
+
+	if est.pcrFrags == 0 || coverage < adviseMinCoverage || synthesisCost <= est.cost {
+		a.method = synthesisMethod
+		if est.pcrFrags == 0 {
+			a.reasons = append(a.reasons, "no match in the databases is long enough to PCR")
+		} else if coverage < adviseMinCoverage {
+			a.reasons = append(a.reasons, fmt.Sprintf("only %.1f%% of the target is in the databases", 100*coverage))
+		}
+		a.reasons = append(a.reasons, fmt.Sprintf(
+			"synthesis costs an estimated %.2f %s, against %.2f %s for %d fragments from the databases",
+			conf.ToCurrency(synthesisCost), currency, conf.ToCurrency(est.cost), currency, fragCount,
+		))
+		return a
+	}
+
+	a.method = gibsonMethod
+	a.reasons = append(a.reasons,
+		fmt.Sprintf("%.1f%% of the target is in the databases", 100*coverage),
+		fmt.Sprintf("an estimated %d fragments, %d PCR'ed and %d synthesized, for %.2f %s", fragCount, est.pcrFrags, est.synthFrags, conf.ToCurrency(est.cost), currency),
+	)
+	if fragCount <= adviseGibsonMaxFragments {
+		return a
+	}
+
+	var siteFree, withSites []string
+	for _, name := range goldenGateEnzymes {
+		if n, ok := sites[name]; ok && n == 0 {
+			siteFree = append(siteFree, name)
+		} else if ok {
+			withSites = append(withSites, fmt.Sprintf("%d %s", n, name))
+		}
+	}
+	if len(siteFree) == 0 {
+		a.reasons = append(a.reasons, fmt.Sprintf(
+			"Golden Gate assembly would suit more than %d fragments, but the target has %s sites that would have to be removed",
+			adviseGibsonMaxFragments, strings.Join(withSites, " and "),
+		))
+		return a
+	}
+	a.method = goldenGateMethod
+	a.reasons = append(a.reasons,
+		fmt.Sprintf("Gibson Assembly's efficiency falls off past %d fragments", adviseGibsonMaxFragments),
+		fmt.Sprintf("the target has no %s sites, so its fragments can be digested with %s", strings.Join(siteFree, " or "), siteFree[0]),
+	)
+	return a
+}
+
+// adviseCommand returns the command that designs a target with an assembly method
+func adviseCommand(method, in string, dbs []string) string {
+	command := "repp make sequence -i " + in
+	if method == synthesisMethod {
+		return command + " --synth-only"
+	}
+	if len(dbs) > 0 {
+		command += " --dbs " + strings.Join(dbs, ",")
+	}
+	if method == goldenGateMethod {
+		command += ", with golden-gate-overhangs: true in the config"
+	}
+	return command
+}
+
+// print writes the recommendation, its reasons and the command to run next
+func (a advice) print(w io.Writer, target *Frag, circular bool, dbs []string, est coverageEstimate) error {
+	topology := "linear"
+	if circular {
+		topology = "circular"
+	}
+	databases := strings.Join(dbs, ", ")
+	if databases == "" {
+		databases = "none"
+	}
+	coverage := 0.0
+	if est.length > 0 {
+		coverage = 100 * float64(est.covered) / float64(est.length)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "target\t%s (%dbp, %s)\n", target.ID, len(target.Seq), topology)
+	fmt.Fprintf(tw, "databases\t%s\n", databases)
+	if est.longest.entry != "" {
+		fmt.Fprintf(tw, "coverage\t%.1f%% (longest match: %s, %dbp)\n", coverage, est.longest.entry, est.longestCovered())
+	} else {
+		fmt.Fprintf(tw, "coverage\t%.1f%%\n", coverage)
+	}
+	fmt.Fprintf(tw, "recommended\t%s\n", a.method)
+	for _, r := range a.reasons {
+		fmt.Fprintf(tw, "\t- %s\n", r)
+	}
+	fmt.Fprintf(tw, "next\t%s\n", a.command)
+	return tw.Flush()
+}
@@ -0,0 +1,100 @@
+package repp
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// adviseTestConfig has the default costs and PCR limits
+func adviseTestConfig() *config.Config {
+	return &config.Config{
+		PcrMinFragLength:        200,
+		PcrPrimerMaxEmbedLength: 40,
+		PcrPrimerMinLength:      18,
+		PcrPrimerMaxLength:      30,
+		FragmentsMinHomology:    15,
+		PcrBpCost:               0.6,
+		PcrRxnCost:              0.27,
+		SyntheticMaxLength:      1800,
+		SyntheticFragmentCost:   map[int]config.SynthCost{1800: {Cost: 0.07}},
+		Currency:                "USD",
+	}
+}
+
+func Test_estimateCoverage(t *testing.T) {
+	conf := adviseTestConfig()
+	span := func(start, end int) match {
+		return match{entry: "entry", queryStart: start, queryEnd: end, subjectStart: 0, subjectEnd: end - start}
+	}
+	tests := []struct {
+		name       string
+		matches    []match
+		covered    int
+		pcrFrags   int
+		synthFrags int
+		cost       float64
+	}{
+		{"one match of the whole target", []match{span(0, 4999)}, 5000, 1, 0, 29.07},
+		{"matches joined across the zero-index", []match{span(1990, 3499), span(0, 1999), span(3480, 5019)}, 5000, 3, 0, 87.21},
+		{"gap between matches synthesized", []match{span(0, 1999), span(3000, 4999)}, 4000, 2, 1, 128.14},
+		{"matches too short to PCR", []match{span(0, 149)}, 0, 0, 3, 349.86},
+		{"no matches", nil, 0, 0, 3, 349.86},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := estimateCoverage(tt.matches, 5000, true, conf)
+			if est.covered != tt.covered || est.pcrFrags != tt.pcrFrags || est.synthFrags != tt.synthFrags {
+				t.Errorf("estimateCoverage() = %d covered, %d PCR, %d synthetic, want %d, %d, %d",
+					est.covered, est.pcrFrags, est.synthFrags, tt.covered, tt.pcrFrags, tt.synthFrags)
+			}
+			if math.Abs(est.cost-tt.cost) > 0.01 {
+				t.Errorf("estimateCoverage() cost = %.2f, want %.2f", est.cost, tt.cost)
+			}
+		})
+	}
+}
+
+func Test_adviseMethod(t *testing.T) {
+	conf := adviseTestConfig()
+	whole := match{entry: "pAB1", db: DB{Name: "addgene"}, queryStart: 0, queryEnd: 4999, subjectStart: 0, subjectEnd: 4999}
+	part := match{entry: "pAB2", db: DB{Name: "addgene"}, queryStart: 0, queryEnd: 1999, subjectStart: 0, subjectEnd: 1999}
+	siteFree := map[string]int{"BsaI": 0, "BsmBI": 2}
+	withSites := map[string]int{"BsaI": 1, "BsmBI": 2}
+
+	tests := []struct {
+		name   string
+		est    coverageEstimate
+		sites  map[string]int
+		method string
+	}{
+		{"in one database entry", coverageEstimate{length: 5000, covered: 5000, longest: whole, pcrFrags: 1, cost: 29.07}, siteFree, singleFragmentMethod},
+		{"mostly missing from the databases", coverageEstimate{length: 5000, covered: 2000, longest: part, pcrFrags: 1, synthFrags: 2, cost: 239.07}, siteFree, synthesisMethod},
+		{"not in the databases", coverageEstimate{length: 5000, synthFrags: 3, cost: 349.86}, siteFree, synthesisMethod},
+		{"a few fragments", coverageEstimate{length: 5000, covered: 4000, longest: part, pcrFrags: 2, synthFrags: 1, cost: 128.14}, siteFree, gibsonMethod},
+		{"many fragments", coverageEstimate{length: 5000, covered: 5000, longest: part, pcrFrags: 8, cost: 232.56}, siteFree, goldenGateMethod},
+		{"many fragments with Type IIS sites", coverageEstimate{length: 5000, covered: 5000, longest: part, pcrFrags: 8, cost: 232.56}, withSites, gibsonMethod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := adviseMethod(tt.est, tt.sites, conf)
+			if a.method != tt.method {
+				t.Errorf("adviseMethod() = %s, want %s", a.method, tt.method)
+			}
+			if len(a.reasons) == 0 {
+				t.Errorf("adviseMethod() recommended %s without a reason", a.method)
+			}
+		})
+	}
+}
+
+func Test_typeIISSites(t *testing.T) {
+	enzymes := []enzyme{newEnzyme("BsaI", "GGTCTCN^NNNN_N"), newEnzyme("BsmBI", "CGTCTCN^NNNN_N")}
+	seq := "ATGCGGTCTCAATTGCATGCATCGATGAGACCTTAGCA" // BsaI on both strands
+	want := map[string]int{"BsaI": 2, "BsmBI": 0}
+	if got := typeIISSites(seq, enzymes); !reflect.DeepEqual(got, want) {
+		t.Errorf("typeIISSites() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,246 @@
+package repp
+
+import (
+	"strings"
+)
+
+// scores of the local alignment of reads, ex: Sanger reads, against an expected sequence
+const (
+	alignMatch    = 2
+	alignMismatch = -3
+	alignGap      = -5
+)
+
+// alignSeedLength is the length (bp) of the exact k-mers that place a read on the sequence
+// it's aligned against, before it's aligned within a band around that placement
+const alignSeedLength = 12
+
+// alignBand is how far (bp) the alignment of a read may drift off the diagonal of its
+// seeds, ie the longest net insertion or deletion it can have
+const alignBand = 40
+
+// seqDiff is a difference between a read and the sequence it's aligned to. Substitutions
+// have a base of each, insertions only the read's bases and deletions only the sequence's
+type seqDiff struct {
+	// pos of the difference on the sequence (0-based). Insertions are before it
+	pos int
+
+	// ref is the sequence's bases
+	ref string
+
+	// read is the read's bases
+	read string
+}
+
+// kind returns the kind of the difference: SNV, insertion or deletion
+func (d seqDiff) kind() string {
+	switch {
+	case d.ref == "":
+		return "insertion"
+	case d.read == "":
+		return "deletion"
+	}
+	return "SNV"
+}
+
+// readAlignment is the local alignment of a read against a sequence
+type readAlignment struct {
+	// start and end of the alignment on the sequence, [start, end)
+	start, end int
+
+	// readStart and readEnd of the alignment on the read (or its reverse complement), [start, end)
+	readStart, readEnd int
+
+	// revComp is whether the reverse complement of the read aligned
+	revComp bool
+
+	// matches is the number of aligned bases that are identical
+	matches int
+
+	// columns is the number of columns of the alignment, ie its length with gaps
+	columns int
+
+	// diffs between the read and the sequence, by their position
+	diffs []seqDiff
+}
+
+// identity returns the fraction of the alignment's columns that are matches
+func (a readAlignment) identity() float64 {
+	if a.columns == 0 {
+		return 0
+	}
+	return float64(a.matches) / float64(a.columns)
+}
+
+// seqAligner aligns reads against a sequence by seeding them with its k-mers
+type seqAligner struct {
+	seq string
+
+	// kmers are the positions of the sequence's alignSeedLength bp k-mers
+	kmers map[string][]int
+}
+
+// newSeqAligner indexes a sequence's k-mers to align reads against it. Circular sequences
+// are indexed doubled, so reads across the zero-index align in one piece
+func newSeqAligner(seq string, circular bool) *seqAligner {
+	seq = strings.ToUpper(seq)
+	if circular {
+		seq += seq
+	}
+	a := &seqAligner{seq: seq, kmers: make(map[string][]int)}
+	for i := 0; i+alignSeedLength <= len(seq); i++ {
+		kmer := seq[i : i+alignSeedLength]
+		a.kmers[kmer] = append(a.kmers[kmer], i)
+	}
+	return a
+}
+
+// align returns the best local alignment of a read, or its reverse complement, against
+// the sequence. False if none of the read's k-mers are in the sequence
+func (a *seqAligner) align(read string) (readAlignment, bool) {
+	read = strings.ToUpper(read)
+	var best readAlignment
+	found := false
+	for _, revComp := range []bool{false, true} {
+		strand := read
+		if revComp {
+			strand = reverseComplement(read)
+		}
+		diagonal, ok := a.seedDiagonal(strand)
+		if !ok {
+			continue
+		}
+		aligned := bandedLocalAlign(strand, a.seq, diagonal, alignBand)
+		aligned.revComp = revComp
+		if !found || aligned.matches > best.matches {
+			best, found = aligned, true
+		}
+	}
+	return best, found && best.columns > 0
+}
+
+// seedDiagonal returns the most common offset, on the sequence, of the read's k-mers.
+// Ties are broken by the smallest offset
+func (a *seqAligner) seedDiagonal(read string) (diagonal int, ok bool) {
+	votes := make(map[int]int)
+	for i := 0; i+alignSeedLength <= len(read); i++ {
+		for _, pos := range a.kmers[read[i:i+alignSeedLength]] {
+			votes[pos-i]++
+		}
+	}
+	most := 0
+	for d, count := range votes {
+		if count > most || count == most && d < diagonal {
+			diagonal, most = d, count
+		}
+	}
+	return diagonal, most > 0
+}
+
+// traceback directions of the banded alignment
+const (
+	traceStop byte = iota
+	traceDiagonal
+	traceInsertion // a base of the read that isn't in the sequence
+	traceDeletion  // a base of the sequence that isn't in the read
+)
+
+// bandedLocalAlign is a Smith-Waterman local alignment of a read against a sequence,
+// restricted to the band of columns within band of the diagonal, where base i of the
+// read is across from base i+diagonal of the sequence. Ns of the read are neither
+// matches nor differences
+func bandedLocalAlign(read, seq string, diagonal, band int) (aligned readAlignment) {
+	width := 2*band + 1
+	scores := make([][]int, len(read)+1)
+	trace := make([][]byte, len(read)+1)
+	for i := range scores {
+		scores[i] = make([]int, width)
+		trace[i] = make([]byte, width)
+	}
+
+	// column b of row i is base j = i + diagonal + b - band of the sequence (1-based)
+	bestScore, bestI, bestB := 0, 0, 0
+	for i := 1; i <= len(read); i++ {
+		for b := 0; b < width; b++ {
+			j := i + diagonal + b - band
+			if j < 1 || j > len(seq) {
+				continue
+			}
+			score, dir := 0, traceStop
+			diag := scores[i-1][b]
+			switch {
+			case read[i-1] == 'N':
+			case read[i-1] == seq[j-1]:
+				diag += alignMatch
+			default:
+				diag += alignMismatch
+			}
+			if diag > score {
+				score, dir = diag, traceDiagonal
+			}
+			if b+1 < width {
+				if up := scores[i-1][b+1] + alignGap; up > score {
+					score, dir = up, traceInsertion
+				}
+			}
+			if b > 0 {
+				if left := scores[i][b-1] + alignGap; left > score {
+					score, dir = left, traceDeletion
+				}
+			}
+			scores[i][b], trace[i][b] = score, dir
+			if score > bestScore {
+				bestScore, bestI, bestB = score, i, b
+			}
+		}
+	}
+	if bestScore == 0 {
+		return aligned
+	}
+
+	// trace back from the best cell, collecting the differences in reverse
+	i, b := bestI, bestB
+	aligned.readEnd, aligned.end = i, i+diagonal+b-band
+	var diffs []seqDiff
+	for trace[i][b] != traceStop {
+		j := i + diagonal + b - band
+		aligned.columns++
+		switch trace[i][b] {
+		case traceDiagonal:
+			if read[i-1] == seq[j-1] {
+				aligned.matches++
+			} else if read[i-1] != 'N' {
+				diffs = append(diffs, seqDiff{pos: j - 1, ref: seq[j-1 : j], read: read[i-1 : i]})
+			}
+			i--
+		case traceInsertion:
+			diffs = append(diffs, seqDiff{pos: j, read: read[i-1 : i]})
+			i--
+			b++
+		case traceDeletion:
+			diffs = append(diffs, seqDiff{pos: j - 1, ref: seq[j-1 : j]})
+			b--
+		}
+	}
+	aligned.readStart, aligned.start = i, i+diagonal+b-band
+
+	// in order, with the bases of neighboring insertions and deletions merged
+	for l, r := 0, len(diffs)-1; l < r; l, r = l+1, r-1 {
+		diffs[l], diffs[r] = diffs[r], diffs[l]
+	}
+	for _, d := range diffs {
+		if n := len(aligned.diffs); n > 0 {
+			last := &aligned.diffs[n-1]
+			if d.kind() == "insertion" && last.kind() == "insertion" && last.pos == d.pos {
+				last.read += d.read
+				continue
+			}
+			if d.kind() == "deletion" && last.kind() == "deletion" && last.pos+len(last.ref) == d.pos {
+				last.ref += d.ref
+				continue
+			}
+		}
+		aligned.diffs = append(aligned.diffs, d)
+	}
+	return aligned
+}
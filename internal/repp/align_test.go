@@ -0,0 +1,46 @@
+package repp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_seqAligner_align(t *testing.T) {
+	target := randomSeq(2000, 3)
+
+	// a read across the zero-index, with an SNV, a 2bp deletion and an insertion
+	read := target[1700:] + target[:300]
+	read = read[:100] + "A" + read[101:] // target[1800]
+	if target[1800] == 'A' {
+		read = read[:100] + "C" + read[101:]
+	}
+	read = read[:400] + read[402:]        // target[100:102]
+	read = read[:450] + "GG" + read[450:] // before target[152]
+
+	aligner := newSeqAligner(target, true)
+	for _, revComp := range []bool{false, true} {
+		query := read
+		if revComp {
+			query = reverseComplement(read)
+		}
+		a, ok := aligner.align(query)
+		if !ok {
+			t.Fatalf("align() found no alignment")
+		}
+		if a.revComp != revComp || a.start != 1700 || a.end != 2300 {
+			t.Errorf("align() = %d-%d, revComp %t, want 1700-2300, revComp %t", a.start, a.end, a.revComp, revComp)
+		}
+		want := []seqDiff{
+			{pos: 1800, ref: target[1800:1801], read: read[100:101]},
+			{pos: 2100, ref: target[100:102]},
+			{pos: 2152, read: "GG"},
+		}
+		if !reflect.DeepEqual(a.diffs, want) {
+			t.Errorf("align() diffs = %+v, want %+v", a.diffs, want)
+		}
+	}
+
+	if _, ok := aligner.align(randomSeq(500, 4)); ok {
+		t.Errorf("align() aligned an unrelated read")
+	}
+}
@@ -0,0 +1,208 @@
+package repp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// annealingOption is a way of resizing a PCR fragment's primers at their 3' ends
+type annealingOption struct {
+	// primers after resizing
+	primers []Primer
+
+	// ta is the annealing temperature of the resized primers
+	ta float64
+
+	// resized is the number of bp the primers were lengthened or shortened by
+	resized int
+
+	// screened is whether the resized primers passed the hairpin and off-target screens
+	screened bool
+
+	// warnings of the screens, ex: a near off-target
+	warnings []string
+}
+
+// annealingTemp is the annealing temperature of a pair of primers, the lower of their Tms
+func annealingTemp(primers []Primer) float64 {
+	return math.Min(primers[0].Tm, primers[1].Tm)
+}
+
+// annealingOptions returns the ways a PCR fragment's primers can be resized at their 3' ends,
+// see resizeOptions
+func annealingOptions(f *Frag, target string, conf *config.Config) (options []annealingOption) {
+	p := &primer3{seq: strings.ToUpper(target), templateMismatches: f.mismatchIndexes, config: conf}
+	return p.resizeOptions(f.Primers)
+}
+
+// resizeOptions returns the ways a pair of primers can be resized at their 3' ends, within
+// the primer length and Tm limits, and still be within the max allowed Tm difference.
+// The first option is the primers as they are.
+func (p *primer3) resizeOptions(primers []Primer) (options []annealingOption) {
+	conf := p.config
+
+	// each side's primer, resized by increasing amounts in each direction
+	var sides [2][]annealingOption
+	for side := range sides {
+		sides[side] = append(sides[side], annealingOption{primers: primers})
+		for _, bp := range []int{1, -1} {
+			resized := []Primer{primers[0], primers[1]}
+			for n := 1; p.resizePrimer(resized, side, bp); n++ {
+				if tm := resized[side].Tm; tm < conf.PcrPrimerMinTm || tm > conf.PcrPrimerMaxTm {
+					continue
+				}
+				sides[side] = append(sides[side], annealingOption{primers: []Primer{resized[0], resized[1]}, resized: n})
+			}
+		}
+	}
+
+	for _, left := range sides[0] {
+		for _, right := range sides[1] {
+			primers := []Primer{left.primers[0], right.primers[1]}
+			if primers[0].Range.end > primers[1].Range.start+1 {
+				continue // overlapping 3' ends
+			}
+			if maxDiff := conf.PcrMaxFwdRevPrimerTmDiff; maxDiff > 0 && left.resized+right.resized > 0 && math.Abs(primers[0].Tm-primers[1].Tm) > maxDiff {
+				continue
+			}
+			options = append(options, annealingOption{
+				primers: primers,
+				ta:      annealingTemp(primers),
+				resized: left.resized + right.resized,
+			})
+		}
+	}
+	return options
+}
+
+// shareAnnealingTemp resizes the primers of an assembly's PCR fragments so they all anneal
+// within a window of the same temperature, and the whole assembly can be amplified in one
+// thermocycler program. The window is chosen to resize the primers as little as possible.
+// Resized primers are screened for hairpins and off-targets again, like primer3's, and
+// the next best window is tried if any fail. It returns an error if there's no such window.
+func shareAnnealingTemp(frags []*Frag, target string, conf *config.Config) error {
+	window := conf.PcrAnnealingWindow
+	var pcrFrags []*Frag
+	var options [][]annealingOption
+	for _, f := range frags {
+		if f.fragType == pcr && len(f.Primers) == 2 {
+			pcrFrags = append(pcrFrags, f)
+			options = append(options, annealingOptions(f, target, conf))
+		}
+	}
+	if len(pcrFrags) == 0 {
+		return nil
+	}
+
+	for {
+		chosen := chooseAnnealingWindow(options, window)
+		if chosen == nil {
+			return fmt.Errorf("PCR fragments can't be amplified within a %.1f°C annealing window", window)
+		}
+
+		rejected := false
+		for i, f := range pcrFrags {
+			o := &options[i][chosen[i]]
+			if o.resized == 0 || o.screened {
+				continue
+			}
+			warnings, err := screenResizedPrimers(f, o.primers, conf)
+			if err != nil {
+				rlog.Debugf("not resizing the primers of %s for the shared annealing temperature: %v", f.ID, err)
+				options[i] = append(options[i][:chosen[i]:chosen[i]], options[i][chosen[i]+1:]...)
+				rejected = true
+				continue
+			}
+			o.screened, o.warnings = true, warnings
+		}
+		if rejected {
+			continue
+		}
+
+		for i, f := range pcrFrags {
+			o := options[i][chosen[i]]
+			if o.resized == 0 {
+				continue
+			}
+			for side, primer := range o.primers {
+				if primer.Seq != f.Primers[side].Seq {
+					o.primers[side].Notes = addNote(primer.Notes, resizeNote("resized for the shared annealing temperature", f.Primers[side], primer))
+				}
+			}
+			f.Primers = o.primers // a new slice, the primer cache is unchanged
+			for _, w := range o.warnings {
+				f.warn(w)
+			}
+		}
+		return nil
+	}
+}
+
+// chooseAnnealingWindow returns the index of each fragment's option in the annealing window
+// that resizes the primers the least, nil if there's no window all the fragments fit in.
+// A window starting at each annealing temperature the fragments can have is tried
+func chooseAnnealingWindow(options [][]annealingOption, window float64) (best []int) {
+	bestResized := 0
+	for _, fragOptions := range options {
+		for _, candidate := range fragOptions {
+			low, high := candidate.ta, candidate.ta+window
+			chosen := make([]int, len(options))
+			resized := 0
+			for i, opts := range options {
+				found := false
+				for j, o := range opts {
+					if o.ta >= low && o.ta <= high && (!found || o.resized < opts[chosen[i]].resized) {
+						chosen[i], found = j, true
+					}
+				}
+				if !found {
+					chosen = nil
+					break
+				}
+				resized += opts[chosen[i]].resized
+			}
+			if chosen != nil && (best == nil || resized < bestResized) {
+				best, bestResized = chosen, resized
+			}
+		}
+	}
+	return best
+}
+
+// screenResizedPrimers screens a fragment's resized primers as its primer3 primers were:
+// for hairpins above primer3's max and for off-targets in its template. It returns the
+// warnings of the screens, ex: a near off-target
+func screenResizedPrimers(f *Frag, primers []Primer, conf *config.Config) (warnings []string, err error) {
+	for side, primer := range primers {
+		if primer.Seq == f.Primers[side].Seq {
+			continue
+		}
+		if melt := hairpin(primer.Seq, conf); melt > conf.FragmentsMaxHairpinMelt {
+			return nil, fmt.Errorf("the resized primer %s has a %.1f°C hairpin", primer.Seq, melt)
+		}
+	}
+
+	screened := *f
+	screened.Primers, screened.Warnings = primers, nil
+	if err = screened.checkOffTargets(conf); err != nil {
+		return nil, err
+	}
+	return screened.Warnings, nil
+}
+
+// sharedAnnealingTemp returns the annealing temperature to amplify all of a solution's
+// PCR fragments at, the lowest of their annealing temperatures. It's 0 if there are none.
+func sharedAnnealingTemp(frags []*Frag) (ta float64) {
+	for _, f := range frags {
+		if f.fragType != pcr || len(f.Primers) != 2 {
+			continue
+		}
+		if fragTa := annealingTemp(f.Primers); ta == 0 || fragTa < ta {
+			ta = fragTa
+		}
+	}
+	return math.Round(ta*10) / 10
+}
@@ -0,0 +1,98 @@
+package repp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_shareAnnealingTemp(t *testing.T) {
+	// first fragment has an AT rich left primer and a GC rich right primer, the second is balanced
+	first := "ATTAGACTTAACATTGAATGCATACGTTAGCAATCG" + strings.Repeat("ACGT", 25) + "CGGAGCGCTGACCGGTACGCAGGCGCTCAG"
+	second := "GATCCTAGGCTAAGCTTGCAGTCAGTTACGGATCCAAGTCGAAC"
+	target := first + second
+
+	// pcrFrag is a PCR fragment with 20bp primers at the ends of [start, end)
+	pcrFrag := func(start, end int) *Frag {
+		left, right := target[start:start+20], reverseComplement(target[end-20:end])
+		return &Frag{
+			fragType: pcr,
+			Primers: []Primer{
+				{Seq: left, PrimingRegion: left, Strand: true, Tm: primerTm(left), Range: ranged{start, start + 20}},
+				{Seq: right, PrimingRegion: right, Tm: primerTm(right), Range: ranged{end - 21, end - 1}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		minLength int
+		maxLength int
+		wantErr   bool
+	}{
+		{"primers resized into a shared window", 15, 30, false},
+		{"no room to resize the primers", 20, 20, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.New()
+			c.PcrAnnealingWindow = 3
+			c.PcrMaxFwdRevPrimerTmDiff = 5
+			c.PcrPrimerMinLength = tt.minLength
+			c.PcrPrimerMaxLength = tt.maxLength
+
+			frags := []*Frag{pcrFrag(0, len(first)), {fragType: synthetic}, pcrFrag(len(first), len(target))}
+			unchanged := frags[2].Primers
+
+			err := shareAnnealingTemp(frags, target, c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("shareAnnealingTemp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			firstTa, secondTa := annealingTemp(frags[0].Primers), annealingTemp(frags[2].Primers)
+			if firstTa < secondTa-c.PcrAnnealingWindow || firstTa > secondTa+c.PcrAnnealingWindow {
+				t.Errorf("shareAnnealingTemp() annealing temps = %.1f, %.1f, want within %.1f", firstTa, secondTa, c.PcrAnnealingWindow)
+			}
+			if frags[2].Primers[0].Seq != unchanged[0].Seq || frags[2].Primers[1].Seq != unchanged[1].Seq {
+				t.Errorf("shareAnnealingTemp() resized the primers of a fragment already in the window: %+v", frags[2].Primers)
+			}
+			if !strings.Contains(frags[0].Primers[0].Notes, "shared annealing temperature") {
+				t.Errorf("shareAnnealingTemp() resized a primer without a note: %+v", frags[0].Primers[0])
+			}
+			want := firstTa
+			if secondTa < want {
+				want = secondTa
+			}
+			if got := sharedAnnealingTemp(frags); got < want-0.05 || got > want+0.05 {
+				t.Errorf("sharedAnnealingTemp() = %.1f, want %.1f", got, want)
+			}
+		})
+	}
+}
+
+func Test_chooseAnnealingWindow(t *testing.T) {
+	options := [][]annealingOption{
+		{{ta: 52}, {ta: 58, resized: 2}, {ta: 60, resized: 4}},
+		{{ta: 60}, {ta: 55, resized: 3}},
+	}
+
+	// resizing the first fragment's primers by 2bp is the least
+	if got, want := chooseAnnealingWindow(options, 3), []int{1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("chooseAnnealingWindow() = %v, want %v", got, want)
+	}
+
+	// once that option is rejected by the screens, the next best window is chosen
+	options[0] = append(options[0][:1:1], options[0][2:]...)
+	if got, want := chooseAnnealingWindow(options, 3), []int{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("chooseAnnealingWindow() after a rejection = %v, want %v", got, want)
+	}
+
+	if got := chooseAnnealingWindow([][]annealingOption{{{ta: 50}}, {{ta: 60}}}, 3); got != nil {
+		t.Errorf("chooseAnnealingWindow() = %v, want nil without a shared window", got)
+	}
+}
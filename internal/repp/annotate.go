@@ -116,7 +116,7 @@ func annotate(name, seq, output string, identity int, ungapped bool, dbs []DB, f
 		}
 		features = cleanedFeatures
 	} else {
-		features, err = blast(name, seq, false, 0, dbs, filters, identity, false)
+		features, err = blast(name, seq, false, 0, dbs, filters, nil, identity, false, nil)
 		handleErr(err)
 	}
 
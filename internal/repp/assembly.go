@@ -2,6 +2,7 @@ package repp
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 
@@ -28,8 +29,18 @@ type assembly struct {
 	pcrs int
 	// total number of synthetic nodes that will be needed to make this
 	synths int
+
+	// linear - the target is linear, so the last fragment doesn't anneal to the first
+	linear bool
 }
 
+// linearStartID and linearEndID are the IDs of the mock fragments before the first
+// and after the last fragment of a linear target's assembly
+const (
+	linearStartID = "linearStart"
+	linearEndID   = "linearEnd"
+)
+
 // String display method for an assembly
 func (a assembly) String() string {
 	res := ""
@@ -84,6 +95,17 @@ func (a assembly) coverage() int {
 	return bps
 }
 
+// belowMinIdentity returns the first fragment whose template is less than minIdentity %-identical
+// to the target, or nil if there's none. Fragments that aren't database matches are skipped.
+func (a assembly) belowMinIdentity(minIdentity float64) *Frag {
+	for _, f := range a.frags {
+		if f.matchRatio > 0 && f.matchRatio*100 < minIdentity {
+			return f
+		}
+	}
+	return nil
+}
+
 func (a assembly) isBetterThan(ref assembly) bool {
 	if a.len() < ref.len() {
 		return true
@@ -100,30 +122,45 @@ func (a assembly) isBetterThan(ref assembly) bool {
 
 // fill traverses frags in an assembly and adds primers or makes synthetic fragments where necessary.
 // It can fail. For example, a PCR Frag may have off-targets in the parent plasmid.
-func (a assembly) fill(target string, conf *config.Config) ([]*Frag, error) {
+// The primers of a previous plan are reused where they're still valid, if there's one
+func (a assembly) fill(target string, plan *priorPlan, conf *config.Config) ([]*Frag, error) {
 	// check for and error out if there are duplicate ends between fragments,
 	// ie unintended junctions between fragments that shouldn't be annealing
 	if hasDuplicate, left, right, dupSeq := duplicates(a.frags, conf.FragmentsMinHomology, conf.FragmentsMaxHomology); hasDuplicate {
 		return nil, fmt.Errorf("duplicate junction between %s and %s: %s", left, right, dupSeq)
 	}
 
+	// error out if a fragment's template is too different from the target
+	if f := a.belowMinIdentity(conf.FragmentsMinIdentity); f != nil {
+		return nil, fmt.Errorf("%s is %.1f%% identical to the target, below the minimum of %.1f%%",
+			f.ID, f.matchRatio*100, conf.FragmentsMinIdentity)
+	}
+
 	// edge case where a single Frag fills the whole target plasmid. Return just a single
 	// "fragment" (of circular type... it is misnomer) that matches the target sequence 100%
-	if a.len() == 1 && len(a.frags[0].Seq) >= len(target) {
+	if a.len() == 1 && len(a.frags[0].Seq) >= len(target) && !a.linear {
 		f := a.frags[0]
+		f.setMismatches(len(target))
 
 		return []*Frag{
 			{
-				ID:         f.ID,
-				uniqueID:   f.uniqueID,
-				Seq:        strings.ToUpper(f.Seq)[0:len(target)], // it may be longer
-				fragType:   circular,
-				matchRatio: f.matchRatio,
-				conf:       conf,
+				ID:                f.ID,
+				uniqueID:          f.uniqueID,
+				Seq:               strings.ToUpper(f.Seq)[0:len(target)], // it may be longer
+				Mismatches:        f.Mismatches,
+				MismatchesUnknown: f.MismatchesUnknown,
+				fragType:          circular,
+				matchRatio:        f.matchRatio,
+				conf:              conf,
 			},
 		}, nil
 	}
 
+	// synthesize the ends of a linear target that its first and last fragments can't reach
+	if a.linear {
+		a.frags = linearEnds(a.frags, target, conf)
+	}
+
 	// copy all the fragments. needed because ranges are mutated in assembly.fill,
 	// so distance to neightbor estimates become invalid after a neighbor is mutated
 	var origFrags []*Frag
@@ -137,8 +174,8 @@ func (a assembly) fill(target string, conf *config.Config) ([]*Frag, error) {
 	// will span it to the last and next fragments (if reachable)
 	for i, f := range a.frags {
 		// try and make primers for the fragment (need prev and next nodes)
-		prev := prevFragment(origFrags, i, target, conf)
-		next := nextFragment(origFrags, i, target, conf)
+		prev := prevFragment(origFrags, i, target, a.linear, conf)
+		next := nextFragment(origFrags, i, target, a.linear, conf)
 
 		needsPCR := f.fragType == circular ||
 			f.fragType == pcr ||
@@ -149,16 +186,24 @@ func (a assembly) fill(target string, conf *config.Config) ([]*Frag, error) {
 		if needsPCR {
 			// create primers for the Frag and add them to the Frag if it needs them
 			// to anneal to the adjacent fragments
-			if err := f.setPrimers(prev, next, target, conf); err != nil || len(f.Primers) < 2 {
+			if err := f.setPrimers(prev, next, target, plan, conf); err != nil || len(f.Primers) < 2 {
 				return nil, err
 			}
 			f.fragType = pcr // is now a pcr type
 		}
+		f.setMismatches(len(target))
 
 		// accumulate the prepared fragment
 		pcrFrags = append(pcrFrags, f)
 	}
 
+	// make sure the PCR fragments can all be amplified at the same annealing temperature
+	if conf.PcrAnnealingWindow > 0 {
+		if err := shareAnnealingTemp(pcrFrags, target, conf); err != nil {
+			return nil, err
+		}
+	}
+
 	// second loop to fill in gaps between fragments that need to be filled via synthesis
 	pcrAndSynthFrags := []*Frag{}
 	for i, f := range pcrFrags {
@@ -167,13 +212,16 @@ func (a assembly) fill(target string, conf *config.Config) ([]*Frag, error) {
 		}
 
 		// add synthesized fragments between this Frag and the next (if necessary)
-		next := nextFragment(pcrFrags, i, target, conf)
+		next := nextFragment(pcrFrags, i, target, a.linear, conf)
 		if synthedFrags := f.synthTo(next, target); synthedFrags != nil {
+			for _, s := range synthedFrags {
+				s.synthReason = fmt.Sprintf("the gap between %s and %s is too wide for their primers to span", f.ID, next.ID)
+			}
 			pcrAndSynthFrags = append(pcrAndSynthFrags, synthedFrags...)
 		}
 	}
 	// validate that fragments will anneal to one another
-	if err := validateJunctions(pcrAndSynthFrags, conf); err != nil {
+	if err := validateJunctions(pcrAndSynthFrags, !a.linear, conf); err != nil {
 		return pcrAndSynthFrags, err
 	}
 
@@ -181,6 +229,7 @@ func (a assembly) fill(target string, conf *config.Config) ([]*Frag, error) {
 }
 
 // createAssemblies builds up circular assemblies (unfilled lists of fragments that should be combinable)
+// or, if linear, assemblies that span the target from its first to its last bp
 //
 // It is created by traversing a DAG in forward order:
 //
@@ -189,7 +238,7 @@ func (a assembly) fill(target string, conf *config.Config) ([]*Frag, error) {
 //	  foreach otherFragment that fragment overlaps with + reachSynthCount more:
 //		   foreach assembly on fragment:
 //	      add otherFragment to the assembly to create a new assembly, store on otherFragment
-func createAssemblies(frags []*Frag, target string, targetLength int, features bool, conf *config.Config) []assembly {
+func createAssemblies(frags []*Frag, target string, targetLength int, features, linear bool, conf *config.Config) []assembly {
 	// sort by start index again
 	sort.Slice(frags, func(i, j int) bool {
 		return frags[i].start < frags[j].start
@@ -210,23 +259,57 @@ func createAssemblies(frags []*Frag, target string, targetLength int, features b
 					frags:  []*Frag{f.copy()},
 					synths: 0,
 					pcrs:   1,
+					linear: linear,
 				},
 			}
 		}
 		// create a starting assembly for each fragment containing just it
 		cost, adjustedCost := f.cost(true)
-		indexedAssemblies[i] = []assembly{
-			{
-				frags:        []*Frag{f.copy()}, // just self
-				cost:         cost,              // just PCR,
-				adjustedCost: adjustedCost,
-				synths:       0, // no synthetic frags at start
-				pcrs:         1,
-			},
+		seed := assembly{
+			frags:        []*Frag{f.copy()}, // just self
+			cost:         cost,              // just PCR,
+			adjustedCost: adjustedCost,
+			synths:       0, // no synthetic frags at start
+			pcrs:         1,
+			linear:       linear,
 		}
+		if linear && f.start > conf.PcrPrimerMaxEmbedLength {
+			// a linear target's first bps are synthesized if the primers can't reach them
+			synths, synthCost, adjustedSynthCost := spanSynths(f.start+conf.FragmentsMinHomology+1, conf)
+			seed.synths += synths
+			seed.cost += synthCost
+			seed.adjustedCost += adjustedSynthCost
+			if seed.len() > conf.FragmentsMaxCount {
+				continue
+			}
+		}
+		indexedAssemblies[i] = []assembly{seed}
 	}
 
 	finalAssemblies := map[string]assembly{}
+	addFinal := func(a assembly) {
+		id := a.assemblyHash()
+		if _, exists := finalAssemblies[id]; !exists {
+			rlog.Debugf("Adding final assembly: %v", a)
+			finalAssemblies[id] = a
+		} else {
+			rlog.Debugf("Discard %v - was already found", a)
+		}
+	}
+
+	// a linear target's assemblies can be completed from any fragment by synthesizing its last bps
+	if linear {
+		for _, seeds := range indexedAssemblies {
+			for _, a := range seeds {
+				if tailed, ok := a.withTail(targetLength, conf); ok {
+					addFinal(tailed)
+				}
+			}
+		}
+	}
+
+	// the partial assemblies kept are bounded by the memory limit, see --max-ram
+	maxPartial, partial, warnedPartial := maxPartialAssemblies(), len(frags), false
 
 	for i, f := range frags { // for every Frag in the list of increasing start index frags
 		for _, j := range f.reach(frags, i, features) { // for every overlapping fragment + reach more
@@ -238,22 +321,30 @@ func createAssemblies(frags []*Frag, target string, targetLength int, features b
 					continue
 				}
 
-				if complete { // we've circularized a plasmid, it's ready for filling
-					newAssemblyID := newAssembly.assemblyHash()
-					if _, exists := finalAssemblies[newAssemblyID]; !exists {
-						rlog.Debugf("Adding final assembly: %v", newAssembly)
-						finalAssemblies[newAssemblyID] = newAssembly
-					} else {
-						rlog.Debugf("Discard %v - was already found", newAssembly)
+				if linear {
+					// complete a linear assembly by synthesizing the rest of the target, if it needs any
+					if tailed, ok := newAssembly.withTail(targetLength, conf); ok {
+						addFinal(tailed)
 					}
-				} else {
+				} else if complete { // we've circularized a plasmid, it's ready for filling
+					addFinal(newAssembly)
+				}
+				if !complete {
 					// the new fragment was created by adding the j-th fragment
 					// so when it's processed it will be extended started with j-th frag
 					// this works because j > i so indexedAssemblies[j] is still in the queue
 
 					// before considering it check that it has not already reached the allowed number of fragments
+					if maxPartial > 0 && partial >= maxPartial {
+						if !warnedPartial {
+							rlog.Warnf("the assembly search reached the %d partial assemblies of its memory limit, the rest aren't extended", maxPartial)
+							warnedPartial = true
+						}
+						continue
+					}
 					if newAssembly.len() < conf.FragmentsMaxCount {
 						indexedAssemblies[j] = append(indexedAssemblies[j], newAssembly)
+						partial++
 					} else {
 						// if a is already at the max length and it's not complete so do not even attempt to extend this anymore
 						rlog.Debugf("Abandon candidate %v because it already reached the max fragments count: %d\n",
@@ -280,13 +371,25 @@ func createAssemblies(frags []*Frag, target string, targetLength int, features b
 		conf:     conf,
 	}
 	cost, adjustedCost := mockStart.costTo(mockEnd)
-	synths := mockStart.synthTo(mockEnd, target)
+	var synths []*Frag
+	if linear {
+		synths = synthSpan(mockStart.ID+"-"+mockEnd.ID, 0, len(target), target, conf)
+	} else {
+		synths = mockStart.synthTo(mockEnd, target)
+	}
+	if len(finalAssemblies) == 0 {
+		// otherwise fillAssemblies records why it's picked over the assemblies of database fragments
+		for _, s := range synths {
+			s.synthReason = "no assembly of database fragments spans the target"
+		}
+	}
 	mockSynthAssembly := assembly{
 		frags:        synths,
 		cost:         cost,
 		adjustedCost: adjustedCost,
 		synths:       len(synths),
 		pcrs:         0,
+		linear:       linear,
 	}
 	if _, mockAssemblyFound := finalAssemblies[mockSynthAssembly.assemblyHash()]; mockAssemblyFound {
 		rlog.Errorf("Found an assembly similar to the mock synthesized assembly: %v", mockSynthAssembly)
@@ -325,6 +428,10 @@ func extendAssembly(currentAssembly assembly, f *Frag, maxCount, targetLength in
 
 	// check if we could complete an assembly with this new Frag
 	complete := end >= currentAssemblyStart+targetLength-1
+	if currentAssembly.linear {
+		// a linear assembly is complete once the primers can reach the target's last bp
+		complete = end >= targetLength-1-f.conf.PcrPrimerMaxEmbedLength
+	}
 
 	// check if this is the first fragment annealing to itself
 	selfAnnealing := f.uniqueID == first.uniqueID
@@ -392,19 +499,106 @@ func extendAssembly(currentAssembly assembly, f *Frag, maxCount, targetLength in
 		adjustedCost:  currentAssembly.adjustedCost + adjustedCost,
 		synths:        currentAssembly.synths + synths,
 		pcrs:          currentAssembly.pcrs + 1,
+		linear:        currentAssembly.linear,
 	}, complete, nil
 }
 
+// withTail returns the linear assembly with the synthetic fragments needed to get from its
+// last fragment to the target's last bp, and whether it's within the max fragment count
+func (a assembly) withTail(targetLength int, conf *config.Config) (assembly, bool) {
+	if gap := targetLength - 1 - a.lastFrag().end; gap > conf.PcrPrimerMaxEmbedLength {
+		synths, cost, adjustedCost := spanSynths(gap+conf.FragmentsMinHomology+1, conf)
+		a.synths += synths
+		a.cost += cost
+		a.adjustedCost += adjustedCost
+	}
+	return a, a.len() <= conf.FragmentsMaxCount
+}
+
+// linearEnds returns the fragments of a linear target's assembly with synthetic fragments
+// before and after them for the target's bps that their primers can't reach
+func linearEnds(frags []*Frag, target string, conf *config.Config) []*Frag {
+	first, last := frags[0], frags[len(frags)-1]
+	homology := conf.FragmentsMinHomology
+
+	var withEnds []*Frag
+	if first.start > conf.PcrPrimerMaxEmbedLength {
+		withEnds = synthSpan(linearStartID+"-"+first.ID, 0, first.start+homology+1, target, conf)
+		for _, s := range withEnds {
+			s.synthReason = fmt.Sprintf("the start of the linear target is too far from %s for its primers to reach", first.ID)
+		}
+	}
+	withEnds = append(withEnds, frags...)
+	if len(target)-1-last.end > conf.PcrPrimerMaxEmbedLength {
+		end := synthSpan(last.ID+"-"+linearEndID, last.end-homology, len(target), target, conf)
+		for _, s := range end {
+			s.synthReason = fmt.Sprintf("the end of the linear target is too far from %s for its primers to reach", last.ID)
+		}
+		withEnds = append(withEnds, end...)
+	}
+	return withEnds
+}
+
+// synthSpan returns the synthetic fragments that span the target from start to end (exclusive),
+// evenly split and each with the min homology to the next
+func synthSpan(id string, start, end int, target string, conf *config.Config) (synths []*Frag) {
+	count, _, _ := spanSynths(end-start, conf)
+	homology := conf.FragmentsMinHomology
+	for i := 0; i < count; i++ {
+		synthStart := start + i*(end-start)/count
+		synthEnd := end
+		if i+1 < count {
+			synthEnd = start + (i+1)*(end-start)/count + homology
+		}
+		synths = append(synths, &Frag{
+			ID:       fmt.Sprintf("%s-synthesis-%d", id, i+1),
+			Seq:      target[synthStart:synthEnd],
+			start:    synthStart,
+			end:      synthEnd,
+			fragType: synthetic,
+			conf:     conf,
+		})
+	}
+	return synths
+}
+
+// spanSynths returns the number, cost and adjusted cost of the synthetic fragments
+// that span length bp of the target
+func spanSynths(length int, conf *config.Config) (count int, cost, adjustedCost float64) {
+	count = 1
+	if conf.SyntheticMaxLength > conf.FragmentsMinHomology {
+		// each fragment after the first repeats the homology with the one before it
+		count = int(math.Ceil(float64(length-conf.FragmentsMinHomology) / float64(conf.SyntheticMaxLength-conf.FragmentsMinHomology)))
+		if count < 1 {
+			count = 1
+		}
+	}
+	cost = conf.SynthFragmentCost(length)
+	return count, cost, cost * float64(conf.GetSyntheticFragmentFactor())
+}
+
 // nextFragment returns the fragment that's one beyond the one passed.
 // The fragments are considered to be part of a "circular" sequence
 // simulated by concatenating the sequence to itself
 // the next fragment after the last from the list is based on the
-// first fragment from the list by adding target sequence length to its start and end
-func nextFragment(frags []*Frag, i int, target string, conf *config.Config) *Frag {
+// first fragment from the list by adding target sequence length to its start and end.
+// The last fragment of a linear target is followed by a mock one at the target's end
+func nextFragment(frags []*Frag, i int, target string, linear bool, conf *config.Config) *Frag {
 	if i < len(frags)-1 {
 		return frags[i+1]
 	}
 
+	if linear {
+		// overlap it by the min homology so no homology is added to reach it
+		last := frags[len(frags)-1]
+		return &Frag{
+			uniqueID: linearEndID,
+			start:    last.end - conf.FragmentsMinHomology,
+			end:      last.end + conf.FragmentsMinHomology,
+			conf:     conf,
+		}
+	}
+
 	// mock up a next fragment that's to the right of this terminal Frag
 	return &Frag{
 		start: frags[0].start + len(target),
@@ -414,16 +608,31 @@ func nextFragment(frags []*Frag, i int, target string, conf *config.Config) *Fra
 }
 
 // fillAssemblies fills in assemblies and returns the pareto optimal solutions.
-func fillAssemblies(target string, assemblies []assembly, selectedAssembliesStart int, conf *config.Config) (solutions []*assembly) {
+func fillAssemblies(target string, assemblies []assembly, selectedAssembliesStart int, plan *priorPlan, synthFragsDB *oligosDB, conf *config.Config) (solutions []*assembly) {
 	var filled []*assembly
+	var unfilled int  // assemblies ranked above the current one that failed to fill
+	var fillErr error // the first of their errors
+	var rejected int  // assemblies rejected for a template below the min identity
+	lowIdentity := make(map[string]float64)
 	for ai, a := range assemblies {
 		rlog.Debugf("Try to fill a[%d]: %v\n", selectedAssembliesStart+ai+1, a)
-		filledFragments, err := a.fill(target, conf)
+		if f := a.belowMinIdentity(conf.FragmentsMinIdentity); f != nil {
+			lowIdentity[f.ID] = f.matchRatio
+			rejected++
+		}
+		filledFragments, err := a.fill(target, plan, conf)
 		if err != nil || filledFragments == nil || len(filledFragments) == 0 {
 			// this error can be pretty verbose so I am only displaying it in debug mode
 			rlog.Debugf("Error filling assembly a[%d]: %v because: %v\n",
 				selectedAssembliesStart+ai+1, a, err)
+			unfilled++
+			if fillErr == nil && err != nil {
+				fillErr = err
+			}
 		} else {
+			// PCR the synthetic fragments out of larger ones that were already synthesized
+			reuseSynthFrags(target, filledFragments, synthFragsDB, a.linear, conf)
+
 			assemblyCost := 0.0
 			assemblyAdjustedCost := 0.0
 			npcrs := 0
@@ -439,6 +648,9 @@ func fillAssemblies(target string, assemblies []assembly, selectedAssembliesStar
 				assemblyCost += fCost
 				assemblyAdjustedCost += fAdjustedCost
 			}
+			if npcrs == 0 {
+				explainFullSynthesis(filledFragments, selectedAssembliesStart+ai, unfilled, fillErr)
+			}
 			filledAssembly := &assembly{
 				frags:        filledFragments,
 				cost:         assemblyCost,
@@ -452,20 +664,64 @@ func fillAssemblies(target string, assemblies []assembly, selectedAssembliesStar
 			filled = append(filled, filledAssembly)
 		}
 	}
+
+	if rejected > 0 {
+		templates := make([]string, 0, len(lowIdentity))
+		for id, ratio := range lowIdentity {
+			templates = append(templates, fmt.Sprintf("%s (%.1f%%)", id, ratio*100))
+		}
+		sort.Strings(templates)
+		rlog.Warnf("%d assemblies were rejected for templates below the minimum identity of %.1f%%: %s",
+			rejected, conf.FragmentsMinIdentity, strings.Join(templates, ", "))
+	}
 	return filled
 }
 
+// explainFullSynthesis records why an assembly of only synthetic fragments was filled, at rank
+// (0-based) among the assemblies, when unfilled of the assemblies ranked above it in its batch
+// failed to fill, the first with fillErr. A reason recorded by createAssemblies is kept
+func explainFullSynthesis(synths []*Frag, rank, unfilled int, fillErr error) {
+	reason := ""
+	switch {
+	case unfilled > 0 && fillErr != nil:
+		reason = fmt.Sprintf("%d assemblies of database fragments ranked above it couldn't be filled, ex: %v", unfilled, fillErr)
+	case unfilled > 0:
+		reason = fmt.Sprintf("%d assemblies of database fragments ranked above it couldn't be filled", unfilled)
+	case rank == 0:
+		reason = "it ranked above every assembly of database fragments by fragment count, then cost"
+	default:
+		reason = "an alternative to the assemblies of database fragments ranked above it"
+	}
+	for _, s := range synths {
+		if s.fragType == synthetic && s.synthReason == "" {
+			s.synthReason = reason
+		}
+	}
+}
+
 // prevFragment returns the fragment that's one before the current one.
 // The fragments are considered to be part of a "circular" sequence
 // simulated by concatenating the sequence to itself
 // the prev fragment of the first from the list is based on the
 // last fragment from the list by subtracting the length of the target sequence
-// from its start and end
-func prevFragment(frags []*Frag, i int, target string, conf *config.Config) *Frag {
+// from its start and end. The first fragment of a linear target is preceded by a mock
+// one at the target's start
+func prevFragment(frags []*Frag, i int, target string, linear bool, conf *config.Config) *Frag {
 	if i > 0 {
 		return frags[i-1]
 	}
 
+	if linear {
+		// overlap it by the min homology so no homology is added to reach it
+		first := frags[0]
+		return &Frag{
+			uniqueID: linearStartID,
+			start:    first.start - conf.FragmentsMinHomology,
+			end:      first.start + conf.FragmentsMinHomology,
+			conf:     conf,
+		}
+	}
+
 	// mock up a next fragment that's to the right of this terminal Frag
 	return &Frag{
 		start: frags[len(frags)-1].start - len(target),
@@ -478,21 +734,54 @@ func prevFragment(frags []*Frag, i int, target string, conf *config.Config) *Fra
 // them have unintended homology, or "duplicate homology".
 func duplicates(frags []*Frag, min, max int) (isDup bool, first, second, dup string) {
 	c := len(frags) // Frag count
-	for i, f := range frags {
-		// check to make sure the fragment doesn't anneal to itself
-		if c > 1 {
-			if selfJ := f.selfJunction(min, max); selfJ != "" && len(selfJ) < len(f.Seq) {
-				return true, f.ID, f.ID, selfJ
-			}
+	if c >= parallelJunctionsMinFrags {
+		return parallelDuplicates(frags, min, max)
+	}
+	for i := range frags {
+		if second, dup := fragDuplicate(frags, i, min, max); dup != "" {
+			return true, frags[i].ID, second, dup
 		}
+	}
 
-		for j := 2; j < c; j++ { // skip next Frag, i+1 is supposed to anneal to i
-			junc := f.junction(frags[(j+i)%c], min, max)
-			if junc != "" {
-				return true, f.ID, frags[(j+i)%c].ID, junc
-			}
+	return false, "", "", ""
+}
+
+// parallelJunctionsMinFrags is the fewest fragments whose junctions are checked in parallel,
+// ex: the hundreds of a library's fragments. Fewer aren't worth the goroutines
+const parallelJunctionsMinFrags = 32
+
+// parallelDuplicates is duplicates with each fragment's junctions checked in parallel. The
+// duplicate of the first fragment with one is returned, as it is when they're checked in order
+func parallelDuplicates(frags []*Frag, min, max int) (isDup bool, first, second, dup string) {
+	seconds, dups := make([]string, len(frags)), make([]string, len(frags))
+	parallelFor(len(frags), func(i int) {
+		seconds[i], dups[i] = fragDuplicate(frags, i, min, max)
+	})
+	for i, dup := range dups {
+		if dup != "" {
+			return true, frags[i].ID, seconds[i], dup
 		}
 	}
-
 	return false, "", "", ""
 }
+
+// fragDuplicate returns the ID of the fragment the i-th anneals to, other than the next one,
+// and the junction between them. Empty if there's none
+func fragDuplicate(frags []*Frag, i, min, max int) (second, dup string) {
+	c := len(frags)
+	f := frags[i]
+	// check to make sure the fragment doesn't anneal to itself
+	if c > 1 {
+		if selfJ := f.selfJunction(min, max); selfJ != "" && len(selfJ) < len(f.Seq) {
+			return f.ID, selfJ
+		}
+	}
+
+	for j := 2; j < c; j++ { // skip next Frag, i+1 is supposed to anneal to i
+		junc := f.junction(frags[(j+i)%c], min, max)
+		if junc != "" {
+			return frags[(j+i)%c].ID, junc
+		}
+	}
+	return "", ""
+}
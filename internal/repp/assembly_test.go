@@ -3,6 +3,7 @@ package repp
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"reflect"
 	"testing"
 
@@ -403,3 +404,171 @@ func Test_assembly_duplicates(t *testing.T) {
 		})
 	}
 }
+
+func Test_assembly_belowMinIdentity(t *testing.T) {
+	a := assembly{frags: []*Frag{
+		{ID: "input", matchRatio: 0},
+		{ID: "exact", matchRatio: 1},
+		{ID: "imperfect", matchRatio: 0.97},
+	}}
+
+	tests := []struct {
+		name        string
+		minIdentity float64
+		want        string
+	}{
+		{"not enforced", 0, ""},
+		{"all above the minimum", 95, ""},
+		{"imperfect template below the minimum", 98, "imperfect"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if f := a.belowMinIdentity(tt.minIdentity); f != nil {
+				got = f.ID
+			}
+			if got != tt.want {
+				t.Errorf("assembly.belowMinIdentity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fragments that start at the same place on a circular target, but on different strands,
+// aren't the first fragment's copy across the zero index
+func Test_extendAssembly_zeroIndex(t *testing.T) {
+	c := config.New()
+	c.PcrMinFragLength = 0
+
+	seqLen := 100
+	first := match{entry: "p1", queryStart: 10, queryEnd: 60, subjectStart: 0}
+	copied := first
+	copied.queryStart, copied.queryEnd = first.queryStart+seqLen, first.queryEnd+seqLen
+	revComp := copied
+	revComp.subjectRevCompMatch = true
+
+	newFrag := func(m match) *Frag {
+		return &Frag{ID: m.entry, uniqueID: m.matchID(seqLen), fragType: pcr, start: m.queryStart, end: m.queryEnd, conf: c}
+	}
+	second := newFrag(match{entry: "p2", queryStart: 40, queryEnd: 130})
+	a := assembly{frags: []*Frag{newFrag(first), second}}
+
+	tests := []struct {
+		name             string
+		next             *Frag
+		wantSelfAnnealed bool
+	}{
+		{"first fragment's copy", newFrag(copied), true},
+		{"reverse complement match at the copy's position", newFrag(revComp), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, complete, err := extendAssembly(a, tt.next, 5, seqLen, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !complete {
+				t.Error("extendAssembly() should complete the assembly")
+			}
+			if got.selfAnnealing != tt.wantSelfAnnealed {
+				t.Errorf("extendAssembly() selfAnnealing = %t, want %t", got.selfAnnealing, tt.wantSelfAnnealed)
+			}
+		})
+	}
+}
+
+func Test_createAssemblies_linear(t *testing.T) {
+	c := config.New()
+	c.PcrAnnealingWindow = 0
+	c.SyntheticMaxLength = 300
+
+	target := randomSeq(1000, 3)
+	f := &Frag{ID: "p1", uniqueID: "p1", Seq: target[300:700], fragType: pcr, start: 300, end: 699, matchRatio: 1, conf: c}
+	homology := c.FragmentsMinHomology
+
+	assemblies := createAssemblies([]*Frag{f}, target, len(target), false, true, c)
+	if len(assemblies) != 2 {
+		t.Fatalf("createAssemblies() = %v, want the assembly of p1 and a synthetic one", assemblies)
+	}
+	for _, a := range assemblies {
+		if !a.linear {
+			t.Errorf("createAssemblies() = %v, want a linear assembly", a)
+		}
+		if a.firstFrag().ID != "p1" {
+			continue
+		}
+		head, _, _ := spanSynths(f.start+homology+1, c)
+		tail, _, _ := spanSynths(len(target)-1-f.end+homology+1, c)
+		if a.synths != head+tail {
+			t.Errorf("createAssemblies() synths = %d, want %d to synthesize both ends of the target", a.synths, head+tail)
+		}
+	}
+
+	// the ends of the target are synthesized, and the last fragment doesn't anneal to the first
+	withEnds := linearEnds([]*Frag{f}, target, c)
+	first, last := withEnds[0], withEnds[len(withEnds)-1]
+	if first.start != 0 || first.fragType != synthetic || last.end != len(target) || last.fragType != synthetic {
+		t.Errorf("linearEnds() = %v, want synthetic fragments from the target's start and to its end", withEnds)
+	}
+	if err := validateJunctions(withEnds, false, c); err != nil {
+		t.Error(err)
+	}
+	if j := last.junction(first, homology, c.FragmentsMaxHomology+1); j != "" {
+		t.Errorf("linearEnds() last fragment anneals to the first by %s", j)
+	}
+
+	for _, a := range assemblies {
+		if a.pcrs > 0 {
+			continue // filling the PCR fragment needs primer3
+		}
+		filled, err := a.fill(target, nil, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var seq string
+		for i, frag := range filled {
+			if i == 0 {
+				seq = frag.Seq
+			} else {
+				seq += frag.Seq[homology:]
+			}
+		}
+		if seq != target {
+			t.Errorf("fill() = %v, want the synthetic fragments to span the target once", filled)
+		}
+		if j := filled[len(filled)-1].junction(filled[0], homology, c.FragmentsMaxHomology+1); j != "" {
+			t.Errorf("fill() last fragment anneals to the first by %s", j)
+		}
+	}
+}
+
+func Test_parallelDuplicates(t *testing.T) {
+	// a library's worth of fragments, each joined to the next by its last 20bp
+	r := rand.New(rand.NewSource(7))
+	randomSeq := func(n int) string {
+		bases := make([]byte, n)
+		for i := range bases {
+			bases[i] = "ACGT"[r.Intn(4)]
+		}
+		return string(bases)
+	}
+	n := 2 * parallelJunctionsMinFrags
+	ends := make([]string, n)
+	for i := range ends {
+		ends[i] = randomSeq(20)
+	}
+	frags := make([]*Frag, n)
+	for i := range frags {
+		frags[i] = &Frag{ID: fmt.Sprintf("frag%d", i), Seq: ends[(i+n-1)%n] + randomSeq(60) + ends[i]}
+	}
+	if isDup, first, second, dup := duplicates(frags, 15, 25); isDup {
+		t.Fatalf("duplicates() = %s, %s, %s, want none", first, second, dup)
+	}
+
+	// fragment 40 ends like fragment 9, so it anneals to fragment 10 too
+	frags[40].Seq = frags[40].Seq[:80] + ends[9]
+	isDup, first, second, dup := duplicates(frags, 15, 25)
+	if !isDup || first != "frag40" || second != "frag10" || dup != ends[9] {
+		t.Errorf("duplicates() = %v, %s, %s, %s, want the junction of frag40 and frag10", isDup, first, second, dup)
+	}
+}
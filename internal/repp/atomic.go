@@ -0,0 +1,99 @@
+package repp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// outputFooterPrefix starts the last line of CSV outputs. The rest of the line is the
+// hex SHA-256 of everything before it, so tools can check the output is complete
+const outputFooterPrefix = "# complete, sha256: "
+
+// atomicFile is an output written to a temporary file next to it, and renamed into place
+// once it's complete, so a crash mid-write never leaves a truncated output behind
+type atomicFile struct {
+	// tmp is the temporary file being written
+	tmp *os.File
+
+	// filename of the output
+	filename string
+
+	// hash of everything written so far, for the footer
+	hash hash.Hash
+}
+
+// createAtomic creates a temporary file for an output in the output's directory
+func createAtomic(filename string) (*atomicFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{tmp: tmp, filename: filename, hash: sha256.New()}, nil
+}
+
+// Write writes to the temporary file
+func (f *atomicFile) Write(p []byte) (int, error) {
+	n, err := f.tmp.Write(p)
+	f.hash.Write(p[:n])
+	return n, err
+}
+
+// writeFooter writes the footer line with the SHA-256 of everything written before it
+func (f *atomicFile) writeFooter() error {
+	_, err := fmt.Fprintf(f.tmp, "%s%x\n", outputFooterPrefix, f.hash.Sum(nil))
+	return err
+}
+
+// close renames the temporary file to the output's name if *err is nil, or removes it
+// otherwise. *err is set if the rename fails
+func (f *atomicFile) close(err *error) {
+	closeErr := f.tmp.Close()
+	if *err == nil && closeErr == nil {
+		closeErr = os.Chmod(f.tmp.Name(), 0644)
+	}
+	if *err == nil && closeErr == nil {
+		closeErr = os.Rename(f.tmp.Name(), f.filename)
+	}
+	if *err == nil {
+		*err = closeErr
+	}
+	if *err != nil {
+		os.Remove(f.tmp.Name())
+	}
+}
+
+// writeFileAtomic writes an output in one piece, see atomicFile
+func writeFileAtomic(filename string, contents []byte) (err error) {
+	f, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer f.close(&err)
+
+	_, err = f.Write(contents)
+	return err
+}
+
+// stripFooter checks the footer of a CSV output and returns the output without it.
+// It fails if the footer is missing or its SHA-256 doesn't match, ex: the output
+// was truncated or edited
+func stripFooter(contents []byte) ([]byte, error) {
+	trimmed := bytes.TrimSuffix(contents, []byte("\n"))
+	last := bytes.LastIndexByte(trimmed, '\n') + 1
+	footer := string(trimmed[last:])
+	if !strings.HasPrefix(footer, outputFooterPrefix) {
+		return nil, fmt.Errorf("no footer, the output is incomplete")
+	}
+
+	sum := sha256.Sum256(contents[:last])
+	if strings.TrimPrefix(footer, outputFooterPrefix) != hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("the footer's SHA-256 doesn't match, the output is incomplete or was changed")
+	}
+	return contents[:last], nil
+}
@@ -0,0 +1,63 @@
+package repp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_atomicFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "out-strategy.csv")
+
+	write := func(fail bool) (err error) {
+		f, err := createAtomic(filename)
+		if err != nil {
+			return err
+		}
+		defer f.close(&err)
+
+		fmt.Fprintf(f, "# Solution 1\nFrag ID,Size\nout_1_pcr,1200\n")
+		if fail {
+			return errors.New("crashed mid-write")
+		}
+		return f.writeFooter()
+	}
+
+	// a failed write leaves nothing behind
+	if err := write(true); err == nil {
+		t.Fatal("write() should fail")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("failed write left %d files behind", len(entries))
+	}
+
+	if err := write(false); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("write left %d files, want only the output", len(entries))
+	}
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := stripFooter(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Solution 1\nFrag ID,Size\nout_1_pcr,1200\n"; string(body) != want {
+		t.Errorf("stripFooter() = %q, want %q", body, want)
+	}
+
+	// truncated and edited outputs fail the check
+	if _, err = stripFooter(contents[:20]); err == nil {
+		t.Error("stripFooter() of a truncated output should fail")
+	}
+	edited := append([]byte("# Solution 2\n"), contents[13:]...)
+	if _, err = stripFooter(edited); err == nil {
+		t.Error("stripFooter() of an edited output should fail")
+	}
+}
@@ -0,0 +1,196 @@
+package repp
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// benchlingFeatureKeys maps the kinds of track features to GenBank feature keys
+var benchlingFeatureKeys = map[string]string{
+	"primer":   "primer_bind",
+	"junction": "misc_feature",
+}
+
+// ExportBenchling writes the Benchling bulk import files of a solution of a JSON output,
+// next to the output: a GenBank file with the target, annotated with the solution's
+// fragments, primer binding sites and junctions, and a record per fragment, and an oligos
+// CSV of its primers with their metadata.
+func ExportBenchling(filename string, solution int, primersDBLocations, synthFragsDBLocations []string) {
+	out, err := readOutput(filename)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if solution < 1 || solution > len(out.Solutions) {
+		rlog.Fatalf("no solution %d in %s, it has %d", solution, filename, len(out.Solutions))
+	}
+
+	conf := config.New()
+	names, err := newNamingMap(conf.NamingMap)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	primersDB, synthFragsDB, err := readOligoDBs(primersDBLocations, synthFragsDBLocations, conf)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	reagentIDs := newReagentIDs(primersDB, synthFragsDB, names)
+
+	s := out.Solutions[solution-1]
+
+	gbFilename := solutionFilename(filename, solution, "-benchling.gb")
+	if err = writeFileAtomic(gbFilename, []byte(benchlingGenbank(out.Target, out.TargetSeq, s))); err != nil {
+		rlog.Fatal(err)
+	}
+	oligosFilename := solutionFilename(filename, solution, "-benchling-oligos.csv")
+	if err = writeBenchlingOligos(oligosFilename, s, reagentIDs); err != nil {
+		rlog.Fatal(err)
+	}
+	rlog.Infof("wrote %s and %s", gbFilename, oligosFilename)
+}
+
+// locateFragments returns copies of the fragments of a solution read from a JSON output,
+// which lacks their positions, positioned on the target by their sequences along with
+// their primers. Fragments and primers that aren't in the target, ex: templates with
+// mismatches, are left out
+func locateFragments(s Solution, targetSeq string) (located []*Frag) {
+	template := newCircularSeq(targetSeq)
+	for _, frag := range s.Fragments {
+		f := *frag
+		seq := strings.ToUpper(f.Seq)
+		if seq == "" || len(seq) > len(targetSeq) {
+			continue
+		}
+		if i := template.indexFrom(seq, 0); i >= 0 {
+			f.start, f.end = i, i+len(seq)-1
+		} else if i = template.indexFrom(reverseComplement(seq), 0); i >= 0 {
+			f.start, f.end, f.revCompFlag = i, i+len(seq)-1, true
+		} else {
+			continue
+		}
+
+		// primers may have 5' tails past the fragment's ends
+		from := f.start - len(targetSeq)/2
+		if from < 0 {
+			from = 0
+		}
+		f.Primers = nil
+		for _, p := range frag.Primers {
+			site := strings.ToUpper(p.Seq)
+			if !p.Strand {
+				site = reverseComplement(site)
+			}
+			j := template.indexFrom(site, from)
+			if j < 0 {
+				continue
+			}
+			if p.Strand {
+				p.Range = ranged{j, j + len(site)}
+			} else {
+				p.Range = ranged{j - 1, j + len(site) - 1}
+			}
+			f.Primers = append(f.Primers, p)
+		}
+		located = append(located, &f)
+	}
+	return located
+}
+
+// benchlingGenbank returns a GenBank file with the target, annotated with the solution's
+// fragments, primer binding sites and junctions, and a record of each fragment's product
+func benchlingGenbank(target, targetSeq string, s Solution) string {
+	name := strings.Join(strings.Fields(target), "_")
+	var gb strings.Builder
+
+	gb.WriteString(genbankLocus(name, len(targetSeq), true))
+	gb.WriteString(fmt.Sprintf("DEFINITION  %s assembled from %d fragments.\n", target, len(s.Fragments)))
+	gb.WriteString("FEATURES             Location/Qualifiers\n")
+	located := Solution{Fragments: locateFragments(s, targetSeq)}
+	for _, feat := range solutionTrackFeatures(located, len(targetSeq)) {
+		var spans []string
+		for _, span := range trackSpans(feat.start, feat.end, len(targetSeq)) {
+			spans = append(spans, fmt.Sprintf("%d..%d", span[0]+1, span[1]))
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		location := strings.Join(spans, ",")
+		if len(spans) > 1 {
+			location = "join(" + location + ")"
+		}
+		if feat.strand == '-' {
+			location = "complement(" + location + ")"
+		}
+		key := benchlingFeatureKeys[feat.kind]
+		if key == "" {
+			key = "misc_feature"
+		}
+		gb.WriteString(fmt.Sprintf("     %-16s%s\n", key, location))
+		gb.WriteString(fmt.Sprintf("                     /label=\"%s\"\n", feat.name))
+	}
+	gb.WriteString(genbankOrigin(strings.ToLower(targetSeq)))
+
+	for i, f := range s.Fragments {
+		seq := f.PCRSeq
+		if seq == "" {
+			seq = f.Seq
+		}
+		if seq == "" {
+			continue
+		}
+		gb.WriteString(genbankLocus(fmt.Sprintf("%s_fragment%d", name, i+1), len(seq), f.fragType == circular))
+		gb.WriteString(fmt.Sprintf("DEFINITION  %s fragment %s of %s.\n", f.fragType, f.ID, target))
+		gb.WriteString("FEATURES             Location/Qualifiers\n")
+		gb.WriteString(genbankOrigin(strings.ToLower(seq)))
+	}
+	return gb.String()
+}
+
+// writeBenchlingOligos writes the primers of a solution as a Benchling oligo bulk import
+// CSV: their names and bases, and their metadata as extra columns
+func writeBenchlingOligos(filename string, s Solution, reagentIDs *reagentIDs) (err error) {
+	oligosFile, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer oligosFile.close(&err)
+
+	w := csv.NewWriter(oligosFile)
+	if err = w.Write([]string{"Name", "Bases", "Fragment", "Direction", "Tm", "GC", "Priming Region", "Plate", "Well", "Notes"}); err != nil {
+		return err
+	}
+	written := make(map[string]bool)
+	for _, f := range s.Fragments {
+		for _, p := range f.Primers {
+			o := reagentIDs.primer(p.Seq)
+			if o.isEmpty() || written[o.id] {
+				continue
+			}
+			written[o.id] = true
+
+			direction := "FWD"
+			if !p.Strand {
+				direction = "REV"
+			}
+			if err = w.Write([]string{
+				o.id,
+				p.Seq,
+				f.ID,
+				direction,
+				fmt.Sprintf("%.1f", p.Tm),
+				fmt.Sprintf("%.1f", p.GC),
+				p.PrimingRegion,
+				o.plate,
+				o.well,
+				p.Notes,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
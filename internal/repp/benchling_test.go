@@ -0,0 +1,64 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_benchlingExport(t *testing.T) {
+	targetSeq := randomSeq(1000, 1)
+	fwd, rev := targetSeq[0:20], reverseComplement(targetSeq[580:600])
+	s := Solution{Fragments: []*Frag{
+		{
+			ID:       "pSB1A3",
+			Seq:      targetSeq[0:600],
+			fragType: pcr,
+			Primers: []Primer{
+				{Seq: fwd, Strand: true, Tm: 60.2, GC: 50, PrimingRegion: fwd},
+				{Seq: rev, Strand: false, Tm: 59.8, GC: 45, PrimingRegion: rev},
+			},
+		},
+		{ID: "target_2_synthetic", Seq: targetSeq[570:] + targetSeq[:30], fragType: synthetic},
+	}}
+
+	located := locateFragments(s, targetSeq)
+	if len(located) != 2 || located[0].start != 0 || located[0].end != 599 || located[1].start != 570 {
+		t.Fatalf("locateFragments() = %v, want pSB1A3 at 0-599 and the synthetic fragment at 570", located)
+	}
+	if p := located[0].Primers[1]; p.Range.end != 599 {
+		t.Errorf("locateFragments() rev primer ends at %d, want 599", p.Range.end)
+	}
+
+	gb := benchlingGenbank("target plasmid", targetSeq, s)
+	for _, want := range []string{
+		"LOCUS       target_plasmid",
+		"misc_feature    1..600",
+		"primer_bind     1..20",
+		"primer_bind     complement(581..600)",
+		"misc_feature    join(571..1000,1..30)",
+		"/label=\"junction-1\"",
+		"LOCUS       target_plasmid_fragment2",
+	} {
+		if !strings.Contains(gb, want) {
+			t.Errorf("benchlingGenbank() is missing %q", want)
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "oligos.csv")
+	if err := writeBenchlingOligos(filename, s, newReagentIDs(newOligosDB(primerIDPrefix, false), newOligosDB(synthFragIDPrefix, true), nil)); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "Name,Bases,Fragment,Direction") {
+		t.Fatalf("writeBenchlingOligos() = %q, want a header and 2 primers", lines)
+	}
+	if !strings.Contains(lines[2], rev+",pSB1A3,REV,59.8,45.0") {
+		t.Errorf("writeBenchlingOligos() rev primer = %q", lines[2])
+	}
+}
@@ -1,12 +1,15 @@
 package repp
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,12 +18,25 @@ import (
 	"go.uber.org/multierr"
 )
 
+const (
+	// blastMaxHits is the default upper limit on the number of hits parsed
+	// from a single BLAST output file. Hits beyond it are dropped with a warning
+	blastMaxHits = 1000000
+
+	// blastMaxLineLength is the longest BLAST output line we can parse.
+	// The subject sequence column may hold an entire (doubled) plasmid
+	blastMaxLineLength = 64 * 1024 * 1024
+)
+
+// errNotCached is the error of cache only BLAST runs whose output isn't cached
+var errNotCached = errors.New("BLAST output is not cached")
+
 // match is a blast "hit" in the blastdb.
 type match struct {
 	// entry of the matched building fragment in the database
 	entry string
 
-	// unique id for the match (entry name + start index % seqL). also used by fragments
+	// unique id for the match, see matchID. also used by fragments
 	uniqueID string
 
 	// seq of the match on the subject match
@@ -53,11 +69,36 @@ type match struct {
 	// mismatching number of bps in the match (for primer off-targets)
 	mismatching int
 
+	// gaps in the alignment of the match, included in mismatching
+	gaps int
+
 	// queryRevCompMatch if the query match is on the reverse complement sequence
 	queryRevCompMatch bool
 
 	// subjectRevCompMatch if the subject match is on the reverse complement sequence
 	subjectRevCompMatch bool
+
+	// subjectLength is the length of the whole subject, doubled if it's circular. 0 if unknown
+	subjectLength int
+}
+
+// matchID returns the unique ID of a match: its entry, where it starts on the target, its
+// strand and where it starts on the subject. The start on the target is modulo the target's
+// length, and the start on a circular subject (doubled in the database) modulo the subject's
+// length, so the copies of a match in both halves of a circular target share the ID, and
+// an assembly that reaches its first fragment's copy is detected as complete. The strand
+// and subject start tell apart matches of the same entry that start at the same place
+// on the target, ex: forward and reverse complement matches
+func (m match) matchID(seqLen int) string {
+	strand := "+"
+	if m.isRevCompMatch() {
+		strand = "-"
+	}
+	subjectStart := m.subjectStart
+	if m.circular && m.subjectLength > 1 {
+		subjectStart %= m.subjectLength / 2
+	}
+	return fmt.Sprintf("%s-%d%s%d", m.entry, m.queryStart%seqLen, strand, subjectStart)
 }
 
 // String display method
@@ -86,6 +127,22 @@ func (m match) isMatchRatioGEThreshold(th float64) bool {
 	return matchRatio >= th
 }
 
+// mismatchIndexes returns the indexes on the query where the subject sequence differs
+// from it. It's empty if the alignment had gaps, where they can't be told apart.
+func (m match) mismatchIndexes() (indexes []int) {
+	if m.mismatching == 0 || m.gaps > 0 || len(m.querySeq) != len(m.seq) {
+		return nil
+	}
+	querySeq := strings.ToUpper(m.querySeq)
+	subjectSeq := strings.ToUpper(m.seq)
+	for i := range querySeq {
+		if querySeq[i] != subjectSeq[i] {
+			indexes = append(indexes, m.queryStart+i)
+		}
+	}
+	return indexes
+}
+
 func (m match) isRevCompMatch() bool {
 	return m.queryRevCompMatch != m.subjectRevCompMatch
 }
@@ -99,8 +156,14 @@ type mismatchResult struct {
 	wasMismatch bool
 	m           match
 	err         error
+
+	// nearTm is the highest Tm of the primers' off-targets within offTargetTmMargin of the max, 0 if none
+	nearTm float64
 }
 
+// offTargetTmMargin is how far (C) below pcr-primer-max-offtarget-tm an off-target's Tm is warned about
+const offTargetTmMargin = 5.0
+
 // blastExec is a small utility object for executing BLAST.
 type blastExec struct {
 	// the name of the query
@@ -117,9 +180,22 @@ type blastExec struct {
 	// that could potentially be better if extended with bps from the end
 	matchLeftMargin int
 
+	// how matches in the left margin are handled: leftMarginMerge or leftMarginDrop
+	leftMarginHandling string
+
+	// the matches in the left margin and what was done with them
+	marginReport []marginMatch
+
+	// filter expression of the entries to keep, nil to keep all
+	filter filterExpr
+
 	// the database we're BLASTing against
 	db DB
 
+	// the databases spanned by db if it's an alias, and their lookup indexes
+	members       []DB
+	memberIndexes []*lookupIndex
+
 	// the input BLAST file
 	in *os.File
 
@@ -137,11 +213,25 @@ type blastExec struct {
 
 	// perform an ungapped alignment
 	ungapped bool
+
+	// maximum number of hits to parse from the output; blastMaxHits if not set
+	maxHits int
+
+	// only use the cached output, errNotCached if there's none
+	cachedOnly bool
+
+	// fail the parse on matches with inconsistent coordinates rather than keeping them
+	strict bool
 }
 
 // input creates an input query file (FASTA) for blastn.
 func (b *blastExec) input() error {
-	// create the query sequence file.
+	_, err := b.in.WriteString(b.query())
+	return err
+}
+
+// query returns the FASTA query of the input file
+func (b *blastExec) query() string {
 	var querySeq string
 
 	if b.circular {
@@ -152,30 +242,53 @@ func (b *blastExec) input() error {
 		querySeq = b.seq
 	}
 
-	// write the query ID and sequence
-	_, err := b.in.WriteString(fmt.Sprintf(">%s\n%s\n", b.name, querySeq))
-
-	return err
+	// the query ID and sequence
+	return fmt.Sprintf(">%s\n%s\n", b.name, querySeq)
 }
 
 // run calls the external blastn binary on the input file.
 func (b *blastExec) run() (err error) {
-	threads := runtime.NumCPU() - 1
-	if threads < 1 {
-		threads = 1
-	}
+	threads := blastThreads()
 
 	rlog.Infof("Query %s against %s -> %s\n", b.in.Name(),
 		b.db.Path, b.out.Name())
 
-	flags := []string{
+	flags := append([]string{
 		"-task", "blastn",
 		"-db", b.db.Path,
 		"-query", b.in.Name(),
 		"-out", b.out.Name(),
-		"-outfmt", "7 sseqid qstart qend sstart send sseq mismatch gaps stitle",
-		"-perc_identity", fmt.Sprintf("%d", b.identity),
 		"-num_threads", strconv.Itoa(threads),
+	}, b.searchFlags()...)
+
+	// https://www.ncbi.nlm.nih.gov/books/NBK279682/
+	blastCmd := exec.Command(
+		getExecutable("NCBITOOLS_HOME", "bin", "blastn"),
+		flags...)
+
+	rlog.Debugf("Run: %v", blastCmd)
+	// execute BLAST and wait on it to finish
+	if output, err := commands.combinedOutput(blastCmd); err != nil {
+		version := b.version()
+		var hint string
+		if version != "" {
+			hint = fmt.Sprintf("We know problems exist with BLASTN 2.13.0 - you are currently using %s", version)
+		} else {
+			hint = "We know problems exist with BLASTN <=2.13.0"
+		}
+		return fmt.Errorf("failed to execute blastn against %s: %v: %s %s - command was: %v",
+			b.db.Name, err, string(output), hint, blastCmd)
+	}
+
+	return
+}
+
+// searchFlags returns the blastn flags that determine its output: the output format and
+// the scoring for the percent identity. Input and output files, and threads, are excluded.
+func (b *blastExec) searchFlags() []string {
+	flags := []string{
+		"-outfmt", "7 sseqid qstart qend sstart send sseq mismatch gaps stitle slen",
+		"-perc_identity", fmt.Sprintf("%d", b.identity),
 	}
 
 	if b.identity > 99 {
@@ -223,51 +336,106 @@ func (b *blastExec) run() (err error) {
 		flags = append(flags, "-ungapped")
 	}
 
-	// https://www.ncbi.nlm.nih.gov/books/NBK279682/
-	blastCmd := exec.Command(
-		getExecutable("NCBITOOLS_HOME", "bin", "blastn"),
-		flags...)
+	return flags
+}
 
-	rlog.Debugf("Run: %v", blastCmd)
-	// execute BLAST and wait on it to finish
-	if output, err := blastCmd.CombinedOutput(); err != nil {
-		version := b.version()
-		var hint string
-		if version != "" {
-			hint = fmt.Sprintf("We know problems exist with BLASTN 2.13.0 - you are currently using %s", version)
-		} else {
-			hint = "We know problems exist with BLASTN <=2.13.0"
+// cacheKey returns the key of the BLAST output in the result cache. It changes
+// with the query, the search flags, and when the database is rebuilt.
+func (b *blastExec) cacheKey() (string, error) {
+	dbs := b.members
+	if len(dbs) == 0 {
+		dbs = []DB{b.db}
+	}
+	parts := []string{b.query(), strings.Join(b.searchFlags(), " ")}
+	for _, db := range dbs {
+		info, err := os.Stat(db.Path)
+		if err != nil {
+			return "", err
 		}
-		return fmt.Errorf("failed to execute blastn against %s: %v: %s %s - command was: %v",
-			b.db.Name, err, string(output), hint, blastCmd)
+		parts = append(parts,
+			db.Path,
+			strconv.FormatInt(info.Size(), 10),
+			strconv.FormatInt(info.ModTime().UnixNano(), 10),
+		)
 	}
+	return cacheKey(parts...), nil
+}
 
-	return
+// runCached writes the cached output of the same query against the same database
+// to the output file, or runs BLAST and caches its output.
+func (b *blastExec) runCached(rc *resultCache) error {
+	key, err := b.cacheKey()
+	if err != nil || rc == nil {
+		if b.cachedOnly {
+			return errNotCached
+		}
+		return b.run()
+	}
+
+	kind := blastDBCacheKind(b.db.Name)
+	if cached, ok := rc.get(kind, key); ok {
+		rlog.Infof("Use cached BLAST output of %s against %s", b.name, b.db.Name)
+		_, err = b.out.Write(cached)
+		return err
+	}
+	if b.cachedOnly {
+		return errNotCached
+	}
+
+	if err = b.run(); err != nil {
+		return err
+	}
+	if output, err := os.ReadFile(b.out.Name()); err == nil {
+		rc.put(kind, key, output)
+	}
+	return nil
 }
 
 func (b *blastExec) parse(filters []string) (matches []match, err error) {
-	// read in the results
-	file, err := os.ReadFile(b.out.Name())
+	// stream the results instead of reading the whole file into memory
+	file, err := os.Open(b.out.Name())
 	if err != nil {
 		return
 	}
-	fileS := string(file)
+	defer file.Close()
+
+	maxHits := b.maxHits
+	if maxHits <= 0 {
+		maxHits = blastMaxHits
+	}
 
 	fullQuery := b.seq + b.seq
 	identityThreshold := float64(b.identity)/100.0 - 0.0001
 
-	// read it into Matches
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), blastMaxLineLength)
+
+	// read it into Matches, filtering them as they are read
 	var ms []match
-	for li, line := range strings.Split(fileS, "\n") {
-		m, err := b.parseLine(li, line, fullQuery, filters)
+	for li := 0; scanner.Scan(); li++ {
+		m, err := b.parseLine(li, scanner.Text(), fullQuery, filters)
 		if err != nil {
 			return ms, err
 		}
 		// check if match is valid and if it is above identityThreshold
-		if m.isValid() && m.isMatchRatioGEThreshold(identityThreshold) {
-			// create and append the new match
-			ms = append(ms, m)
+		if !m.isValid() || !m.isMatchRatioGEThreshold(identityThreshold) {
+			continue
+		}
+		if len(ms) >= maxHits {
+			rlog.Warnf("BLAST output %s has more than %d hits; the remaining hits were ignored - consider a higher identity or more exclude filters",
+				b.out.Name(), maxHits)
+			break
 		}
+		// create and append the new match
+		ms = append(ms, m)
+	}
+	if err = scanner.Err(); err != nil {
+		return ms, fmt.Errorf("failed to read BLAST output %s: %v", b.out.Name(), err)
+	}
+
+	// for circular fragments, matches at the beginning may be part of a longer one that includes bps from the end
+	if b.circular {
+		ms, b.marginReport = applyLeftMargin(ms, b.seq, b.matchLeftMargin, b.leftMarginHandling)
 	}
 
 	return ms, nil
@@ -301,6 +469,10 @@ func (b *blastExec) parseLine(lineIndex int, line, inputQuerySeq string, filters
 	mismatching, _ := strconv.Atoi(cols[6]) // mismatch count
 	gaps, _ := strconv.Atoi(cols[7])        // gap count
 	titles := cols[8]                       // salltitles, eg: "fwd-terminator-2011"
+	subjectLength := 0                      // length of the whole subject, missing from older cached outputs
+	if len(cols) > 9 {
+		subjectLength, _ = strconv.Atoi(cols[9])
+	}
 	queryReverseComplementMatch := false
 	subjectReverseComplementMatch := false
 	if subjectSeq == "" {
@@ -330,52 +502,86 @@ func (b *blastExec) parseLine(lineIndex int, line, inputQuerySeq string, filters
 		subjectReverseComplementMatch = true
 	}
 
-	if b.circular && queryStart < b.matchLeftMargin {
-		// for circular fragments - if this match is at the beginning
-		// there might be a longer match that includes bps from the end
-		return
-	}
-
 	// filter on titles
-	matchesFilter := false
-	titles += entry
-	titles = strings.ToUpper(titles)
-	for _, f := range filters {
-		if strings.Contains(titles, f) {
-			matchesFilter = true
-			break
-		}
+	db, ok := b.entryDB(entry)
+	if !ok {
+		return // not in any of the alias's databases
 	}
-	if matchesFilter {
-		return // has been filtered out because of the "exclude" CLI flag
+	titles, circular, keep := screenEntry(entry, titles, db, subjectLength, filters, b.filter)
+	if !keep {
+		return
 	}
 
-	// get a unique identifier to distinguish this match/fragment from the others
-	uniqueID := entry + "-" + strconv.Itoa(queryStart%len(b.seq))
-
 	// gather the query sequence
 	querySeq := inputQuerySeq[queryStart : queryEnd+1]
 
 	// create and append the new match
 	m = match{
 		entry:               entry,
-		uniqueID:            uniqueID,
 		querySeq:            querySeq,
 		queryStart:          queryStart,
 		queryEnd:            queryEnd,
 		seq:                 subjectSeq,
 		subjectStart:        subjectStart,
 		subjectEnd:          subjectEnd,
-		circular:            strings.Contains(entry+titles, "CIRCULAR"),
+		circular:            circular,
 		mismatching:         mismatching + gaps,
-		db:                  b.db,
+		gaps:                gaps,
+		db:                  db,
 		title:               titles,
 		queryRevCompMatch:   queryReverseComplementMatch,
 		subjectRevCompMatch: subjectReverseComplementMatch,
+		subjectLength:       subjectLength,
 	}
+	if b.strict {
+		if inconsistency := m.coordinateInconsistency(); inconsistency != "" {
+			return m, fmt.Errorf("strict mode: inconsistent match of %s in %s, line %d of %s: %s", entry, db.Name, lineIndex+1, b.out.Name(), inconsistency)
+		}
+	}
+	// get a unique identifier to distinguish this match/fragment from the others
+	m.uniqueID = m.matchID(len(b.seq))
 	return m, nil
 }
 
+// splitHeader splits a FASTA header into the entry's ID and the rest of it, its title
+func splitHeader(header string) (entry, title string) {
+	cols := strings.Fields(header)
+	if len(cols) == 0 {
+		return header, ""
+	}
+	return cols[0], strings.Join(cols[1:], " ")
+}
+
+// screenEntry returns the upper cased titles of a database entry, as they're matched
+// against the exclude filters, whether the entry is circular and whether it's kept by
+// both the exclude filters and the filter expression. length is the length of the
+// entry's sequence in the database, 0 if unknown. Circular entries are stored doubled,
+// so they're filtered on half of it
+func screenEntry(entry, title string, db DB, length int, filters []string, filter filterExpr) (titles string, circular, keep bool) {
+	titles = strings.ToUpper(title + entry)
+	if matchesFilters(titles, filters) {
+		return titles, false, false // has been filtered out because of the "exclude" CLI flag
+	}
+	circular = strings.Contains(titles, "CIRCULAR")
+	if circular {
+		length /= 2
+	}
+	if filter != nil && !filter.eval(filterCandidate{entry: entry, title: title, db: db.Name, length: length, circular: circular}) {
+		return titles, circular, false // has been filtered out by the "filter" CLI flag
+	}
+	return titles, circular, true
+}
+
+// matchesFilters returns whether the (upper cased) titles contain any of the filters
+func matchesFilters(titles string, filters []string) bool {
+	for _, f := range filters {
+		if strings.Contains(titles, f) {
+			return true
+		}
+	}
+	return false
+}
+
 // runs blast on the query file against another subject file (rather than blastdb)
 func (b *blastExec) runAgainst() (err error) {
 	// create the blast command
@@ -386,12 +592,12 @@ func (b *blastExec) runAgainst() (err error) {
 		"-query", b.in.Name(),
 		"-subject", b.subject,
 		"-out", b.out.Name(),
-		"-outfmt", "7 sseqid qstart qend sstart send sseq mismatch gaps stitle",
+		"-outfmt", "7 sseqid qstart qend sstart send sseq mismatch gaps stitle slen",
 	)
 
 	// execute BLAST and wait on it to finish
 	rlog.Debugf("Run: %v", blastCmd)
-	if output, err := blastCmd.CombinedOutput(); err != nil {
+	if output, err := commands.combinedOutput(blastCmd); err != nil {
 		version := b.version()
 		var hint string
 		if version != "" {
@@ -435,7 +641,7 @@ func (b *blastExec) version() string {
 	)
 
 	// execute BLAST and wait on it to finish
-	output, err := blastCmd.CombinedOutput()
+	output, err := commands.combinedOutput(blastCmd)
 	if err != nil {
 		rlog.Errorf("Error trying to get NCBI BLAST version: %v -> %v", blastCmd, err)
 		return ""
@@ -460,11 +666,58 @@ func blast(
 	matchLeftMargin int,
 	dbs []DB,
 	filters []string,
+	filter filterExpr,
+	identity int,
+	ungapped bool,
+	conf *config.Config,
+) ([]match, error) {
+	return blastDBs(name, seq, circular, matchLeftMargin, dbs, filters, filter, identity, ungapped, conf, false)
+}
+
+// blastDBs is blast, but with cachedOnly it fails with errNotCached rather than
+// running BLAST if the output of any database isn't in the result cache.
+func blastDBs(
+	name, seq string,
+	circular bool,
+	matchLeftMargin int,
+	dbs []DB,
+	filters []string,
+	filter filterExpr,
 	identity int,
 	ungapped bool,
+	conf *config.Config,
+	cachedOnly bool,
 ) ([]match, error) {
+	setRunStage(searchStage)
+	rc := openCache(conf)
+	leftMarginHandling := leftMarginMerge
+	if conf != nil {
+		var err error
+		if leftMarginHandling, err = parseLeftMarginHandling(conf.GetMatchLeftMarginHandling()); err != nil {
+			return nil, err
+		}
+	}
 	matches := []match{}
-	for _, db := range dbs {
+	var marginReport []marginMatch
+	for _, target := range blastTargets(dbs, registeredAliases()) {
+		db := target.db
+
+		// search small databases in-process, there's nothing to cache
+		if len(target.members) == 0 && nativeSearchable(db, conf) {
+			rlog.Infof("Query %s against %s in-process", name, db.Name)
+			dbMatches, err := nativeSearch(seq, circular, db, filters, filter, identity)
+			if err != nil {
+				return nil, err
+			}
+			if circular {
+				var report []marginMatch
+				dbMatches, report = applyLeftMargin(dbMatches, seq, matchLeftMargin, leftMarginHandling)
+				marginReport = append(marginReport, report...)
+			}
+			matches = append(matches, dbMatches...)
+			continue
+		}
+
 		in, err := os.CreateTemp("", "blast-in-*")
 		if err != nil {
 			return nil, err
@@ -476,21 +729,30 @@ func blast(
 		}
 
 		b := &blastExec{
-			name:            name,
-			seq:             seq,
-			circular:        circular,
-			matchLeftMargin: matchLeftMargin,
-			db:              db,
-			in:              in,
-			out:             out,
-			identity:        identity,
-			ungapped:        ungapped,
+			name:               name,
+			seq:                seq,
+			circular:           circular,
+			matchLeftMargin:    matchLeftMargin,
+			leftMarginHandling: leftMarginHandling,
+			db:                 db,
+			members:            target.members,
+			in:                 in,
+			out:                out,
+			identity:           identity,
+			ungapped:           ungapped,
+			filter:             filter,
+			cachedOnly:         cachedOnly,
+			strict:             conf != nil && conf.Strict,
 		}
 		defer b.close()
 
 		// make sure the db exists
-		if _, err := os.Stat(db.Path); os.IsNotExist(err) {
-			return nil, fmt.Errorf("failed to find a BLAST database at %s", db.Path)
+		if len(b.members) == 0 {
+			if _, err := os.Stat(db.Path); os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to find a BLAST database at %s", db.Path)
+			}
+		} else if err := b.indexMembers(); err != nil {
+			return nil, err
 		}
 
 		// create the input file
@@ -498,8 +760,10 @@ func blast(
 			return nil, fmt.Errorf("failed to write a BLAST input file at %s: %v", b.in.Name(), err)
 		}
 
-		// execute BLAST
-		if err := b.run(); err != nil {
+		// execute BLAST, or reuse its cached output
+		if err := b.runCached(rc); err == errNotCached {
+			return nil, err
+		} else if err != nil {
 			return nil, fmt.Errorf("failed executing BLAST: %v", err)
 		}
 
@@ -512,7 +776,9 @@ func blast(
 
 		// add these matches against the growing list of matches
 		matches = append(matches, dbMatches...)
+		marginReport = append(marginReport, b.marginReport...)
 	}
+	logLeftMarginReport(marginReport)
 
 	return matches, nil
 }
@@ -569,6 +835,124 @@ func blastAgainst(
 	return matches, nil
 }
 
+// extendIdenticalEnds greedily extends each match left and right while the bases of the
+// query and subject remain identical. BLAST's scoring often stops a match a few bp
+// short of identical ends, and the homology lost there has to be added back by primers.
+//
+// The subject sequences are read from the matches' database FASTA files. Circular
+// matches are not extended below the left margin, as with parsing BLAST's output.
+func extendIdenticalEnds(matches []match, seq string, circular bool, matchLeftMargin int) error {
+	querySeq := seq
+	if circular {
+		querySeq = seq + seq
+	}
+	upperQuerySeq := strings.ToUpper(querySeq)
+
+	// read the sequences of the matched entries from each database, seeking to
+	// each entry's offset in the database's lookup index
+	subjects := make(map[string]map[string]string)
+	indexes := make(map[string]*lookupIndex)
+	for _, m := range matches {
+		idx, ok := indexes[m.db.Path]
+		if !ok {
+			var err error
+			if idx, err = m.db.lookupIndex(); err != nil {
+				return fmt.Errorf("failed to index database %s: %v", m.db.Path, err)
+			}
+			indexes[m.db.Path] = idx
+			subjects[m.db.Path] = make(map[string]string)
+		}
+		entries := subjects[m.db.Path]
+		if _, read := entries[m.entry]; read {
+			continue
+		}
+
+		entries[m.entry] = ""
+		if e, ok := idx.entry(m.entry); ok {
+			var err error
+			if entries[m.entry], err = readEntry(m.db.Path, e.Offset); err != nil {
+				return fmt.Errorf("failed to read %s from database %s: %v", m.entry, m.db.Path, err)
+			}
+		}
+	}
+
+	minQueryStart := 0
+	if circular {
+		minQueryStart = matchLeftMargin
+	}
+	for i := range matches {
+		m := &matches[i]
+		subject := subjects[m.db.Path][m.entry]
+		if subject == "" || m.queryRevCompMatch {
+			continue
+		}
+
+		// work on the subject strand that aligns to the query
+		ss, se := m.subjectStart, m.subjectEnd
+		if m.subjectRevCompMatch {
+			subject = reverseComplement(subject)
+			ss, se = len(subject)-1-m.subjectEnd, len(subject)-1-m.subjectStart
+		}
+		if se >= len(subject) {
+			continue
+		}
+
+		qs, qe := m.queryStart, m.queryEnd
+		for qs > minQueryStart && ss > 0 && upperQuerySeq[qs-1] == subject[ss-1] {
+			qs--
+			ss--
+		}
+		for qe < len(querySeq)-1 && se < len(subject)-1 && upperQuerySeq[qe+1] == subject[se+1] {
+			qe++
+			se++
+		}
+		if qs == m.queryStart && qe == m.queryEnd {
+			continue
+		}
+
+		m.querySeq = querySeq[qs : qe+1]
+		m.seq = subject[ss : se+1]
+		m.queryStart, m.queryEnd = qs, qe
+		if m.subjectRevCompMatch {
+			ss, se = len(subject)-1-se, len(subject)-1-ss
+		}
+		m.subjectStart, m.subjectEnd = ss, se
+		m.uniqueID = m.matchID(len(seq))
+	}
+
+	return nil
+}
+
+// cullGrouping is how matches are grouped before those engulfed by others in their group are culled
+type cullGrouping string
+
+const (
+	// cullByDatabase culls matches engulfed by others from the same database
+	cullByDatabase cullGrouping = "database"
+
+	// cullByTemplate culls matches engulfed by others from the same template, keeping
+	// shorter matches of cheaper templates that are engulfed by a costlier one's
+	cullByTemplate cullGrouping = "template"
+
+	// cullAll culls matches engulfed by any others
+	cullAll cullGrouping = "none"
+)
+
+// parseCullGrouping returns the grouping of the cull-grouping setting
+func parseCullGrouping(grouping string) (cullGrouping, error) {
+	switch g := cullGrouping(grouping); g {
+	case cullByDatabase, cullByTemplate, cullAll:
+		return g, nil
+	}
+	return "", fmt.Errorf("invalid cull-grouping %q; valid values [database, template, none]", grouping)
+}
+
+// culledMatch is a match that was culled and why
+type culledMatch struct {
+	match  match
+	reason string
+}
+
 // cull removes matches that are engulfed in others
 //
 // culling fragment matches means removing those that are completely
@@ -577,6 +961,13 @@ func blastAgainst(
 // will be the better one, since it covers a greater region and will almost
 // always be preferable to the smaller one
 func cull(matches []match, minSize, limit int) (culled []match) {
+	culled, _ = cullGrouped(matches, minSize, limit, cullByDatabase)
+	return culled
+}
+
+// cullGrouped removes matches shorter than minSize and those engulfed in limit others of
+// their group. It also returns the removed matches and why they were removed
+func cullGrouped(matches []match, minSize, limit int, grouping cullGrouping) (culled []match, removed []culledMatch) {
 	// remove fragments that are shorter the minimum cut off size
 	// propertize by source because this isn't smart enough to propertize based on
 	// cost as well. Ie we want to avoid propertizing a small fragment enclosed in a larger
@@ -584,14 +975,25 @@ func cull(matches []match, minSize, limit int) (culled []match) {
 	groupedMatches := map[string][]match{}
 	for _, m := range matches {
 		if minSize > 0 && m.length() < minSize {
+			removed = append(removed, culledMatch{m, fmt.Sprintf("shorter than the minimum length of %dbp", minSize)})
 			continue // too short
 		}
-		groupedMatches[m.db.Path] = append(groupedMatches[m.db.Path], m)
+		var group string
+		switch grouping {
+		case cullByTemplate:
+			group = m.db.Path + "\t" + m.entry
+		case cullAll:
+		default:
+			group = m.db.Path
+		}
+		groupedMatches[group] = append(groupedMatches[group], m)
 	}
 
 	// create culled matches (non-self contained)
 	for _, group := range groupedMatches {
-		culled = append(culled, properize(group, limit)...)
+		kept, engulfed := properize(group, limit)
+		culled = append(culled, kept...)
+		removed = append(removed, engulfed...)
 	}
 
 	// because we culled the matches, we may have removed a match from the
@@ -604,24 +1006,62 @@ func cull(matches []match, minSize, limit int) (culled []match) {
 
 	// sort again now that we added copied matches
 	sortMatches(culled)
-	return culled
+	sort.SliceStable(removed, func(i, j int) bool {
+		return removed[i].match.queryStart < removed[j].match.queryStart
+	})
+	return culled, removed
 }
 
-// properize remove matches that are entirely contained within others
-func properize(matches []match, limit int) []match {
+// properize remove matches that are entirely contained within others. It also
+// returns the removed matches with the match they're contained in
+func properize(matches []match, limit int) ([]match, []culledMatch) {
 	sortMatches(matches)
 
 	// only include those that aren't encompassed by the one before it
 	culled := []match{}
+	var removed []culledMatch
 	for _, m := range matches {
 		check := len(culled) - limit
 		if check < 0 || m.queryEnd > culled[check].queryEnd {
 			culled = append(culled, m)
+			continue
 		}
+		by := culled[check]
+		removed = append(removed, culledMatch{m, fmt.Sprintf("engulfed by %s (%d-%d)", by.entry, by.queryStart+1, by.queryEnd+1)})
 	}
 
 	rlog.Debugf("%v matches out of %v left after culling", culled, matches)
-	return culled
+	return culled, removed
+}
+
+// writeCulledMatches writes the culled matches, and why they were culled, to a TSV
+func writeCulledMatches(w io.Writer, removed []culledMatch) error {
+	if _, err := fmt.Fprintln(w, "entry\tdatabase\tqstart\tqend\tlength\treason"); err != nil {
+		return err
+	}
+	for _, r := range removed {
+		m := r.match
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", m.entry, m.db.Name, m.queryStart+1, m.queryEnd+1, m.length(), r.reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dumpCulledMatches writes the culled matches to a temporary TSV, for debugging which
+// fragments were never considered
+func dumpCulledMatches(removed []culledMatch) {
+	f, err := os.CreateTemp("", "culled-*.tsv")
+	if err != nil {
+		rlog.Debugf("failed to write the culled matches: %v", err)
+		return
+	}
+	defer f.Close()
+	if err = writeCulledMatches(f, removed); err != nil {
+		rlog.Debugf("failed to write the culled matches: %v", err)
+		return
+	}
+	rlog.Debugf("wrote %d culled matches to %s", len(removed), f.Name())
 }
 
 // sortMatches sorts matches by their start index
@@ -648,13 +1088,22 @@ func sortMatches(matches []match) {
 	})
 }
 
-// queryDatabases is for finding a fragment/plasmid with the entry name in one of the dbs
-func queryDatabases(entry string, dbs []DB) (f *Frag, err error) {
+// queryDatabases is for finding a fragment/plasmid with the entry name in one of the dbs.
+// In strict mode, an entry that was renamed to several entries of a database is an error
+func queryDatabases(entry string, dbs []DB, strict bool) (f *Frag, err error) {
 	// first try to get the entry out of a local file
 	if frags, err := read(entry, false, false); err == nil && len(frags) > 0 {
 		return frags[0], nil // it was a local file
 	}
 
+	// the ID the entry was written to each database with
+	dbEntries := make(map[string]string, len(dbs))
+	for _, db := range dbs {
+		if dbEntries[db.Name], err = db.resolveEntry(entry, strict); err != nil {
+			return nil, err
+		}
+	}
+
 	// channel that returns filename to an output result from blastdbcmd
 	outFileCh := make(chan string, len(dbs))
 	dbSourceCh := make(chan DB, len(dbs))
@@ -663,7 +1112,7 @@ func queryDatabases(entry string, dbs []DB) (f *Frag, err error) {
 	for _, db := range dbs {
 		go func(db DB) {
 			// if outFile is defined here we managed to query the entry from the db
-			outFile, _, err := blastdbcmd(entry, db)
+			outFile, _, err := blastdbcmd(dbEntries[db.Name], db)
 			if err == nil && outFile != nil {
 				outFileCh <- outFile.Name() // "" if not found
 				dbSourceCh <- db
@@ -711,7 +1160,7 @@ func queryDatabases(entry string, dbs []DB) (f *Frag, err error) {
 func seqMismatch(primers []Primer, parentID, parentSeq string, conf *config.Config) mismatchResult {
 	parentFile, err := os.CreateTemp("", "parent-*")
 	if err != nil {
-		return mismatchResult{false, match{}, err}
+		return mismatchResult{false, match{}, err, 0}
 	}
 	defer os.Remove(parentFile.Name())
 
@@ -720,18 +1169,20 @@ func seqMismatch(primers []Primer, parentID, parentSeq string, conf *config.Conf
 	}
 	inContent := fmt.Sprintf(">%s\n%s\n", parentID, parentSeq)
 	if _, err = parentFile.WriteString(inContent); err != nil {
-		return mismatchResult{false, match{}, fmt.Errorf("failed to write primer sequence to query FASTA file: %v", err)}
+		return mismatchResult{false, match{}, fmt.Errorf("failed to write primer sequence to query FASTA file: %v", err), 0}
 	}
 
 	// check each primer for mismatches
+	var nearTm float64
 	for _, primer := range primers {
-		wasMismatch, m, err := mismatch(primer.Seq, parentFile, conf)
+		wasMismatch, m, primerNearTm, err := mismatch(primer.Seq, parentFile, conf)
 		if wasMismatch || err != nil {
-			return mismatchResult{wasMismatch, m, err}
+			return mismatchResult{wasMismatch, m, err, 0}
 		}
+		nearTm = math.Max(nearTm, primerNearTm)
 	}
 
-	return mismatchResult{false, match{}, nil}
+	return mismatchResult{false, match{}, nil, nearTm}
 }
 
 // parentMismatch both searches for a the parent fragment in its source DB and queries for
@@ -745,14 +1196,15 @@ func parentMismatch(primers []Primer, parent string, db DB, conf *config.Config)
 	// this is similar to what io.IsNotExist does
 	if err != nil {
 		if strings.Contains(err.Error(), "failed to query") {
-			stderr.Println(err) // just write the error
+			rlog.Warn(err) // just write the error
 			// TODO: if we fail to find the parent, query the fullSeq as it was sent
-			return mismatchResult{false, match{}, nil}
+			return mismatchResult{false, match{}, nil, 0}
 		}
-		return mismatchResult{false, match{}, err}
+		return mismatchResult{false, match{}, err, 0}
 	}
 
 	// check each primer for mismatches
+	var nearTm float64
 	if parentFile.Name() != "" {
 		defer os.Remove(parentFile.Name())
 
@@ -764,18 +1216,19 @@ func parentMismatch(primers []Primer, parent string, db DB, conf *config.Config)
 				if i > 0 {
 					dir = "REV"
 				}
-				return mismatchResult{false, match{}, fmt.Errorf("does not contain end of %s primer: %s", dir, primerEnd)}
+				return mismatchResult{false, match{}, fmt.Errorf("does not contain end of %s primer: %s", dir, primerEnd), 0}
 			}
 
 			// check for a mismatch in the parent sequence
-			wasMismatch, m, err := mismatch(primer.Seq, parentFile, conf)
+			wasMismatch, m, primerNearTm, err := mismatch(primer.Seq, parentFile, conf)
 			if wasMismatch || err != nil {
-				return mismatchResult{wasMismatch, m, err}
+				return mismatchResult{wasMismatch, m, err, 0}
 			}
+			nearTm = math.Max(nearTm, primerNearTm)
 		}
 	}
 
-	return mismatchResult{false, match{}, err}
+	return mismatchResult{false, match{}, err, nearTm}
 }
 
 // blastdbcmd queries a fragment/plasmid by its FASTA entry name (entry) and writes the
@@ -815,7 +1268,7 @@ func blastdbcmd(entry string, db DB) (output *os.File, parentSeq string, err err
 	)
 
 	// execute
-	if _, err := queryCmd.CombinedOutput(); err != nil {
+	if _, err := commands.combinedOutput(queryCmd); err != nil {
 		return nil, "", fmt.Errorf("warning: failed to query %s from %s db\n\t%s", entry, db.Name, err.Error())
 	}
 
@@ -834,24 +1287,25 @@ func blastdbcmd(entry string, db DB) (output *os.File, parentSeq string, err err
 // mismatch finds mismatching sequences between the query sequence and
 // the parent sequence (in the parent file)
 //
-// The fragment to query against is stored in parentFile
-func mismatch(primer string, parentFile *os.File, c *config.Config) (wasMismatch bool, m match, err error) {
+// The fragment to query against is stored in parentFile. nearTm is the highest Tm of the
+// primer's off-targets within offTargetTmMargin of the max, 0 if there are none
+func mismatch(primer string, parentFile *os.File, c *config.Config) (wasMismatch bool, m match, nearTm float64, err error) {
 	// path to the entry batch file to hold the entry accession
 	in, err := os.CreateTemp("", "primer3-in-*")
 	if err != nil {
-		return false, match{}, err
+		return false, match{}, 0, err
 	}
 
 	// path to the output sequence file from querying the entry's sequence from the BLAST db
 	out, err := os.CreateTemp("", "primer3-out-*")
 	if err != nil {
-		return false, match{}, err
+		return false, match{}, 0, err
 	}
 
 	// create input file
 	inContent := fmt.Sprintf(">primer\n%s\n", primer)
 	if _, err = in.WriteString(inContent); err != nil {
-		return false, m, fmt.Errorf("failed to write primer sequence to query FASTA file: %v", err)
+		return false, m, 0, fmt.Errorf("failed to write primer sequence to query FASTA file: %v", err)
 	}
 
 	// BLAST the query sequence against the parentFile sequence
@@ -867,20 +1321,20 @@ func mismatch(primer string, parentFile *os.File, c *config.Config) (wasMismatch
 
 	// execute BLAST
 	if err = b.runAgainst(); err != nil {
-		return false, m, fmt.Errorf("failed to run blast against parent: %v", err)
+		return false, m, 0, fmt.Errorf("failed to run blast against parent: %v", err)
 	}
 
 	// get the BLAST matches
 	matches, err := b.parse([]string{})
 	if err != nil {
-		return false, match{}, fmt.Errorf("failed to parse matches from %s: %v", out.Name(), err)
+		return false, match{}, 0, fmt.Errorf("failed to parse matches from %s: %v", out.Name(), err)
 	}
 
 	// parse the results and check whether any are cause for concern (by Tm)
 	primerCount := 1 // number of times we expect to see the primer itself
 	parentFileContents, err := os.ReadFile(parentFile.Name())
 	if err != nil {
-		return false, match{}, err
+		return false, match{}, 0, err
 	}
 
 	if strings.Contains(string(parentFileContents), "circular") {
@@ -890,16 +1344,19 @@ func mismatch(primer string, parentFile *os.File, c *config.Config) (wasMismatch
 	}
 
 	for _, m := range matches {
-		if isMismatch(primer, m, c) {
+		tm, ok := offTargetTm(primer, m, c)
+		if !ok || tm > c.PcrPrimerMaxOfftargetTm {
 			primerCount--
+		} else if tm > c.PcrPrimerMaxOfftargetTm-offTargetTmMargin && tm > nearTm {
+			nearTm = tm
 		}
 
 		if primerCount < 0 {
-			return true, m, nil
+			return true, m, 0, nil
 		}
 	}
 
-	return false, match{}, nil
+	return false, match{}, nearTm, nil
 }
 
 // isMismatch returns whether the match constitutes a mismatch
@@ -908,6 +1365,12 @@ func mismatch(primer string, parentFile *os.File, c *config.Config) (wasMismatch
 // estimate the ntthal and check against the max offtarget tm
 // from the settings
 func isMismatch(primer string, m match, c *config.Config) bool {
+	tm, ok := offTargetTm(primer, m, c)
+	return !ok || tm > c.PcrPrimerMaxOfftargetTm
+}
+
+// offTargetTm estimates the Tm of the primer at a match with ntthal. It returns false if ntthal fails
+func offTargetTm(primer string, m match, c *config.Config) (float64, bool) {
 	// we want the reverse complement of one to the other
 	ectopic := m.seq
 	if m.isFwdMatch() {
@@ -923,10 +1386,10 @@ func isMismatch(primer string, m match, c *config.Config) bool {
 		"-r", // temperature only
 	)
 
-	ntthalOut, err := ntthalCmd.CombinedOutput()
+	ntthalOut, err := commands.combinedOutput(ntthalCmd)
 	if err != nil {
 		stderr.Printf("failed to execute ntthal: %s", strings.Join(ntthalCmd.Args, ","))
-		return true
+		return 0, false
 	}
 
 	ntthalOutString := string(ntthalOut)
@@ -935,12 +1398,15 @@ func isMismatch(primer string, m match, c *config.Config) bool {
 		rlog.Fatal(err)
 	}
 
-	return temp > c.PcrPrimerMaxOfftargetTm
+	return temp, true
 }
 
-// makeblastdb runs makeblastdb against a FASTA file.
-func makeblastdb(fullDbPath string) error {
+// makeblastdb runs makeblastdb against a FASTA file, splitting it into volumes of at most maxFileSize.
+func makeblastdb(fullDbPath, maxFileSize string) error {
 	rlog.Infof("Make BlastDB %s\n", fullDbPath)
+	if err := checkVolumes(fullDbPath, maxFileSize); err != nil {
+		return err
+	}
 	cleanblastdb(fullDbPath, false)
 
 	cmd := exec.Command(
@@ -948,11 +1414,11 @@ func makeblastdb(fullDbPath string) error {
 		"-dbtype", "nucl",
 		"-in", fullDbPath,
 		"-parse_seqids",
-		"-max_file_sz", "10M",
+		"-max_file_sz", maxFileSize,
 	)
 
 	rlog.Debugf("Run: %v", cmd.Args)
-	if stdout, err := cmd.CombinedOutput(); err != nil {
+	if stdout, err := commands.combinedOutput(cmd); err != nil {
 		return fmt.Errorf("failed to makeblastdb: %s %w", string(stdout), err)
 	}
 	return nil
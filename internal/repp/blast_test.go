@@ -1,7 +1,10 @@
 package repp
 
 import (
+	"os"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/Lattice-Automation/repp/internal/config"
@@ -15,7 +18,7 @@ func Test_BLAST_CircularGenomeWithLeftMargin(t *testing.T) {
 	leftMargin := 500
 
 	// run blast
-	matches, err := blast(id, seq, true, leftMargin, []DB{testDB}, []string{}, 10, false) // any match over 10 bp
+	matches, err := blast(id, seq, true, leftMargin, []DB{testDB}, []string{}, nil, 10, false, nil) // any match over 10 bp
 
 	// check if it fails
 	if err != nil {
@@ -43,7 +46,7 @@ func Test_BLAST(t *testing.T) {
 	seq := "GGCCGCAATAAAATATCTTTATTTTCATTACATCTGTGTGTTGGTTTTTTGTGTGAATCGATAGTACTAACATGACCACCTTGATCTTCATGGTCTGGGTGCCCTCGTAGGGCTTGCCTTCGCCCTCGGATGTGCACTTGAAGTGGTGGTTGTTCACGGTGCCCTCCATGTACAGCTTCATGTGCATGTTCTCCTTGATCAGCTCGCTCATAGGTCCAGGGTTCTCCTCCACGTCTCCAGCCTGCTTCAGCAGGCTGAAGTTAGTAGCTCCGCTTCCGGATCCCCCGGGGAGCATGTCAAGGTCAAAATCGTCAAGAGCGTCAGCAGGCAGCATATCAAGGTCAAAGTCGTCAAGGGCATCGGCTGGGAgCATGTCTAAgTCAAAATCGTCAAGGGCGTCGGCCGGCCCGCCGCTTTcgcacGCCCTGGCAATCGAGATGCTGGACAGGCATCATACCCACTTCTGCCCCCTGGAAGGCGAGTCATGGCAAGACTTTCTGCGGAACAACGCCAAGTCATTCCGCTGTGCTCTCCTCTCACATCGCGACGGGGCTAAAGTGCATCTCGGCACCCGCCCAACAGAGAAACAGTACGAAACCCTGGAAAATCAGCTCGCGTTCCTGTGTCAGCAAGGCTTCTCCCTGGAGAACGCACTGTACGCTCTGTCCGCCGTGGGCCACTTTACACTGGGCTGCGTATTGGAGGATCAGGAGCATCAAGTAGCAAAAGAGGAAAGAGAGACACCTACCACCGATTCTATGCCTGACTGTGGCGGGTGAGCTTAGGGGGCCTCCGCTCCAGCTCGACACCGGGCAGCTGCTGAAGATCGCGAAGAGAGGGGGAGTAACAGCGGTAGAGGCAGTGCACGCCTGGCGCAATGCGCTCACCGGGGCCCCCTTGAACCTGACCCCAGACCAGGTAGTCGCAATCGCGAACAATAATGGGGGAAAGCAAGCCCTGGAAACCGTGCAAAGGTTGTTGCCGGTCCTTTGTCAAGACCACGGCCTTACACCGGAGCAAGTCGTGGCCATTGCAAGCAATGGGGGTGGCAAACAGGCTCTTGAGACGGTTCAGAGACTTCTCCCAGTTCTCTGTCAAGCCGTTGGAGTCCACGTTCTTTAATAGTGGACTCTTGTTCCAAACTGGAACAACACTCAACCCTATCTCGGTCTATTCTTTTGATTTATAAGGGATTTTGCCGATTTCGGCCTATTGGTTAAAAAATGAGCTGATTTAACAAAAATTTAACGCGAATTTTAACAAAATATTAACGCTTACAATTTAGGTGGCACTTTTCGGGGAAATGTGCGCGGAACCCCTATTTGTTTATTTTTCTAAATACATTCAAATATGTATCCGCTCATGAGACAATAACCCTGATAAATGCTTCAATAATATTGAAAAAGGAAGAGTATGAGTATTCAACATTTCCGTGTCGCCCTTATTCCCTTTTTTGCGGCATTTTGCCTTCCTGTTTTTGCTCACCCAGAAACGCTGGTGAAAGTAAAAGATGCTGAAGATCAGTTGGGTGCACGAGTGGGTTACATCGAACTGGATCTCAACAGCGGTAAGATCCTTGAGAGTTTTCGCCCCGAAGAACGTTTTCCAATGATGAGCACTTTTAAAGTTCTGCTATGTGGCGCGGTATTATCCCGTATTGACGCCGGGCAAGAGCAACTCGGTCGCCGCATACACTATTCTCAGAATGACTTGGTTGAGTACTCACCAGTCACAGAAAAGCATCTTACGGATGGCATGACAGTAAGAGAATTATGCAGTGCTGCCATAACCATGAGTGATAACACTGCGGCCAACTTACTTCTGACAACGATCGGAGGACCGAAGGAGCTAACCGCTTTTTTGCACAACATGGGGGATCATGTAACTCGCCTTGATCGTTGGGAACCGGAGCTGAATGAAGCCATACCAAACGACGAGCGTGACACCACGATGCCTGTAGCAATGGCAACAACGTTGCGCAAACTATTAACTGGCGAACTACTTACTCTAGCTTCCCGGCAACAATTAATAGACTGGATGGAGGCGGATAAAGTTGCAGGACCACTTCTGCGCTCGGCCCTTCCGGCTGGCTGGTTTATTGCTGATAAATCTGGAGCCGGTGAGCGTGGGTCTCGCGGTATCATTGCAGCACTGGGGCCAGATGGTAAGCCCTCCCGTATCGTAGTTATCTACACGACGGGGAGTCAGGCAACTATGGATGAACGAAATAGACAGATCGCTGAGATAGGTGCCTCACTGATTAAGCATTGGTAACTGTCAGACCAAGTTTACTCATATATACTTTAGATTGATTTAAAACTTCATTTTTAATTTAAAAGGATCTAGGTGAAGATCCTTTTTGATAATCTCATGACCAAAATCCCTTAACGTGAGTTTTCGTTCCACTGAGCGTCAGACCCCGTAGAA"
 
 	// run blast
-	matches, err := blast(id, seq, true, 0, []DB{testDB}, []string{}, 10, false) // any match over 10 bp
+	matches, err := blast(id, seq, true, 0, []DB{testDB}, []string{}, nil, 10, false, nil) // any match over 10 bp
 
 	// check if it fails
 	if err != nil {
@@ -74,6 +77,72 @@ func Test_BLAST(t *testing.T) {
 	})
 }
 
+// test that the BLAST output is parsed and capped at the max hits
+func Test_blastExec_parse(t *testing.T) {
+	out, err := os.CreateTemp(t.TempDir(), "blast-out-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		"# BLASTN 2.12.0+",
+		"# Fields: subject id, q. start, q. end, s. start, s. end, subject seq, mismatches, gaps, subject title",
+		"m1\t1\t8\t1\t8\tATGCATGC\t0\t0\tfirst",
+		"m2\t3\t10\t10\t3\tGCATGCAT\t0\t0\tmutant",
+		"m3\t5\t12\t1\t8\tATGCATGC\t0\t0\tthird",
+		"m4\t7\t14\t1\t8\tATGCATGC\t0\t0\tfourth",
+	}
+	if _, err = out.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		filters     []string
+		maxHits     int
+		wantEntries []string
+	}{
+		{
+			"all hits",
+			[]string{},
+			0,
+			[]string{"m1", "m2", "m3", "m4"},
+		},
+		{
+			"filters applied while parsing",
+			[]string{"MUTANT"},
+			0,
+			[]string{"m1", "m3", "m4"},
+		},
+		{
+			"capped at max hits",
+			[]string{"MUTANT"},
+			2,
+			[]string{"m1", "m3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &blastExec{
+				seq:      "ATGCATGCATGCATGC",
+				out:      out,
+				identity: 100,
+				maxHits:  tt.maxHits,
+			}
+			matches, err := b.parse(tt.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotEntries := []string{}
+			for _, m := range matches {
+				gotEntries = append(gotEntries, m.entry)
+			}
+			if !reflect.DeepEqual(gotEntries, tt.wantEntries) {
+				t.Errorf("parse() = %v, want %v", gotEntries, tt.wantEntries)
+			}
+		})
+	}
+}
+
 // test that we can filter out overlapping regions from blast results
 // and those that are up against the edge of the fragment
 func Test_cull(t *testing.T) {
@@ -123,6 +192,55 @@ func Test_cull(t *testing.T) {
 	}
 }
 
+func Test_cullGrouped(t *testing.T) {
+	cheap, costly := DB{Name: "cheap", Path: "cheap"}, DB{Name: "costly", Path: "costly"}
+	matches := []match{
+		{entry: "long", db: costly, queryStart: 0, queryEnd: 500},
+		{entry: "short", db: cheap, queryStart: 100, queryEnd: 300},
+		{entry: "shorter", db: costly, queryStart: 100, queryEnd: 300},
+		{entry: "tiny", db: cheap, queryStart: 400, queryEnd: 420},
+	}
+
+	tests := []struct {
+		name        string
+		grouping    cullGrouping
+		wantKept    []string
+		wantReasons []string
+	}{
+		{"by database", cullByDatabase, []string{"long", "short"}, []string{"engulfed by long (1-501)", "shorter than the minimum length of 50bp"}},
+		{"by template", cullByTemplate, []string{"long", "short", "shorter"}, []string{"shorter than the minimum length of 50bp"}},
+		{"none", cullAll, []string{"long"}, []string{"engulfed by long (1-501)", "engulfed by long (1-501)", "shorter than the minimum length of 50bp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			culled, removed := cullGrouped(append([]match{}, matches...), 50, 1, tt.grouping)
+			var kept, reasons []string
+			for _, m := range culled {
+				kept = append(kept, m.entry)
+			}
+			for _, r := range removed {
+				reasons = append(reasons, r.reason)
+			}
+			sort.Strings(kept)
+			if !reflect.DeepEqual(kept, tt.wantKept) {
+				t.Errorf("cullGrouped() kept %v, want %v", kept, tt.wantKept)
+			}
+			if !reflect.DeepEqual(reasons, tt.wantReasons) {
+				t.Errorf("cullGrouped() culled for %v, want %v", reasons, tt.wantReasons)
+			}
+		})
+	}
+
+	_, removed := cullGrouped(append([]match{}, matches...), 50, 1, cullByDatabase)
+	var out strings.Builder
+	if err := writeCulledMatches(&out, removed); err != nil {
+		t.Fatal(err)
+	}
+	if want := "shorter\tcostly\t101\t301\t201\tengulfed by long (1-501)\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("writeCulledMatches() = %q, want a line %q", out.String(), want)
+	}
+}
+
 func Test_isMismatch(t *testing.T) {
 	c := config.New()
 	c.PcrPrimerMaxOfftargetTm = 40.0
@@ -206,7 +324,7 @@ func Test_parentMismatch(t *testing.T) {
 			true,
 			match{
 				entry:       "addgene:107006",
-				uniqueID:    "addgene:107006-0",
+				uniqueID:    "addgene:107006-0+",
 				seq:         "AGTATAGTAGGTAGTCATTCTT",
 				querySeq:    "AGTATAGGATAGGTAGTCATTCTT",
 				queryStart:  0,
@@ -238,6 +356,13 @@ func Test_parentMismatch(t *testing.T) {
 			gotMatch.subjectEnd = 0
 			gotMatch.queryRevCompMatch = false
 			gotMatch.subjectRevCompMatch = false
+			gotMatch.subjectLength = 0
+
+			// the unique ID ends with the subject start
+			if !strings.HasPrefix(gotMatch.uniqueID, tt.wantMatch.uniqueID) {
+				t.Errorf("parentMismatch() gotMatch.uniqueID = %s, want it to start with %s", gotMatch.uniqueID, tt.wantMatch.uniqueID)
+			}
+			gotMatch.uniqueID = tt.wantMatch.uniqueID
 
 			if !reflect.DeepEqual(gotMatch, tt.wantMatch) {
 				t.Errorf("parentMismatch() gotMatch = %+v, want %+v", gotMatch, tt.wantMatch)
@@ -281,7 +406,7 @@ func Test_queryDatabases(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotF, err := queryDatabases(tt.args.entry, tt.args.dbs)
+			gotF, err := queryDatabases(tt.args.entry, tt.args.dbs, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("queryDatabases() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -289,7 +414,7 @@ func Test_queryDatabases(t *testing.T) {
 			if gotF.ID != tt.wantF.ID {
 				t.Errorf("queryDatabases().ID = %v, want %v", gotF.ID, tt.wantF.ID)
 			}
-			if gotF.db != tt.wantF.db {
+			if !reflect.DeepEqual(gotF.db, tt.wantF.db) {
 				t.Errorf("queryDatabases().DB = %v, want %v", gotF.db, tt.wantF.db)
 			}
 		})
@@ -325,3 +450,148 @@ func Test_blastdbcmd(t *testing.T) {
 		})
 	}
 }
+
+func Test_extendIdenticalEnds(t *testing.T) {
+	query := "ATGCGTACGTTAGCCGATCGATCGGCTAGCTAGGCTTACG"
+	subject := "TTTT" + query + "GGGG"
+
+	dbFile, err := os.CreateTemp("", "extend-db-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(dbFile.Name())
+	defer os.Remove(dbIndexPath(dbFile.Name()))
+	dbFile.WriteString(">fwd \n" + subject + "\n>rev \n" + reverseComplement(subject) + "\n")
+	dbFile.Close()
+	db := DB{Name: "extend", Path: dbFile.Name()}
+
+	type args struct {
+		m          match
+		circular   bool
+		leftMargin int
+	}
+	tests := []struct {
+		name             string
+		args             args
+		wantQueryStart   int
+		wantQueryEnd     int
+		wantSubjectStart int
+		wantSubjectEnd   int
+	}{
+		{
+			"extend forward match to identical ends",
+			args{match{entry: "fwd", db: db, queryStart: 5, queryEnd: 30, subjectStart: 9, subjectEnd: 34}, false, 0},
+			0, 39, 4, 43,
+		},
+		{
+			"extend reverse complement match",
+			args{match{entry: "rev", db: db, queryStart: 3, queryEnd: 35, subjectStart: 8, subjectEnd: 40, subjectRevCompMatch: true}, false, 0},
+			0, 39, 4, 43,
+		},
+		{
+			"respect circular left margin",
+			args{match{entry: "fwd", db: db, queryStart: 5, queryEnd: 30, subjectStart: 9, subjectEnd: 34}, true, 3},
+			3, 39, 7, 43,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := []match{tt.args.m}
+			if err := extendIdenticalEnds(matches, query, tt.args.circular, tt.args.leftMargin); err != nil {
+				t.Fatal(err)
+			}
+
+			m := matches[0]
+			if m.queryStart != tt.wantQueryStart || m.queryEnd != tt.wantQueryEnd {
+				t.Errorf("extendIdenticalEnds() query = %d-%d, want %d-%d", m.queryStart, m.queryEnd, tt.wantQueryStart, tt.wantQueryEnd)
+			}
+			if m.subjectStart != tt.wantSubjectStart || m.subjectEnd != tt.wantSubjectEnd {
+				t.Errorf("extendIdenticalEnds() subject = %d-%d, want %d-%d", m.subjectStart, m.subjectEnd, tt.wantSubjectStart, tt.wantSubjectEnd)
+			}
+			if m.querySeq != m.seq {
+				t.Errorf("extendIdenticalEnds() seq = %s, want %s", m.seq, m.querySeq)
+			}
+		})
+	}
+}
+
+func Test_match_mismatchIndexes(t *testing.T) {
+	tests := []struct {
+		name string
+		m    match
+		want []int
+	}{
+		{
+			"identical",
+			match{querySeq: "ACGTACGT", seq: "ACGTACGT", queryStart: 10},
+			nil,
+		},
+		{
+			"mismatches are indexed on the query",
+			match{querySeq: "ACGTACGT", seq: "ACCTACGA", queryStart: 10, mismatching: 2},
+			[]int{12, 17},
+		},
+		{
+			"case is ignored",
+			match{querySeq: "acgtacgt", seq: "ACCTACGT", queryStart: 0, mismatching: 1},
+			[]int{2},
+		},
+		{
+			"gapped alignment",
+			match{querySeq: "ACGTACGT", seq: "ACGACGT", queryStart: 10, mismatching: 1, gaps: 1},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.mismatchIndexes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("match.mismatchIndexes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_match_matchID(t *testing.T) {
+	seqLen := 1000
+	fwd := match{entry: "pSB1A3", queryStart: 950, subjectStart: 100}
+	tests := []struct {
+		name     string
+		m        match
+		wantSame bool
+	}{
+		{
+			"copy in the second half of a circular target",
+			match{entry: "pSB1A3", queryStart: 1950, subjectStart: 100},
+			true,
+		},
+		{
+			"copy against the second half of a circular subject",
+			match{entry: "pSB1A3", queryStart: 1950, subjectStart: 2100, circular: true, subjectLength: 4000},
+			true,
+		},
+		{
+			"reverse complement match at the same position",
+			match{entry: "pSB1A3", queryStart: 1950, subjectStart: 100, subjectRevCompMatch: true},
+			false,
+		},
+		{
+			"other region of the subject at the same position",
+			match{entry: "pSB1A3", queryStart: 1950, subjectStart: 700},
+			false,
+		},
+		{
+			"other entry",
+			match{entry: "pUC19", queryStart: 1950, subjectStart: 100},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fwd := fwd
+			fwd.circular, fwd.subjectLength = tt.m.circular, tt.m.subjectLength
+			if got := fwd.matchID(seqLen) == tt.m.matchID(seqLen); got != tt.wantSame {
+				t.Errorf("matchID() %s == %s is %t, want %t", fwd.matchID(seqLen), tt.m.matchID(seqLen), got, tt.wantSame)
+			}
+		})
+	}
+}
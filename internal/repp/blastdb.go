@@ -0,0 +1,223 @@
+package repp
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// blastMaxVolumeSize is the largest volume makeblastdb accepts for -max_file_sz
+const blastMaxVolumeSize = 4 << 30
+
+// fileSizeRegex matches sizes like makeblastdb's -max_file_sz, ex: 500MB, 10M, 1GB
+var fileSizeRegex = regexp.MustCompile(`(?i)^\s*(\d+)\s*([KMGT]?)B?\s*$`)
+
+// parseBytes returns the bytes of a size like 500MB, 10M or 1GB, false if it isn't one
+func parseBytes(size string) (int64, bool) {
+	m := fileSizeRegex.FindStringSubmatch(size)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch strings.ToUpper(m[2]) {
+	case "K":
+		n <<= 10
+	case "M":
+		n <<= 20
+	case "G":
+		n <<= 30
+	case "T":
+		n <<= 40
+	}
+	return n, true
+}
+
+// parseFileSize returns the bytes of a BLAST volume size like 500MB, 10M or 1GB
+func parseFileSize(size string) (int64, error) {
+	n, ok := parseBytes(size)
+	if !ok {
+		return 0, fmt.Errorf("invalid BLAST volume size %q, ex: 500MB or 1GB", size)
+	}
+	if n <= 0 || n > blastMaxVolumeSize {
+		return 0, fmt.Errorf("BLAST volume size %s is out of range, it's at most 4GB", size)
+	}
+	return n, nil
+}
+
+// estimateVolumes returns the number of volumes makeblastdb will split a FASTA file
+// into. Sequences are packed 4 bases per byte, so the sequence files are about a
+// quarter of the FASTA file's size
+func estimateVolumes(fastaSize, maxFileSize int64) int {
+	seqSize := fastaSize / 4
+	volumes := int((seqSize + maxFileSize - 1) / maxFileSize)
+	if volumes < 1 {
+		return 1
+	}
+	return volumes
+}
+
+// checkVolumes validates the volume size of a database and warns if its FASTA file
+// would be split into many volumes, each opened by every query against it
+func checkVolumes(dbPath, maxFileSize string) error {
+	maxBytes, err := parseFileSize(maxFileSize)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return err
+	}
+	if volumes := estimateVolumes(info.Size(), maxBytes); volumes > 1 {
+		rlog.Warnf("%s will be split into about %d BLAST volumes of %s, each opened by every query - consider a larger --max-file-sz (at most 4GB)",
+			path.Base(dbPath), volumes, maxFileSize)
+	}
+	return nil
+}
+
+// Alias is a BLAST alias database spanning several sequence databases, so they're
+// queried with one BLAST run rather than one per database
+type Alias struct {
+	// Name of the alias
+	Name string `json:"name"`
+
+	// Path to the alias database, without its .nal extension
+	Path string `json:"path"`
+
+	// DBs are the names of the databases it spans
+	DBs []string `json:"dbs"`
+}
+
+// AddAlias builds a BLAST alias database with blastdb_aliastool spanning the named
+// databases. Queries against all of them use the alias instead
+func AddAlias(name string, dbNames []string) error {
+	if len(dbNames) < 2 {
+		return fmt.Errorf("an alias spans at least 2 databases, got %d", len(dbNames))
+	}
+
+	m, err := newManifest()
+	if err != nil {
+		return err
+	}
+	if _, ok := m.DBs[name]; ok {
+		return fmt.Errorf("%s is already the name of a database", name)
+	}
+
+	var paths []string
+	for _, dbName := range dbNames {
+		db, ok := m.DBs[dbName]
+		if !ok {
+			return fmt.Errorf("no database named %s - known databases: %v", dbName, m.GetNames())
+		}
+		paths = append(paths, db.Path)
+	}
+
+	aliasDir := path.Join(config.SeqDatabaseDir, name)
+	if err = os.MkdirAll(aliasDir, 0755); err != nil {
+		return err
+	}
+	alias := Alias{Name: name, Path: path.Join(aliasDir, name), DBs: dbNames}
+
+	cmd := exec.Command(
+		getExecutable("NCBITOOLS_HOME", "bin", "blastdb_aliastool"),
+		"-dblist", strings.Join(paths, " "),
+		"-dbtype", "nucl",
+		"-out", alias.Path,
+		"-title", name,
+	)
+	rlog.Debugf("Run: %v", cmd.Args)
+	if stdout, err := commands.combinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to blastdb_aliastool: %s %w", string(stdout), err)
+	}
+
+	if m.Aliases == nil {
+		m.Aliases = map[string]Alias{}
+	}
+	m.Aliases[name] = alias
+	return m.save()
+}
+
+// blastTarget is a database to BLAST against: a sequence database,
+// or an alias and the databases it spans
+type blastTarget struct {
+	db      DB
+	members []DB
+}
+
+// blastTargets groups the databases into the aliases spanning them. An alias is used
+// if all its databases are queried and none is in an alias already used
+func blastTargets(dbs []DB, aliases map[string]Alias) (targets []blastTarget) {
+	byName := make(map[string]DB)
+	for _, db := range dbs {
+		byName[db.Name] = db
+	}
+
+	var names []string
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	used := make(map[string]bool)
+	for _, name := range names {
+		alias := aliases[name]
+		var members []DB
+		for _, dbName := range alias.DBs {
+			if db, ok := byName[dbName]; ok && !used[dbName] {
+				members = append(members, db)
+			}
+		}
+		if len(members) < 2 || len(members) != len(alias.DBs) {
+			continue
+		}
+		if _, err := os.Stat(alias.Path + ".nal"); err != nil {
+			continue
+		}
+		for _, db := range members {
+			used[db.Name] = true
+		}
+		targets = append(targets, blastTarget{db: DB{Name: alias.Name, Path: alias.Path}, members: members})
+	}
+
+	for _, db := range dbs {
+		if !used[db.Name] {
+			targets = append(targets, blastTarget{db: db})
+		}
+	}
+	return targets
+}
+
+// indexMembers loads the lookup indexes of an alias's databases, to find which one
+// each match's entry is from
+func (b *blastExec) indexMembers() (err error) {
+	b.memberIndexes = make([]*lookupIndex, len(b.members))
+	for i, db := range b.members {
+		if b.memberIndexes[i], err = db.lookupIndex(); err != nil {
+			return fmt.Errorf("failed to index %s: %v", db.Name, err)
+		}
+	}
+	return nil
+}
+
+// entryDB returns the database with an entry: the queried database, or the first of
+// an alias's databases with it. It's false if none of the alias's databases have it
+func (b *blastExec) entryDB(entry string) (DB, bool) {
+	if len(b.members) == 0 {
+		return b.db, true
+	}
+	for i, idx := range b.memberIndexes {
+		if _, ok := idx.entry(entry); ok {
+			return b.members[i], true
+		}
+	}
+	return DB{}, false
+}
@@ -0,0 +1,110 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parseFileSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{"10M", 10 << 20, false},
+		{"500MB", 500 << 20, false},
+		{"1GB", 1 << 30, false},
+		{"4gb", 4 << 30, false},
+		{"2048", 2048, false},
+		{"5GB", 0, true},
+		{"0MB", 0, true},
+		{"big", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := parseFileSize(tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFileSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseFileSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_estimateVolumes(t *testing.T) {
+	if got := estimateVolumes(100, 10<<20); got != 1 {
+		t.Errorf("estimateVolumes() = %d, want 1", got)
+	}
+	if got := estimateVolumes(4*(25<<20), 10<<20); got != 3 {
+		t.Errorf("estimateVolumes() = %d, want 3", got)
+	}
+}
+
+func Test_blastTargets(t *testing.T) {
+	dir := t.TempDir()
+	aliasPath := filepath.Join(dir, "lab")
+	if err := os.WriteFile(aliasPath+".nal", []byte("DBLIST a b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	aliases := map[string]Alias{
+		"lab":     {Name: "lab", Path: aliasPath, DBs: []string{"a", "b"}},
+		"missing": {Name: "missing", Path: filepath.Join(dir, "missing"), DBs: []string{"c", "d"}},
+	}
+	a, b, c, d := DB{Name: "a"}, DB{Name: "b"}, DB{Name: "c"}, DB{Name: "d"}
+
+	tests := []struct {
+		name string
+		dbs  []DB
+		want string
+	}{
+		{"alias spans the databases", []DB{a, b, c}, "lab[a b],c"},
+		{"alias missing a database", []DB{a, c}, "a,c"},
+		{"alias not built", []DB{c, d}, "c,d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, target := range blastTargets(tt.dbs, aliases) {
+				name := target.db.Name
+				if len(target.members) > 0 {
+					name += "[" + strings.Join(dbNames(target.members), " ") + "]"
+				}
+				got = append(got, name)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("blastTargets() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_blastExec_entryDB(t *testing.T) {
+	a, b := DB{Name: "a"}, DB{Name: "b"}
+	builderA, builderB := newIndexBuilder(), newIndexBuilder()
+	builderA.add(indexEntry{ID: "pSB1A3"})
+	builderB.add(indexEntry{ID: "pSB1A3"})
+	builderB.add(indexEntry{ID: "pUC19"})
+	idxA, err := builderA.inMemory("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idxB, err := builderB.inMemory("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exec := &blastExec{db: DB{Name: "lab"}, members: []DB{a, b}, memberIndexes: []*lookupIndex{idxA, idxB}}
+	if db, ok := exec.entryDB("pSB1A3"); !ok || db.Name != "a" {
+		t.Errorf("entryDB(pSB1A3) = %s, %t, want a", db.Name, ok)
+	}
+	if db, ok := exec.entryDB("pUC19"); !ok || db.Name != "b" {
+		t.Errorf("entryDB(pUC19) = %s, %t, want b", db.Name, ok)
+	}
+	if _, ok := exec.entryDB("pET28"); ok {
+		t.Error("entryDB(pET28) should be in none of the alias's databases")
+	}
+}
@@ -0,0 +1,213 @@
+package repp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/exp/maps"
+)
+
+// builtinFetchTimeout is how long GenBank has to return each sequence of a builtin database
+const builtinFetchTimeout = 60 * time.Second
+
+// mcsMaxSiteGap is the most bp between neighboring sites of a multiple cloning site
+const mcsMaxSiteGap = 30
+
+// mcsMinSites is the fewest unique sites in a multiple cloning site
+const mcsMinSites = 3
+
+// ncbiFetchURL is the NCBI E-utilities endpoint that the sequences of builtin databases are fetched from
+var ncbiFetchURL = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/efetch.fcgi"
+
+// builtinBackbone is a common cloning vector of a builtin database
+type builtinBackbone struct {
+	// name of the vector, its ID in the database
+	name string
+
+	// accession of the vector's GenBank record
+	accession string
+
+	// description of the vector
+	description string
+
+	// length of the vector's sequence, that the fetched sequence is verified against
+	length int
+}
+
+// builtinDatabases are the curated databases that can be installed with 'repp add database --builtin'
+var builtinDatabases = map[string][]builtinBackbone{
+	"backbones": {
+		{name: "pUC19", accession: "L09137", description: "high copy cloning vector, AmpR, lacZ alpha", length: 2686},
+		{name: "pBR322", accession: "J01749", description: "medium copy cloning vector, AmpR, TetR", length: 4361},
+		{name: "pACYC184", accession: "X06403", description: "low copy p15A cloning vector, CmR, TetR", length: 4245},
+	},
+}
+
+// BuiltinDatabases returns the sorted names of the builtin databases. Used for shell completion.
+func BuiltinDatabases() []string {
+	names := maps.Keys(builtinDatabases)
+	sort.Strings(names)
+	return names
+}
+
+// AddBuiltinDatabase installs a builtin database, ex: "backbones", as the sequence database dbName.
+// Its sequences are fetched from GenBank and verified against their expected lengths, and the
+// multiple cloning site of each is printed with the unique restriction sites in it
+func AddBuiltinDatabase(builtin, dbName string, cost float64, strict bool) error {
+	backbones, ok := builtinDatabases[builtin]
+	if !ok {
+		return fmt.Errorf("no builtin database named %s; valid values %v", builtin, BuiltinDatabases())
+	}
+
+	dir, err := os.MkdirTemp("", "repp-builtin-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var seqFiles []string
+	seqs := make(map[string]string)
+	for _, b := range backbones {
+		seq, err := fetchBuiltinBackbone(b)
+		if err != nil {
+			return err
+		}
+		seqs[b.name] = seq
+
+		seqFile := filepath.Join(dir, b.name+".fa")
+		if err = writeFileAtomic(seqFile, []byte(fmt.Sprintf(">%s %s\n%s\n", b.name, b.description, seq))); err != nil {
+			return err
+		}
+		seqFiles = append(seqFiles, seqFile)
+	}
+
+	if err = AddDatabase(dbName, seqFiles, ImportOptions{Circularize: true, Cost: cost, Screen: noContaminantScreen, Strict: strict}); err != nil {
+		return err
+	}
+
+	enzymeDB := NewEnzymeDB()
+	enzymeNames := maps.Keys(enzymeDB.contents)
+	sort.Strings(enzymeNames)
+	var enzymes []enzyme
+	for _, name := range enzymeNames {
+		if e := newEnzyme(name, enzymeDB.contents[name]); e.name != "" {
+			enzymes = append(enzymes, e)
+		}
+	}
+	return printBuiltinBackbones(os.Stdout, dbName, backbones, seqs, enzymes)
+}
+
+// fetchBuiltinBackbone returns the sequence of a builtin backbone from its GenBank record. It
+// fails if the sequence isn't the expected length, ex: if the record was revised
+func fetchBuiltinBackbone(b builtinBackbone) (string, error) {
+	params := url.Values{"db": {"nuccore"}, "id": {b.accession}, "rettype": {"fasta"}, "retmode": {"text"}}
+	resp, err := httpClient(builtinFetchTimeout).Get(ncbiFetchURL + "?" + params.Encode())
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s (GenBank %s): %v", b.name, b.accession, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to fetch %s (GenBank %s): %s", b.name, b.accession, resp.Status)
+	}
+
+	seq, err := readFastaSeq(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s (GenBank %s): %v", b.name, b.accession, err)
+	}
+	if len(seq) != b.length {
+		return "", fmt.Errorf("%s (GenBank %s) is %d bp, expected %d bp", b.name, b.accession, len(seq), b.length)
+	}
+	if !dnaRegex.MatchString(seq) {
+		return "", fmt.Errorf("%s (GenBank %s) has bases other than ACGT", b.name, b.accession)
+	}
+	return seq, nil
+}
+
+// readFastaSeq returns the uppercase sequence of the first entry of a FASTA
+func readFastaSeq(r io.Reader) (string, error) {
+	var seq strings.Builder
+	scanner := bufio.NewScanner(r)
+	headers := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, ">") {
+			if headers++; headers > 1 {
+				break
+			}
+			continue
+		}
+		seq.WriteString(strings.ToUpper(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if headers == 0 || seq.Len() == 0 {
+		return "", fmt.Errorf("no FASTA sequence")
+	}
+	return seq.String(), nil
+}
+
+// multipleCloningSite returns the span of the largest cluster of unique restriction sites in a
+// circular backbone, each at most mcsMaxSiteGap bp from the next, and the enzymes that cut them.
+// ok is false if no cluster has mcsMinSites
+func multipleCloningSite(seq string, enzymes []enzyme) (start, end int, names []string, ok bool) {
+	cuts, _ := cutsites(seq, enzymes)
+	counts := make(map[string]int)
+	for _, c := range cuts {
+		counts[c.enzyme.name]++
+	}
+	var unique []cut
+	for _, c := range cuts {
+		if counts[c.enzyme.name] == 1 {
+			unique = append(unique, c)
+		}
+	}
+
+	best := []cut{}
+	var cluster []cut
+	for _, c := range unique {
+		if len(cluster) > 0 && c.index-cluster[len(cluster)-1].index > mcsMaxSiteGap {
+			cluster = nil
+		}
+		cluster = append(cluster, c)
+		if len(cluster) > len(best) {
+			best = append([]cut{}, cluster...)
+		}
+	}
+	if len(best) < mcsMinSites {
+		return 0, 0, nil, false
+	}
+
+	start, end = best[0].index, best[0].index
+	for _, c := range best {
+		if e := c.index + len(c.enzyme.recog); e > end {
+			end = e
+		}
+		names = append(names, c.enzyme.name)
+	}
+	return start, end, names, true
+}
+
+// printBuiltinBackbones writes the backbones of a builtin database and their multiple cloning sites
+func printBuiltinBackbones(out io.Writer, dbName string, backbones []builtinBackbone, seqs map[string]string, enzymes []enzyme) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(w, "added %d backbones to %s\n\n", len(backbones), dbName)
+	fmt.Fprintf(w, "backbone\tGenBank\tbp\tMCS\tunique sites\tdescription\n")
+	for _, b := range backbones {
+		mcs, sites := "-", "-"
+		if start, end, names, ok := multipleCloningSite(seqs[b.name], enzymes); ok {
+			mcs = fmt.Sprintf("%d-%d", start+1, end)
+			sites = strings.Join(names, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", b.name, b.accession, b.length, mcs, sites, b.description)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,59 @@
+package repp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_fetchBuiltinBackbone(t *testing.T) {
+	seq := randomSeq(120, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "X00001" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, ">X00001.1 Cloning vector\n%s\n%s\n", strings.ToLower(seq[:60]), seq[60:])
+	}))
+	defer server.Close()
+
+	defaultURL := ncbiFetchURL
+	ncbiFetchURL = server.URL
+	defer func() { ncbiFetchURL = defaultURL }()
+
+	got, err := fetchBuiltinBackbone(builtinBackbone{name: "pTest", accession: "X00001", length: 120})
+	if err != nil || got != seq {
+		t.Errorf("fetchBuiltinBackbone() = %s, %v, want %s", got, err, seq)
+	}
+	if _, err := fetchBuiltinBackbone(builtinBackbone{name: "pTest", accession: "X00001", length: 100}); err == nil {
+		t.Error("fetchBuiltinBackbone() of an unexpected length, want an error")
+	}
+	if _, err := fetchBuiltinBackbone(builtinBackbone{name: "pMissing", accession: "X00002", length: 120}); err == nil {
+		t.Error("fetchBuiltinBackbone() of a missing record, want an error")
+	}
+}
+
+func Test_multipleCloningSite(t *testing.T) {
+	enzymes := []enzyme{
+		newEnzyme("EcoRI", "G^AATT_C"),
+		newEnzyme("BamHI", "G^GATC_C"),
+		newEnzyme("HindIII", "A^AGCT_T"),
+		newEnzyme("PstI", "C_TGCA^G"),
+	}
+
+	// EcoRI, BamHI and HindIII sites 10bp apart, PstI cuts twice
+	backbone := strings.Repeat("T", 100) + "GAATTC" + strings.Repeat("T", 10) + "GGATCC" + strings.Repeat("T", 10) + "AAGCTT" +
+		strings.Repeat("T", 100) + "CTGCAG" + strings.Repeat("T", 100) + "CTGCAG" + strings.Repeat("T", 100)
+
+	start, end, names, ok := multipleCloningSite(backbone, enzymes)
+	if !ok || start != 100 || end != 138 || !reflect.DeepEqual(names, []string{"EcoRI", "BamHI", "HindIII"}) {
+		t.Errorf("multipleCloningSite() = %d, %d, %v, %v, want 100, 138, [EcoRI BamHI HindIII]", start, end, names, ok)
+	}
+
+	if _, _, _, ok := multipleCloningSite(strings.Repeat("T", 100)+"GAATTC"+strings.Repeat("T", 100), enzymes); ok {
+		t.Error("multipleCloningSite() of a backbone with one site, want none")
+	}
+}
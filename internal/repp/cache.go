@@ -0,0 +1,340 @@
+package repp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+const (
+	// blastCacheKind is the kind of cached BLAST outputs. They're cached by database
+	blastCacheKind = "blast"
+
+	// primersCacheKind is the kind of cached primer3 outputs
+	primersCacheKind = "primers"
+
+	// cacheTempPrefix prefixes the files of cache entries still being written
+	cacheTempPrefix = ".tmp-"
+)
+
+// resultCache caches the outputs of BLAST and primer3 in the repp data directory.
+//
+// Entries are files named by a hash of their inputs, in a directory per kind. Reading
+// an entry updates its modification time, and the least recently used entries are
+// evicted once the cache is larger than its size cap. The cache's size is read from
+// disk once, then tracked as entries are written, so writes don't walk the cache.
+type resultCache struct {
+	// dir is the root directory of the cache
+	dir string
+
+	// maxBytes is the size cap of the cache
+	maxBytes int64
+
+	// mu guards size and sized
+	mu sync.Mutex
+
+	// size is the cache's size in bytes, as of the last walk plus the entries written since
+	size int64
+
+	// sized is whether size was read from disk
+	sized bool
+}
+
+// openCaches are the result caches opened in this process, by directory, so each
+// cache's size is only read from disk once
+var (
+	openCaches   = make(map[string]*resultCache)
+	openCachesMu sync.Mutex
+)
+
+// cacheEntry is a single cached result
+type cacheEntry struct {
+	// path to the entry's file
+	path string
+
+	// kind of the entry, ex: "primers" or "blast/igem"
+	kind string
+
+	// size of the entry in bytes
+	size int64
+
+	// used is when the entry was last written or read
+	used time.Time
+}
+
+// openCache returns the result cache. It's nil, and caches nothing, if the size cap is 0.
+func openCache(conf *config.Config) *resultCache {
+	if conf == nil || conf.CacheMaxSizeMB <= 0 || config.CacheDir == "" {
+		return nil
+	}
+
+	openCachesMu.Lock()
+	defer openCachesMu.Unlock()
+	rc, ok := openCaches[config.CacheDir]
+	if !ok {
+		rc = &resultCache{dir: config.CacheDir}
+		openCaches[config.CacheDir] = rc
+	}
+	rc.mu.Lock()
+	rc.maxBytes = int64(conf.CacheMaxSizeMB) << 20
+	rc.mu.Unlock()
+	return rc
+}
+
+// blastDBCacheKind returns the kind of the cached BLAST outputs against a database
+func blastDBCacheKind(dbName string) string {
+	return filepath.Join(blastCacheKind, dbName)
+}
+
+// cacheKey returns the key of a cache entry from all the inputs that determine its result
+func cacheKey(inputs ...string) string {
+	h := sha256.New()
+	for _, input := range inputs {
+		h.Write([]byte(input))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns a cached result and marks it as recently used
+func (c *resultCache) get(kind, key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	entryPath := filepath.Join(c.dir, kind, key)
+	contents, err := os.ReadFile(entryPath)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(entryPath, now, now)
+
+	rlog.Debugf("Read cached %s result %s", kind, key)
+	return contents, true
+}
+
+// put caches a result and evicts the least recently used results if the cache is over its size cap.
+// Failing to cache is logged but not returned: the result was computed regardless.
+func (c *resultCache) put(kind, key string, contents []byte) {
+	if c == nil {
+		return
+	}
+
+	kindDir := filepath.Join(c.dir, kind)
+	if err := os.MkdirAll(kindDir, 0755); err != nil {
+		rlog.Warnf("failed to create cache directory %s: %v", kindDir, err)
+		return
+	}
+
+	// the size of the entry this replaces, if any
+	entryPath := filepath.Join(kindDir, key)
+	var replaced int64
+	if info, err := os.Stat(entryPath); err == nil {
+		replaced = info.Size()
+	}
+
+	// write to a temporary file first so concurrent runs never read a partial entry
+	tmp, err := os.CreateTemp(kindDir, cacheTempPrefix+"*")
+	if err != nil {
+		rlog.Warnf("failed to cache %s result: %v", kind, err)
+		return
+	}
+	_, err = tmp.Write(contents)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), entryPath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		rlog.Warnf("failed to cache %s result: %v", kind, err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sized {
+		c.size += int64(len(contents)) - replaced
+		if c.size <= c.maxBytes {
+			return
+		}
+	}
+	if err = c.evict(); err != nil {
+		rlog.Warnf("failed to evict cached results: %v", err)
+	}
+}
+
+// evict reads the cache's size from disk and removes the least recently used entries
+// until the cache is within its size cap. The caller holds c.mu
+func (c *resultCache) evict() error {
+	entries, err := cacheEntries(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	c.size, c.sized = total, true
+	if total <= c.maxBytes {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].used.Before(entries[j].used)
+	})
+	for _, e := range entries {
+		if total <= c.maxBytes {
+			break
+		}
+		if err = os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= e.size
+		c.size = total
+	}
+	return nil
+}
+
+// cacheEntries returns all the entries in a cache directory
+func cacheEntries(dir string) (entries []cacheEntry, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), cacheTempPrefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed by a concurrent eviction
+		}
+		kind, _ := filepath.Rel(dir, filepath.Dir(path))
+		entries = append(entries, cacheEntry{
+			path: path,
+			kind: filepath.ToSlash(kind),
+			size: info.Size(),
+			used: info.ModTime(),
+		})
+		return nil
+	})
+	return entries, err
+}
+
+// purgeCache removes the cached results of a kind, or every cached result if kind is empty.
+func purgeCache(dir, kind string) (removed int, size int64, err error) {
+	entries, err := cacheEntries(filepath.Join(dir, kind))
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, e := range entries {
+		removed++
+		size += e.size
+	}
+	return removed, size, os.RemoveAll(filepath.Join(dir, kind))
+}
+
+// purgeDBCache removes the cached BLAST outputs against a database
+func purgeDBCache(dbName string) {
+	removed, size, err := purgeCache(config.CacheDir, blastDBCacheKind(dbName))
+	if err != nil {
+		rlog.Warnf("failed to purge the cached results of %s: %v", dbName, err)
+	} else if removed > 0 {
+		rlog.Infof("Purged %d cached results (%s) of %s", removed, formatBytes(size), dbName)
+	}
+}
+
+// CacheStats prints the number and size of the cached results by kind.
+func CacheStats(conf *config.Config) {
+	entries, err := cacheEntries(config.CacheDir)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+
+	type kindStats struct {
+		count int
+		size  int64
+		used  time.Time
+	}
+	stats := make(map[string]*kindStats)
+	var total kindStats
+	for _, e := range entries {
+		if _, ok := stats[e.kind]; !ok {
+			stats[e.kind] = &kindStats{}
+		}
+		for _, s := range []*kindStats{stats[e.kind], &total} {
+			s.count++
+			s.size += e.size
+			if e.used.After(s.used) {
+				s.used = e.used
+			}
+		}
+	}
+
+	kinds := make([]string, 0, len(stats))
+	for kind := range stats {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	cacheLimit := "disabled"
+	if conf.CacheMaxSizeMB > 0 {
+		cacheLimit = formatBytes(int64(conf.CacheMaxSizeMB) << 20)
+	}
+	fmt.Printf("cache %s (limit %s)\n\n", config.CacheDir, cacheLimit)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(w, "kind\tentries\tsize\tlast used\n")
+	for _, kind := range kinds {
+		s := stats[kind]
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", kind, s.count, formatBytes(s.size), outputTime(s.used))
+	}
+	fmt.Fprintf(w, "total\t%d\t%s\t\n", total.count, formatBytes(total.size))
+	w.Flush()
+}
+
+// PurgeCache removes the cached BLAST outputs against a database, or every cached result if dbName is empty.
+func PurgeCache(dbName string) {
+	kind := ""
+	if dbName != "" {
+		kind = blastDBCacheKind(dbName)
+	}
+
+	removed, size, err := purgeCache(config.CacheDir, kind)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	fmt.Printf("purged %d cached results (%s)\n", removed, formatBytes(size))
+}
+
+// formatBytes returns a human readable size, ex: "1.5MB"
+func formatBytes(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d%s", size, units[0])
+	}
+	return fmt.Sprintf("%.1f%s", value, units[unit])
+}
@@ -0,0 +1,105 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_resultCache(t *testing.T) {
+	dir := t.TempDir()
+	rc := &resultCache{dir: dir, maxBytes: 25}
+
+	rc.put(primersCacheKind, "a", []byte("0123456789"))
+	rc.put(blastDBCacheKind("igem"), "b", []byte("0123456789"))
+
+	// mark "a" as less recently used than "b", then read it so it's the most recently used
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, primersCacheKind, "a"), old, old)
+	os.Chtimes(filepath.Join(dir, blastDBCacheKind("igem"), "b"), old.Add(time.Minute), old.Add(time.Minute))
+	if got, ok := rc.get(primersCacheKind, "a"); !ok || string(got) != "0123456789" {
+		t.Fatalf("get() = %q, %v, want the cached result", got, ok)
+	}
+
+	// over the size cap, "b" is evicted as the least recently used
+	rc.put(primersCacheKind, "c", []byte("0123456789"))
+	if _, ok := rc.get(blastDBCacheKind("igem"), "b"); ok {
+		t.Error("put() did not evict the least recently used result")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := rc.get(primersCacheKind, key); !ok {
+			t.Errorf("put() evicted %s, a recently used result", key)
+		}
+	}
+
+	entries, err := cacheEntries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].kind != primersCacheKind {
+		t.Errorf("cacheEntries() = %+v, want the two primers results", entries)
+	}
+
+	removed, size, err := purgeCache(dir, primersCacheKind)
+	if err != nil || removed != 2 || size != 20 {
+		t.Errorf("purgeCache() = %d, %d, %v, want 2 results of 20 bytes removed", removed, size, err)
+	}
+	if _, ok := rc.get(primersCacheKind, "a"); ok {
+		t.Error("purgeCache() did not remove the results")
+	}
+
+	var disabled *resultCache
+	disabled.put(primersCacheKind, "a", []byte("0123456789"))
+	if _, ok := disabled.get(primersCacheKind, "a"); ok {
+		t.Error("get() on a disabled cache returned a result")
+	}
+}
+
+func Test_resultCache_size(t *testing.T) {
+	dir := t.TempDir()
+	rc := &resultCache{dir: dir, maxBytes: 100}
+
+	rc.put(primersCacheKind, "a", []byte("0123456789"))
+	rc.put(primersCacheKind, "b", []byte("0123456789"))
+	rc.put(primersCacheKind, "a", []byte("01234"))
+	if !rc.sized || rc.size != 15 {
+		t.Errorf("put() size = %d, %v, want 15 bytes tracked without a walk per write", rc.size, rc.sized)
+	}
+
+	// entries written by another process are counted once the cache is over its cap
+	if err := os.WriteFile(filepath.Join(dir, primersCacheKind, "other"), make([]byte, 90), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rc.put(primersCacheKind, "c", make([]byte, 90))
+	if rc.size > rc.maxBytes {
+		t.Errorf("put() size = %d, want the cache evicted to within its %d byte cap", rc.size, rc.maxBytes)
+	}
+}
+
+func Test_cacheKey(t *testing.T) {
+	if cacheKey("ab", "c") == cacheKey("a", "bc") {
+		t.Error("cacheKey() is the same for different inputs")
+	}
+	if cacheKey("a", "b") != cacheKey("a", "b") {
+		t.Error("cacheKey() differs for the same inputs")
+	}
+}
+
+func Test_formatBytes(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1536, "1.5KB"},
+		{5 << 20, "5.0MB"},
+		{3 << 30, "3.0GB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.size); got != tt.want {
+			t.Errorf("formatBytes(%d) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package repp
+
+import "strings"
+
+// circularSeq is the sequence of a circular plasmid. Its bases are indexed modulo its length,
+// so a range can span the zero-index, start before it or run past the end, without copying
+// the sequence end to end
+type circularSeq struct {
+	seq string
+}
+
+// newCircularSeq returns an upper-cased circular sequence
+func newCircularSeq(seq string) circularSeq {
+	return circularSeq{seq: strings.ToUpper(seq)}
+}
+
+// len returns the length of one copy of the sequence
+func (c circularSeq) len() int {
+	return len(c.seq)
+}
+
+// index returns the index of i in one copy of the sequence, [0, len)
+func (c circularSeq) index(i int) int {
+	n := len(c.seq)
+	return ((i % n) + n) % n
+}
+
+// get returns the bases in [start, end), wrapping around the zero-index as many times as needed
+func (c circularSeq) get(start, end int) string {
+	n := len(c.seq)
+	if n == 0 || end <= start {
+		return ""
+	}
+
+	from := c.index(start)
+	if from+end-start <= n {
+		return c.seq[from : from+end-start]
+	}
+
+	var b strings.Builder
+	b.Grow(end - start)
+	for remaining := end - start; remaining > 0; {
+		to := n
+		if from+remaining < n {
+			to = from + remaining
+		}
+		b.WriteString(c.seq[from:to])
+		remaining -= to - from
+		from = 0
+	}
+	return b.String()
+}
+
+// indexFrom returns the index of the first occurrence of seq at or after from on the sequence
+// doubled, as strings.Index would on the sequence concatenated to itself, without the copy.
+// It's -1 if seq isn't there or is longer than the sequence
+func (c circularSeq) indexFrom(seq string, from int) int {
+	n := len(c.seq)
+	if n == 0 || seq == "" || len(seq) > n || from < 0 || from >= 2*n {
+		return -1
+	}
+
+	// in the second copy, the occurrence can't run past its end
+	if from >= n {
+		if i := strings.Index(c.seq[from-n:], seq); i >= 0 {
+			return from + i
+		}
+		return -1
+	}
+
+	if i := strings.Index(c.seq[from:], seq); i >= 0 {
+		return from + i
+	}
+
+	// across the zero-index, only copying the bases around it
+	wrapFrom := n - len(seq) + 1
+	if wrapFrom < from {
+		wrapFrom = from
+	}
+	if i := strings.Index(c.seq[wrapFrom:]+c.seq[:len(seq)-1], seq); i >= 0 {
+		return wrapFrom + i
+	}
+
+	// in the second copy, before from
+	end := from + len(seq) - 1
+	if end > n {
+		end = n
+	}
+	if i := strings.Index(c.seq[:end], seq); i >= 0 {
+		return n + i
+	}
+	return -1
+}
@@ -0,0 +1,87 @@
+package repp
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_circularSeq_get(t *testing.T) {
+	c := newCircularSeq("atgcATGCgg")
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       string
+	}{
+		{"within", 2, 6, "GCAT"},
+		{"to the end", 6, 10, "GCGG"},
+		{"across the zero-index", 8, 12, "GGAT"},
+		{"before the zero-index", -2, 2, "GGAT"},
+		{"second copy", 12, 14, "GC"},
+		{"more than one copy", 8, 21, "GGATGCATGCGGA"},
+		{"empty", 4, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.get(tt.start, tt.end); got != tt.want {
+				t.Errorf("get(%d, %d) = %s, want %s", tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_circularSeq_indexFrom(t *testing.T) {
+	c := newCircularSeq("atgcATGCgg")
+	doubled := "ATGCATGCGGATGCATGCGG"
+
+	tests := []struct {
+		name string
+		seq  string
+		from int
+	}{
+		{"within", "GCAT", 0},
+		{"after from", "ATGC", 1},
+		{"across the zero-index", "GGAT", 0},
+		{"across the zero-index after from", "GGAT", 9},
+		{"second copy before from", "TGCA", 6},
+		{"from the second copy", "GCGG", 12},
+		{"missing", "TTTT", 0},
+		{"longer than the sequence", "ATGCATGCGGA", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := strings.Index(doubled[tt.from:], tt.seq)
+			if want >= 0 {
+				want += tt.from
+			}
+			if len(tt.seq) > c.len() {
+				want = -1
+			}
+			if got := c.indexFrom(tt.seq, tt.from); got != want {
+				t.Errorf("indexFrom(%s, %d) = %d, want %d", tt.seq, tt.from, got, want)
+			}
+		})
+	}
+}
+
+func Test_mutatePrimers_acrossZeroIndex(t *testing.T) {
+	f := &Frag{
+		start: 45,
+		end:   54,
+		Primers: []Primer{
+			{Seq: "TGGAG", Range: ranged{start: 45, end: 50}, Strand: true},
+			{Seq: "TGATC", Range: ranged{start: 49, end: 54}, Strand: false},
+		},
+	}
+	mutatePrimers(f, "GATCACTCGATGACCTCGGCTCCCCATTGCTACTACGGCGATTCTTGGAG", 3, 3)
+
+	if f.Seq != "TGGAGGATCA" {
+		t.Errorf("mutatePrimers() Seq = %s, want TGGAGGATCA", f.Seq)
+	}
+	if f.Primers[0].Seq != "TCTTGGAG" || f.Primers[1].Seq != "GAGTGATC" {
+		t.Errorf("mutatePrimers() primers = %s, %s, want TCTTGGAG, GAGTGATC", f.Primers[0].Seq, f.Primers[1].Seq)
+	}
+	if f.PCRSeq != "TCTTGGAGGATCACTC" {
+		t.Errorf("mutatePrimers() PCRSeq = %s, want TCTTGGAGGATCACTC", f.PCRSeq)
+	}
+}
@@ -0,0 +1,237 @@
+package repp
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// name collision strategies of the output-name-collisions setting
+const (
+	// incrementCollisions numbers new reagents past, and suffixes fragment IDs that collide with, previous outputs
+	incrementCollisions = "increment"
+
+	// errorCollisions fails before writing outputs with names that collide with previous outputs
+	errorCollisions = "error"
+
+	// ignoreCollisions doesn't check previous outputs
+	ignoreCollisions = "ignore"
+)
+
+// previousNames are the reagent and fragment names of the CSV outputs already in a directory,
+// ex: from earlier runs in a project directory. A name that's reused for a different sequence
+// would put two different oligos, or fragments, in the lab under one label
+type previousNames struct {
+	// reagents are the sequences of each reagent ID. One ID may name several, one per solution
+	reagents map[string]map[string]bool
+
+	// fragments are the template and size of each fragment ID
+	fragments map[string]map[string]bool
+
+	// files are the files each name is from
+	files map[string]string
+}
+
+// outputName is a name in an output and what it names: a reagent's sequence, or a fragment's template and size
+type outputName struct {
+	kind, id, value string
+}
+
+// readPreviousNames reads the names in the strategy and reagents CSVs next to an output, other than its
+// own and the redacted ones, whose names are numbered independently of the lab's
+func readPreviousNames(filename string) (*previousNames, error) {
+	prev := &previousNames{
+		reagents:  make(map[string]map[string]bool),
+		fragments: make(map[string]map[string]bool),
+		files:     make(map[string]string),
+	}
+	own := map[string]bool{
+		filepath.Clean(resultFilename(filename, "reagents")): true,
+		filepath.Clean(resultFilename(filename, "strategy")): true,
+	}
+	for _, suffix := range []string{"reagents", "strategy"} {
+		paths, err := filepath.Glob(filepath.Join(filepath.Dir(filename), "*-"+suffix+".csv"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			base := filepath.Base(path)
+			if own[filepath.Clean(path)] || strings.HasPrefix(base, ".") || strings.HasSuffix(base, "-redacted-"+suffix+".csv") {
+				continue
+			}
+			if err = prev.read(path, suffix == "reagents"); err != nil {
+				return nil, fmt.Errorf("failed to read the names in %s: %v", path, err)
+			}
+		}
+	}
+	return prev, nil
+}
+
+// read adds the names of a reagents or strategy CSV. Reagents are named by their "Reagent ID"
+// column, with the sequence in "Seq", and fragments by "Frag ID", with their "Template" and
+// "Size". Synthetic fragments, without a template, are among the reagents
+func (p *previousNames) read(path string, reagents bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	columns := make(map[string]int)
+	for i, header := range rows[0] {
+		columns[header] = i
+	}
+	idColumn, valueColumns, names := "Frag ID", []string{"Template", "Size"}, p.fragments
+	if reagents {
+		idColumn, valueColumns, names = "Reagent ID", []string{"Seq"}, p.reagents
+	}
+	for _, column := range append(valueColumns, idColumn) {
+		if _, ok := columns[column]; !ok {
+			return fmt.Errorf("no %q column", column)
+		}
+	}
+
+	for _, row := range rows[1:] {
+		if len(row) != len(rows[0]) {
+			continue
+		}
+		id := strings.TrimPrefix(row[columns[idColumn]], "*") // marks reagents of the manifests
+		var values []string
+		for _, column := range valueColumns {
+			values = append(values, row[columns[column]])
+		}
+		if id == "" || id == "N/A" || values[0] == "N/A" {
+			continue
+		}
+		if names[id] == nil {
+			names[id] = make(map[string]bool)
+		}
+		names[id][strings.ToUpper(strings.Join(values, " "))] = true
+		p.files[id] = filepath.Base(path)
+	}
+	return nil
+}
+
+// collisions returns the names that previous outputs use for something else
+func (p *previousNames) collisions(names []outputName) (collisions []outputName) {
+	for _, n := range names {
+		previous := p.reagents
+		if n.kind == "fragment" {
+			previous = p.fragments
+		}
+		if values, ok := previous[n.id]; ok && !values[strings.ToUpper(n.value)] {
+			collisions = append(collisions, n)
+		}
+	}
+	return collisions
+}
+
+// maxIndex returns the largest index of the previous reagent IDs with the base, ex: 12 for oS12
+func (p *previousNames) maxIndex(base string) (max uint, ok bool) {
+	for id := range p.reagents {
+		if idBase, index := extractOligoIDComps(id); idBase == base && index >= max {
+			max, ok = index, true
+		}
+	}
+	return max, ok
+}
+
+// outputNames returns the names of the new reagents and the fragments of an output as they'll be
+// written. Reagents of the manifests are skipped, they're named by the lab
+func outputNames(out *Output, names namingMap, existingPrimers, existingSynthFrags *oligosDB, fragIDs *fragIDNamer) (outNames []outputName) {
+	for si, s := range out.Solutions {
+		order := newSolutionOrder(s, names, existingPrimers, existingSynthFrags)
+		for _, o := range order.primers {
+			outNames = append(outNames, outputName{"primer", o.id, o.seq})
+		}
+		for _, o := range order.synthFrags {
+			outNames = append(outNames, outputName{"synthetic fragment", o.id, o.seq})
+		}
+		for _, o := range order.probes {
+			outNames = append(outNames, outputName{"probe", o.id, o.seq})
+		}
+		if fragIDs == nil {
+			continue
+		}
+		used := make(map[string]bool)
+		for fi, f := range s.Fragments {
+			if f.fragType == synthetic {
+				continue
+			}
+			id := fragIDs.unique(fragIDs.format(si+1, fi+1, f), used)
+			outNames = append(outNames, outputName{"fragment", id, templateName(f.ID, names) + " " + strconv.Itoa(len(f.PCRSeq))})
+		}
+	}
+	return outNames
+}
+
+// avoidNameCollisions checks the names of an output's new reagents and fragments against
+// the strategy and reagents CSVs of previous outputs in its directory. With the increment
+// strategy, new reagents are numbered past the previous ones and colliding fragment IDs
+// get a numeric suffix. With the error strategy, it fails if any name collides
+func avoidNameCollisions(filename string, out *Output, names namingMap, existingPrimers, existingSynthFrags *oligosDB, fragIDs *fragIDNamer, strategy string) error {
+	switch strategy {
+	case ignoreCollisions:
+		return nil
+	case incrementCollisions, errorCollisions:
+	default:
+		return fmt.Errorf("invalid output-name-collisions %q; valid values [increment, error, ignore]", strategy)
+	}
+
+	prev, err := readPreviousNames(filename)
+	if err != nil {
+		return err
+	}
+	collisions := prev.collisions(outputNames(out, names, existingPrimers, existingSynthFrags, fragIDs))
+	if len(collisions) == 0 {
+		return nil
+	}
+	if strategy == errorCollisions {
+		return prev.collisionsError(collisions)
+	}
+
+	// number new reagents past those of previous outputs
+	for _, db := range []*oligosDB{existingPrimers, existingSynthFrags} {
+		base, first := extractOligoIDComps(names.rename(db.getNewOligoID(0)))
+		if max, ok := prev.maxIndex(base); ok && max >= first {
+			db.nextOligoID += max - first + 1
+		}
+	}
+	// and suffix the fragment IDs that collide, until none do
+	for attempt := 0; attempt < 10 && len(collisions) > 0; attempt++ {
+		for _, c := range collisions {
+			if c.kind == "fragment" && fragIDs != nil {
+				fragIDs.taken[c.id] = true
+			}
+		}
+		collisions = prev.collisions(outputNames(out, names, existingPrimers, existingSynthFrags, fragIDs))
+	}
+	if len(collisions) > 0 {
+		return prev.collisionsError(collisions)
+	}
+	rlog.Infof("numbered new reagents and fragments past those of previous outputs in %s", filepath.Dir(filename))
+	return nil
+}
+
+// collisionsError lists the names that collide with previous outputs
+func (p *previousNames) collisionsError(collisions []outputName) error {
+	var lines []string
+	for _, c := range collisions {
+		lines = append(lines, fmt.Sprintf("%s %s is already used for something else in %s", c.kind, c.id, p.files[c.id]))
+	}
+	sort.Strings(lines)
+	return fmt.Errorf("names in the output collide with previous outputs in the directory, "+
+		"set output-name-collisions to increment to renumber them:\n\t%s", strings.Join(lines, "\n\t"))
+}
@@ -0,0 +1,103 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_avoidNameCollisions(t *testing.T) {
+	newOutput := func() *Output {
+		return &Output{
+			Solutions: []Solution{{
+				Fragments: []*Frag{{
+					ID:       "pUC19",
+					fragType: pcr,
+					PCRSeq:   "ACGTACGTACGTACGTACGTACGTACGTACGT",
+					Primers: []Primer{
+						{Seq: "ACGTACGTACGT", Strand: true},
+						{Seq: "TTTTGGGGCCCC", Strand: false},
+					},
+				}},
+			}},
+		}
+	}
+
+	dir := t.TempDir()
+	previous := "Reagent ID,Seq,Priming Region,Tm,Notes\n" +
+		"oS1,GGGGGGGGGGGG,,,\n" +
+		"oS2,TTTTGGGGCCCC,,,\n" +
+		"oS3,AAAAAAAAAAAA,,,\n"
+	if err := os.WriteFile(filepath.Join(dir, "run1-reagents.csv"), []byte(previous), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "run2.csv")
+
+	t.Run("ignore", func(t *testing.T) {
+		primers := newOligosDB(primerIDPrefix, false)
+		if err := avoidNameCollisions(filename, newOutput(), nil, primers, newOligosDB(synthFragIDPrefix, true), nil, ignoreCollisions); err != nil {
+			t.Fatal(err)
+		}
+		if primers.nextOligoID != 1 {
+			t.Errorf("nextOligoID = %d, want 1", primers.nextOligoID)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		primers := newOligosDB(primerIDPrefix, false)
+		err := avoidNameCollisions(filename, newOutput(), nil, primers, newOligosDB(synthFragIDPrefix, true), nil, errorCollisions)
+		if err == nil || !strings.Contains(err.Error(), "primer oS1") || !strings.Contains(err.Error(), "run1-reagents.csv") {
+			t.Fatalf("avoidNameCollisions() = %v, want a collision of primer oS1", err)
+		}
+		if strings.Contains(err.Error(), "primer oS2") {
+			t.Errorf("avoidNameCollisions() = %v, oS2 is the same sequence", err)
+		}
+	})
+
+	t.Run("increment", func(t *testing.T) {
+		primers := newOligosDB(primerIDPrefix, false)
+		synthFrags := newOligosDB(synthFragIDPrefix, true)
+		out := newOutput()
+		if err := avoidNameCollisions(filename, out, nil, primers, synthFrags, nil, incrementCollisions); err != nil {
+			t.Fatal(err)
+		}
+		if primers.nextOligoID != 4 {
+			t.Errorf("nextOligoID = %d, want 4", primers.nextOligoID)
+		}
+		order := newSolutionOrder(out.Solutions[0], nil, primers, synthFrags)
+		for _, o := range order.primers {
+			if o.id == "oS1" || o.id == "oS2" || o.id == "oS3" {
+				t.Errorf("new primer %s reuses a previous ID", o.id)
+			}
+		}
+	})
+}
+
+func Test_previousNames_collisions(t *testing.T) {
+	dir := t.TempDir()
+	strategy := "# solution 1\nFrag ID,Type,Template,Size\n" +
+		"proj_1_pcr,PCR,pUC19,2686\n" +
+		"proj_2_pcr,PCR,pSB1C3,2070\n"
+	if err := os.WriteFile(filepath.Join(dir, "run1-strategy.csv"), []byte(strategy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the output's own files aren't previous outputs
+	own := "Frag ID,Type,Template,Size\nproj_3_pcr,PCR,pUC19,100\n"
+	if err := os.WriteFile(filepath.Join(dir, "run2-strategy.csv"), []byte(own), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prev, err := readPreviousNames(filepath.Join(dir, "run2.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collisions := prev.collisions([]outputName{
+		{"fragment", "proj_1_pcr", "pUC19 2686"},  // same fragment
+		{"fragment", "proj_2_pcr", "pUC19 1500"},  // another fragment under a previous ID
+		{"fragment", "proj_3_pcr", "pSB1C3 2070"}, // only in the output's own strategy
+	})
+	if len(collisions) != 1 || collisions[0].id != "proj_2_pcr" {
+		t.Errorf("collisions() = %+v, want proj_2_pcr", collisions)
+	}
+}
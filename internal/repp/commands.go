@@ -0,0 +1,137 @@
+package repp
+
+import (
+	"errors"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CommandStats are the counters of the runs of an external command, ex: blastn
+type CommandStats struct {
+	// Command is the name of the executable
+	Command string `json:"command"`
+
+	// Runs is the number of times it was run
+	Runs int `json:"runs"`
+
+	// Failures is the number of runs that exited with a non-zero code or failed to start
+	Failures int `json:"failures"`
+
+	// Seconds is the total time spent running it
+	Seconds float64 `json:"seconds"`
+
+	// MaxSeconds is the longest run
+	MaxSeconds float64 `json:"maxSeconds"`
+
+	// WaitSeconds is the total time its runs waited for another subprocess to finish
+	WaitSeconds float64 `json:"waitSeconds"`
+}
+
+// commandRunner runs the external commands, ex: BLAST and primer3, limiting how many run
+// at once so parallel searches and per-fragment primer designs don't overwhelm shared servers
+type commandRunner struct {
+	// slots holds a token for each running subprocess
+	slots chan struct{}
+
+	// mu guards stats
+	mu sync.Mutex
+
+	// stats of each command, by name
+	stats map[string]*CommandStats
+}
+
+// commands is the runner of all of repp's external commands
+var commands = newCommandRunner(runtime.NumCPU())
+
+// newCommandRunner returns a runner of at most maxSubprocesses commands at once
+func newCommandRunner(maxSubprocesses int) *commandRunner {
+	return &commandRunner{
+		slots: make(chan struct{}, maxSubprocesses),
+		stats: make(map[string]*CommandStats),
+	}
+}
+
+// SetMaxSubprocesses limits the number of external commands that run at once. It's the
+// number of CPUs a run may use if n isn't positive. It must be called before any command runs
+func SetMaxSubprocesses(n int) {
+	if n <= 0 {
+		n = availableCPUs()
+	}
+	commands = newCommandRunner(n)
+}
+
+// maxSubprocesses returns the most external commands that run at once
+func (r *commandRunner) maxSubprocesses() int {
+	return cap(r.slots)
+}
+
+// combinedOutput runs a command, once a subprocess slot is free, and returns its combined
+// stdout and stderr. Its duration and exit code are recorded
+func (r *commandRunner) combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	queued := time.Now()
+	r.slots <- struct{}{}
+	defer func() { <-r.slots }()
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	r.record(cmd, start.Sub(queued), time.Since(start), err)
+	return output, err
+}
+
+// record adds a run of a command to its stats
+func (r *commandRunner) record(cmd *exec.Cmd, wait, duration time.Duration, err error) {
+	name := filepath.Base(cmd.Path)
+	exitCode := 0
+	if err != nil {
+		exitCode = -1 // failed to start
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	rlog.Debugf("%s exited with %d after %.2fs (waited %.2fs)", name, exitCode, duration.Seconds(), wait.Seconds())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.stats[name]
+	if !ok {
+		s = &CommandStats{Command: name}
+		r.stats[name] = s
+	}
+	s.Runs++
+	if exitCode != 0 {
+		s.Failures++
+	}
+	s.Seconds += duration.Seconds()
+	if duration.Seconds() > s.MaxSeconds {
+		s.MaxSeconds = duration.Seconds()
+	}
+	s.WaitSeconds += wait.Seconds()
+}
+
+// report returns the stats of each command that ran, by name, with their times rounded to ms
+func (r *commandRunner) report() (stats []CommandStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.stats {
+		rounded := *s
+		rounded.Seconds = roundSeconds(s.Seconds)
+		rounded.MaxSeconds = roundSeconds(s.MaxSeconds)
+		rounded.WaitSeconds = roundSeconds(s.WaitSeconds)
+		stats = append(stats, rounded)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Command < stats[j].Command
+	})
+	return stats
+}
+
+// roundSeconds rounds seconds to ms
+func roundSeconds(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
@@ -0,0 +1,51 @@
+package repp
+
+import (
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_commandRunner(t *testing.T) {
+	r := newCommandRunner(1)
+
+	// two runs at once wait for each other
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.combinedOutput(exec.Command("sleep", "0.1")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("two runs with one slot took %v, want them one after the other", elapsed)
+	}
+
+	if output, err := r.combinedOutput(exec.Command("sh", "-c", "echo failed; exit 3")); err == nil || string(output) != "failed\n" {
+		t.Errorf("combinedOutput() = %q, %v, want the output and exit error", output, err)
+	}
+	if _, err := r.combinedOutput(exec.Command("/no/such/executable")); err == nil {
+		t.Error("combinedOutput() of a missing executable, want an error")
+	}
+
+	report := r.report()
+	if len(report) != 3 {
+		t.Fatalf("report() = %+v, want the stats of 3 commands", report)
+	}
+	sh, sleep, missing := report[1], report[2], report[0]
+	if sleep.Command != "sleep" || sleep.Runs != 2 || sleep.Failures != 0 || sleep.Seconds < 0.2 || sleep.WaitSeconds < 0.05 {
+		t.Errorf("report() sleep = %+v, want 2 runs, one after waiting for the other", sleep)
+	}
+	if sh.Command != "sh" || sh.Runs != 1 || sh.Failures != 1 {
+		t.Errorf("report() sh = %+v, want 1 failed run", sh)
+	}
+	if missing.Command != "executable" || missing.Failures != 1 {
+		t.Errorf("report() missing executable = %+v, want 1 failed run", missing)
+	}
+}
@@ -0,0 +1,81 @@
+package repp
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// ExportCSV writes the strategy, reagents and pick list CSVs of a JSON output, as a design
+// with the CSV output format would have, so they can be regenerated with other options without
+// redoing the design. The CSVs are written next to csvFilename, or the output if it's empty.
+// Reagents are named against the primer and synthetic fragment manifests given here.
+func ExportCSV(filename, csvFilename string, withFragLocation bool, primersDBLocations, synthFragsDBLocations []string) {
+	if csvFilename == "" {
+		csvFilename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".csv"
+	}
+	if err := exportCSV(filename, csvFilename, withFragLocation, primersDBLocations, synthFragsDBLocations, config.New()); err != nil {
+		rlog.Fatal(err)
+	}
+	rlog.Infof("wrote %s and %s", resultFilename(csvFilename, "strategy"), resultFilename(csvFilename, "reagents"))
+}
+
+// exportCSV reads a JSON output and writes its solutions as CSVs
+func exportCSV(filename, csvFilename string, withFragLocation bool, primersDBLocations, synthFragsDBLocations []string, conf *config.Config) error {
+	out, err := readOutput(filename)
+	if err != nil {
+		return err
+	}
+	for i := range out.Solutions {
+		out.Solutions[i] = restoreSolution(out.Solutions[i], out.TargetSeq)
+	}
+
+	names, err := newNamingMap(conf.NamingMap)
+	if err != nil {
+		return err
+	}
+	primersDB, synthFragsDB, err := readOligoDBs(primersDBLocations, synthFragsDBLocations, conf)
+	if err != nil {
+		return err
+	}
+	fragIDs, err := newFragIDNamer(conf.FragmentIDTemplate, conf.Project, out.Target, fragmentBase(csvFilename), synthFragsDB)
+	if err != nil {
+		return err
+	}
+
+	if err = writeCSV(csvFilename, fragIDs, names, primersDB, synthFragsDB, withFragLocation, conf.Strict, out); err != nil {
+		return err
+	}
+	if out.Metadata != nil {
+		return writeMetadata(metadataFilename(csvFilename), out.Metadata)
+	}
+	return nil
+}
+
+// restoreSolution returns a solution read from a JSON output with what the CSVs need that
+// isn't in the JSON: the counts of its fragments by type and, from their sequences and
+// mismatches, where each fragment is on the target and how well its template matched it.
+// Template coordinates aren't in the JSON, and are left unknown
+func restoreSolution(s Solution, targetSeq string) Solution {
+	s.pcrFragsCount, s.synthFragsCount = 0, 0
+	frags := make([]*Frag, 0, len(s.Fragments))
+	for _, frag := range s.Fragments {
+		f := frag
+		if located := locateFragments(Solution{Fragments: []*Frag{frag}}, targetSeq); len(located) == 1 {
+			f = located[0]
+			f.Primers = frag.Primers // primers with 5' edits aren't located, but are still reagents
+		}
+		if f.fragType == synthetic {
+			s.synthFragsCount++
+		} else {
+			s.pcrFragsCount++
+			if len(f.Seq) > 0 && !f.MismatchesUnknown {
+				f.matchRatio = 1 - float64(len(f.Mismatches))/float64(len(f.Seq))
+			}
+		}
+		frags = append(frags, f)
+	}
+	s.Fragments = frags
+	return s
+}
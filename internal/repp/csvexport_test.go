@@ -0,0 +1,67 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_exportCSV(t *testing.T) {
+	targetSeq := randomSeq(1000, 1)
+	fwd, rev := targetSeq[0:20], reverseComplement(targetSeq[580:600])
+	out := &Output{
+		Target:    "target",
+		TargetSeq: targetSeq,
+		Solutions: []Solution{{
+			Count: 2,
+			Fragments: []*Frag{
+				{
+					ID:     "pSB1A3",
+					Type:   "pcr",
+					Seq:    targetSeq[0:600],
+					PCRSeq: targetSeq[0:600],
+					Primers: []Primer{
+						{Seq: fwd, Strand: true, Tm: 60.2, PrimingRegion: fwd},
+						{Seq: rev, Strand: false, Tm: 59.8, PrimingRegion: rev},
+					},
+				},
+				{ID: "target_2_synthetic", Type: "synthetic", Seq: targetSeq[570:] + targetSeq[:30]},
+			},
+		}},
+	}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "target.output.json")
+	if err := writeJSON(filename, out); err != nil {
+		t.Fatal(err)
+	}
+	csvFilename := filepath.Join(dir, "target.output.csv")
+	if err := exportCSV(filename, csvFilename, true, nil, nil, config.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	strategy, err := os.ReadFile(resultFilename(csvFilename, "strategy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Fragments:2 (1 - pcr, 1 - synth)",
+		",oS1,oS2,pSB1A3,600,100,,0,599,N/A,N/A,",
+		",N/A,N/A,N/A,460,N/A,N/A,570,1029,",
+	} {
+		if !strings.Contains(string(strategy), want) {
+			t.Errorf("exportCSV() strategy is missing %q:\n%s", want, strategy)
+		}
+	}
+
+	reagents, err := os.ReadFile(resultFilename(csvFilename, "reagents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(reagents), fwd) || !strings.Contains(string(reagents), rev) {
+		t.Errorf("exportCSV() reagents are missing the primers:\n%s", reagents)
+	}
+}
@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sort"
+	"strings"
+	"sync"
 	"text/tabwriter"
+	"time"
 
 	"github.com/Lattice-Automation/repp/internal/config"
+	"golang.org/x/exp/slices"
 )
 
 // manifest is a serializable list of sequence databases.
@@ -21,6 +26,9 @@ import (
 type manifest struct {
 	// DBs is a map from DB name (base of originally added DB file) to DB
 	DBs map[string]DB `json:"dbs"`
+
+	// Aliases is a map from alias name to the BLAST alias database spanning several DBs
+	Aliases map[string]Alias `json:"aliases,omitempty"`
 }
 
 // GetNames returns the list of known DB names.
@@ -43,10 +51,210 @@ type DB struct {
 	// Cost per order from this sequence provider.
 	// Eg $65 to order from Addgene.
 	Cost float64 `json:"cost"`
+
+	// Stats summarizes the database's sequences, nil if it was added before they were stored
+	Stats *DBStats `json:"stats,omitempty"`
+
+	// MaxFileSize is the max size of its BLAST volumes, blast-max-file-size in the config if empty
+	MaxFileSize string `json:"maxFileSize,omitempty"`
+
+	// NoPCR is whether its entries are only sequence references, never PCR templates
+	NoPCR bool `json:"noPCR,omitempty"`
+
+	// NoPCREntries are the IDs of its entries that are only sequence references, never PCR templates
+	NoPCREntries []string `json:"noPCREntries,omitempty"`
+
+	// FlippedEntries are the IDs of its entries that were reverse complemented, relative to
+	// their sequence files, to the orientation of the --orient-by features
+	FlippedEntries []string `json:"flippedEntries,omitempty"`
+
+	// ContaminatedEntries are the IDs of its entries with contaminant sequence at their ends,
+	// flagged rather than trimmed by --screen-contaminants
+	ContaminatedEntries []string `json:"contaminatedEntries,omitempty"`
+}
+
+// dbIDMapPath returns the path to a database's ID map: a JSON map from the IDs
+// of renamed (truncated or duplicate) entries to the IDs of the original sequences.
+func dbIDMapPath(dbPath string) string {
+	return dbPath + ".ids.json"
+}
+
+// renamedIDs returns the map from renamed entry IDs to original sequence IDs.
+// It's empty if the database has no ID map.
+func (db DB) renamedIDs() map[string]string {
+	if _, err := os.Stat(dbIDMapPath(db.Path)); err != nil {
+		return map[string]string{}
+	}
+	return newKV(dbIDMapPath(db.Path)).contents
+}
+
+// renamedEntries returns the sorted IDs of the entries that were renamed from the ID of
+// an original sequence, by the database's renamedIDs, empty if none were
+func renamedEntries(renamed map[string]string, originalID string) (entries []string) {
+	for dbID, id := range renamed {
+		if id == originalID {
+			entries = append(entries, dbID)
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// resolveEntry returns the ID that entry was written to the database with.
+// entry may be the ID of an original sequence that was renamed when the database
+// was built. If several entries were renamed from it, the first is used, or
+// an error is returned in strict mode.
+func (db DB) resolveEntry(entry string, strict bool) (string, error) {
+	renamed := db.renamedIDs()
+	if _, ok := renamed[entry]; ok {
+		return entry, nil // already an entry ID in the database
+	}
+
+	candidates := renamedEntries(renamed, entry)
+	if len(candidates) == 0 {
+		return entry, nil
+	}
+	if len(candidates) > 1 {
+		if err := strictErrorf(strict, "%s was renamed to %d entries in %s: %s. Using %s",
+			entry, len(candidates), db.Name, strings.Join(candidates, ", "), candidates[0]); err != nil {
+			return "", err
+		}
+	}
+	return candidates[0], nil
+}
+
+// dbOffsetsPath returns the path to a database's offset map: a JSON map from entry IDs
+// to the bases that were stripped from the entries' original sequences.
+func dbOffsetsPath(dbPath string) string {
+	return dbPath + ".offsets.json"
+}
+
+// entryOffsets records the non-ACGT bases stripped from a database entry
+// so its coordinates can be mapped back to the original sequence file.
+type entryOffsets struct {
+	// Length of the entry's sequence after stripping (not doubled if circular)
+	Length int `json:"length"`
+
+	// Stripped are the ascending indexes of the stripped bases in the original sequence
+	Stripped []int `json:"stripped"`
+}
+
+// originalIndex maps an index in the database entry to the index in the original sequence.
+// Indexes in the second half of a doubled circular entry are mapped to the original
+// sequence repeated after itself. The zero value maps indexes to themselves.
+func (o entryOffsets) originalIndex(index int) int {
+	if o.Length == 0 || len(o.Stripped) == 0 || index < 0 {
+		return index
+	}
+
+	copies, index := index/o.Length, index%o.Length
+	for _, s := range o.Stripped {
+		if s > index {
+			break
+		}
+		index++
+	}
+	return index + copies*(o.Length+len(o.Stripped))
+}
+
+// entryOffsets returns the offsets of the entries that had bases stripped when the database
+// was built. It's empty if the database has no offset map, or if it can't be parsed outside
+// strict mode.
+func (db DB) entryOffsets(strict bool) (map[string]entryOffsets, error) {
+	offsets := map[string]entryOffsets{}
+	contents, err := os.ReadFile(dbOffsetsPath(db.Path))
+	if err != nil {
+		return offsets, nil
+	}
+	if err = json.Unmarshal(contents, &offsets); err != nil {
+		if err = strictErrorf(strict, "failed to parse offset map %s of %s: %v", dbOffsetsPath(db.Path), db.Name, err); err != nil {
+			return nil, err
+		}
+		return map[string]entryOffsets{}, nil
+	}
+	return offsets, nil
+}
+
+// saveEntryOffsets writes the offset map of the entries that had bases stripped.
+// Any previous offset map is removed if none did.
+func saveEntryOffsets(dbPath string, entries map[string]*Frag) (int, error) {
+	offsets := make(map[string]entryOffsets)
+	for id, f := range entries {
+		if len(f.strippedIndexes) > 0 {
+			offsets[id] = entryOffsets{Length: len(f.Seq), Stripped: f.strippedIndexes}
+		}
+	}
+
+	if len(offsets) == 0 {
+		os.Remove(dbOffsetsPath(dbPath))
+		return 0, nil
+	}
+
+	contents, err := json.Marshal(offsets)
+	if err != nil {
+		return 0, err
+	}
+	return len(offsets), writeFileAtomic(dbOffsetsPath(dbPath), contents)
+}
+
+// ImportOptions are how sequence files are imported into a database, see AddDatabase
+type ImportOptions struct {
+	// Circularize the sequences, doubling those that are circular
+	Circularize bool
+
+	// Cost of ordering a fragment from the database
+	Cost float64
+
+	// PrefixSeqIDs prefixes the sequences' IDs with their files' names
+	PrefixSeqIDs bool
+
+	// MaskAmbiguous replaces non-ACGT bases by N rather than stripping them
+	MaskAmbiguous bool
+
+	// MaxFileSize of the BLAST volumes, ex: 1GB
+	MaxFileSize string
+
+	// NoPCR is whether the database's fragments can't be PCR'd, only used whole
+	NoPCR bool
+
+	// NoPCREntries are the entries that can't be PCR'd, only used whole
+	NoPCREntries []string
+
+	// OrientBy are the features the entries are reverse complemented to the orientation of
+	OrientBy []string
+
+	// Screen for contaminants at the entries' ends: none, flag or trim
+	Screen string
+
+	// ContaminantsDB is the FASTA file of the contaminants screened for
+	ContaminantsDB string
+
+	// Strict fails the import on malformed files and duplicate IDs, rather than warning
+	Strict bool
 }
 
 // AddDatabase imports one or more sequence files into a BLAST database to the REPP directory.
-func AddDatabase(dbName string, seqFiles []string, circularizeSequences bool, cost float64, prefixSeqIDWithFName bool) (err error) {
+// Non-ACGT bases in the sequences are replaced by N if MaskAmbiguous. Otherwise they're
+// stripped and an offset map is saved so match coordinates can be reported against the original files.
+// Its BLAST volumes are at most MaxFileSize, or the size it was last built with if empty.
+// Entries are reverse complemented to the orientation of the OrientBy features, if any,
+// and the flipped entries are recorded in the manifest. The ends of the entries are screened
+// for the contaminants of ContaminantsDB: those with contaminants are flagged in the
+// manifest, or the contaminants are trimmed off, depending on the screen.
+func AddDatabase(dbName string, seqFiles []string, opts ImportOptions) (err error) {
+	switch opts.Screen {
+	case noContaminantScreen, flagContaminants, trimContaminants:
+	default:
+		return fmt.Errorf("invalid contaminant screen %q; valid values [none, flag, trim]", opts.Screen)
+	}
+	var orientation orientationIndex
+	if len(opts.OrientBy) > 0 {
+		if orientation, err = newOrientationIndex(opts.OrientBy); err != nil {
+			return err
+		}
+	}
+	var flippedEntries, contaminatedEntries []string
+
 	// Each database will be in its own directory because blastdb creates a lot of files for each database
 	dbSequenceDir := path.Join(config.SeqDatabaseDir, dbName)
 
@@ -73,6 +281,9 @@ func AddDatabase(dbName string, seqFiles []string, circularizeSequences bool, co
 			rlog.Warnf("Error reading sequence from the standard input")
 			return err
 		}
+		// sequences from stdin are written as is - remove the ID and offset maps of any previous build
+		os.Remove(dbIDMapPath(dbSequenceFilepath))
+		os.Remove(dbOffsetsPath(dbSequenceFilepath))
 		dbSeqInput := os.Stdin
 		dbSeqReader := bufio.NewReader(dbSeqInput)
 
@@ -81,18 +292,68 @@ func AddDatabase(dbName string, seqFiles []string, circularizeSequences bool, co
 			return err
 		}
 	} else {
-		dbSeqs, report, err := multiFileRead(seqFiles, prefixSeqIDWithFName)
+		strict := opts.Strict
+		dbSeqs, report, err := multiFileRead(seqFiles, opts.PrefixSeqIDs, opts.MaskAmbiguous, strict)
 		report.printReport()
 		if err != nil {
+			if strict {
+				return err
+			}
 			rlog.Warnf("Error reading one or more sequence files into the database: %v", err)
 		}
 		if len(dbSeqs) > 0 {
+			var contaminated map[*Frag]bool
+			if opts.Screen != noContaminantScreen {
+				idx, err := newContaminantIndex(opts.ContaminantsDB)
+				if err != nil {
+					rlog.Warnf("Error reading the contaminants to screen %s for: %v", dbName, err)
+				} else {
+					var trimmed int
+					if contaminated, trimmed = screenContaminants(dbSeqs, idx, opts.Screen); trimmed > 0 {
+						rlog.Infof("%d fragments had contaminant sequence trimmed off their ends", trimmed)
+					}
+				}
+			}
+			var flipped map[*Frag]bool
+			if orientation != nil {
+				flipped = normalizeOrientation(dbSeqs, orientation, opts.Circularize)
+			}
 			// truncate the ID to 50 chars - max ID supported by makeblastdb is 50
-			err = writeFragsToFastaFile(dbSeqs, 50, circularizeSequences, dbSeqFile)
+			dbSeqWriter := bufio.NewWriterSize(dbSeqFile, 1<<20)
+			entries, err := writeFragsToFastaFile(dbSeqs, 50, opts.Circularize, strict, dbSeqWriter)
+			if err == nil {
+				err = dbSeqWriter.Flush()
+			}
 			if err != nil {
 				rlog.Errorf("Error writing database sequence to %f\n", dbSequenceFilepath)
 				return err
 			}
+			// keep the original IDs so entries can still be found by them
+			renamedIDs := renamedEntryIDs(entries)
+			idMap := &kv{contents: renamedIDs, path: dbIDMapPath(dbSequenceFilepath)}
+			if err = idMap.save(); err != nil {
+				rlog.Errorf("Error writing database ID map to %s\n", idMap.path)
+				return err
+			}
+			if len(renamedIDs) > 0 {
+				rlog.Infof("%d fragment IDs were renamed in the database, see %s", len(renamedIDs), idMap.path)
+			}
+			// keep the stripped bases so coordinates can be mapped back to the original sequences
+			strippedCount, err := saveEntryOffsets(dbSequenceFilepath, entries)
+			if err != nil {
+				rlog.Errorf("Error writing database offset map to %s\n", dbOffsetsPath(dbSequenceFilepath))
+				return err
+			}
+			if strippedCount > 0 {
+				rlog.Infof("%d fragments had non-ACGT bases stripped, coordinates are mapped back to the original sequences with %s",
+					strippedCount, dbOffsetsPath(dbSequenceFilepath))
+			}
+			if flippedEntries = markedEntryIDs(entries, flipped); len(flippedEntries) > 0 {
+				rlog.Infof("%d fragments were reverse complemented to the orientation of %s", len(flippedEntries), strings.Join(opts.OrientBy, ", "))
+			}
+			if contaminatedEntries = markedEntryIDs(entries, contaminated); len(contaminatedEntries) > 0 {
+				rlog.Warnf("%d fragments have contaminant sequence at their ends, trim it with --screen-contaminants trim", len(contaminatedEntries))
+			}
 			rlog.Infof("%d fragments written to %s", len(dbSeqs), dbSequenceFilepath)
 		} else {
 			rlog.Warnf("No sequence was read from the input files")
@@ -105,10 +366,63 @@ func AddDatabase(dbName string, seqFiles []string, circularizeSequences bool, co
 		rlog.Fatal(err)
 	}
 
-	if err = m.add(dbName, dbSequenceFilepath, cost); err != nil {
+	// the statistics, index and versions each scan the database, at once
+	var stats *DBStats
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		var err error
+		if stats, err = dbSeqStats(dbSequenceFilepath, time.Now()); err != nil {
+			rlog.Warnf("Error computing statistics of %s: %v", dbName, err)
+		}
+	}()
+
+	// index the entries so they're looked up by name without scanning the database
+	go func() {
+		defer wg.Done()
+		idx, err := buildLookupIndex(dbSequenceFilepath)
+		if err == nil {
+			err = idx.save(dbIndexPath(dbSequenceFilepath))
+		}
+		if err != nil {
+			rlog.Warnf("Error indexing %s: %v", dbName, err)
+		}
+	}()
+
+	// track the entries' sequences across builds so stale outputs can be found
+	go func() {
+		defer wg.Done()
+		changed, removed, err := updateEntryVersions(dbSequenceFilepath, time.Now())
+		if err != nil {
+			rlog.Warnf("Error versioning the entries of %s: %v", dbName, err)
+		} else if changed > 0 || removed > 0 {
+			rlog.Infof("%d entries of %s changed and %d were removed since its last build", changed, dbName, removed)
+		}
+	}()
+	wg.Wait()
+
+	if opts.MaxFileSize == "" {
+		opts.MaxFileSize = m.DBs[dbName].MaxFileSize
+	}
+	db := DB{
+		Name:                dbName,
+		Path:                dbSequenceFilepath,
+		Cost:                opts.Cost,
+		Stats:               stats,
+		MaxFileSize:         opts.MaxFileSize,
+		NoPCR:               opts.NoPCR,
+		NoPCREntries:        opts.NoPCREntries,
+		FlippedEntries:      flippedEntries,
+		ContaminatedEntries: contaminatedEntries,
+	}
+	if err = m.add(db); err != nil {
 		rlog.Fatal(err)
 	}
 
+	// BLAST outputs against a previous build of the database are stale
+	purgeDBCache(dbName)
+
 	return err
 }
 
@@ -125,15 +439,43 @@ func ListDatabases() {
 
 	// from https://golang.org/pkg/text/tabwriter/
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
-	fmt.Fprintf(w, "name\tcost\n")
+	fmt.Fprintf(w, "name\tcost\tdo-not-PCR\n")
 	for _, db := range m.DBs {
-		fmt.Fprintf(w, "%s\t%.2f\n", path.Base(db.Path), db.Cost)
+		noPCR := ""
+		if db.NoPCR {
+			noPCR = "all"
+		} else if len(db.NoPCREntries) > 0 {
+			noPCR = strings.Join(db.NoPCREntries, ",")
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t%s\n", path.Base(db.Path), db.Cost, noPCR)
+	}
+	if len(m.Aliases) > 0 {
+		fmt.Fprintf(w, "\nalias\tdatabases\n")
+		for _, alias := range m.Aliases {
+			fmt.Fprintf(w, "%s\t%s\n", alias.Name, strings.Join(alias.DBs, ","))
+		}
 	}
 	w.Flush()
 }
 
+// DatabaseNames returns the sorted names of the sequence databases and aliases,
+// or none if the manifest can't be read. Used for shell completion.
+func DatabaseNames() []string {
+	m, err := newManifest()
+	if err != nil {
+		return nil
+	}
+	names := m.GetNames()
+	for name := range m.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // DeleteCmd deletes an existing sequence database from the REPP directory.
-func DeleteDatabase(db string) {
+// The cached BLAST outputs against it are removed too if purgeCache is set.
+func DeleteDatabase(db string, purgeCache bool) {
 	m, err := newManifest()
 	if err != nil {
 		rlog.Fatal(err)
@@ -142,6 +484,10 @@ func DeleteDatabase(db string) {
 	if err = m.remove(db); err != nil {
 		rlog.Fatal(err)
 	}
+
+	if purgeCache {
+		purgeDBCache(db)
+	}
 }
 
 // newManifest returns a new deserialized Manifest.
@@ -163,15 +509,14 @@ func newManifest() (*manifest, error) {
 	return m, nil
 }
 
-// add imports a FASTA sequence database into REPP, storing it in the manifest.
-func (m *manifest) add(dbName string, seqFilepath string, cost float64) error {
-	db := DB{
-		Name: dbName,
-		Path: seqFilepath,
-		Cost: cost,
+// add imports a FASTA sequence database into REPP, storing it in the manifest with its statistics.
+func (m *manifest) add(db DB) error {
+	maxFileSize := db.MaxFileSize
+	if maxFileSize == "" {
+		maxFileSize = config.New().GetBlastMaxFileSize()
 	}
-	l := rlog.With("path", db.Path, "name", dbName, "cost", cost)
-	if err := makeblastdb(db.Path); err != nil {
+	l := rlog.With("path", db.Path, "name", db.Name, "cost", db.Cost)
+	if err := makeblastdb(db.Path, maxFileSize); err != nil {
 		l.Error("failed to makeblastdb")
 		return err
 	}
@@ -187,15 +532,32 @@ func (m *manifest) empty() bool {
 	return len(m.DBs) == 0
 }
 
-// remove deletes a local, repp-managed FASTA file and removes it from the manifest
+// remove deletes a local, repp-managed FASTA file and removes it from the manifest,
+// along with the aliases spanning it. name may be an alias, which is removed alone
 func (m *manifest) remove(name string) error {
+	if alias, ok := m.Aliases[name]; ok {
+		os.RemoveAll(path.Dir(alias.Path))
+		delete(m.Aliases, name)
+		return m.save()
+	}
+
 	db, ok := m.DBs[name]
 	if !ok {
 		rlog.Warnf("No DB with name %s was found", name)
 		return nil
 	}
 	cleanblastdb(db.Path, true)
+	os.Remove(dbIndexPath(db.Path))
+	os.Remove(db.Path + ".index.json") // the lookup index's earlier, JSON, format
+	// the entry versions are kept, so their history continues if the database is added again
 	delete(m.DBs, name)
+	for aliasName, alias := range m.Aliases {
+		if slices.Contains(alias.DBs, name) {
+			rlog.Infof("Remove alias %s, it spans %s", aliasName, name)
+			os.RemoveAll(path.Dir(alias.Path))
+			delete(m.Aliases, aliasName)
+		}
+	}
 	return m.save()
 }
 
@@ -204,7 +566,7 @@ func (m manifest) save() error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(config.SeqDatabaseManifest, contents, 0644)
+	return writeFileAtomic(config.SeqDatabaseManifest, contents)
 }
 
 func getRegisteredDBs(dbNames []string) (dbs []DB, err error) {
@@ -225,7 +587,13 @@ func getRegisteredDBs(dbNames []string) (dbs []DB, err error) {
 	// but only warn the user if a db is not found
 	for _, dbName := range dbNames {
 		db, ok := m.DBs[dbName]
-		if ok {
+		if alias, isAlias := m.Aliases[dbName]; !ok && isAlias {
+			for _, aliased := range alias.DBs {
+				if db, ok := m.DBs[aliased]; ok {
+					dbs = append(dbs, db)
+				}
+			}
+		} else if ok {
 			dbs = append(dbs, db)
 		} else {
 			rlog.Warnf("DB %s not registered", dbName)
@@ -239,6 +607,15 @@ func getRegisteredDBs(dbNames []string) (dbs []DB, err error) {
 	return
 }
 
+// registeredAliases returns the aliases in the manifest, none if it can't be read
+func registeredAliases() map[string]Alias {
+	m, err := newManifest()
+	if err != nil {
+		return nil
+	}
+	return m.Aliases
+}
+
 func dbNames(dbs []DB) (names []string) {
 	for _, d := range dbs {
 		names = append(names, d.Name)
@@ -2,9 +2,11 @@ package repp
 
 import (
 	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -85,3 +87,77 @@ func Test_dbNames(t *testing.T) {
 		})
 	}
 }
+
+func Test_DB_resolveEntry(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "renamed")
+	dbFile, err := os.Create(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	longID := strings.Repeat("x", 60)
+	entries, err := writeFragsToFastaFile([]*Frag{
+		{ID: "dup_1", Seq: "ATGC"},
+		{ID: "dup_1", Seq: "GGCC"},
+		{ID: longID, Seq: "AATT"},
+		{ID: "plain", Seq: "CCGG"},
+	}, 50, false, false, dbFile)
+	dbFile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = (&kv{contents: renamedEntryIDs(entries), path: dbIDMapPath(dbPath)}).save(); err != nil {
+		t.Fatal(err)
+	}
+	db := DB{Name: "renamed", Path: dbPath}
+
+	tests := []struct {
+		name  string
+		entry string
+		want  string
+	}{
+		{"truncated ID", longID, longID[:50]},
+		{"duplicate ID uses first renamed entry", "dup_1", "dupa_1"},
+		{"renamed entry ID", "dupb_1", "dupb_1"},
+		{"unchanged ID", "plain", "plain"},
+		{"unknown ID", "missing", "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.resolveEntry(tt.entry, false)
+			if err != nil || got != tt.want {
+				t.Errorf("DB.resolveEntry() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := db.resolveEntry("dup_1", true); err == nil {
+		t.Error("DB.resolveEntry() = nil error in strict mode, want the renamed entries reported")
+	}
+}
+
+func Test_entryOffsets_originalIndex(t *testing.T) {
+	// original NATGNNC is stripped to ATGC
+	offsets := entryOffsets{Length: 4, Stripped: []int{0, 4, 5}}
+
+	tests := []struct {
+		name    string
+		offsets entryOffsets
+		index   int
+		want    int
+	}{
+		{"no offsets", entryOffsets{}, 3, 3},
+		{"after leading stripped base", offsets, 0, 1},
+		{"before stripped bases", offsets, 2, 3},
+		{"after stripped bases", offsets, 3, 6},
+		{"second half of circular entry", offsets, 4, 8},
+		{"end of circular entry", offsets, 7, 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.offsets.originalIndex(tt.index); got != tt.want {
+				t.Errorf("entryOffsets.originalIndex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// screens of the contaminants at the ends of the entries of a database
+const (
+	// noContaminantScreen doesn't screen the entries
+	noContaminantScreen = "none"
+
+	// flagContaminants logs the entries with contaminants at their ends and lists them in the manifest
+	flagContaminants = "flag"
+
+	// trimContaminants trims the contaminants off the ends of the entries
+	trimContaminants = "trim"
+)
+
+// contaminantEndMargin is how close (bp) to an end of a database entry a stretch of a contaminant
+// has to be for it to be a cloning or sequencing artifact, ex: an adapter left on a read, rather
+// than part of the entry. Like the terminal matches of NCBI's VecScreen
+const contaminantEndMargin = 25
+
+// contaminantIndex indexes the contaminantMinMatch bp k-mers of the contaminants, on both strands,
+// to the name of the contaminant
+type contaminantIndex struct {
+	kmers map[string]string
+
+	// longest is the length of the longest contaminant
+	longest int
+}
+
+// contaminantStretch is a stretch [start, end) of a sequence that matches a contaminant
+type contaminantStretch struct {
+	name       string
+	start, end int
+}
+
+// newContaminantIndex indexes the contaminants of a FASTA file
+func newContaminantIndex(path string) (*contaminantIndex, error) {
+	k := contaminantMinMatch
+	idx := &contaminantIndex{kmers: make(map[string]string)}
+	err := scanFasta(path, func(header, seq string) {
+		seq = strings.ToUpper(seq)
+		if len(seq) > idx.longest {
+			idx.longest = len(seq)
+		}
+		for _, strand := range []string{seq, reverseComplement(seq)} {
+			for i := 0; i+k <= len(strand); i++ {
+				if _, ok := idx.kmers[strand[i:i+k]]; !ok {
+					idx.kmers[strand[i:i+k]] = header
+				}
+			}
+		}
+	})
+	return idx, err
+}
+
+// endContaminants returns the stretches of a sequence that match a contaminant and start, or
+// end, within contaminantEndMargin bp of its ends. Only its ends are scanned
+func (idx *contaminantIndex) endContaminants(seq string) (stretches []contaminantStretch) {
+	k := contaminantMinMatch
+	if len(seq) < k || len(idx.kmers) == 0 {
+		return nil
+	}
+	seq = strings.ToUpper(seq)
+
+	window := contaminantEndMargin + idx.longest
+	names := make(map[int]string)
+	scan := func(start, end int) {
+		for i := start; i < end && i+k <= len(seq); i++ {
+			name, ok := idx.kmers[seq[i:i+k]]
+			if !ok {
+				continue
+			}
+			for j := i; j < i+k; j++ {
+				if _, covered := names[j]; !covered {
+					names[j] = name
+				}
+			}
+		}
+	}
+	scan(0, window)
+	if tail := len(seq) - k - window; tail > window {
+		scan(tail, len(seq))
+	} else {
+		scan(window, len(seq))
+	}
+
+	var indexes []int
+	for i := range names {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, r := range mergeIndexes(indexes) {
+		if r[0] < contaminantEndMargin || r[1] > len(seq)-contaminantEndMargin {
+			stretches = append(stretches, contaminantStretch{name: names[r[0]], start: r[0], end: r[1]})
+		}
+	}
+	return stretches
+}
+
+// screenContaminants screens the ends of the fragments for contaminants and, if the screen
+// is trimContaminants, trims them off. The trimmed bases are recorded as stripped, so the
+// entries' coordinates still map back to their sequence files. It returns the fragments
+// with contaminants at their ends that weren't trimmed, and the number that were
+func screenContaminants(frags []*Frag, idx *contaminantIndex, screen string) (flagged map[*Frag]bool, trimmed int) {
+	flagged = make(map[*Frag]bool)
+	for _, f := range frags {
+		stretches := idx.endContaminants(f.Seq)
+		if len(stretches) == 0 {
+			continue
+		}
+
+		var found []string
+		keepStart, keepEnd := 0, len(f.Seq)
+		for _, s := range stretches {
+			found = append(found, fmt.Sprintf("%s at %d-%d", s.name, s.start+1, s.end))
+			if s.start < contaminantEndMargin && s.end > keepStart {
+				keepStart = s.end
+			}
+			if s.end > len(f.Seq)-contaminantEndMargin && s.start < keepEnd {
+				keepEnd = s.start
+			}
+		}
+		if screen != trimContaminants || keepStart >= keepEnd {
+			rlog.Warnf("%s has contaminant sequence at its ends: %s", f.ID, strings.Join(found, ", "))
+			flagged[f] = true
+			continue
+		}
+
+		rlog.Debugf("trimming %s off %s", strings.Join(found, ", "), f.ID)
+		offsets := entryOffsets{Length: len(f.Seq), Stripped: f.strippedIndexes}
+		stripped := append([]int(nil), f.strippedIndexes...)
+		for i := 0; i < len(f.Seq); i++ {
+			if i < keepStart || i >= keepEnd {
+				stripped = append(stripped, offsets.originalIndex(i))
+			}
+		}
+		sort.Ints(stripped)
+		f.strippedIndexes = stripped
+		f.Seq = f.Seq[keepStart:keepEnd]
+		trimmed++
+	}
+	return flagged, trimmed
+}
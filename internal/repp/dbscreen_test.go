@@ -0,0 +1,56 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_screenContaminants(t *testing.T) {
+	adapter := "AGATCGGAAGAGCACACGTCTGAACTCCAGTCA"
+	contaminants := filepath.Join(t.TempDir(), "contaminants.fasta")
+	if err := os.WriteFile(contaminants, []byte(">adapter TruSeq\n"+adapter+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := newContaminantIndex(contaminants)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insert := randomSeq(400, 12)
+	clean := &Frag{ID: "clean", Seq: insert}
+	// the adapter in the middle is part of the entry, not an artifact
+	internal := &Frag{ID: "internal", Seq: insert[:200] + adapter + insert[200:]}
+	leftover := &Frag{ID: "leftover", Seq: "ACGTA" + adapter + insert, strippedIndexes: []int{2}}
+	reversed := &Frag{ID: "reversed", Seq: insert + reverseComplement(adapter)}
+
+	if stretches := idx.endContaminants(internal.Seq); len(stretches) != 0 {
+		t.Errorf("endContaminants() = %+v of an internal adapter, want none", stretches)
+	}
+	if stretches := idx.endContaminants(reversed.Seq); len(stretches) != 1 || stretches[0].start != 400 || stretches[0].end != 433 {
+		t.Errorf("endContaminants() = %+v, want the adapter at 400-433", stretches)
+	}
+
+	frags := []*Frag{clean, internal, leftover, reversed}
+	flagged, trimmed := screenContaminants(frags, idx, flagContaminants)
+	if trimmed != 0 || len(flagged) != 2 || !flagged[leftover] || !flagged[reversed] {
+		t.Errorf("screenContaminants(flag) = %v, %d, want leftover and reversed flagged", flagged, trimmed)
+	}
+
+	flagged, trimmed = screenContaminants(frags, idx, trimContaminants)
+	if trimmed != 2 || len(flagged) != 0 {
+		t.Errorf("screenContaminants(trim) = %v, %d, want 2 trimmed", flagged, trimmed)
+	}
+	if leftover.Seq != insert || reversed.Seq != insert {
+		t.Errorf("screenContaminants(trim) didn't trim the adapters")
+	}
+	// the original had 39bp of leftover, its 3rd base already stripped
+	var wantStripped []int
+	for i := 0; i < 39; i++ {
+		wantStripped = append(wantStripped, i)
+	}
+	if !reflect.DeepEqual(leftover.strippedIndexes, wantStripped) {
+		t.Errorf("screenContaminants(trim) stripped indexes = %v, want %v", leftover.strippedIndexes, wantStripped)
+	}
+}
@@ -0,0 +1,150 @@
+package repp
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DBStats summarizes the sequences of a database. It's computed when the
+// database is added and stored in the manifest.
+type DBStats struct {
+	// Sequences is the number of entries in the database
+	Sequences int `json:"sequences"`
+
+	// Circular is the number of circular entries
+	Circular int `json:"circular"`
+
+	// Linear is the number of linear entries
+	Linear int `json:"linear"`
+
+	// Duplicates is the number of entries with the same sequence as an earlier entry
+	Duplicates int `json:"duplicates"`
+
+	// Length is the distribution of the sequences' lengths in bp (not doubled if circular)
+	Length Distribution `json:"length"`
+
+	// GC is the distribution of the sequences' GC content, as a fraction
+	GC Distribution `json:"gc"`
+
+	// Rebuilt is when the database was last built
+	Rebuilt time.Time `json:"rebuilt"`
+}
+
+// Distribution summarizes a set of values
+type Distribution struct {
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+	Max    float64 `json:"max"`
+}
+
+// newDistribution returns the distribution of values. It's zero if there are none.
+func newDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+
+	mid := len(sorted) / 2
+	median := sorted[mid]
+	if len(sorted)%2 == 0 {
+		median = (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	return Distribution{
+		Min:    sorted[0],
+		Median: median,
+		Mean:   sum / float64(len(sorted)),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// dbSeqStats computes the statistics of a database's FASTA file. Circular entries
+// are written doubled, so only their first half is measured.
+func dbSeqStats(dbPath string, rebuilt time.Time) (*DBStats, error) {
+	stats := &DBStats{Rebuilt: rebuilt}
+	var lengths, gcs []float64
+	seen := make(map[[sha256.Size]byte]bool)
+
+	err := scanFasta(dbPath, func(header, seq string) {
+		stats.Sequences++
+		if strings.Contains(header, "circular") {
+			stats.Circular++
+			seq = seq[:len(seq)/2]
+		} else {
+			stats.Linear++
+		}
+
+		// hash the sequences so large databases aren't held in memory
+		h := sha256.Sum256([]byte(seq))
+		if seen[h] {
+			stats.Duplicates++
+		}
+		seen[h] = true
+
+		lengths = append(lengths, float64(len(seq)))
+		if len(seq) > 0 {
+			gcCount := strings.Count(seq, "G") + strings.Count(seq, "C")
+			gcs = append(gcs, float64(gcCount)/float64(len(seq)))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Length = newDistribution(lengths)
+	stats.GC = newDistribution(gcs)
+	return stats, nil
+}
+
+// DatabaseStats prints the statistics of a sequence database. They're computed
+// from its sequence file if the database was added before they were stored.
+func DatabaseStats(dbName string) {
+	m, err := newManifest()
+	if err != nil {
+		rlog.Fatal(err)
+	}
+
+	db, ok := m.DBs[dbName]
+	if !ok {
+		rlog.Fatalf("DB %s not registered - known databases: %v", dbName, DatabaseNames())
+	}
+
+	stats := db.Stats
+	if stats == nil {
+		info, err := os.Stat(db.Path)
+		if err != nil {
+			rlog.Fatal(err)
+		}
+		if stats, err = dbSeqStats(db.Path, info.ModTime()); err != nil {
+			rlog.Fatal(err)
+		}
+		rlog.Infof("%s was added without statistics, they were computed from %s", dbName, db.Path)
+	}
+
+	fmt.Printf("database %s (%s)\n\n", db.Name, db.Path)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(w, "sequences\t%d\n", stats.Sequences)
+	fmt.Fprintf(w, "circular\t%d\n", stats.Circular)
+	fmt.Fprintf(w, "linear\t%d\n", stats.Linear)
+	fmt.Fprintf(w, "duplicate sequences\t%d\n", stats.Duplicates)
+	fmt.Fprintf(w, "length (bp)\tmin %.0f\tmedian %.0f\tmean %.0f\tmax %.0f\n",
+		stats.Length.Min, stats.Length.Median, stats.Length.Mean, stats.Length.Max)
+	fmt.Fprintf(w, "GC%%\tmin %.1f\tmedian %.1f\tmean %.1f\tmax %.1f\n",
+		stats.GC.Min*100, stats.GC.Median*100, stats.GC.Mean*100, stats.GC.Max*100)
+	fmt.Fprintf(w, "last rebuilt\t%s\n", outputTime(stats.Rebuilt))
+	w.Flush()
+}
@@ -0,0 +1,62 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_newDistribution(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   Distribution
+	}{
+		{"no values", nil, Distribution{}},
+		{"odd number of values", []float64{3, 1, 2}, Distribution{Min: 1, Median: 2, Mean: 2, Max: 3}},
+		{"even number of values", []float64{4, 1, 2, 1}, Distribution{Min: 1, Median: 1.5, Mean: 2, Max: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newDistribution(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newDistribution() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dbSeqStats(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+	contents := ">a circular\nGGCCAATTGGCCAATT\n" + // circular, written doubled
+		">b \nGGCC\nAATT\n" +
+		">c \nGGCCAATT\n" + // same sequence as a and b
+		">d \nGGGG\n"
+	if err := os.WriteFile(dbPath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilt := time.Date(2023, 9, 21, 0, 0, 0, 0, time.UTC)
+	got, err := dbSeqStats(dbPath, rebuilt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &DBStats{
+		Sequences:  4,
+		Circular:   1,
+		Linear:     3,
+		Duplicates: 2,
+		Length:     Distribution{Min: 4, Median: 8, Mean: 7, Max: 8},
+		GC:         Distribution{Min: 0.5, Median: 0.5, Mean: 0.625, Max: 1},
+		Rebuilt:    rebuilt,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dbSeqStats() = %+v, want %+v", got, want)
+	}
+
+	if _, err := dbSeqStats(filepath.Join(t.TempDir(), "missing"), rebuilt); err == nil {
+		t.Error("dbSeqStats() of a missing file did not return an error")
+	}
+}
@@ -0,0 +1,115 @@
+package repp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// matchDump collects the matches considered for a target and why those that didn't reach
+// the assembly search were removed, ex: to audit why an expected template wasn't used
+type matchDump struct {
+	// raw are the matches found, before any were removed
+	raw []match
+
+	// removed are the matches that were removed and why, culledMatch is reused for them
+	removed []culledMatch
+}
+
+// matchKey identifies a match by its template, strands and coordinates on the target and
+// template. Matches that are trimmed, ex: off a sourced feature, get another key
+func matchKey(m match) string {
+	return fmt.Sprintf("%s\t%s\t%d\t%d\t%d\t%d\t%t\t%t", m.db.Path, m.entry, m.queryStart, m.queryEnd,
+		m.subjectStart, m.subjectEnd, m.queryRevCompMatch, m.subjectRevCompMatch)
+}
+
+// drop records the matches of before that aren't in after, removed for the reason
+func (d *matchDump) drop(before, after []match, reason string) {
+	if d == nil {
+		return
+	}
+	kept := make(map[string]int)
+	for _, m := range after {
+		kept[matchKey(m)]++
+	}
+	for _, m := range before {
+		if key := matchKey(m); kept[key] > 0 {
+			kept[key]--
+			continue
+		}
+		d.removed = append(d.removed, culledMatch{m, reason})
+	}
+}
+
+// cull records the matches removed by culling
+func (d *matchDump) cull(removed []culledMatch) {
+	if d == nil {
+		return
+	}
+	d.removed = append(d.removed, removed...)
+}
+
+// write writes the raw matches, with whether each was kept or culled and why, to
+// prefix.raw.tsv and the matches left after culling to prefix.culled.tsv
+func (d *matchDump) write(prefix string, culled []match) error {
+	reasons := make(map[string][]string)
+	for _, r := range d.removed {
+		key := matchKey(r.match)
+		reasons[key] = append(reasons[key], r.reason)
+	}
+	raw := make([]culledMatch, len(d.raw))
+	for i, m := range d.raw {
+		raw[i] = culledMatch{match: m}
+		key := matchKey(m)
+		if len(reasons[key]) > 0 {
+			raw[i].reason = reasons[key][0]
+			reasons[key] = reasons[key][1:]
+		}
+	}
+	kept := make([]culledMatch, len(culled))
+	for i, m := range culled {
+		kept[i] = culledMatch{match: m}
+	}
+
+	for filename, matches := range map[string][]culledMatch{prefix + ".raw.tsv": raw, prefix + ".culled.tsv": kept} {
+		var contents bytes.Buffer
+		if err := writeMatchDump(&contents, matches); err != nil {
+			return fmt.Errorf("failed to write %s: %v", filename, err)
+		}
+		if err := writeFileAtomic(filename, contents.Bytes()); err != nil {
+			return err
+		}
+	}
+	rlog.Infof("wrote %d matches to %s.raw.tsv and %d culled matches to %s.culled.tsv", len(raw), prefix, len(kept), prefix)
+	return nil
+}
+
+// writeMatchDump writes matches to a TSV: their entry, database, coordinates on the target
+// and template (1-based), strand, length, %-identity and, for those without a reason,
+// that they were kept. Otherwise that they were culled and why
+func writeMatchDump(w io.Writer, matches []culledMatch) error {
+	if _, err := fmt.Fprintln(w, "entry\tdatabase\tqstart\tqend\tsstart\tsend\tstrand\tlength\tidentity\tstatus\treason"); err != nil {
+		return err
+	}
+	for _, r := range matches {
+		m := r.match
+		strand := "+"
+		if m.isRevCompMatch() {
+			strand = "-"
+		}
+		identity := 0.0
+		if length := m.length(); length > 0 {
+			identity = 100 * float64(length-m.mismatching) / float64(length)
+		}
+		status := "kept"
+		if r.reason != "" {
+			status = "culled"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%s\t%d\t%.1f\t%s\t%s\n",
+			m.entry, m.db.Name, m.queryStart+1, m.queryEnd+1, m.subjectStart+1, m.subjectEnd+1,
+			strand, m.length(), identity, status, r.reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_matchDump(t *testing.T) {
+	db := DB{Name: "igem", Path: "/dbs/igem"}
+	long := match{entry: "long", db: db, queryStart: 0, queryEnd: 99, subjectStart: 10, subjectEnd: 109}
+	engulfed := match{entry: "engulfed", db: db, queryStart: 10, queryEnd: 49, subjectStart: 0, subjectEnd: 39, mismatching: 4}
+	reference := match{entry: "reference", db: db, queryStart: 50, queryEnd: 149, subjectStart: 0, subjectEnd: 99}
+
+	dump := &matchDump{raw: []match{long, engulfed, reference}}
+	dump.drop([]match{reference}, nil, "in a do-not-PCR template")
+	dump.drop([]match{long, engulfed}, []match{long, engulfed}, "longer than the 5000bp PCR limit")
+	dump.cull([]culledMatch{{engulfed, "engulfed by long (1-100)"}})
+
+	prefix := filepath.Join(t.TempDir(), "target")
+	if err := dump.write(prefix, []match{long}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(prefix + ".raw.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"long\tigem\t1\t100\t11\t110\t+\t100\t100.0\tkept\t\n",
+		"engulfed\tigem\t11\t50\t1\t40\t+\t40\t90.0\tculled\tengulfed by long (1-100)\n",
+		"reference\tigem\t51\t150\t1\t100\t+\t100\t100.0\tculled\tin a do-not-PCR template\n",
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("raw matches are missing %q:\n%s", want, raw)
+		}
+	}
+
+	culled, err := os.ReadFile(prefix + ".culled.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(culled)), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "long\t") {
+		t.Errorf("culled matches = %q, want the header and long", culled)
+	}
+}
@@ -67,7 +67,7 @@ func Test_sequence_e2e(t *testing.T) {
 		}
 
 		for _, s := range sols {
-			e := validateJunctions(s, cfg)
+			e := validateJunctions(s, true, cfg)
 			if e != nil {
 				t.Logf("failed making %s\n", tt.in)
 				t.Error(e)
@@ -126,7 +126,7 @@ func Test_features(t *testing.T) {
 			}
 
 			for _, s := range sols {
-				e := validateJunctions(s, conf)
+				e := validateJunctions(s, true, conf)
 				if e != nil {
 					t.Error(e)
 				}
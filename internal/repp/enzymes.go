@@ -42,12 +42,127 @@ func (c cut) String() string {
 }
 
 func (c cut) getDigestionSites(seqLen int) (cutIndex int) {
+	return c.getTopStrandSite() % seqLen
+}
+
+// getTopStrandSite returns where the top strand is cut, not wrapped around the sequence's length
+func (c cut) getTopStrandSite() int {
 	if c.strand {
-		cutIndex = c.index + c.enzyme.seqCutIndex
-	} else {
-		cutIndex = c.index + len(c.enzyme.recog) - c.enzyme.compCutIndex
+		return c.index + c.enzyme.seqCutIndex
+	}
+	return c.index + len(c.enzyme.recog) - c.enzyme.compCutIndex
+}
+
+// getBottomStrandSite returns where the bottom strand is cut, in top strand coordinates
+// and not wrapped around the sequence's length
+func (c cut) getBottomStrandSite() int {
+	if c.strand {
+		return c.index + c.enzyme.compCutIndex
+	}
+	return c.index + len(c.enzyme.recog) - c.enzyme.seqCutIndex
+}
+
+// digestEnd is the end a cut leaves on a digested backbone
+type digestEnd struct {
+	// enzyme that made the cut
+	enzyme string
+
+	// top and bottom are where the top and bottom strands are cut, in top strand coordinates
+	top, bottom int
+
+	// overhang is the top strand's sequence between the two cuts, empty if blunt
+	overhang string
+}
+
+// newDigestEnd returns the end of a cut in a circular sequence
+func newDigestEnd(c cut, seq string) digestEnd {
+	top, bottom := c.getTopStrandSite(), c.getBottomStrandSite()
+
+	start, length := top, bottom-top
+	if bottom < top {
+		start, length = bottom, top-bottom
+	}
+	start = ((start % len(seq)) + len(seq)) % len(seq)
+	doubled := seq + seq
+	overhang := ""
+	if length < len(seq) {
+		overhang = doubled[start : start+length]
+	}
+
+	return digestEnd{enzyme: c.enzyme.name, top: top, bottom: bottom, overhang: overhang}
+}
+
+// overhangType is 5', 3' or blunt. A 5' overhang is cut first on the top strand
+func (e digestEnd) overhangType() string {
+	switch {
+	case e.top < e.bottom:
+		return "5'"
+	case e.top > e.bottom:
+		return "3'"
+	default:
+		return "blunt"
+	}
+}
+
+// palindromic is whether the overhang is its own reverse complement, so the end can
+// ligate to another copy of itself
+func (e digestEnd) palindromic() bool {
+	return e.overhang != "" && e.overhang == reverseComplement(e.overhang)
+}
+
+// compatibleEnds returns whether the two ends of a band can ligate to one another:
+// both are blunt or they have the same overhang
+func compatibleEnds(left, right digestEnd) bool {
+	return left.overhangType() == right.overhangType() && left.overhang == right.overhang
+}
+
+// digestWarnings returns warnings about the ends of a backbone digested by two cuts.
+// Compatible ends let the backbone re-ligate without an insert, palindromic
+// ones let it ligate to other copies of itself
+func digestWarnings(backboneID string, left, right digestEnd) (warnings []string) {
+	if compatibleEnds(left, right) {
+		ends := "blunt ends"
+		if left.overhang != "" {
+			ends = fmt.Sprintf("the same %s overhang %s", left.overhangType(), left.overhang)
+		}
+		return []string{fmt.Sprintf(
+			"%s digested with %s and %s has %s at both ends and can re-ligate without an insert, dephosphorylate it or gel purify the band",
+			backboneID, left.enzyme, right.enzyme, ends,
+		)}
+	}
+
+	for _, end := range []digestEnd{left, right} {
+		if end.palindromic() {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s end of %s has the self-compatible %s overhang %s and can form concatemers",
+				end.enzyme, backboneID, end.overhangType(), end.overhang,
+			))
+		}
+	}
+	return
+}
+
+// homologyBackbone returns a digested backbone with the sequence that's left of it after
+// exonuclease chew-back, the sequence homology arms have to span. The 3' end of each strand
+// is kept: the first end's overhang bases are added if they're a 3' overhang and removed
+// if they're a 5' overhang. The last end already stops at the top strand's 3' end
+func homologyBackbone(f *Frag, backbone *Backbone) *Frag {
+	if len(backbone.ends) != 2 || f.Seq == "" {
+		return f
+	}
+
+	left := backbone.ends[0]
+	homology := *f
+	switch left.overhangType() {
+	case "3'":
+		homology.Seq = left.overhang + f.Seq
+	case "5'":
+		if len(left.overhang) >= len(f.Seq) {
+			return f
+		}
+		homology.Seq = f.Seq[len(left.overhang):]
 	}
-	return cutIndex % seqLen
+	return &homology
 }
 
 // Backbone is for information on a linearized backbone in the output payload
@@ -66,6 +181,12 @@ type Backbone struct {
 
 	// Strands of each cut direction. True if fwd, False if rev direction
 	Strands []bool `json:"strands"`
+
+	// ends are the first and last ends of the digested backbone
+	ends []digestEnd
+
+	// warnings about the ends of the digested backbone
+	warnings []string
 }
 
 // parses a recognition sequence into a hangInd, cutInd for overhang calculation.
@@ -135,6 +256,7 @@ func digest(frag *Frag, enzymes []enzyme) (digested *Frag, backbone *Backbone, e
 
 		cutIndex := cut.getDigestionSites(len(frag.Seq))
 		digestedSeq := frag.Seq[cutIndex:] + frag.Seq[:cutIndex]
+		end := newDigestEnd(cut, frag.Seq)
 
 		return &Frag{
 				ID:         frag.ID,
@@ -149,6 +271,7 @@ func digest(frag *Frag, enzymes []enzyme) (digested *Frag, backbone *Backbone, e
 				Enzymes:  []string{cut.enzyme.name},
 				Cutsites: []int{cutIndex},
 				Strands:  []bool{cut.strand},
+				ends:     []digestEnd{end, end},
 			},
 			nil
 	}
@@ -180,6 +303,14 @@ func digest(frag *Frag, enzymes []enzyme) (digested *Frag, backbone *Backbone, e
 
 	digestedSeq := doubled[cut1SiteIndex:cut2SiteIndex]
 
+	left, right := newDigestEnd(cut1, frag.Seq), newDigestEnd(cut2, frag.Seq)
+	rlog.Infof("Digested %s has a %s end from %s and a %s end from %s",
+		frag.ID, left.overhangType(), left.enzyme, right.overhangType(), right.enzyme)
+	warnings := digestWarnings(frag.ID, left, right)
+	for _, w := range warnings {
+		rlog.Warn(w)
+	}
+
 	return &Frag{
 			ID:         frag.ID,
 			uniqueID:   "backbone",
@@ -193,6 +324,8 @@ func digest(frag *Frag, enzymes []enzyme) (digested *Frag, backbone *Backbone, e
 			Enzymes:  []string{cut1.enzyme.name, cut2.enzyme.name},
 			Cutsites: []int{cut1SiteIndex, cut2SiteIndex},
 			Strands:  []bool{cut1.strand, cut2.strand},
+			ends:     []digestEnd{left, right},
+			warnings: warnings,
 		},
 		nil
 }
@@ -279,9 +412,14 @@ func NewEnzymeDB() *kv {
 	return newKV(config.EnzymeDB)
 }
 
+// EnzymeNames returns the sorted names of the enzymes in the enzymes db. Used for shell completion.
+func EnzymeNames() []string {
+	return kvKeys(config.EnzymeDB)
+}
+
 // PrintEnzymes writes enzymes that are similar in queried name to stdout.
-// if multiple enzyme names include the enzyme name, they are all returned.
-// otherwise a list of enzyme names are returned (those beneath a levenshtein distance cutoff).
+// the enzymes with names that include the enzyme name are returned, followed by
+// those with names beneath a levenshtein distance cutoff from it.
 func PrintEnzymes(enzyme string) {
 	f := NewEnzymeDB()
 
@@ -314,7 +452,21 @@ func PrintEnzymes(enzyme string) {
 	containing := []string{}
 	lowDistance := []string{}
 
+	// enzymes with names starting with the query come from the index. The rest
+	// of the db is still scanned for names containing it or a few edits from it
+	idx, err := kvIndex(config.EnzymeDB)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	indexed := make(map[string]bool)
+	for _, e := range idx.search(enzyme) {
+		containing = append(containing, e.ID+"\t"+f.contents[e.ID])
+		indexed[e.ID] = true
+	}
 	for fName, fSeq := range f.contents {
+		if indexed[fName] {
+			continue
+		}
 		if strings.Contains(fName, enzyme) {
 			containing = append(containing, fName+"\t"+fSeq)
 		} else if len(fName) > ldCutoff && ld(enzyme, fName, true) <= ldCutoff {
@@ -322,14 +474,9 @@ func PrintEnzymes(enzyme string) {
 		}
 	}
 
-	if len(containing) < 3 {
-		lowDistance = append(lowDistance, containing...)
-		containing = []string{} // clear
-	}
-	if len(containing) > 0 {
-		fmt.Fprint(w, strings.Join(containing, "\n"))
-	} else if len(lowDistance) > 0 {
-		fmt.Fprint(w, strings.Join(lowDistance, "\n"))
+	// the names containing the query, then those a few edits from it
+	if similar := append(containing, lowDistance...); len(similar) > 0 {
+		fmt.Fprint(w, strings.Join(similar, "\n"))
 	} else {
 		fmt.Fprintf(w, "failed to find any enzymes for %s", enzyme)
 	}
@@ -2,6 +2,7 @@ package repp
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/go-test/deep"
@@ -311,6 +312,7 @@ func Test_digest(t *testing.T) {
 					gotDigested, tt.wantDigested, diff)
 			}
 
+			gotBackbone.ends, gotBackbone.warnings = nil, nil // checked in Test_digestEnds
 			if !reflect.DeepEqual(gotBackbone, tt.wantBackbone) {
 				t.Errorf("digest.Backbone = %v, want %v", gotBackbone, tt.wantBackbone)
 			}
@@ -318,6 +320,70 @@ func Test_digest(t *testing.T) {
 	}
 }
 
+func Test_digestEnds(t *testing.T) {
+	pstI := enzyme{name: "PstI", recog: "CTGCAG", seqCutIndex: 5, compCutIndex: 1}
+	ecoRI := enzyme{name: "EcoRI", recog: "GAATTC", seqCutIndex: 1, compCutIndex: 5}
+	ecoRV := enzyme{name: "EcoRV", recog: "GATATC", seqCutIndex: 3, compCutIndex: 3}
+	seq := "ATGAGGTTAGCCAAAAAAGCACGTGAATTCGGTGGCGCCCACCGACTGTTCCCAAACTGTAGCTGCAGTTCGTTCCGTCAAGGCCCGATATCCATCGCGGCCCATTCCA"
+
+	tests := []struct {
+		name         string
+		enzymes      []enzyme
+		wantTypes    []string
+		wantWarnings int
+		wantSeq      string
+	}{
+		{
+			"incompatible 3' and 5' ends",
+			[]enzyme{ecoRI, pstI},
+			[]string{"3'", "5'"},
+			2,                            // both overhangs are palindromic
+			"TGCA" + seq[67:] + seq[:25], // PstI's 3' overhang is kept, EcoRI's 5' one isn't in the band
+		},
+		{
+			"blunt and 3' ends",
+			[]enzyme{pstI, ecoRV},
+			[]string{"blunt", "3'"},
+			1,
+			seq[89:] + seq[:67],
+		},
+		{
+			"compatible ends of a single enzyme",
+			[]enzyme{ecoRI},
+			[]string{"5'", "5'"},
+			0,                   // only warned about when there are two cuts
+			seq[29:] + seq[:25], // the AATT overhang is chewed back
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digested, backbone, err := digest(&Frag{ID: "bb", Seq: seq}, tt.enzymes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(backbone.ends) != 2 {
+				t.Fatalf("digest() ends = %v, want 2", backbone.ends)
+			}
+			for i, end := range backbone.ends {
+				if end.overhangType() != tt.wantTypes[i] {
+					t.Errorf("end %d overhangType() = %s, want %s", i, end.overhangType(), tt.wantTypes[i])
+				}
+			}
+			if len(backbone.warnings) != tt.wantWarnings {
+				t.Errorf("digest() warnings = %v, want %d", backbone.warnings, tt.wantWarnings)
+			}
+			if got := homologyBackbone(digested, backbone).Seq; got != tt.wantSeq {
+				t.Errorf("homologyBackbone() = %s, want %s", got, tt.wantSeq)
+			}
+		})
+	}
+
+	ecoRIEnd := newDigestEnd(cut{index: 24, strand: true, enzyme: ecoRI}, seq)
+	if w := digestWarnings("bb", ecoRIEnd, ecoRIEnd); len(w) != 1 || !strings.Contains(w[0], "re-ligate") {
+		t.Errorf("digestWarnings() = %v, want a re-ligation warning", w)
+	}
+}
+
 func Test_newEnzyme(t *testing.T) {
 	type args struct {
 		name  string
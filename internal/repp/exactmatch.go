@@ -0,0 +1,266 @@
+package repp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// exactMatchK is the length of the k-mers used to seed exact matches.
+// 32bp k-mers fit in a uint64 at 2 bits per bp
+const exactMatchK = 32
+
+// kmerIndex maps 2-bit encoded k-mers to their start indexes in a sequence
+type kmerIndex map[uint64][]int
+
+// encodeKmer packs a k-mer into a uint64. The second return value
+// is false if the k-mer has any non-ACGT bases
+func encodeKmer(kmer string) (uint64, bool) {
+	var code uint64
+	for i := 0; i < len(kmer); i++ {
+		code <<= 2
+		switch kmer[i] {
+		case 'A':
+		case 'C':
+			code |= 1
+		case 'G':
+			code |= 2
+		case 'T':
+			code |= 3
+		default:
+			return 0, false
+		}
+	}
+	return code, true
+}
+
+// newKmerIndex indexes every k-mer in the sequence
+func newKmerIndex(seq string, k int) kmerIndex {
+	index := make(kmerIndex)
+	for i := 0; i+k <= len(seq); i++ {
+		if code, ok := encodeKmer(seq[i : i+k]); ok {
+			index[code] = append(index[code], i)
+		}
+	}
+	return index
+}
+
+// exactMatches finds the matches between the query sequence and the database entries
+// that are exact and at least minLength long without running BLAST.
+//
+// The query is indexed by its k-mers. Each entry in the database FASTA files is
+// streamed through and sampled at a stride short enough that every exact match at least
+// minLength long contains a sampled k-mer. Seed hits are then extended in both directions.
+// Matches follow the same conventions as the ones parsed from BLAST output.
+func exactMatches(
+	seq string,
+	circular bool,
+	matchLeftMargin int,
+	leftMarginHandling string,
+	dbs []DB,
+	filters []string,
+	filter filterExpr,
+	minLength int,
+) ([]match, error) {
+	seq = strings.ToUpper(seq)
+	querySeq := seq
+	if circular {
+		querySeq = seq + seq
+	}
+
+	k := exactMatchK
+	if minLength < k {
+		k = minLength
+	}
+	if k < 1 || len(querySeq) < k {
+		return nil, nil
+	}
+	stride := minLength - k + 1
+
+	e := &exactMatcher{
+		seq:       seq,
+		querySeq:  querySeq,
+		circular:  circular,
+		filters:   filters,
+		filter:    filter,
+		minLength: minLength,
+		k:         k,
+		stride:    stride,
+		index:     newKmerIndex(querySeq, k),
+	}
+
+	var matches []match
+	for _, db := range dbs {
+		dbMatches, err := e.search(db)
+		if err != nil {
+			return nil, err
+		}
+		if circular {
+			// same as with BLAST: a match at the start may be part of one wrapping the zero index
+			var report []marginMatch
+			dbMatches, report = applyLeftMargin(dbMatches, seq, matchLeftMargin, leftMarginHandling)
+			logLeftMarginReport(report)
+		}
+		matches = append(matches, dbMatches...)
+	}
+
+	return matches, nil
+}
+
+// exactMatcher holds the query index used to find exact matches in databases
+type exactMatcher struct {
+	// the query sequence (not doubled)
+	seq string
+
+	// the query sequence that's indexed (doubled if circular)
+	querySeq string
+
+	// whether the query is circular
+	circular bool
+
+	// title filters, as in blastExec
+	filters []string
+
+	// filter expression of the entries to keep, as in blastExec
+	filter filterExpr
+
+	// minimum length of a reported exact match
+	minLength int
+
+	// length of the indexed k-mers
+	k int
+
+	// distance between the sampled k-mers in the database entries
+	stride int
+
+	// k-mer index of querySeq
+	index kmerIndex
+}
+
+// search streams a database's FASTA file and returns its exact matches with the query
+func (e *exactMatcher) search(db DB) (ms []match, err error) {
+	err = scanFasta(db.Path, func(header, seq string) {
+		ms = append(ms, e.searchEntry(db, header, seq)...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database %s: %v", db.Path, err)
+	}
+
+	return ms, nil
+}
+
+// searchEntry returns the exact matches between the query and a single database entry
+func (e *exactMatcher) searchEntry(db DB, header, subject string) (ms []match) {
+	entry, title := splitHeader(header)
+	titles, circular, keep := screenEntry(entry, title, db, len(subject), e.filters, e.filter)
+	if !keep {
+		return nil
+	}
+
+	revSubject := reverseComplement(subject)
+	seen := make(map[string]bool)
+	addMatch := func(subjectSeq string, qs, ss int, revComp bool) {
+		// extend the seed to the left and right
+		qe, se := qs+e.k-1, ss+e.k-1
+		for qs > 0 && ss > 0 && e.querySeq[qs-1] == subjectSeq[ss-1] {
+			qs--
+			ss--
+		}
+		for qe < len(e.querySeq)-1 && se < len(subjectSeq)-1 && e.querySeq[qe+1] == subjectSeq[se+1] {
+			qe++
+			se++
+		}
+
+		key := fmt.Sprintf("%d-%d-%t", qs, ss, revComp)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		if qe-qs+1 < e.minLength {
+			return
+		}
+		m := match{
+			entry:               entry,
+			querySeq:            e.querySeq[qs : qe+1],
+			queryStart:          qs,
+			queryEnd:            qe,
+			seq:                 subjectSeq[ss : se+1],
+			subjectStart:        ss,
+			subjectEnd:          se,
+			db:                  db,
+			title:               titles,
+			circular:            circular,
+			subjectRevCompMatch: revComp,
+			subjectLength:       len(subjectSeq),
+		}
+		if revComp {
+			// report subject coordinates on the entry's own strand
+			m.subjectStart, m.subjectEnd = len(subjectSeq)-1-se, len(subjectSeq)-1-ss
+		}
+		m.uniqueID = m.matchID(len(e.seq))
+		ms = append(ms, m)
+	}
+
+	for i := 0; i+e.k <= len(subject); i += e.stride {
+		kmer := subject[i : i+e.k]
+		if code, ok := encodeKmer(kmer); ok {
+			for _, q := range e.index[code] {
+				addMatch(subject, q, i, false)
+			}
+		}
+
+		// the reverse complement of the k-mer, from the entry's reverse complement
+		revStart := len(subject) - i - e.k
+		if code, ok := encodeKmer(revSubject[revStart : revStart+e.k]); ok {
+			for _, q := range e.index[code] {
+				addMatch(revSubject, q, revStart, true)
+			}
+		}
+	}
+
+	return ms
+}
+
+// exactMatchesCover returns whether the matches cover the (circular) target of
+// length targetLength leaving no gap longer than maxGap.
+func exactMatchesCover(matches []match, targetLength, maxGap int) bool {
+	if targetLength == 0 || len(matches) == 0 {
+		return false
+	}
+
+	covered := make([]bool, targetLength)
+	for _, m := range matches {
+		if m.queryEnd-m.queryStart+1 >= targetLength {
+			return true
+		}
+		for i := m.queryStart; i <= m.queryEnd; i++ {
+			covered[i%targetLength] = true
+		}
+	}
+
+	// find the first covered index and walk the target from there so gaps across the zero index are measured once
+	first := -1
+	for i, c := range covered {
+		if c {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return false
+	}
+
+	gap := 0
+	for i := 1; i <= targetLength; i++ {
+		if covered[(first+i)%targetLength] {
+			gap = 0
+			continue
+		}
+		gap++
+		if gap > maxGap {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,150 @@
+package repp
+
+import (
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_exactMatches(t *testing.T) {
+	bases := []byte("ACGT")
+	r := rand.New(rand.NewSource(1))
+	randSeq := func(n int) string {
+		seq := make([]byte, n)
+		for i := range seq {
+			seq[i] = bases[r.Intn(4)]
+		}
+		return string(seq)
+	}
+
+	target := randSeq(1000)
+	// a point mutation at 500
+	mutated := []byte(target)
+	mutated[500] = 'A'
+	if target[500] == 'A' {
+		mutated[500] = 'C'
+	}
+
+	dbFile, err := os.CreateTemp("", "exact-db-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(dbFile.Name())
+	dbFile.WriteString(">plasmid circular\n" + string(mutated) + string(mutated) + "\n")
+	dbFile.WriteString(">insert \n" + strings.Repeat("N", 50) + reverseComplement(target[100:400]) + strings.Repeat("N", 50) + "\n")
+	dbFile.WriteString(">other \n" + randSeq(1000) + "\n")
+	dbFile.Close()
+	db := DB{Name: "exact", Path: dbFile.Name()}
+
+	type args struct {
+		filters []string
+		filter  string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantEntries map[string]bool
+		wantCover   bool
+	}{
+		{
+			"plasmid and reverse complement insert",
+			args{[]string{}, ""},
+			map[string]bool{"plasmid": true, "insert": true},
+			true,
+		},
+		{
+			"filtered plasmid",
+			args{[]string{"PLASMID"}, ""},
+			map[string]bool{"insert": true},
+			false,
+		},
+		{
+			"filter expression keeps the shorter entries",
+			args{[]string{}, "length<1000 AND circular=false"},
+			map[string]bool{"insert": true},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parseFilter(tt.args.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matches, err := exactMatches(target, true, 0, leftMarginMerge, []DB{db}, tt.args.filters, filter, 200)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entries := make(map[string]bool)
+			for _, m := range matches {
+				entries[m.entry] = true
+				if m.querySeq != m.seq {
+					t.Errorf("match %s has a subject sequence that differs from the query", m.uniqueID)
+				}
+				if m.entry == "insert" {
+					if !m.subjectRevCompMatch {
+						t.Errorf("insert match should be on the reverse complement")
+					}
+					if m.subjectStart != 50 || m.subjectEnd != 349 {
+						t.Errorf("insert match at %d-%d, want 50-349", m.subjectStart, m.subjectEnd)
+					}
+				}
+			}
+			if len(entries) != len(tt.wantEntries) {
+				t.Errorf("exactMatches() entries = %v, want %v", entries, tt.wantEntries)
+			}
+			for e := range tt.wantEntries {
+				if !entries[e] {
+					t.Errorf("exactMatches() missing entry %s", e)
+				}
+			}
+
+			if got := exactMatchesCover(matches, len(target), 60); got != tt.wantCover {
+				t.Errorf("exactMatchesCover() = %v, want %v", got, tt.wantCover)
+			}
+		})
+	}
+}
+
+func Test_exactMatchesCover(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []match
+		maxGap  int
+		want    bool
+	}{
+		{
+			"full length match",
+			[]match{{queryStart: 150, queryEnd: 1149}},
+			0,
+			true,
+		},
+		{
+			"small gap across the zero index",
+			[]match{{queryStart: 10, queryEnd: 990}},
+			20,
+			true,
+		},
+		{
+			"large gap",
+			[]match{{queryStart: 10, queryEnd: 500}, {queryStart: 600, queryEnd: 1005}},
+			50,
+			false,
+		},
+		{
+			"no matches",
+			[]match{},
+			50,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exactMatchesCover(tt.matches, 1000, tt.maxGap); got != tt.want {
+				t.Errorf("exactMatchesCover() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package repp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// explainSolutions adds an explanation of the optimizer's choices to each solution:
+// why each stretch of the target is PCR'ed from a database fragment or synthesized,
+// and how the solution compares to the other solutions. Solutions are ranked by their
+// number of fragments, then their number of synthetic fragments, then their cost.
+func explainSolutions(solutions []Solution, targetLength int, conf *config.Config) {
+	for i := range solutions {
+		s := &solutions[i]
+		s.Explanation = nil
+		for _, f := range s.Fragments {
+			s.Explanation = append(s.Explanation, explainFragment(f, targetLength, conf))
+		}
+		for j, other := range solutions {
+			if i != j {
+				s.Explanation = append(s.Explanation, fmt.Sprintf("vs solution %d: %s", j+1, compareSolutions(*s, other)))
+			}
+		}
+	}
+}
+
+// explainFragment returns why a fragment was used for its stretch of the target
+func explainFragment(f *Frag, targetLength int, conf *config.Config) string {
+	length := len(f.Seq)
+	span := fmt.Sprintf("%d-%d (%dbp)", f.start%targetLength+1, (f.start+length-1)%targetLength+1, length)
+
+	switch f.fragType {
+	case synthetic:
+		if f.synthReason != "" {
+			return fmt.Sprintf("synthesized %s: %s", span, f.synthReason)
+		}
+		return fmt.Sprintf("synthesized %s", span)
+	case pcr:
+		identity := ""
+		if f.matchRatio > 0 {
+			identity = fmt.Sprintf(", %.0f%% identical to the target", f.matchRatio*100)
+		}
+		note := fmt.Sprintf("PCR %s for %s%s", fragmentSource(f), span, identity)
+		if synthCost := conf.SynthFragmentCost(length); synthCost > f.Cost {
+			note += fmt.Sprintf(": %.2f vs %.2f to synthesize", f.Cost, synthCost)
+		}
+		return note
+	default:
+		return fmt.Sprintf("%s used as is for %s", fragmentSource(f), span)
+	}
+}
+
+// fragmentSource returns a fragment's ID prefixed by its database, ex: "addgene:12345"
+func fragmentSource(f *Frag) string {
+	if f.db.Name == "" {
+		return f.ID
+	}
+	return f.db.Name + ":" + f.ID
+}
+
+// compareSolutions returns how a solution differs from another, in the order the optimizer ranks them
+func compareSolutions(s, other Solution) string {
+	var diffs []string
+	if d := s.Count - other.Count; d != 0 {
+		diffs = append(diffs, countDiff(d, "fragment"))
+	}
+	if d := s.synthFragsCount - other.synthFragsCount; d != 0 {
+		diffs = append(diffs, countDiff(d, "synthetic fragment"))
+	}
+	if d := s.Cost - other.Cost; d < 0 {
+		diffs = append(diffs, fmt.Sprintf("%.2f cheaper", -d))
+	} else if d > 0 {
+		diffs = append(diffs, fmt.Sprintf("%.2f more", d))
+	}
+	if s.SuccessScore != other.SuccessScore && s.SuccessScore > 0 && other.SuccessScore > 0 {
+		diffs = append(diffs, fmt.Sprintf("%.0f%% vs %.0f%% predicted success", s.SuccessScore*100, other.SuccessScore*100))
+	}
+	if len(diffs) == 0 {
+		diffs = append(diffs, "same fragment count and cost")
+	}
+
+	used, otherUsed := sourcesOnlyIn(s, other), sourcesOnlyIn(other, s)
+	if len(used) > 0 && len(otherUsed) > 0 {
+		diffs = append(diffs, fmt.Sprintf("uses %s instead of %s", strings.Join(used, ", "), strings.Join(otherUsed, ", ")))
+	} else if len(used) > 0 {
+		diffs = append(diffs, fmt.Sprintf("also uses %s", strings.Join(used, ", ")))
+	} else if len(otherUsed) > 0 {
+		diffs = append(diffs, fmt.Sprintf("doesn't use %s", strings.Join(otherUsed, ", ")))
+	}
+
+	return strings.Join(diffs, ", ")
+}
+
+// countDiff formats a difference in a count, ex: "2 fewer fragments"
+func countDiff(d int, noun string) string {
+	comparison := "more"
+	if d < 0 {
+		comparison, d = "fewer", -d
+	}
+	if d > 1 {
+		noun += "s"
+	}
+	return fmt.Sprintf("%d %s %s", d, comparison, noun)
+}
+
+// sourcesOnlyIn returns the sources of the database fragments in s that aren't in other
+func sourcesOnlyIn(s, other Solution) (sources []string) {
+	inOther := make(map[string]bool)
+	for _, f := range other.Fragments {
+		inOther[fragmentSource(f)] = true
+	}
+	seen := make(map[string]bool)
+	for _, f := range s.Fragments {
+		source := fragmentSource(f)
+		if f.fragType != synthetic && !inOther[source] && !seen[source] {
+			sources = append(sources, source)
+			seen[source] = true
+		}
+	}
+	return sources
+}
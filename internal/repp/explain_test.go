@@ -0,0 +1,129 @@
+package repp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_explainFragment(t *testing.T) {
+	c := config.New()
+	addgene := DB{Name: "addgene"}
+
+	tests := []struct {
+		name string
+		f    *Frag
+		want string
+	}{
+		{
+			"synthetic",
+			&Frag{Seq: "ACGTACGTAC", start: 105, end: 115, fragType: synthetic},
+			"synthesized 6-15 (10bp)",
+		},
+		{
+			"synthetic, with the reason it was synthesized",
+			&Frag{Seq: "ACGTACGTAC", start: 105, end: 115, fragType: synthetic, synthReason: "listed with --synthetic"},
+			"synthesized 6-15 (10bp): listed with --synthetic",
+		},
+		{
+			"pcr from a database",
+			&Frag{ID: "12345", Seq: "ACGTACGTAC", start: 10, end: 19, fragType: pcr, matchRatio: 0.98, db: addgene, Cost: 10000},
+			"PCR addgene:12345 for 11-20 (10bp), 98% identical to the target",
+		},
+		{
+			"used as is",
+			&Frag{ID: "pSB1C3", Seq: "ACGTACGTAC", start: 0, end: 9, fragType: circular},
+			"pSB1C3 used as is for 1-10 (10bp)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := explainFragment(tt.f, 100, c); got != tt.want {
+				t.Errorf("explainFragment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_explainFullSynthesis(t *testing.T) {
+	tests := []struct {
+		name     string
+		rank     int
+		unfilled int
+		fillErr  error
+		want     string
+	}{
+		{"ranked first", 0, 0, nil, "it ranked above every assembly of database fragments by fragment count, then cost"},
+		{"others failed", 3, 2, errors.New("no primers"), "2 assemblies of database fragments ranked above it couldn't be filled, ex: no primers"},
+		{"alternative", 3, 0, nil, "an alternative to the assemblies of database fragments ranked above it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synth := &Frag{fragType: synthetic}
+			explainFullSynthesis([]*Frag{synth}, tt.rank, tt.unfilled, tt.fillErr)
+			if synth.synthReason != tt.want {
+				t.Errorf("explainFullSynthesis() = %q, want %q", synth.synthReason, tt.want)
+			}
+		})
+	}
+
+	// the reason recorded when the assembly was created is kept
+	synth := &Frag{fragType: synthetic, synthReason: "no assembly of database fragments spans the target"}
+	explainFullSynthesis([]*Frag{synth}, 0, 0, nil)
+	if synth.synthReason != "no assembly of database fragments spans the target" {
+		t.Errorf("explainFullSynthesis() replaced the reason with %q", synth.synthReason)
+	}
+}
+
+func Test_explainSolutions(t *testing.T) {
+	c := config.New()
+	addgene, igem := DB{Name: "addgene"}, DB{Name: "igem"}
+
+	solutions := []Solution{
+		{
+			Count: 2,
+			Cost:  150,
+			Fragments: []*Frag{
+				{ID: "A", Seq: "ACGT", fragType: pcr, db: addgene},
+				{ID: "B", Seq: "ACGT", start: 50, fragType: pcr, db: addgene},
+			},
+		},
+		{
+			Count:           3,
+			Cost:            120.5,
+			synthFragsCount: 1,
+			Fragments: []*Frag{
+				{ID: "A", Seq: "ACGT", fragType: pcr, db: addgene},
+				{ID: "C", Seq: "ACGT", start: 30, fragType: pcr, db: igem},
+				{ID: "syn", Seq: "ACGT", start: 60, fragType: synthetic},
+			},
+		},
+	}
+	explainSolutions(solutions, 100, c)
+
+	if got, want := solutions[0].Explanation[2], "vs solution 2: 1 fewer fragment, 1 fewer synthetic fragment, 29.50 more, uses addgene:B instead of igem:C"; got != want {
+		t.Errorf("explainSolutions() = %q, want %q", got, want)
+	}
+	if got, want := solutions[1].Explanation[3], "vs solution 1: 1 more fragment, 1 more synthetic fragment, 29.50 cheaper, uses igem:C instead of addgene:B"; got != want {
+		t.Errorf("explainSolutions() = %q, want %q", got, want)
+	}
+	if len(solutions[1].Explanation) != 4 {
+		t.Errorf("explainSolutions() = %v, want an explanation of each fragment and a comparison", solutions[1].Explanation)
+	}
+}
+
+func Test_countDiff(t *testing.T) {
+	tests := []struct {
+		d    int
+		want string
+	}{
+		{1, "1 more fragment"},
+		{-2, "2 fewer fragments"},
+	}
+	for _, tt := range tests {
+		if got := countDiff(tt.d, "fragment"); got != tt.want {
+			t.Errorf("countDiff(%d) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
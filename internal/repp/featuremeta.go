@@ -0,0 +1,112 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FeatureMeta is the metadata of a feature in the features database
+type FeatureMeta struct {
+	// Type of the feature, ex: promoter, rbs, cds or terminator
+	Type string `json:"type,omitempty"`
+
+	// Organism the feature is from
+	Organism string `json:"organism,omitempty"`
+
+	// Reference for the feature, ex: a DOI or GenBank accession
+	Reference string `json:"reference,omitempty"`
+
+	// Notes about the feature
+	Notes string `json:"notes,omitempty"`
+}
+
+// featureRecord is a feature of the features database with its metadata, rather than just its sequence
+type featureRecord struct {
+	Seq string `json:"seq"`
+	FeatureMeta
+}
+
+// featureTypes are the valid types of features
+var featureTypes = map[string]bool{
+	"promoter":   true,
+	"rbs":        true,
+	"utr":        true,
+	"cds":        true,
+	"tag":        true,
+	"linker":     true,
+	"terminator": true,
+	"ori":        true,
+	"marker":     true,
+	"insulator":  true,
+	"misc":       true,
+}
+
+// featureFusionTypes are the types of features that may be between an RBS and its CDS
+var featureFusionTypes = map[string]bool{"utr": true, "tag": true, "linker": true}
+
+// isEmpty returns whether the feature has no metadata
+func (m FeatureMeta) isEmpty() bool {
+	return m == FeatureMeta{}
+}
+
+// normalize lower cases the type of a feature and checks that it's valid
+func (m FeatureMeta) normalize() (FeatureMeta, error) {
+	m.Type = strings.ToLower(strings.TrimSpace(m.Type))
+	if m.Type != "" && !featureTypes[m.Type] {
+		var valid []string
+		for t := range featureTypes {
+			valid = append(valid, t)
+		}
+		sort.Strings(valid)
+		return m, fmt.Errorf("invalid feature type %q; valid values [%s]", m.Type, strings.Join(valid, ", "))
+	}
+	return m, nil
+}
+
+// featureOrderWarnings returns warnings about typed features in an order that doesn't make
+// transcription units: a CDS without a promoter upstream of it, an RBS that isn't followed by
+// a CDS, or a terminator without a CDS upstream of it. Reversed features, with names that end
+// in ":REV", are read from the end of the insert. Features without a type are skipped
+func featureOrderWarnings(feats [][]string, metadata map[string]FeatureMeta) (warnings []string) {
+	var fwd, rev []string
+	for _, feat := range feats {
+		if name := strings.TrimSuffix(feat[0], ":REV"); name != feat[0] {
+			rev = append([]string{name}, rev...)
+		} else {
+			fwd = append(fwd, name)
+		}
+	}
+
+	for _, strand := range [][]string{fwd, rev} {
+		var promoter, cds bool
+		rbs := "" // an RBS waiting for its CDS
+		for _, name := range strand {
+			t := metadata[name].Type
+			if rbs != "" && t != "" && t != "cds" && !featureFusionTypes[t] {
+				warnings = append(warnings, fmt.Sprintf("RBS %s isn't followed by a CDS", rbs))
+				rbs = ""
+			}
+			switch t {
+			case "promoter":
+				promoter, cds = true, false
+			case "rbs":
+				rbs = name
+			case "cds":
+				if !promoter {
+					warnings = append(warnings, fmt.Sprintf("CDS %s has no promoter upstream of it", name))
+				}
+				cds, rbs = true, ""
+			case "terminator":
+				if !cds {
+					warnings = append(warnings, fmt.Sprintf("terminator %s has no CDS upstream of it", name))
+				}
+				promoter, cds = false, false
+			}
+		}
+		if rbs != "" {
+			warnings = append(warnings, fmt.Sprintf("RBS %s isn't followed by a CDS", rbs))
+		}
+	}
+	return warnings
+}
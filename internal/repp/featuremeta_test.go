@@ -0,0 +1,60 @@
+package repp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseKV(t *testing.T) {
+	dat := []byte(`{
+  "BBa_B0010": "CCAGGCATCAAATAAAACGAAAGGCTCAGTCGAAAGACTGGGCCTTTCGTTTTAT",
+  "BBa_R0062": {"seq": "ACCTGTAGGATCGTACAGGTTTACGCAAGAAAATGGTTTGTTATAGTCGAATAAA", "type": "promoter", "organism": "Vibrio fischeri"}
+}`)
+	contents, metadata, err := parseKV(dat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 2 || contents["BBa_R0062"] != "ACCTGTAGGATCGTACAGGTTTACGCAAGAAAATGGTTTGTTATAGTCGAATAAA" {
+		t.Errorf("parseKV() contents = %v", contents)
+	}
+	want := map[string]FeatureMeta{"BBa_R0062": {Type: "promoter", Organism: "Vibrio fischeri"}}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("parseKV() metadata = %v, want %v", metadata, want)
+	}
+
+	if _, _, err = parseKV([]byte(`{"bad": 1}`)); err == nil {
+		t.Error("parseKV() parsed a value that's neither a sequence nor a record")
+	}
+}
+
+func Test_featureOrderWarnings(t *testing.T) {
+	metadata := map[string]FeatureMeta{
+		"pTet":  {Type: "promoter"},
+		"B0034": {Type: "rbs"},
+		"His6":  {Type: "tag"},
+		"GFP":   {Type: "cds"},
+		"B0015": {Type: "terminator"},
+	}
+	feat := func(name string) []string { return []string{name, ""} }
+
+	tests := []struct {
+		name  string
+		feats [][]string
+		want  []string
+	}{
+		{"transcription unit", [][]string{feat("pTet"), feat("B0034"), feat("His6"), feat("GFP"), feat("B0015")}, nil},
+		{"untyped features are skipped", [][]string{feat("pTet"), feat("spacer"), feat("GFP"), feat("B0015")}, nil},
+		{"reversed transcription unit", [][]string{feat("B0015:REV"), feat("GFP:REV"), feat("B0034:REV"), feat("pTet:REV")}, nil},
+		{"no promoter", [][]string{feat("B0034"), feat("GFP"), feat("B0015")}, []string{"CDS GFP has no promoter upstream of it"}},
+		{"reversed promoter", [][]string{feat("pTet:REV"), feat("GFP"), feat("B0015")}, []string{"CDS GFP has no promoter upstream of it"}},
+		{"RBS without a CDS", [][]string{feat("pTet"), feat("B0034"), feat("B0015")}, []string{"RBS B0034 isn't followed by a CDS", "terminator B0015 has no CDS upstream of it"}},
+		{"trailing RBS", [][]string{feat("pTet"), feat("GFP"), feat("B0034")}, []string{"RBS B0034 isn't followed by a CDS"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := featureOrderWarnings(tt.feats, metadata); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("featureOrderWarnings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package repp
 
 import (
+	"encoding/csv"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -35,26 +37,37 @@ func Features(assemblyParams AssemblyParams, maxSolutions int, conf *config.Conf
 		rlog.Fatal(err)
 	}
 	// prepare backbone if needed
-	backboneFrag, backboneMeta, err := prepareBackbone(assemblyParams.GetBackboneName(), enzymes, dbs)
+	backboneFrag, backboneMeta, err := prepareBackbone(assemblyParams.GetBackboneName(), enzymes, dbs, conf.Strict)
 	if err != nil {
 		// error getting the backbone
 		rlog.Fatal(err)
 	}
+	// homology arms have to span the ends left after chew-back
+	backboneFrag = homologyBackbone(backboneFrag, backboneMeta)
 
 	// turn feature names into sequences
 	insertFeats, bbFeat := queryFeatures(
 		assemblyParams.GetIn(),
 		backboneFrag,
 		dbs,
+		conf.Strict,
 	)
+	for _, w := range featureOrderWarnings(insertFeats, NewFeatureDB().metadata) {
+		rlog.Warn(w)
+	}
 	feats := insertFeats
 	if len(bbFeat) > 0 {
 		feats = append(feats, bbFeat)
 	}
 
 	// find matches in the databases
+	filter, err := parseFilter(assemblyParams.GetFilterExpr())
+	if err != nil {
+		rlog.Fatal(err)
+	}
 	featureMatches := blastFeatures(
 		assemblyParams.GetFilters(),
+		filter,
 		assemblyParams.GetIdentity(),
 		assemblyParams.GetUngapped(),
 		dbs,
@@ -69,12 +82,21 @@ func Features(assemblyParams AssemblyParams, maxSolutions int, conf *config.Conf
 		rlog.Fatal("failed to find fragments with specified features", "features", featNames)
 	}
 
+	// the primers on hand discount assemblies that can reuse them
+	primersDB, synthFragsDB, err := readOligoDBs(assemblyParams.GetPrimersDBLocations(), assemblyParams.GetSynthFragsDBLocations(), conf)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+
 	// build assemblies containing the matched fragments
+	setRunStage(assemblyStage)
 	target, solutions := featureSolutions(
 		feats,
 		featureMatches,
 		assemblyParams.GetIdentity(),
 		assemblyParams.GetUngapped(),
+		primersDB,
+		synthFragsDB,
 		dbs,
 		maxSolutions,
 		conf,
@@ -86,19 +108,21 @@ func Features(assemblyParams AssemblyParams, maxSolutions int, conf *config.Conf
 		insertLength += len(f[1])
 	}
 
-	// do not use the oligos manifest
-	primersDB := readOligos(assemblyParams.GetPrimersDBLocations(), primerIDPrefix, false)
-	synthFragsDB := readOligos(assemblyParams.GetSynthFragsDBLocations(), synthFragIDPrefix, true)
+	// add the fixed 5' sequences of the primer-additions setting to the designed primers
+	if err := addPrimerAdditions(solutions, conf); err != nil {
+		rlog.Fatal(err)
+	}
 
 	if _, err := writeResult(
-		assemblyParams.GetOut(),
-		assemblyParams.GetOutputFormat(),
+		newOutputOptions(assemblyParams),
 		assemblyParams.GetIn(),
 		target,
 		solutions,
 		primersDB,
 		synthFragsDB,
 		backboneMeta,
+		nil,
+		dbs,
 		time.Since(start).Seconds(),
 		conf,
 	); err != nil {
@@ -112,15 +136,31 @@ func Features(assemblyParams AssemblyParams, maxSolutions int, conf *config.Conf
 func queryFeatures(
 	featuresInput string,
 	backbone *Frag,
-	dbs []DB) ([][]string, []string) {
+	dbs []DB,
+	strict bool) ([][]string, []string) {
 	var insertFeats [][]string // slice of tuples [feature name, feature sequence]
-	if readFeatures, err := read(featuresInput, true, false); err == nil {
+	featureDB := NewFeatureDB()
+	if strings.EqualFold(filepath.Ext(featuresInput), ".csv") {
+		// features in a CSV, with their sequences and orientations
+		inputs, err := readFeaturesCSV(featuresInput)
+		if err != nil {
+			rlog.Fatal(err)
+		}
+		for _, in := range inputs {
+			feat, err := resolveFeature(in, featureDB, dbs, strict)
+			if err != nil {
+				rlog.Fatal(err)
+			}
+			insertFeats = append(insertFeats, feat)
+		}
+	} else if readFeatures, err := read(featuresInput, true, false); err == nil {
 		// see if the features are in a file (multi-FASTA or features in a Genbank)
 		seenFeatures := make(map[string]string) // map feature name to sequence
 		for _, f := range readFeatures {
-			if seq := seenFeatures[f.ID]; seq != f.Seq {
-				rlog.Fatal("failed to parse features, %s has two different sequences:\n\t%s\n\t%s\n", f.ID, f.Seq, seq)
+			if seq, seen := seenFeatures[f.ID]; seen && seq != f.Seq {
+				rlog.Fatalf("failed to parse features, %s has two different sequences:\n\t%s\n\t%s\n", f.ID, f.Seq, seq)
 			}
+			seenFeatures[f.ID] = f.Seq
 			insertFeats = append(insertFeats, []string{f.ID, f.Seq})
 		}
 	} else {
@@ -137,35 +177,19 @@ func queryFeatures(
 			rlog.Fatal("no features chosen. see 'repp make features --help'")
 		}
 
-		featureDB := NewFeatureDB()
 		for _, f := range featureNames {
-			fwd := true
+			in := featureInput{name: f, fwd: true}
 			if strings.Contains(f, ":") {
 				ns := strings.Split(f, ":")
-				f = ns[0]
-				fwd = !strings.Contains(strings.ToLower(ns[1]), "rev")
+				in.name = ns[0]
+				in.fwd = !strings.Contains(strings.ToLower(ns[1]), "rev")
 			}
 
-			if seq, contained := featureDB.contents[f]; contained {
-				if !fwd {
-					f = f + ":REV"
-					seq = reverseComplement(seq)
-				}
-				insertFeats = append(insertFeats, []string{f, seq})
-			} else if dbFrag, err := queryDatabases(f, dbs); err == nil {
-				f = strings.Replace(f, ":", "|", -1)
-				if !fwd {
-					dbFrag.Seq = reverseComplement(dbFrag.Seq)
-				}
-				insertFeats = append(insertFeats, []string{f, dbFrag.Seq})
-			} else {
-				rlog.Fatalf(
-					"failed to find '%s' among the features in (%s) or any db: %s",
-					f,
-					config.FeatureDB,
-					strings.Join(dbNames(dbs), ","),
-				)
+			feat, err := resolveFeature(in, featureDB, dbs, strict)
+			if err != nil {
+				rlog.Fatal(err)
 			}
+			insertFeats = append(insertFeats, feat)
 		}
 	}
 
@@ -178,9 +202,106 @@ func queryFeatures(
 	return insertFeats, bbFeat
 }
 
+// featureInput is a feature requested by name, or with its sequence, and its orientation
+type featureInput struct {
+	name string
+	seq  string
+	fwd  bool
+}
+
+// resolveFeature returns the [name, sequence] tuple of a requested feature. Features
+// without a sequence are looked up in the features database, then the sequence databases.
+// Reversed features are reverse complemented and their names end with ":REV"
+func resolveFeature(in featureInput, featureDB *kv, dbs []DB, strict bool) ([]string, error) {
+	f, seq := in.name, in.seq
+	if seq != "" {
+		seq, _ = cleanSeq(seq, false)
+	} else if dbSeq, contained := featureDB.contents[f]; contained {
+		seq = dbSeq
+	} else if dbFrag, err := queryDatabases(f, dbs, strict); err == nil {
+		f = strings.Replace(f, ":", "|", -1)
+		if !in.fwd {
+			return []string{f, reverseComplement(dbFrag.Seq)}, nil
+		}
+		return []string{f, dbFrag.Seq}, nil
+	} else {
+		return nil, fmt.Errorf(
+			"failed to find '%s' among the features in (%s) or any db: %s",
+			f,
+			config.FeatureDB,
+			strings.Join(dbNames(dbs), ","),
+		)
+	}
+
+	if !in.fwd {
+		return []string{f + ":REV", reverseComplement(seq)}, nil
+	}
+	return []string{f, seq}, nil
+}
+
+// readFeaturesCSV reads the features of a CSV file, in order. Its header names the columns:
+// "name" and the optional "sequence" and "orientation" (fwd or rev, + or -). Features without
+// a sequence are looked up by name like those passed to 'repp make features' directly
+func readFeaturesCSV(path string) (inputs []featureInput, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read features from %s: %v", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no features in %s, it needs a header and a row per feature", path)
+	}
+
+	nameCol, seqCol, orientationCol := -1, -1, -1
+	for i, column := range records[0] {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "name":
+			nameCol = i
+		case "sequence", "seq":
+			seqCol = i
+		case "orientation", "strand":
+			orientationCol = i
+		}
+	}
+	if nameCol < 0 {
+		return nil, fmt.Errorf("%s has no name column in its header", path)
+	}
+
+	cell := func(record []string, col int) string {
+		if col < 0 || col >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[col])
+	}
+	for i, record := range records[1:] {
+		in := featureInput{name: cell(record, nameCol), seq: cell(record, seqCol), fwd: true}
+		if in.name == "" {
+			continue
+		}
+		switch orientation := strings.ToLower(cell(record, orientationCol)); orientation {
+		case "", "fwd", "forward", "+", "1":
+		case "rev", "reverse", "-", "-1", "complement":
+			in.fwd = false
+		default:
+			return nil, fmt.Errorf("row %d of %s has an unknown orientation %q, use fwd or rev", i+2, path, orientation)
+		}
+		inputs = append(inputs, in)
+	}
+	return inputs, nil
+}
+
 // blastFeatures returns matches between the target features and entries in the databases with those features
 func blastFeatures(
 	filters []string,
+	filter filterExpr,
 	identity int,
 	ungapped bool,
 	dbs []DB,
@@ -196,12 +317,18 @@ func blastFeatures(
 			0,
 			dbs,
 			filters,
+			filter,
 			identity,
 			ungapped,
+			conf,
 		)
 		if err != nil {
 			rlog.Fatal(err)
 		}
+		matches, references := splitNoPCR(matches)
+		if len(references) > 0 {
+			rlog.Infof("%d matches of %s are in do-not-PCR templates and won't be PCR'ed", len(references), target[0])
+		}
 
 		for _, m := range matches {
 			// needs to be at least identity % as long as the queried feature
@@ -233,6 +360,7 @@ func featureSolutions(
 	featureMatches map[string][]featureMatch,
 	identity int,
 	ungapped bool,
+	primersDB, synthFragsDB *oligosDB,
 	dbs []DB,
 	keepNSolutions int,
 	conf *config.Config) (string, [][]*Frag) {
@@ -246,7 +374,7 @@ func featureSolutions(
 	extendedMatches = cull(extendedMatches, 1, 4)
 
 	// create a subject file from the matches' source fragments
-	subjectDB, frags := subjectDatabase(extendedMatches, dbs)
+	subjectDB, frags := subjectDatabase(extendedMatches, dbs, conf.Strict)
 	defer os.Remove(subjectDB)
 
 	// re-BLAST the features against the new subject database
@@ -278,7 +406,7 @@ func featureSolutions(
 		}
 		seenMatches[m.uniqueID] = true
 
-		frag, err := queryDatabases(m.entry, dbs)
+		frag, err := queryDatabases(m.entry, dbs, conf.Strict)
 		if err != nil {
 			rlog.Fatal(err)
 		}
@@ -314,7 +442,8 @@ func featureSolutions(
 	}
 
 	// traverse the fragments, accumulate assemblies that span all the features
-	assemblies := createAssemblies(frags, target, len(feats), true, conf)
+	setPrimerReuseBonus(frags, target, primersDB, conf.ToCurrency(conf.PrimerReuseBonus), conf)
+	assemblies := createAssemblies(frags, target, len(feats), true, false, conf)
 
 	// sort assemblies
 	sort.Slice(assemblies, func(i, j int) bool {
@@ -334,7 +463,16 @@ func featureSolutions(
 	}
 
 	// fill each assembly and accumulate the pareto optimal solutions
-	filledAssemblies := fillAssemblies(target, selectedAssemblies, 0, conf)
+	filledAssemblies := fillAssemblies(target, selectedAssemblies, 0, nil, synthFragsDB, conf)
+	var templateLimitDiscarded []*assembly
+	if conf.FragmentsMaxPerTemplate > 0 {
+		filledAssemblies, templateLimitDiscarded = templateLimited(filledAssemblies, conf.FragmentsMaxPerTemplate)
+	}
+
+	// record which fragment, and template, makes each feature
+	for _, a := range filledAssemblies {
+		sourceFeatures(a.frags, feats, featureToStart, featureMatches)
+	}
 
 	// update the target to the first filled assembly
 	if len(filledAssemblies) > 0 {
@@ -344,6 +482,15 @@ func featureSolutions(
 	sort.Slice(filledAssemblies, func(i, j int) bool {
 		return filledAssemblies[i].isBetterThan(*filledAssemblies[j])
 	})
+	if len(templateLimitDiscarded) > 0 {
+		var top *assembly
+		if len(filledAssemblies) > 0 {
+			top = filledAssemblies[0]
+		}
+		if change := templateLimitChange(top, templateLimitDiscarded, conf.FragmentsMaxPerTemplate); change != "" {
+			rlog.Warn(change)
+		}
+	}
 	finalSolutions := make([][]*Frag, len(filledAssemblies))
 	for i := range finalSolutions {
 		finalSolutions[i] = filledAssemblies[i].frags
@@ -403,10 +550,10 @@ func extendMatches(feats [][]string, featureMatches map[string][]featureMatch) (
 // create a subject database to query specifically for all
 // features. Needed because the first BLAST may not return
 // all feature matches on each fragment
-func subjectDatabase(extendedMatches []match, dbs []DB) (filename string, frags []*Frag) {
+func subjectDatabase(extendedMatches []match, dbs []DB, strict bool) (filename string, frags []*Frag) {
 	subject := ""
 	for _, m := range extendedMatches {
-		frag, err := queryDatabases(m.entry, dbs)
+		frag, err := queryDatabases(m.entry, dbs, strict)
 		if err != nil {
 			rlog.Fatal(err)
 		}
@@ -505,9 +652,14 @@ func NewFeatureDB() *kv {
 	return newKV(config.FeatureDB)
 }
 
+// FeatureNames returns the sorted names of the features in the features db. Used for shell completion.
+func FeatureNames() []string {
+	return kvKeys(config.FeatureDB)
+}
+
 // ListFeatures returns features that are similar in name to the feature name requested.
-// if multiple feature names include the feature name, they are all returned.
-// otherwise a list of feature names are returned (those beneath a levenshtein distance cutoff)
+// the features with names that include the feature name are returned, followed by
+// those with names beneath a levenshtein distance cutoff from it
 func ListFeatures(featureName string) {
 	f := NewFeatureDB()
 
@@ -532,7 +684,7 @@ func ListFeatures(featureName string) {
 			if len(seq) > 20 {
 				seq = seq[:20] + "..."
 			}
-			fmt.Fprintf(w, "%s\t%s\n", feat, seq)
+			fmt.Fprintf(w, "%s\t%s\t%s\n", feat, f.metadata[feat].Type, seq)
 		}
 
 		w.Flush()
@@ -547,11 +699,25 @@ func ListFeatures(featureName string) {
 	containing := []string{}
 	lowDistance := []string{}
 
+	// features with names starting with the name's words come from the index. The rest
+	// of the db is still scanned for names containing it or a few edits from it
+	idx, err := kvIndex(config.FeatureDB)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	indexed := make(map[string]bool)
+	for _, e := range idx.search(featureName) {
+		containing = append(containing, e.ID+"\t"+f.metadata[e.ID].Type+"\t"+f.contents[e.ID])
+		indexed[e.ID] = true
+	}
 	for fName, fSeq := range f.contents {
+		if indexed[fName] {
+			continue
+		}
 		if strings.Contains(fName, featureName) {
-			containing = append(containing, fName+"\t"+fSeq)
+			containing = append(containing, fName+"\t"+f.metadata[fName].Type+"\t"+fSeq)
 		} else if len(fName) > ldCutoff && ld(featureName, fName, true) <= ldCutoff {
-			lowDistance = append(lowDistance, fName+"\t"+fSeq)
+			lowDistance = append(lowDistance, fName+"\t"+f.metadata[fName].Type+"\t"+fSeq)
 		}
 	}
 
@@ -563,19 +729,19 @@ func ListFeatures(featureName string) {
 		if _, err := w.Write([]byte("\n")); err != nil {
 			rlog.Fatal(err)
 		}
+		meta := f.metadata[featureName]
+		for _, field := range [][]string{{"type", meta.Type}, {"organism", meta.Organism}, {"reference", meta.Reference}, {"notes", meta.Notes}} {
+			if field[1] != "" {
+				fmt.Fprintf(w, "%s:\t%s\n", field[0], field[1])
+			}
+		}
 		w.Flush()
 		return
 	}
 
-	// from https://golang.org/pkg/text/tabwriter/
-	if len(containing) < 3 {
-		lowDistance = append(lowDistance, containing...)
-		containing = []string{} // clear
-	}
-	if len(containing) > 0 {
-		fmt.Fprint(w, strings.Join(containing, "\n"))
-	} else if len(lowDistance) > 0 {
-		fmt.Fprint(w, strings.Join(lowDistance, "\n"))
+	// the names containing the query, then those a few edits from it
+	if similar := append(containing, lowDistance...); len(similar) > 0 {
+		fmt.Fprint(w, strings.Join(similar, "\n"))
 	} else {
 		if _, err := fmt.Fprintf(w, "failed to find any features for %s", featureName); err != nil {
 			rlog.Fatal(err)
@@ -584,11 +750,21 @@ func ListFeatures(featureName string) {
 	w.Flush()
 }
 
-// AddFeatures - add the feature's seq in the database (or create if it isn't in the feature db)
-func AddFeatures(name, seq string) {
+// AddFeatures - add the feature's seq in the database (or create if it isn't in the feature db),
+// with its type, organism, reference and notes if there are any
+func AddFeatures(name, seq string, meta FeatureMeta) {
 	f := NewFeatureDB()
 
+	meta, err := meta.normalize()
+	if err != nil {
+		rlog.Fatal(err)
+	}
 	f.contents[name] = seq
+	if meta.isEmpty() {
+		delete(f.metadata, name)
+	} else {
+		f.metadata[name] = meta
+	}
 	if err := f.save(); err != nil {
 		rlog.Fatal(err)
 	}
@@ -603,6 +779,7 @@ func DeleteFeature(name string) {
 	}
 
 	delete(f.contents, name)
+	delete(f.metadata, name)
 	if err := f.save(); err != nil {
 		rlog.Fatal(err)
 	}
@@ -1,6 +1,9 @@
 package repp
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -58,11 +61,12 @@ func Test_queryFeatures(t *testing.T) {
 				tt.args.GetBackboneName(),
 				enzymes,
 				dbs,
+				false,
 			)
 			if err != nil {
 				t.Fail()
 			}
-			if got, _ := queryFeatures(tt.args.GetIn(), backbone, dbs); !reflect.DeepEqual(got, tt.want) {
+			if got, _ := queryFeatures(tt.args.GetIn(), backbone, dbs, false); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("queryFeatures() = %v, want %v", got, tt.want)
 			}
 		})
@@ -99,6 +103,7 @@ func Test_blastFeatures(t *testing.T) {
 			}
 			got := blastFeatures(
 				tt.args.flags.GetFilters(),
+				nil,
 				tt.args.flags.GetIdentity(),
 				tt.args.flags.GetUngapped(),
 				dbs,
@@ -128,3 +133,83 @@ func Test_blastFeatures(t *testing.T) {
 		})
 	}
 }
+
+func Test_readFeaturesCSV(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name     string
+		contents string
+		want     []featureInput
+		wantErr  bool
+	}{
+		{
+			"names and orientations",
+			"Name,Orientation\np10 promoter,fwd\nmEGFP,rev\nT7 terminator,\n",
+			[]featureInput{{name: "p10 promoter", fwd: true}, {name: "mEGFP"}, {name: "T7 terminator", fwd: true}},
+			false,
+		},
+		{
+			"sequences",
+			"orientation,name,sequence\n-,insert,atgcaa\n",
+			[]featureInput{{name: "insert", seq: "atgcaa"}},
+			false,
+		},
+		{
+			"unknown orientation",
+			"name,orientation\nmEGFP,backwards\n",
+			nil,
+			true,
+		},
+		{
+			"no name column",
+			"feature,orientation\nmEGFP,rev\n",
+			nil,
+			true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readFeaturesCSV(write(fmt.Sprintf("features-%d.csv", i), tt.contents))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readFeaturesCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readFeaturesCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolveFeature(t *testing.T) {
+	featureDB := &kv{contents: map[string]string{"lacO": "AATTGTGAGCGGATAACAATT"}}
+
+	tests := []struct {
+		name string
+		in   featureInput
+		want []string
+	}{
+		{"from the features database", featureInput{name: "lacO", fwd: true}, []string{"lacO", "AATTGTGAGCGGATAACAATT"}},
+		{"reversed", featureInput{name: "lacO"}, []string{"lacO:REV", "AATTGTTATCCGCTCACAATT"}},
+		{"with a sequence", featureInput{name: "insert", seq: "atg caa", fwd: true}, []string{"insert", "ATGCAA"}},
+		{"reversed with a sequence", featureInput{name: "insert", seq: "atgcaa"}, []string{"insert:REV", "TTGCAT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveFeature(tt.in, featureDB, nil, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveFeature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package repp
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// FeatureSource is how a solution makes one of the features of a features mode target
+type FeatureSource struct {
+	// Feature is the name of the feature
+	Feature string `json:"feature"`
+
+	// Type is the type of the fragment the feature is in: plasmid, pcr or synthetic
+	Type string `json:"type"`
+
+	// Template is the database entry the feature is PCR'ed from, or the plasmid it's in
+	Template string `json:"template,omitempty"`
+
+	// Identity is the %-identity of the feature to the template
+	Identity float64 `json:"identity,omitempty"`
+
+	// TemplateStart is where the feature starts on the template (1-based)
+	TemplateStart int `json:"templateStart,omitempty"`
+
+	// TemplateEnd is where the feature ends on the template (1-based)
+	TemplateEnd int `json:"templateEnd,omitempty"`
+
+	// index of the feature in the target
+	index int
+
+	// length of the feature
+	length int
+}
+
+// sourceFeatures records, on the fragments of a filled features mode solution, the features
+// each one makes. A feature is sourced by the PCR fragment or plasmid it's wholly in, or else
+// by the synthetic fragment that makes (some of) it. featureToStart maps the index of each
+// feature to where it starts in the target
+func sourceFeatures(frags []*Frag, feats [][]string, featureToStart map[int]int, featureMatches map[string][]featureMatch) {
+	targetLen := 0
+	for _, feat := range feats {
+		targetLen += len(feat[1])
+	}
+
+	for i, feat := range feats {
+		start, end := featureToStart[i], featureToStart[i]+len(feat[1])-1
+		source := featureSourceFrag(frags, start, end, targetLen)
+		if source == nil {
+			continue
+		}
+
+		fs := FeatureSource{Feature: feat[0], Type: source.fragType.String(), index: i, length: len(feat[1])}
+		if source.fragType != synthetic {
+			fs.Template = source.ID
+			if m, ok := bestFeatureMatch(featureMatches[source.ID], i); ok {
+				fs.Identity = math.Round(10000*float64(len(feat[1])-m.mismatching)/float64(len(feat[1]))) / 100
+				fs.TemplateStart = m.subjectStart + 1
+				fs.TemplateEnd = m.subjectStart + len(strings.ReplaceAll(m.seq, "-", ""))
+			}
+		}
+		source.featureSources = append(source.featureSources, fs)
+	}
+}
+
+// featureSourceFrag returns the fragment that makes the feature in [start, end] of the target:
+// a PCR fragment or plasmid it's wholly in, or else a synthetic fragment that overlaps it
+func featureSourceFrag(frags []*Frag, start, end, targetLen int) *Frag {
+	if len(frags) == 1 && frags[0].fragType == circular {
+		return frags[0] // a plasmid with all the features
+	}
+
+	var synth *Frag
+	for _, f := range frags {
+		for _, offset := range []int{-targetLen, 0, targetLen} {
+			fStart, fEnd := f.start+offset, f.end+offset
+			if f.fragType != synthetic && fStart <= start && end <= fEnd {
+				return f
+			}
+			if f.fragType == synthetic && synth == nil && fStart <= end && start <= fEnd {
+				synth = f
+			}
+		}
+	}
+	return synth
+}
+
+// bestFeatureMatch returns the match of a feature in a template with the fewest mismatches
+func bestFeatureMatch(matches []featureMatch, featureIndex int) (best match, ok bool) {
+	for _, fm := range matches {
+		if fm.featureIndex != featureIndex {
+			continue
+		}
+		if !ok || fm.match.mismatching < best.mismatching {
+			best, ok = fm.match, true
+		}
+	}
+	return best, ok
+}
+
+// solutionFeatures returns the features made by a solution's fragments, in the target's
+// order, and the fraction of their bp that come from templates rather than synthesis
+func solutionFeatures(frags []*Frag) (features []FeatureSource, coverage *float64) {
+	for _, f := range frags {
+		features = append(features, f.featureSources...)
+	}
+	if len(features) == 0 {
+		return nil, nil
+	}
+	sort.Slice(features, func(i, j int) bool {
+		return features[i].index < features[j].index
+	})
+
+	total, fromTemplates := 0, 0
+	for _, fs := range features {
+		total += fs.length
+		if fs.Type != synthetic.String() {
+			fromTemplates += fs.length
+		}
+	}
+	fraction := math.Round(100*float64(fromTemplates)/float64(total)) / 100
+	return features, &fraction
+}
@@ -0,0 +1,51 @@
+package repp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_sourceFeatures(t *testing.T) {
+	feats := [][]string{
+		{"p10 promoter", randomSeq(100, 1)},
+		{"mEGFP", randomSeq(700, 2)},
+		{"T7 terminator", randomSeq(50, 3)},
+	}
+	featureToStart := map[int]int{0: 0, 1: 100, 2: 800}
+	featureMatches := map[string][]featureMatch{
+		"pGFP": {
+			{featureIndex: 0, match: match{entry: "pGFP", subjectStart: 1000, seq: feats[0][1], mismatching: 2}},
+			{featureIndex: 0, match: match{entry: "pGFP", subjectStart: 3000, seq: feats[0][1], mismatching: 5}},
+			{featureIndex: 1, match: match{entry: "pGFP", subjectStart: 1100, seq: feats[1][1]}},
+		},
+	}
+
+	pcrFrag := &Frag{ID: "pGFP", fragType: pcr, start: 0, end: 820}
+	synthFrag := &Frag{ID: "synth", fragType: synthetic, start: 780, end: 870}
+	sourceFeatures([]*Frag{synthFrag, pcrFrag}, feats, featureToStart, featureMatches)
+
+	features, coverage := solutionFeatures([]*Frag{synthFrag, pcrFrag})
+	want := []FeatureSource{
+		{Feature: "p10 promoter", Type: "pcr", Template: "pGFP", Identity: 98, TemplateStart: 1001, TemplateEnd: 1100, index: 0, length: 100},
+		{Feature: "mEGFP", Type: "pcr", Template: "pGFP", Identity: 100, TemplateStart: 1101, TemplateEnd: 1800, index: 1, length: 700},
+		{Feature: "T7 terminator", Type: "synthetic", index: 2, length: 50},
+	}
+	if !reflect.DeepEqual(features, want) {
+		t.Errorf("solutionFeatures() = %+v, want %+v", features, want)
+	}
+	if coverage == nil || *coverage != 0.94 {
+		t.Errorf("solutionFeatures() coverage = %v, want 0.94", coverage)
+	}
+
+	// a plasmid with all the features
+	plasmid := &Frag{ID: "pGFP", fragType: circular}
+	sourceFeatures([]*Frag{plasmid}, feats, featureToStart, featureMatches)
+	if features, coverage := solutionFeatures([]*Frag{plasmid}); len(features) != 3 || features[2].Type != "plasmid" || *coverage != 1 {
+		t.Errorf("solutionFeatures() of a plasmid = %+v, %v, want all the features in it", features, *coverage)
+	}
+
+	// not a features mode solution
+	if features, coverage := solutionFeatures([]*Frag{{ID: "pGFP", fragType: pcr}}); features != nil || coverage != nil {
+		t.Errorf("solutionFeatures() = %+v, %v, want no features", features, coverage)
+	}
+}
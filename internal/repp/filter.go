@@ -0,0 +1,309 @@
+package repp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// filterCandidate is a database entry that a filter expression is evaluated against
+type filterCandidate struct {
+	// entry is the ID of the entry
+	entry string
+
+	// title is the rest of the entry's FASTA header
+	title string
+
+	// db is the name of the entry's database
+	db string
+
+	// length of the entry's sequence, 0 if unknown
+	length int
+
+	// circular is whether the entry is circular
+	circular bool
+}
+
+// yearRegex matches a year in an entry's title
+var yearRegex = regexp.MustCompile(`\b(19|20)\d\d\b`)
+
+// year returns the first year in the candidate's title, 0 if there's none
+func (c filterCandidate) year() int {
+	year, _ := strconv.Atoi(yearRegex.FindString(c.title))
+	return year
+}
+
+// filterExpr is a parsed filter expression. Database entries it's false for are
+// excluded from the matches.
+type filterExpr interface {
+	eval(c filterCandidate) bool
+}
+
+// andExpr is true if both its operands are
+type andExpr struct{ left, right filterExpr }
+
+func (e andExpr) eval(c filterCandidate) bool { return e.left.eval(c) && e.right.eval(c) }
+
+// orExpr is true if either of its operands is
+type orExpr struct{ left, right filterExpr }
+
+func (e orExpr) eval(c filterCandidate) bool { return e.left.eval(c) || e.right.eval(c) }
+
+// notExpr is true if its operand isn't
+type notExpr struct{ operand filterExpr }
+
+func (e notExpr) eval(c filterCandidate) bool { return !e.operand.eval(c) }
+
+// filterFields are the fields of a candidate that can be compared, and the operators each supports
+var filterFields = map[string][]string{
+	"title":    {"=", "!=", "~", "!~"},
+	"entry":    {"=", "!=", "~", "!~"},
+	"db":       {"=", "!=", "~", "!~"},
+	"length":   {"=", "!=", ">", ">=", "<", "<="},
+	"year":     {"=", "!=", ">", ">=", "<", "<="},
+	"circular": {"=", "!="},
+}
+
+// compareExpr compares a field of a candidate to a value. String comparisons ignore
+// case and ~ is "contains". Comparisons of a year are false if the title has none.
+type compareExpr struct {
+	field, op, value string
+
+	// number is the value of a numeric comparison
+	number int
+}
+
+func (e compareExpr) eval(c filterCandidate) bool {
+	switch e.field {
+	case "title":
+		return compareStrings(c.title, e.op, e.value)
+	case "entry":
+		return compareStrings(c.entry, e.op, e.value)
+	case "db":
+		return compareStrings(c.db, e.op, e.value)
+	case "length":
+		return compareNumbers(c.length, e.op, e.number)
+	case "year":
+		year := c.year()
+		return year > 0 && compareNumbers(year, e.op, e.number)
+	case "circular":
+		return (c.circular == (e.number == 1)) == (e.op == "=")
+	}
+	return false
+}
+
+// compareStrings compares two strings, ignoring case
+func compareStrings(s, op, value string) bool {
+	s, value = strings.ToUpper(s), strings.ToUpper(value)
+	switch op {
+	case "=":
+		return s == value
+	case "!=":
+		return s != value
+	case "~":
+		return strings.Contains(s, value)
+	case "!~":
+		return !strings.Contains(s, value)
+	}
+	return false
+}
+
+// compareNumbers compares two ints
+func compareNumbers(n int, op string, value int) bool {
+	switch op {
+	case "=":
+		return n == value
+	case "!=":
+		return n != value
+	case ">":
+		return n > value
+	case ">=":
+		return n >= value
+	case "<":
+		return n < value
+	case "<=":
+		return n <= value
+	}
+	return false
+}
+
+// filterToken is a lexed token of a filter expression
+type filterToken struct {
+	text string
+
+	// quoted is whether the token was a quoted string, never a keyword or operator
+	quoted bool
+}
+
+// lexFilter splits a filter expression into parentheses, operators, and words or quoted strings
+func lexFilter(expr string) (tokens []filterToken, err error) {
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, filterToken{text: string(c)})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i+1)
+			}
+			tokens = append(tokens, filterToken{text: expr[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		case strings.ContainsRune("=!~<>", rune(c)):
+			op := string(c)
+			if i+1 < len(expr) && strings.ContainsRune("=~", rune(expr[i+1])) && (c == '!' || c == '<' || c == '>') {
+				op += string(expr[i+1])
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unknown operator at %d, use NOT", i+1)
+			}
+			tokens = append(tokens, filterToken{text: op})
+			i += len(op)
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t()'\"=!~<>", rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, filterToken{text: expr[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser is a recursive descent parser of filter expressions:
+//
+//	expr       = and { "OR" and }
+//	and        = not { "AND" not }
+//	not        = "NOT" not | "(" expr ")" | comparison
+//	comparison = field operator value
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// parseFilter parses a filter expression, ex: "db!=igem AND length>3000 AND NOT title~'mutant'".
+// It returns nil if the expression is empty.
+func parseFilter(expr string) (filterExpr, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filter %q: %v", expr, err)
+	}
+	p := &filterParser{tokens: tokens}
+	parsed, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filter %q: %v", expr, err)
+	}
+	return parsed, nil
+}
+
+// keyword returns whether the next token is the keyword, and consumes it if it is
+func (p *filterParser) keyword(keyword string) bool {
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// next returns and consumes the next token
+func (p *filterParser) next() (filterToken, error) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, fmt.Errorf("unexpected end of the expression")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) or() (filterExpr, error) {
+	left, err := p.and()
+	for err == nil && p.keyword("OR") {
+		var right filterExpr
+		if right, err = p.and(); err == nil {
+			left = orExpr{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) and() (filterExpr, error) {
+	left, err := p.not()
+	for err == nil && p.keyword("AND") {
+		var right filterExpr
+		if right, err = p.not(); err == nil {
+			left = andExpr{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) not() (filterExpr, error) {
+	if p.keyword("NOT") {
+		operand, err := p.not()
+		return notExpr{operand}, err
+	}
+	if p.keyword("(") {
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+func (p *filterParser) comparison() (filterExpr, error) {
+	field, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	name := strings.ToLower(field.text)
+	ops, ok := filterFields[name]
+	if !ok || field.quoted {
+		return nil, fmt.Errorf("unknown field %q, valid fields are title, entry, db, length, year and circular", field.text)
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(ops, op.text) || op.quoted {
+		return nil, fmt.Errorf("%s doesn't support the operator %q, only %s", name, op.text, strings.Join(ops, " "))
+	}
+
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	e := compareExpr{field: name, op: op.text, value: value.text}
+	switch name {
+	case "length", "year":
+		if e.number, err = strconv.Atoi(value.text); err != nil {
+			return nil, fmt.Errorf("%s needs a number, not %q", name, value.text)
+		}
+	case "circular":
+		switch strings.ToLower(value.text) {
+		case "true", "yes":
+			e.number = 1
+		case "false", "no":
+		default:
+			return nil, fmt.Errorf("circular needs true or false, not %q", value.text)
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,80 @@
+package repp
+
+import "testing"
+
+func Test_parseFilter(t *testing.T) {
+	pSB1A3 := filterCandidate{entry: "pSB1A3", title: "BBa_pSB1A3 circular 2008", db: "igem", length: 2155, circular: true}
+	addgene := filterCandidate{entry: "addgene-1000", title: "pLKO.1 mutant puro", db: "addgene", length: 7052}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    []bool // for pSB1A3 and addgene
+		wantErr bool
+	}{
+		{"empty", "", []bool{true, true}, false},
+		{"db", "db!=igem", []bool{false, true}, false},
+		{"length", "length>3000", []bool{false, true}, false},
+		{"contains, ignoring case", "title~'MUTANT'", []bool{false, true}, false},
+		{"and not", "db!=igem AND length>3000 AND NOT title~'mutant'", []bool{false, false}, false},
+		{"or", "entry=pSB1A3 OR db=addgene", []bool{true, true}, false},
+		{"and before or", "db=igem OR db=addgene AND length<1000", []bool{true, false}, false},
+		{"parentheses", "(db=igem OR db=addgene) AND length<3000", []bool{true, false}, false},
+		{"year", "year>=2008", []bool{true, false}, false}, // no year in the addgene title
+		{"circular", "circular=true", []bool{true, false}, false},
+		{"quoted value with spaces", `title~"mutant puro"`, []bool{false, true}, false},
+		{"unknown field", "name=pSB1A3", nil, true},
+		{"unsupported operator", "length~30", nil, true},
+		{"not a number", "length>long", nil, true},
+		{"missing value", "db!=", nil, true},
+		{"missing parenthesis", "(db=igem", nil, true},
+		{"trailing tokens", "db=igem addgene", nil, true},
+		{"unterminated string", "title~'mutant", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parseFilter(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for i, c := range []filterCandidate{pSB1A3, addgene} {
+				if got := filter == nil || filter.eval(c); got != tt.want[i] {
+					t.Errorf("parseFilter(%q).eval(%s) = %v, want %v", tt.expr, c.entry, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_screenEntry(t *testing.T) {
+	filter, err := parseFilter("length>3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := DB{Name: "lab"}
+
+	tests := []struct {
+		name   string
+		title  string
+		length int
+		want   bool
+	}{
+		{"linear", "a linear template", 4000, true},
+		{"doubled circular", "a circular plasmid", 4000, false}, // 2kb, doubled in the database
+		{"long circular", "a circular plasmid", 8000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, keep := screenEntry("pTEST", tt.title, db, tt.length, nil, filter); keep != tt.want {
+				t.Errorf("screenEntry(%q, %d) kept = %v, want %v", tt.title, tt.length, keep, tt.want)
+			}
+		})
+	}
+
+	if _, _, keep := screenEntry("pTEST", "a mutant", db, 0, []string{"MUTANT"}, nil); keep {
+		t.Error("screenEntry() kept an entry with an excluded title")
+	}
+}
@@ -15,6 +15,12 @@ var (
 
 	// primerErrs, errors found during prior builds
 	primerErrs = make(map[string]error)
+
+	// primerWarnings, warnings about the fragments of formerly made primers
+	primerWarnings = make(map[string][]string)
+
+	// primerOffTargetTms, the off-target Tms near the max of formerly made primers
+	primerOffTargetTms = make(map[string]float64)
 )
 
 // fragType is the Frag building type to be used in the assembly
@@ -57,11 +63,26 @@ type Frag struct {
 	// primers necessary to create this (if pcr fragment)
 	Primers []Primer `json:"primers,omitempty"`
 
+	// LongRange is whether the PCR product is too long for a standard PCR and needs long-range PCR
+	LongRange bool `json:"longRange,omitempty"`
+
+	// Mismatches are the 1-based positions on the target where the fragment's template
+	// differs from it, ie where PCR introduces sequence edits
+	Mismatches []int `json:"mismatches,omitempty"`
+
+	// MismatchesUnknown is whether the template's alignment had gaps, so where it differs
+	// from the target isn't known and Mismatches is empty
+	MismatchesUnknown bool `json:"mismatchesUnknown,omitempty"`
+
+	// Warnings about the fragment's design that may need a look at the bench, ex: primers that
+	// primer3 picked despite their constraints or an end trimmed to limit its homology
+	Warnings []string `json:"warnings,omitempty"`
+
 	// fragType of this fragment. circular | pcr | synthetic | existing
 	fragType fragType
 
-	// uniqueID of a match, ID + the start index % seq-length
-	// used identified that catches nodes that cross the zero-index
+	// uniqueID of a match, see matchID. Shared by the copies of a match
+	// on both sides of the zero-index
 	uniqueID string
 
 	// fullSeq is the entire seq of the Frag/fragment as it was read in (for forward engineering)
@@ -82,6 +103,13 @@ type Frag struct {
 	// match ratio
 	matchRatio float64
 
+	// mismatchIndexes are the indexes on the target (not wrapped around its length) where the
+	// template differs from it. Unknown, and empty, if the template's alignment had gaps
+	mismatchIndexes []int
+
+	// gapped is whether the template's alignment had gaps
+	gapped bool
+
 	// start of the frag's first feature
 	featureStart int
 
@@ -97,6 +125,39 @@ type Frag struct {
 	// template match was on the reverse complement seq
 	revCompTemplateFlag bool
 
+	// linearTopology is whether the sequence was read from a GenBank record with a linear LOCUS topology
+	linearTopology bool
+
+	// circularTopology is whether the sequence was read from a GenBank record with a circular LOCUS topology.
+	// Unlike a circular fragType, it isn't set by a FASTA header that mentions "circular"
+	circularTopology bool
+
+	// indexes of the non-ACGT bases stripped from the sequence when it was read,
+	// relative to the original sequence
+	strippedIndexes []int
+
+	// templateIssues is the number of issues of the PCR template, cached by templatePenalty
+	templateIssues *int
+
+	// synthReason is why a synthetic fragment is synthesized, recorded where the choice is made.
+	// See explainFragment
+	synthReason string
+
+	// offTargetTm is the highest Tm of the primers' off-targets within offTargetTmMargin
+	// of the max, 0 if there are none. See checkOffTargets
+	offTargetTm float64
+
+	// reuseBonus is the estimated discount, to the adjusted cost, of the fragment's primers likely
+	// to be reused from the primer manifests. See setPrimerReuseBonus
+	reuseBonus float64
+
+	// fwdAddition and revAddition are the fixed sequences of the primer-additions setting
+	// added to the 5' ends of the primers, and to PCRSeq, after they were designed
+	fwdAddition, revAddition string
+
+	// featureSources are the features of a features mode target the fragment makes
+	featureSources []FeatureSource
+
 	// build configuration
 	conf *config.Config
 }
@@ -174,12 +235,26 @@ func newFrag(m match, conf *config.Config) *Frag {
 		templateEnd:         m.subjectEnd,
 		revCompTemplateFlag: m.subjectRevCompMatch,
 		matchRatio:          matchRatio,
+		mismatchIndexes:     m.mismatchIndexes(),
+		gapped:              m.gaps > 0,
 		db:                  m.db,
 		conf:                conf,
 		fragType:            fType,
 	}
 }
 
+// setMismatches sets the 1-based positions on the target, within the fragment's
+// range, where the fragment's template differs from the target. They're unknown
+// if the template's alignment had gaps.
+func (f *Frag) setMismatches(targetLength int) {
+	f.Mismatches, f.MismatchesUnknown = nil, f.gapped
+	for _, i := range f.mismatchIndexes {
+		if i >= f.start && i <= f.end {
+			f.Mismatches = append(f.Mismatches, i%targetLength+1)
+		}
+	}
+}
+
 // newFrags is the plural of newFrag
 func newFrags(matches []match, conf *config.Config) []*Frag {
 	min := conf.FragmentsMinHomology
@@ -225,12 +300,26 @@ func (f Frag) getPrimers() (fwd, rev Primer) {
 
 func (f *Frag) getFragSeq() string {
 	if f.PCRSeq != "" {
+		// the primer additions are for downstream workflows, they aren't part of the assembly
+		if n := len(f.fwdAddition) + len(f.revAddition); n > 0 && n <= len(f.PCRSeq) {
+			return strings.ToUpper(f.PCRSeq[len(f.fwdAddition) : len(f.PCRSeq)-len(f.revAddition)])
+		}
 		return strings.ToUpper(f.PCRSeq)
 	} else {
 		return strings.ToUpper(f.Seq)
 	}
 }
 
+// warn adds a warning about the fragment's design, if it doesn't have it already
+func (f *Frag) warn(warning string) {
+	for _, w := range f.Warnings {
+		if w == warning {
+			return
+		}
+	}
+	f.Warnings = append(f.Warnings, warning)
+}
+
 // copy returns a deep dopy of a Frag. used because nodes are mutated
 // during assembly filling, and we don't want primers being shared between
 // nodes in different assemblies
@@ -244,30 +333,55 @@ func (f *Frag) copy() (newFrag *Frag) {
 
 // cost returns the estimated cost of a fragment. Combination of source and preparation
 func (f *Frag) cost(procure bool) (fragCost float64, adjustedFragCost float64) {
+	b := f.costBreakdown(procure)
+	fragCost = b.Procurement
+	adjustedFragCost = b.Procurement
+
+	if f.fragType == pcr {
+		pcrFragCost := b.Primers + b.PCRReactions
+		fragCost += pcrFragCost
+		adjustedFragCost += pcrFragCost
+		if rate := f.conf.PcrLongRangeSuccessRate; f.longRange() && rate > 0 && rate < 1 {
+			// the reactions expected to be repeated after failing
+			adjustedFragCost += b.PCRReactions * (1/rate - 1)
+		}
+		adjustedFragCost += f.templatePenalty()
+	} else if f.fragType == synthetic {
+		fragCost += b.Synthesis
+		adjustedFragCost += b.Synthesis * float64(f.conf.GetSyntheticFragmentFactor())
+	}
+
+	if f.Primers == nil {
+		// once primers are designed, applyPrimerReuse discounts those actually reused
+		adjustedFragCost -= f.reuseBonus
+	}
+
+	return
+}
+
+// costBreakdown returns the estimated cost of a fragment by category
+func (f *Frag) costBreakdown(procure bool) (b CostBreakdown) {
 	if procure {
-		fragCost = f.db.Cost
-		adjustedFragCost = f.db.Cost
+		b.Procurement = f.db.Cost
 	}
 
 	if f.fragType == pcr {
-		var primersCost float64
 		if f.Primers != nil {
 			// cost of primers plus the cost of a single PCR reaction
-			primersCost = float64(len(f.Primers[0].Seq)+len(f.Primers[1].Seq)) * f.conf.PcrBpCost
+			b.Primers = float64(len(f.Primers[0].Seq)+len(f.Primers[1].Seq)) * f.conf.PcrBpCost
 		} else {
 			// estimate the price using a default of 24bp for primers length estimate
-			primersCost = 2 * float64(f.conf.EstimatePCRPrimersLength(24)) * f.conf.PcrBpCost
+			b.Primers = 2 * float64(f.conf.EstimatePCRPrimersLength(24)) * f.conf.PcrBpCost
+		}
+		b.PCRReactions = f.conf.PcrRxnCost
+		if f.longRange() {
+			b.PCRReactions = f.conf.PcrLongRangeRxnCost
 		}
-		pcrFragCost := primersCost + f.conf.PcrRxnCost
-		fragCost += pcrFragCost
-		adjustedFragCost += pcrFragCost
 	} else if f.fragType == synthetic {
-		synthFragCost := f.conf.SynthFragmentCost(len(f.Seq))
-		fragCost += synthFragCost
-		adjustedFragCost += synthFragCost * float64(f.conf.GetSyntheticFragmentFactor())
+		b.Synthesis = f.conf.SynthFragmentCost(len(f.Seq))
 	}
 
-	return
+	return b
 }
 
 // distTo returns the distance between the start of this Frag and the end of the other.
@@ -478,8 +592,8 @@ func (f *Frag) synthTo(next *Frag, target string) (synths []*Frag) {
 		synthSeqLength = f.conf.SyntheticMinLength
 	}
 
-	// add to self to account for sequence across the zero-index (when sequence subselecting)
-	target = strings.ToUpper(target + target + target + target) // TODO remove this
+	// index the target across the zero-index (when sequence subselecting)
+	circ := newCircularSeq(target)
 
 	// slide along the range of sequence to create synthetic fragments
 	// and create one at each point, each w/ jL for the fragment
@@ -488,18 +602,35 @@ func (f *Frag) synthTo(next *Frag, target string) (synths []*Frag) {
 	start := f.end - f.conf.FragmentsMinHomology + tL // start w/ homology, move left
 	for len(synths) < synCount {
 		end := start + synthSeqLength + 1
-		seq := target[start:end]
+		seq := circ.get(start, end)
 
 		// check for a hairpin in the junction and shift this fragment's synthesis
 		// to the right if a hairpin is found
-		for hairpin(seq[len(seq)-f.conf.FragmentsMinHomology:], f.conf) > f.conf.FragmentsMaxHairpinMelt {
+		var warnings []string
+		unshifted := end
+		for hairpin(seq[len(seq)-f.conf.FragmentsMinHomology:], f.conf) > f.conf.JunctionMaxHairpinMelt() {
+			end += f.conf.FragmentsMinHomology / 2
+			seq = circ.get(start, end)
+		}
+		if end > unshifted {
+			warnings = append(warnings, fmt.Sprintf("end junction moved %dbp off a hairpin", end-unshifted))
+		}
+
+		// move the junction out of unstable motifs, ex: poly-A tracts, where it may misanneal
+		unshifted = end
+		for f.conf.SyntheticAvoidUnstable && end-start < f.conf.SyntheticMaxLength &&
+			inUnstableRegion(end-f.conf.FragmentsMinHomology, end, tL) {
 			end += f.conf.FragmentsMinHomology / 2
-			seq = target[start:end]
+			seq = circ.get(start, end)
+		}
+		if end > unshifted {
+			warnings = append(warnings, fmt.Sprintf("end junction moved %dbp out of an unstable motif", end-unshifted))
 		}
 
 		synths = append(synths, &Frag{
 			ID:       fmt.Sprintf("%s-%s-synthesis-%d", f.ID, next.ID, len(synths)+1),
 			Seq:      seq,
+			Warnings: warnings,
 			start:    start,
 			end:      end,
 			fragType: synthetic,
@@ -515,10 +646,12 @@ func (f *Frag) synthTo(next *Frag, target string) (synths []*Frag) {
 // setPrimers creates primers against a Frag and returns an error if:
 //  1. the primers have an unacceptably high primer3 penalty score
 //  2. the primers have off-targets in their source plasmid/fragment
-func (f *Frag) setPrimers(prev, next *Frag, seq string, conf *config.Config) (err error) {
+func (f *Frag) setPrimers(prev, next *Frag, seq string, plan *priorPlan, conf *config.Config) (err error) {
 	pHash := primerHash(prev, f, next)
 	if oldPrimers, contained := madePrimers[pHash]; contained {
 		f.Primers = oldPrimers
+		f.Warnings = append([]string(nil), primerWarnings[pHash]...)
+		f.offTargetTm = primerOffTargetTms[pHash]
 		mutatePrimers(f, seq, 0, 0) // set PCRSeq
 		return nil
 	}
@@ -526,8 +659,24 @@ func (f *Frag) setPrimers(prev, next *Frag, seq string, conf *config.Config) (er
 	if oldErr, contained := primerErrs[pHash]; contained {
 		return oldErr
 	}
+	f.Warnings = nil
+
+	// reuse the primers of a previous plan if they're still valid and have no off-targets
+	if plan != nil && plan.reusePrimers(f, prev, next, seq, conf) {
+		if err = f.checkOffTargets(conf); err == nil {
+			f.fragType = pcr
+			madePrimers[pHash] = f.Primers
+			primerWarnings[pHash] = f.Warnings
+			primerOffTargetTms[pHash] = f.offTargetTm
+			return nil
+		}
+		rlog.Debugf("not reusing the primers of %s from %s: %v", f.ID, plan.name, err)
+		f.Primers = nil
+	}
 
 	psExec := newPrimer3(seq, conf)
+	psExec.parentSeq = f.fullSeq
+	psExec.templateMismatches = f.mismatchIndexes
 	defer psExec.close()
 
 	// make input file and write to the fs
@@ -549,10 +698,40 @@ func (f *Frag) setPrimers(prev, next *Frag, seq string, conf *config.Config) (er
 		return
 	}
 
+	// primers primer3 picked anyway, despite their constraints, have the problems in their notes
+	if !conf.PcrPrimerUseStrictConstraints {
+		for i, primer := range f.Primers {
+			if primer.Notes != "" {
+				f.warn(fmt.Sprintf("primer3 picked the %s primer despite: %s", []string{"forward", "reverse"}[i], primer.Notes))
+			}
+		}
+	}
+
+	// primers are designed against the target, make sure they also anneal to the template
+	if err = psExec.annealToTemplate(f.Primers); err != nil {
+		f.Primers = nil
+		primerErrs[pHash] = err
+		return
+	}
+
+	// try to bring the primers' Tms closer together before rejecting them for it
+	psExec.balanceTms(f.Primers)
+
+	// use stock universal primers where their binding sites allow
+	psExec.anchorUniversalPrimers(f.Primers, f.start, f.end, addLeft, addRight)
+
 	// update Frag's range, and add additional bp to the left and right primer
 	// if it wasn't included in the primer3 output
 	mutatePrimers(f, seq, addLeft, addRight)
 
+	// make sure the fragment isn't too long for PCR, even long-range PCR
+	if maxLength := maxPCRLength(conf); maxLength > 0 && len(f.PCRSeq) > maxLength {
+		err = fmt.Errorf("%s is %dbp, longer than the PCR limit of %dbp", f.ID, len(f.PCRSeq), maxLength)
+		f.Primers = nil
+		primerErrs[pHash] = err
+		return
+	}
+
 	// make sure the fragment's length is still long enough for PCR
 	if len(f.PCRSeq) < conf.PcrMinFragLength {
 		err = fmt.Errorf(
@@ -583,10 +762,10 @@ func (f *Frag) setPrimers(prev, next *Frag, seq string, conf *config.Config) (er
 	// check the Tm difference
 	if conf.PcrMaxFwdRevPrimerTmDiff > 0 && math.Abs(f.Primers[0].Tm-f.Primers[1].Tm) > conf.PcrMaxFwdRevPrimerTmDiff {
 		err = fmt.Errorf(
-			"the difference in Tm of the 2 primers %f - %f is greater than max allowed: %f",
+			"the difference in Tm of the 2 primers %f - %f is greater than max allowed, even after balancing: %f",
 			f.Primers[0].Tm,
 			f.Primers[1].Tm,
-			conf.PcrPrimerMaxPairPenalty,
+			conf.PcrMaxFwdRevPrimerTmDiff,
 		)
 		f.Primers = nil
 		primerErrs[pHash] = err
@@ -594,45 +773,49 @@ func (f *Frag) setPrimers(prev, next *Frag, seq string, conf *config.Config) (er
 	}
 
 	// 2. check for whether either of the primers have an off-target/mismatch
-	var mismatchExists bool
-	var mm match
+	if err = f.checkOffTargets(conf); err != nil {
+		f.Primers = nil
+		primerErrs[pHash] = err
+		return
+	}
+
+	f.fragType = pcr
+
+	madePrimers[pHash] = f.Primers
+	primerWarnings[pHash] = f.Warnings
+	primerOffTargetTms[pHash] = f.offTargetTm
 
+	return
+}
+
+// checkOffTargets returns an error if either of a Frag's primers have an off-target
+// in its source plasmid/fragment
+func (f *Frag) checkOffTargets(conf *config.Config) error {
+	var mismatchResult mismatchResult
 	if f.fullSeq != "" {
 		// we have the full sequence (it was included in the forward design)
-		mismatchResult := seqMismatch(f.Primers, f.ID, f.fullSeq, conf)
-		mismatchExists = mismatchResult.wasMismatch
-		mm = mismatchResult.m
-		err = mismatchResult.err
+		mismatchResult = seqMismatch(f.Primers, f.ID, f.fullSeq, conf)
 	} else if f.db.Path != "" {
 		// otherwise, query the fragment from the DB (try to find it) and then check for mismatches
-		mismatchResult := parentMismatch(f.Primers, f.ID, f.db, conf)
-		mismatchExists = mismatchResult.wasMismatch
-		mm = mismatchResult.m
-		err = mismatchResult.err
+		mismatchResult = parentMismatch(f.Primers, f.ID, f.db, conf)
 	}
 
-	if err != nil {
-		f.Primers = nil
-		primerErrs[pHash] = err
-		return err
+	if mismatchResult.err != nil {
+		return mismatchResult.err
 	}
-	if mismatchExists {
-		err = fmt.Errorf(
+	f.offTargetTm = mismatchResult.nearTm
+	if mismatchResult.nearTm > 0 {
+		f.warn(fmt.Sprintf("a primer has a %.1fC off-target, near the max of %.1fC", mismatchResult.nearTm, conf.PcrPrimerMaxOfftargetTm))
+	}
+	if mismatchResult.wasMismatch {
+		return fmt.Errorf(
 			"found a mismatching sequence %s for primers: %s, %s",
-			mm.seq,
+			mismatchResult.m.seq,
 			f.Primers[0].Seq,
 			f.Primers[1].Seq,
 		)
-		f.Primers = nil
-		primerErrs[pHash] = err
-		return
 	}
-
-	f.fragType = pcr
-
-	madePrimers[pHash] = f.Primers
-
-	return
+	return nil
 }
 
 // mutatePrimers adds additional bp to the sides of a Frag
@@ -643,8 +826,7 @@ func (f *Frag) setPrimers(prev, next *Frag, seq string, conf *config.Config) (er
 //
 // returning Frag for testing
 func mutatePrimers(f *Frag, seq string, addLeft, addRight int) *Frag {
-	sl := len(seq)
-	seq = strings.ToUpper(seq + seq + seq + seq) // TODO
+	target := newCircularSeq(seq)
 
 	// change the Frag's start and end index to match those of the start and end index
 	// of the primers, since the range may have shifted to get better primers
@@ -652,24 +834,24 @@ func mutatePrimers(f *Frag, seq string, addLeft, addRight int) *Frag {
 	f.end = f.Primers[1].Range.end
 
 	// update fragment sequence
-	f.Seq = seq[f.start+sl : f.end+sl+1]
+	f.Seq = target.get(f.start, f.end+1)
 
 	// add bp to the left/FWD primer to match the fragment to the left
 	if addLeft > 0 {
-		oldStart := f.Primers[0].Range.start + sl
-		f.Primers[0].Seq = seq[oldStart-addLeft:oldStart] + f.Primers[0].Seq
+		oldStart := f.Primers[0].Range.start
+		f.Primers[0].Seq = target.get(oldStart-addLeft, oldStart) + f.Primers[0].Seq
 		f.Primers[0].Range.start -= addLeft
 	}
 
 	// add bp to the right/REV primer to match the fragment to the right
 	if addRight > 0 {
-		oldEnd := f.Primers[1].Range.end + sl
-		f.Primers[1].Seq = reverseComplement(seq[oldEnd+1:oldEnd+addRight+1]) + f.Primers[1].Seq
+		oldEnd := f.Primers[1].Range.end
+		f.Primers[1].Seq = reverseComplement(target.get(oldEnd+1, oldEnd+addRight+1)) + f.Primers[1].Seq
 		f.Primers[1].Range.end += addRight
 	}
 
 	// update fragment sequence
-	f.PCRSeq = seq[f.Primers[0].Range.start+sl : f.Primers[1].Range.end+sl+1]
+	f.PCRSeq = target.get(f.Primers[0].Range.start, f.Primers[1].Range.end+1)
 
 	return f
 }
@@ -748,7 +748,7 @@ func Test_setPrimers(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.n.setPrimers(tt.args.last, tt.args.next, tt.args.Seq, c)
+			err := tt.n.setPrimers(tt.args.last, tt.args.next, tt.args.Seq, nil, c)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("setPrimers() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -822,3 +822,43 @@ func Test_fragType_String(t *testing.T) {
 		})
 	}
 }
+
+func Test_Frag_setMismatches(t *testing.T) {
+	tests := []struct {
+		name            string
+		start, end      int
+		mismatchIndexes []int
+		gapped          bool
+		want            []int
+	}{
+		{"no mismatches", 10, 40, nil, false, nil},
+		{"1-based positions", 10, 40, []int{10, 25, 40}, false, []int{11, 26, 41}},
+		{"outside the fragment's range", 20, 40, []int{10, 25, 45}, false, []int{26}},
+		{"wrapped around the target", 90, 120, []int{95, 105}, false, []int{96, 6}},
+		{"gapped alignment", 10, 40, nil, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Frag{start: tt.start, end: tt.end, mismatchIndexes: tt.mismatchIndexes, gapped: tt.gapped}
+			f.setMismatches(100)
+			if !reflect.DeepEqual(f.Mismatches, tt.want) {
+				t.Errorf("Frag.setMismatches() = %v, want %v", f.Mismatches, tt.want)
+			}
+			if f.MismatchesUnknown != tt.gapped {
+				t.Errorf("Frag.setMismatches() unknown = %v, want %v", f.MismatchesUnknown, tt.gapped)
+			}
+		})
+	}
+}
+
+func Test_Frag_warn(t *testing.T) {
+	f := &Frag{}
+	f.warn("end trimmed by 40bp")
+	f.warn("a primer has a 42.0C off-target, near the max of 45.0C")
+	f.warn("end trimmed by 40bp")
+
+	want := []string{"end trimmed by 40bp", "a primer has a 42.0C off-target, near the max of 45.0C"}
+	if !reflect.DeepEqual(f.Warnings, want) {
+		t.Errorf("Frag.warn() = %v, want %v", f.Warnings, want)
+	}
+}
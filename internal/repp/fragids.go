@@ -0,0 +1,99 @@
+package repp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultFragIDTemplate is the naming scheme of the fragments in the strategy if none is configured
+const defaultFragIDTemplate = "{base}_{index}_{type}"
+
+// fragIDPlaceholderRegex matches the placeholders of a fragment ID template
+var fragIDPlaceholderRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// fragIDUnsafeRegex matches the characters of a placeholder's value that aren't kept in IDs
+var fragIDUnsafeRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fragIDNamer names the fragments of the strategy from a template, ex: "{project}_{target}_{index}_{type}".
+// The placeholders are {project}, {target}, {base} (the output filename truncated to
+// 10 characters), {solution}, {index} (of the fragment in its solution) and {type}.
+type fragIDNamer struct {
+	// template of the IDs
+	template string
+
+	// fields are the values of the placeholders that are the same for every fragment
+	fields map[string]string
+
+	// taken are the IDs in the synthetic fragment manifest
+	taken map[string]bool
+}
+
+// newFragIDNamer returns a namer of fragment IDs. It fails if the template has an unknown placeholder
+func newFragIDNamer(template, project, target, base string, existingSynthFrags *oligosDB) (*fragIDNamer, error) {
+	if strings.TrimSpace(template) == "" {
+		template = defaultFragIDTemplate
+	}
+	for _, m := range fragIDPlaceholderRegex.FindAllStringSubmatch(template, -1) {
+		switch m[1] {
+		case "project", "target", "base", "solution", "index", "type":
+		default:
+			return nil, fmt.Errorf("unknown placeholder %s in the fragment ID template %q, valid placeholders are "+
+				"{project}, {target}, {base}, {solution}, {index} and {type}", m[0], template)
+		}
+	}
+
+	n := &fragIDNamer{
+		template: template,
+		fields: map[string]string{
+			"project": fragIDUnsafeRegex.ReplaceAllString(project, "-"),
+			"target":  fragIDUnsafeRegex.ReplaceAllString(target, "-"),
+			"base":    base,
+		},
+		taken: make(map[string]bool),
+	}
+	if existingSynthFrags != nil {
+		for _, o := range existingSynthFrags.indexedOligos {
+			n.taken[o.id] = true
+		}
+	}
+	return n, nil
+}
+
+// name returns the ID of the fragment at an index of a solution. IDs that are already used in
+// the solution or are in the synthetic fragment manifest get a numeric suffix to keep them unique
+func (n *fragIDNamer) name(solution, index int, f *Frag, used map[string]bool) string {
+	id := n.format(solution, index, f)
+	unique := n.unique(id, used)
+	if unique != id {
+		rlog.Warnf("fragment ID %s is already used, renamed to %s", id, unique)
+	}
+	return unique
+}
+
+// format returns the ID of the fragment at an index of a solution from the template
+func (n *fragIDNamer) format(solution, index int, f *Frag) string {
+	return fragIDPlaceholderRegex.ReplaceAllStringFunc(n.template, func(placeholder string) string {
+		switch field := placeholder[1 : len(placeholder)-1]; field {
+		case "solution":
+			return strconv.Itoa(solution)
+		case "index":
+			return strconv.Itoa(index)
+		case "type":
+			return fragTypeAsString(f.fragType)
+		default:
+			return n.fields[field]
+		}
+	})
+}
+
+// unique returns the ID with a numeric suffix if it's already used or taken, and marks it used
+func (n *fragIDNamer) unique(id string, used map[string]bool) string {
+	unique := id
+	for i := 2; used[unique] || n.taken[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", id, i)
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,71 @@
+package repp
+
+import (
+	"testing"
+)
+
+func Test_fragIDNamer_name(t *testing.T) {
+	manifest := newOligosDB(synthFragIDPrefix, true)
+	manifest.indexedOligos["ACGT"] = oligo{id: "gfp_1_pcr", seq: "acgt"}
+
+	type args struct {
+		template, project, target string
+		solution, index           int
+		f                         *Frag
+		used                      map[string]bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			"default template",
+			args{"", "", "target", 1, 2, &Frag{fragType: pcr}, map[string]bool{}},
+			"out_2_pcr",
+			false,
+		},
+		{
+			"project and target",
+			args{"{project}_{target}_s{solution}_{index}", "lab 42", "pUC19/GFP", 3, 1, &Frag{fragType: linear}, map[string]bool{}},
+			"lab-42_pUC19-GFP_s3_1",
+			false,
+		},
+		{
+			"unknown placeholder",
+			args{"{plate}_{index}", "", "", 1, 1, &Frag{}, map[string]bool{}},
+			"",
+			true,
+		},
+		{
+			"collides with the manifest",
+			args{"{target}_{index}_{type}", "", "gfp", 1, 1, &Frag{fragType: pcr}, map[string]bool{}},
+			"gfp_1_pcr_2",
+			false,
+		},
+		{
+			"collides within the solution",
+			args{"{target}", "", "gfp", 1, 2, &Frag{fragType: pcr}, map[string]bool{"gfp": true, "gfp_2": true}},
+			"gfp_3",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := newFragIDNamer(tt.args.template, tt.args.project, tt.args.target, "out", manifest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newFragIDNamer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := n.name(tt.args.solution, tt.args.index, tt.args.f, tt.args.used); got != tt.want {
+				t.Errorf("fragIDNamer.name() = %v, want %v", got, tt.want)
+			}
+			if !tt.args.used[tt.want] {
+				t.Errorf("fragIDNamer.name() didn't mark %s as used", tt.want)
+			}
+		})
+	}
+}
@@ -2,19 +2,75 @@ package repp
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/Lattice-Automation/repp/internal/config"
 )
 
-// PrintFragment logs the building fragment with the name passed.
-func PrintFragment(name string, dbNames []string) {
+// maxSimilarFragments is the most entries listed when no fragment has the name looked up
+const maxSimilarFragments = 50
+
+// PrintFragment logs the building fragment with the name passed. It's looked up in the
+// databases' lookup indexes. If no fragment has the name, the entries with IDs or titles
+// containing its words are listed instead.
+func PrintFragment(name string, dbNames []string, strict bool) {
 	dbs, err := getRegisteredDBs(dbNames)
 	if err != nil {
 		rlog.Fatal(err)
 	}
-	frag, err := queryDatabases(name, dbs)
+	sort.Slice(dbs, func(i, j int) bool { return dbs[i].Name < dbs[j].Name })
+
+	var similar []string
+	if _, err := os.Stat(name); err != nil { // not a local sequence file
+		for _, db := range dbs {
+			idx, err := db.lookupIndex()
+			if err != nil {
+				rlog.Warnf("failed to index %s: %v", db.Name, err)
+				continue
+			}
+
+			entry, err := db.resolveEntry(name, strict)
+			if err != nil {
+				rlog.Fatal(err)
+			}
+			if e, ok := idx.entry(entry); ok {
+				seq, err := readEntry(db.Path, e.Offset)
+				if err != nil {
+					rlog.Fatal(err)
+				}
+				if strings.Contains(e.Title, "circular") {
+					seq = seq[:len(seq)/2]
+				}
+				fmt.Printf("%s\t%s\n%s\n", name, db.Name, seq)
+				return
+			}
+
+			for _, e := range idx.search(name) {
+				similar = append(similar, fmt.Sprintf("%s\t%s\t%s", e.ID, db.Name, e.Title))
+			}
+		}
+	}
+
+	if len(similar) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
+		for i, s := range similar {
+			if i == maxSimilarFragments {
+				fmt.Fprintf(w, "... and %d more\n", len(similar)-maxSimilarFragments)
+				break
+			}
+			fmt.Fprintln(w, s)
+		}
+		w.Flush()
+		return
+	}
+
+	frag, err := queryDatabases(name, dbs, strict)
 	if err != nil {
 		rlog.Fatal(err)
 	}
@@ -24,11 +80,12 @@ func PrintFragment(name string, dbNames []string) {
 	fmt.Printf("%s\t%s\n%s\n", name, frag.db.Name, frag.Seq)
 }
 
-// AssembleFragments assembles a list of building fragments in order
+// AssembleFragments assembles a list of building fragments in order, or in the order
+// inferred from their end homology if the assembly params ask for it
 func AssembleFragments(assemblyParams AssemblyParams, conf *config.Config) {
 
-	// read in the constituent fragments
-	frags, err := read(assemblyParams.GetIn(), false, false)
+	// read in the constituent fragments, setting the conf property on each as it's read
+	frags, err := readFragments(assemblyParams.GetIn(), func(f *Frag) { f.conf = conf })
 	if err != nil {
 		rlog.Fatal(err)
 	}
@@ -45,41 +102,134 @@ func AssembleFragments(assemblyParams AssemblyParams, conf *config.Config) {
 		rlog.Fatal(err)
 	}
 	// prepare backbone if needed
-	backboneFrag, backboneMeta, err := prepareBackbone(assemblyParams.GetBackboneName(), enzymes, dbs)
+	backboneFrag, backboneMeta, err := prepareBackbone(assemblyParams.GetBackboneName(), enzymes, dbs, conf.Strict)
 	if err != nil {
 		// error getting the backbone
 		rlog.Fatal(err)
 	}
+	// homology arms have to span the ends left after chew-back
+	backboneFrag = homologyBackbone(backboneFrag, backboneMeta)
 	// add in the backbone if it was provided
 	if backboneFrag.ID != "" {
+		backboneFrag.conf = conf
 		frags = append([]*Frag{backboneFrag}, frags...)
 	}
 
-	// set the conf property on each frag
-	for _, f := range frags {
-		f.conf = conf
+	// fragments that will be synthesized get their homology from synthesis, not PCR
+	markSyntheticFragments(frags, assemblyParams.GetSyntheticFragments())
+
+	if assemblyParams.GetInferOrder() {
+		if frags, err = inferFragmentOrder(frags, conf.FragmentsMinHomology, conf.FragmentsMaxHomology); err != nil {
+			rlog.Fatal(err)
+		}
 	}
 
+	setRunStage(assemblyStage)
 	target, solution := fragments(frags, conf)
 
-	primersDB := readOligos(assemblyParams.GetPrimersDBLocations(), primerIDPrefix, false)
-	synthFragsDB := readOligos(assemblyParams.GetSynthFragsDBLocations(), synthFragIDPrefix, true)
+	primersDB, synthFragsDB, err := readOligoDBs(assemblyParams.GetPrimersDBLocations(), assemblyParams.GetSynthFragsDBLocations(), conf)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+
+	// add the fixed 5' sequences of the primer-additions setting to the designed primers
+	if err := addPrimerAdditions([][]*Frag{solution}, conf); err != nil {
+		rlog.Fatal(err)
+	}
 
 	// write the single list of fragments as a possible solution to the output file
-	if _, err := writeResult(
-		assemblyParams.GetOut(),
-		assemblyParams.GetOutputFormat(),
+	out, err := writeResult(
+		newOutputOptions(assemblyParams),
 		assemblyParams.GetIn(),
 		target.Seq,
 		[][]*Frag{solution},
 		primersDB,
 		synthFragsDB,
 		backboneMeta,
+		nil,
+		dbs,
 		0,
 		conf,
-	); err != nil {
+	)
+	if err != nil {
 		rlog.Fatal(err)
 	}
+
+	// and in chunks, ex: a plate's worth of fragments to prepare at a time
+	if chunkSize := assemblyParams.GetChunkSize(); chunkSize > 0 {
+		if err = writeFragmentChunks(assemblyParams.GetOut(), out, chunkSize); err != nil {
+			rlog.Fatal(err)
+		}
+	}
+}
+
+// readFragments reads the fragments to assemble, calling prepare on each as it's read.
+// FASTA files are streamed an entry at a time, rather than read whole, so a library's
+// hundreds of fragments aren't held in memory more than once.
+func readFragments(path string, prepare func(*Frag)) (frags []*Frag, err error) {
+	err = readEach(path, false, false, func(f *Frag) {
+		prepare(f)
+		frags = append(frags, f)
+	})
+	if err == nil && len(frags) == 0 {
+		err = fmt.Errorf("failed to parse fragment(s) from %s", path)
+	}
+	return frags, err
+}
+
+// fragmentsChunk is a chunk of the fragments of a solution, written to its own file
+type fragmentsChunk struct {
+	// Target is the name of the target the fragments assemble
+	Target string `json:"target"`
+
+	// Solution is the 1-based index of the solution the fragments are from
+	Solution int `json:"solution"`
+
+	// Chunk is the 1-based index of the chunk
+	Chunk int `json:"chunk"`
+
+	// Chunks is the number of chunks the solution's fragments are split into
+	Chunks int `json:"chunks"`
+
+	// Fragments of the chunk, with their primers
+	Fragments []*Frag `json:"fragments"`
+}
+
+// writeFragmentChunks writes the fragments of each of the output's solutions, in chunks of
+// chunkSize, to <filename without extension>.chunk<i>.json. If there are several solutions,
+// each one's chunks are written to <filename without extension>.solution<s>.chunk<i>.json
+func writeFragmentChunks(filename string, out *Output, chunkSize int) error {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	for s, solution := range out.Solutions {
+		solutionBase := base
+		if len(out.Solutions) > 1 {
+			solutionBase = fmt.Sprintf("%s.solution%d", base, s+1)
+		}
+
+		frags := solution.Fragments
+		chunks := (len(frags) + chunkSize - 1) / chunkSize
+		for i := 0; i < chunks; i++ {
+			end := (i + 1) * chunkSize
+			if end > len(frags) {
+				end = len(frags)
+			}
+			contents, err := json.MarshalIndent(fragmentsChunk{
+				Target:    out.Target,
+				Solution:  s + 1,
+				Chunk:     i + 1,
+				Chunks:    chunks,
+				Fragments: frags[i*chunkSize : end],
+			}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to serialize chunk %d of the fragments of solution %d: %v", i+1, s+1, err)
+			}
+			if err = writeFileAtomic(fmt.Sprintf("%s.chunk%d.json", solutionBase, i+1), contents); err != nil {
+				return fmt.Errorf("failed to write chunk %d of the fragments of solution %d: %v", i+1, s+1, err)
+			}
+		}
+		rlog.Infof("wrote %d fragments in %d chunks to %s.chunk*.json", len(frags), chunks, solutionBase)
+	}
+	return nil
 }
 
 // fragments pieces together a list of fragments into a single plasmid
@@ -90,6 +240,9 @@ func fragments(frags []*Frag, conf *config.Config) (target *Frag, solution []*Fr
 		rlog.Fatal("failed: no fragments to assemble")
 	}
 
+	// extend the synthetic fragments to overlap the neighbors they lack homology with
+	extendSyntheticFragments(frags, conf.FragmentsMinHomology, conf.FragmentsMaxHomology, conf.SyntheticMaxLength)
+
 	// anneal the fragments together, shift their junctions and create the plasmid sequence
 	vecSeq := annealFragments(conf.FragmentsMinHomology, conf.FragmentsMaxHomology, frags)
 
@@ -101,7 +254,7 @@ func fragments(frags []*Frag, conf *config.Config) (target *Frag, solution []*Fr
 
 	// create an assembly out of the frags (to fill/convert to fragments with primers)
 	a := assembly{frags: frags}
-	solution, err := a.fill(target.Seq, conf)
+	solution, err := a.fill(target.Seq, nil, conf)
 	if err != nil {
 		rlog.Fatal(err)
 	}
@@ -109,6 +262,179 @@ func fragments(frags []*Frag, conf *config.Config) (target *Frag, solution []*Fr
 	return target, solution
 }
 
+// markSyntheticFragments marks the fragments, by ID, that will be synthesized rather than
+// amplified from a template. It warns about IDs that aren't among the fragments.
+func markSyntheticFragments(frags []*Frag, ids []string) {
+	for _, id := range ids {
+		found := false
+		for _, f := range frags {
+			if f.ID == id || entryID(f.ID) == id {
+				f.fragType = synthetic
+				f.synthReason = "listed with --synthetic"
+				found = true
+			}
+		}
+		if !found {
+			rlog.Warnf("synthetic fragment %s is not among the input fragments", id)
+		}
+	}
+}
+
+// extendSyntheticFragments extends synthetic fragments that lack homology with a neighbor
+// with the neighbor's end, so they overlap it once synthesized and the neighbor doesn't need
+// PCR to create the junction. The junction made is half again the minimum homology length.
+// Where two synthetic fragments meet, the left one is extended.
+func extendSyntheticFragments(frags []*Frag, minHomology, maxHomology, synthMaxLength int) {
+	junctionLength := minHomology + minHomology/2
+	if junctionLength > maxHomology {
+		junctionLength = maxHomology
+	}
+
+	// decide on the extensions against the sequences as they were read
+	n := len(frags)
+	seqs := make([]string, n)
+	for i, f := range frags {
+		seqs[i] = strings.ToUpper(f.Seq)
+	}
+	prefixes, suffixes := make([]string, n), make([]string, n)
+	for i, f := range frags {
+		next := frags[(i+1)%n]
+		if n < 2 || f.fragType != synthetic && next.fragType != synthetic {
+			continue
+		}
+		if len(seqOverlap(seqs[i], seqs[(i+1)%n], minHomology, maxHomology)) >= minHomology {
+			continue // there's already homology between them, not just a few bp shared by chance
+		}
+
+		if f.fragType == synthetic {
+			nextSeq := seqs[(i+1)%n]
+			length := junctionLength
+			if length > len(nextSeq) {
+				length = len(nextSeq)
+			}
+			suffixes[i] = nextSeq[:length]
+		} else {
+			length := junctionLength
+			if length > len(seqs[i]) {
+				length = len(seqs[i])
+			}
+			prefixes[(i+1)%n] = seqs[i][len(seqs[i])-length:]
+		}
+	}
+
+	for i, f := range frags {
+		if prefixes[i] == "" && suffixes[i] == "" {
+			continue
+		}
+
+		f.Seq = prefixes[i] + seqs[i] + suffixes[i]
+		rlog.Infof(
+			"Extended synthetic fragment %s by %dbp on the left and %dbp on the right to overlap its neighbors",
+			f.ID, len(prefixes[i]), len(suffixes[i]),
+		)
+		if synthMaxLength > 0 && len(f.Seq) > synthMaxLength {
+			rlog.Warnf("extended synthetic fragment %s is %dbp, longer than the max synthesis length of %dbp", f.ID, len(f.Seq), synthMaxLength)
+		}
+	}
+}
+
+// inferOrderMaxSteps is the most partial orders inferFragmentOrder extends before giving up.
+// Fragments with shared ends join many ways, and the orders to search grow factorially
+const inferOrderMaxSteps = 100000
+
+// inferFragmentOrder orders and orients the fragments so that the end of each one is
+// homologous with the start of the next, circularly. The first fragment, the backbone if
+// there is one, keeps its place and orientation. Fragments that are only joined by their
+// reverse complement are reverse complemented. It errors if no order joins all the fragments,
+// if more than one does, or if there are too many partial orders to search.
+func inferFragmentOrder(frags []*Frag, minHomology, maxHomology int) ([]*Frag, error) {
+	n := len(frags)
+	if n < 2 {
+		return frags, nil
+	}
+
+	// each fragment in each orientation is a node: 2*i forward and 2*i+1 reverse complemented
+	nodeSeqs := make([]string, 2*n)
+	for i, f := range frags {
+		nodeSeqs[2*i] = strings.ToUpper(f.Seq)
+		nodeSeqs[2*i+1] = reverseComplement(f.Seq)
+	}
+	joined := make([][]bool, 2*n)
+	parallelFor(len(joined), func(a int) {
+		joined[a] = make([]bool, 2*n)
+		for b := range joined[a] {
+			if a/2 != b/2 {
+				joined[a][b] = seqOverlap(nodeSeqs[a], nodeSeqs[b], minHomology, maxHomology) != ""
+			}
+		}
+	})
+
+	// find the circular orders that join every fragment, stopping once it's ambiguous
+	var orders [][]int
+	used := make([]bool, n)
+	path := []int{0}
+	used[0] = true
+	steps := 0
+	var extend func()
+	extend = func() {
+		if len(orders) > 1 || steps >= inferOrderMaxSteps {
+			return
+		}
+		steps++
+		last := path[len(path)-1]
+		if len(path) == n {
+			if joined[last][path[0]] {
+				orders = append(orders, append([]int{}, path...))
+			}
+			return
+		}
+		for next := 0; next < 2*n; next++ {
+			if used[next/2] || !joined[last][next] {
+				continue
+			}
+			used[next/2] = true
+			path = append(path, next)
+			extend()
+			path = path[:len(path)-1]
+			used[next/2] = false
+		}
+	}
+	extend()
+	if len(orders) < 2 && steps >= inferOrderMaxSteps {
+		return nil, fmt.Errorf("failed to infer the fragments' order: their ends join too many ways to search, list them in order without --infer-order")
+	}
+
+	describe := func(order []int) string {
+		ids := make([]string, len(order))
+		for i, node := range order {
+			ids[i] = frags[node/2].ID
+			if node%2 == 1 {
+				ids[i] += " (reverse complement)"
+			}
+		}
+		return strings.Join(ids, ", ")
+	}
+
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("failed to infer the fragments' order: no order joins all %d fragments with %d-%dbp of homology", n, minHomology, maxHomology)
+	}
+	if len(orders) > 1 {
+		return nil, fmt.Errorf("failed to infer the fragments' order, it's ambiguous. Both [%s] and [%s] join them", describe(orders[0]), describe(orders[1]))
+	}
+
+	ordered := make([]*Frag, n)
+	for i, node := range orders[0] {
+		f := frags[node/2]
+		if node%2 == 1 {
+			f.Seq = nodeSeqs[node]
+		}
+		ordered[i] = f
+	}
+	rlog.Infof("Inferred the fragments' order: %s", describe(orders[0]))
+
+	return ordered, nil
+}
+
 // annealFragments shifts the start and end of junctions that overlap one another
 func annealFragments(min, max int, frags []*Frag) (vec string) {
 	// set the start, end, and plasmid sequence
@@ -149,9 +475,13 @@ func annealFragments(min, max int, frags []*Frag) (vec string) {
 }
 
 // validateJunctions checks each fragment and confirms that it has sufficient homology
-// with its adjacent fragments and that the match is exact. Largely for testing
-func validateJunctions(frags []*Frag, conf *config.Config) error {
+// with its adjacent fragments and that the match is exact. Largely for testing.
+// The last fragment only anneals to the first if the target is circular
+func validateJunctions(frags []*Frag, circular bool, conf *config.Config) error {
 	for i, f := range frags {
+		if i == len(frags)-1 && !circular {
+			break
+		}
 		next := frags[(i+1)%len(frags)]
 		j := f.junction(next, conf.FragmentsMinHomology, conf.FragmentsMaxHomology+1)
 		if j == "" {
@@ -1,6 +1,12 @@
 package repp
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -157,3 +163,204 @@ func Test_reverseComplement(t *testing.T) {
 		})
 	}
 }
+
+func Test_inferFragmentOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randSeq := func(n int) string {
+		seq := make([]byte, n)
+		for i := range seq {
+			seq[i] = "ACGT"[r.Intn(4)]
+		}
+		return string(seq)
+	}
+	plasmid := randSeq(600)
+	a, b, c := plasmid[0:220], plasmid[200:420], plasmid[400:]+plasmid[:20]
+	junction := randSeq(20)
+
+	// every fragment but the first joins every other, and nothing joins back to the first
+	shared := []*Frag{{ID: "w", Seq: randSeq(200) + junction}}
+	for i := 0; i < 12; i++ {
+		shared = append(shared, &Frag{ID: fmt.Sprint(i), Seq: junction + randSeq(200) + junction})
+	}
+
+	tests := []struct {
+		name    string
+		frags   []*Frag
+		wantIDs []string
+		wantSeq []string
+		wantErr bool
+	}{
+		{
+			"shuffled fragments",
+			[]*Frag{{ID: "a", Seq: a}, {ID: "c", Seq: c}, {ID: "b", Seq: b}},
+			[]string{"a", "b", "c"},
+			[]string{a, b, c},
+			false,
+		},
+		{
+			"reverse complemented fragment",
+			[]*Frag{{ID: "a", Seq: a}, {ID: "c", Seq: reverseComplement(c)}, {ID: "b", Seq: b}},
+			[]string{"a", "b", "c"},
+			[]string{a, b, c},
+			false,
+		},
+		{
+			"fragment without homology",
+			[]*Frag{{ID: "a", Seq: a}, {ID: "b", Seq: b}, {ID: "d", Seq: randSeq(200)}},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"ambiguous order",
+			[]*Frag{
+				{ID: "x", Seq: junction + randSeq(200) + junction},
+				{ID: "y", Seq: junction + randSeq(200) + junction},
+				{ID: "z", Seq: junction + randSeq(200) + junction},
+			},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"too many orders to search",
+			shared,
+			nil,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inferFragmentOrder(tt.frags, 15, 40)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("inferFragmentOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, f := range got {
+				if f.ID != tt.wantIDs[i] || f.Seq != tt.wantSeq[i] {
+					t.Errorf("inferFragmentOrder() fragment %d = %s, want %s", i, f.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_extendSyntheticFragments(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	randSeq := func(n int) string {
+		seq := make([]byte, n)
+		for i := range seq {
+			seq[i] = "ACGT"[r.Intn(4)]
+		}
+		return string(seq)
+	}
+	plasmid := randSeq(300)
+	a, b, s := plasmid[0:130], plasmid[100:200], plasmid[200:300]
+	s1, s2 := randSeq(100), randSeq(100)
+
+	tests := []struct {
+		name  string
+		frags []*Frag
+		want  []string
+	}{
+		{
+			"synthetic fragment without homology on either side",
+			[]*Frag{{Seq: a}, {Seq: b}, {Seq: s, fragType: synthetic}},
+			[]string{a, b, b[70:] + s + a[:30]},
+		},
+		{
+			"synthetic fragment with homology",
+			[]*Frag{{Seq: a}, {Seq: b + s[:25], fragType: synthetic}, {Seq: s + a[:25]}},
+			[]string{a, b + s[:25], s + a[:25]},
+		},
+		{
+			"adjacent synthetic fragments",
+			[]*Frag{{Seq: s1, fragType: synthetic}, {Seq: s2, fragType: synthetic}},
+			[]string{s1 + s2[:30], s2 + s1[:30]},
+		},
+		{
+			"no synthetic fragments",
+			[]*Frag{{Seq: s1}, {Seq: s2}},
+			[]string{s1, s2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extendSyntheticFragments(tt.frags, 20, 120, 0)
+			for i, f := range tt.frags {
+				if f.Seq != tt.want[i] {
+					t.Errorf("extendSyntheticFragments() fragment %d = %s, want %s", i, f.Seq, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_readFragments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.fa")
+	contents := "\n>frag1\nacgtacgt\nACGT\n>frag2 circular\nTTTTnGGGG\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prepared := 0
+	frags, err := readFragments(path, func(*Frag) { prepared++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frags) != 2 || prepared != 2 {
+		t.Fatalf("readFragments() = %d fragments, %d prepared, want 2", len(frags), prepared)
+	}
+	if f := frags[0]; f.ID != "frag1" || f.Seq != "ACGTACGTACGT" || f.fragType != linear {
+		t.Errorf("readFragments()[0] = %+v", f)
+	}
+	if f := frags[1]; f.ID != "frag2 circular" || f.Seq != "TTTTGGGG" || f.fragType != circular || len(f.strippedIndexes) != 1 {
+		t.Errorf("readFragments()[1] = %+v", f)
+	}
+}
+
+func Test_writeFragmentChunks(t *testing.T) {
+	var frags []*Frag
+	for i := 0; i < 5; i++ {
+		frags = append(frags, &Frag{ID: fmt.Sprintf("frag%d", i+1), Seq: "ACGT"})
+	}
+	filename := filepath.Join(t.TempDir(), "library.output.json")
+	out := &Output{Target: "library", Solutions: []Solution{{Fragments: frags}}}
+	if err := writeFragmentChunks(filename, out, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	base := strings.TrimSuffix(filename, ".json")
+	for i, want := range [][]string{{"frag1", "frag2"}, {"frag3", "frag4"}, {"frag5"}} {
+		contents, err := os.ReadFile(fmt.Sprintf("%s.chunk%d.json", base, i+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var chunk fragmentsChunk
+		if err = json.Unmarshal(contents, &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Chunk != i+1 || chunk.Chunks != 3 || len(chunk.Fragments) != len(want) {
+			t.Fatalf("chunk %d = %+v, want %v", i+1, chunk, want)
+		}
+		for j, id := range want {
+			if chunk.Fragments[j].ID != id {
+				t.Errorf("chunk %d fragment %d = %s, want %s", i+1, j, chunk.Fragments[j].ID, id)
+			}
+		}
+	}
+
+	// each solution's fragments are chunked once there are several
+	out.Solutions = append(out.Solutions, Solution{Fragments: frags[:3]})
+	if err := writeFragmentChunks(filename, out, 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range []string{"solution1.chunk3", "solution2.chunk1", "solution2.chunk2"} {
+		if _, err := os.Stat(fmt.Sprintf("%s.%s.json", base, chunk)); err != nil {
+			t.Errorf("writeFragmentChunks() did not write %s: %v", chunk, err)
+		}
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.solution2.chunk3.json", base)); err == nil {
+		t.Error("writeFragmentChunks() wrote a third chunk of the second solution's 3 fragments")
+	}
+}
@@ -0,0 +1,247 @@
+package repp
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// overhangLength is the length of the overhangs left by Type IIS enzymes like BsaI and BsmBI
+const overhangLength = 4
+
+// maxOverhangRounds is the most passes over the junctions when improving the overhang set
+const maxOverhangRounds = 10
+
+// misligationWeights are the rates, relative to a correct ligation, at which T4 DNA ligase joins
+// overhangs with a single mismatch. Mismatches at the edges of the overhang are tolerated more
+// than those in the middle, and G:T mismatches more than others (Potapov et al., 2018,
+// ACS Synth. Biol.). Indexed by [edge][G:T]
+var misligationWeights = [2][2]float64{
+	{0.005, 0.02}, // in the middle
+	{0.02, 0.1},   // at an edge
+}
+
+// doubleMismatchWeight is the relative rate of ligation of overhangs with two mismatches
+const doubleMismatchWeight = 0.001
+
+// GoldenGate is the set of overhangs to join a solution's fragments by Golden Gate
+// Assembly rather than Gibson Assembly, and its predicted ligation fidelity
+type GoldenGate struct {
+	// Overhangs at the solution's junctions, one per junction
+	Overhangs []Overhang `json:"overhangs"`
+
+	// Fidelity is the predicted fraction, from 0 to 1, of assemblies with only correct ligations
+	Fidelity float64 `json:"fidelity"`
+}
+
+// Overhang is the 4bp overhang at the junction of a fragment and the next one
+type Overhang struct {
+	// Fragment is the fragment (1-based) the junction is at the end of
+	Fragment int `json:"fragment"`
+
+	// Overhang is the sequence of the overhang on the top strand
+	Overhang string `json:"overhang"`
+
+	// Start is where the overhang starts in the fragment (1-based)
+	Start int `json:"start"`
+
+	// Fidelity is the predicted fraction of the overhang's ligations that are correct
+	Fidelity float64 `json:"fidelity"`
+}
+
+// overhangCandidate is an overhang in the homology of a junction
+type overhangCandidate struct {
+	seq   string
+	start int // in the fragment the junction is at the end of
+}
+
+// goldenGateOverhangs assigns an overhang, from the homology of each junction of the fragments,
+// to maximize the fidelity of the whole set. It returns nil if the solution has no junctions
+// or one of them has no usable overhang
+func goldenGateOverhangs(frags []*Frag, conf *config.Config) *GoldenGate {
+	candidates := junctionOverhangs(frags, conf.FragmentsMinHomology, conf.FragmentsMaxHomology+1)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	chosen := selectOverhangs(candidates)
+	seqs := make([]string, len(chosen))
+	for i, c := range chosen {
+		seqs[i] = c.seq
+	}
+	fidelities := overhangFidelities(seqs)
+
+	gg := &GoldenGate{Fidelity: 1}
+	for i, c := range chosen {
+		gg.Overhangs = append(gg.Overhangs, Overhang{
+			Fragment: i + 1,
+			Overhang: c.seq,
+			Start:    c.start + 1,
+			Fidelity: roundFidelity(fidelities[i]),
+		})
+		gg.Fidelity *= fidelities[i]
+	}
+	gg.Fidelity = roundFidelity(gg.Fidelity)
+	return gg
+}
+
+// junctionOverhangs returns the candidate overhangs in the homology of each junction, including
+// the one between the last and first fragments if they circularize. Palindromes, which ligate
+// to themselves, are skipped as are overhangs without G or C, which ligate inefficiently
+func junctionOverhangs(frags []*Frag, minHomology, maxHomology int) (candidates [][]overhangCandidate) {
+	if len(frags) < 2 {
+		return nil
+	}
+
+	for i, f := range frags {
+		next := frags[(i+1)%len(frags)]
+		homology := f.junction(next, minHomology, maxHomology)
+		if homology == "" {
+			if i == len(frags)-1 {
+				break // a linear product
+			}
+			return nil
+		}
+
+		offset := len(f.getFragSeq()) - len(homology)
+		seen := make(map[string]bool)
+		var junctionCandidates []overhangCandidate
+		for j := 0; j+overhangLength <= len(homology); j++ {
+			seq := strings.ToUpper(homology[j : j+overhangLength])
+			if seen[seq] || seq == reverseComplement(seq) || !strings.ContainsAny(seq, "GC") {
+				continue
+			}
+			seen[seq] = true
+			junctionCandidates = append(junctionCandidates, overhangCandidate{seq: seq, start: offset + j})
+		}
+		if len(junctionCandidates) == 0 {
+			return nil
+		}
+		candidates = append(candidates, junctionCandidates)
+	}
+	return candidates
+}
+
+// selectOverhangs picks one candidate per junction. Junctions with the fewest candidates are
+// assigned first, each the candidate that keeps the set's fidelity highest, and then each junction's
+// overhang is swapped for the best of its candidates until the set stops improving
+func selectOverhangs(candidates [][]overhangCandidate) []overhangCandidate {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(candidates[order[i]]) < len(candidates[order[j]])
+	})
+
+	chosen := make([]overhangCandidate, len(candidates))
+	assigned := make([]bool, len(candidates))
+	setSeqs := func() (seqs []string) {
+		for i, c := range chosen {
+			if assigned[i] {
+				seqs = append(seqs, c.seq)
+			}
+		}
+		return seqs
+	}
+
+	best := func(junction int) {
+		bestCandidate, bestFidelity := chosen[junction], -1.0
+		for _, c := range candidates[junction] {
+			chosen[junction] = c
+			if fidelity := setFidelity(setSeqs()); fidelity > bestFidelity {
+				bestCandidate, bestFidelity = c, fidelity
+			}
+		}
+		chosen[junction] = bestCandidate
+	}
+
+	for _, junction := range order {
+		assigned[junction] = true
+		best(junction)
+	}
+
+	fidelity := setFidelity(setSeqs())
+	for round := 0; round < maxOverhangRounds; round++ {
+		for _, junction := range order {
+			best(junction)
+		}
+		improved := setFidelity(setSeqs())
+		if improved <= fidelity {
+			break
+		}
+		fidelity = improved
+	}
+	return chosen
+}
+
+// setFidelity returns the predicted fraction of assemblies in which all the overhangs ligate correctly
+func setFidelity(overhangs []string) float64 {
+	fidelity := 1.0
+	for _, f := range overhangFidelities(overhangs) {
+		fidelity *= f
+	}
+	return fidelity
+}
+
+// overhangFidelities returns the predicted fraction of each overhang's ligations that are to its
+// reverse complement, given every other end, of both strands, in the assembly
+func overhangFidelities(overhangs []string) []float64 {
+	var ends []string
+	for _, o := range overhangs {
+		ends = append(ends, o, reverseComplement(o))
+	}
+
+	fidelities := make([]float64, len(overhangs))
+	for i := range overhangs {
+		misligation := 0.0
+		for _, e := range [][2]int{{2 * i, 2*i + 1}, {2*i + 1, 2 * i}} {
+			end, partner := e[0], e[1] // each strand of the overhang and the one it should ligate to
+			for j, other := range ends {
+				if j != partner {
+					misligation += ligationWeight(ends[end], other)
+				}
+			}
+		}
+		fidelities[i] = 1 / (1 + misligation/2)
+	}
+	return fidelities
+}
+
+// ligationWeight returns the rate, relative to a correct ligation, at which two single
+// stranded overhangs anneal and are ligated
+func ligationWeight(end, other string) float64 {
+	mismatches := 0
+	weight := 1.0
+	for k := 0; k < len(end); k++ {
+		a, b := end[k], other[len(other)-1-k] // antiparallel
+		if reverseComplement(string(b))[0] == a {
+			continue
+		}
+		mismatches++
+		edge, wobble := 0, 0
+		if k == 0 || k == len(end)-1 {
+			edge = 1
+		}
+		if (a == 'G' && b == 'T') || (a == 'T' && b == 'G') {
+			wobble = 1
+		}
+		weight = misligationWeights[edge][wobble]
+	}
+
+	switch mismatches {
+	case 0, 1:
+		return weight
+	case 2:
+		return doubleMismatchWeight
+	default:
+		return 0
+	}
+}
+
+// roundFidelity rounds a fidelity to three decimal places
+func roundFidelity(fidelity float64) float64 {
+	return math.Round(fidelity*1000) / 1000
+}
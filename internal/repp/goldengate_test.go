@@ -0,0 +1,82 @@
+package repp
+
+import (
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_ligationWeight(t *testing.T) {
+	tests := []struct {
+		name       string
+		end, other string
+		want       float64
+	}{
+		{"correct", "GGAG", "CTCC", 1},
+		{"mismatch in the middle", "GGAG", "CTAC", 0.005},
+		{"G:T mismatch at an edge", "GGAG", "CTCT", 0.1},
+		{"two mismatches", "GGAG", "ATCT", doubleMismatchWeight},
+		{"unrelated", "GGAG", "AAAA", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ligationWeight(tt.end, tt.other); got != tt.want {
+				t.Errorf("ligationWeight() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_setFidelity(t *testing.T) {
+	distinct := setFidelity([]string{"GGAG", "TACT", "AATG", "GCTT", "CGCT"})
+	if distinct < 0.9 {
+		t.Errorf("setFidelity() of distinct overhangs = %v, want > 0.9", distinct)
+	}
+	if similar := setFidelity([]string{"GGAG", "GGAT"}); similar >= distinct {
+		t.Errorf("setFidelity() of overhangs one mismatch apart = %v, want < %v", similar, distinct)
+	}
+	if repeated := setFidelity([]string{"GGAG", "GGAG"}); repeated > 0.5 {
+		t.Errorf("setFidelity() of a repeated overhang = %v, want <= 0.5", repeated)
+	}
+}
+
+func Test_goldenGateOverhangs(t *testing.T) {
+	conf := config.New()
+	conf.FragmentsMinHomology = 10
+	conf.FragmentsMaxHomology = 20
+
+	// three fragments of a plasmid, each sharing 12bp of homology with the next
+	seq := randomSeq(300, 1)
+	plasmid := seq + seq[:12]
+	frags := []*Frag{
+		{ID: "a", Seq: plasmid[0:112], fragType: pcr, conf: conf},
+		{ID: "b", Seq: plasmid[100:212], fragType: pcr, conf: conf},
+		{ID: "c", Seq: plasmid[200:312], fragType: pcr, conf: conf},
+	}
+
+	gg := goldenGateOverhangs(frags, conf)
+	if gg == nil || len(gg.Overhangs) != 3 {
+		t.Fatalf("goldenGateOverhangs() = %+v, want 3 overhangs", gg)
+	}
+	seen := make(map[string]bool)
+	for _, o := range gg.Overhangs {
+		f := frags[o.Fragment-1]
+		if got := f.Seq[o.Start-1 : o.Start-1+overhangLength]; got != o.Overhang {
+			t.Errorf("overhang %s is %s in fragment %d", o.Overhang, got, o.Fragment)
+		}
+		if o.Start-1 < len(f.Seq)-12 {
+			t.Errorf("overhang %s starts at %d, outside the junction's homology", o.Overhang, o.Start)
+		}
+		if seen[o.Overhang] || o.Overhang == reverseComplement(o.Overhang) {
+			t.Errorf("overhang %s is repeated or palindromic", o.Overhang)
+		}
+		seen[o.Overhang] = true
+	}
+	if gg.Fidelity <= 0 || gg.Fidelity > 1 {
+		t.Errorf("goldenGateOverhangs() fidelity = %v", gg.Fidelity)
+	}
+
+	if gg := goldenGateOverhangs(frags[:1], conf); gg != nil {
+		t.Errorf("goldenGateOverhangs() of one fragment = %+v, want nil", gg)
+	}
+}
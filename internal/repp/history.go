@@ -0,0 +1,333 @@
+package repp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// HistoryEntry is a design run in the local history: how it was run and what it made, to
+// find previous designs and their parameters later
+type HistoryEntry struct {
+	// Time the run ended
+	Time time.Time `json:"time"`
+
+	// Command that was run, ex: "make sequence"
+	Command string `json:"command"`
+
+	// Args of the command line, without the program's name
+	Args []string `json:"args"`
+
+	// Dir is the working directory of the run
+	Dir string `json:"dir"`
+
+	// Version of repp
+	Version string `json:"version,omitempty"`
+
+	// Inputs are the files the run read, with their SHA-256 when it ran
+	Inputs []HistoryInput `json:"inputs,omitempty"`
+
+	// Output is the path of the output file, empty if the run failed
+	Output string `json:"output,omitempty"`
+
+	// Status of the run: "succeeded" or "failed"
+	Status string `json:"status"`
+
+	// Error of a failed run
+	Error string `json:"error,omitempty"`
+
+	// Target is the ID of the target
+	Target string `json:"target,omitempty"`
+
+	// Solutions is the number of solutions in the output
+	Solutions int `json:"solutions"`
+
+	// Fragments is the number of fragments of the first solution
+	Fragments int `json:"fragments,omitempty"`
+
+	// Cost of the first solution
+	Cost float64 `json:"cost,omitempty"`
+
+	// Currency of the cost, ex: "USD"
+	Currency string `json:"currency,omitempty"`
+
+	// Seconds the run took
+	Seconds float64 `json:"seconds"`
+}
+
+// HistoryInput is a file that a run read
+type HistoryInput struct {
+	// Path of the file, absolute
+	Path string `json:"path"`
+
+	// SHA256 of the file's contents when the run ended
+	SHA256 string `json:"sha256"`
+}
+
+// historyRecorder collects the history entry of the current run
+type historyRecorder struct {
+	// path of the history log the entry is appended to
+	path string
+
+	// start of the run
+	start time.Time
+
+	// inputs of the run, files that don't exist are left out of the entry
+	inputs []string
+
+	// entry of the run so far
+	entry HistoryEntry
+}
+
+// runHistory is the recorder of the current run, nil unless history is on in the config
+var runHistory *historyRecorder
+
+// SetHistory records the run of a command, ex: "make sequence", with its command line
+// arguments and input files, in the local history when it finishes or fails
+func SetHistory(command string, args, inputs []string) {
+	dir, _ := os.Getwd()
+	runHistory = &historyRecorder{
+		path:   config.HistoryLog,
+		start:  time.Now(),
+		inputs: inputs,
+		entry:  HistoryEntry{Command: command, Args: args, Dir: dir, Version: releaseVersion},
+	}
+	setRunEndHook()
+}
+
+// historySucceeded records a run that wrote its output
+func historySucceeded(filename string, out *Output) {
+	if runHistory == nil {
+		return
+	}
+	e := runHistory.entry
+	e.Status = "succeeded"
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	e.Output = filename
+	if out != nil {
+		e.Target = out.Target
+		e.Solutions = len(out.Solutions)
+		e.Currency = out.Currency
+		if len(out.Solutions) > 0 {
+			e.Fragments, e.Cost = out.Solutions[0].Count, out.Solutions[0].Cost
+		}
+	}
+	runHistory.write(e)
+}
+
+// historyFailed records a run that failed with a fatal error
+func historyFailed(message string) {
+	if runHistory == nil {
+		return
+	}
+	e := runHistory.entry
+	e.Status = "failed"
+	e.Error = message
+	runHistory.write(e)
+}
+
+// write appends the entry to the history log. Failures are logged, they don't fail the run
+func (h *historyRecorder) write(e HistoryEntry) {
+	e.Time = time.Now().UTC()
+	e.Seconds = roundSeconds(time.Since(h.start).Seconds())
+	e.Inputs = historyInputs(h.inputs)
+	line, err := json.Marshal(e)
+	if err != nil {
+		rlog.Warnf("failed to encode the history entry: %v", err)
+		return
+	}
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		rlog.Warnf("failed to open the history %s: %v", h.path, err)
+		return
+	}
+	defer f.Close()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		rlog.Warnf("failed to write the history %s: %v", h.path, err)
+	}
+}
+
+// historyInputs returns the absolute paths and checksums of the inputs that are files.
+// Others, ex: a backbone's database entry, are left out
+func historyInputs(paths []string) (inputs []HistoryInput) {
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			rlog.Warnf("failed to hash %s for the history: %v", path, err)
+			continue
+		}
+		inputs = append(inputs, HistoryInput{Path: path, SHA256: checksum})
+	}
+	return inputs
+}
+
+// readHistory reads the entries of a history log, oldest first. Unparseable lines, ex: of a
+// run that was killed while writing, are skipped
+func readHistory(path string) (entries []HistoryEntry, err error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(contents), "\n") {
+		var e HistoryEntry
+		if strings.TrimSpace(line) == "" || json.Unmarshal([]byte(line), &e) != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// openHistory reads the local history, failing if there's none
+func openHistory() []HistoryEntry {
+	entries, err := readHistory(config.HistoryLog)
+	if os.IsNotExist(err) || (err == nil && len(entries) == 0) {
+		rlog.Fatalf("no runs in %s, set history: true in the config to record them", config.HistoryLog)
+	} else if err != nil {
+		rlog.Fatal(err)
+	}
+	return entries
+}
+
+// ListHistory prints the most recent runs in the local history, newest first. All of them
+// if limit isn't positive. With asJSON the entries themselves are printed, to export them.
+func ListHistory(limit int, asJSON bool) {
+	entries := openHistory()
+	if asJSON {
+		contents, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			rlog.Fatal(err)
+		}
+		fmt.Println(string(contents))
+		return
+	}
+	if err := printHistory(os.Stdout, entries, limit); err != nil {
+		rlog.Fatal(err)
+	}
+}
+
+// ShowHistory prints a run of the local history by its ID, the latest if id is 0
+func ShowHistory(id int) {
+	entries := openHistory()
+	if id == 0 {
+		id = len(entries)
+	}
+	if id < 1 || id > len(entries) {
+		rlog.Fatalf("no run %d in the history, IDs are 1 to %d", id, len(entries))
+	}
+	if err := printHistoryEntry(os.Stdout, id, entries[id-1]); err != nil {
+		rlog.Fatal(err)
+	}
+}
+
+// printHistory writes a line per run, newest first. Runs are identified by their 1-based
+// index in the history, oldest first, so IDs don't change as runs are added
+func printHistory(w io.Writer, entries []HistoryEntry, limit int) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "id\ttime\tcommand\tstatus\ttarget\tresult\toutput")
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(entries)-i <= limit); i-- {
+		e := entries[i]
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i+1,
+			e.Time.Local().Format("2006-01-02 15:04"),
+			e.Command,
+			e.Status,
+			e.Target,
+			historyResult(e),
+			e.Output,
+		)
+	}
+	return tw.Flush()
+}
+
+// printHistoryEntry writes everything recorded about a run. Inputs that changed or were
+// removed since the run are flagged
+func printHistoryEntry(w io.Writer, id int, e HistoryEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "id\t%d\n", id)
+	fmt.Fprintf(tw, "time\t%s\n", e.Time.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(tw, "command\trepp %s\n", historyCommandLine(e.Args))
+	fmt.Fprintf(tw, "dir\t%s\n", e.Dir)
+	if e.Version != "" {
+		fmt.Fprintf(tw, "version\t%s\n", e.Version)
+	}
+	for _, input := range e.Inputs {
+		status := "unchanged"
+		if checksum, err := fileChecksum(input.Path); os.IsNotExist(err) {
+			status = "removed"
+		} else if err != nil || checksum != input.SHA256 {
+			status = "changed"
+		}
+		fmt.Fprintf(tw, "input\t%s\tsha256 %.12s\t%s\n", input.Path, input.SHA256, status)
+	}
+	fmt.Fprintf(tw, "status\t%s\n", e.Status)
+	if e.Error != "" {
+		fmt.Fprintf(tw, "error\t%s\n", e.Error)
+	}
+	if e.Target != "" {
+		fmt.Fprintf(tw, "target\t%s\n", e.Target)
+	}
+	if e.Status == "succeeded" {
+		fmt.Fprintf(tw, "result\t%s\n", historyResult(e))
+	}
+	if e.Output != "" {
+		fmt.Fprintf(tw, "output\t%s\n", e.Output)
+	}
+	fmt.Fprintf(tw, "seconds\t%.1f\n", e.Seconds)
+	return tw.Flush()
+}
+
+// historyResult summarizes the first solution of a run, ex: "2 solutions, 3 fragments, 94.10 USD"
+func historyResult(e HistoryEntry) string {
+	if e.Status != "succeeded" {
+		return ""
+	}
+	result := historyCount(e.Solutions, "solution")
+	if e.Solutions > 0 {
+		result += ", " + historyCount(e.Fragments, "fragment")
+		result += ", " + strings.TrimSpace(strconv.FormatFloat(e.Cost, 'f', 2, 64)+" "+e.Currency)
+	}
+	return result
+}
+
+// historyCount formats a count of a noun, ex: "1 solution" or "3 fragments"
+func historyCount(n int, noun string) string {
+	if n != 1 {
+		noun += "s"
+	}
+	return strconv.Itoa(n) + " " + noun
+}
+
+// historyCommandLine joins the arguments of a run, quoting those with spaces or quotes
+func historyCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,73 @@
+package repp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_historyRecorder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.jsonl")
+	input := filepath.Join(dir, "target.fa")
+	if err := os.WriteFile(input, []byte(">target\nACGT\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runHistory = &historyRecorder{
+		path:   path,
+		start:  time.Now(),
+		inputs: []string{input, "pSB1C3"}, // the backbone is a database entry, not a file
+		entry:  HistoryEntry{Command: "make sequence", Args: []string{"make", "sequence", "-i", input}},
+	}
+	defer func() { runHistory = nil }()
+
+	historyFailed("failed to blast target")
+	historySucceeded(filepath.Join(dir, "target.output.json"), &Output{
+		Target:    "target",
+		Currency:  "USD",
+		Solutions: []Solution{{Count: 3, Cost: 94.1}, {Count: 4}},
+	})
+
+	entries, err := readHistory(path)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("readHistory() = %+v, %v, want 2 entries", entries, err)
+	}
+	if e := entries[0]; e.Status != "failed" || e.Error != "failed to blast target" || e.Output != "" {
+		t.Errorf("failed run entry = %+v", e)
+	}
+	e := entries[1]
+	if e.Status != "succeeded" || e.Solutions != 2 || e.Fragments != 3 || e.Target != "target" {
+		t.Errorf("succeeded run entry = %+v", e)
+	}
+	if len(e.Inputs) != 1 || e.Inputs[0].Path != input || len(e.Inputs[0].SHA256) != 64 {
+		t.Errorf("succeeded run inputs = %+v, want the target's checksum", e.Inputs)
+	}
+
+	var out bytes.Buffer
+	if err = printHistory(&out, entries, 1); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "2 ") {
+		t.Errorf("printHistory() with a limit of 1 = %q, want the header and run 2", out.String())
+	}
+	if !strings.Contains(out.String(), "2 solutions, 3 fragments, 94.10 USD") {
+		t.Errorf("printHistory() is missing the result:\n%s", out.String())
+	}
+
+	// inputs edited since the run are flagged
+	if err = os.WriteFile(input, []byte(">target\nACGTA\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err = printHistoryEntry(&out, 2, e); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"repp make sequence -i " + input, "changed", "target.output.json"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("printHistoryEntry() is missing %q:\n%s", want, out.String())
+		}
+	}
+}
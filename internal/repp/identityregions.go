@@ -0,0 +1,178 @@
+package repp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// identityRegion is a region of the target whose templates have to match it with a higher
+// %-identity than the rest, ex: 100% over a CDS where a PCR-introduced mismatch changes the protein
+type identityRegion struct {
+	// name of the region, its range if it's unnamed
+	name string
+
+	// start of the region on the target (0-indexed)
+	start int
+
+	// end of the region on the target (exclusive). Past the target's end if it crosses the zero-index
+	end int
+
+	// identity is the min %-identity of a template over the region
+	identity int
+}
+
+// readIdentityRegions reads the regions of a BED-like file: tab or space separated
+// columns of the target's name (ignored), the region's 0-based start, its exclusive end,
+// the min %-identity over it and optionally its name. Empty, '#', 'track' and 'browser'
+// lines are skipped. A region that ends before it starts crosses the zero-index
+func readIdentityRegions(path string, seqLen int) (regions []identityRegion, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || fields[0] == "track" || fields[0] == "browser" {
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d of %s has %d columns, want the target, start, end and %%-identity", line, path, len(fields))
+		}
+		start, startErr := strconv.Atoi(fields[1])
+		end, endErr := strconv.Atoi(fields[2])
+		identity, identityErr := strconv.Atoi(strings.TrimSuffix(fields[3], "%"))
+		if startErr != nil || endErr != nil {
+			return nil, fmt.Errorf("line %d of %s has an invalid range %s-%s", line, path, fields[1], fields[2])
+		}
+		if identityErr != nil || identity < 1 || identity > 100 {
+			return nil, fmt.Errorf("line %d of %s has an invalid %%-identity %s, want 1-100", line, path, fields[3])
+		}
+		if start < 0 || end < 1 || start >= seqLen || end > seqLen || start == end {
+			return nil, fmt.Errorf("line %d of %s: %d-%d is outside the %dbp target", line, path, start, end, seqLen)
+		}
+		name := fmt.Sprintf("%d-%d", start+1, end)
+		if len(fields) > 4 {
+			name = strings.Join(fields[4:], " ")
+		}
+		if end < start {
+			end += seqLen // across the zero-index
+		}
+		regions = append(regions, identityRegion{name: name, start: start, end: end, identity: identity})
+	}
+	return regions, scanner.Err()
+}
+
+// identityOver returns the %-identity of a match over a region, in any copy of it on the
+// doubled target, and whether the match overlaps the region. The identity of gapped
+// matches, whose mismatches can't be placed, is that of the whole match
+func (m match) identityOver(r identityRegion, seqLen int) (identity float64, overlaps bool) {
+	for _, offset := range []int{-seqLen, 0, seqLen, 2 * seqLen} {
+		start, end := r.start+offset, r.end+offset
+		if m.queryEnd < start || m.queryStart >= end {
+			continue
+		}
+		if m.gaps > 0 || len(m.querySeq) != len(m.seq) {
+			return 100 * float64(m.length()-m.mismatching) / float64(m.length()), true
+		}
+		if start < m.queryStart {
+			start = m.queryStart
+		}
+		if end > m.queryEnd+1 {
+			end = m.queryEnd + 1
+		}
+		mismatches := 0
+		for i := start; i < end; i++ {
+			if !strings.EqualFold(m.querySeq[i-m.queryStart:i-m.queryStart+1], m.seq[i-m.queryStart:i-m.queryStart+1]) {
+				mismatches++
+			}
+		}
+		return 100 * float64(end-start-mismatches) / float64(end-start), true
+	}
+	return 100, false
+}
+
+// applyIdentityRegions trims the matches off the regions they match with less than the
+// regions' %-identity. Like with sourcing, matches that can't be trimmed base for base are
+// dropped instead
+func applyIdentityRegions(matches []match, regions []identityRegion, seqLen int) (kept []match) {
+	if len(regions) == 0 {
+		return matches
+	}
+
+	trimmed := 0
+	for _, m := range matches {
+		pieces := []match{m}
+		for _, r := range regions {
+			var next []match
+			for _, p := range pieces {
+				if identity, overlaps := p.identityOver(r, seqLen); overlaps && identity < float64(r.identity) {
+					next = append(next, trimOffRegion(p, sourcingRegion{name: r.name, start: r.start, end: r.end}, seqLen)...)
+					continue
+				}
+				next = append(next, p)
+			}
+			pieces = next
+		}
+		if len(pieces) != 1 || pieces[0].queryStart != m.queryStart || pieces[0].queryEnd != m.queryEnd {
+			trimmed++
+		}
+		kept = append(kept, pieces...)
+	}
+	if trimmed > 0 {
+		rlog.Debugf("trimmed %d matches off the regions they match below the regions' %%-identity", trimmed)
+	}
+	return kept
+}
+
+// belowRegionIdentity returns an error for the first PCR fragment whose template matches a
+// region with less than the region's %-identity, ex: after it was extended past its match
+func belowRegionIdentity(frags []*Frag, regions []identityRegion, seqLen int) error {
+	for _, f := range frags {
+		if f.fragType != pcr && f.fragType != circular {
+			continue
+		}
+		for _, r := range regions {
+			for _, offset := range []int{-seqLen, 0, seqLen, 2 * seqLen} {
+				start, end := r.start+offset, r.end+offset
+				if start < f.start {
+					start = f.start
+				}
+				if end > f.end+1 {
+					end = f.end + 1
+				}
+				if start >= end {
+					continue
+				}
+				mismatches := 0
+				for _, i := range f.mismatchIndexes {
+					if i >= start && i < end {
+						mismatches++
+					}
+				}
+				if identity := 100 * float64(end-start-mismatches) / float64(end-start); identity < float64(r.identity) {
+					return fmt.Errorf("%s matches %s with %.1f%% identity, below its %d%%", f.ID, r.name, identity, r.identity)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// identicalAssemblies returns the filled assemblies whose PCR fragments' templates match
+// each region with at least its %-identity
+func identicalAssemblies(assemblies []*assembly, regions []identityRegion, seqLen int) (kept []*assembly) {
+	for _, a := range assemblies {
+		if err := belowRegionIdentity(a.frags, regions, seqLen); err != nil {
+			rlog.Debugf("Discard %v: %v", a, err)
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
@@ -0,0 +1,71 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_readIdentityRegions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regions.bed")
+	contents := "track name=cds\n# CDSs\ntarget\t10\t40\t100\tGFP\ntarget 90 5 98\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	regions, err := readIdentityRegions(path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []identityRegion{
+		{name: "GFP", start: 10, end: 40, identity: 100},
+		{name: "91-5", start: 90, end: 105, identity: 98},
+	}
+	if len(regions) != len(want) {
+		t.Fatalf("readIdentityRegions() = %+v, want %+v", regions, want)
+	}
+	for i := range want {
+		if regions[i] != want[i] {
+			t.Errorf("readIdentityRegions()[%d] = %+v, want %+v", i, regions[i], want[i])
+		}
+	}
+
+	for _, invalid := range []string{"target 10 40\n", "target 10 40 0\n", "target 10 140 100\n", "target a 40 100\n"} {
+		if err := os.WriteFile(path, []byte(invalid), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readIdentityRegions(path, 100); err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("readIdentityRegions(%q) = %v, want an error on line 1", invalid, err)
+		}
+	}
+}
+
+func Test_applyIdentityRegions(t *testing.T) {
+	seqLen := 100
+	query := strings.Repeat("A", 60)
+	subject := []byte(query)
+	subject[20] = 'C' // a mismatch at 30 of the target, in the CDS
+	m := match{entry: "template", querySeq: query, seq: string(subject), queryStart: 10, queryEnd: 69, subjectStart: 0, subjectEnd: 59, mismatching: 1}
+
+	// the mismatch is outside a region at 95%
+	if kept := applyIdentityRegions([]match{m}, []identityRegion{{name: "promoter", start: 0, end: 40, identity: 95}}, seqLen); len(kept) != 1 || kept[0].queryStart != 10 || kept[0].queryEnd != 69 {
+		t.Errorf("applyIdentityRegions() at 95%% = %+v, want the match untouched", kept)
+	}
+
+	// and the match is trimmed off a CDS at 100%
+	cds := identityRegion{name: "CDS", start: 25, end: 35, identity: 100}
+	kept := applyIdentityRegions([]match{m}, []identityRegion{cds}, seqLen)
+	if len(kept) != 2 || kept[0].queryEnd != 24 || kept[1].queryStart != 35 || kept[0].mismatching+kept[1].mismatching != 0 {
+		t.Errorf("applyIdentityRegions() at 100%% = %+v, want the pieces either side of the CDS", kept)
+	}
+
+	// fragments of the template across the CDS are rejected
+	f := &Frag{ID: "template", fragType: pcr, start: 10, end: 69, mismatchIndexes: m.mismatchIndexes()}
+	if err := belowRegionIdentity([]*Frag{f}, []identityRegion{cds}, seqLen); err == nil || !strings.Contains(err.Error(), "90.0%") {
+		t.Errorf("belowRegionIdentity() = %v, want the fragment below 100%% over the CDS", err)
+	}
+	if err := belowRegionIdentity([]*Frag{f}, []identityRegion{{name: "CDS", start: 25, end: 35, identity: 90}}, seqLen); err != nil {
+		t.Errorf("belowRegionIdentity() at 90%% = %v, want nil", err)
+	}
+}
@@ -0,0 +1,587 @@
+package repp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// lookupIndex is an inverted index over the entries of a database's FASTA file, or the
+// keys of the features and enzymes dbs: from the lower cased tokens of the entries' IDs
+// and titles to the entries. It's saved next to what it indexes and rebuilt when that
+// changes, so looking up entries by name doesn't scan the database or run blastdbcmd.
+//
+// The saved index isn't loaded. Its tokens and IDs are saved as sorted lines that are binary
+// searched in place, and its entries are found by their fixed-width positions, so a lookup
+// reads a few dozen lines of the file however many entries the database has.
+type lookupIndex struct {
+	// name of what's indexed, for errors
+	name string
+
+	// tokens are lines of a token and the ordinals of entries with it, by token
+	tokens indexSection
+
+	// ids are lines of an entry ID, offset and title, by ID. Only the first entry with an ID
+	ids indexSection
+
+	// entries are lines of an entry's ID, offset and title, in the order of the database
+	entries indexSection
+
+	// positions are the zero-padded positions of the entries' lines in the saved index, one per line
+	positions indexSection
+}
+
+// indexSection is a range of a saved index's sorted lines. Each line starts with its key and a tab
+type indexSection struct {
+	r          io.ReaderAt
+	start, end int64
+}
+
+// indexEntry is a single entry in a lookup index
+type indexEntry struct {
+	// ID of the entry in the database
+	ID string
+
+	// Title of the entry, the rest of its FASTA header
+	Title string
+
+	// Offset of the entry's header in the database's FASTA file
+	Offset int64
+}
+
+// indexBuilder collects the entries of a lookup index before it's saved
+type indexBuilder struct {
+	// entries of the database, in the order of its FASTA file
+	entries []indexEntry
+
+	// tokens maps each token to the ordinals of the entries that have it
+	tokens map[string][]int
+}
+
+const (
+	// lookupIndexHeader starts a saved lookup index. It's followed by the offsets of the
+	// index's sections and the end of the last one, see lookupIndex
+	lookupIndexHeader = "repp lookup index v1"
+
+	// offsetWidth is the zero-padded width of the offsets in a saved index's header and positions
+	offsetWidth = 20
+
+	// lookupIndexHeaderLength is the length of a saved index's header line
+	lookupIndexHeaderLength = len(lookupIndexHeader) + 5*(1+offsetWidth) + 1
+
+	// tokenLineOrdinals is the most entries' ordinals on a line of a token. Common tokens
+	// are split across lines so a binary search never reads a long one
+	tokenLineOrdinals = 128
+)
+
+var (
+	// indexEscaper escapes the tabs and newlines that separate the fields and lines of a saved index
+	indexEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`)
+
+	// indexUnescaper undoes indexEscaper
+	indexUnescaper = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n")
+)
+
+// dbIndexPath returns the path to a database's lookup index
+func dbIndexPath(dbPath string) string {
+	return dbPath + ".index"
+}
+
+// tokenize splits a name or title into its lower cased alphanumeric tokens
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// newIndexBuilder returns an empty index builder
+func newIndexBuilder() *indexBuilder {
+	return &indexBuilder{tokens: make(map[string][]int)}
+}
+
+// buildLookupIndex indexes the entries of a FASTA file
+func buildLookupIndex(fastaPath string) (*indexBuilder, error) {
+	f, err := os.Open(fastaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	idx := newIndexBuilder()
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, ">") {
+			header := strings.TrimSpace(line[1:])
+			id := entryID(header)
+			idx.add(indexEntry{
+				ID:     id,
+				Title:  strings.TrimSpace(strings.TrimPrefix(header, id)),
+				Offset: offset,
+			})
+		}
+		offset += int64(len(line))
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return idx, nil
+}
+
+// buildKVIndex indexes the keys of a key-value store, with the features' types as their titles
+func buildKVIndex(kvPath string) (*indexBuilder, error) {
+	dat, err := os.ReadFile(kvPath)
+	if err != nil {
+		return nil, err
+	}
+	contents, metadata, err := parseKV(dat)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(contents))
+	for key := range contents {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	idx := newIndexBuilder()
+	for _, key := range keys {
+		idx.add(indexEntry{ID: key, Title: metadata[key].Type})
+	}
+
+	return idx, nil
+}
+
+// add appends an entry to the index
+func (b *indexBuilder) add(e indexEntry) {
+	i := len(b.entries)
+	b.entries = append(b.entries, e)
+
+	seen := make(map[string]bool)
+	for _, token := range append(tokenize(e.ID), tokenize(e.Title)...) {
+		if !seen[token] {
+			b.tokens[token] = append(b.tokens[token], i)
+			seen[token] = true
+		}
+	}
+}
+
+// encode returns the saved form of the index: its header, then its tokens, IDs, entries and
+// positions sections
+func (b *indexBuilder) encode() []byte {
+	var tokens, ids, entries, positions bytes.Buffer
+
+	sortedTokens := make([]string, 0, len(b.tokens))
+	for token := range b.tokens {
+		sortedTokens = append(sortedTokens, token)
+	}
+	sort.Strings(sortedTokens)
+	for _, token := range sortedTokens {
+		for i, ordinal := range b.tokens[token] {
+			if i%tokenLineOrdinals == 0 {
+				if i > 0 {
+					tokens.WriteByte('\n')
+				}
+				tokens.WriteString(token)
+				tokens.WriteByte('\t')
+			} else {
+				tokens.WriteByte(',')
+			}
+			tokens.WriteString(strconv.Itoa(ordinal))
+		}
+		tokens.WriteByte('\n')
+	}
+
+	firstByID := make(map[string]indexEntry, len(b.entries))
+	for i := len(b.entries) - 1; i >= 0; i-- {
+		firstByID[indexEscaper.Replace(b.entries[i].ID)] = b.entries[i]
+	}
+	sortedIDs := make([]string, 0, len(firstByID))
+	for id := range firstByID {
+		sortedIDs = append(sortedIDs, id)
+	}
+	sort.Strings(sortedIDs)
+	for _, id := range sortedIDs {
+		e := firstByID[id]
+		fmt.Fprintf(&ids, "%s\t%d\t%s\n", id, e.Offset, indexEscaper.Replace(e.Title))
+	}
+
+	tokensStart := int64(lookupIndexHeaderLength)
+	idsStart := tokensStart + int64(tokens.Len())
+	entriesStart := idsStart + int64(ids.Len())
+	for _, e := range b.entries {
+		fmt.Fprintf(&positions, "%0*d\n", offsetWidth, entriesStart+int64(entries.Len()))
+		fmt.Fprintf(&entries, "%s\t%d\t%s\n", indexEscaper.Replace(e.ID), e.Offset, indexEscaper.Replace(e.Title))
+	}
+	positionsStart := entriesStart + int64(entries.Len())
+	end := positionsStart + int64(positions.Len())
+
+	var saved bytes.Buffer
+	saved.Grow(int(end))
+	fmt.Fprintf(&saved, "%s %0*d %0*d %0*d %0*d %0*d\n", lookupIndexHeader, offsetWidth, tokensStart,
+		offsetWidth, idsStart, offsetWidth, entriesStart, offsetWidth, positionsStart, offsetWidth, end)
+	tokens.WriteTo(&saved)
+	ids.WriteTo(&saved)
+	entries.WriteTo(&saved)
+	positions.WriteTo(&saved)
+	return saved.Bytes()
+}
+
+// save writes the index to the filesystem
+func (b *indexBuilder) save(path string) error {
+	return writeFileAtomic(path, b.encode())
+}
+
+// inMemory returns the index to search without saving it
+func (b *indexBuilder) inMemory(name string) (*lookupIndex, error) {
+	return openLookupIndex(bytes.NewReader(b.encode()), name)
+}
+
+// openLookupIndex reads the header of a saved lookup index
+func openLookupIndex(r io.ReaderAt, name string) (*lookupIndex, error) {
+	header := make([]byte, lookupIndexHeaderLength)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read the header of the lookup index of %s: %v", name, err)
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(string(header), lookupIndexHeader))
+	if !strings.HasPrefix(string(header), lookupIndexHeader+" ") || len(fields) != 5 {
+		return nil, fmt.Errorf("the lookup index of %s has an unknown header: %q", name, header)
+	}
+	var offsets [5]int64
+	for i, field := range fields {
+		offset, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("the lookup index of %s has an invalid offset %q in its header", name, field)
+		}
+		offsets[i] = offset
+	}
+
+	return &lookupIndex{
+		name:      name,
+		tokens:    indexSection{r: r, start: offsets[0], end: offsets[1]},
+		ids:       indexSection{r: r, start: offsets[1], end: offsets[2]},
+		entries:   indexSection{r: r, start: offsets[2], end: offsets[3]},
+		positions: indexSection{r: r, start: offsets[3], end: offsets[4]},
+	}, nil
+}
+
+// openLookupIndexFile opens a saved lookup index. The file is kept open to be searched
+// for the rest of the process
+func openLookupIndexFile(indexPath, name string) (*lookupIndex, error) {
+	f, err := os.Open(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := openLookupIndex(f, name)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return idx, nil
+}
+
+// entry returns the entry with an ID
+func (idx *lookupIndex) entry(id string) (indexEntry, bool) {
+	line, found, err := idx.ids.get(indexEscaper.Replace(id))
+	if err != nil {
+		rlog.Warnf("failed to look up %s in the lookup index of %s: %v", id, idx.name, err)
+		return indexEntry{}, false
+	}
+	if !found {
+		return indexEntry{}, false
+	}
+
+	e, err := parseIndexEntry(line)
+	if err != nil {
+		rlog.Warnf("failed to look up %s in the lookup index of %s: %v", id, idx.name, err)
+		return indexEntry{}, false
+	}
+	return e, true
+}
+
+// entryAt returns the entry with an ordinal, from its position
+func (idx *lookupIndex) entryAt(ordinal int) (indexEntry, error) {
+	position := idx.positions.start + int64(ordinal)*(offsetWidth+1)
+	if ordinal < 0 || position >= idx.positions.end {
+		return indexEntry{}, fmt.Errorf("no entry %d", ordinal)
+	}
+	line, _, err := idx.positions.lineAt(position)
+	if err != nil {
+		return indexEntry{}, err
+	}
+	start, err := strconv.ParseInt(line, 10, 64)
+	if err != nil || start < idx.entries.start || start >= idx.entries.end {
+		return indexEntry{}, fmt.Errorf("invalid position of entry %d: %q", ordinal, line)
+	}
+	if line, _, err = idx.entries.lineAt(start); err != nil {
+		return indexEntry{}, err
+	}
+	return parseIndexEntry(line)
+}
+
+// parseIndexEntry parses a line of an entry's ID, offset and title
+func parseIndexEntry(line string) (indexEntry, error) {
+	fields := strings.SplitN(line, "\t", 3)
+	if len(fields) != 3 {
+		return indexEntry{}, fmt.Errorf("invalid entry %q", line)
+	}
+	offset, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return indexEntry{}, fmt.Errorf("invalid offset of entry %q", line)
+	}
+	return indexEntry{
+		ID:     indexUnescaper.Replace(fields[0]),
+		Title:  indexUnescaper.Replace(fields[2]),
+		Offset: offset,
+	}, nil
+}
+
+// search returns the entries with a token starting with each token of the query, in index order
+func (idx *lookupIndex) search(query string) []indexEntry {
+	entries, err := idx.searchEntries(query)
+	if err != nil {
+		rlog.Warnf("failed to search the lookup index of %s for %s: %v", idx.name, query, err)
+	}
+	return entries
+}
+
+// searchEntries returns the entries with a token starting with each token of the query, in index order.
+// Only the entries of the query's token with the fewest are read, by the bytes of their lines, and
+// filtered by the query's other tokens, so the long lines of common tokens aren't read
+func (idx *lookupIndex) searchEntries(query string) (entries []indexEntry, err error) {
+	tokens := tokenize(query)
+	var rarestStart, rarestEnd int64
+	for i, token := range tokens {
+		// the tokens starting with the query's token are the lines from it to the first after the prefix
+		start, err := idx.tokens.find(token)
+		if err != nil {
+			return nil, err
+		}
+		end, err := idx.tokens.find(afterPrefix(token))
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 || end-start < rarestEnd-rarestStart {
+			rarestStart, rarestEnd = start, end
+		}
+	}
+
+	var ordinals []int
+	seen := make(map[int]bool)
+	for pos := rarestStart; pos < rarestEnd; {
+		var line string
+		if line, pos, err = idx.tokens.lineAt(pos); err != nil {
+			return nil, err
+		}
+		key, list, _ := strings.Cut(line, "\t")
+		for _, o := range strings.Split(list, ",") {
+			ordinal, err := strconv.Atoi(o)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entry of token %s: %q", key, o)
+			}
+			if !seen[ordinal] {
+				ordinals = append(ordinals, ordinal)
+				seen[ordinal] = true
+			}
+		}
+	}
+
+	sort.Ints(ordinals)
+	for _, ordinal := range ordinals {
+		e, err := idx.entryAt(ordinal)
+		if err != nil {
+			return nil, err
+		}
+		if e.hasTokens(tokens) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// afterPrefix returns the first string after all those starting with a token. A token's last
+// byte is never 0xff, it isn't in UTF-8
+func afterPrefix(token string) string {
+	return token[:len(token)-1] + string([]byte{token[len(token)-1] + 1})
+}
+
+// hasTokens returns whether each of the tokens starts a token of the entry's ID or title
+func (e indexEntry) hasTokens(tokens []string) bool {
+	entryTokens := append(tokenize(e.ID), tokenize(e.Title)...)
+	for _, token := range tokens {
+		found := false
+		for _, entryToken := range entryTokens {
+			if strings.HasPrefix(entryToken, token) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// get returns the line with a key, without its newline, and whether there's one
+func (s indexSection) get(key string) (line string, found bool, err error) {
+	pos, err := s.find(key)
+	if err != nil || pos >= s.end {
+		return "", false, err
+	}
+	if line, _, err = s.lineAt(pos); err != nil {
+		return "", false, err
+	}
+	lineKey, _, _ := strings.Cut(line, "\t")
+	return line, lineKey == key, nil
+}
+
+// find returns the start of the first line with a key at or after key, by binary search,
+// or the section's end if there's none
+func (s indexSection) find(key string) (int64, error) {
+	// lines starting before lo have lesser keys, those starting at or after hi don't
+	lo, hi := s.start, s.end
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, err := s.lineStartFrom(mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi {
+			// no line starts in [mid, hi)
+			hi = mid
+			continue
+		}
+
+		line, next, err := s.lineAt(start)
+		if err != nil {
+			return 0, err
+		}
+		if lineKey, _, _ := strings.Cut(line, "\t"); lineKey < key {
+			lo = next
+		} else {
+			hi = start
+		}
+	}
+	return lo, nil
+}
+
+// lineStartFrom returns the start of the first line that starts at or after pos
+func (s indexSection) lineStartFrom(pos int64) (int64, error) {
+	if pos <= s.start {
+		return s.start, nil
+	}
+	_, next, err := s.lineAt(pos - 1) // the rest of the line with the byte before pos
+	return next, err
+}
+
+// lineAt returns the line, or rest of the line, from pos without its newline, and the start of the next line
+func (s indexSection) lineAt(pos int64) (string, int64, error) {
+	var line []byte
+	for size := 256; pos < s.end; size *= 2 {
+		// most lines are short, but those of common tokens list many entries
+		buf := make([]byte, size)
+		if remaining := s.end - pos; remaining < int64(len(buf)) {
+			buf = buf[:remaining]
+		}
+		n, err := s.r.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return string(append(line, buf[:i]...)), pos + int64(i) + 1, nil
+		}
+		line = append(line, buf[:n]...)
+		pos += int64(n)
+		if n < len(buf) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", 0, err
+		}
+	}
+	return string(line), s.end, nil
+}
+
+// readEntry returns the upper cased sequence of the FASTA entry at an offset of a file
+func readEntry(fastaPath string, offset int64) (string, error) {
+	f, err := os.Open(fastaPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	var seq strings.Builder
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), blastMaxLineLength)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, ">") {
+			break
+		}
+		seq.WriteString(strings.ToUpper(line))
+	}
+	return seq.String(), scanner.Err()
+}
+
+// lookupIndex returns the database's lookup index. It's rebuilt if it's missing
+// or older than the database, ex: for databases added before it was indexed.
+func (db DB) lookupIndex() (*lookupIndex, error) {
+	return loadLookupIndex(db.Path, buildLookupIndex)
+}
+
+// kvIndex returns the lookup index of a key-value store, ex: the features or enzymes db
+func kvIndex(kvPath string) (*lookupIndex, error) {
+	return loadLookupIndex(kvPath, buildKVIndex)
+}
+
+// loadLookupIndex opens the saved lookup index of the file at path, or builds and saves it
+// first if it's missing or older than the file. If it can't be saved, it's searched in memory
+func loadLookupIndex(path string, build func(string) (*indexBuilder, error)) (*lookupIndex, error) {
+	indexPath := dbIndexPath(path)
+	if isNewer(indexPath, path) {
+		idx, err := openLookupIndexFile(indexPath, path)
+		if err == nil {
+			return idx, nil
+		}
+		rlog.Warnf("failed to read the lookup index of %s, rebuilding it: %v", path, err)
+	}
+
+	b, err := build(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.save(indexPath); err != nil {
+		rlog.Warnf("failed to save the lookup index of %s: %v", path, err)
+		return b.inMemory(path)
+	}
+	return openLookupIndexFile(indexPath, path)
+}
+
+// isNewer returns whether the file at path was modified after the file at ref
+func isNewer(path, ref string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	refInfo, err := os.Stat(ref)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().Before(refInfo.ModTime())
+}
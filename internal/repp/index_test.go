@@ -0,0 +1,191 @@
+package repp
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_lookupIndex(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+	contents := ">pSB1C3 circular\nACGT\nACGT\n" +
+		">BBa_J23100 promoter-constitutive\nGGGG\n" +
+		">BBa_J23101 promoter_weak\nCCCC\n"
+	if err := os.WriteFile(dbPath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	built, err := buildLookupIndex(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := built.inMemory(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, ok := idx.entry("BBa_J23101")
+	if !ok || e.Title != "promoter_weak" {
+		t.Fatalf("lookupIndex.entry() = %+v, %v, want BBa_J23101", e, ok)
+	}
+	if seq, err := readEntry(dbPath, e.Offset); err != nil || seq != "CCCC" {
+		t.Errorf("readEntry() = %s, %v, want CCCC", seq, err)
+	}
+	if e, _ := idx.entry("pSB1C3"); e.Offset != 0 {
+		t.Errorf("lookupIndex.entry() offset = %d, want 0", e.Offset)
+	}
+	if seq, _ := readEntry(dbPath, 0); seq != "ACGTACGT" {
+		t.Errorf("readEntry() = %s, want the multi-line sequence", seq)
+	}
+	if _, ok := idx.entry("BBa_J231"); ok {
+		t.Error("lookupIndex.entry() found an entry by a partial ID")
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"promoter", []string{"BBa_J23100", "BBa_J23101"}},
+		{"PROMOTER weak", []string{"BBa_J23101"}},
+		{"bba j231", []string{"BBa_J23100", "BBa_J23101"}},
+		{"circ", []string{"pSB1C3"}},
+		{"terminator", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []string
+			for _, e := range idx.search(tt.query) {
+				got = append(got, e.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupIndex.search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the saved index is read back, and rebuilt once the database changes
+	db := DB{Name: "db", Path: dbPath}
+	if _, err := db.lookupIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dbIndexPath(dbPath)); err != nil {
+		t.Fatalf("lookupIndex() did not save the index: %v", err)
+	}
+	if saved, err := db.lookupIndex(); err != nil || len(saved.search("promoter")) != 2 {
+		t.Errorf("lookupIndex() did not read back the saved index: %v", err)
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(dbPath, []byte(">terminator\nAAAA\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(dbPath, later, later)
+	if rebuilt, err := db.lookupIndex(); err != nil || len(rebuilt.search("terminator")) != 1 {
+		t.Errorf("lookupIndex() did not rebuild the index of a changed database: %v", err)
+	}
+}
+
+func Test_kvIndex(t *testing.T) {
+	kvPath := filepath.Join(t.TempDir(), "features.json")
+	contents := `{"pLac": {"seq": "AAAA", "type": "promoter"}, "lacI": "CCCC", "T7 terminator": "GGGG"}`
+	if err := os.WriteFile(kvPath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := kvIndex(kvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range idx.search("promoter") {
+		got = append(got, e.ID)
+	}
+	if !reflect.DeepEqual(got, []string{"pLac"}) {
+		t.Errorf("kvIndex().search() = %v, want the feature by its type", got)
+	}
+	if len(idx.search("t7 term")) != 1 {
+		t.Error("kvIndex().search() did not find a feature by the words of its name")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(kvPath, []byte(`{"AmpR": "TTTT"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(kvPath, later, later)
+	if rebuilt, err := kvIndex(kvPath); err != nil || len(rebuilt.search("ampr")) != 1 || len(rebuilt.search("promoter")) != 0 {
+		t.Errorf("kvIndex() did not rebuild the index of a changed store: %v", err)
+	}
+}
+
+func Test_lookupIndex_binarySearch(t *testing.T) {
+	// entries with shared prefixes and long titles, so lookups land mid-line
+	b := newIndexBuilder()
+	rng := rand.New(rand.NewSource(1))
+	var ids []string
+	for i := 0; i < 2000; i++ {
+		id := fmt.Sprintf("pLab%d", rng.Intn(5000))
+		ids = append(ids, id)
+		b.add(indexEntry{ID: id, Title: strings.Repeat("x", rng.Intn(600)) + fmt.Sprintf(" tag%d", i%7), Offset: int64(i)})
+	}
+	b.add(indexEntry{ID: "tab\tand\nnew\\line", Title: "escaped\ttitle"})
+	idx, err := b.inMemory("lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, id := range ids {
+		first := i
+		for j := range ids[:i] {
+			if ids[j] == id {
+				first = j
+				break
+			}
+		}
+		if e, ok := idx.entry(id); !ok || e.Offset != int64(first) {
+			t.Fatalf("lookupIndex.entry(%s) = %+v, %t, want the entry at %d", id, e, ok, first)
+		}
+	}
+	if e, ok := idx.entry("tab\tand\nnew\\line"); !ok || e.Title != "escaped\ttitle" {
+		t.Errorf("lookupIndex.entry() = %+v, %t, want the escaped entry", e, ok)
+	}
+	for _, missing := range []string{"", "pLab", "pLab5000", "zzz"} {
+		if _, ok := idx.entry(missing); ok {
+			t.Errorf("lookupIndex.entry(%s) found an entry", missing)
+		}
+	}
+
+	for _, query := range []string{"plab12", "tag3", "plab4 tag0", "tag"} {
+		var want []int64
+		for i, e := range b.entries {
+			if hasQueryTokens(e, query) {
+				want = append(want, int64(i))
+			}
+		}
+		var got []int64
+		for _, e := range idx.search(query) {
+			got = append(got, e.Offset)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("lookupIndex.search(%s) = %d entries, want %d", query, len(got), len(want))
+		}
+	}
+}
+
+// hasQueryTokens returns whether each token of the query starts a token of the entry, by brute force
+func hasQueryTokens(e indexEntry, query string) bool {
+	for _, q := range tokenize(query) {
+		found := false
+		for _, token := range append(tokenize(e.ID), tokenize(e.Title)...) {
+			found = found || strings.HasPrefix(token, q)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -1,13 +1,17 @@
 package repp
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode"
 
 	"go.uber.org/multierr"
 )
@@ -30,9 +34,39 @@ type AssemblyParams interface {
 	GetOutputFormat() string
 	SetOutputFormat(f string)
 
+	GetTrackFormat() string
+	SetTrackFormat(f string)
+
+	GetEmitOrderFiles() bool
+	SetEmitOrderFiles(b bool)
+
+	GetEmitProtocol() bool
+	SetEmitProtocol(b bool)
+
+	GetRedact() bool
+	SetRedact(b bool)
+
+	GetSelfCheck() bool
+	SetSelfCheck(b bool)
+
+	GetInferOrder() bool
+	SetInferOrder(b bool)
+
+	GetSyntheticFragments() []string
+	SetSyntheticFragments(ids []string)
+
+	GetChunkSize() int
+	SetChunkSize(n int)
+
 	GetFilters() []string
 	SetFilters(fs []string)
 
+	GetFilterExpr() string
+	SetFilterExpr(expr string)
+
+	GetTopology() string
+	SetTopology(t string)
+
 	GetIdentity() int
 	SetIdentity(i int)
 
@@ -42,6 +76,36 @@ type AssemblyParams interface {
 	GetLeftMargin() int
 	SetLeftMargin(i int)
 
+	GetSynthOnly() bool
+	SetSynthOnly(b bool)
+
+	GetPareto() bool
+	SetPareto(b bool)
+
+	GetReuseFrom() string
+	SetReuseFrom(filename string)
+
+	GetDiffAgainst() string
+	SetDiffAgainst(filename string)
+
+	GetFromGenbankFeatures() bool
+	SetFromGenbankFeatures(b bool)
+
+	GetMonomer() bool
+	SetMonomer(b bool)
+
+	GetSynthesizeRegions() []string
+	SetSynthesizeRegions(regions []string)
+
+	GetIdentityRegions() string
+	SetIdentityRegions(filename string)
+
+	GetDumpMatches() string
+	SetDumpMatches(prefix string)
+
+	GetLandingPads() []string
+	SetLandingPads(pads []string)
+
 	GetBackboneName() string
 	SetBackboneName(bn string)
 
@@ -69,6 +133,30 @@ type assemblyParamsImpl struct {
 	// output format (JSON, CSV)
 	outFormat string
 
+	// format of the per solution tracks (BED, GFF), none if empty
+	trackFormat string
+
+	// write per solution files for ordering the new primers and synthetic fragments
+	emitOrderFiles bool
+
+	// write a bench protocol per solution
+	emitProtocol bool
+
+	// write a variant of the output with its identifiers replaced by anonymous tokens
+	redact bool
+
+	// fail, rather than warn, if a solution's fragments don't reconstruct the target
+	selfCheck bool
+
+	// infer the order and orientation of the input fragments from their end homology
+	inferOrder bool
+
+	// IDs of the input fragments that will be synthesized rather than PCR amplified
+	syntheticFragments []string
+
+	// most fragments per chunk of the output, none are written if 0
+	chunkSize int
+
 	// a list of dbs to run BLAST against (their names' on the filesystem)
 	dbNames []string
 
@@ -87,6 +175,12 @@ type assemblyParamsImpl struct {
 	// slice of strings to weed out fragments from BLAST matches
 	filters []string
 
+	// expression of the database entries to keep
+	filterExpr string
+
+	// target topology (circular, linear), inferred from the input if empty
+	topology string
+
 	// percentage identity for finding building fragments in BLAST databases
 	identity int
 
@@ -95,6 +189,36 @@ type assemblyParamsImpl struct {
 
 	// left margin for circular matches
 	leftMargin int
+
+	// split the target into synthetic fragments without searching the dbs
+	synthOnly bool
+
+	// keep one solution per point of the pareto front instead of the top solutions
+	pareto bool
+
+	// previous JSON output to reuse fragments and primers from
+	reuseFrom string
+
+	// previous target to BLAST only the changed region of the target against
+	diffAgainst string
+
+	// honor the /repp_synthesize and /repp_source qualifiers of the GenBank target's features
+	fromGenbankFeatures bool
+
+	// design one copy of a target that's a tandem repeat, ex: a doubled plasmid
+	monomer bool
+
+	// ranges of the target that have to be synthesized, ex: 100-450
+	synthesizeRegions []string
+
+	// BED-like file of the regions of the target with a higher min %-identity than identity
+	identityRegions string
+
+	// prefix of the TSVs the matches considered, before and after culling, are written to
+	dumpMatches string
+
+	// feature names or sequences of the only sites fragments may join at
+	landingPads []string
 }
 
 func MkAssemblyParams() AssemblyParams {
@@ -125,6 +249,70 @@ func (ap *assemblyParamsImpl) SetOutputFormat(f string) {
 	ap.outFormat = f
 }
 
+func (ap assemblyParamsImpl) GetTrackFormat() string {
+	return ap.trackFormat
+}
+
+func (ap *assemblyParamsImpl) SetTrackFormat(f string) {
+	ap.trackFormat = f
+}
+
+func (ap assemblyParamsImpl) GetEmitOrderFiles() bool {
+	return ap.emitOrderFiles
+}
+
+func (ap *assemblyParamsImpl) SetEmitOrderFiles(b bool) {
+	ap.emitOrderFiles = b
+}
+
+func (ap assemblyParamsImpl) GetEmitProtocol() bool {
+	return ap.emitProtocol
+}
+
+func (ap *assemblyParamsImpl) SetEmitProtocol(b bool) {
+	ap.emitProtocol = b
+}
+
+func (ap assemblyParamsImpl) GetRedact() bool {
+	return ap.redact
+}
+
+func (ap *assemblyParamsImpl) SetRedact(b bool) {
+	ap.redact = b
+}
+
+func (ap assemblyParamsImpl) GetSelfCheck() bool {
+	return ap.selfCheck
+}
+
+func (ap *assemblyParamsImpl) SetSelfCheck(b bool) {
+	ap.selfCheck = b
+}
+
+func (ap assemblyParamsImpl) GetInferOrder() bool {
+	return ap.inferOrder
+}
+
+func (ap *assemblyParamsImpl) SetInferOrder(b bool) {
+	ap.inferOrder = b
+}
+
+func (ap assemblyParamsImpl) GetSyntheticFragments() []string {
+	return ap.syntheticFragments
+}
+
+func (ap *assemblyParamsImpl) SetSyntheticFragments(ids []string) {
+	ap.syntheticFragments = ids
+}
+
+func (ap assemblyParamsImpl) GetChunkSize() int {
+	return ap.chunkSize
+}
+
+func (ap *assemblyParamsImpl) SetChunkSize(n int) {
+	ap.chunkSize = n
+}
+
 func (ap assemblyParamsImpl) GetFilters() []string {
 	return ap.filters
 }
@@ -133,6 +321,22 @@ func (ap *assemblyParamsImpl) SetFilters(filters []string) {
 	ap.filters = filters
 }
 
+func (ap assemblyParamsImpl) GetFilterExpr() string {
+	return ap.filterExpr
+}
+
+func (ap *assemblyParamsImpl) SetFilterExpr(expr string) {
+	ap.filterExpr = expr
+}
+
+func (ap assemblyParamsImpl) GetTopology() string {
+	return ap.topology
+}
+
+func (ap *assemblyParamsImpl) SetTopology(t string) {
+	ap.topology = t
+}
+
 func (ap assemblyParamsImpl) GetIdentity() int {
 	return ap.identity
 }
@@ -157,6 +361,86 @@ func (ap *assemblyParamsImpl) SetLeftMargin(leftMargin int) {
 	ap.leftMargin = leftMargin
 }
 
+func (ap assemblyParamsImpl) GetSynthOnly() bool {
+	return ap.synthOnly
+}
+
+func (ap *assemblyParamsImpl) SetSynthOnly(synthOnly bool) {
+	ap.synthOnly = synthOnly
+}
+
+func (ap assemblyParamsImpl) GetPareto() bool {
+	return ap.pareto
+}
+
+func (ap *assemblyParamsImpl) SetPareto(pareto bool) {
+	ap.pareto = pareto
+}
+
+func (ap assemblyParamsImpl) GetReuseFrom() string {
+	return ap.reuseFrom
+}
+
+func (ap *assemblyParamsImpl) SetReuseFrom(reuseFrom string) {
+	ap.reuseFrom = reuseFrom
+}
+
+func (ap assemblyParamsImpl) GetDiffAgainst() string {
+	return ap.diffAgainst
+}
+
+func (ap *assemblyParamsImpl) SetDiffAgainst(diffAgainst string) {
+	ap.diffAgainst = diffAgainst
+}
+
+func (ap assemblyParamsImpl) GetFromGenbankFeatures() bool {
+	return ap.fromGenbankFeatures
+}
+
+func (ap *assemblyParamsImpl) SetFromGenbankFeatures(b bool) {
+	ap.fromGenbankFeatures = b
+}
+
+func (ap assemblyParamsImpl) GetMonomer() bool {
+	return ap.monomer
+}
+
+func (ap *assemblyParamsImpl) SetMonomer(b bool) {
+	ap.monomer = b
+}
+
+func (ap assemblyParamsImpl) GetSynthesizeRegions() []string {
+	return ap.synthesizeRegions
+}
+
+func (ap *assemblyParamsImpl) SetSynthesizeRegions(regions []string) {
+	ap.synthesizeRegions = regions
+}
+
+func (ap assemblyParamsImpl) GetIdentityRegions() string {
+	return ap.identityRegions
+}
+
+func (ap *assemblyParamsImpl) SetIdentityRegions(filename string) {
+	ap.identityRegions = filename
+}
+
+func (ap assemblyParamsImpl) GetDumpMatches() string {
+	return ap.dumpMatches
+}
+
+func (ap *assemblyParamsImpl) SetDumpMatches(prefix string) {
+	ap.dumpMatches = prefix
+}
+
+func (ap assemblyParamsImpl) GetLandingPads() []string {
+	return ap.landingPads
+}
+
+func (ap *assemblyParamsImpl) SetLandingPads(pads []string) {
+	ap.landingPads = pads
+}
+
 func (ap assemblyParamsImpl) GetBackboneName() string {
 	return ap.backboneName
 }
@@ -182,7 +466,9 @@ func (ap *assemblyParamsImpl) SetSynthFragsDBLocations(dbLocations []string) {
 }
 
 func (ap assemblyParamsImpl) getDBs() (dbs []DB, err error) {
-	return getRegisteredDBs(ap.dbNames)
+	dbs, err = getRegisteredDBs(ap.dbNames)
+	setRunDatabases(len(dbs))
+	return dbs, err
 }
 
 func (ap *assemblyParamsImpl) SetDbNames(dbNames []string) {
@@ -212,7 +498,8 @@ func (r inputReport) printReport() {
 func prepareBackbone(
 	bbName string,
 	enzymes []enzyme,
-	dbs []DB) (f *Frag, backbone *Backbone, err error) {
+	dbs []DB,
+	strict bool) (f *Frag, backbone *Backbone, err error) {
 
 	if bbName == "" {
 		// if no backbone was specified, return an empty Frag
@@ -220,7 +507,7 @@ func prepareBackbone(
 	}
 
 	// confirm that the backbone exists in one of the dbs (or local fs) gather it as a Frag if it does
-	bbFrag, err := queryDatabases(bbName, dbs)
+	bbFrag, err := queryDatabases(bbName, dbs, strict)
 	if err != nil {
 		return &Frag{}, &Backbone{}, err
 	}
@@ -252,11 +539,46 @@ func prepareBackbone(
 	return
 }
 
-// read a dir of FASTA or Genbank files to a slice of fragments
-func multiFileRead(fs []string, prefixSeqIDWithFName bool) (fragments []*Frag, rep inputReport, err error) {
+// read a dir of FASTA or Genbank files to a slice of fragments. The files are parsed in
+// parallel, on the available CPUs, and their fragments returned in the files' order.
+// Non-ACGT bases are replaced by N if maskAmbiguous, and are stripped otherwise.
+// In strict mode, duplicate sequence IDs and malformed Genbank records are errors.
+func multiFileRead(fs []string, prefixSeqIDWithFName, maskAmbiguous, strict bool) (fragments []*Frag, rep inputReport, err error) {
+	type fileRead struct {
+		frags []*Frag
+		err   error
+	}
+
+	// parse the files with a pool of workers, ex: for thousands of GenBank files
+	reads := make([]fileRead, len(fs))
+	files := make(chan int)
+	workers := availableCPUs()
+	if workers > len(fs) {
+		workers = len(fs)
+	}
+	p := newProgress("Read sequence files", len(fs))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range files {
+				reads[i].frags, reads[i].err = readSeqFile(fs[i], false, prefixSeqIDWithFName, maskAmbiguous, strict)
+				p.add(1)
+			}
+		}()
+	}
+	for i := range fs {
+		files <- i
+	}
+	close(files)
+	wg.Wait()
+
+	// and collect their fragments in the files' order
 	newFrags := make(map[string]*Frag)
-	for _, f := range fs {
-		fFrags, ferr := read(f, false, prefixSeqIDWithFName)
+	fragFiles := make(map[string]string)
+	for i, f := range fs {
+		fFrags, ferr := reads[i].frags, reads[i].err
 		if ferr != nil {
 			err = multierr.Append(err, ferr)
 			rep.errored++
@@ -268,11 +590,15 @@ func multiFileRead(fs []string, prefixSeqIDWithFName bool) (fragments []*Frag, r
 				indexedFragID := strings.ToUpper(frag.ID)
 				_, found := newFrags[indexedFragID]
 				if found {
+					if strict {
+						err = multierr.Append(err, fmt.Errorf("strict mode: duplicate sequence ID %s in %s, also in %s", frag.ID, f, fragFiles[indexedFragID]))
+					}
 					// do not skip the duplicates but report them
 					rep.duplicatedIDs++
 					rlog.Debugf("Duplicate id found %s in %s", frag.ID, f)
 				} else {
 					newFrags[indexedFragID] = frag
+					fragFiles[indexedFragID] = f
 				}
 				fragments = append(fragments, frag)
 				rep.sequencesRead++
@@ -285,20 +611,38 @@ func multiFileRead(fs []string, prefixSeqIDWithFName bool) (fragments []*Frag, r
 
 // read a FASTA or Genbank file (by its path on local FS) to a slice of Fragments.
 func read(path string, feature, prefixSeqIDWithFName bool) (fragments []*Frag, err error) {
+	return readSeqFile(path, feature, prefixSeqIDWithFName, false, false)
+}
+
+// readEach reads a FASTA or Genbank file like read, but calls fn with each Fragment
+// as it's parsed rather than returning them all. FASTA files are streamed.
+func readEach(path string, feature, prefixSeqIDWithFName bool, fn func(*Frag)) error {
+	return readSeqFileEach(path, feature, prefixSeqIDWithFName, false, false, fn)
+}
+
+// readSeqFile reads a FASTA or Genbank file to a slice of Fragments. Non-ACGT bases
+// are replaced by N if maskAmbiguous, otherwise they're stripped and their indexes
+// are recorded in each Fragment's strippedIndexes. In strict mode, a malformed
+// Genbank record fails the read rather than being skipped.
+func readSeqFile(path string, feature, prefixSeqIDWithFName, maskAmbiguous, strict bool) (fragments []*Frag, err error) {
+	fragments = []*Frag{}
+	err = readSeqFileEach(path, feature, prefixSeqIDWithFName, maskAmbiguous, strict, func(f *Frag) {
+		fragments = append(fragments, f)
+	})
+	return fragments, err
+}
+
+// readSeqFileEach reads a FASTA or Genbank file like readSeqFile, calling fn with each of
+// its Fragments in turn. FASTA files are streamed an entry at a time, so a file of
+// thousands of sequences is never held in memory whole.
+func readSeqFileEach(path string, feature, prefixSeqIDWithFName, maskAmbiguous, strict bool, fn func(*Frag)) (err error) {
 	if !filepath.IsAbs(path) {
 		path, err = filepath.Abs(path)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create path to input file: %s", err)
+			return fmt.Errorf("failed to create path to input file: %s", err)
 		}
 	}
 
-	fcontent, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	// convert content to string
-	scontent := strings.TrimSpace(string(fcontent))
-
 	var seqIDNamespace string
 	if prefixSeqIDWithFName {
 		fname := filepath.Base(path)
@@ -308,85 +652,221 @@ func read(path string, feature, prefixSeqIDWithFName bool) (fragments []*Frag, e
 
 	// inspect content to figure out whether it's FASTA or Genbank
 	// this is slower than just looking at the file extension
-	// but the file is already in memory anyway
-	if scontent[0] == '>' {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	if first, err := firstNonSpace(r); err != nil && err != io.EOF {
+		return err
+	} else if first == '>' {
 		rlog.Debugf("Add sequences from FASTA file: %s", path)
-		return readFasta(path, scontent, seqIDNamespace)
+		return readFastaEach(path, r, seqIDNamespace, maskAmbiguous, fn)
 	}
 
+	fcontent, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	scontent := strings.TrimSpace(string(fcontent))
 	if strings.Contains(scontent, "LOCUS") && strings.Contains(scontent, "ORIGIN") {
 		rlog.Debugf("Add sequences from Genbank file: %s", path)
-		return readGenbank(path, scontent, feature, seqIDNamespace)
+		frags, err := readGenbank(path, scontent, feature, seqIDNamespace, maskAmbiguous, strict)
+		for _, frag := range frags {
+			fn(frag)
+		}
+		return err
 	}
 
 	rlog.Debugf("Ignoring file %s because it does not recognize the file type", path)
-	return []*Frag{}, nil
+	return nil
 }
 
-// readFasta parses the multifasta file to fragments.
-func readFasta(path, contents, idNamespace string) (frags []*Frag, err error) {
-	// split by newlines
-	lines := strings.Split(contents, "\n")
-
-	// read in the frags
-	var headerIndices []int
-	var ids []string
-	var fragTypes []fragType
-	for i, line := range lines {
-		if strings.HasPrefix(line, ">") {
-			headerIndices = append(headerIndices, i)
-			ids = append(ids, strings.TrimSpace(line[1:]))
-			if strings.Contains(line, "circular") {
-				fragTypes = append(fragTypes, circular)
+// firstNonSpace returns the first non-whitespace character of a reader, leaving it unread
+func firstNonSpace(r *bufio.Reader) (rune, error) {
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(c) {
+			return c, r.UnreadRune()
+		}
+	}
+}
+
+// cleanSeq upper cases a sequence and removes the characters that aren't bases (whitespace,
+// digits, etc). Letters other than ACGT are replaced by N if maskAmbiguous. Otherwise they're
+// stripped and their indexes in the original sequence (counting only letters) are returned.
+func cleanSeq(raw string, maskAmbiguous bool) (seq string, stripped []int) {
+	var cleaned strings.Builder
+	index := 0
+	for _, c := range strings.ToUpper(raw) {
+		if c < 'A' || c > 'Z' {
+			continue
+		}
+		switch c {
+		case 'A', 'C', 'G', 'T':
+			cleaned.WriteRune(c)
+		default:
+			if maskAmbiguous {
+				cleaned.WriteRune('N')
 			} else {
-				fragTypes = append(fragTypes, linear)
+				stripped = append(stripped, index)
 			}
 		}
+		index++
 	}
+	return cleaned.String(), stripped
+}
+
+// readFasta parses the multifasta file to fragments.
+func readFasta(path, contents, idNamespace string, maskAmbiguous bool) (frags []*Frag, err error) {
+	err = readFastaEach(path, strings.NewReader(contents), idNamespace, maskAmbiguous, func(f *Frag) {
+		frags = append(frags, f)
+	})
+	return frags, err
+}
 
-	// create a regex for cleaning the sequence
-	var unwantedChars = regexp.MustCompile(`(?im)[^atgc]|\W`)
+// readFastaEach parses a multifasta file, a line at a time, calling fn with each of its
+// fragments once its sequence is read. It errors if there are none.
+func readFastaEach(path string, r io.Reader, idNamespace string, maskAmbiguous bool, fn func(*Frag)) error {
+	var seqIDNamespace string
+	if idNamespace != "" {
+		seqIDNamespace = idNamespace + "|"
+	}
 
-	// accumulate the sequences from between the headers
-	var seqs []string
-	for i, headerIndex := range headerIndices {
-		nextLine := len(lines)
-		if i < len(headerIndices)-1 {
-			nextLine = headerIndices[i+1]
+	var header string
+	var seqLines strings.Builder
+	count := 0
+	flush := func() {
+		if header == "" {
+			return // lines before the first header
 		}
-		seqLines := lines[headerIndex+1 : nextLine]
-		seqJoined := strings.Join(seqLines, "")
-		seq := unwantedChars.ReplaceAllString(seqJoined, "")
-		seq = strings.ToUpper(seq)
-		seqs = append(seqs, seq)
+		seq, stripped := cleanSeq(seqLines.String(), maskAmbiguous)
+		f := &Frag{
+			ID:              seqIDNamespace + strings.TrimSpace(header[1:]),
+			Seq:             seq,
+			fragType:        linear,
+			strippedIndexes: stripped,
+		}
+		if strings.Contains(header, "circular") {
+			f.fragType = circular
+		}
+		fn(f)
+		count++
 	}
 
-	// build and return the new frags
-	var seqIDNamespace string
-	if idNamespace == "" {
-		seqIDNamespace = ""
-	} else {
-		seqIDNamespace = idNamespace + "|"
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), blastMaxLineLength)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ">") {
+			flush()
+			header = line
+			seqLines.Reset()
+			continue
+		}
+		seqLines.WriteString(line)
 	}
-	for i, id := range ids {
-		frags = append(frags, &Frag{
-			ID:       seqIDNamespace + id,
-			Seq:      seqs[i],
-			fragType: fragTypes[i],
-		})
+	if err := scanner.Err(); err != nil {
+		return err
 	}
+	flush()
 
 	// opened and parsed file but found nothing
-	if len(frags) < 1 {
-		return frags, fmt.Errorf("failed to parse fragment(s) from %s", path)
+	if count < 1 {
+		return fmt.Errorf("failed to parse fragment(s) from %s", path)
 	}
+	return nil
+}
 
-	return
+// scanFasta streams a FASTA file, such as a database's sequence file, calling
+// fn with the header (without the '>') and upper cased sequence of each entry.
+// Unlike readFasta it never holds more than one entry in memory.
+func scanFasta(path string, fn func(header, seq string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var header string
+	var seq strings.Builder
+	flush := func() {
+		if header != "" {
+			fn(header, seq.String())
+		}
+		seq.Reset()
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), blastMaxLineLength)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, ">") {
+			flush()
+			header = line[1:]
+			continue
+		}
+		seq.WriteString(strings.ToUpper(line))
+	}
+	flush()
+
+	return scanner.Err()
 }
 
-// readGenbank parses a genbank file to fragments. Returns either fragments or parseFeatures,
-// depending on the parseFeatures parameter.
-func readGenbank(path, contents string, parseFeatures bool, idNamespace string) (fragments []*Frag, err error) {
+// genbankLocusRegex matches the start of each record in a genbank file
+var genbankLocusRegex = regexp.MustCompile(`(?m)^LOCUS`)
+
+// readGenbank parses a genbank file, with one or more records, to fragments. Returns
+// either fragments or parseFeatures, depending on the parseFeatures parameter.
+// Malformed records are skipped as long as at least one record can be parsed, or fail
+// the read in strict mode.
+func readGenbank(path, contents string, parseFeatures bool, idNamespace string, maskAmbiguous, strict bool) (fragments []*Frag, err error) {
+	records := splitGenbankRecords(contents)
+	if len(records) == 1 {
+		return readGenbankRecord(path, records[0], parseFeatures, idNamespace, maskAmbiguous)
+	}
+
+	for i, record := range records {
+		recordFrags, recordErr := readGenbankRecord(path, record, parseFeatures, idNamespace, maskAmbiguous)
+		if recordErr != nil {
+			if strictErr := strictErrorf(strict, "skipping record %d of %s: %v", i+1, path, recordErr); strictErr != nil {
+				return nil, strictErr
+			}
+			err = multierr.Append(err, recordErr)
+			continue
+		}
+		fragments = append(fragments, recordFrags...)
+	}
+	if len(fragments) == 0 {
+		return nil, err
+	}
+
+	return fragments, nil
+}
+
+// splitGenbankRecords splits the contents of a genbank file on its LOCUS lines
+func splitGenbankRecords(contents string) (records []string) {
+	starts := genbankLocusRegex.FindAllStringIndex(contents, -1)
+	if len(starts) <= 1 {
+		return []string{contents}
+	}
+
+	for i, start := range starts {
+		end := len(contents)
+		if i < len(starts)-1 {
+			end = starts[i+1][0]
+		}
+		records = append(records, contents[start[0]:end])
+	}
+	return records
+}
+
+// readGenbankRecord parses a single genbank record to fragments
+func readGenbankRecord(path, contents string, parseFeatures bool, idNamespace string, maskAmbiguous bool) (fragments []*Frag, err error) {
 	// use "\nORIGIN" because there are annotations that contain the word origin
 	// which may generate an error because of more than 2 components as a result of the split
 	genbankSplit := strings.Split(contents, "\nORIGIN")
@@ -395,9 +875,7 @@ func readGenbank(path, contents string, parseFeatures bool, idNamespace string)
 		return nil, fmt.Errorf("failed to parse %s: improperly formatted genbank file", path)
 	}
 
-	seq := strings.ToUpper(genbankSplit[1])
-	nonBpRegex := regexp.MustCompile("[^ATGC]")
-	cleanedSeq := nonBpRegex.ReplaceAllString(seq, "")
+	cleanedSeq, stripped := cleanSeq(genbankSplit[1], maskAmbiguous)
 
 	var seqIDNamespace string
 	if idNamespace == "" {
@@ -416,6 +894,7 @@ func readGenbank(path, contents string, parseFeatures bool, idNamespace string)
 
 		featureSplitRegex := regexp.MustCompile(`\w+\s+\w+`)
 		featureStrings := featureSplitRegex.Split(splitOnFeatures[1], -1)
+		featureSeparators := featureSplitRegex.FindAllString(splitOnFeatures[1], -1)
 
 		features := []*Frag{}
 		for featureIndex, feature := range featureStrings {
@@ -426,6 +905,13 @@ func readGenbank(path, contents string, parseFeatures bool, idNamespace string)
 				continue
 			}
 
+			// "complement" is before the range, in the feature or the separator before it
+			location := feature[:strings.Index(feature, rangeIndexes[0])]
+			if featureIndex > 0 {
+				location = featureSeparators[featureIndex-1] + location
+			}
+			complement := strings.Contains(location, "complement(")
+
 			start, err := strconv.Atoi(rangeIndexes[1])
 			if err != nil {
 				return nil, err
@@ -446,6 +932,10 @@ func readGenbank(path, contents string, parseFeatures bool, idNamespace string)
 			} else {
 				label = strconv.Itoa(featureIndex)
 			}
+			if complement {
+				// keep the annotated orientation, like features passed as name:rev
+				label += ":REV"
+			}
 
 			features = append(features, &Frag{
 				ID:  seqIDNamespace + label,
@@ -456,7 +946,14 @@ func readGenbank(path, contents string, parseFeatures bool, idNamespace string)
 		return features, nil
 	}
 
-	// parse just the file's sequence
+	// parse just the record's sequence and its topology
+	fragType := linear
+	locusLine := strings.ToLower(strings.SplitN(genbankSplit[0], "\n", 2)[0])
+	if strings.Contains(locusLine, "circular") {
+		fragType = circular
+	}
+	linearTopology := strings.Contains(locusLine, "linear")
+
 	idRegex := regexp.MustCompile(`LOCUS[ \t]*([^ \t]*)`)
 	idMatches := idRegex.FindStringSubmatch(genbankSplit[0])
 
@@ -473,8 +970,12 @@ func readGenbank(path, contents string, parseFeatures bool, idNamespace string)
 
 	return []*Frag{
 		{
-			ID:  seqIDNamespace + id,
-			Seq: cleanedSeq,
+			ID:               seqIDNamespace + id,
+			Seq:              cleanedSeq,
+			fragType:         fragType,
+			linearTopology:   linearTopology,
+			circularTopology: fragType == circular,
+			strippedIndexes:  stripped,
 		},
 	}, nil
 }
@@ -1,7 +1,12 @@
 package repp
 
 import (
+	"fmt"
+	"os"
 	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -77,3 +82,116 @@ func Test_read(t *testing.T) {
 		}
 	}
 }
+
+func Test_cleanSeq(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		maskAmbiguous bool
+		wantSeq       string
+		wantStripped  []int
+	}{
+		{"plain", "atgc\nATGC", false, "ATGCATGC", nil},
+		{"genbank formatting", "1 atgcnn 7 ryat", false, "ATGCAT", []int{4, 5, 6, 7}},
+		{"masked", "1 atgcnn 7 ryat", true, "ATGCNNNNAT", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSeq, gotStripped := cleanSeq(tt.raw, tt.maskAmbiguous)
+			if gotSeq != tt.wantSeq {
+				t.Errorf("cleanSeq() seq = %v, want %v", gotSeq, tt.wantSeq)
+			}
+			if !reflect.DeepEqual(gotStripped, tt.wantStripped) {
+				t.Errorf("cleanSeq() stripped = %v, want %v", gotStripped, tt.wantStripped)
+			}
+		})
+	}
+}
+
+func Test_readGenbank_records(t *testing.T) {
+	contents := `LOCUS       pFirst      12 bp    DNA     circular SYN 01-JAN-2024
+DEFINITION  first record.
+ORIGIN
+        1 atgcatgcat gc
+//
+LOCUS       pSecond      8 bp    DNA     linear   SYN 01-JAN-2024
+DEFINITION  second record.
+ORIGIN
+        1 ggccggcc
+//
+LOCUS       pBroken      8 bp    DNA     linear   SYN 01-JAN-2024
+DEFINITION  record without a sequence.
+//
+`
+
+	got, err := readGenbank("records.gb", contents, false, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Frag{
+		{ID: "pFirst", Seq: "ATGCATGCATGC", fragType: circular, circularTopology: true},
+		{ID: "pSecond", Seq: "GGCCGGCC", fragType: linear, linearTopology: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readGenbank() = %v, want %v", got, want)
+	}
+
+	if _, err = readGenbank("records.gb", contents, false, "", false, true); err == nil || !strings.Contains(err.Error(), "record 3") {
+		t.Errorf("readGenbank() = %v in strict mode, want an error locating record 3", err)
+	}
+}
+
+func Test_readGenbank_complementFeatures(t *testing.T) {
+	contents := `LOCUS       pFeatures      12 bp    DNA     circular SYN 01-JAN-2024
+FEATURES             Location/Qualifiers
+     promoter        1..6
+                     /label=pLac
+                     /loom_color=#ff9ccd
+     CDS             complement(7..12)
+                     /label=gfp
+                     /loom_color=#75c6a9
+ORIGIN
+        1 atgcatggcc tt
+//
+`
+
+	got, err := readGenbank("features.gb", contents, true, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Frag{
+		{ID: "pLac", Seq: "ATGCAT"},
+		{ID: "gfp:REV", Seq: "GGCCTT"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readGenbank() = %v, want %v", got, want)
+	}
+}
+
+func Test_multiFileRead(t *testing.T) {
+	dir := t.TempDir()
+	var files, want []string
+	for i := 0; i < 20; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("entry%d.fa", i))
+		if err := os.WriteFile(file, []byte(fmt.Sprintf(">entry%d\n%s\n", i, randomSeq(100, int64(i)))), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+		want = append(want, fmt.Sprintf("entry%d", i))
+	}
+	files = append(files, filepath.Join(dir, "missing.fa"))
+
+	frags, report, err := multiFileRead(files, false, false, false)
+	if err == nil || report.errored != 1 || report.successful != 20 || report.sequencesRead != 20 {
+		t.Errorf("multiFileRead() report = %+v, err = %v, want 20 read and 1 error", report, err)
+	}
+	var got []string
+	for _, f := range frags {
+		got = append(got, f.ID)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("multiFileRead() = %v, want the fragments in the files' order", got)
+	}
+}
@@ -0,0 +1,117 @@
+package repp
+
+import (
+	"encoding/csv"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// Junction is the homology at which a fragment of a solution is joined to the next one
+type Junction struct {
+	// Left is the fragment (1-based) the junction is at the end of
+	Left int `json:"left"`
+
+	// Right is the fragment (1-based) the junction is at the start of
+	Right int `json:"right"`
+
+	// Seq of the junction's homology
+	Seq string `json:"seq"`
+
+	// Length of the junction's homology (bp)
+	Length int `json:"length"`
+
+	// Tm of the junction's homology
+	Tm float64 `json:"tm"`
+
+	// Start of the junction on the target (1-based), 0 if the homology isn't in the target
+	Start int `json:"start"`
+
+	// End of the junction on the target (1-based), 0 if the homology isn't in the target
+	End int `json:"end"`
+}
+
+// assemblyJunctions returns the junctions of an assembly's fragments, including the
+// one between the last and first fragments that circularizes the plasmid. They're
+// the same homology that the success score and reconstruction check are based on
+func assemblyJunctions(frags []*Frag, targetSeq string, conf *config.Config) (junctions []Junction) {
+	if len(frags) < 2 {
+		return nil
+	}
+
+	template := newCircularSeq(targetSeq)
+	for i, f := range frags {
+		next := frags[(i+1)%len(frags)]
+		seq := strings.ToUpper(f.junction(next, conf.FragmentsMinHomology, conf.FragmentsMaxHomology+1))
+		if seq == "" {
+			continue
+		}
+
+		j := Junction{
+			Left:   i + 1,
+			Right:  (i+1)%len(frags) + 1,
+			Seq:    seq,
+			Length: len(seq),
+			Tm:     math.Round(primerTm(seq)*10) / 10,
+		}
+		// the homology is at the end of the left fragment, search from its start
+		from := 0
+		if len(targetSeq) > 0 && f.start > 0 {
+			from = f.start % len(targetSeq)
+		}
+		index := template.indexFrom(seq, from)
+		if index < 0 {
+			index = template.indexFrom(seq, 0)
+		}
+		if index >= 0 {
+			j.Start = index%len(targetSeq) + 1
+			j.End = (index+len(seq)-1)%len(targetSeq) + 1
+		}
+		junctions = append(junctions, j)
+	}
+	return junctions
+}
+
+// writeJunctions writes the junctions of the solutions to a CSV, to troubleshoot
+// assemblies without recomputing the overlaps of their fragments
+func writeJunctions(filename string, out *Output) (err error) {
+	hasJunctions := false
+	for _, s := range out.Solutions {
+		hasJunctions = hasJunctions || len(s.Junctions) > 0
+	}
+	if !hasJunctions {
+		return nil
+	}
+
+	junctionsFile, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer junctionsFile.close(&err)
+
+	w := csv.NewWriter(junctionsFile)
+	if err = w.Write([]string{"Solution", "Left Fragment", "Right Fragment", "Seq", "Length", "Tm", "Start", "End"}); err != nil {
+		return err
+	}
+	for si, s := range out.Solutions {
+		for _, j := range s.Junctions {
+			if err = w.Write([]string{
+				strconv.Itoa(si + 1),
+				strconv.Itoa(j.Left),
+				strconv.Itoa(j.Right),
+				j.Seq,
+				strconv.Itoa(j.Length),
+				strconv.FormatFloat(j.Tm, 'f', 1, 64),
+				strconv.Itoa(j.Start),
+				strconv.Itoa(j.End),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,64 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_assemblyJunctions(t *testing.T) {
+	target := randomSeq(1000, 8)
+	conf := &config.Config{FragmentsMinHomology: 15, FragmentsMaxHomology: 40}
+
+	// three fragments with 20bp of homology, the last across the zero-index
+	frags := []*Frag{
+		{ID: "a", Seq: target[:400], start: 0, end: 399},
+		{ID: "b", Seq: target[380:700], start: 380, end: 699},
+		{ID: "c", Seq: target[680:] + target[:20], start: 680, end: 1019},
+	}
+	junctions := assemblyJunctions(frags, target, conf)
+	want := []Junction{
+		{Left: 1, Right: 2, Seq: target[380:400], Length: 20, Start: 381, End: 400},
+		{Left: 2, Right: 3, Seq: target[680:700], Length: 20, Start: 681, End: 700},
+		{Left: 3, Right: 1, Seq: target[:20], Length: 20, Start: 1, End: 20},
+	}
+	for i := range junctions {
+		if junctions[i].Tm <= 0 {
+			t.Errorf("assemblyJunctions() junction %d Tm = %v", i+1, junctions[i].Tm)
+		}
+		junctions[i].Tm = 0
+	}
+	if !reflect.DeepEqual(junctions, want) {
+		t.Errorf("assemblyJunctions() = %+v, want %+v", junctions, want)
+	}
+
+	if junctions = assemblyJunctions(frags[:1], target, conf); len(junctions) != 0 {
+		t.Errorf("assemblyJunctions() of one fragment = %+v, want none", junctions)
+	}
+
+	filename := filepath.Join(t.TempDir(), "out-junctions.csv")
+	out := &Output{Solutions: []Solution{{}, {Junctions: want[:1]}}}
+	if err := writeJunctions(filename, out); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := "Solution,Left Fragment,Right Fragment,Seq,Length,Tm,Start,End\n2,1,2," + target[380:400] + ",20,0.0,381,400\n"
+	if string(contents) != wantCSV {
+		t.Errorf("writeJunctions() = %q, want %q", contents, wantCSV)
+	}
+
+	// no file without junctions
+	filename = filepath.Join(t.TempDir(), "none-junctions.csv")
+	if err = writeJunctions(filename, &Output{Solutions: []Solution{{}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("writeJunctions() wrote %s without junctions", filename)
+	}
+}
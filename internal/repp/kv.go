@@ -2,13 +2,17 @@ package repp
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
 )
 
 // kv is a simple JSON/serialized key-value store.
-// used for enzymes and features right now.
+// used for enzymes and features right now. Values are sequences or, for
+// features, records of their sequence and metadata, ex: {"seq": "...", "type": "promoter"}
 type kv struct {
 	contents map[string]string
+	metadata map[string]FeatureMeta
 	path     string
 }
 
@@ -18,21 +22,74 @@ func newKV(path string) *kv {
 		rlog.Fatal(err)
 	}
 
-	contents := make(map[string]string)
-	if err = json.Unmarshal(dat, &contents); err != nil {
-		rlog.Fatal(err)
+	contents, metadata, err := parseKV(dat)
+	if err != nil {
+		rlog.Fatalf("failed to parse %s: %v", path, err)
 	}
 
 	return &kv{
 		contents: contents,
+		metadata: metadata,
 		path:     path,
 	}
 }
 
+// parseKV parses the sequences of a key-value store, and the metadata of its records
+func parseKV(dat []byte) (contents map[string]string, metadata map[string]FeatureMeta, err error) {
+	values := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(dat, &values); err != nil {
+		return nil, nil, err
+	}
+
+	contents = make(map[string]string)
+	metadata = make(map[string]FeatureMeta)
+	for key, value := range values {
+		var seq string
+		if json.Unmarshal(value, &seq) == nil {
+			contents[key] = seq
+			continue
+		}
+		var record featureRecord
+		if err = json.Unmarshal(value, &record); err != nil {
+			return nil, nil, fmt.Errorf("%s is neither a sequence nor a record: %v", key, err)
+		}
+		contents[key] = record.Seq
+		if !record.FeatureMeta.isEmpty() {
+			metadata[key] = record.FeatureMeta
+		}
+	}
+	return contents, metadata, nil
+}
+
 func (k *kv) save() error {
-	dat, err := json.MarshalIndent(k.contents, "", "  ")
+	values := make(map[string]interface{}, len(k.contents))
+	for key, seq := range k.contents {
+		if meta, ok := k.metadata[key]; ok && !meta.isEmpty() {
+			values[key] = featureRecord{Seq: seq, FeatureMeta: meta}
+		} else {
+			values[key] = seq
+		}
+	}
+	dat, err := json.MarshalIndent(values, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(k.path, dat, 0644)
+	return writeFileAtomic(k.path, dat)
+}
+
+// kvKeys returns the sorted keys of the key-value store at path, or none if it can't be read
+func kvKeys(path string) (keys []string) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	contents := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(dat, &contents); err != nil {
+		return nil
+	}
+	for key := range contents {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
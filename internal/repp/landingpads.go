@@ -0,0 +1,91 @@
+package repp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// landingPad is a sequence of the target, ex: a recombination site, that fragments
+// are only allowed to join at
+type landingPad struct {
+	// name of the landing pad, its sequence if it wasn't named
+	name string
+
+	// seq of the landing pad
+	seq string
+}
+
+// dnaRegex matches a sequence of nucleotides
+var dnaRegex = regexp.MustCompile(`^[ACGTacgt]+$`)
+
+// resolveLandingPads turns landing pads, by the name of a feature or by sequence, into
+// sequences. It fails if a landing pad is unknown or isn't in the target, on either strand
+func resolveLandingPads(names []string, features map[string]string, target string) ([]landingPad, error) {
+	doubledTarget := strings.ToUpper(target + target)
+
+	var pads []landingPad
+	for _, name := range names {
+		seq, ok := features[name]
+		if !ok {
+			if !dnaRegex.MatchString(name) {
+				return nil, fmt.Errorf("landing pad %s is neither a feature in %s nor a sequence", name, config.FeatureDB)
+			}
+			seq = name
+		}
+		seq = strings.ToUpper(seq)
+
+		if !strings.Contains(doubledTarget, seq) && !strings.Contains(doubledTarget, reverseComplement(seq)) {
+			return nil, fmt.Errorf("landing pad %s isn't in the target", name)
+		}
+		pads = append(pads, landingPad{name: name, seq: seq})
+	}
+	return pads, nil
+}
+
+// offPadJunction returns an error for the first junction between neighboring fragments
+// that isn't at a landing pad. A junction is at a landing pad if its homology is in
+// the landing pad or the landing pad is in its homology.
+func offPadJunction(frags []*Frag, pads []landingPad, conf *config.Config) error {
+	if len(frags) < 2 {
+		return nil // a single fragment has no junction
+	}
+
+	for i, f := range frags {
+		next := frags[(i+1)%len(frags)]
+		j := f.junction(next, conf.FragmentsMinHomology, conf.FragmentsMaxHomology+1)
+		if !atLandingPad(j, pads) {
+			return fmt.Errorf("junction between %s and %s isn't at a landing pad: %s", f.ID, next.ID, j)
+		}
+	}
+	return nil
+}
+
+// atLandingPad returns whether a junction's homology overlaps a landing pad, on either strand
+func atLandingPad(junction string, pads []landingPad) bool {
+	if junction == "" {
+		return false
+	}
+	for _, p := range pads {
+		for _, seq := range []string{p.seq, reverseComplement(p.seq)} {
+			if strings.Contains(seq, junction) || strings.Contains(junction, seq) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// atLandingPads returns the filled assemblies whose junctions are all at landing pads
+func atLandingPads(assemblies []*assembly, pads []landingPad, conf *config.Config) (kept []*assembly) {
+	for _, a := range assemblies {
+		if err := offPadJunction(a.frags, pads, conf); err != nil {
+			rlog.Debugf("Discard %v: %v", a, err)
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
@@ -0,0 +1,82 @@
+package repp
+
+import (
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_resolveLandingPads(t *testing.T) {
+	target := "ttgacaattaatcatcggctcgtataatgtgtggaattgtgagcggataacaatt"
+	features := map[string]string{"attB": "CGGCTCGTATAATGTGTGG", "lacO": "GGGGGGGGGGGGGGGGGGG"}
+
+	pads, err := resolveLandingPads([]string{"attB", "aattgttatccgctcacaa"}, features, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []landingPad{{"attB", "CGGCTCGTATAATGTGTGG"}, {"aattgttatccgctcacaa", "AATTGTTATCCGCTCACAA"}}
+	if len(pads) != len(want) || pads[0] != want[0] || pads[1] != want[1] {
+		t.Errorf("resolveLandingPads() = %v, want %v", pads, want)
+	}
+
+	// across the zero index of the target
+	if _, err = resolveLandingPads([]string{"aacaattttgacaattaat"}, features, target); err != nil {
+		t.Errorf("resolveLandingPads() error = %v, want a landing pad across the zero index", err)
+	}
+	if _, err = resolveLandingPads([]string{"lacO"}, features, target); err == nil {
+		t.Error("resolveLandingPads() should fail for a landing pad that isn't in the target")
+	}
+	if _, err = resolveLandingPads([]string{"attP"}, features, target); err == nil {
+		t.Error("resolveLandingPads() should fail for an unknown feature")
+	}
+}
+
+func Test_offPadJunction(t *testing.T) {
+	c := config.New()
+	c.FragmentsMinHomology = 8
+	c.FragmentsMaxHomology = 20
+
+	pads := []landingPad{{"attB", "CGGCTCGTATAATGTGTGG"}}
+	tests := []struct {
+		name    string
+		frags   []*Frag
+		wantErr bool
+	}{
+		{
+			"single fragment",
+			[]*Frag{{ID: "1", Seq: "ACGTACGTACGTACGT"}},
+			false,
+		},
+		{
+			"junctions in the landing pad",
+			[]*Frag{
+				{ID: "1", Seq: "GTATAATGTTTTTTTTTTTTTTTTTTTCGGCTCGT"},
+				{ID: "2", Seq: "CGGCTCGTAAAAAAAAAAAAAAAAAAAGTATAATG"},
+			},
+			false,
+		},
+		{
+			"junction in the landing pad's reverse complement",
+			[]*Frag{
+				{ID: "1", Seq: "ATTATACGTTTTTTTTTTTTTTTTTTTCCACACAT"},
+				{ID: "2", Seq: "CCACACATAAAAAAAAAAAAAAAAAAAATTATACG"},
+			},
+			false,
+		},
+		{
+			"junction outside the landing pad",
+			[]*Frag{
+				{ID: "1", Seq: "GTATAATGTTTTTTTTTTTTTTTTTTTGATCGATC"},
+				{ID: "2", Seq: "GATCGATCAAAAAAAAAAAAAAAAAAAGTATAATG"},
+			},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := offPadJunction(tt.frags, pads, c); (err != nil) != tt.wantErr {
+				t.Errorf("offPadJunction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
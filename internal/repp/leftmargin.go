@@ -0,0 +1,191 @@
+package repp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// how matches that start in the left margin of a circular target are handled
+const (
+	// leftMarginMerge moves them past the zero-index, merging them into the match that ends
+	// there if they're part of the same alignment, and drops those that a match already has
+	leftMarginMerge = "merge"
+
+	// leftMarginDrop drops all of them, assuming a longer match across the zero-index has them
+	leftMarginDrop = "drop"
+)
+
+// parseLeftMarginHandling returns the handling of the match-left-margin-handling setting
+func parseLeftMarginHandling(handling string) (string, error) {
+	switch handling {
+	case leftMarginMerge, leftMarginDrop:
+		return handling, nil
+	}
+	return "", fmt.Errorf("unknown match-left-margin-handling %q; valid values [merge, drop]", handling)
+}
+
+// marginMatch is a match in the left margin of a circular target and what was done with it
+type marginMatch struct {
+	match  match
+	action string
+	reason string
+}
+
+// applyLeftMargin handles the matches that start in the left margin of a circular target,
+// the first matchLeftMargin bp of its doubled sequence. They may be the end of a longer match
+// across the zero-index. With leftMarginDrop they're dropped. Otherwise they're moved to
+// the copy of the target past the zero-index, where they're dropped if another match of the
+// same template already spans them, or merged into one that ends where they start on the
+// same alignment, ex: when BLAST splits a match at the zero-index. The rest are kept
+func applyLeftMargin(matches []match, seq string, matchLeftMargin int, handling string) (kept []match, report []marginMatch) {
+	seqLen := len(seq)
+	querySeq := seq + seq
+	var inMargin []match
+	for _, m := range matches {
+		if m.queryStart < matchLeftMargin {
+			inMargin = append(inMargin, m)
+		} else {
+			kept = append(kept, m)
+		}
+	}
+
+	for _, m := range inMargin {
+		if handling == leftMarginDrop {
+			report = append(report, marginMatch{m, "dropped", "in the left margin"})
+			continue
+		}
+		if m.queryEnd+seqLen >= len(querySeq) {
+			// longer than the rest of the target, there's no copy to move it to
+			kept = append(kept, m)
+			report = append(report, marginMatch{m, "kept", "spans the whole target"})
+			continue
+		}
+
+		shifted := m
+		shifted.queryStart += seqLen
+		shifted.queryEnd += seqLen
+		shifted.querySeq = querySeq[shifted.queryStart : shifted.queryEnd+1]
+
+		if i := spanningMatch(kept, shifted); i >= 0 {
+			o := kept[i]
+			report = append(report, marginMatch{m, "dropped", fmt.Sprintf("in the match of %d-%d", o.queryStart+1, o.queryEnd+1)})
+			continue
+		}
+		if i := abuttingMatch(kept, shifted); i >= 0 {
+			o := kept[i]
+			kept[i] = mergeMatches(o, shifted, querySeq, seqLen)
+			report = append(report, marginMatch{m, "merged", fmt.Sprintf("into the match of %d-%d across the zero-index", o.queryStart+1, o.queryEnd+1)})
+			continue
+		}
+		kept = append(kept, shifted)
+		report = append(report, marginMatch{m, "moved", fmt.Sprintf("to %d-%d past the zero-index", shifted.queryStart+1, shifted.queryEnd+1)})
+	}
+
+	return kept, report
+}
+
+// sameAlignment returns whether two matches are of the same template, on the same strands
+func sameAlignment(a, b match) bool {
+	return a.entry == b.entry && a.db.Path == b.db.Path &&
+		a.queryRevCompMatch == b.queryRevCompMatch && a.subjectRevCompMatch == b.subjectRevCompMatch
+}
+
+// spanningMatch returns the index of a match of the same template that spans m, -1 if none does
+func spanningMatch(matches []match, m match) int {
+	for i, o := range matches {
+		if sameAlignment(o, m) && o.queryStart <= m.queryStart && o.queryEnd >= m.queryEnd {
+			return i
+		}
+	}
+	return -1
+}
+
+// abuttingMatch returns the index of a gapless match of the same template that ends where
+// the gapless m starts, or overlaps its start, on the same diagonal of the template. -1 if none
+func abuttingMatch(matches []match, m match) int {
+	if m.gaps > 0 {
+		return -1
+	}
+	for i, o := range matches {
+		if !sameAlignment(o, m) || o.gaps > 0 || o.queryStart >= m.queryStart || o.queryEnd+1 < m.queryStart {
+			continue
+		}
+		sameDiagonal := o.subjectStart-o.queryStart == m.subjectStart-m.queryStart
+		if o.subjectRevCompMatch {
+			// the template's coordinates fall as the target's rise
+			sameDiagonal = o.subjectEnd+o.queryStart == m.subjectEnd+m.queryStart
+		}
+		if sameDiagonal {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeMatches joins a match with the one after it on the same alignment
+func mergeMatches(first, second match, querySeq string, seqLen int) match {
+	overlap := first.queryEnd - second.queryStart + 1
+	merged := first
+	merged.queryEnd = second.queryEnd
+	merged.querySeq = querySeq[merged.queryStart : merged.queryEnd+1]
+	merged.seq = first.seq + second.seq[overlap:]
+	if first.subjectRevCompMatch {
+		merged.subjectStart = second.subjectStart
+	} else {
+		merged.subjectEnd = second.subjectEnd
+	}
+
+	merged.mismatching = 0
+	upperQuery, upperSubject := strings.ToUpper(merged.querySeq), strings.ToUpper(merged.seq)
+	for i := 0; i < len(upperQuery) && i < len(upperSubject); i++ {
+		if upperQuery[i] != upperSubject[i] {
+			merged.mismatching++
+		}
+	}
+	merged.uniqueID = merged.matchID(seqLen)
+	return merged
+}
+
+// writeLeftMarginReport writes the matches in the left margin, and what was done with them, to a TSV
+func writeLeftMarginReport(w io.Writer, report []marginMatch) error {
+	if _, err := fmt.Fprintln(w, "entry\tdatabase\tqstart\tqend\tlength\taction\treason"); err != nil {
+		return err
+	}
+	for _, r := range report {
+		m := r.match
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n", m.entry, m.db.Name, m.queryStart+1, m.queryEnd+1, m.length(), r.action, r.reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logLeftMarginReport logs how many matches in the left margin were dropped, merged and
+// moved and, with verbose logging, writes them to a temporary TSV
+func logLeftMarginReport(report []marginMatch) {
+	if len(report) == 0 {
+		return
+	}
+	actions := make(map[string]int)
+	for _, r := range report {
+		actions[r.action]++
+	}
+	rlog.Debugf("%d matches in the left margin: %s", len(report), countsString(actions))
+	if !isVerboseLogging() {
+		return
+	}
+
+	f, err := os.CreateTemp("", "left-margin-*.tsv")
+	if err != nil {
+		rlog.Debugf("failed to write the left margin matches: %v", err)
+		return
+	}
+	defer f.Close()
+	if err = writeLeftMarginReport(f, report); err != nil {
+		rlog.Debugf("failed to write the left margin matches: %v", err)
+		return
+	}
+	rlog.Debugf("wrote %d left margin matches to %s", len(report), f.Name())
+}
@@ -0,0 +1,99 @@
+package repp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_applyLeftMargin(t *testing.T) {
+	seq := "ACGTACGTTTGCAAGGCCTTAGCAGGCATCGATTCAGGCTAACCGG" // 46 bp
+	seqLen := len(seq)
+	querySeq := seq + seq
+	db := DB{Name: "igem", Path: "/dbs/igem"}
+	mk := func(entry string, queryStart, queryEnd, subjectStart int) match {
+		return match{
+			entry:        entry,
+			db:           db,
+			querySeq:     querySeq[queryStart : queryEnd+1],
+			seq:          querySeq[queryStart : queryEnd+1],
+			queryStart:   queryStart,
+			queryEnd:     queryEnd,
+			subjectStart: subjectStart,
+			subjectEnd:   subjectStart + queryEnd - queryStart,
+		}
+	}
+
+	// split by BLAST at the zero-index: 30-45 and 0-9 of the doubled target, 0-25 of the template
+	before := mk("split", 30, seqLen-1, 0)
+	after := mk("split", 0, 9, seqLen-30)
+	// already in a longer match across the zero-index
+	spanning := mk("spanning", 40, seqLen+20, 100)
+	contained := mk("spanning", 5, 15, 100+seqLen-40+5)
+	// of an entry without a match past the zero-index
+	lone := mk("lone", 2, 12, 7)
+
+	matches := []match{before, after, spanning, contained, lone}
+
+	t.Run("merge", func(t *testing.T) {
+		kept, report := applyLeftMargin(matches, seq, 20, leftMarginMerge)
+		if len(kept) != 3 {
+			t.Fatalf("applyLeftMargin() kept %d matches, want 3: %+v", len(kept), kept)
+		}
+		if m := kept[0]; m.queryStart != 30 || m.queryEnd != seqLen+9 || m.subjectEnd != seqLen-30+9 || m.mismatching != 0 {
+			t.Errorf("merged match = %d-%d (subject end %d), want 30-%d", m.queryStart, m.queryEnd, m.subjectEnd, seqLen+9)
+		}
+		if m := kept[2]; m.queryStart != seqLen+2 || m.queryEnd != seqLen+12 {
+			t.Errorf("moved match = %d-%d, want %d-%d", m.queryStart, m.queryEnd, seqLen+2, seqLen+12)
+		}
+
+		actions := make(map[string]string)
+		for _, r := range report {
+			actions[r.match.entry] = r.action
+		}
+		want := map[string]string{"split": "merged", "spanning": "dropped", "lone": "moved"}
+		for entry, action := range want {
+			if actions[entry] != action {
+				t.Errorf("applyLeftMargin() %s match was %q, want %q", entry, actions[entry], action)
+			}
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		kept, report := applyLeftMargin(matches, seq, 20, leftMarginDrop)
+		if len(kept) != 2 || len(report) != 3 {
+			t.Fatalf("applyLeftMargin() kept %d and reported %d, want 2 and 3", len(kept), len(report))
+		}
+		for _, r := range report {
+			if r.action != "dropped" {
+				t.Errorf("applyLeftMargin() %s match was %q, want dropped", r.match.entry, r.action)
+			}
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		_, report := applyLeftMargin(matches, seq, 20, leftMarginMerge)
+		var out bytes.Buffer
+		if err := writeLeftMarginReport(&out, report); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 4 || !strings.HasPrefix(lines[0], "entry\tdatabase") {
+			t.Fatalf("writeLeftMarginReport() = %q, want the header and 3 matches", out.String())
+		}
+		if !strings.HasPrefix(lines[1], "split\tigem\t1\t10\t10\tmerged\t") {
+			t.Errorf("writeLeftMarginReport() first row = %q", lines[1])
+		}
+	})
+}
+
+func Test_parseLeftMarginHandling(t *testing.T) {
+	for _, h := range []string{leftMarginMerge, leftMarginDrop} {
+		if got, err := parseLeftMarginHandling(h); err != nil || got != h {
+			t.Errorf("parseLeftMarginHandling(%q) = %q, %v", h, got, err)
+		}
+	}
+	if _, err := parseLeftMarginHandling("keep"); err == nil {
+		t.Error("parseLeftMarginHandling(keep) expected an error")
+	}
+}
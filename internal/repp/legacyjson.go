@@ -0,0 +1,67 @@
+package repp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// legacyURLs are the URLs of the entries of the databases that outputs before the
+// schema version (v0.x) linked each fragment to, by the database's name
+var legacyURLs = map[string]string{
+	"addgene": "https://www.addgene.org/%s/",
+	"igem":    "http://parts.igem.org/Part:%s",
+	"dnasu":   "https://dnasu.org/DNASU/GetCloneDetail.do?cloneid=%s",
+}
+
+// legacyOutput is an output with the fields of v0.x outputs alongside the current ones
+type legacyOutput struct {
+	*Output
+
+	Solutions []legacySolution `json:"solutions"`
+}
+
+// legacySolution is a solution with the legacy fields of its fragments
+type legacySolution struct {
+	Solution
+
+	Fragments []legacyFrag `json:"fragments"`
+}
+
+// legacyFrag is a fragment with the URL of its template's database entry, empty if
+// it's not from one of the legacyURLs databases
+type legacyFrag struct {
+	*Frag
+
+	URL string `json:"url,omitempty"`
+}
+
+// legacyFragURL returns the URL of a fragment's database entry, as v0.x outputs had
+func legacyFragURL(f *Frag) string {
+	format, ok := legacyURLs[strings.ToLower(f.db.Name)]
+	if !ok || f.ID == "" || f.fragType == synthetic {
+		return ""
+	}
+	return fmt.Sprintf(format, f.ID)
+}
+
+// writeLegacyJSON writes the output as JSON with the fields that v0.x readers expect
+// alongside the current ones, so downstream code can move to the current fields over time
+func writeLegacyJSON(filename string, out *Output) error {
+	legacy := legacyOutput{Output: out, Solutions: make([]legacySolution, len(out.Solutions))}
+	for i, s := range out.Solutions {
+		legacy.Solutions[i] = legacySolution{Solution: s, Fragments: make([]legacyFrag, len(s.Fragments))}
+		for j, f := range s.Fragments {
+			legacy.Solutions[i].Fragments[j] = legacyFrag{Frag: f, URL: legacyFragURL(f)}
+		}
+	}
+
+	contents, err := json.MarshalIndent(legacy, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize output: %v", err)
+	}
+	if err = writeFileAtomic(filename, contents); err != nil {
+		return fmt.Errorf("failed to write the output: %v", err)
+	}
+	return nil
+}
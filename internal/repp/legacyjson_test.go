@@ -0,0 +1,61 @@
+package repp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_writeLegacyJSON(t *testing.T) {
+	out := &Output{
+		SchemaVersion: "1.0.0",
+		Target:        "target",
+		Solutions: []Solution{{
+			Count: 3,
+			Fragments: []*Frag{
+				{ID: "103998", Type: "pcr", fragType: pcr, db: DB{Name: "addgene"}},
+				{ID: "pLAB", Type: "pcr", fragType: pcr, db: DB{Name: "lab"}},
+				{ID: "target-synthesis-1", Type: "synthetic", fragType: synthetic, db: DB{Name: "addgene"}},
+			},
+		}},
+	}
+
+	filename := filepath.Join(t.TempDir(), "out.json")
+	if err := writeLegacyJSON(filename, out); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var legacy struct {
+		SchemaVersion string `json:"schemaVersion"`
+		Solutions     []struct {
+			Count     int `json:"count"`
+			Fragments []struct {
+				ID   string `json:"id"`
+				Type string `json:"type"`
+				URL  string `json:"url"`
+			} `json:"fragments"`
+		} `json:"solutions"`
+	}
+	if err = json.Unmarshal(contents, &legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	// the current fields are kept
+	if legacy.SchemaVersion != "1.0.0" || len(legacy.Solutions) != 1 || legacy.Solutions[0].Count != 3 {
+		t.Fatalf("writeLegacyJSON() = %s, want the current fields", contents)
+	}
+	frags := legacy.Solutions[0].Fragments
+	if len(frags) != 3 || frags[0].ID != "103998" || frags[0].Type != "pcr" {
+		t.Fatalf("writeLegacyJSON() fragments = %+v", frags)
+	}
+	for i, want := range []string{"https://www.addgene.org/103998/", "", ""} {
+		if frags[i].URL != want {
+			t.Errorf("writeLegacyJSON() fragment %d url = %q, want %q", i, frags[i].URL, want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package repp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+	"github.com/Lattice-Automation/repp/pkg/output"
+)
+
+// ligationMethod is how an insert is joined to a backbone when not using Gibson Assembly
+type ligationMethod string
+
+const (
+	// stickyEnds is the ligation of an insert PCR'ed with the backbone's restriction sites
+	stickyEnds ligationMethod = "sticky"
+
+	// bluntEnds is the ligation of a blunt insert into a backbone cut with blunt cutters
+	bluntEnds ligationMethod = "blunt"
+
+	// topo is directional TOPO cloning into a linearized, TOPO activated, backbone
+	topo ligationMethod = "topo"
+)
+
+const (
+	// ligationSitePad is added 5' of the restriction sites in the insert's primers
+	// so the enzymes can bind and cut close to the end of the PCR product
+	ligationSitePad = "GCGCGC"
+
+	// topoOverhang is added to the fwd primer for directional TOPO cloning.
+	// It anneals to the GTGG overhang of the TOPO vector
+	topoOverhang = "CACC"
+)
+
+// Ligate plans the cloning of an insert into a backbone by ligation rather than Gibson Assembly.
+//
+// The insert is the only fragment that's prepared, by PCR with primers that carry the
+// restriction sites of the digested backbone (sticky ends), no additional bp (blunt ends),
+// or the CACC overhang of directional TOPO vectors. No homology arms are added.
+func Ligate(assemblyParams AssemblyParams, method string, conf *config.Config) {
+	start := time.Now()
+
+	lm := ligationMethod(strings.ToLower(method))
+	if lm != stickyEnds && lm != bluntEnds && lm != topo {
+		rlog.Fatalf("unknown ligation method %s; valid values [sticky, blunt, topo]", method)
+	}
+
+	if assemblyParams.GetBackboneName() == "" {
+		rlog.Fatal("a backbone is required for ligation")
+	}
+
+	inserts, err := read(assemblyParams.GetIn(), false, false)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if len(inserts) > 1 {
+		rlog.Warnf("%d sequences were in %s. Only ligating the first: %s", len(inserts), assemblyParams.GetIn(), inserts[0].ID)
+	}
+	insert := inserts[0]
+	insert.Seq = strings.ToUpper(insert.Seq)
+	setRunTarget(len(insert.Seq))
+
+	// get registered blast databases
+	dbs, err := assemblyParams.getDBs()
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	// get registered enzymes
+	enzymes, err := assemblyParams.getEnzymes()
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if lm == topo && len(enzymes) > 0 {
+		rlog.Warnf("enzymes are ignored for TOPO cloning, the backbone is expected to be linearized")
+		enzymes = nil
+	}
+	if lm == stickyEnds && len(enzymes) == 0 {
+		rlog.Fatal("sticky end ligation needs enzymes to digest the backbone with")
+	}
+
+	backboneFrag, backboneMeta, err := prepareBackbone(assemblyParams.GetBackboneName(), enzymes, dbs, conf.Strict)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	startEnzyme, endEnzyme := backboneEndEnzymes(backboneMeta, enzymes)
+
+	warnings, err := validateLigation(lm, insert.Seq, startEnzyme, endEnzyme, enzymes)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	for _, w := range warnings {
+		rlog.Warn(w)
+	}
+
+	insertFrag, err := ligationInsert(insert, conf)
+	if err != nil {
+		rlog.Fatalf("failed to create primers for %s: %v", insert.ID, err)
+	}
+	addLigationTails(insertFrag, lm, startEnzyme, endEnzyme)
+	product := backboneFrag.Seq + ligatedInsertSeq(insertFrag.PCRSeq, lm, startEnzyme, endEnzyme)
+
+	reactionCost := conf.LigationCost
+	if lm == topo {
+		reactionCost = conf.TopoCloningCost
+	}
+	solution := ligationSolution(backboneFrag, insertFrag, reactionCost, conf)
+	solution.Method = string(lm)
+	solution.Warnings = append(warnings, backboneMeta.warnings...)
+	solutions := []Solution{solution}
+	addScreening(solutions, product, backboneMeta)
+
+	if backboneMeta.Seq == "" {
+		backboneMeta = nil
+	}
+	setRunStage(outputStage)
+	out := &Output{
+		SchemaVersion: output.SchemaVersion,
+		Time:          outputTime(time.Now()),
+		Target:        insert.ID,
+		TargetSeq:     product,
+		Execution:     time.Since(start).Seconds(),
+		Currency:      conf.Currency,
+		Solutions:     solutions,
+		Backbone:      backboneMeta,
+	}
+	out.Metadata = newRunMetadata(out, dbs, conf)
+
+	format := assemblyParams.GetOutputFormat()
+	if format == "CSV" || format == "XLSX" {
+		primersDB, synthFragsDB, oligosErr := readOligoDBs(assemblyParams.GetPrimersDBLocations(), assemblyParams.GetSynthFragsDBLocations(), conf)
+		if oligosErr != nil {
+			rlog.Fatal(oligosErr)
+		}
+		fragIDs, idErr := newFragIDNamer(conf.FragmentIDTemplate, conf.Project, insert.ID, fragmentBase(assemblyParams.GetOut()), synthFragsDB)
+		if idErr != nil {
+			rlog.Fatal(idErr)
+		}
+		names, namesErr := newNamingMap(conf.NamingMap)
+		if namesErr != nil {
+			rlog.Fatal(namesErr)
+		}
+		if format == "CSV" {
+			err = writeCSV(assemblyParams.GetOut(), fragIDs, names, primersDB, synthFragsDB, conf.IncludeFragLocationInStrategyOutput, conf.Strict, out)
+		} else {
+			err = writeXLSXOutput(assemblyParams.GetOut(), fragIDs, names, primersDB, synthFragsDB, conf.IncludeFragLocationInStrategyOutput, conf.Strict, out)
+		}
+		if err == nil {
+			err = writeMetadata(metadataFilename(assemblyParams.GetOut()), out.Metadata)
+		}
+	} else if format == "TSV-KV" {
+		err = writeTSVKV(assemblyParams.GetOut(), out)
+	} else if format == "JSON-V1" {
+		err = writeLegacyJSON(assemblyParams.GetOut(), out)
+	} else {
+		err = writeJSON(assemblyParams.GetOut(), out)
+	}
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	reportOutput(assemblyParams.GetOut(), out)
+}
+
+// backboneEndEnzymes returns the enzymes that cut the start and end of a digested backbone.
+// Both are the same if the backbone was cut once, and empty if it was not digested.
+func backboneEndEnzymes(backbone *Backbone, enzymes []enzyme) (startEnzyme, endEnzyme enzyme) {
+	byName := make(map[string]enzyme)
+	for _, e := range enzymes {
+		byName[e.name] = e
+	}
+
+	if backbone == nil || len(backbone.Enzymes) == 0 {
+		return enzyme{}, enzyme{}
+	}
+	startEnzyme = byName[backbone.Enzymes[0]]
+	endEnzyme = byName[backbone.Enzymes[len(backbone.Enzymes)-1]]
+	return startEnzyme, endEnzyme
+}
+
+// overhang returns the single stranded overhang left by the enzyme. It's empty for blunt cutters.
+func (e enzyme) overhang() string {
+	from, to := e.seqCutIndex, e.compCutIndex
+	if from > to {
+		from, to = to, from
+	}
+	if from < 0 || to > len(e.recog) {
+		return ""
+	}
+	return e.recog[from:to]
+}
+
+// validateLigation checks whether an insert can be ligated into a backbone digested
+// by startEnzyme and endEnzyme. It returns warnings for designs that will work
+// but need extra screening, like non-directional ligations.
+func validateLigation(lm ligationMethod, insert string, startEnzyme, endEnzyme enzyme, enzymes []enzyme) (warnings []string, err error) {
+	if lm == topo {
+		if strings.HasSuffix(insert, reverseComplement(topoOverhang)) {
+			warnings = append(warnings, fmt.Sprintf(
+				"the insert ends with %s, so its reverse complement also starts with %s: it may be cloned in either orientation",
+				reverseComplement(topoOverhang), topoOverhang))
+		}
+		return warnings, nil
+	}
+
+	// the insert is digested with the same enzymes as the backbone
+	for _, e := range enzymes {
+		site := regexp.MustCompile(recogRegex(e.recog))
+		if site.MatchString(insert) || site.MatchString(reverseComplement(insert)) {
+			return nil, fmt.Errorf("the insert has an internal %s site and would be cut during digestion", e.name)
+		}
+	}
+
+	switch lm {
+	case stickyEnds:
+		if startEnzyme.overhang() == "" && endEnzyme.overhang() == "" {
+			return nil, fmt.Errorf("%s and %s leave blunt ends, use blunt end ligation", startEnzyme.name, endEnzyme.name)
+		}
+	case bluntEnds:
+		for _, e := range []enzyme{startEnzyme, endEnzyme} {
+			if e.overhang() != "" {
+				return nil, fmt.Errorf("%s leaves a %s overhang, use sticky end ligation", e.name, e.overhang())
+			}
+		}
+	}
+
+	// the insert can flip if the backbone's ends are compatible with one another
+	if startEnzyme.overhang() == endEnzyme.overhang() {
+		warnings = append(warnings,
+			"the ligation is non-directional: the insert may be ligated in either orientation, screen colonies for orientation")
+	}
+
+	return warnings, nil
+}
+
+// ligationInsert creates the PCR fragment of an insert for ligation. The primers
+// span the whole insert without any additional bp for homology.
+func ligationInsert(insert *Frag, conf *config.Config) (*Frag, error) {
+	f := &Frag{
+		ID:         insert.ID,
+		uniqueID:   insert.ID + "-ligation",
+		Seq:        insert.Seq,
+		fullSeq:    insert.Seq,
+		fragType:   pcr,
+		start:      0,
+		end:        len(insert.Seq) - 1,
+		matchRatio: 1,
+		conf:       conf,
+	}
+
+	// neighbors that already share the minimum homology with the insert,
+	// so primer3 neither adds bp to the primers nor moves them inward
+	prev := &Frag{end: f.start + conf.FragmentsMinHomology, conf: conf}
+	next := &Frag{start: f.end - conf.FragmentsMinHomology, conf: conf}
+	if err := f.setPrimers(prev, next, insert.Seq, nil, conf); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// ligationTails returns the bp to add to the 5' ends of the insert's fwd and rev primers
+func ligationTails(lm ligationMethod, startEnzyme, endEnzyme enzyme) (fwdTail, revTail string) {
+	switch lm {
+	case stickyEnds:
+		// the insert's start ligates to the backbone's end and vice versa
+		fwdTail = ligationSitePad + concreteSite(endEnzyme.recog)
+		revTail = ligationSitePad + reverseComplement(concreteSite(startEnzyme.recog))
+	case topo:
+		fwdTail = topoOverhang
+	}
+	return fwdTail, revTail
+}
+
+// addLigationTails adds the ligation tails to the insert's primers and PCR sequence
+func addLigationTails(f *Frag, lm ligationMethod, startEnzyme, endEnzyme enzyme) {
+	fwdTail, revTail := ligationTails(lm, startEnzyme, endEnzyme)
+	if len(f.Primers) > 1 {
+		f.Primers[0].Seq = fwdTail + f.Primers[0].Seq
+		f.Primers[1].Seq = revTail + f.Primers[1].Seq
+	}
+	f.PCRSeq = fwdTail + f.PCRSeq + reverseComplement(revTail)
+}
+
+// ligatedInsertSeq returns the top strand of the PCR'ed insert as it's ligated into the backbone.
+// For sticky ends, that's the PCR product after it's digested.
+func ligatedInsertSeq(pcrSeq string, lm ligationMethod, startEnzyme, endEnzyme enzyme) string {
+	if lm != stickyEnds {
+		return pcrSeq
+	}
+
+	left := len(ligationSitePad) + endEnzyme.seqCutIndex
+	right := len(pcrSeq) - len(ligationSitePad) - startEnzyme.compCutIndex
+	if left < 0 || right > len(pcrSeq) || left >= right {
+		return pcrSeq
+	}
+	return pcrSeq[left:right]
+}
+
+// concreteSite replaces degenerate bases in a recognition sequence with the first base they stand for
+func concreteSite(recog string) string {
+	var site strings.Builder
+	for _, c := range recog {
+		switch c {
+		case 'M', 'R', 'W', 'H', 'D', 'V', 'N', 'X':
+			site.WriteRune('A')
+		case 'Y', 'S', 'B':
+			site.WriteRune('C')
+		case 'K':
+			site.WriteRune('G')
+		default:
+			site.WriteRune(c)
+		}
+	}
+	return site.String()
+}
+
+// ligationSolution returns the solution for ligating the insert into the backbone.
+// Unlike Gibson solutions, its cost includes a ligation (or TOPO) reaction.
+func ligationSolution(backbone, insert *Frag, reactionCost float64, conf *config.Config) Solution {
+	cost, adjustedCost := reactionCost, reactionCost
+	breakdown := CostBreakdown{AssemblyReaction: reactionCost}
+	purchases := vendorPurchases{}
+	for _, f := range []*Frag{backbone, insert} {
+		fragCost, fragAdjustedCost := f.cost(true)
+		breakdown.add(purchases.add(f, f.costBreakdown(true)))
+		f.Cost = roundCost(fragCost)
+		f.AdjustedCost = roundCost(fragAdjustedCost)
+		f.Type = f.fragType.String()
+		cost += f.Cost
+		adjustedCost += f.AdjustedCost
+	}
+
+	s := Solution{
+		Count:         2,
+		Cost:          roundCost(cost),
+		AdjustedCost:  roundCost(adjustedCost),
+		CostBreakdown: breakdown.rounded(),
+		Fragments:     []*Frag{backbone, insert},
+		pcrFragsCount: 1,
+	}
+	applyPurchaseTerms(&s, purchases, conf)
+	return s
+}
@@ -0,0 +1,154 @@
+package repp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_enzyme_overhang(t *testing.T) {
+	tests := []struct {
+		name string
+		e    enzyme
+		want string
+	}{
+		{"5' overhang", newEnzyme("EcoRI", "G^AATT_C"), "AATT"},
+		{"3' overhang", newEnzyme("PstI", "C_TGCA^G"), "TGCA"},
+		{"blunt", newEnzyme("EcoRV", "GAT^_ATC"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.overhang(); got != tt.want {
+				t.Errorf("enzyme.overhang() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateLigation(t *testing.T) {
+	ecoRI := newEnzyme("EcoRI", "G^AATT_C")
+	pstI := newEnzyme("PstI", "C_TGCA^G")
+	ecoRV := newEnzyme("EcoRV", "GAT^_ATC")
+	insert := "GGTCGACTCTAGAGGATCCCCGGGTACC"
+
+	type args struct {
+		lm          ligationMethod
+		insert      string
+		startEnzyme enzyme
+		endEnzyme   enzyme
+		enzymes     []enzyme
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantWarnings int
+		wantErr      bool
+	}{
+		{
+			"directional sticky ends",
+			args{stickyEnds, insert, ecoRI, pstI, []enzyme{ecoRI, pstI}},
+			0,
+			false,
+		},
+		{
+			"single enzyme is non-directional",
+			args{stickyEnds, insert, ecoRI, ecoRI, []enzyme{ecoRI}},
+			1,
+			false,
+		},
+		{
+			"internal site",
+			args{stickyEnds, "AAAGAATTCAAA", ecoRI, pstI, []enzyme{ecoRI, pstI}},
+			0,
+			true,
+		},
+		{
+			"blunt enzymes for sticky ends",
+			args{stickyEnds, insert, ecoRV, ecoRV, []enzyme{ecoRV}},
+			0,
+			true,
+		},
+		{
+			"blunt ends are non-directional",
+			args{bluntEnds, insert, ecoRV, ecoRV, []enzyme{ecoRV}},
+			1,
+			false,
+		},
+		{
+			"sticky enzyme for blunt ends",
+			args{bluntEnds, insert, ecoRI, ecoRV, []enzyme{ecoRI, ecoRV}},
+			0,
+			true,
+		},
+		{
+			"directional topo",
+			args{topo, insert, enzyme{}, enzyme{}, nil},
+			0,
+			false,
+		},
+		{
+			"topo insert ending in GGTG",
+			args{topo, insert + "GGTG", enzyme{}, enzyme{}, nil},
+			1,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotWarnings, err := validateLigation(tt.args.lm, tt.args.insert, tt.args.startEnzyme, tt.args.endEnzyme, tt.args.enzymes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateLigation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(gotWarnings) != tt.wantWarnings {
+				t.Errorf("validateLigation() warnings = %v, want %d", gotWarnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func Test_ligatedInsertSeq(t *testing.T) {
+	ecoRI := newEnzyme("EcoRI", "G^AATT_C")
+	pstI := newEnzyme("PstI", "C_TGCA^G")
+	insert := "ATGCATGCATGC"
+
+	tests := []struct {
+		name        string
+		lm          ligationMethod
+		startEnzyme enzyme
+		endEnzyme   enzyme
+		wantTails   []string
+		want        string
+	}{
+		{
+			// backbone ---CTGCA ... G---(PstI at its start, EcoRI at its end)
+			"sticky ends",
+			stickyEnds,
+			pstI,
+			ecoRI,
+			[]string{"GCGCGCGAATTC", "GCGCGCCTGCAG"},
+			"AATTC" + insert + "CTGCA",
+		},
+		{
+			"topo",
+			topo,
+			enzyme{},
+			enzyme{},
+			[]string{"CACC", ""},
+			"CACC" + insert,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fwdTail, revTail := ligationTails(tt.lm, tt.startEnzyme, tt.endEnzyme)
+			if got := []string{fwdTail, revTail}; !reflect.DeepEqual(got, tt.wantTails) {
+				t.Errorf("ligationTails() = %v, want %v", got, tt.wantTails)
+			}
+
+			f := &Frag{PCRSeq: insert}
+			addLigationTails(f, tt.lm, tt.startEnzyme, tt.endEnzyme)
+			if got := ligatedInsertSeq(f.PCRSeq, tt.lm, tt.startEnzyme, tt.endEnzyme); got != tt.want {
+				t.Errorf("ligatedInsertSeq() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
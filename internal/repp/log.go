@@ -1,8 +1,10 @@
 package repp
 
 import (
+	"fmt"
 	"os"
 
+	"github.com/Lattice-Automation/repp/internal/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -11,11 +13,16 @@ var (
 	// logLevel is a configurable log level
 	verboseLogging bool
 
+	// quietLogging only logs errors, for embedding repp in pipelines
+	quietLogging bool
+
 	logLevel = zap.LevelEnablerFunc(func(level zapcore.Level) bool {
 
 		// true: log message at this level
 		// false: skip message at this level
-		if verboseLogging {
+		if quietLogging {
+			return level >= zapcore.ErrorLevel
+		} else if verboseLogging {
 			return level >= zapcore.DebugLevel
 		} else {
 			return level >= zapcore.InfoLevel
@@ -42,3 +49,44 @@ func SetVerboseLogging() {
 func isVerboseLogging() bool {
 	return verboseLogging
 }
+
+// SetQuietLogging only logs errors, including those of the settings
+func SetQuietLogging() {
+	quietLogging = true
+	config.SetQuietLogging()
+}
+
+// IsQuietLogging returns whether only errors are logged
+func IsQuietLogging() bool {
+	return quietLogging
+}
+
+// runEndHook reports a run that failed with a fatal error, to the notification hooks, the
+// metrics log and the history, before exiting
+type runEndHook struct{}
+
+// OnWrite reports the failed run and exits
+func (runEndHook) OnWrite(ce *zapcore.CheckedEntry, _ []zapcore.Field) {
+	recordFailed()
+	historyFailed(ce.Message)
+	notifyFailed(ce.Message)
+	os.Exit(1)
+}
+
+// setRunEndHook reports fatal errors to the notification hooks, metrics log and history before exiting
+func setRunEndHook() {
+	rlog = l.WithOptions(zap.WithFatalHook(runEndHook{})).Sugar()
+}
+
+// reportOutput reports the file an output was written to, and notifies the hooks. In quiet
+// mode the path is the only thing written to stdout, for the calling pipeline to read.
+func reportOutput(filename string, out *Output) {
+	defer notifySucceeded(filename, out)
+	defer recordSucceeded(out)
+	defer historySucceeded(filename, out)
+	if quietLogging {
+		fmt.Println(filename)
+		return
+	}
+	rlog.Infof("Wrote the output to %s", filename)
+}
@@ -0,0 +1,35 @@
+package repp
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_logLevel(t *testing.T) {
+	defer func(verbose, quiet bool) {
+		verboseLogging, quietLogging = verbose, quiet
+	}(verboseLogging, quietLogging)
+
+	tests := []struct {
+		name    string
+		verbose bool
+		quiet   bool
+		level   zapcore.Level
+		want    bool
+	}{
+		{"info by default", false, false, zapcore.InfoLevel, true},
+		{"no debug by default", false, false, zapcore.DebugLevel, false},
+		{"debug when verbose", true, false, zapcore.DebugLevel, true},
+		{"no warnings when quiet", false, true, zapcore.WarnLevel, false},
+		{"errors when quiet", false, true, zapcore.ErrorLevel, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verboseLogging, quietLogging = tt.verbose, tt.quiet
+			if got := logLevel.Enabled(tt.level); got != tt.want {
+				t.Errorf("logLevel.Enabled(%v) = %v, want %v", tt.level, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package repp
+
+import (
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// maxPCRLength returns the longest PCR product that can be amplified, by long-range
+// PCR if it's used. It's 0 if there's no limit.
+func maxPCRLength(conf *config.Config) int {
+	if conf.PcrMaxFragLength <= 0 {
+		return 0
+	}
+	if conf.PcrLongRangeMaxFragLength > conf.PcrMaxFragLength {
+		return conf.PcrLongRangeMaxFragLength
+	}
+	return conf.PcrMaxFragLength
+}
+
+// pcrLength returns the length of a Frag's PCR product, estimated from its range
+// before its primers are made
+func (f *Frag) pcrLength() int {
+	if f.PCRSeq != "" {
+		return len(f.PCRSeq)
+	}
+	return f.end - f.start + 1
+}
+
+// longRange returns whether a Frag's PCR product is too long for a standard PCR
+func (f *Frag) longRange() bool {
+	return f.fragType == pcr && f.conf != nil && f.conf.PcrMaxFragLength > 0 && f.pcrLength() > f.conf.PcrMaxFragLength
+}
+
+// amplifiableMatches drops the matches that are too long to PCR. Matches that span the
+// whole target are kept, they're used as they are.
+func amplifiableMatches(matches []match, targetLength int, conf *config.Config) (kept []match) {
+	maxLength := maxPCRLength(conf)
+	if maxLength == 0 {
+		return matches
+	}
+
+	dropped := 0
+	for _, m := range matches {
+		if length := m.queryEnd - m.queryStart + 1; length > maxLength && length < targetLength {
+			dropped++
+			continue
+		}
+		kept = append(kept, m)
+	}
+	if dropped > 0 {
+		rlog.Debugf("dropped %d matches longer than the %dbp PCR limit", dropped, maxLength)
+	}
+	return kept
+}
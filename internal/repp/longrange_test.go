@@ -0,0 +1,79 @@
+package repp
+
+import (
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_amplifiableMatches(t *testing.T) {
+	matches := []match{
+		{entry: "standard", queryStart: 0, queryEnd: 9999},
+		{entry: "long-range", queryStart: 0, queryEnd: 24999},
+		{entry: "too long", queryStart: 0, queryEnd: 34999},
+		{entry: "whole target", queryStart: 0, queryEnd: 39999},
+	}
+
+	tests := []struct {
+		name      string
+		maxLength int
+		longRange int
+		want      []string
+	}{
+		{"no limit", 0, 30000, []string{"standard", "long-range", "too long", "whole target"}},
+		{"long-range PCR", 10000, 30000, []string{"standard", "long-range", "whole target"}},
+		{"standard PCR only", 10000, 0, []string{"standard", "whole target"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.New()
+			c.PcrMaxFragLength = tt.maxLength
+			c.PcrLongRangeMaxFragLength = tt.longRange
+
+			var got []string
+			for _, m := range amplifiableMatches(matches, 40000, c) {
+				got = append(got, m.entry)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("amplifiableMatches() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("amplifiableMatches() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_Frag_cost_longRange(t *testing.T) {
+	c := config.New()
+	c.PcrBpCost = 0
+	c.PcrRxnCost = 0.5
+	c.PcrMaxFragLength = 10000
+	c.PcrLongRangeRxnCost = 2
+	c.PcrLongRangeSuccessRate = 0.5
+
+	tests := []struct {
+		name             string
+		length           int
+		wantLongRange    bool
+		wantCost         float64
+		wantAdjustedCost float64
+	}{
+		{"standard PCR", 5000, false, 0.5, 0.5},
+		{"long-range PCR, expecting a repeat", 20000, true, 2, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Frag{fragType: pcr, start: 0, end: tt.length - 1, conf: c}
+			if got := f.longRange(); got != tt.wantLongRange {
+				t.Errorf("longRange() = %v, want %v", got, tt.wantLongRange)
+			}
+			cost, adjustedCost := f.cost(false)
+			if cost != tt.wantCost || adjustedCost != tt.wantAdjustedCost {
+				t.Errorf("cost() = %.2f, %.2f, want %.2f, %.2f", cost, adjustedCost, tt.wantCost, tt.wantAdjustedCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package repp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	// releaseVersion is the version of repp embedded in outputs
+	releaseVersion string
+
+	// releaseCommit is the commit repp was built from
+	releaseCommit string
+)
+
+// SetBuildInfo sets the version and commit of repp embedded in the run metadata of outputs.
+func SetBuildInfo(version, commit string) {
+	releaseVersion = version
+	releaseCommit = strings.TrimSpace(commit)
+}
+
+// RunMetadata describes the run that produced an output so it can be audited and reproduced.
+type RunMetadata struct {
+	// Version of repp
+	Version string `json:"version"`
+
+	// Commit repp was built from
+	Commit string `json:"commit,omitempty"`
+
+	// CommandLine that repp was run with
+	CommandLine []string `json:"commandLine"`
+
+	// Hostname of the machine repp ran on
+	Hostname string `json:"hostname,omitempty"`
+
+	// Config is the full resolved configuration, including overrides from user config files
+	Config map[string]interface{} `json:"config"`
+
+	// Databases are the sequence databases that were searched
+	Databases []DatabaseChecksum `json:"databases,omitempty"`
+
+	// Templates are the versions of the database entries that the solutions use
+	Templates []TemplateVersion `json:"templates,omitempty"`
+
+	// PlanHash is a hash of the target and solutions. It's the same for
+	// runs that produce the same plan, regardless of when and where they ran.
+	PlanHash string `json:"planHash"`
+
+	// Commands are the counters of the external commands run, ex: blastn and primer3_core
+	Commands []CommandStats `json:"commands,omitempty"`
+}
+
+// DatabaseChecksum is the checksum of a sequence database's FASTA file.
+type DatabaseChecksum struct {
+	// Name of the database
+	Name string `json:"name"`
+
+	// Path to the database's FASTA file
+	Path string `json:"path"`
+
+	// SHA256 of the database's FASTA file
+	SHA256 string `json:"sha256"`
+}
+
+// newRunMetadata returns the metadata of the current run that produced the output.
+func newRunMetadata(out *Output, dbs []DB, conf *config.Config) *RunMetadata {
+	meta := &RunMetadata{
+		Version:     releaseVersion,
+		Commit:      releaseCommit,
+		CommandLine: os.Args,
+		Config:      map[string]interface{}{},
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		meta.Hostname = hostname
+	}
+
+	if conf != nil {
+		if err := mapstructure.Decode(conf, &meta.Config); err != nil {
+			rlog.Warnf("failed to add the config to the run metadata: %v", err)
+		}
+	}
+
+	for _, db := range dbs {
+		checksum, err := fileChecksum(db.Path)
+		if err != nil {
+			rlog.Warnf("failed to checksum database %s: %v", db.Name, err)
+		}
+		meta.Databases = append(meta.Databases, DatabaseChecksum{Name: db.Name, Path: db.Path, SHA256: checksum})
+	}
+	sort.Slice(meta.Databases, func(i, j int) bool {
+		return meta.Databases[i].Name < meta.Databases[j].Name
+	})
+
+	meta.Templates = templateVersions(out.Solutions)
+	meta.PlanHash = planHash(out)
+	meta.Commands = commands.report()
+
+	return meta
+}
+
+// fileChecksum returns the hex encoded SHA256 of a file's contents
+func fileChecksum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// planHash returns a stable hash of the output's target and solutions.
+// Timestamps, execution times and run metadata are excluded.
+func planHash(out *Output) string {
+	plan, err := json.Marshal(struct {
+		Target    string     `json:"target"`
+		TargetSeq string     `json:"seq"`
+		Solutions []Solution `json:"solutions"`
+		Backbone  *Backbone  `json:"backbone,omitempty"`
+	}{out.Target, out.TargetSeq, out.Solutions, out.Backbone})
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(plan)
+	return hex.EncodeToString(sum[:])
+}
+
+// metadataFilename returns the name of the file with the run metadata of a CSV output
+func metadataFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	return filename[0:len(filename)-len(ext)] + "-metadata.json"
+}
+
+// writeMetadata writes the run metadata of an output to its own JSON file
+func writeMetadata(filename string, meta *RunMetadata) error {
+	contents, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize run metadata: %v", err)
+	}
+	return writeFileAtomic(filename, contents)
+}
+
+// readMetadata reads the run metadata from a JSON output or from the metadata file of a CSV output.
+func readMetadata(filename string) (*RunMetadata, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	out := struct {
+		Metadata *RunMetadata `json:"metadata"`
+	}{}
+	if err = json.Unmarshal(contents, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", filename, err)
+	}
+	if out.Metadata != nil {
+		return out.Metadata, nil
+	}
+
+	meta := &RunMetadata{}
+	if err = json.Unmarshal(contents, meta); err != nil || meta.PlanHash == "" {
+		return nil, fmt.Errorf("no run metadata found in %s", filename)
+	}
+	return meta, nil
+}
+
+// Inspect prints the run metadata of a repp output: the repp version, command line,
+// database checksums, plan hash and the resolved config.
+func Inspect(filename string) {
+	meta, err := readMetadata(filename)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(w, "version\t%s\n", meta.Version)
+	fmt.Fprintf(w, "commit\t%s\n", meta.Commit)
+	fmt.Fprintf(w, "command\t%s\n", strings.Join(meta.CommandLine, " "))
+	fmt.Fprintf(w, "hostname\t%s\n", meta.Hostname)
+	fmt.Fprintf(w, "plan hash\t%s\n", meta.PlanHash)
+	w.Flush()
+
+	if len(meta.Databases) > 0 {
+		fmt.Println("\ndatabases")
+		w = tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+		for _, db := range meta.Databases {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", db.Name, db.SHA256, db.Path)
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\nconfig")
+	keys := make([]string, 0, len(meta.Config))
+	for k := range meta.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	for _, k := range keys {
+		value, err := json.Marshal(meta.Config[k])
+		if err != nil {
+			value = []byte(fmt.Sprint(meta.Config[k]))
+		}
+		fmt.Fprintf(w, "%s\t%s\n", k, value)
+	}
+	w.Flush()
+}
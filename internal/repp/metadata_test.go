@@ -0,0 +1,68 @@
+package repp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_newRunMetadata(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "db")
+	if err := os.WriteFile(dbPath, []byte(">a\nATGC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &Output{
+		Target:    "target",
+		TargetSeq: "ATGCATGC",
+		Time:      "2024/01/01 00:00:00",
+		Solutions: []Solution{{Count: 1, Fragments: []*Frag{{ID: "a", Seq: "ATGC"}}}},
+	}
+	meta := newRunMetadata(out, []DB{{Name: "db", Path: dbPath}}, config.New())
+
+	// the plan hash ignores when the plan was made
+	later := *out
+	later.Time = "2025/01/01 00:00:00"
+	later.Execution = 10
+	if got := planHash(&later); got != meta.PlanHash {
+		t.Errorf("planHash() = %v, want %v", got, meta.PlanHash)
+	}
+	changed := *out
+	changed.TargetSeq = "ATGCATGG"
+	if got := planHash(&changed); got == meta.PlanHash {
+		t.Errorf("planHash() of a different plan = %v, want a different hash", got)
+	}
+
+	wantChecksum := sha256.Sum256([]byte(">a\nATGC\n"))
+	if got := meta.Databases[0].SHA256; got != hex.EncodeToString(wantChecksum[:]) {
+		t.Errorf("newRunMetadata() database checksum = %v, want %x", got, wantChecksum)
+	}
+	if _, ok := meta.Config["fragments-min-junction-length"]; !ok {
+		t.Errorf("newRunMetadata() config is missing fragments-min-junction-length: %v", meta.Config)
+	}
+
+	// metadata can be read back from both a JSON output and a standalone metadata file
+	out.Metadata = meta
+	jsonOut := filepath.Join(dir, "out.json")
+	if err := writeJSON(jsonOut, out); err != nil {
+		t.Fatal(err)
+	}
+	metaOut := metadataFilename(filepath.Join(dir, "out.csv"))
+	if err := writeMetadata(metaOut, meta); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{jsonOut, metaOut} {
+		got, err := readMetadata(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.PlanHash != meta.PlanHash {
+			t.Errorf("readMetadata(%s) plan hash = %v, want %v", filename, got.PlanHash, meta.PlanHash)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package repp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// stages of a run, the last one a failed run reached is recorded in the metrics log
+const (
+	inputStage    = "input"
+	searchStage   = "search"
+	assemblyStage = "assembly"
+	outputStage   = "output"
+)
+
+// RunRecord is the anonymous record of a run in the local metrics log. It has no sequences,
+// names or paths, only the sizes of the run's inputs and how it ended.
+type RunRecord struct {
+	// Time the run ended
+	Time time.Time `json:"time"`
+
+	// Command that was run, ex: "make sequence"
+	Command string `json:"command"`
+
+	// Version of repp
+	Version string `json:"version,omitempty"`
+
+	// Status of the run: "succeeded" or "failed"
+	Status string `json:"status"`
+
+	// Stage the run reached: input, search, assembly or output
+	Stage string `json:"stage"`
+
+	// TargetLength is the length of the target in bp, 0 if the run failed before reading it
+	TargetLength int `json:"targetLength,omitempty"`
+
+	// Databases is the number of sequence databases searched
+	Databases int `json:"databases"`
+
+	// Solutions is the number of solutions in the output
+	Solutions int `json:"solutions"`
+
+	// Fragments is the number of fragments of the first solution
+	Fragments int `json:"fragments,omitempty"`
+
+	// Seconds the run took
+	Seconds float64 `json:"seconds"`
+}
+
+// metricsRecorder collects the record of the current run
+type metricsRecorder struct {
+	// path of the metrics log the record is appended to
+	path string
+
+	// start of the run
+	start time.Time
+
+	// record of the run so far
+	record RunRecord
+}
+
+// runMetrics is the recorder of the current run, nil unless metrics are on in the config
+var runMetrics *metricsRecorder
+
+// SetMetrics records the run of a command, ex: "make sequence", in the local metrics log when
+// it finishes or fails. Nothing is sent anywhere, see 'repp stats runs'
+func SetMetrics(command string) {
+	runMetrics = &metricsRecorder{
+		path:   config.MetricsLog,
+		start:  time.Now(),
+		record: RunRecord{Command: command, Version: releaseVersion, Stage: inputStage},
+	}
+	setRunEndHook()
+}
+
+// setRunStage sets the stage the current run reached
+func setRunStage(stage string) {
+	if runMetrics != nil {
+		runMetrics.record.Stage = stage
+	}
+}
+
+// setRunTarget sets the length of the current run's target
+func setRunTarget(length int) {
+	if runMetrics != nil {
+		runMetrics.record.TargetLength = length
+	}
+}
+
+// setRunDatabases sets the number of databases the current run searches
+func setRunDatabases(count int) {
+	if runMetrics != nil {
+		runMetrics.record.Databases = count
+	}
+}
+
+// recordSucceeded records a run that wrote its output
+func recordSucceeded(out *Output) {
+	if runMetrics == nil {
+		return
+	}
+	r := runMetrics.record
+	r.Status = "succeeded"
+	if out != nil {
+		r.Solutions = len(out.Solutions)
+		if len(out.Solutions) > 0 {
+			r.Fragments = out.Solutions[0].Count
+		}
+	}
+	runMetrics.write(r)
+}
+
+// recordFailed records a run that failed with a fatal error
+func recordFailed() {
+	if runMetrics == nil {
+		return
+	}
+	r := runMetrics.record
+	r.Status = "failed"
+	runMetrics.write(r)
+}
+
+// write appends the record to the metrics log. Failures are logged, they don't fail the run
+func (m *metricsRecorder) write(r RunRecord) {
+	r.Time = time.Now().UTC()
+	r.Seconds = roundSeconds(time.Since(m.start).Seconds())
+	line, err := json.Marshal(r)
+	if err != nil {
+		rlog.Warnf("failed to encode the run record: %v", err)
+		return
+	}
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		rlog.Warnf("failed to open the metrics log %s: %v", m.path, err)
+		return
+	}
+	defer f.Close()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		rlog.Warnf("failed to write the metrics log %s: %v", m.path, err)
+	}
+}
+
+// readRunRecords reads the records of a metrics log. Unparseable lines, ex: of a run
+// that was killed while writing, are skipped
+func readRunRecords(r io.Reader) (records []RunRecord, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var record RunRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// RunStats prints a summary of the runs in the local metrics log: how many succeeded, the
+// stages the others failed at and the distributions of their targets' lengths and wall times.
+// With asJSON the records themselves are printed, to export them.
+func RunStats(asJSON bool) {
+	f, err := os.Open(config.MetricsLog)
+	if os.IsNotExist(err) {
+		rlog.Fatalf("no runs in %s, set metrics: true in the config to record them", config.MetricsLog)
+	} else if err != nil {
+		rlog.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := readRunRecords(f)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if asJSON {
+		contents, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			rlog.Fatal(err)
+		}
+		fmt.Println(string(contents))
+		return
+	}
+	if err = printRunStats(os.Stdout, records); err != nil {
+		rlog.Fatal(err)
+	}
+}
+
+// printRunStats writes the summary of the records of a metrics log
+func printRunStats(out io.Writer, records []RunRecord) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(out, "no runs recorded")
+		return err
+	}
+
+	commands := make(map[string]int)
+	failedStages := make(map[string]int)
+	var lengths, seconds, databases, fragments []float64
+	succeeded := 0
+	for _, r := range records {
+		commands[r.Command]++
+		if r.Status == "succeeded" {
+			succeeded++
+			fragments = append(fragments, float64(r.Fragments))
+		} else {
+			failedStages[r.Stage]++
+		}
+		if r.TargetLength > 0 {
+			lengths = append(lengths, float64(r.TargetLength))
+		}
+		seconds = append(seconds, r.Seconds)
+		databases = append(databases, float64(r.Databases))
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(w, "runs\t%d\t%s to %s\n", len(records), records[0].Time.Format("2006-01-02"), records[len(records)-1].Time.Format("2006-01-02"))
+	fmt.Fprintf(w, "succeeded\t%d\t%.1f%%\n", succeeded, 100*float64(succeeded)/float64(len(records)))
+	fmt.Fprintf(w, "failed\t%d\t%s\n", len(records)-succeeded, countsString(failedStages))
+	fmt.Fprintf(w, "commands\t%s\n", countsString(commands))
+	for _, d := range []struct {
+		name   string
+		values []float64
+	}{
+		{"target length (bp)", lengths},
+		{"databases", databases},
+		{"fragments", fragments},
+		{"wall time (s)", seconds},
+	} {
+		dist := newDistribution(d.values)
+		fmt.Fprintf(w, "%s\tmin %.0f\tmedian %.0f\tmean %.1f\tmax %.0f\n", d.name, dist.Min, dist.Median, dist.Mean, dist.Max)
+	}
+	return w.Flush()
+}
+
+// countsString returns counts by name, most first, ex: "search 3, input 1"
+func countsString(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s %d", name, counts[name]))
+	}
+	return strings.Join(parts, ", ")
+}
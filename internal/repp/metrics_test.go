@@ -0,0 +1,50 @@
+package repp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_metricsRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.jsonl")
+	runMetrics = &metricsRecorder{path: path, start: time.Now(), record: RunRecord{Command: "make sequence", Stage: inputStage}}
+	defer func() { runMetrics = nil }()
+
+	setRunDatabases(2)
+	setRunTarget(5000)
+	setRunStage(searchStage)
+	recordFailed()
+
+	setRunStage(outputStage)
+	recordSucceeded(&Output{Solutions: []Solution{{Count: 3}, {Count: 4}}})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := readRunRecords(f)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("readRunRecords() = %+v, %v, want 2 records", records, err)
+	}
+	if r := records[0]; r.Status != "failed" || r.Stage != searchStage || r.TargetLength != 5000 || r.Databases != 2 {
+		t.Errorf("failed run record = %+v", r)
+	}
+	if r := records[1]; r.Status != "succeeded" || r.Solutions != 2 || r.Fragments != 3 {
+		t.Errorf("succeeded run record = %+v", r)
+	}
+
+	var out bytes.Buffer
+	if err = printRunStats(&out, records); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"50.0%", "search 1", "make sequence 2", "max 5000"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("printRunStats() is missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package repp
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// nameRule renames the names that its pattern matches all of
+type nameRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// namingMap renames template IDs and reagent names in the outputs to a lab's
+// conventions, ex: "gnl|addgene|107006" to "pAB107006"
+type namingMap []nameRule
+
+// newNamingMap returns the naming map of the naming-map setting. It fails if a pattern isn't
+// a valid regular expression
+func newNamingMap(mappings []config.NameMapping) (namingMap, error) {
+	var m namingMap
+	for _, mapping := range mappings {
+		pattern, err := regexp.Compile("^(?:" + mapping.Pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid naming-map pattern %q: %v", mapping.Pattern, err)
+		}
+		m = append(m, nameRule{pattern: pattern, replacement: mapping.Replacement})
+	}
+	return m, nil
+}
+
+// match returns the name renamed by the first rule that matches it, and whether one did
+func (m namingMap) match(name string) (string, bool) {
+	for _, rule := range m {
+		if rule.pattern.MatchString(name) {
+			return rule.pattern.ReplaceAllString(name, rule.replacement), true
+		}
+	}
+	return name, false
+}
+
+// rename returns the name renamed by the first rule that matches it, or the name if none do
+func (m namingMap) rename(name string) string {
+	renamed, _ := m.match(name)
+	return renamed
+}
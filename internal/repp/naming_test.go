@@ -0,0 +1,60 @@
+package repp
+
+import (
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_namingMap_rename(t *testing.T) {
+	names, err := newNamingMap([]config.NameMapping{
+		{Pattern: `gnl\|addgene\|(\d+)`, Replacement: "pAB$1"},
+		{Pattern: `oligo(\d+)`, Replacement: "AB-oligo-$1"},
+		{Pattern: `.*`, Replacement: "unreachable"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"gnl|addgene|107006", "pAB107006"},
+		{"oligo12", "AB-oligo-12"},
+		{"x-gnl|addgene|107006", "unreachable"},
+	}
+	for _, tt := range tests {
+		if got := names.rename(tt.name); got != tt.want {
+			t.Errorf("rename(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := newNamingMap([]config.NameMapping{{Pattern: "(", Replacement: "x"}}); err == nil {
+		t.Error("newNamingMap() with an invalid pattern, want an error")
+	}
+
+	var none namingMap
+	if got := none.rename("gnl|addgene|107006"); got != "gnl|addgene|107006" {
+		t.Errorf("rename() without a naming map = %s, want the name", got)
+	}
+}
+
+func Test_reagentIDs_names(t *testing.T) {
+	names, err := newNamingMap([]config.NameMapping{{Pattern: `oS(\d+)`, Replacement: "AB$1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reagentIDs := newReagentIDs(newOligosDB(primerIDPrefix, false), newOligosDB(synthFragIDPrefix, true), names)
+
+	first := reagentIDs.primer("ATGCATGCATGCATGCATGC")
+	if first.id == "" || first.id[:2] != "AB" {
+		t.Fatalf("primer() id = %s, want it renamed", first.id)
+	}
+	if again := reagentIDs.primer("ATGCATGCATGCATGCATGC"); again.id != first.id {
+		t.Errorf("primer() id = %s the second time, want %s", again.id, first.id)
+	}
+	if empty := reagentIDs.primer(""); !empty.isEmpty() || empty.id != "" {
+		t.Errorf("primer() of an empty sequence = %+v, want an empty primer", empty)
+	}
+}
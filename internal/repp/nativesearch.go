@@ -0,0 +1,243 @@
+package repp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+const (
+	// nativeSearchK is the length of the exact k-mers that seed in-process matches, blastn's word size
+	nativeSearchK = 11
+
+	// nativeSearchXDrop is how far an extension's score may drop below its best before it stops
+	nativeSearchXDrop = 20
+
+	// nativeSearchMinScore is the lowest score of a reported match, about the shortest
+	// hit BLAST's default e-value keeps against a small database
+	nativeSearchMinScore = 20
+)
+
+// nativeSearchable returns whether a database is small enough, by the size of its FASTA
+// file, to be searched in-process rather than with BLAST. Never without a config
+func nativeSearchable(db DB, conf *config.Config) bool {
+	if conf == nil || conf.NativeSearchMaxDBSizeKB <= 0 {
+		return false
+	}
+	info, err := os.Stat(db.Path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return info.Size() <= int64(conf.NativeSearchMaxDBSizeKB)<<10
+}
+
+// nativeMismatchPenalty returns the mismatch penalty of the in-process search, the
+// same as blastn's for the %-identity in searchFlags. Matches score 1 per bp
+func nativeMismatchPenalty(identity int) int {
+	switch {
+	case identity > 99:
+		return 5
+	case identity >= 98:
+		return 3
+	case identity >= 90:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// nativeSearch finds the matches between the query sequence and a database's entries
+// in-process, without BLAST: entries are seeded with the k-mers of the query and the
+// seeds are extended, with mismatches, along their diagonal until the score drops off.
+// The alignments are ungapped, so a template with an indel has a match on either side
+// of it. Matches follow the same conventions as the ones parsed from BLAST output,
+// including the %-identity threshold, but the left margin isn't applied
+func nativeSearch(seq string, circular bool, db DB, filters []string, filter filterExpr, identity int) (ms []match, err error) {
+	seq = strings.ToUpper(seq)
+	querySeq := seq
+	if circular {
+		querySeq = seq + seq
+	}
+	if len(querySeq) < nativeSearchK {
+		return nil, nil
+	}
+
+	s := &nativeSearcher{
+		seq:      seq,
+		querySeq: querySeq,
+		filters:  filters,
+		filter:   filter,
+		identity: float64(identity)/100.0 - 0.0001,
+		penalty:  nativeMismatchPenalty(identity),
+		index:    newKmerIndex(querySeq, nativeSearchK),
+	}
+	err = scanFasta(db.Path, func(header, subject string) {
+		ms = append(ms, s.searchEntry(db, header, subject)...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database %s: %v", db.Path, err)
+	}
+	rlog.Debugf("found %d matches in %d entries of %s in-process", len(ms), s.entryHits, db.Name)
+
+	return ms, nil
+}
+
+// nativeSearcher holds the query index and scoring of an in-process search
+type nativeSearcher struct {
+	// the query sequence (not doubled)
+	seq string
+
+	// the query sequence that's indexed (doubled if circular)
+	querySeq string
+
+	// title filters, as in blastExec
+	filters []string
+
+	// filter expression of the entries to keep, as in blastExec
+	filter filterExpr
+
+	// identity is the min ratio of matching bp of a match
+	identity float64
+
+	// penalty of a mismatch, a match scores 1
+	penalty int
+
+	// index of querySeq's k-mers
+	index kmerIndex
+
+	// entryHits is the number of entries with matches, for logging
+	entryHits int
+}
+
+// searchEntry returns the matches between the query and a single database entry
+func (s *nativeSearcher) searchEntry(db DB, header, subject string) (ms []match) {
+	entry, title := splitHeader(header)
+	titles, circular, keep := screenEntry(entry, title, db, len(subject), s.filters, s.filter)
+	if !keep {
+		return nil
+	}
+
+	for _, revComp := range []bool{false, true} {
+		subjectSeq := subject
+		if revComp {
+			subjectSeq = reverseComplement(subject)
+		}
+		for _, a := range s.align(subjectSeq) {
+			m := match{
+				entry:               entry,
+				querySeq:            s.querySeq[a.queryStart : a.queryEnd+1],
+				queryStart:          a.queryStart,
+				queryEnd:            a.queryEnd,
+				seq:                 subjectSeq[a.subjectStart : a.subjectEnd+1],
+				subjectStart:        a.subjectStart,
+				subjectEnd:          a.subjectEnd,
+				db:                  db,
+				title:               titles,
+				circular:            circular,
+				mismatching:         a.mismatching,
+				subjectRevCompMatch: revComp,
+				subjectLength:       len(subject),
+			}
+			if revComp {
+				// report subject coordinates on the entry's own strand, like BLAST
+				m.subjectStart, m.subjectEnd = len(subject)-1-a.subjectEnd, len(subject)-1-a.subjectStart
+			}
+			m.uniqueID = m.matchID(len(s.seq))
+			ms = append(ms, m)
+		}
+	}
+	if len(ms) > 0 {
+		s.entryHits++
+	}
+
+	return ms
+}
+
+// nativeAlignment is an ungapped alignment between the query and a subject sequence
+type nativeAlignment struct {
+	queryStart, queryEnd     int
+	subjectStart, subjectEnd int
+	mismatching              int
+}
+
+// align returns the ungapped alignments of the query against a subject sequence. Every
+// seed is extended unless it's within an earlier alignment on the same diagonal
+func (s *nativeSearcher) align(subject string) (alignments []nativeAlignment) {
+	// the subject index that the alignments reached, by diagonal (subject - query index)
+	reached := make(map[int]int)
+	for si := 0; si+nativeSearchK <= len(subject); si++ {
+		code, ok := encodeKmer(subject[si : si+nativeSearchK])
+		if !ok {
+			continue
+		}
+		for _, qi := range s.index[code] {
+			diagonal := si - qi
+			if end, ok := reached[diagonal]; ok && si <= end {
+				continue
+			}
+			a, score := s.extend(subject, qi, si)
+			reached[diagonal] = a.subjectEnd
+			if score < nativeSearchMinScore {
+				continue
+			}
+			length := a.queryEnd - a.queryStart + 1
+			if float64(length-a.mismatching)/float64(length) < s.identity {
+				continue
+			}
+			alignments = append(alignments, a)
+		}
+	}
+	return alignments
+}
+
+// extend extends the seed at qi of the query and si of the subject in both directions,
+// scoring 1 per match and -penalty per mismatch, and stops once the score drops
+// nativeSearchXDrop below its best. The alignment is trimmed to its best scoring ends
+func (s *nativeSearcher) extend(subject string, qi, si int) (a nativeAlignment, score int) {
+	score = nativeSearchK
+	queryEnd := qi + nativeSearchK - 1
+	best, current := score, score
+	for q, t := qi+nativeSearchK, si+nativeSearchK; q < len(s.querySeq) && t < len(subject); q, t = q+1, t+1 {
+		if s.querySeq[q] == subject[t] {
+			current++
+		} else {
+			current -= s.penalty
+		}
+		if current > best {
+			best, queryEnd = current, q
+		} else if best-current > nativeSearchXDrop {
+			break
+		}
+	}
+
+	queryStart := qi
+	score, current = best, best
+	for q, t := qi-1, si-1; q >= 0 && t >= 0; q, t = q-1, t-1 {
+		if s.querySeq[q] == subject[t] {
+			current++
+		} else {
+			current -= s.penalty
+		}
+		if current > score {
+			score, queryStart = current, q
+		} else if score-current > nativeSearchXDrop {
+			break
+		}
+	}
+
+	diagonal := si - qi
+	a = nativeAlignment{
+		queryStart:   queryStart,
+		queryEnd:     queryEnd,
+		subjectStart: queryStart + diagonal,
+		subjectEnd:   queryEnd + diagonal,
+	}
+	for q := queryStart; q <= queryEnd; q++ {
+		if s.querySeq[q] != subject[q+diagonal] {
+			a.mismatching++
+		}
+	}
+	return a, score
+}
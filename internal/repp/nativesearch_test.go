@@ -0,0 +1,110 @@
+package repp
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_nativeSearch(t *testing.T) {
+	bases := []byte("ACGT")
+	r := rand.New(rand.NewSource(1))
+	randSeq := func(n int) string {
+		seq := make([]byte, n)
+		for i := range seq {
+			seq[i] = bases[r.Intn(4)]
+		}
+		return string(seq)
+	}
+
+	target := randSeq(600)
+	// a point mutation at 300
+	mutated := []byte(target[200:400])
+	mutated[100] = map[byte]byte{'A': 'C', 'C': 'G', 'G': 'T', 'T': 'A'}[mutated[100]]
+	flank := strings.Repeat("N", 20)
+
+	dbPath := filepath.Join(t.TempDir(), "db.fa")
+	contents := ">fwd a template\n" + flank + string(mutated) + flank + "\n" +
+		">rev\n" + flank + reverseComplement(target[450:550]) + "\n" +
+		">excluded\n" + target[0:100] + "\n" +
+		">unrelated\n" + randSeq(500) + "\n"
+	if err := os.WriteFile(dbPath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db := DB{Name: "small", Path: dbPath}
+
+	matches, err := nativeSearch(target, false, db, []string{"EXCLUDED"}, nil, 95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byEntry := make(map[string]match)
+	for _, m := range matches {
+		byEntry[m.entry] = m
+	}
+	if len(matches) != 2 {
+		t.Fatalf("nativeSearch() = %v, want the fwd and rev matches", matches)
+	}
+
+	fwd := byEntry["fwd"]
+	if fwd.queryStart != 200 || fwd.queryEnd != 399 || fwd.subjectStart != 20 || fwd.subjectEnd != 219 {
+		t.Errorf("nativeSearch() fwd = %v, want [200:399] -> [20:219]", fwd)
+	}
+	if fwd.mismatching != 1 || fwd.isRevCompMatch() || fwd.subjectLength != 240 {
+		t.Errorf("nativeSearch() fwd mismatching = %d, rev comp = %t, subject length = %d", fwd.mismatching, fwd.isRevCompMatch(), fwd.subjectLength)
+	}
+	if indexes := fwd.mismatchIndexes(); len(indexes) != 1 || indexes[0] != 300 {
+		t.Errorf("nativeSearch() fwd mismatch indexes = %v, want [300]", indexes)
+	}
+
+	rev := byEntry["rev"]
+	if rev.queryStart != 450 || rev.queryEnd != 549 || rev.subjectStart != 20 || rev.subjectEnd != 119 || !rev.isRevCompMatch() {
+		t.Errorf("nativeSearch() rev = %v, want [450:549] -> [20:119] on the reverse strand", rev)
+	}
+	if rev.querySeq != rev.seq {
+		t.Errorf("nativeSearch() rev seq = %s, want it on the target's strand", rev.seq)
+	}
+
+	// a point mutation is too many at 100% identity
+	if matches, err = nativeSearch(target, false, db, nil, nil, 100); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range matches {
+		if m.mismatching > 0 {
+			t.Errorf("nativeSearch() at 100%% identity = %v with %d mismatches", m, m.mismatching)
+		}
+	}
+}
+
+func Test_nativeSearchable(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db.fa")
+	if err := os.WriteFile(dbPath, []byte(">a\n"+strings.Repeat("ACGT", 512)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db := DB{Name: "small", Path: dbPath}
+
+	tests := []struct {
+		name string
+		conf *config.Config
+		want bool
+	}{
+		{"no config", nil, false},
+		{"disabled", &config.Config{}, false},
+		{"under the max", &config.Config{NativeSearchMaxDBSizeKB: 4}, true},
+		{"over the max", &config.Config{NativeSearchMaxDBSizeKB: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nativeSearchable(db, tt.conf); got != tt.want {
+				t.Errorf("nativeSearchable() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	if nativeSearchable(DB{Path: filepath.Join(t.TempDir(), "missing.fa")}, &config.Config{NativeSearchMaxDBSizeKB: 4}) {
+		t.Error("nativeSearchable() of a missing FASTA file = true, want false")
+	}
+}
@@ -0,0 +1,41 @@
+package repp
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// errOffline is the error of requests made in offline mode
+var errOffline = errors.New("network access is disabled in offline mode")
+
+// offline is whether network access is disabled
+var offline bool
+
+// SetOffline disables all network access, ex: for labs with restricted environments.
+// repp sends no telemetry, its only requests are to hooks the user configures
+func SetOffline() {
+	offline = true
+}
+
+// IsOffline returns whether network access is disabled
+func IsOffline() bool {
+	return offline
+}
+
+// guardedTransport sends requests unless in offline mode
+type guardedTransport struct{}
+
+// RoundTrip fails in offline mode, otherwise it sends the request with the default transport
+func (guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if offline {
+		return nil, errOffline
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// httpClient returns an HTTP client whose requests time out after timeout. All requests
+// go through it so offline mode is guaranteed to block them
+func httpClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: guardedTransport{}, Timeout: timeout}
+}
@@ -0,0 +1,81 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// referenceMatches are the matches of the last design's target against do-not-PCR templates:
+// entries that are only sequence references, so they were synthesized rather than PCR'ed
+var referenceMatches []match
+
+// noPCREntries returns the IDs of a database's entries that are never PCR templates, as they're
+// written in the database, with all the entries renamed from them. It's nil if all of them are,
+// or none are
+func (db DB) noPCREntries() map[string]bool {
+	if db.NoPCR || len(db.NoPCREntries) == 0 {
+		return nil
+	}
+	renamed := db.renamedIDs()
+	entries := make(map[string]bool)
+	for _, entry := range db.NoPCREntries {
+		entries[entry] = true
+		for _, renamedEntry := range renamedEntries(renamed, entry) {
+			entries[renamedEntry] = true
+		}
+	}
+	return entries
+}
+
+// splitNoPCR splits matches into those of PCR templates and those of do-not-PCR templates,
+// the entries of databases marked with --no-pcr or listed in their --no-pcr-entries
+func splitNoPCR(matches []match) (templates, references []match) {
+	entriesByDB := make(map[string]map[string]bool)
+	for _, m := range matches {
+		if _, ok := entriesByDB[m.db.Name]; !ok {
+			entriesByDB[m.db.Name] = m.db.noPCREntries()
+		}
+		if m.db.NoPCR || entriesByDB[m.db.Name][m.entry] {
+			references = append(references, m)
+		} else {
+			templates = append(templates, m)
+		}
+	}
+	return templates, references
+}
+
+// noPCRWarnings returns a warning for each synthetic fragment of a solution that could
+// have been PCR'ed from a do-not-PCR template, noting why it's synthesized instead.
+// Matches shorter than minLength couldn't have been PCR'ed and are ignored
+func noPCRWarnings(frags []*Frag, references []match, seqLen, minLength int) (warnings []string) {
+	for _, f := range frags {
+		if f.fragType != synthetic {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		var entries []string
+		for _, m := range references {
+			if m.length() < minLength || seen[m.entry] {
+				continue
+			}
+			for _, offset := range []int{-seqLen, 0, seqLen} {
+				if m.queryStart+offset < f.end && m.queryEnd+offset >= f.start {
+					seen[m.entry] = true
+					entries = append(entries, m.entry)
+					break
+				}
+			}
+		}
+		if len(entries) > 0 {
+			sort.Strings(entries)
+			templates := "template"
+			if len(entries) > 1 {
+				templates += "s"
+			}
+			warnings = append(warnings, fmt.Sprintf("%s is synthesized rather than PCR'ed from the do-not-PCR %s %s", f.ID, templates, strings.Join(entries, ", ")))
+		}
+	}
+	return warnings
+}
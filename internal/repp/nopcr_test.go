@@ -0,0 +1,45 @@
+package repp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_splitNoPCR(t *testing.T) {
+	lab := DB{Name: "lab", Path: t.TempDir() + "/lab", NoPCREntries: []string{"pToxic"}}
+	toxic := DB{Name: "toxic", NoPCR: true}
+	matches := []match{
+		{entry: "pSB1A3", db: lab},
+		{entry: "pToxic", db: lab},
+		{entry: "pUnstable", db: toxic},
+	}
+
+	templates, references := splitNoPCR(matches)
+	if len(templates) != 1 || templates[0].entry != "pSB1A3" {
+		t.Errorf("splitNoPCR() templates = %v, want pSB1A3", templates)
+	}
+	if len(references) != 2 || references[0].entry != "pToxic" || references[1].entry != "pUnstable" {
+		t.Errorf("splitNoPCR() references = %v, want pToxic and pUnstable", references)
+	}
+}
+
+func Test_noPCRWarnings(t *testing.T) {
+	frags := []*Frag{
+		{ID: "out_1_pcr", fragType: pcr, start: 0, end: 500},
+		{ID: "out_2_synthetic", fragType: synthetic, start: 480, end: 1200},
+		{ID: "out_3_synthetic", fragType: synthetic, start: 1180, end: 2020},
+	}
+	references := []match{
+		{entry: "pToxic", queryStart: 600, queryEnd: 1100},
+		{entry: "pShort", queryStart: 1300, queryEnd: 1350},
+		{entry: "pAcrossZero", queryStart: 3900, queryEnd: 4400},
+	}
+
+	want := []string{
+		"out_2_synthetic is synthesized rather than PCR'ed from the do-not-PCR template pToxic",
+		"out_3_synthetic is synthesized rather than PCR'ed from the do-not-PCR template pAcrossZero",
+	}
+	if got := noPCRWarnings(frags, references, 2000, 100); !reflect.DeepEqual(got, want) {
+		t.Errorf("noPCRWarnings() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package repp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// notifyTimeout is how long the notification URL has to respond
+const notifyTimeout = 30 * time.Second
+
+// RunSummary is the summary of a run sent to the notification hooks when it finishes or fails
+type RunSummary struct {
+	// Status of the run: "succeeded" or "failed"
+	Status string `json:"status"`
+
+	// Error the run failed with
+	Error string `json:"error,omitempty"`
+
+	// Target of the design
+	Target string `json:"target,omitempty"`
+
+	// Output is the file the output was written to
+	Output string `json:"output,omitempty"`
+
+	// Solutions is the number of solutions in the output
+	Solutions int `json:"solutions"`
+
+	// Count is the number of fragments of the first solution
+	Count int `json:"count,omitempty"`
+
+	// Cost of the first solution
+	Cost float64 `json:"cost,omitempty"`
+
+	// Execution is the number of seconds the run took
+	Execution float64 `json:"execution"`
+
+	// CommandLine that repp was run with
+	CommandLine []string `json:"commandLine"`
+
+	// Hostname of the machine repp ran on
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// notifier sends the summary of a run to a URL and a command when it ends
+type notifier struct {
+	// url the summary is POSTed to
+	url string
+
+	// command run by the shell with the summary on its stdin
+	command string
+
+	// start of the run
+	start time.Time
+}
+
+// runNotifier is the notifier of the current run, nil if there are no hooks
+var runNotifier *notifier
+
+// SetNotify sets the URL that the run's summary JSON is POSTed to, and the command that's run
+// with it on stdin, when the run finishes or fails. Either can be empty.
+func SetNotify(url, command string) {
+	if url == "" && command == "" {
+		return
+	}
+	runNotifier = &notifier{url: url, command: command, start: time.Now()}
+
+	// notify of fatal errors before exiting
+	setRunEndHook()
+}
+
+// notifyFailed notifies of a run that failed with a fatal error
+func notifyFailed(message string) {
+	if runNotifier == nil {
+		return
+	}
+	runNotifier.notify(RunSummary{Status: "failed", Error: message})
+}
+
+// notifySucceeded notifies of a run that wrote its output
+func notifySucceeded(filename string, out *Output) {
+	if runNotifier == nil {
+		return
+	}
+	summary := RunSummary{Status: "succeeded", Output: filename}
+	if out != nil {
+		summary.Target = out.Target
+		summary.Solutions = len(out.Solutions)
+		if len(out.Solutions) > 0 {
+			summary.Count, summary.Cost = out.Solutions[0].Count, out.Solutions[0].Cost
+		}
+	}
+	runNotifier.notify(summary)
+}
+
+// notify sends the summary to the URL and command. Failures are logged, they don't fail the run
+func (n *notifier) notify(summary RunSummary) {
+	summary.Execution = time.Since(n.start).Seconds()
+	summary.CommandLine = os.Args
+	summary.Hostname, _ = os.Hostname()
+	body, err := json.Marshal(summary)
+	if err != nil {
+		rlog.Warnf("failed to encode the run summary: %v", err)
+		return
+	}
+
+	if n.url != "" {
+		if err = postSummary(n.url, body); err != nil {
+			rlog.Warnf("failed to notify %s: %v", n.url, err)
+		}
+	}
+	if n.command != "" {
+		cmd := exec.Command("sh", "-c", n.command)
+		cmd.Stdin = bytes.NewReader(body)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		cmd.Env = append(os.Environ(), "REPP_STATUS="+summary.Status, "REPP_OUTPUT="+summary.Output)
+		if err = cmd.Run(); err != nil {
+			rlog.Warnf("failed to run the notify command %q: %v", n.command, err)
+		}
+	}
+}
+
+// postSummary POSTs the summary JSON to a URL
+func postSummary(url string, body []byte) error {
+	resp, err := httpClient(notifyTimeout).Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("response status %s", resp.Status)
+	}
+	return nil
+}
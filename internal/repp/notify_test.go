@@ -0,0 +1,87 @@
+package repp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_notifier_notify(t *testing.T) {
+	var posted RunSummary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &posted); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	ran := filepath.Join(t.TempDir(), "summary.json")
+	n := &notifier{url: server.URL, command: "cat > " + ran + " && test \"$REPP_STATUS\" = succeeded", start: time.Now()}
+	runNotifier = n
+	defer func() { runNotifier = nil }()
+
+	out := &Output{Target: "target", Solutions: []Solution{{Count: 3, Cost: 42.5}, {Count: 4}}}
+	notifySucceeded("out.json", out)
+
+	want := RunSummary{Status: "succeeded", Target: "target", Output: "out.json", Solutions: 2, Count: 3, Cost: 42.5}
+	posted.Execution, posted.CommandLine, posted.Hostname = 0, nil, "" // differ between runs
+	if !reflect.DeepEqual(posted, want) {
+		t.Errorf("notify() posted %+v, want %+v", posted, want)
+	}
+
+	contents, err := os.ReadFile(ran)
+	if err != nil {
+		t.Fatalf("notify() didn't run the command: %v", err)
+	}
+	var piped RunSummary
+	if err = json.Unmarshal(contents, &piped); err != nil || piped.Output != "out.json" {
+		t.Errorf("notify() piped %s to the command, want the summary", contents)
+	}
+}
+
+func Test_postSummary_offline(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	SetOffline()
+	defer func() { offline = false }()
+
+	if err := postSummary(server.URL, []byte("{}")); !errors.Is(err, errOffline) {
+		t.Errorf("postSummary() error = %v, want %v", err, errOffline)
+	}
+	if requests > 0 {
+		t.Errorf("postSummary() sent %d requests offline", requests)
+	}
+}
+
+// Test_httpClient checks that requests only go through httpClient, so offline mode blocks them all
+func Test_httpClient(t *testing.T) {
+	unguarded := regexp.MustCompile(`http\.(Get|Head|Post|PostForm)\(|http\.DefaultClient|http\.Client\{|net\.Dial`)
+	sources, _ := filepath.Glob("*.go")
+	cmdSources, _ := filepath.Glob(filepath.Join("..", "cmd", "*.go"))
+	for _, source := range append(sources, cmdSources...) {
+		if strings.HasSuffix(source, "_test.go") || filepath.Base(source) == "network.go" {
+			continue
+		}
+		contents, err := os.ReadFile(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loc := unguarded.FindIndex(contents); loc != nil {
+			t.Errorf("%s makes a request without httpClient: %s", source, contents[loc[0]:loc[1]])
+		}
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"encoding/csv"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Lattice-Automation/repp/internal/config"
 	"go.uber.org/multierr"
+	"golang.org/x/exp/slices"
 )
 
 type oligo struct {
@@ -19,6 +23,77 @@ type oligo struct {
 	primingRegion string
 	tm            float64
 	notes         string
+	plate         string     // plate of an existing oligo, if the manifest has one
+	well          string     // well of an existing oligo on its plate
+	ordered       time.Time  // when an existing oligo was ordered, zero if unknown
+	validation    validation // whether an existing oligo was validated at the bench
+	location      string     // where an existing oligo is stored, ex: a freezer and rack
+}
+
+// validation is whether an oligo of a manifest was validated at the bench
+type validation int
+
+const (
+	// unknownValidation is the validation of oligos in manifests without the validated column
+	unknownValidation validation = iota
+
+	// validated oligos worked at the bench
+	validated
+
+	// unvalidated oligos weren't tested, or failed
+	unvalidated
+)
+
+// parseValidation parses the validated column of a manifest, ex: y, yes, true, n
+func parseValidation(field string) validation {
+	switch strings.ToLower(strings.TrimSpace(field)) {
+	case "y", "yes", "true", "1", "validated":
+		return validated
+	case "n", "no", "false", "0", "unvalidated":
+		return unvalidated
+	default:
+		return unknownValidation
+	}
+}
+
+// orderedDateLayouts are the layouts of the dates in the ordered column of manifests
+var orderedDateLayouts = []string{"2006-01-02", "2006/01/02", "1/2/2006", time.RFC3339}
+
+// parseOrdered parses the ordered date column of a manifest, ex: 2023-05-01. Dates of xlsx
+// manifests may be the serial numbers of their days since 1899-12-30
+func parseOrdered(field string) (time.Time, error) {
+	field = strings.TrimSpace(field)
+	for _, layout := range orderedDateLayouts {
+		if t, err := time.Parse(layout, field); err == nil {
+			return t, nil
+		}
+	}
+	if days, err := strconv.ParseFloat(field, 64); err == nil && days > 0 {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(days)), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q, expected YYYY-MM-DD", field)
+}
+
+// inventoryNotes returns where an existing oligo is and its validation, ex:
+// "freezer B, rack 2; plate P1, well A3; validated; ordered 2023-05-01"
+func (o oligo) inventoryNotes() string {
+	var notes []string
+	if o.location != "" {
+		notes = append(notes, o.location)
+	}
+	if o.onPlate() {
+		notes = append(notes, fmt.Sprintf("plate %s, well %s", o.plate, o.well))
+	}
+	switch o.validation {
+	case validated:
+		notes = append(notes, "validated")
+	case unvalidated:
+		notes = append(notes, "not validated")
+	}
+	if !o.ordered.IsZero() {
+		notes = append(notes, "ordered "+o.ordered.Format("2006-01-02"))
+	}
+	return strings.Join(notes, "; ")
 }
 
 func (o oligo) isEmpty() bool {
@@ -29,6 +104,10 @@ func (o oligo) hasID() bool {
 	return o.id != ""
 }
 
+func (o oligo) onPlate() bool {
+	return o.plate != "" && o.well != ""
+}
+
 func (o oligo) getIDOrDefault(markID bool, defaultValue string) string {
 	if o.hasID() {
 		if markID {
@@ -113,8 +192,14 @@ func newOligosDB(defaultBasePrefix string, synthOligos bool) *oligosDB {
 	return o
 }
 
+// addOligo indexes an oligo by its sequence. An oligo with the same sequence is replaced,
+// unless it was validated and the new one wasn't, so validated oligos are reused first
 func (oligos *oligosDB) addOligo(o oligo) {
-	oligos.indexedOligos[strings.ToUpper(o.seq)] = o
+	key := strings.ToUpper(o.seq)
+	if existing, ok := oligos.indexedOligos[key]; ok && existing.validation == validated && o.validation != validated {
+		return
+	}
+	oligos.indexedOligos[key] = o
 }
 
 // check if the provided sequence exists in the provided databases
@@ -134,12 +219,74 @@ func searchOligoDBs(seq string, oligoDBs []*oligosDB) oligo {
 	return oligo{seq: seq}
 }
 
-func readOligos(dbLocations []string, basePrefix string, synthOligos bool) (oligos *oligosDB) {
+// reagentIDs assigns IDs to the primers and synthetic fragments of a solution
+// that aren't in the manifests, continuing the numbering of the manifests
+type reagentIDs struct {
+	existingPrimers, existingSynthFrags *oligosDB
+	newPrimers, newSynthFrags           *oligosDB
+
+	// names renames the IDs to the lab's conventions
+	names namingMap
+}
+
+func newReagentIDs(existingPrimers, existingSynthFrags *oligosDB, names namingMap) *reagentIDs {
+	return &reagentIDs{
+		existingPrimers:    existingPrimers,
+		existingSynthFrags: existingSynthFrags,
+		newPrimers:         newOligosDB(primerIDPrefix, false),
+		newSynthFrags:      newOligosDB(synthFragIDPrefix, true),
+		names:              names,
+	}
+}
+
+// primer returns the primer with the sequence, from the manifest or with a new ID.
+// The primer is empty, without an ID, if the sequence is empty, ex: a fragment without primers
+func (r *reagentIDs) primer(seq string) oligo {
+	o := searchOligoDBs(seq, []*oligosDB{r.existingPrimers, r.newPrimers})
+	if !o.isEmpty() && !o.hasID() {
+		o.assignNewOligoID(r.existingPrimers.getNewOligoID(len(r.newPrimers.indexedOligos)))
+		r.newPrimers.addOligo(o)
+	}
+	if o.hasID() {
+		o.id = r.names.rename(o.id)
+	}
+	return o
+}
+
+// synthFrag returns the synthetic fragment with the sequence, from the manifest or with a new ID
+func (r *reagentIDs) synthFrag(seq string) oligo {
+	o := searchOligoDBs(seq, []*oligosDB{r.existingSynthFrags, r.newSynthFrags})
+	if !o.hasID() {
+		o.assignNewOligoID(r.existingSynthFrags.getNewOligoID(len(r.newSynthFrags.indexedOligos)))
+		o.synth = true
+		r.newSynthFrags.addOligo(o)
+	}
+	o.id = r.names.rename(o.id)
+	return o
+}
+
+// readOligoDBs reads the manifests of the primers and synthetic fragments that are already
+// available, ex: the primers-databases and synth-frags-databases of a design
+func readOligoDBs(primersDBLocations, synthFragsDBLocations []string, conf *config.Config) (primersDB, synthFragsDB *oligosDB, err error) {
+	if primersDB, err = readOligos(primersDBLocations, primerIDPrefix, false, conf); err != nil {
+		return nil, nil, err
+	}
+	if synthFragsDB, err = readOligos(synthFragsDBLocations, synthFragIDPrefix, true, conf); err != nil {
+		return nil, nil, err
+	}
+	return primersDB, synthFragsDB, nil
+}
+
+// readOligos reads the oligo manifests in dbLocations. Manifests and rows that can't be
+// read are skipped, or are an error in strict mode
+func readOligos(dbLocations []string, basePrefix string, synthOligos bool, conf *config.Config) (oligos *oligosDB, err error) {
 	oligos = newOligosDB(basePrefix, synthOligos)
 	oligosFnames, collectFilesErr := CollectFiles(dbLocations)
 	if collectFilesErr != nil {
-		rlog.Warnf("Errors trying to collect oligo filenames from: %v", dbLocations)
-		return
+		if err = strictErrorf(conf.Strict, "failed to collect the oligo manifests from %v: %v", dbLocations, collectFilesErr); err != nil {
+			return nil, err
+		}
+		return oligos, nil
 	}
 
 	var allErrs error
@@ -147,20 +294,41 @@ func readOligos(dbLocations []string, basePrefix string, synthOligos bool) (olig
 		if fn == "" {
 			continue
 		}
-		oligosReadErr := readOligosFromFile(fn, oligos)
+		oligosReadErr := readOligosFromFile(fn, oligos, conf)
 		if oligosReadErr != nil {
 			allErrs = multierr.Append(allErrs, oligosReadErr)
 		}
 	}
 
 	if allErrs != nil {
-		rlog.Warnf("Errors trying to read oligos: %v", allErrs)
+		if err = strictErrorf(conf.Strict, "failed to read the oligo manifests: %v", allErrs); err != nil {
+			return nil, err
+		}
+	}
+
+	// universal primers are stock, they're never ordered. Those in a manifest keep its ID
+	if !synthOligos {
+		for _, u := range conf.GetUniversalPrimers() {
+			if _, inManifest := oligos.indexedOligos[strings.ToUpper(u.Seq)]; !inManifest && u.Seq != "" {
+				oligos.addOligo(oligo{id: u.Name, seq: u.Seq})
+			}
+		}
 	}
 	return
 }
 
-func readOligosFromFile(oligosCSVFilename string, oligos *oligosDB) error {
+func readOligosFromFile(oligosCSVFilename string, oligos *oligosDB, conf *config.Config) error {
 	rlog.Infof("Read available oligos from %s", oligosCSVFilename)
+	if strings.EqualFold(filepath.Ext(oligosCSVFilename), ".xlsx") {
+		if err := readOligosFromXLSX(oligosCSVFilename, oligos, conf); err != nil {
+			rlog.Warnf("Error parsing oligos manifest %s: %v", oligosCSVFilename, err)
+			if conf.Strict {
+				return fmt.Errorf("%s: %v", oligosCSVFilename, err)
+			}
+		}
+		return nil
+	}
+
 	f, err := os.Open(oligosCSVFilename)
 	if err != nil {
 		rlog.Warnf("Error opening oligos manifest %s: %v", oligosCSVFilename, err)
@@ -170,14 +338,17 @@ func readOligosFromFile(oligosCSVFilename string, oligos *oligosDB) error {
 
 	manifestReader := csv.NewReader(f)
 
-	if err = readOligosFromCSV(manifestReader, oligos); err != nil {
+	if err = readOligosFromCSV(manifestReader, oligos, conf.Strict); err != nil {
 		rlog.Warnf("Error parsing oligos manifest %s: %v", oligosCSVFilename, err)
+		if conf.Strict {
+			return fmt.Errorf("%s: %v", oligosCSVFilename, err)
+		}
 	}
 
 	return nil
 }
 
-func readOligosFromCSV(manifestReader *csv.Reader, oligos *oligosDB) error {
+func readOligosFromCSV(manifestReader *csv.Reader, oligos *oligosDB, strict bool) error {
 
 	manifestReader.Comment = '#'
 	manifestReader.TrimLeadingSpace = true
@@ -186,10 +357,69 @@ func readOligosFromCSV(manifestReader *csv.Reader, oligos *oligosDB) error {
 		return err
 	}
 
+	return addOligoRecords(records, oligos, strict)
+}
+
+// readOligosFromXLSX reads oligos from a sheet of an xlsx manifest. The columns of their IDs,
+// sequences, plates and wells are mapped by the oligos-xlsx-columns setting
+func readOligosFromXLSX(filename string, oligos *oligosDB, conf *config.Config) error {
+	rows, err := readXLSX(filename, conf.OligosXlsxSheet)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var columns []int
+	header := false // whether the first row is a header, it is if columns are mapped by name
+	for _, field := range []string{"id", "sequence", "plate", "well", "ordered", "validated", "location"} {
+		column := conf.OligosXlsxColumn(field)
+		index := slices.IndexFunc(rows[0], func(name string) bool {
+			return strings.EqualFold(strings.TrimSpace(name), column)
+		})
+		if index >= 0 {
+			header = true
+		} else {
+			index = xlsxColumnIndex(column)
+		}
+		if index < 0 {
+			return fmt.Errorf("the %s column %q is neither a header nor column letters", field, column)
+		}
+		columns = append(columns, index)
+	}
+
+	if header {
+		rows = rows[1:]
+	}
+	records := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		if len(row) > 0 && strings.HasPrefix(strings.TrimSpace(row[0]), "#") {
+			continue
+		}
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			if column < len(row) {
+				record[i] = row[column]
+			}
+		}
+		records = append(records, record)
+	}
+	return addOligoRecords(records, oligos, conf.Strict)
+}
+
+// addOligoRecords adds the oligos of a manifest's records: id, sequence and optionally
+// plate, well, the date ordered, whether it's validated and its storage location.
+// Headers and rows without an ID or sequence are skipped. In strict mode, the skipped rows
+// are returned as an error
+func addOligoRecords(records [][]string, oligos *oligosDB, strict bool) (err error) {
 	for i, r := range records {
 		if len(r) < 2 {
 			// skip this row because it has too few items
 			rlog.Warnf("Skip row %d:%v because it has too few colums\n", i+1, r)
+			if strict {
+				err = multierr.Append(err, fmt.Errorf("row %d has too few columns", i+1))
+			}
 			continue
 		}
 		oligoIdField := strings.TrimSpace(r[0])
@@ -200,6 +430,9 @@ func readOligosFromCSV(manifestReader *csv.Reader, oligos *oligosDB) error {
 			continue
 		} else if oligoIdField == "" || oligoSeqField == "" {
 			rlog.Warnf("Skip row %d:%v because ID and/or sequence field is empty\n", i+1, r)
+			if strict {
+				err = multierr.Append(err, fmt.Errorf("row %d has no ID or sequence", i+1))
+			}
 			continue
 		}
 
@@ -229,10 +462,31 @@ func readOligosFromCSV(manifestReader *csv.Reader, oligos *oligosDB) error {
 			seq:   oligoSequence, // put the original sequence field here as read from the file
 			synth: oligos.synthOligos,
 		}
+		// optional plate and well columns locate oligos that are already on plates
+		if len(r) >= 4 {
+			oligo.plate = strings.TrimSpace(r[2])
+			oligo.well = strings.TrimSpace(r[3])
+		}
+		// optional inventory columns: when it was ordered, whether it's validated and where it's stored
+		if len(r) >= 5 && strings.TrimSpace(r[4]) != "" {
+			if ordered, orderedErr := parseOrdered(r[4]); orderedErr != nil {
+				rlog.Warnf("Ignore the ordered date of %s in row %d: %v", oligoIdField, i+1, orderedErr)
+				if strict {
+					err = multierr.Append(err, fmt.Errorf("row %d has an invalid ordered date: %v", i+1, orderedErr))
+				}
+			} else {
+				oligo.ordered = ordered
+			}
+		}
+		if len(r) >= 6 {
+			oligo.validation = parseValidation(r[5])
+		}
+		if len(r) >= 7 {
+			oligo.location = strings.TrimSpace(r[6])
+		}
 		oligos.addOligo(oligo)
 	}
-
-	return nil
+	return err
 }
 
 func extractOligoIDComps(oligoId string) (string, uint) {
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_readOligosFromCSV(t *testing.T) {
@@ -70,13 +71,40 @@ func Test_readOligosFromCSV(t *testing.T) {
 			},
 			nextIndex: 11,
 		},
+		{
+			name: "oligos with plate locations",
+			args: args{
+				`primer_id, sequence, plate, well
+				os1, act, P1, A1
+				os2, tgacg, , `,
+			},
+			want: map[string]oligo{
+				"ACT":   {id: "os1", seq: "act", plate: "P1", well: "A1"},
+				"TGACG": {id: "os2", seq: "tgacg"},
+			},
+			nextIndex: 3,
+		},
+		{
+			name: "oligos with inventory columns, validated copies are kept",
+			args: args{
+				`primer_id, sequence, plate, well, ordered, validated, location
+				os1, act, P1, A1, 2021-03-04, y, freezer B
+				os2, act, , , 2023-01-02, n, freezer A
+				os3, tgacg, , , 45292, no, `,
+			},
+			want: map[string]oligo{
+				"ACT":   {id: "os1", seq: "act", plate: "P1", well: "A1", ordered: time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), validation: validated, location: "freezer B"},
+				"TGACG": {id: "os3", seq: "tgacg", ordered: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), validation: unvalidated},
+			},
+			nextIndex: 4,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := csv.NewReader(strings.NewReader(tt.args.csvData))
 			oligos := newOligosDB("oS", false)
-			err := readOligosFromCSV(r, oligos)
+			err := readOligosFromCSV(r, oligos, false)
 			if err != nil {
 				t.Errorf("%s: Error parsing oligos %v\n", tt.name, err)
 			}
@@ -89,3 +117,13 @@ func Test_readOligosFromCSV(t *testing.T) {
 		})
 	}
 }
+
+func Test_oligo_inventoryNotes(t *testing.T) {
+	o := oligo{plate: "P1", well: "A3", location: "freezer B", validation: unvalidated, ordered: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)}
+	if got, want := o.inventoryNotes(), "freezer B; plate P1, well A3; not validated; ordered 2023-05-01"; got != want {
+		t.Errorf("inventoryNotes() = %q, want %q", got, want)
+	}
+	if got := (oligo{}).inventoryNotes(); got != "" {
+		t.Errorf("inventoryNotes() of an oligo without inventory = %q, want none", got)
+	}
+}
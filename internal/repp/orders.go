@@ -0,0 +1,108 @@
+package repp
+
+import (
+	"encoding/csv"
+	"fmt"
+)
+
+// solutionOrder is what has to be ordered to build a solution: the primers and
+// synthetic fragments that aren't in the manifests, and the probes of its qPCR assays
+type solutionOrder struct {
+	primers    []oligo
+	synthFrags []oligo
+	probes     []oligo
+}
+
+// newSolutionOrder returns the new primers and synthetic fragments of a solution. Their IDs
+// match those in the reagents CSV
+func newSolutionOrder(s Solution, names namingMap, existingPrimers, existingSynthFrags *oligosDB) (order solutionOrder) {
+	reagentIDs := newReagentIDs(existingPrimers, existingSynthFrags, names)
+	ordered := make(map[string]bool)
+	add := func(o oligo, list *[]oligo) {
+		if o.isEmpty() || !o.isNew || ordered[o.id] {
+			return
+		}
+		ordered[o.id] = true
+		*list = append(*list, o)
+	}
+
+	for _, f := range s.Fragments {
+		fwdPrimer, revPrimer := f.getPrimers()
+		add(reagentIDs.primer(fwdPrimer.Seq), &order.primers)
+		add(reagentIDs.primer(revPrimer.Seq), &order.primers)
+		if f.fragType == synthetic {
+			add(reagentIDs.synthFrag(f.Seq), &order.synthFrags)
+		}
+	}
+	// the assays' primers are numbered after the assembly's, as in the reagents CSV
+	for _, assay := range s.QPCR {
+		add(reagentIDs.primer(assay.Forward.Seq), &order.primers)
+		add(reagentIDs.primer(assay.Reverse.Seq), &order.primers)
+		add(reagentIDs.primer(assay.Probe.Seq), &order.probes)
+	}
+	return order
+}
+
+// writeOrderFiles writes the order files of each solution, next to the output file: a
+// "Name,Sequence" CSV of the new primers, the bulk input format of IDT, and a FASTA
+// of the new synthetic fragments. The probes of qPCR assays are in their own "Name,Sequence"
+// CSV, they're ordered with a reporter and quencher. Files are only written if there's something to order.
+func writeOrderFiles(filename string, out *Output, names namingMap, existingPrimers, existingSynthFrags *oligosDB) error {
+	for si, s := range out.Solutions {
+		order := newSolutionOrder(s, names, existingPrimers, existingSynthFrags)
+		if len(order.primers) > 0 {
+			if err := writePrimerOrder(solutionFilename(filename, si+1, "-primers.csv"), order.primers); err != nil {
+				return err
+			}
+		}
+		if len(order.synthFrags) > 0 {
+			if err := writeSynthFragOrder(solutionFilename(filename, si+1, "-synth-frags.fasta"), order.synthFrags); err != nil {
+				return err
+			}
+		}
+		if len(order.probes) > 0 {
+			if err := writePrimerOrder(solutionFilename(filename, si+1, "-probes.csv"), order.probes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writePrimerOrder writes primers as a "Name,Sequence" CSV
+func writePrimerOrder(filename string, primers []oligo) (err error) {
+	orderFile, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer orderFile.close(&err)
+
+	w := csv.NewWriter(orderFile)
+	if err = w.Write([]string{"Name", "Sequence"}); err != nil {
+		return err
+	}
+	for _, p := range primers {
+		if err = w.Write([]string{p.id, p.seq}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
+
+// writeSynthFragOrder writes synthetic fragments to a FASTA file
+func writeSynthFragOrder(filename string, synthFrags []oligo) (err error) {
+	orderFile, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer orderFile.close(&err)
+
+	for _, f := range synthFrags {
+		if _, err = fmt.Fprintf(orderFile, ">%s\n%s\n", f.id, f.seq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
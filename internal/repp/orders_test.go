@@ -0,0 +1,97 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_writeOrderFiles(t *testing.T) {
+	existingPrimers := newOligosDB(primerIDPrefix, false)
+	existingPrimers.addOligo(oligo{id: "oS7", seq: "ACGTACGT"})
+	existingPrimers.nextOligoID = 8
+	existingSynthFrags := newOligosDB(synthFragIDPrefix, true)
+
+	out := &Output{
+		Solutions: []Solution{
+			{
+				Fragments: []*Frag{
+					{
+						fragType: pcr,
+						Primers: []Primer{
+							{Seq: "ACGTACGT", Strand: true},
+							{Seq: "TTTTGGGG", Strand: false},
+						},
+					},
+					{
+						fragType: pcr,
+						Primers: []Primer{
+							{Seq: "TTTTGGGG", Strand: true}, // reused from the first fragment
+							{Seq: "CCCCAAAA", Strand: false},
+						},
+					},
+					{
+						fragType: synthetic,
+						Seq:      "GATTACA",
+					},
+				},
+			},
+			{
+				Fragments: []*Frag{
+					{
+						fragType: pcr,
+						Primers: []Primer{
+							{Seq: "ACGTACGT", Strand: true},
+							{Seq: "ACGTACGT", Strand: false},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	filename := filepath.Join(t.TempDir(), "out.json")
+	if err := writeOrderFiles(filename, out, nil, existingPrimers, existingSynthFrags); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			"new primers of the first solution",
+			"out-solution1-primers.csv",
+			"Name,Sequence\noS8,TTTTGGGG\noS9,CCCCAAAA\n",
+		},
+		{
+			"new synthetic fragments of the first solution",
+			"out-solution1-synth-frags.fasta",
+			">syn1\nGATTACA\n",
+		},
+		{
+			"nothing to order for the second solution",
+			"out-solution2-primers.csv",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(filepath.Dir(filename), tt.file))
+			if tt.want == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("writeOrderFiles() wrote %s, want no file", tt.file)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(string(got), tt.want) {
+				t.Errorf("writeOrderFiles() %s = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// orientationK is the length of the k-mers that entries are oriented by. Long enough
+// to be specific to the reference features, short enough to survive point mutations
+const orientationK = 20
+
+// orientationIndex holds the k-mers of the reference features entries are oriented by:
+// 1 if the k-mer is on a reference's top strand, -1 if on its bottom strand and 0 if on both
+type orientationIndex map[string]int
+
+// newOrientationIndex returns the index of the features' k-mers, by name from the
+// features database
+func newOrientationIndex(featureNames []string) (orientationIndex, error) {
+	featureDB := NewFeatureDB()
+	var refs []string
+	for _, name := range featureNames {
+		seq, ok := featureDB.contents[name]
+		if !ok {
+			return nil, fmt.Errorf("failed to find %s in the features database to orient entries by, see 'repp ls features'", name)
+		}
+		refs = append(refs, seq)
+	}
+	return orientationKmers(refs), nil
+}
+
+// orientationKmers indexes the k-mers of reference sequences on both of their strands
+func orientationKmers(refs []string) orientationIndex {
+	idx := make(orientationIndex)
+	add := func(kmer string, strand int) {
+		if prev, ok := idx[kmer]; ok && prev != strand {
+			idx[kmer] = 0 // palindromic, or shared between references on opposite strands
+			return
+		}
+		idx[kmer] = strand
+	}
+	for _, ref := range refs {
+		ref = strings.ToUpper(ref)
+		rc := reverseComplement(ref)
+		for i := 0; i+orientationK <= len(ref); i++ {
+			add(ref[i:i+orientationK], 1)
+			add(rc[i:i+orientationK], -1)
+		}
+	}
+	return idx
+}
+
+// isReversed returns whether more of the references' k-mers are on the sequence's bottom
+// strand than on its top strand. Circular sequences are scanned across their origin
+func (idx orientationIndex) isReversed(seq string, isCircular bool) bool {
+	seq = strings.ToUpper(seq)
+	if isCircular && len(seq) >= orientationK {
+		seq += seq[:orientationK-1]
+	}
+	votes := 0
+	for i := 0; i+orientationK <= len(seq); i++ {
+		votes += idx[seq[i:i+orientationK]]
+	}
+	return votes < 0
+}
+
+// normalizeOrientation reverse complements the fragments whose reference features are
+// mostly on their bottom strand, so they're in the references' orientation. The indexes
+// of their stripped bases are mirrored, so they still map back to the original sequences,
+// reverse complemented. It returns the flipped fragments
+func normalizeOrientation(frags []*Frag, idx orientationIndex, circularize bool) map[*Frag]bool {
+	flipped := make(map[*Frag]bool)
+	for _, f := range frags {
+		if !idx.isReversed(f.Seq, circularize || f.circularTopology) {
+			continue
+		}
+		originalLength := len(f.Seq) + len(f.strippedIndexes)
+		f.Seq = reverseComplement(f.Seq)
+		for i, stripped := range f.strippedIndexes {
+			f.strippedIndexes[i] = originalLength - 1 - stripped
+		}
+		sort.Ints(f.strippedIndexes)
+		flipped[f] = true
+	}
+	return flipped
+}
+
+// markedEntryIDs returns the sorted IDs of the written entries that were marked, ex: flipped
+func markedEntryIDs(entries map[string]*Frag, marked map[*Frag]bool) (ids []string) {
+	for id, f := range entries {
+		if marked[f] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,35 @@
+package repp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_normalizeOrientation(t *testing.T) {
+	ref := randomSeq(200, 7)
+	idx := orientationKmers([]string{ref})
+
+	forward := &Frag{ID: "forward", Seq: randomSeq(100, 8) + ref + randomSeq(100, 9)}
+	reversed := &Frag{ID: "reversed", Seq: reverseComplement(forward.Seq), strippedIndexes: []int{0, 10}}
+	// across the origin of a circular sequence
+	acrossOrigin := &Frag{ID: "acrossOrigin", Seq: reverseComplement(ref[100:] + randomSeq(300, 10) + ref[:100]), fragType: circular}
+	unrelated := &Frag{ID: "unrelated", Seq: randomSeq(400, 11)}
+	wantReversed := reverseComplement(reversed.Seq)
+
+	flipped := normalizeOrientation([]*Frag{forward, reversed, acrossOrigin, unrelated}, idx, false)
+	if len(flipped) != 2 || !flipped[reversed] || !flipped[acrossOrigin] {
+		t.Fatalf("normalizeOrientation() flipped %v, want reversed and acrossOrigin", flipped)
+	}
+	if reversed.Seq != wantReversed {
+		t.Errorf("normalizeOrientation() didn't reverse complement reversed")
+	}
+	// the original was 402bp with its 1st and 11th bases stripped
+	if want := []int{391, 401}; !reflect.DeepEqual(reversed.strippedIndexes, want) {
+		t.Errorf("normalizeOrientation() stripped indexes = %v, want %v", reversed.strippedIndexes, want)
+	}
+
+	entries := map[string]*Frag{"forward": forward, "reversed": reversed, "acrossOrigin": acrossOrigin}
+	if got := markedEntryIDs(entries, flipped); !reflect.DeepEqual(got, []string{"acrossOrigin", "reversed"}) {
+		t.Errorf("markedEntryIDs() = %v", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Lattice-Automation/repp/internal/config"
+	"github.com/Lattice-Automation/repp/pkg/output"
 	"go.uber.org/multierr"
 )
 
@@ -26,18 +28,169 @@ type Solution struct {
 	// Adjusted cost for synthentic fragments
 	AdjustedCost float64 `json:"adjustedCost"`
 
+	// CostBreakdown is the cost of the solution by category
+	CostBreakdown CostBreakdown `json:"costBreakdown"`
+
 	// Fragments used to build this solution
 	Fragments []*Frag `json:"fragments"`
 
+	// Method is how the fragments are joined if not by Gibson Assembly (eg: sticky, blunt, topo)
+	Method string `json:"method,omitempty"`
+
+	// Warnings about the solution that may need extra screening at the bench
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Explanation of why the solution's fragments were chosen and how it compares to the other solutions
+	Explanation []string `json:"explanation,omitempty"`
+
+	// ReusedPrimers is the number of the solution's primers that are already on plates
+	ReusedPrimers int `json:"reusedPrimers,omitempty"`
+
+	// PickList is where to pick the reused primers from
+	PickList []PickListEntry `json:"pickList,omitempty"`
+
+	// Screening tells the intended product apart from failure products, like the
+	// religated backbone, when the backbone was linearized with enzymes
+	Screening *Screening `json:"screening,omitempty"`
+
+	// Pareto is whether the solution is on the pareto front of fragment count, synthetic
+	// fragment count and cost: no other solution is at least as good in all three and better in one
+	Pareto bool `json:"pareto,omitempty"`
+
+	// AnnealingTemp is the annealing temperature (Ta) to amplify all the PCR fragments at,
+	// when they're constrained to a shared annealing window
+	AnnealingTemp float64 `json:"annealingTemp,omitempty"`
+
+	// PriorReagents is the number of primers and synthetic fragments reused from a previous
+	// plan, that don't have to be ordered again
+	PriorReagents int `json:"priorReagents,omitempty"`
+
+	// SuccessScore is a heuristic score, from 0 to 1, of how likely the assembly is to work the first time
+	SuccessScore float64 `json:"successScore,omitempty"`
+
+	// Features are how each feature of a features mode target is made: the fragment type,
+	// and the template, identity and coordinates of those that aren't synthesized
+	Features []FeatureSource `json:"features,omitempty"`
+
+	// FeatureCoverage is the fraction, from 0 to 1, of the features' bp that come from
+	// templates rather than synthesis. Only set in features mode
+	FeatureCoverage *float64 `json:"featureCoverage,omitempty"`
+
+	// GoldenGate is the set of overhangs to join the fragments by Golden Gate Assembly
+	// and its predicted fidelity, if golden-gate-overhangs is set in the config
+	GoldenGate *GoldenGate `json:"goldenGate,omitempty"`
+
+	// QPCR are the qPCR assays that verify the solution, if qpcr-assays is set in the config
+	QPCR []QPCRAssay `json:"qpcr,omitempty"`
+
+	// Junctions are the homologies at which the fragments are joined
+	Junctions []Junction `json:"junctions,omitempty"`
+
+	// Verification is how the solution's build was checked against its Sanger reads, by repp verify
+	Verification *Verification `json:"verification,omitempty"`
+
 	// number of PCR fragments
 	pcrFragsCount int
 
 	// number of synthentic fragments
 	synthFragsCount int
+
+	// why the fragments, joined at their junctions, don't reconstruct the target, if they don't
+	reconstructionErr error
+}
+
+// CostBreakdown is the cost of a solution by category. The categories add up to the solution's cost.
+type CostBreakdown struct {
+	// Procurement of the fragments from their sequence databases (eg ordering plasmids)
+	Procurement float64 `json:"procurement"`
+
+	// Primers for the PCR fragments
+	Primers float64 `json:"primers"`
+
+	// PCRReactions to prepare the PCR fragments
+	PCRReactions float64 `json:"pcrReactions"`
+
+	// Synthesis of the synthetic fragments
+	Synthesis float64 `json:"synthesis"`
+
+	// AssemblyReaction joining the fragments (eg Gibson Assembly or ligation)
+	AssemblyReaction float64 `json:"assemblyReaction"`
+
+	// Time is the human time cost of the assembly and PCRs
+	Time float64 `json:"time"`
+
+	// Discount from the vendors' institutional discounts (negative)
+	Discount float64 `json:"discount,omitempty"`
+
+	// Shipping and handling of the orders from each vendor
+	Shipping float64 `json:"shipping,omitempty"`
+
+	// Tax on the purchases and shipping
+	Tax float64 `json:"tax,omitempty"`
+}
+
+// add accumulates another breakdown into this one
+func (b *CostBreakdown) add(other CostBreakdown) {
+	b.Procurement += other.Procurement
+	b.Primers += other.Primers
+	b.PCRReactions += other.PCRReactions
+	b.Synthesis += other.Synthesis
+	b.AssemblyReaction += other.AssemblyReaction
+	b.Time += other.Time
+	b.Discount += other.Discount
+	b.Shipping += other.Shipping
+	b.Tax += other.Tax
+}
+
+// rounded returns the breakdown with each category rounded to two decimal places
+func (b CostBreakdown) rounded() CostBreakdown {
+	return CostBreakdown{
+		Procurement:      roundCost(b.Procurement),
+		Primers:          roundCost(b.Primers),
+		PCRReactions:     roundCost(b.PCRReactions),
+		Synthesis:        roundCost(b.Synthesis),
+		AssemblyReaction: roundCost(b.AssemblyReaction),
+		Time:             roundCost(b.Time),
+		Discount:         roundCost(b.Discount),
+		Shipping:         roundCost(b.Shipping),
+		Tax:              roundCost(b.Tax),
+	}
+}
+
+// scaled returns the breakdown with each category multiplied by the factor
+func (b CostBreakdown) scaled(factor float64) CostBreakdown {
+	return CostBreakdown{
+		Procurement:      b.Procurement * factor,
+		Primers:          b.Primers * factor,
+		PCRReactions:     b.PCRReactions * factor,
+		Synthesis:        b.Synthesis * factor,
+		AssemblyReaction: b.AssemblyReaction * factor,
+		Time:             b.Time * factor,
+		Discount:         b.Discount * factor,
+		Shipping:         b.Shipping * factor,
+		Tax:              b.Tax * factor,
+	}
+}
+
+// String returns the breakdown as it's written to the strategy CSV
+func (b CostBreakdown) String() string {
+	breakdown := fmt.Sprintf(
+		"procurement %.2f, primers %.2f, PCR reactions %.2f, synthesis %.2f, assembly reaction %.2f, time %.2f",
+		b.Procurement, b.Primers, b.PCRReactions, b.Synthesis, b.AssemblyReaction, b.Time,
+	)
+	if b.Discount != 0 || b.Shipping != 0 || b.Tax != 0 {
+		breakdown += fmt.Sprintf(", discount %.2f, shipping %.2f, tax %.2f", b.Discount, b.Shipping, b.Tax)
+	}
+	return breakdown
 }
 
 // Output is a struct containing design results for the assembly.
+// Its JSON follows the schema in pkg/output: keep changes additive within a major version.
+// The types of pkg/output mirror its fields, and a test fails if they drift apart.
 type Output struct {
+	// SchemaVersion of the output's JSON schema
+	SchemaVersion string `json:"schemaVersion"`
+
 	// Target's name. In >example_CDS FASTA its "example_CDS"
 	Target string `json:"target"`
 
@@ -50,25 +203,74 @@ type Output struct {
 	// Execution is the number of seconds it took to execute the command
 	Execution float64 `json:"execution"`
 
+	// Currency of the solutions' costs, ex: "USD"
+	Currency string `json:"currency,omitempty"`
+
 	// Solutions builds
 	Solutions []Solution `json:"solutions"`
 
 	// Backbone is the user linearized a backbone fragment
 	Backbone *Backbone `json:"backbone,omitempty"`
+
+	// ParetoFront is the solutions' pareto front of fragment count, synthetic fragment count and cost
+	ParetoFront []ParetoPoint `json:"paretoFront,omitempty"`
+
+	// Metadata of the run that produced this output
+	Metadata *RunMetadata `json:"metadata,omitempty"`
 }
 
-// writeResult
+// outputOptions are the files a result is written to, and how
+type outputOptions struct {
+	// filename of the output
+	filename string
+
+	// format of the output: JSON, CSV, XLSX, TSV-KV or JSON-V1
+	format string
+
+	// trackFormat of the annotation tracks written next to the output, none if empty
+	trackFormat string
+
+	// emitOrderFiles writes the vendor order files of the reagents to order
+	emitOrderFiles bool
+
+	// emitProtocol writes the bench protocols of the solutions
+	emitProtocol bool
+
+	// redact writes a copy of the output without the lab's identifiers
+	redact bool
+
+	// selfCheck fails the output if the solutions don't reassemble the target
+	selfCheck bool
+}
+
+// newOutputOptions returns the output options of the assembly params
+func newOutputOptions(assemblyParams AssemblyParams) outputOptions {
+	return outputOptions{
+		filename:       assemblyParams.GetOut(),
+		format:         assemblyParams.GetOutputFormat(),
+		trackFormat:    assemblyParams.GetTrackFormat(),
+		emitOrderFiles: assemblyParams.GetEmitOrderFiles(),
+		emitProtocol:   assemblyParams.GetEmitProtocol(),
+		redact:         assemblyParams.GetRedact(),
+		selfCheck:      assemblyParams.GetSelfCheck(),
+	}
+}
+
+// writeResult writes the solutions to the output files of the options
 func writeResult(
-	filename,
-	format,
+	opts outputOptions,
 	targetName,
 	targetSeq string,
 	assemblies [][]*Frag,
 	primersDB, synthFragsDB *oligosDB,
 	backbone *Backbone,
+	plan *priorPlan,
+	dbs []DB,
 	seconds float64,
 	conf *config.Config,
 ) (*Output, error) {
+	setRunStage(outputStage)
+	setRunTarget(len(targetSeq))
 	out, err := prepareSolutionsOutput(
 		targetName,
 		targetSeq,
@@ -80,14 +282,85 @@ func writeResult(
 	if err != nil {
 		return nil, err
 	}
-	if format == "CSV" {
-		err = writeCSV(filename, fragmentBase(filename), primersDB, synthFragsDB, conf.IncludeFragLocationInStrategyOutput, out)
+	if plan != nil {
+		for i := range out.Solutions {
+			out.Solutions[i].PriorReagents = plan.reusedReagents(out.Solutions[i].Fragments)
+		}
+	}
+	if opts.selfCheck {
+		if err = selfCheck(out.Solutions); err != nil {
+			return nil, err
+		}
+	}
+	applyPrimerReuse(out.Solutions, primersDB, conf.ToCurrency(conf.PrimerReuseBonus), time.Duration(conf.PrimerMaxUnvalidatedAgeDays)*24*time.Hour)
+	reportUniversalPrimers(out, conf)
+	out.Metadata = newRunMetadata(out, dbs, conf)
+	names, err := newNamingMap(conf.NamingMap)
+	if err != nil {
+		return nil, err
+	}
+	var fragIDs *fragIDNamer
+	if opts.format == "CSV" || opts.format == "XLSX" {
+		fragIDs, err = newFragIDNamer(conf.FragmentIDTemplate, conf.Project, targetName, fragmentBase(opts.filename), synthFragsDB)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.format == "CSV" || opts.format == "XLSX" || opts.emitOrderFiles || opts.emitProtocol {
+		// don't reuse the labels of previous outputs in the directory for other sequences
+		if err = avoidNameCollisions(opts.filename, out, names, primersDB, synthFragsDB, fragIDs, conf.GetOutputNameCollisions()); err != nil {
+			return nil, err
+		}
+	}
+	if opts.format == "CSV" || opts.format == "XLSX" {
+		if opts.format == "CSV" {
+			err = writeCSV(opts.filename, fragIDs, names, primersDB, synthFragsDB, conf.IncludeFragLocationInStrategyOutput, conf.Strict, out)
+		} else {
+			err = writeXLSXOutput(opts.filename, fragIDs, names, primersDB, synthFragsDB, conf.IncludeFragLocationInStrategyOutput, conf.Strict, out)
+		}
+		if err == nil {
+			err = writeMetadata(metadataFilename(opts.filename), out.Metadata)
+		}
+	} else if opts.format == "TSV-KV" {
+		err = writeTSVKV(opts.filename, out)
+	} else if opts.format == "JSON-V1" {
+		err = writeLegacyJSON(opts.filename, out)
 	} else {
-		err = writeJSON(filename, out)
+		err = writeJSON(opts.filename, out)
+	}
+	if err == nil && opts.trackFormat != "" {
+		err = writeTracks(opts.filename, opts.trackFormat, out)
+	}
+	if err == nil && opts.emitOrderFiles {
+		err = writeOrderFiles(opts.filename, out, names, primersDB, synthFragsDB)
+	}
+	if err == nil && opts.emitProtocol {
+		err = writeProtocols(opts.filename, out, names, primersDB, synthFragsDB, conf)
+	}
+	if err == nil && opts.redact {
+		err = writeRedacted(opts.filename, opts.format, out, conf)
+	}
+	if err == nil {
+		reportOutput(opts.filename, out)
 	}
 	return out, err
 }
 
+// outputTime formats the time of a result, using the same format as log.Println
+// https://golang.org/pkg/log/#Println
+func outputTime(t time.Time) string {
+	return fmt.Sprintf(
+		"%d/%02d/%02d %02d:%02d:%02d",
+		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(),
+	)
+}
+
+// roundCost rounds a cost to two decimal places
+func roundCost(cost float64) float64 {
+	rounded, _ := strconv.ParseFloat(fmt.Sprintf("%.2f", cost), 64)
+	return rounded
+}
+
 // prepareSolutionsOutput turns a list of solutions into a Solution object.
 func prepareSolutionsOutput(
 	targetName,
@@ -97,24 +370,18 @@ func prepareSolutionsOutput(
 	seconds float64,
 	conf *config.Config,
 ) (out *Output, err error) {
-	// store save time, using same format as log.Println https://golang.org/pkg/log/#Println
-	t := time.Now() // https://gobyexample.com/time-formatting-parsing
-	time := fmt.Sprintf(
-		"%d/%02d/%02d %02d:%02d:%02d",
-		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(),
-	)
-	roundCost := func(cost float64) (float64, error) {
-		return strconv.ParseFloat(fmt.Sprintf("%.2f", cost), 64)
-	}
-
 	// calculate final cost of the assembly and fragment count
 	solutions := []Solution{}
+	unstable := findUnstableRegions(targetSeq)
 	for _, assembly := range assemblies {
 		assemblyCost := 0.0
 		assemblyAdjustedCost := 0.0
+		var breakdown CostBreakdown
+		purchases := vendorPurchases{}
 		assemblyFragmentIDs := make(map[string]bool)
-		gibson := false // whether it will be assembled via Gibson assembly
-		hasPCR := false // whether there will be a batch PCR
+		gibson := false    // whether it will be assembled via Gibson assembly
+		hasPCR := false    // whether there will be a batch PCR
+		longRange := false // whether any of the PCRs are long-range
 		npcrs := 0
 		nsynths := 0
 		for _, f := range assembly {
@@ -126,6 +393,8 @@ func prepareSolutionsOutput(
 			if f.fragType == pcr {
 				hasPCR = true
 				npcrs++
+				f.LongRange = f.longRange()
+				longRange = longRange || f.LongRange
 			} else {
 				nsynths++
 			}
@@ -134,17 +403,15 @@ func prepareSolutionsOutput(
 			// if it's already in the assembly, don't count cost twice
 			if _, contained := assemblyFragmentIDs[f.ID]; f.ID != "" && contained {
 				fragCost, fragAdjustedCost = f.cost(false)
+				breakdown.add(purchases.add(f, f.costBreakdown(false)))
 			} else {
 				fragCost, fragAdjustedCost = f.cost(true) // do not include procurement costs twice
+				breakdown.add(purchases.add(f, f.costBreakdown(true)))
 				assemblyFragmentIDs[f.ID] = true
 			}
 			// round to two decimal places
-			if f.Cost, err = roundCost(fragCost); err != nil {
-				return nil, err
-			}
-			if f.AdjustedCost, err = roundCost(fragAdjustedCost); err != nil {
-				return nil, err
-			}
+			f.Cost = roundCost(fragCost)
+			f.AdjustedCost = roundCost(fragAdjustedCost)
 
 			// accumulate assembly cost
 			assemblyCost += f.Cost
@@ -154,30 +421,49 @@ func prepareSolutionsOutput(
 		if gibson {
 			assemblyCost += conf.GibsonAssemblyCost + conf.GibsonAssemblyTimeCost
 			assemblyAdjustedCost += conf.GibsonAssemblyCost + conf.GibsonAssemblyTimeCost
+			breakdown.AssemblyReaction += conf.GibsonAssemblyCost
+			breakdown.Time += conf.GibsonAssemblyTimeCost
 		}
 
 		if hasPCR {
 			assemblyCost += conf.PcrTimeCost
 			assemblyAdjustedCost += conf.PcrTimeCost
+			breakdown.Time += conf.PcrTimeCost
 		}
 
-		solutionCost, err := roundCost(assemblyCost)
-		if err != nil {
-			return nil, err
-		}
-		solutionAdjustedCost, err := roundCost(assemblyAdjustedCost)
-		if err != nil {
-			return nil, err
+		if longRange {
+			assemblyCost += conf.PcrLongRangeTimeCost
+			assemblyAdjustedCost += conf.PcrLongRangeTimeCost
+			breakdown.Time += conf.PcrLongRangeTimeCost
 		}
 
-		solutions = append(solutions, Solution{
+		s := Solution{
 			Count:           len(assembly),
-			Cost:            solutionCost,
-			AdjustedCost:    solutionAdjustedCost,
+			Cost:            roundCost(assemblyCost),
+			AdjustedCost:    roundCost(assemblyAdjustedCost),
+			CostBreakdown:   breakdown.rounded(),
 			Fragments:       assembly,
 			pcrFragsCount:   npcrs,
 			synthFragsCount: nsynths,
-		})
+			SuccessScore:    successScore(assembly, conf),
+		}
+		s.Features, s.FeatureCoverage = solutionFeatures(assembly)
+		s.Junctions = assemblyJunctions(assembly, targetSeq, conf)
+		if conf.PcrAnnealingWindow > 0 {
+			s.AnnealingTemp = sharedAnnealingTemp(assembly)
+		}
+		if conf.GoldenGateOverhangs {
+			s.GoldenGate = goldenGateOverhangs(assembly, conf)
+		}
+		s.Warnings = pcrTemplateWarnings(assembly, conf)
+		s.Warnings = append(s.Warnings, primerAdditionWarnings(assembly, conf)...)
+		s.Warnings = append(s.Warnings, noPCRWarnings(assembly, referenceMatches, len(targetSeq), conf.PcrMinFragLength)...)
+		s.Warnings = append(s.Warnings, stabilityWarnings(assembly, unstable, len(targetSeq))...)
+		if s.reconstructionErr = checkReconstruction(assembly, targetSeq, conf.FragmentsMinHomology, conf.FragmentsMaxHomology+1); s.reconstructionErr != nil {
+			s.Warnings = append(s.Warnings, s.reconstructionErr.Error())
+		}
+		applyPurchaseTerms(&s, purchases, conf)
+		solutions = append(solutions, s)
 	}
 
 	// sort solutions in increasing fragment count order
@@ -185,17 +471,43 @@ func prepareSolutionsOutput(
 		return solutions[i].Count < solutions[j].Count
 	})
 
+	// the digested backbone is in every solution, its warnings were logged when it was digested
+	warned := make(map[string]bool)
+	for i := range solutions {
+		solutions[i].Warnings = append(solutions[i].Warnings, backbone.warnings...)
+	}
+	for _, w := range backbone.warnings {
+		warned[w] = true
+	}
+
+	// warn once about each PCR template that may fail, it may be in several solutions
+	for _, s := range solutions {
+		for _, w := range s.Warnings {
+			if !warned[w] {
+				rlog.Warn(w)
+				warned[w] = true
+			}
+		}
+	}
+
 	if backbone.Seq == "" {
 		backbone = nil
 	}
+	addScreening(solutions, targetSeq, backbone)
+	addQPCRAssays(solutions, targetSeq, conf)
+	explainSolutions(solutions, len(targetSeq), conf)
+	paretoPoints := paretoFront(solutions)
 
 	out = &Output{
-		Time:      time,
-		Target:    targetName,
-		TargetSeq: strings.ToUpper(targetSeq),
-		Execution: seconds,
-		Solutions: solutions,
-		Backbone:  backbone,
+		SchemaVersion: output.SchemaVersion,
+		Time:          outputTime(time.Now()),
+		Target:        targetName,
+		TargetSeq:     strings.ToUpper(targetSeq),
+		Execution:     seconds,
+		Currency:      conf.Currency,
+		Solutions:     solutions,
+		Backbone:      backbone,
+		ParetoFront:   paretoPoints,
 	}
 
 	return out, nil
@@ -204,25 +516,27 @@ func prepareSolutionsOutput(
 // writeCSV writes solutions as csv.
 // The results are output to two csv files;
 // one containing the strategy and the other one the reagents
-func writeCSV(filename, fragmentIDBase string,
+func writeCSV(filename string,
+	fragIDs *fragIDNamer,
+	names namingMap,
 	existingPrimers, existingSynthFrags *oligosDB,
-	withFragLocation bool,
+	withFragLocation, strict bool,
 	out *Output) (err error) {
 
 	reagentsFilename := resultFilename(filename, "reagents")
 	strategyFilename := resultFilename(filename, "strategy")
 
-	reagentsFile, err := os.Create(reagentsFilename)
+	reagentsFile, err := createAtomic(reagentsFilename)
 	if err != nil {
 		return err
 	}
-	defer reagentsFile.Close()
+	defer reagentsFile.close(&err)
 
-	strategyFile, err := os.Create(strategyFilename)
+	strategyFile, err := createAtomic(strategyFilename)
 	if err != nil {
 		return err
 	}
-	defer strategyFile.Close()
+	defer strategyFile.close(&err)
 
 	strategyCSVWriter := csv.NewWriter(strategyFile)
 	// write timestamp
@@ -242,6 +556,7 @@ func writeCSV(filename, fragmentIDBase string,
 			"Template",
 			"Size",
 			"Match Pct",
+			"Mismatches",
 			"Frag Start",
 			"Frag End",
 			"Template Start",
@@ -250,6 +565,7 @@ func writeCSV(filename, fragmentIDBase string,
 			"50 low GC%",
 			"50 high GC%",
 			"Homopolymer",
+			"Notes",
 		}
 	} else {
 		headers = []string{
@@ -259,15 +575,17 @@ func writeCSV(filename, fragmentIDBase string,
 			"Template",
 			"Size",
 			"Match Pct",
+			"Mismatches",
 			"GC%",
 			"50 low GC%",
 			"50 high GC%",
 			"Homopolymer",
+			"Notes",
 		}
 	}
 	err = strategyCSVWriter.Write(headers)
 	if err != nil {
-		return nil
+		return err
 	}
 	// Write the reagents headers
 	err = reagentsCSVWriter.Write([]string{
@@ -277,6 +595,19 @@ func writeCSV(filename, fragmentIDBase string,
 		"Tm",
 		"Notes",
 	})
+	// offset maps of the templates' databases, by database path, to report template
+	// coordinates against the original sequence files
+	dbOffsets := make(map[string]map[string]entryOffsets)
+	templateOffsets := func(f *Frag) (entryOffsets, error) {
+		if _, ok := dbOffsets[f.db.Path]; !ok {
+			offsets, err := f.db.entryOffsets(strict)
+			if err != nil {
+				return entryOffsets{}, err
+			}
+			dbOffsets[f.db.Path] = offsets
+		}
+		return dbOffsets[f.db.Path][entryID(f.ID)], nil
+	}
 	for si, s := range out.Solutions {
 		snumber := si + 1
 		// Write the solution cost and the number of fragments
@@ -287,56 +618,68 @@ func writeCSV(filename, fragmentIDBase string,
 			s.Cost, s.AdjustedCost); err != nil {
 			return err
 		}
-		if _, err = fmt.Fprintf(reagentsFile, "# Solution %d\n", snumber); err != nil {
+		if out.Currency != "" {
+			_, err = fmt.Fprintf(strategyFile, "# Cost breakdown (%s): %s\n", out.Currency, s.CostBreakdown)
+		} else {
+			_, err = fmt.Fprintf(strategyFile, "# Cost breakdown: %s\n", s.CostBreakdown)
+		}
+		if err != nil {
 			return err
 		}
-		reagents := []oligo{}
-		var newPrimerIndex int = 0
-		var newSynthFragIndex int = 0
-
-		newPrimers := newOligosDB(primerIDPrefix, false)
-		newSynthFrags := newOligosDB(synthFragIDPrefix, true)
-
-		var updatedPrimerDBs []*oligosDB = []*oligosDB{
-			existingPrimers,
-			newPrimers,
+		if s.SuccessScore > 0 {
+			if _, err = fmt.Fprintf(strategyFile, "# Predicted success: %.2f\n", s.SuccessScore); err != nil {
+				return err
+			}
 		}
-
-		var updatedSynthFragsDBs []*oligosDB = []*oligosDB{
-			existingSynthFrags,
-			newSynthFrags,
+		if s.AnnealingTemp > 0 {
+			if _, err = fmt.Fprintf(strategyFile, "# Annealing temperature: %.1f\n", s.AnnealingTemp); err != nil {
+				return err
+			}
+		}
+		for _, e := range s.Explanation {
+			if _, err = fmt.Fprintf(strategyFile, "# Explanation: %s\n", e); err != nil {
+				return err
+			}
+		}
+		if s.Screening != nil {
+			if s.Screening.DiagnosticEnzyme != "" {
+				if _, err = fmt.Fprintf(strategyFile, "# Diagnostic digest: %s\n", s.Screening.DiagnosticEnzyme); err != nil {
+					return err
+				}
+			}
+			for _, p := range s.Screening.Products {
+				if _, err = fmt.Fprintf(strategyFile, "# Product: %s\n", p); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err = fmt.Fprintf(reagentsFile, "# Solution %d\n", snumber); err != nil {
+			return err
 		}
+		reagents := []oligo{}
+		reagentIDs := newReagentIDs(existingPrimers, existingSynthFrags, names)
+		usedFragIDs := make(map[string]bool)
 
 		for fi, f := range s.Fragments {
 			fnumber := fi + 1
 			var fwdPrimer, revPrimer Primer
 			var synthSeq string
 
-			fID := fmt.Sprintf("%s_%d_%s", fragmentIDBase, fnumber, fragTypeAsString(f.fragType))
+			var fID string
 			fwdPrimer, revPrimer = f.getPrimers()
 			if fwdPrimer.Seq == "" && revPrimer.Seq == "" {
 				synthSeq = f.Seq
 			}
 
-			fwdOligo := searchOligoDBs(fwdPrimer.Seq, updatedPrimerDBs)
+			fwdOligo := reagentIDs.primer(fwdPrimer.Seq)
 			if !fwdOligo.isEmpty() {
-				if !fwdOligo.hasID() {
-					fwdOligo.assignNewOligoID(existingPrimers.getNewOligoID(newPrimerIndex))
-					newPrimers.addOligo(fwdOligo)
-					newPrimerIndex++
-				}
 				fwdOligo.primingRegion = fwdPrimer.PrimingRegion
 				fwdOligo.tm = fwdPrimer.Tm
 				fwdOligo.notes = fwdPrimer.Notes
 				reagents = append(reagents, fwdOligo)
 			}
-			revOligo := searchOligoDBs(revPrimer.Seq, updatedPrimerDBs)
+			revOligo := reagentIDs.primer(revPrimer.Seq)
 			if !revOligo.isEmpty() {
-				if !revOligo.hasID() {
-					revOligo.assignNewOligoID(existingPrimers.getNewOligoID(newPrimerIndex))
-					newPrimers.addOligo(revOligo)
-					newPrimerIndex++
-				}
 				revOligo.primingRegion = revPrimer.PrimingRegion
 				revOligo.tm = revPrimer.Tm
 				revOligo.notes = revPrimer.Notes
@@ -344,6 +687,7 @@ func writeCSV(filename, fragmentIDBase string,
 			}
 			var templateID string
 			var matchRatio string
+			var mismatches string
 			var pcrSeqSize int
 			var fragStart, fragEnd, templateStart, templateEnd string
 			var gcContentCol string
@@ -351,16 +695,11 @@ func writeCSV(filename, fragmentIDBase string,
 			var max50GCContentCol string
 			var homopolymerCol string
 			if f.fragType == synthetic {
-				synthReagent := searchOligoDBs(synthSeq, updatedSynthFragsDBs)
-				if !synthReagent.hasID() {
-					synthReagent.assignNewOligoID(existingSynthFrags.getNewOligoID(newSynthFragIndex))
-					synthReagent.synth = true
-					newSynthFrags.addOligo(synthReagent)
-					newSynthFragIndex++
-				}
+				synthReagent := reagentIDs.synthFrag(synthSeq)
 				fID = synthReagent.id
 				templateID = "N/A"
 				matchRatio = "N/A"
+				mismatches = "N/A"
 				pcrSeqSize = len(f.Seq)
 				fragStart = fmt.Sprintf("%d", f.start)
 				fragEnd = fmt.Sprintf("%d", f.end)
@@ -373,8 +712,13 @@ func writeCSV(filename, fragmentIDBase string,
 				max50GCContentCol = fmt.Sprintf("%3.1f", synthFragScores.max50WindowGCContent*100)
 				homopolymerCol = strconv.Itoa(synthFragScores.longestHomopolymer)
 			} else {
-				templateID = fragmentBase(f.ID)
+				fID = fragIDs.name(snumber, fnumber, f, usedFragIDs)
+				templateID = templateName(f.ID, names)
 				matchRatio = fmt.Sprintf("%d", int(f.matchRatio*100))
+				mismatches = strings.Trim(fmt.Sprint(f.Mismatches), "[]")
+				if f.MismatchesUnknown {
+					mismatches = "unknown"
+				}
 				// for PCR fragments display the length including the overhanging primers
 				pcrSeqSize = len(f.PCRSeq)
 				if f.revCompFlag {
@@ -392,12 +736,21 @@ func writeCSV(filename, fragmentIDBase string,
 						fragEnd = fmt.Sprintf("%d", f.end)
 					}
 				}
-				if f.revCompTemplateFlag {
-					templateStart = fmt.Sprintf("%d", f.templateEnd)
-					templateEnd = fmt.Sprintf("%d", f.templateStart)
+				offsets := entryOffsets{}
+				if f.db.Path != "" {
+					if offsets, err = templateOffsets(f); err != nil {
+						return err
+					}
+				}
+				if f.templateStart == 0 && f.templateEnd == 0 {
+					// unknown for fragments read from a JSON output
+					templateStart, templateEnd = "N/A", "N/A"
+				} else if f.revCompTemplateFlag {
+					templateStart = fmt.Sprintf("%d", offsets.originalIndex(f.templateEnd))
+					templateEnd = fmt.Sprintf("%d", offsets.originalIndex(f.templateStart))
 				} else {
-					templateStart = fmt.Sprintf("%d", f.templateStart)
-					templateEnd = fmt.Sprintf("%d", f.templateEnd)
+					templateStart = fmt.Sprintf("%d", offsets.originalIndex(f.templateStart))
+					templateEnd = fmt.Sprintf("%d", offsets.originalIndex(f.templateEnd))
 				}
 				gcContentCol = "N/A"
 				min50GCContentCol = "N/A"
@@ -411,6 +764,7 @@ func writeCSV(filename, fragmentIDBase string,
 				"Template":       templateID,                            // template
 				"Size":           strconv.Itoa(pcrSeqSize),
 				"Match Pct":      matchRatio,
+				"Mismatches":     mismatches,
 				"Frag Start":     fragStart,
 				"Frag End":       fragEnd,
 				"Template Start": templateStart,
@@ -419,16 +773,32 @@ func writeCSV(filename, fragmentIDBase string,
 				"50 low GC%":     min50GCContentCol,
 				"50 high GC%":    max50GCContentCol,
 				"Homopolymer":    homopolymerCol,
+				"Notes":          strings.Join(f.Warnings, "; "),
 			}
 			var fields []string
 			for _, h := range headers {
 				fields = append(fields, fieldMapping[h])
 			}
 			if err = strategyCSVWriter.Write(fields); err != nil {
-				return nil
+				return err
 			}
 		}
 		strategyCSVWriter.Flush()
+		for _, assay := range s.QPCR {
+			for _, p := range []struct {
+				primer Primer
+				name   string
+			}{{assay.Forward, "forward primer"}, {assay.Reverse, "reverse primer"}, {assay.Probe, "probe"}} {
+				qpcrOligo := reagentIDs.primer(p.primer.Seq)
+				if qpcrOligo.isEmpty() {
+					continue
+				}
+				qpcrOligo.primingRegion = p.primer.Seq
+				qpcrOligo.tm = p.primer.Tm
+				qpcrOligo.notes = qpcrNotes(assay, p.name)
+				reagents = append(reagents, qpcrOligo)
+			}
+		}
 		sort.Sort(sortedOligosByID(reagents))
 		for _, r := range reagents {
 			err = writeReagent(reagentsCSVWriter, r)
@@ -438,8 +808,96 @@ func writeCSV(filename, fragmentIDBase string,
 		}
 		reagentsCSVWriter.Flush()
 	}
+	if err = strategyCSVWriter.Error(); err != nil {
+		return err
+	}
+	if err = reagentsCSVWriter.Error(); err != nil {
+		return err
+	}
+
+	// end both files with a footer so truncated copies can be told apart
+	if err = strategyFile.writeFooter(); err != nil {
+		return err
+	}
+	if err = reagentsFile.writeFooter(); err != nil {
+		return err
+	}
+
+	if err = writeJunctions(resultFilename(filename, "junctions"), out); err != nil {
+		return err
+	}
+	return writePickList(resultFilename(filename, "picklist"), out, names)
+}
+
+// writeXLSXOutput writes solutions as a workbook with the strategy, reagents, junctions and,
+// if any primers are reused, the pick list as sheets. The sheets are the same as the CSV files.
+func writeXLSXOutput(filename string,
+	fragIDs *fragIDNamer,
+	names namingMap,
+	existingPrimers, existingSynthFrags *oligosDB,
+	withFragLocation, strict bool,
+	out *Output) error {
+	tmpDir, err := os.MkdirTemp("", "repp-xlsx-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
 
-	return nil
+	csvFilename := filepath.Join(tmpDir, strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))+".csv")
+	if err = writeCSV(csvFilename, fragIDs, names, existingPrimers, existingSynthFrags, withFragLocation, strict, out); err != nil {
+		return err
+	}
+
+	var sheets []xlsxSheet
+	for _, table := range []struct{ suffix, name string }{{"strategy", "Strategy"}, {"reagents", "Reagents"}, {"junctions", "Junctions"}, {"picklist", "Pick List"}} {
+		contents, err := os.ReadFile(resultFilename(csvFilename, table.suffix))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if table.suffix == "strategy" || table.suffix == "reagents" {
+			if contents, err = stripFooter(contents); err != nil {
+				return fmt.Errorf("failed to convert the %s to xlsx: %v", table.suffix, err)
+			}
+		}
+		rows, err := csvRows(string(contents))
+		if err != nil {
+			return fmt.Errorf("failed to convert the %s to xlsx: %v", table.suffix, err)
+		}
+		sheets = append(sheets, xlsxSheet{name: table.name, rows: rows})
+	}
+	return writeXLSX(filename, sheets)
+}
+
+// csvRows splits CSV into rows of cells. Comment lines are kept as a row of one cell
+func csvRows(contents string) (rows [][]string, err error) {
+	for _, line := range strings.Split(strings.TrimRight(contents, "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			rows = append(rows, []string{line})
+			continue
+		}
+		r := csv.NewReader(strings.NewReader(line))
+		r.FieldsPerRecord = -1
+		row, err := r.Read()
+		if err == io.EOF {
+			row, err = []string{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// templateName is the name of a fragment's template in the strategy: renamed by the naming
+// map, or the first component of its ID
+func templateName(id string, names namingMap) string {
+	if renamed, ok := names.match(id); ok {
+		return renamed
+	}
+	return fragmentBase(id)
 }
 
 func fragmentBase(filename string) string {
@@ -479,12 +937,17 @@ func writeReagent(csvWriter *csv.Writer, reagent oligo) (err error) {
 			primingRegion = reagent.primingRegion
 			tm = fmt.Sprintf("%.2f", reagent.tm)
 		}
+		notes := reagent.notes
+		if inventory := reagent.inventoryNotes(); !reagent.isNew && inventory != "" {
+			// where to find the reagent on hand, and whether it was validated
+			notes = strings.TrimPrefix(notes+"; "+inventory, "; ")
+		}
 		err = csvWriter.Write([]string{
 			reagentID,
 			reagent.seq,
 			primingRegion,
 			tm,
-			reagent.notes,
+			notes,
 		})
 	}
 	return
@@ -492,20 +955,24 @@ func writeReagent(csvWriter *csv.Writer, reagent oligo) (err error) {
 
 // writeJSON writes solutions as json.
 func writeJSON(filename string, out *Output) (err error) {
-	output, err := json.MarshalIndent(out, "", "  ")
+	contents, err := json.MarshalIndent(out, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to serialize output: %v", err)
 	}
 
-	if err = os.WriteFile(filename, output, 0666); err != nil {
+	if err = writeFileAtomic(filename, contents); err != nil {
 		return fmt.Errorf("failed to write the output: %v", err)
 	}
 
 	return
 }
 
-// writeFragsToFastaFile writes a slice of fragments to a FASTA file
-func writeFragsToFastaFile(frags []*Frag, maxIDLength int, circularize bool, fastaFile *os.File) (err error) {
+// writeFragsToFastaFile writes a slice of fragments to a FASTA file.
+// IDs are truncated to maxIDLength and duplicates are disambiguated with a base-26 suffix,
+// or are an error in strict mode. Fragments are written as circular if circularize is set or if
+// their GenBank LOCUS line says they are, not for FASTA headers that mention "circular".
+// entries maps each written entry ID to the fragment written with it.
+func writeFragsToFastaFile(frags []*Frag, maxIDLength int, circularize, strict bool, fastaFile io.Writer) (entries map[string]*Frag, err error) {
 	truncID := func(s string) string {
 		if len(s) < maxIDLength {
 			return s
@@ -514,6 +981,8 @@ func writeFragsToFastaFile(frags []*Frag, maxIDLength int, circularize bool, fas
 		}
 	}
 
+	entries = make(map[string]*Frag)
+
 	// create a multimap of fragments indexed by truncated ID
 	fragsByTruncatedIDs := make(map[string][]*Frag)
 	for _, f := range frags {
@@ -546,30 +1015,56 @@ func writeFragsToFastaFile(frags []*Frag, maxIDLength int, circularize bool, fas
 			// no duplicates
 			f := fragsWithFragID[0]
 			rlog.Debugf("Write %s", f.ID)
-			if ferr := writeSeqToFastaFile(fragID, f.Seq, circularize, fastaFile); ferr != nil {
+			if ferr := writeSeqToFastaFile(fragID, f.Seq, circularize || f.circularTopology, fastaFile); ferr != nil {
 				rlog.Errorf("Error writing fragment %s\n", f.ID)
 				err = multierr.Append(err, ferr)
+			} else {
+				entries[entryID(fragID)] = f
 			}
 		} else {
 			// handle duplicates
+			if strict {
+				return nil, fmt.Errorf("strict mode: %d sequence IDs are %s after truncation to %d characters", len(fragsWithFragID), fragID, maxIDLength)
+			}
 			rlog.Infof("%d blast DB fragment ID duplicates found for %s", len(fragsWithFragID), fragID)
 			for i, f := range fragsWithFragID {
 				fragIDPrefix := fragIDComponents(f.ID)[0]
 				fragIDSuffix := f.ID[len(fragIDPrefix):]
 				newFragID := truncID(fmt.Sprintf("%s%s%s", fragIDPrefix, base10ToBase26(i), fragIDSuffix))
 
-				if ferr := writeSeqToFastaFile(newFragID, f.Seq, circularize, fastaFile); ferr != nil {
+				if ferr := writeSeqToFastaFile(newFragID, f.Seq, circularize || f.circularTopology, fastaFile); ferr != nil {
 					rlog.Errorf("Error writing fragment %s\n", f.ID)
 					err = multierr.Append(err, ferr)
+				} else {
+					entries[entryID(newFragID)] = f
 				}
 			}
 		}
 	}
 
-	return err
+	return entries, err
 }
 
-func writeSeqToFastaFile(id, seq string, circular bool, fastaFile *os.File) (err error) {
+// renamedEntryIDs maps each written entry ID that differs from its fragment's entry ID to the latter
+func renamedEntryIDs(entries map[string]*Frag) map[string]string {
+	renamedIDs := make(map[string]string)
+	for id, f := range entries {
+		if id != entryID(f.ID) {
+			renamedIDs[id] = entryID(f.ID)
+		}
+	}
+	return renamedIDs
+}
+
+// entryID is the ID of a FASTA entry as BLAST reports it: the header up to the first space
+func entryID(header string) string {
+	if fields := strings.Fields(header); len(fields) > 0 {
+		return fields[0]
+	}
+	return header
+}
+
+func writeSeqToFastaFile(id, seq string, circular bool, fastaFile io.Writer) (err error) {
 	var outputSeq, circularAttr string
 	if circular {
 		firstHalf := seq[:len(seq)/2]
@@ -585,18 +1080,13 @@ func writeSeqToFastaFile(id, seq string, circular bool, fastaFile *os.File) (err
 		outputSeq = seq
 		circularAttr = ""
 	}
-	_, err = fastaFile.WriteString(fmt.Sprintf(">%s %s\n%s\n", id, circularAttr, outputSeq))
+	_, err = fmt.Fprintf(fastaFile, ">%s %s\n%s\n", id, circularAttr, outputSeq)
 	return err
 }
 
 // writeGenbank writes a slice of fragments/features to a genbank output file.
 func writeGenbank(filename, name, seq string, frags []*Frag, feats []match) {
-	// header row
-	d := time.Now().Local()
-	h1 := fmt.Sprintf("LOCUS       %s", name)
-	h2 := fmt.Sprintf("%d bp DNA      circular      %s\n", len(seq), strings.ToUpper(d.Format("02-Jan-2006")))
-	space := strings.Repeat(" ", 81-len(h1+h2))
-	header := h1 + space + h2
+	header := genbankLocus(name, len(seq), true)
 
 	// feature rows
 	var fsb strings.Builder
@@ -625,7 +1115,32 @@ func writeGenbank(filename, name, seq string, frags []*Frag, feats []match) {
 		)
 	}
 
-	// origin row
+	gb := strings.Join([]string{header, fsb.String(), genbankOrigin(seq)}, "")
+	err := writeFileAtomic(filename, []byte(gb))
+	if err != nil {
+		rlog.Fatal(err)
+	}
+}
+
+// genbankLocus returns the LOCUS line of a GenBank record, dated today
+func genbankLocus(name string, length int, circular bool) string {
+	topology := "circular"
+	if !circular {
+		topology = "linear  "
+	}
+	d := time.Now().Local()
+	h1 := fmt.Sprintf("LOCUS       %s", name)
+	h2 := fmt.Sprintf("%d bp DNA      %s      %s\n", length, topology, strings.ToUpper(d.Format("02-Jan-2006")))
+	pad := 81 - len(h1+h2)
+	if pad < 1 {
+		pad = 1
+	}
+	return h1 + strings.Repeat(" ", pad) + h2
+}
+
+// genbankOrigin returns the ORIGIN section of a GenBank record with the sequence, through
+// the record's ending //
+func genbankOrigin(seq string) string {
 	var ori strings.Builder
 	ori.WriteString("ORIGIN\n")
 	for i := 0; i < len(seq); i += 60 {
@@ -641,10 +1156,5 @@ func writeGenbank(filename, name, seq string, frags []*Frag, feats []match) {
 		ori.WriteString("\n")
 	}
 	ori.WriteString("//\n")
-
-	gb := strings.Join([]string{header, fsb.String(), ori.String()}, "")
-	err := os.WriteFile(filename, []byte(gb), 0644)
-	if err != nil {
-		rlog.Fatal(err)
-	}
+	return ori.String()
 }
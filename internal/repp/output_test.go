@@ -1,8 +1,17 @@
 package repp
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+	"github.com/Lattice-Automation/repp/pkg/output"
 )
 
 func Test_writeGenbank(t *testing.T) {
@@ -56,3 +65,273 @@ func Test_writeGenbank(t *testing.T) {
 		})
 	}
 }
+
+func Test_writeFragsToFastaFile_circular(t *testing.T) {
+	dir := t.TempDir()
+	fastaPath := filepath.Join(dir, "entries.fa")
+	if err := os.WriteFile(fastaPath, []byte(">pFasta circular\nATGCATGGCC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	genbankPath := filepath.Join(dir, "entries.gb")
+	genbank := "LOCUS       pGenbank      10 bp    DNA     circular SYN 01-JAN-2024\nORIGIN\n        1 ttgcatggcc\n//\n"
+	if err := os.WriteFile(genbankPath, []byte(genbank), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var frags []*Frag
+	for _, path := range []string{fastaPath, genbankPath} {
+		read, err := read(path, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frags = append(frags, read...)
+	}
+
+	tests := []struct {
+		name        string
+		circularize bool
+		want        map[string]string
+	}{
+		{"only GenBank circular topology", false, map[string]string{"pFasta": "ATGCATGGCC", "pGenbank": "TTGCATGGCCTTGCATGGCC"}},
+		{"circularize", true, map[string]string{"pFasta": "ATGCATGGCCATGCATGGCC", "pGenbank": "TTGCATGGCCTTGCATGGCC"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written bytes.Buffer
+			if _, err := writeFragsToFastaFile(frags, 50, tt.circularize, false, &written); err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			lines := strings.Split(strings.TrimSpace(written.String()), "\n")
+			for i := 0; i+1 < len(lines); i += 2 {
+				got[entryID(strings.TrimPrefix(lines[i], ">"))] = lines[i+1]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("writeFragsToFastaFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_prepareSolutionsOutput_costBreakdown(t *testing.T) {
+	c := config.New()
+	c.PcrBpCost = 0.5
+	c.PcrRxnCost = 1
+	c.PcrTimeCost = 2
+	c.GibsonAssemblyCost = 10
+	c.GibsonAssemblyTimeCost = 3
+	c.SyntheticMaxLength = 3000
+	c.SyntheticFragmentCost = map[int]config.SynthCost{
+		3000: {Fixed: true, Cost: 100},
+	}
+
+	primers := []Primer{{Seq: "ACGTACGTAC"}, {Seq: "ACGTACGTAC"}}
+	assembly := []*Frag{
+		{ID: "p1", fragType: pcr, Primers: primers, db: DB{Cost: 65}, conf: c},
+		{ID: "p1", fragType: pcr, Primers: primers, db: DB{Cost: 65}, conf: c}, // procured once
+		{ID: "s1", fragType: synthetic, Seq: "ACGT", conf: c},
+	}
+
+	out, err := prepareSolutionsOutput("target", "ACGT", [][]*Frag{assembly}, &Backbone{}, 0, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := out.Solutions[0]
+	want := CostBreakdown{
+		Procurement:      65,
+		Primers:          20,
+		PCRReactions:     2,
+		Synthesis:        100,
+		AssemblyReaction: 10,
+		Time:             5,
+	}
+	if s.CostBreakdown != want {
+		t.Errorf("prepareSolutionsOutput() cost breakdown = %+v, want %+v", s.CostBreakdown, want)
+	}
+	b := s.CostBreakdown
+	if total := b.Procurement + b.Primers + b.PCRReactions + b.Synthesis + b.AssemblyReaction + b.Time; total != s.Cost {
+		t.Errorf("prepareSolutionsOutput() cost breakdown adds up to %v, want %v", total, s.Cost)
+	}
+}
+
+func Test_Output_schema(t *testing.T) {
+	out := &Output{
+		SchemaVersion: output.SchemaVersion,
+		Target:        "target",
+		TargetSeq:     "ACGT",
+		Time:          "2019/06/24 11:51:39",
+		Execution:     1,
+		Currency:      "USD",
+		Solutions: []Solution{{
+			Count:         1,
+			Cost:          10,
+			AdjustedCost:  10,
+			CostBreakdown: CostBreakdown{Procurement: 1, Discount: -1, Shipping: 1, Tax: 1},
+			Fragments: []*Frag{{
+				ID:      "frag",
+				Type:    "pcr",
+				Seq:     "ACGT",
+				PCRSeq:  "ACGT",
+				Primers: []Primer{{Seq: "AC", Strand: true, Tm: 60, PrimingRegion: "AC", Notes: "note"}},
+			}},
+			Method:        "sticky",
+			Warnings:      []string{"warning"},
+			ReusedPrimers: 1,
+			PickList:      []PickListEntry{{ID: "oligo1", Seq: "AC", Plate: "P1", Well: "A1", Location: "freezer A", Validated: "yes", Ordered: "2023-05-01"}},
+			Screening: &Screening{
+				DiagnosticEnzyme: "EcoRI",
+				Products:         []PredictedProduct{{Name: "intended product", Length: 4, ColonyPCRBand: 200, DigestBands: []int{4}}},
+			},
+			Junctions: []Junction{{Left: 1, Right: 1, Seq: "AC", Length: 2, Tm: 10, Start: 1, End: 2}},
+			Verification: &Verification{
+				Time:        "2019/06/25 11:51:39",
+				Reads:       []string{"read"},
+				Coverage:    1,
+				Junctions:   []JunctionCoverage{{Fragment: 1, Start: 1, End: 2, Reads: []string{"read"}}},
+				Differences: []SeqDifference{{Position: 1, Type: "SNV", Expected: "A", Observed: "G", Reads: []string{"read"}, Depth: 1, Fragment: 1, Region: "template"}},
+			},
+		}},
+		Backbone: &Backbone{URL: "url", Seq: "ACGT", Enzymes: []string{"EcoRI"}, Cutsites: []int{1}, Strands: []bool{true}},
+		Metadata: &RunMetadata{
+			Version:     "1.0.0",
+			Commit:      "abc",
+			CommandLine: []string{"repp"},
+			Hostname:    "host",
+			Config:      map[string]interface{}{"pcr-bp-cost": 0.6},
+			Databases:   []DatabaseChecksum{{Name: "db", Path: "db.fa", SHA256: "abc"}},
+			PlanHash:    "abc",
+		},
+	}
+
+	contents, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every field written has to be in the schema
+	var value interface{}
+	if err = json.Unmarshal(contents, &value); err != nil {
+		t.Fatal(err)
+	}
+	schema := map[string]interface{}{}
+	if err = json.Unmarshal(output.Schema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if missing := fieldsMissingFromSchema(value, schema, schema["$defs"].(map[string]interface{}), ""); len(missing) > 0 {
+		t.Errorf("Output fields missing from the JSON schema: %v", missing)
+	}
+
+	// and be read back with the public decoder
+	decoded, err := output.Decode(bytes.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Solutions[0].Fragments[0].Primers[0].Notes; got != "note" {
+		t.Errorf("output.Decode() primer notes = %q, want %q", got, "note")
+	}
+	if got := decoded.Solutions[0].Screening.Products[0].ColonyPCRBand; got != 200 {
+		t.Errorf("output.Decode() colony PCR band = %d, want 200", got)
+	}
+}
+
+// fieldsMissingFromSchema returns the paths of the fields in a JSON value that aren't in its schema
+func fieldsMissingFromSchema(value interface{}, node, defs map[string]interface{}, path string) (missing []string) {
+	if ref, ok := node["$ref"].(string); ok {
+		node, _ = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, ok := node["properties"].(map[string]interface{})
+		if !ok {
+			return nil // free-form object
+		}
+		for key, field := range v {
+			property, ok := properties[key].(map[string]interface{})
+			if !ok {
+				missing = append(missing, path+"/"+key)
+				continue
+			}
+			missing = append(missing, fieldsMissingFromSchema(field, property, defs, path+"/"+key)...)
+		}
+	case []interface{}:
+		items, _ := node["items"].(map[string]interface{})
+		for _, item := range v {
+			missing = append(missing, fieldsMissingFromSchema(item, items, defs, path+"[]")...)
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
+
+func Test_Output_publicFields(t *testing.T) {
+	// the legacy output has every field of Output, and the fields only JSON-V1 outputs have
+	if diff := jsonFieldsDiff(reflect.TypeOf(legacyOutput{}), reflect.TypeOf(output.Output{}), "output"); len(diff) > 0 {
+		t.Errorf("Output and output.Output have different JSON fields, keep pkg/output in step: %v", diff)
+	}
+}
+
+// jsonFieldsDiff returns the paths of the JSON fields in only one of two types, or with
+// a struct in one and not the other, recursing into the structs of the fields in both
+func jsonFieldsDiff(a, b reflect.Type, path string) (diff []string) {
+	a, b = jsonElem(a), jsonElem(b)
+	if a.Kind() != reflect.Struct || b.Kind() != reflect.Struct {
+		if a.Kind() == reflect.Struct || b.Kind() == reflect.Struct {
+			diff = append(diff, path)
+		}
+		return diff
+	}
+
+	aFields, bFields := jsonFields(a), jsonFields(b)
+	for name, aField := range aFields {
+		if bField, ok := bFields[name]; ok {
+			diff = append(diff, jsonFieldsDiff(aField, bField, path+"/"+name)...)
+		} else {
+			diff = append(diff, path+"/"+name)
+		}
+	}
+	for name := range bFields {
+		if _, ok := aFields[name]; !ok {
+			diff = append(diff, path+"/"+name)
+		}
+	}
+
+	sort.Strings(diff)
+	return diff
+}
+
+// jsonElem returns the type of the elements of pointers, slices and maps
+func jsonElem(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonFields returns the types of a struct's fields by their JSON names
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && jsonElem(f.Type).Kind() == reflect.Struct {
+			for embedded, ft := range jsonFields(jsonElem(f.Type)) {
+				fields[embedded] = ft
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
@@ -0,0 +1,158 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParetoPoint is a point on the pareto front of solutions: a fragment count, synthetic
+// fragment count and cost where no other solution is at least as good in all three
+// and better in one
+type ParetoPoint struct {
+	// Count is the number of fragments
+	Count int `json:"count"`
+
+	// SynthCount is the number of synthetic fragments
+	SynthCount int `json:"synthCount"`
+
+	// Cost of the solutions at the point
+	Cost float64 `json:"cost"`
+
+	// Solutions at the point, 1-based
+	Solutions []int `json:"solutions"`
+}
+
+// objectives are the fragment count, synthetic fragment count and cost, all minimized
+type objectives struct {
+	count, synths int
+	cost          float64
+}
+
+// dominates returns whether o is at least as good as other in every objective and better in one
+func (o objectives) dominates(other objectives) bool {
+	if o.count > other.count || o.synths > other.synths || o.cost > other.cost {
+		return false
+	}
+	return o != other
+}
+
+// onParetoFront returns whether each of the points is on the pareto front
+func onParetoFront(points []objectives) []bool {
+	front := make([]bool, len(points))
+	for i, p := range points {
+		front[i] = true
+		for _, other := range points {
+			if other.dominates(p) {
+				front[i] = false
+				break
+			}
+		}
+	}
+	return front
+}
+
+// paretoFront flags the solutions on the pareto front and returns the front's points,
+// in increasing fragment count order
+func paretoFront(solutions []Solution) (points []ParetoPoint) {
+	var objs []objectives
+	for _, s := range solutions {
+		objs = append(objs, objectives{s.Count, s.synthFragsCount, s.Cost})
+	}
+
+	byPoint := make(map[objectives]int)
+	for i, front := range onParetoFront(objs) {
+		solutions[i].Pareto = front
+		if !front {
+			continue
+		}
+		if p, ok := byPoint[objs[i]]; ok {
+			points[p].Solutions = append(points[p].Solutions, i+1)
+			continue
+		}
+		byPoint[objs[i]] = len(points)
+		points = append(points, ParetoPoint{
+			Count:      objs[i].count,
+			SynthCount: objs[i].synths,
+			Cost:       objs[i].cost,
+			Solutions:  []int{i + 1},
+		})
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].Count != points[j].Count {
+			return points[i].Count < points[j].Count
+		}
+		return points[i].Cost < points[j].Cost
+	})
+	return points
+}
+
+// paretoCandidates returns the best assembly of each estimated fragment and synthetic
+// fragment count, to fill for the pareto front. The costs estimated before filling change
+// once the primers and synthetic fragments are made, so the front is picked after.
+// The assemblies are sorted best first.
+func paretoCandidates(assemblies []assembly) (candidates []assembly) {
+	type counts struct{ count, synths int }
+	seen := make(map[counts]bool)
+	for _, a := range assemblies {
+		if c := (counts{a.len(), a.synths}); !seen[c] {
+			candidates = append(candidates, a)
+			seen[c] = true
+		}
+	}
+	return candidates
+}
+
+// paretoAssemblies returns one filled assembly per point of the pareto front of the
+// assemblies' fragment counts, synthetic fragment counts and costs. The first assembly
+// at each point represents it.
+func paretoAssemblies(filled []*assembly) (front []*assembly) {
+	var objs []objectives
+	for _, a := range filled {
+		objs = append(objs, objectives{a.len(), a.synths, a.cost})
+	}
+
+	seen := make(map[objectives]bool)
+	for i, onFront := range onParetoFront(objs) {
+		if onFront && !seen[objs[i]] {
+			front = append(front, filled[i])
+			seen[objs[i]] = true
+		}
+	}
+	return front
+}
+
+// paretoChart is a text bar chart of the cost at each point of the pareto front
+func paretoChart(points []ParetoPoint, currency string) string {
+	const width = 40
+	maxCost := 0.0
+	for _, p := range points {
+		if p.Cost > maxCost {
+			maxCost = p.Cost
+		}
+	}
+
+	cost := "cost"
+	if currency != "" {
+		cost += " (" + currency + ")"
+	}
+	var chart strings.Builder
+	fmt.Fprintf(&chart, "pareto front: %s vs fragments (synthetic)\n", cost)
+	for _, p := range points {
+		bar := 1
+		if maxCost > 0 {
+			bar = int(p.Cost / maxCost * width)
+			if bar < 1 {
+				bar = 1
+			}
+		}
+		var solutions []string
+		for _, s := range p.Solutions {
+			solutions = append(solutions, fmt.Sprint(s))
+		}
+		fmt.Fprintf(&chart, "%3d (%d) | %-*s %.2f  solution %s\n",
+			p.Count, p.SynthCount, width, strings.Repeat("#", bar), p.Cost, strings.Join(solutions, ", "))
+	}
+	return chart.String()
+}
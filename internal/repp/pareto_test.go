@@ -0,0 +1,81 @@
+package repp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_paretoFront(t *testing.T) {
+	solutions := []Solution{
+		{Count: 2, synthFragsCount: 1, Cost: 300},
+		{Count: 3, synthFragsCount: 0, Cost: 100},
+		{Count: 3, synthFragsCount: 1, Cost: 150}, // dominated by the second
+		{Count: 4, synthFragsCount: 0, Cost: 100}, // dominated by the second
+		{Count: 3, synthFragsCount: 0, Cost: 100}, // same point as the second
+		{Count: 2, synthFragsCount: 2, Cost: 250},
+	}
+
+	want := []ParetoPoint{
+		{Count: 2, SynthCount: 2, Cost: 250, Solutions: []int{6}},
+		{Count: 2, SynthCount: 1, Cost: 300, Solutions: []int{1}},
+		{Count: 3, SynthCount: 0, Cost: 100, Solutions: []int{2, 5}},
+	}
+	if got := paretoFront(solutions); !reflect.DeepEqual(got, want) {
+		t.Errorf("paretoFront() = %+v, want %+v", got, want)
+	}
+
+	var flagged []bool
+	for _, s := range solutions {
+		flagged = append(flagged, s.Pareto)
+	}
+	if want := []bool{true, true, false, false, true, true}; !reflect.DeepEqual(flagged, want) {
+		t.Errorf("paretoFront() flagged %v, want %v", flagged, want)
+	}
+}
+
+func Test_paretoCandidates(t *testing.T) {
+	assemblies := []assembly{
+		{frags: []*Frag{{ID: "a"}, {ID: "b"}}, cost: 100},
+		{frags: []*Frag{{ID: "c"}, {ID: "d"}}, cost: 90}, // same counts as the first
+		{frags: []*Frag{{ID: "e"}, {ID: "f"}}, synths: 1, cost: 150},
+		{frags: []*Frag{{ID: "g"}}, synths: 1, cost: 200},
+	}
+
+	got := paretoCandidates(assemblies)
+	if len(got) != 3 || got[0].frags[0].ID != "a" || got[1].frags[0].ID != "e" || got[2].frags[0].ID != "g" {
+		t.Errorf("paretoCandidates() = %v, want the first of each fragment and synthetic fragment count", got)
+	}
+}
+
+func Test_paretoAssemblies(t *testing.T) {
+	filled := []*assembly{
+		{frags: []*Frag{{ID: "a"}, {ID: "b"}}, cost: 100},
+		{frags: []*Frag{{ID: "c"}, {ID: "d"}}, cost: 100}, // same point as the first
+		{frags: []*Frag{{ID: "e"}, {ID: "f"}}, cost: 150}, // dominated by the first
+		{frags: []*Frag{{ID: "g"}}, synths: 1, cost: 90},
+	}
+
+	got := paretoAssemblies(filled)
+	if len(got) != 2 || got[0].frags[0].ID != "a" || got[1].frags[0].ID != "g" {
+		t.Errorf("paretoAssemblies() = %v, want the first and last assemblies", got)
+	}
+}
+
+func Test_paretoChart(t *testing.T) {
+	chart := paretoChart([]ParetoPoint{
+		{Count: 2, SynthCount: 1, Cost: 300, Solutions: []int{1}},
+		{Count: 3, SynthCount: 0, Cost: 150, Solutions: []int{2, 3}},
+	}, "USD")
+
+	lines := strings.Split(strings.TrimSpace(chart), "\n")
+	if len(lines) != 3 || lines[0] != "pareto front: cost (USD) vs fragments (synthetic)" {
+		t.Fatalf("paretoChart() = %q", chart)
+	}
+	if full, half := strings.Count(lines[1], "#"), strings.Count(lines[2], "#"); full != 40 || half != 20 {
+		t.Errorf("paretoChart() bars are %d and %d wide, want 40 and 20", full, half)
+	}
+	if !strings.HasSuffix(lines[2], "150.00  solution 2, 3") {
+		t.Errorf("paretoChart() = %q, want the cost and solutions of each point", lines[2])
+	}
+}
@@ -0,0 +1,136 @@
+package repp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// minPCRTemplateGC and maxPCRTemplateGC are the GC% range of a PCR template's 50bp
+	// windows that amplify reliably
+	minPCRTemplateGC, maxPCRTemplateGC = 25.0, 72.0
+
+	// maxPCRTemplateRepeat is the longest repeat, direct or inverted, in a PCR template
+	// that isn't flagged. Longer ones misprime and fold back on themselves
+	maxPCRTemplateRepeat = 30
+
+	// pcrSiteHairpinMargin is how close, in degrees C, a hairpin's Tm at a primer's binding
+	// site can be to the primer's Tm before it competes with the primer
+	pcrSiteHairpinMargin = 5.0
+)
+
+// pcrTemplateIssues returns the problems of a PCR template's sequence that often fail PCRs:
+// 50bp windows with extreme GC and long repeats
+func pcrTemplateIssues(seq string) (issues []string) {
+	seq = strings.ToUpper(seq)
+	if len(seq) >= 50 {
+		scores := fragSeqQualityChecks(seq)
+		if gc := scores.max50WindowGCContent * 100; gc > maxPCRTemplateGC {
+			issues = append(issues, fmt.Sprintf("a 50bp window is %.0f%% GC (>%.0f%%)", gc, maxPCRTemplateGC))
+		}
+		if gc := scores.min50WindowGCContent * 100; gc < minPCRTemplateGC {
+			issues = append(issues, fmt.Sprintf("a 50bp window is %.0f%% GC (<%.0f%%)", gc, minPCRTemplateGC))
+		}
+	}
+	if repeat := longestTemplateRepeat(seq, maxPCRTemplateRepeat+1); repeat > maxPCRTemplateRepeat {
+		issues = append(issues, fmt.Sprintf("it has a %dbp repeat (>%dbp)", repeat, maxPCRTemplateRepeat))
+	}
+	return issues
+}
+
+// longestTemplateRepeat returns the length of the longest stretch of a linear sequence
+// whose k-mers all occur elsewhere in it, on either strand. It's 0 if no k-mer repeats
+func longestTemplateRepeat(seq string, k int) (longest int) {
+	if len(seq) < k {
+		return 0
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i+k <= len(seq); i++ {
+		counts[seq[i:i+k]]++
+		counts[reverseComplement(seq[i:i+k])]++
+	}
+
+	run := 0
+	for i := 0; i+k <= len(seq); i++ {
+		kmer := seq[i : i+k]
+		repeated := counts[kmer] > 1
+		if kmer == reverseComplement(kmer) {
+			repeated = counts[kmer] > 2 // palindromes are counted twice
+		}
+		if !repeated {
+			run = 0
+			continue
+		}
+		if run == 0 {
+			run = k
+		} else {
+			run++
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// pcrSiteHairpins returns the primer binding sites of a PCR fragment with secondary structure
+// that melts within pcrSiteHairpinMargin of the primer's Tm
+func pcrSiteHairpins(f *Frag, conf *config.Config) (issues []string) {
+	if len(f.Primers) < 2 || f.Seq == "" {
+		return nil
+	}
+
+	site := 60
+	if len(f.Seq) < site {
+		site = len(f.Seq)
+	}
+	sides := []struct {
+		name string
+		seq  string
+	}{{"left", f.Seq[:site]}, {"right", f.Seq[len(f.Seq)-site:]}}
+	for i, side := range sides {
+		primer := f.Primers[i]
+		if melt := hairpin(side.seq, conf); primer.Tm > 0 && melt > primer.Tm-pcrSiteHairpinMargin {
+			issues = append(issues, fmt.Sprintf("a %.0fC hairpin at its %s primer's site, vs the primer's %.0fC Tm", melt, side.name, primer.Tm))
+		}
+	}
+	return issues
+}
+
+// pcrTemplateWarnings returns a warning for each PCR fragment of a solution whose template
+// has extreme GC windows, long repeats, or strong secondary structure where its primers bind,
+// and for each whose template was flagged with contaminant sequence at its ends
+func pcrTemplateWarnings(frags []*Frag, conf *config.Config) (warnings []string) {
+	for _, f := range frags {
+		if f.fragType != pcr {
+			continue
+		}
+		issues := append(pcrTemplateIssues(f.Seq), pcrSiteHairpins(f, conf)...)
+		if len(issues) > 0 {
+			warnings = append(warnings, fmt.Sprintf("PCR of %s may fail: %s", f.ID, strings.Join(issues, ", ")))
+		}
+		if slices.Contains(f.db.ContaminatedEntries, entryID(f.ID)) {
+			warnings = append(warnings, fmt.Sprintf("%s has contaminant sequence at its ends in %s. "+
+				"Check it isn't in the PCR product", f.ID, f.db.Name))
+		}
+	}
+	return warnings
+}
+
+// templatePenalty returns the cost added to a PCR fragment's adjusted cost, so solutions
+// with templates that often fail PCRs are ranked lower: the pcr-template-penalty for each
+// issue of the template. It's cached on the fragment
+func (f *Frag) templatePenalty() float64 {
+	if f.fragType != pcr || f.conf == nil || f.conf.PcrTemplatePenalty <= 0 {
+		return 0
+	}
+	if f.templateIssues == nil {
+		issues := len(pcrTemplateIssues(f.Seq))
+		f.templateIssues = &issues
+	}
+	return f.conf.PcrTemplatePenalty * float64(*f.templateIssues)
+}
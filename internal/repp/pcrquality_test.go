@@ -0,0 +1,83 @@
+package repp
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// randomSeq returns a reproducible sequence without long repeats
+func randomSeq(n int, seed int64) string {
+	r := rand.New(rand.NewSource(seed))
+	var seq strings.Builder
+	for i := 0; i < n; i++ {
+		seq.WriteByte("ACGT"[r.Intn(4)])
+	}
+	return seq.String()
+}
+
+func Test_pcrTemplateIssues(t *testing.T) {
+	template := randomSeq(300, 1)
+	gcRich := "GCGGCCGCGCTGCCGGGCCCGCGGCGGCTCCGGCGCCCAGCCGCGGCGCG"
+	tests := []struct {
+		name string
+		seq  string
+		want int
+	}{
+		{"balanced", template, 0},
+		{"GC rich window", template[:150] + gcRich + template[150:], 1},
+		{"AT rich window", template[:150] + "ATTAAATTTATAATATTTAAATTATTAATTTATATAATTTAATATTATAA" + template[150:], 1},
+		{"long repeat", template[:100] + template[200:240] + template[100:], 1},
+		{"short", "GCGCGCGC", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pcrTemplateIssues(tt.seq); len(got) != tt.want {
+				t.Errorf("pcrTemplateIssues() = %v, want %d issues", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_longestTemplateRepeat(t *testing.T) {
+	unique := randomSeq(100, 10)
+	repeat := randomSeq(36, 3)
+
+	tests := []struct {
+		name string
+		seq  string
+		want int
+	}{
+		{"no repeat", unique, 0},
+		{"direct repeat", repeat + unique + repeat, 36},
+		{"inverted repeat", repeat + unique + reverseComplement(repeat), 36},
+		{"short repeat", repeat[:20] + unique + repeat[:20], 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longestTemplateRepeat(tt.seq, maxPCRTemplateRepeat+1); got != tt.want {
+				t.Errorf("longestTemplateRepeat() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Frag_templatePenalty(t *testing.T) {
+	c := config.New()
+	template := randomSeq(300, 1)
+	f := &Frag{Seq: template[:150] + "GCGGCCGCGCTGCCGGGCCCGCGGCGGCTCCGGCGCCCAGCCGCGGCGCG" + template[150:], fragType: pcr, conf: c}
+
+	if got := f.templatePenalty(); got != 0 {
+		t.Errorf("templatePenalty() = %f without a pcr-template-penalty, want 0", got)
+	}
+
+	c.PcrTemplatePenalty = 10
+	if got := f.templatePenalty(); got != 10 {
+		t.Errorf("templatePenalty() = %f, want 10", got)
+	}
+	if _, adjusted := f.cost(false); adjusted < 10 {
+		t.Errorf("cost() adjusted = %f, want the template penalty in it", adjusted)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -18,6 +19,21 @@ type primer3 struct {
 	// the target sequence
 	seq string
 
+	// parentSeq is the sequence of the fragment's parent plasmid, if known
+	parentSeq string
+
+	// templateMismatches are the indexes on the template where the fragment's
+	// template differs from the target
+	templateMismatches []int
+
+	// shiftedMismatches are the template mismatches that the left and right
+	// primers were kept off of
+	shiftedMismatches [2][]int
+
+	// leftBuffer and rightBuffer are the bps from the template's ends that
+	// primer3 was free to move the primers in
+	leftBuffer, rightBuffer int
+
 	// input file
 	in *os.File
 
@@ -55,14 +71,9 @@ func newPrimer3(seq string, conf *config.Config) primer3 {
 //
 // returning the number of bp that have to be artifically added to the left and right primers
 func (p *primer3) input(f, prev, next *Frag) (addLeft, addRight int, err error) {
-	in, inErr := os.CreateTemp("", "primer3-in-*")
-	out, outErr := os.CreateTemp("", "primer3-out-*")
-
-	if inErr != nil || outErr != nil {
-		return 0, 0, multierr.Append(inErr, outErr)
+	if err = p.createFiles(); err != nil {
+		return 0, 0, err
 	}
-	p.in = in
-	p.out = out
 
 	// adjust the Frag's start and end index in the event that there's too much homology
 	// with the neighboring fragment
@@ -87,6 +98,7 @@ func (p *primer3) input(f, prev, next *Frag) (addLeft, addRight int, err error)
 		leftBuffer = 0
 		rightBuffer = 0
 	}
+	p.leftBuffer, p.rightBuffer = leftBuffer, rightBuffer
 
 	// create the settings map from all instructions
 	settings := p.settings(
@@ -96,17 +108,52 @@ func (p *primer3) input(f, prev, next *Frag) (addLeft, addRight int, err error)
 		leftBuffer,
 		rightBuffer,
 	)
-	// write the settings to a buffer
+	err = p.writeSettings(settings)
+	return
+}
+
+// createFiles creates the temporary input and output files of primer3
+func (p *primer3) createFiles() error {
+	in, inErr := os.CreateTemp("", "primer3-in-*")
+	out, outErr := os.CreateTemp("", "primer3-out-*")
+
+	if inErr != nil || outErr != nil {
+		return multierr.Append(inErr, outErr)
+	}
+	p.in = in
+	p.out = out
+	return nil
+}
+
+// writeSettings writes the settings to the input file
+func (p *primer3) writeSettings(settings map[string]string) error {
+	// write the settings to a buffer, in a stable order so identical inputs can be cached
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 	var fileBuffer bytes.Buffer
-	for key, val := range settings {
-		fmt.Fprintf(&fileBuffer, "%s=%s\n", key, val)
+	for _, key := range keys {
+		fmt.Fprintf(&fileBuffer, "%s=%s\n", key, settings[key])
 	}
 	fileBuffer.WriteString("=") // required at file's end
 	// then write them to the file
-	if _, err = p.in.Write(fileBuffer.Bytes()); err != nil {
-		return 0, 0, fmt.Errorf("failed to write primer3 input file %v: ", err)
+	if _, err := p.in.Write(fileBuffer.Bytes()); err != nil {
+		return fmt.Errorf("failed to write primer3 input file %v: ", err)
 	}
-	return
+	return nil
+}
+
+// template returns the target sequence, indexed across its zero-index
+func (p *primer3) template() circularSeq {
+	return newCircularSeq(p.seq)
+}
+
+// templateLen is the length of primer3's template. Its indexes are linear, so it has two copies
+// of the target for fragments that cross the target's zero-index
+func (p *primer3) templateLen() int {
+	return 2 * len(p.seq)
 }
 
 // shrink adjusts the start and end of a Frag in the scenario where
@@ -131,6 +178,11 @@ func (p *primer3) shrink(last, f, next *Frag) *Frag {
 		f.start += shiftInLeft
 		f.end -= shiftInRight
 		f.Seq = f.Seq[shiftInLeft : len(f.Seq)-shiftInRight]
+		if shiftInRight > 0 {
+			f.warn(fmt.Sprintf("end trimmed by %dbp to keep its homology with %s under %dbp", shiftInRight, next.ID, p.config.FragmentsMaxHomology))
+		}
+	} else if shiftInRight > 0 {
+		f.warn(fmt.Sprintf("%dbp of homology with %s, over the max of %dbp, it's too short to trim", shiftInRight+p.config.FragmentsMaxHomology, next.ID, p.config.FragmentsMaxHomology))
 	}
 
 	return f
@@ -139,6 +191,14 @@ func (p *primer3) shrink(last, f, next *Frag) *Frag {
 // bpToAdd returns the number of bp to add the end of the left Frag to create a junction
 // with the right Frag
 func (p *primer3) bpToAdd(left, right *Frag) int {
+	// the first and last fragments of a linear target are extended to its ends
+	if left.uniqueID == linearStartID {
+		return right.start
+	}
+	if right.uniqueID == linearEndID {
+		return len(p.seq) - 1 - left.end
+	}
+
 	if !left.couldOverlapViaPCR(right) {
 		return 0 // we're going to synthesize there, don't add bp via PCR
 	}
@@ -200,7 +260,7 @@ func (p *primer3) settings(seqID string, start, length, leftBuffer, rightBuffer
 		"PRIMER_THERMODYNAMIC_PARAMETERS_PATH": p.primer3ConfDir,
 		"PRIMER_NUM_RETURN":                    "1",
 		"PRIMER_PICK_ANYWAY":                   strictPrimerSelection,
-		"SEQUENCE_TEMPLATE":                    p.seq + p.seq,                             // TODO
+		"SEQUENCE_TEMPLATE":                    p.template().get(0, p.templateLen()),
 		"PRIMER_MIN_SIZE":                      strconv.Itoa(p.config.PcrPrimerMinLength), // default 18
 		"PRIMER_OPT_SIZE":                      strconv.Itoa(p.config.PcrPrimerOptimumLength),
 		"PRIMER_MAX_SIZE":                      strconv.Itoa(p.config.PcrPrimerMaxLength),
@@ -245,6 +305,31 @@ func (p *primer3) settings(seqID string, start, length, leftBuffer, rightBuffer
 				settings["SEQUENCE_PRIMER_PAIR_OK_REGION_LIST"] = fmt.Sprintf("%d,%d,%d,%d ;", start, leftBuffer+p.config.PcrPrimerMaxLength, rightStart, rightBuffer+p.config.PcrPrimerMaxLength)
 			}
 			settings["PRIMER_PRODUCT_SIZE_RANGE"] = fmt.Sprintf("%d-%d", excludeLength, length)
+
+			var windows [][2]int
+			if leftBuffer > 0 {
+				windows = append(windows, [2]int{start, leftEnd})
+			}
+			if rightBuffer > 0 {
+				windows = append(windows, [2]int{rightStart, start + length})
+			}
+
+			// keep primers out of repeats on the sides where they're free to move
+			var excluded []string
+			if p.config.PcrMaskTemplateRepeats {
+				excluded = p.excludedRepeats(windows)
+			}
+
+			// and off the template's mismatches to the target, they'd anneal poorly to the template
+			if leftBuffer > 0 {
+				excluded = append(excluded, p.excludedMismatches(0, start, leftEnd)...)
+			}
+			if rightBuffer > 0 {
+				excluded = append(excluded, p.excludedMismatches(1, rightStart, start+length)...)
+			}
+			if len(excluded) > 0 {
+				settings["SEQUENCE_EXCLUDED_REGION"] = strings.Join(excluded, " ")
+			}
 		}
 	}
 
@@ -258,8 +343,321 @@ func (p *primer3) settings(seqID string, start, length, leftBuffer, rightBuffer
 	return settings
 }
 
-// run the primer3 executable against the input file
+// excludedRepeats returns primer3 excluded regions ("start,length") of the template in each
+// window [start, end) that are covered by k-mers repeated in the target or the parent sequence.
+// A primer in them may anneal to more than one locus. A window is left unmasked if masking
+// would leave no room for a primer in it.
+func (p *primer3) excludedRepeats(windows [][2]int) (excluded []string) {
+	k := p.config.PcrPrimerMinLength
+	template := p.template()
+	if k < 1 || len(windows) == 0 || len(p.seq) < k {
+		return nil
+	}
+
+	targetCounts := kmerCounts(p.seq, k)
+	parentCounts := kmerCounts(p.parentSeq, k)
+	repeated := func(kmer string) bool {
+		return repeatedKmer(targetCounts, kmer) || repeatedKmer(parentCounts, kmer)
+	}
+
+	for _, w := range windows {
+		start, end := w[0], w[1]
+		if start < 0 {
+			start = 0
+		}
+		if end > p.templateLen() {
+			end = p.templateLen()
+		}
+
+		// merge the repeated k-mers in the window into regions
+		var regions [][2]int
+		for i := start; i+k <= end; i++ {
+			if !repeated(template.get(i, i+k)) {
+				continue
+			}
+			if n := len(regions); n > 0 && regions[n-1][1] >= i {
+				regions[n-1][1] = i + k
+			} else {
+				regions = append(regions, [2]int{i, i + k})
+			}
+		}
+		if len(regions) == 0 {
+			continue
+		}
+
+		if !roomForPrimer(start, end, k, regions) {
+			rlog.Debugf("not masking repeats at %d-%d, no room would be left for a primer", start, end)
+			continue
+		}
+
+		for _, r := range regions {
+			excluded = append(excluded, fmt.Sprintf("%d,%d", r[0], r[1]-r[0]))
+		}
+	}
+
+	return excluded
+}
+
+// excludedMismatches returns primer3 excluded regions ("start,length") of the template
+// mismatches in the window [start, end) of the left (0) or right (1) primer, recording
+// them as shifted. The window is left unmasked if masking would leave no room for a primer in it.
+func (p *primer3) excludedMismatches(side, start, end int) (excluded []string) {
+	var regions [][2]int
+	var shifted []int
+	for _, i := range p.templateMismatches {
+		if i >= start && i < end {
+			regions = append(regions, [2]int{i, i + 1})
+			shifted = append(shifted, i)
+		}
+	}
+	if len(regions) == 0 {
+		return nil
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i][0] < regions[j][0] })
+
+	if !roomForPrimer(start, end, p.config.PcrPrimerMinLength, regions) {
+		rlog.Debugf("not masking template mismatches at %d-%d, no room would be left for a primer", start, end)
+		return nil
+	}
+
+	for _, r := range regions {
+		excluded = append(excluded, fmt.Sprintf("%d,1", r[0]))
+	}
+	p.shiftedMismatches[side] = shifted
+	return excluded
+}
+
+// roomForPrimer returns whether a stretch of at least k bp is left in the window
+// [start, end) outside of the sorted regions
+func roomForPrimer(start, end, k int, regions [][2]int) bool {
+	longestFree, last := 0, start
+	for _, r := range regions {
+		if r[0]-last > longestFree {
+			longestFree = r[0] - last
+		}
+		if r[1] > last {
+			last = r[1]
+		}
+	}
+	if end-last > longestFree {
+		longestFree = end - last
+	}
+	return longestFree >= k
+}
+
+// annealToTemplate makes the primers anneal to the template where it differs from
+// the target. The primers are designed against the target, so a primer over a template
+// mismatch is extended past it: its 3' end anneals to the template and the target's
+// bases are introduced by its 5' tail. The approach used is added to the primers' notes.
+// The priming regions, past the mismatches, and the Tms and GC of the primers are updated
+// for the Tm balancing and pair checks that follow.
+func (p *primer3) annealToTemplate(primers []Primer) error {
+	if len(p.templateMismatches) == 0 || len(primers) < 2 {
+		return nil
+	}
+
+	k := p.config.PcrPrimerMinLength
+	template := p.template()
+
+	left, right := &primers[0], &primers[1]
+	for side, primer := range []*Primer{left, right} {
+		if shifted := p.shiftedMismatches[side]; len(shifted) > 0 {
+			primer.Notes = addNote(primer.Notes, fmt.Sprintf("shifted off template mismatches at %s", p.positions(shifted)))
+		}
+	}
+
+	// left primer's bases are [start, end), its 3' end at end-1
+	if mismatches := p.mismatchesIn(left.Range.start, left.Range.end); len(mismatches) > 0 {
+		end := left.Range.end
+		for anneal := p.mismatchesIn(end-k, end); len(anneal) > 0; anneal = p.mismatchesIn(end-k, end) {
+			end = anneal[len(anneal)-1] + 1 + k
+		}
+		if end > right.Range.start+1 || end > p.templateLen() {
+			return fmt.Errorf("left primer %s can't anneal past template mismatches at %s", left.Seq, p.positions(mismatches))
+		}
+		left.Notes = addNote(left.Notes, p.tailNote(mismatches, end != left.Range.end))
+		left.Seq = template.get(left.Range.start, end)
+		left.Range.end = end
+		all := p.mismatchesIn(left.Range.start, end)
+		setPrimingRegion(left, template.get(all[len(all)-1]+1, end))
+	}
+
+	// right primer's bases are (start, end], its 3' end at start+1
+	if mismatches := p.mismatchesIn(right.Range.start+1, right.Range.end+1); len(mismatches) > 0 {
+		start := right.Range.start + 1
+		for anneal := p.mismatchesIn(start, start+k); len(anneal) > 0; anneal = p.mismatchesIn(start, start+k) {
+			start = anneal[0] - k
+		}
+		if start < left.Range.end || start < 0 {
+			return fmt.Errorf("right primer %s can't anneal past template mismatches at %s", right.Seq, p.positions(mismatches))
+		}
+		right.Notes = addNote(right.Notes, p.tailNote(mismatches, start != right.Range.start+1))
+		right.Seq = reverseComplement(template.get(start, right.Range.end+1))
+		right.Range.start = start - 1
+		all := p.mismatchesIn(start, right.Range.end+1)
+		setPrimingRegion(right, reverseComplement(template.get(start, all[0])))
+	}
+
+	return nil
+}
+
+// mismatchesIn returns the template mismatches in [start, end)
+func (p *primer3) mismatchesIn(start, end int) (mismatches []int) {
+	for _, i := range p.templateMismatches {
+		if i >= start && i < end {
+			mismatches = append(mismatches, i)
+		}
+	}
+	return
+}
+
+// balanceTms brings the primers' Tms within the max allowed difference, if it can,
+// rather than having the pair rejected for it. Of the ways to resize the primers at
+// their 3' ends, see resizeOptions, the one that resizes them the least is used, so the
+// PCR product is unchanged. The primers are left as they are if they can't be balanced.
+func (p *primer3) balanceTms(primers []Primer) {
+	maxDiff := p.config.PcrMaxFwdRevPrimerTmDiff
+	if maxDiff <= 0 || len(primers) < 2 || math.Abs(primers[0].Tm-primers[1].Tm) <= maxDiff {
+		return
+	}
+
+	var balanced []Primer
+	leastResized := 0
+	for _, o := range p.resizeOptions(primers) {
+		if o.resized > 0 && (balanced == nil || o.resized < leastResized) {
+			balanced, leastResized = o.primers, o.resized
+		}
+	}
+
+	for side, primer := range balanced {
+		if primer.Seq != primers[side].Seq {
+			primer.Notes = addNote(primer.Notes, resizeNote("Tm balanced", primers[side], primer))
+			primers[side] = primer
+		}
+	}
+}
+
+// resizePrimer moves the 3' end of the left (side 0) or right (side 1) primer by bp bases.
+// Its 5' end, and any 5' tail, stay where they are. Its Tm is updated by the change in the
+// native Tm of its priming region. It returns false, and leaves the primer as it is, if the
+// priming region would be outside the primer length limits, run past the template or the
+// other primer, or have a template mismatch in its 3' end.
+func (p *primer3) resizePrimer(primers []Primer, side, bp int) bool {
+	left, right, primer := &primers[0], &primers[1], &primers[side]
+	length := len(primer.PrimingRegion) + bp
+	if bp < 0 && length < p.config.PcrPrimerMinLength || bp > 0 && length > p.config.PcrPrimerMaxLength || length < 1 {
+		return false
+	}
+
+	k := p.config.PcrPrimerMinLength
+	template := p.template()
+	var added string
+	if side == 0 {
+		// left primer's bases are [start, end), its 3' end at end-1
+		end := left.Range.end + bp
+		if end > p.templateLen() || end > right.Range.start+1 || len(p.mismatchesIn(end-k, end)) > 0 {
+			return false
+		}
+		if bp > 0 {
+			added = template.get(left.Range.end, end)
+		}
+		left.Range.end = end
+	} else {
+		// right primer's bases are (start, end], its 3' end at start+1
+		start := right.Range.start + 1 - bp
+		if start < 0 || start < left.Range.end || len(p.mismatchesIn(start, start+k)) > 0 {
+			return false
+		}
+		if bp > 0 {
+			added = reverseComplement(template.get(start, right.Range.start+1))
+		}
+		right.Range.start = start - 1
+	}
+
+	trimmed := 0
+	if bp < 0 {
+		trimmed = -bp
+	}
+	primer.Seq = primer.Seq[:len(primer.Seq)-trimmed] + added
+	setPrimingRegion(primer, primer.PrimingRegion[:len(primer.PrimingRegion)-trimmed]+added)
+	return true
+}
+
+// setPrimingRegion sets the bases of a primer that anneal to the template and updates its
+// GC and its Tm, by the change in the native Tm of the priming region so it stays
+// comparable with primer3's Tms
+func setPrimingRegion(primer *Primer, region string) {
+	if region == "" {
+		return
+	}
+	primer.Tm += primerTm(region) - primerTm(primer.PrimingRegion)
+	primer.GC = 100 * float64(strings.Count(region, "G")+strings.Count(region, "C")) / float64(len(region))
+	primer.PrimingRegion = region
+}
+
+// resizeNote is the note of a primer whose 3' end was moved
+func resizeNote(reason string, original, resized Primer) string {
+	change := "lengthened"
+	if len(resized.PrimingRegion) < len(original.PrimingRegion) {
+		change = "shortened"
+	}
+	return fmt.Sprintf("%s from %.1f to %.1f, 3' end %s to %dbp", reason, original.Tm, resized.Tm, change, len(resized.PrimingRegion))
+}
+
+// tailNote is the note of a primer whose 5' tail introduces the target's bases at template mismatches
+func (p *primer3) tailNote(mismatches []int, extended bool) string {
+	if extended {
+		return fmt.Sprintf("extended past template mismatches at %s, introduced by the 5' tail", p.positions(mismatches))
+	}
+	return fmt.Sprintf("template mismatches at %s introduced by the 5' tail", p.positions(mismatches))
+}
+
+// positions formats template indexes as 1-based positions on the target, ex: "12, 40"
+func (p *primer3) positions(indexes []int) string {
+	var positions []string
+	for _, i := range indexes {
+		positions = append(positions, strconv.Itoa(i%len(p.seq)+1))
+	}
+	return strings.Join(positions, ", ")
+}
+
+// addNote appends a note to a primer's notes
+func addNote(notes, note string) string {
+	if notes == "" {
+		return note
+	}
+	return notes + "; " + note
+}
+
+// run the primer3 executable against the input file. The output of an identical
+// input is read from the result cache instead, if it's there.
 func (p *primer3) run() (err error) {
+	rc := openCache(p.config)
+	var key string
+	if rc != nil {
+		input, readErr := os.ReadFile(p.in.Name())
+		if readErr == nil {
+			key = cacheKey(string(input))
+			if cached, ok := rc.get(primersCacheKind, key); ok {
+				return os.WriteFile(p.out.Name(), cached, 0644)
+			}
+		}
+	}
+
+	if err = p.execute(); err != nil {
+		return err
+	}
+
+	if key != "" {
+		if output, readErr := os.ReadFile(p.out.Name()); readErr == nil {
+			rc.put(primersCacheKind, key, output)
+		}
+	}
+	return nil
+}
+
+// execute runs primer3 on the input file
+func (p *primer3) execute() (err error) {
 	p3Cmd := exec.Command(
 		p.primer3Exec,
 		p.in.Name(),
@@ -268,7 +666,7 @@ func (p *primer3) run() (err error) {
 	)
 
 	// execute primer3 and wait on it to finish
-	if output, err := p3Cmd.CombinedOutput(); err != nil {
+	if output, err := commands.combinedOutput(p3Cmd); err != nil {
 		return fmt.Errorf("failed to execute primer3 on input file %s: %s: %v", p.in.Name(), string(output), err)
 	}
 
@@ -279,14 +677,32 @@ func (p *primer3) run() (err error) {
 //
 // target is the target sequence we're building for. We need it to modulo the primer ranges
 func (p *primer3) parse(target string) (primers []Primer, err error) {
+	results, file, err := p.results()
+	if err != nil {
+		return
+	}
+
+	if numPairs := results["PRIMER_PAIR_NUM_RETURNED"]; numPairs == "0" {
+		err = fmt.Errorf("failed to create primers using: \n%s", file)
+		return
+	}
+
+	primers = []Primer{
+		primer3Oligo(results, "LEFT", 0),
+		primer3Oligo(results, "RIGHT", 0),
+	}
+	return
+}
+
+// results reads the output file into a map, they're all 1:1. It fails if primer3 warned or errored
+func (p *primer3) results() (results map[string]string, file string, err error) {
 	fileBytes, err := os.ReadFile(p.out.Name())
 	if err != nil {
 		return
 	}
-	file := string(fileBytes)
+	file = string(fileBytes)
 
-	// read in results into map, they're all 1:1
-	results := make(map[string]string)
+	results = make(map[string]string)
 	for _, line := range strings.Split(file, "\n") {
 		keyVal := strings.Split(line, "=")
 		if len(keyVal) > 1 {
@@ -303,55 +719,46 @@ func (p *primer3) parse(target string) (primers []Primer, err error) {
 		err = fmt.Errorf("failed to execute primer3 against %s: %s", file, p3Error)
 		return
 	}
+	return
+}
 
-	if numPairs := results["PRIMER_PAIR_NUM_RETURNED"]; numPairs == "0" {
-		err = fmt.Errorf("failed to create primers using: \n%s", file)
-		return
-	}
-
-	// read in a single primer from the output string file
-	// side is either "LEFT" or "RIGHT"
-	parsePrimer := func(side string, index int) Primer {
-		seq := results[fmt.Sprintf("PRIMER_%s_%d_SEQUENCE", side, index)]
-		tm := results[fmt.Sprintf("PRIMER_%s_%d_TM", side, index)]
-		gc := results[fmt.Sprintf("PRIMER_%s_%d_GC_PERCENT", side, index)]
-		penalty := results[fmt.Sprintf("PRIMER_%s_%d_PENALTY", side, index)]
-		pairPenalty := results[fmt.Sprintf("PRIMER_PAIR_%d_PENALTY", index)]
-		notes := results[fmt.Sprintf("PRIMER_%s_%d_PROBLEMS", side, index)]
-
-		tmValue, _ := strconv.ParseFloat(tm, 64)
-		gcValue, _ := strconv.ParseFloat(gc, 64)
-		penaltyValue, _ := strconv.ParseFloat(penalty, 64)
-		pairValue, _ := strconv.ParseFloat(pairPenalty, 64)
-
-		primerRange := results[fmt.Sprintf("PRIMER_%s_0", side)]
-		primerStart, _ := strconv.Atoi(strings.Split(primerRange, ",")[0])
-		primerEnd := primerStart + len(seq)
-		if side == "RIGHT" {
-			primerStart -= len(seq)
-			primerEnd = primerStart + len(seq)
-		}
-
-		return Primer{
-			Seq:           seq,
-			Strand:        side == "LEFT",
-			Tm:            tmValue,
-			GC:            gcValue,
-			Penalty:       penaltyValue,
-			PairPenalty:   pairValue,
-			PrimingRegion: seq,
-			Range: ranged{
-				start: primerStart,
-				end:   primerEnd,
-			},
-			Notes: notes,
-		}
+// primer3Oligo reads in a single oligo from the results of primer3
+// side is either "LEFT", "RIGHT" or "INTERNAL"
+func primer3Oligo(results map[string]string, side string, index int) Primer {
+	seq := results[fmt.Sprintf("PRIMER_%s_%d_SEQUENCE", side, index)]
+	tm := results[fmt.Sprintf("PRIMER_%s_%d_TM", side, index)]
+	gc := results[fmt.Sprintf("PRIMER_%s_%d_GC_PERCENT", side, index)]
+	penalty := results[fmt.Sprintf("PRIMER_%s_%d_PENALTY", side, index)]
+	pairPenalty := results[fmt.Sprintf("PRIMER_PAIR_%d_PENALTY", index)]
+	notes := results[fmt.Sprintf("PRIMER_%s_%d_PROBLEMS", side, index)]
+
+	tmValue, _ := strconv.ParseFloat(tm, 64)
+	gcValue, _ := strconv.ParseFloat(gc, 64)
+	penaltyValue, _ := strconv.ParseFloat(penalty, 64)
+	pairValue, _ := strconv.ParseFloat(pairPenalty, 64)
+
+	primerRange := results[fmt.Sprintf("PRIMER_%s_%d", side, index)]
+	primerStart, _ := strconv.Atoi(strings.Split(primerRange, ",")[0])
+	primerEnd := primerStart + len(seq)
+	if side == "RIGHT" {
+		primerStart -= len(seq)
+		primerEnd = primerStart + len(seq)
+	}
+
+	return Primer{
+		Seq:           seq,
+		Strand:        side != "RIGHT",
+		Tm:            tmValue,
+		GC:            gcValue,
+		Penalty:       penaltyValue,
+		PairPenalty:   pairValue,
+		PrimingRegion: seq,
+		Range: ranged{
+			start: primerStart,
+			end:   primerEnd,
+		},
+		Notes: notes,
 	}
-	primers = []Primer{
-		parsePrimer("LEFT", 0),
-		parsePrimer("RIGHT", 0),
-	}
-	return
 }
 
 func (p *primer3) close() (err error) {
@@ -389,13 +796,13 @@ func hairpin(seq string, conf *config.Config) (melt float64) {
 	ntthalCmd := exec.Command(
 		getExecutable("PRIMER3_HOME", "bin", "ntthal"),
 		"-a", "HAIRPIN",
-		"-r",       // temperature only
-		"-t", "50", // gibson assembly is at 50 degrees
+		"-r", // temperature only
+		"-t", assemblyTempArg(conf),
 		"-s1", seq,
 		"-path", conf.GetPrimer3ConfigDir(),
 	)
 
-	ntthalOut, err := ntthalCmd.CombinedOutput()
+	ntthalOut, err := commands.combinedOutput(ntthalCmd)
 	if err != nil {
 		stderr.Printf("failed to execute ntthal: -s1 %s -path %s", seq, conf.GetPrimer3ConfigDir())
 		rlog.Fatal(err)
@@ -410,3 +817,43 @@ func hairpin(seq string, conf *config.Config) (melt float64) {
 
 	return temp
 }
+
+// assemblyTempArg is the temperature of the assembly reaction as an ntthal argument
+func assemblyTempArg(conf *config.Config) string {
+	return strconv.FormatFloat(conf.GetAssemblyTemp(), 'f', -1, 64)
+}
+
+// dimer finds the melting temperature of a dimer between two sequences, 0 if there is none.
+// ntthal is limited to 60bp, so only the 3' 60bp of longer sequences are compared
+func dimer(seq1, seq2 string, conf *config.Config) (melt float64) {
+	if len(seq1) > 60 {
+		seq1 = seq1[len(seq1)-60:]
+	}
+	if len(seq2) > 60 {
+		seq2 = seq2[len(seq2)-60:]
+	}
+
+	ntthalCmd := exec.Command(
+		getExecutable("PRIMER3_HOME", "bin", "ntthal"),
+		"-a", "ANY",
+		"-r", // temperature only
+		"-t", assemblyTempArg(conf),
+		"-s1", seq1,
+		"-s2", seq2,
+		"-path", conf.GetPrimer3ConfigDir(),
+	)
+
+	ntthalOut, err := commands.combinedOutput(ntthalCmd)
+	if err != nil {
+		stderr.Printf("failed to execute ntthal: -s1 %s -s2 %s -path %s", seq1, seq2, conf.GetPrimer3ConfigDir())
+		rlog.Fatal(err)
+	}
+
+	temp, err := strconv.ParseFloat(strings.TrimSpace(string(ntthalOut)), 64)
+	if err != nil {
+		stderr.Printf("failed to parse ntthal: -s1 %s -s2 %s -path %s", seq1, seq2, conf.GetPrimer3ConfigDir())
+		rlog.Fatal(err)
+	}
+
+	return temp
+}
@@ -2,7 +2,9 @@ package repp
 
 import (
 	"math"
+	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Lattice-Automation/repp/internal/config"
@@ -42,14 +44,16 @@ func Test_primer3_shrink(t *testing.T) {
 					end:   300,
 				},
 				next: &Frag{
+					ID:    "next",
 					start: 250,
 					end:   500,
 				},
 			},
 			&Frag{
-				Seq:   "GGGGGAACGCTGAAGATCTCTTCTTCTCATGACTGAACTCGCGAGGGTCGTGATGTCGGTTCCTTCAAAGGTTAAAGAACAAAGGCTTACTGTGCGCAGAGGAACGCCCATTTAGCGGCTGGCGTCTTGAATCCTCGGTCCCCCTTGTCTTTCCAGATTAATCCATTTCCCTCATTCACGAGCTTACCAAGTCAACATTGGTATATGAAT",
-				start: 90,
-				end:   260,
+				Seq:      "GGGGGAACGCTGAAGATCTCTTCTTCTCATGACTGAACTCGCGAGGGTCGTGATGTCGGTTCCTTCAAAGGTTAAAGAACAAAGGCTTACTGTGCGCAGAGGAACGCCCATTTAGCGGCTGGCGTCTTGAATCCTCGGTCCCCCTTGTCTTTCCAGATTAATCCATTTCCCTCATTCACGAGCTTACCAAGTCAACATTGGTATATGAAT",
+				start:    90,
+				end:      260,
+				Warnings: []string{"end trimmed by 40bp to keep its homology with next under 10bp"},
 			},
 		},
 	}
@@ -333,3 +337,273 @@ func Test_hairpin(t *testing.T) {
 		})
 	}
 }
+
+func Test_primer3_excludedRepeats(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randSeq := func(n int) string {
+		seq := make([]byte, n)
+		for i := range seq {
+			seq[i] = "ACGT"[r.Intn(4)]
+		}
+		return string(seq)
+	}
+	unique1, unique2, unique3, repeat := randSeq(100), randSeq(200), randSeq(100), randSeq(30)
+
+	c := config.New()
+	c.PcrPrimerMinLength = 18
+
+	tests := []struct {
+		name      string
+		seq       string
+		parentSeq string
+		windows   [][2]int
+		want      []string
+	}{
+		{
+			"no repeats",
+			unique1 + repeat + unique2,
+			"",
+			[][2]int{{50, 180}},
+			nil,
+		},
+		{
+			"repeat in the target",
+			unique1 + repeat + unique2 + repeat + unique3,
+			"",
+			[][2]int{{50, 180}, {300, 400}},
+			[]string{"100,30", "330,30"},
+		},
+		{
+			"repeat in the parent",
+			unique1 + repeat + unique2,
+			unique3 + repeat + unique2[:50] + reverseComplement(repeat),
+			[][2]int{{50, 180}},
+			[]string{"100,30"},
+		},
+		{
+			"no room left for a primer",
+			unique1 + repeat + unique2 + repeat + unique3,
+			"",
+			[][2]int{{95, 135}},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPrimer3(tt.seq, c)
+			p.parentSeq = tt.parentSeq
+			if got := p.excludedRepeats(tt.windows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("primer3.excludedRepeats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_primer3_annealToTemplate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seq := make([]byte, 200)
+	for i := range seq {
+		seq[i] = "ACGT"[r.Intn(4)]
+	}
+	target := string(seq)
+
+	c := config.New()
+	c.PcrPrimerMinLength = 18
+
+	gc := func(seq string) float64 {
+		return 100 * float64(strings.Count(seq, "G")+strings.Count(seq, "C")) / float64(len(seq))
+	}
+	primer := func(seq string, strand bool, r ranged) Primer {
+		return Primer{Seq: seq, Strand: strand, Range: r, PrimingRegion: seq, Tm: primerTm(seq), GC: gc(seq)}
+	}
+	primers := func(rightStart, rightEnd int) []Primer {
+		return []Primer{
+			primer(target[10:30], true, ranged{10, 30}),
+			primer(reverseComplement(target[rightStart+1:rightEnd+1]), false, ranged{rightStart, rightEnd}),
+		}
+	}
+	// annealed returns a primer whose priming region, past the template mismatches, is region
+	annealed := func(seq string, strand bool, r ranged, region, notes string) Primer {
+		p := primer(seq, strand, r)
+		p.PrimingRegion, p.Tm, p.GC, p.Notes = region, primerTm(region), gc(region), notes
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		mismatches []int
+		shifted    [2][]int
+		right      ranged
+		want       []Primer
+		wantErr    bool
+	}{
+		{
+			"no mismatches",
+			nil,
+			[2][]int{},
+			ranged{150, 170},
+			primers(150, 170),
+			false,
+		},
+		{
+			"mismatch in the left primer's 5' end",
+			[]int{11},
+			[2][]int{},
+			ranged{150, 170},
+			[]Primer{
+				annealed(target[10:30], true, ranged{10, 30}, target[12:30], "template mismatches at 12 introduced by the 5' tail"),
+				primers(150, 170)[1],
+			},
+			false,
+		},
+		{
+			"left primer extended past a mismatch near its 3' end",
+			[]int{25},
+			[2][]int{},
+			ranged{150, 170},
+			[]Primer{
+				annealed(target[10:44], true, ranged{10, 44}, target[26:44], "extended past template mismatches at 26, introduced by the 5' tail"),
+				primers(150, 170)[1],
+			},
+			false,
+		},
+		{
+			"right primer extended past a mismatch near its 3' end",
+			[]int{155},
+			[2][]int{},
+			ranged{150, 170},
+			[]Primer{
+				primers(150, 170)[0],
+				annealed(reverseComplement(target[137:171]), false, ranged{136, 170}, reverseComplement(target[137:155]), "extended past template mismatches at 156, introduced by the 5' tail"),
+			},
+			false,
+		},
+		{
+			"primer shifted off a mismatch",
+			[]int{5},
+			[2][]int{{5}},
+			ranged{150, 170},
+			[]Primer{
+				annealed(target[10:30], true, ranged{10, 30}, target[10:30], "shifted off template mismatches at 6"),
+				primers(150, 170)[1],
+			},
+			false,
+		},
+		{
+			"no room to extend the primer",
+			[]int{25},
+			[2][]int{},
+			ranged{35, 55},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPrimer3(target, c)
+			p.templateMismatches = tt.mismatches
+			p.shiftedMismatches = tt.shifted
+
+			got := primers(tt.right.start, tt.right.end)
+			err := p.annealToTemplate(got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("primer3.annealToTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for i := range got {
+				// the Tm is updated by the change in the priming region's Tm
+				if math.Abs(got[i].Tm-tt.want[i].Tm) > 1e-6 {
+					t.Errorf("primer3.annealToTemplate() primer %d Tm = %f, want %f", i, got[i].Tm, tt.want[i].Tm)
+				}
+				got[i].Tm = tt.want[i].Tm
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("primer3.annealToTemplate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_primer3_excludedMismatches(t *testing.T) {
+	c := config.New()
+	c.PcrPrimerMinLength = 18
+
+	p := newPrimer3(strings.Repeat("ACGT", 50), c)
+	p.templateMismatches = []int{40, 12, 150}
+
+	if got, want := p.excludedMismatches(0, 0, 60), []string{"12,1", "40,1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("primer3.excludedMismatches() = %v, want %v", got, want)
+	}
+	if got := p.shiftedMismatches[0]; !reflect.DeepEqual(got, []int{40, 12}) {
+		t.Errorf("primer3.excludedMismatches() shifted = %v, want the mismatches in the window", got)
+	}
+
+	// no room would be left for a primer between the mismatches
+	if got := p.excludedMismatches(1, 140, 165); got != nil || p.shiftedMismatches[1] != nil {
+		t.Errorf("primer3.excludedMismatches() = %v, want none", got)
+	}
+}
+
+func Test_primer3_balanceTms(t *testing.T) {
+	// AT rich left primer, GC rich right primer
+	target := "ATTAGACTTAACATTGAATGCATACGTTAGCAATCG" + strings.Repeat("ACGT", 25) + "CGGAGCGCTGACCGGTACGCAGGCGCTCAG"
+	rightStart, rightEnd := len(target)-21, len(target)-1
+
+	primers := func() []Primer {
+		left, right := target[0:20], reverseComplement(target[rightStart+1:rightEnd+1])
+		return []Primer{
+			{Seq: left, PrimingRegion: left, Strand: true, Tm: primerTm(left), Range: ranged{0, 20}},
+			{Seq: right, PrimingRegion: right, Tm: primerTm(right), Range: ranged{rightStart, rightEnd}},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		maxDiff      float64
+		minLength    int
+		maxLength    int
+		wantBalanced bool
+		wantChanged  bool
+	}{
+		{"within the max difference", 60, 18, 30, true, false},
+		{"balanced within the length limits", 5, 15, 30, true, true},
+		{"no room to resize the primers", 5, 20, 20, false, false},
+		{"max difference not checked", 0, 15, 30, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.New()
+			c.PcrMaxFwdRevPrimerTmDiff = tt.maxDiff
+			c.PcrPrimerMinLength = tt.minLength
+			c.PcrPrimerMaxLength = tt.maxLength
+			p := &primer3{seq: target, config: c}
+
+			got := primers()
+			p.balanceTms(got)
+
+			if balanced := math.Abs(got[0].Tm-got[1].Tm) <= tt.maxDiff; balanced != tt.wantBalanced {
+				t.Errorf("balanceTms() Tms = %.1f, %.1f, balanced %t, want %t", got[0].Tm, got[1].Tm, balanced, tt.wantBalanced)
+			}
+			if changed := !reflect.DeepEqual(got, primers()); changed != tt.wantChanged {
+				t.Errorf("balanceTms() changed the primers = %t, want %t", changed, tt.wantChanged)
+			}
+
+			for i, primer := range got {
+				if length := len(primer.Seq); length < tt.minLength || length > tt.maxLength {
+					t.Errorf("balanceTms() primer %d is %dbp, outside %d-%d", i, length, tt.minLength, tt.maxLength)
+				}
+				if primer.Seq != primers()[i].Seq && !strings.Contains(primer.Notes, "Tm balanced") {
+					t.Errorf("balanceTms() primer %d was changed without a note: %+v", i, primer)
+				}
+			}
+			if want := target[got[0].Range.start:got[0].Range.end]; got[0].Seq != want {
+				t.Errorf("balanceTms() left primer = %s, want %s", got[0].Seq, want)
+			}
+			if want := reverseComplement(target[got[1].Range.start+1 : got[1].Range.end+1]); got[1].Seq != want {
+				t.Errorf("balanceTms() right primer = %s, want %s", got[1].Seq, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package repp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// extendedPrimerMargin is how close, in degrees C, a hairpin or dimer of a primer with its
+// 5' addition can melt to the primer's Tm before it competes with the primer's annealing
+const extendedPrimerMargin = 5.0
+
+// primerAddition is a fixed sequence added to the 5' ends of the primers of the PCR
+// fragments whose template IDs its pattern matches all of, or of all PCR fragments
+type primerAddition struct {
+	pattern  *regexp.Regexp
+	fwd, rev string
+}
+
+// newPrimerAdditions returns the additions of the primer-additions setting. It fails if a
+// fragments pattern isn't a valid regular expression or an addition has non-ACGT bases
+func newPrimerAdditions(settings []config.PrimerAddition) (additions []primerAddition, err error) {
+	for _, s := range settings {
+		a := primerAddition{fwd: strings.ToUpper(s.Fwd), rev: strings.ToUpper(s.Rev)}
+		if (a.fwd != "" && !dnaRegex.MatchString(a.fwd)) || (a.rev != "" && !dnaRegex.MatchString(a.rev)) {
+			return nil, fmt.Errorf("invalid primer-additions %s, %s: only ACGT bases are allowed", s.Fwd, s.Rev)
+		}
+		if s.Fragments != "" {
+			if a.pattern, err = regexp.Compile("^(?:" + s.Fragments + ")$"); err != nil {
+				return nil, fmt.Errorf("invalid primer-additions fragments pattern %q: %v", s.Fragments, err)
+			}
+		}
+		additions = append(additions, a)
+	}
+	return additions, nil
+}
+
+// addPrimerAdditions adds the first matching addition of the primer-additions setting
+// to the 5' ends of the primers, and to the PCR sequence, of each PCR fragment of the solutions
+func addPrimerAdditions(solutions [][]*Frag, conf *config.Config) error {
+	additions, err := newPrimerAdditions(conf.PrimerAdditions)
+	if err != nil || len(additions) == 0 {
+		return err
+	}
+
+	for _, solution := range solutions {
+		for _, f := range solution {
+			if f.fragType != pcr || len(f.Primers) < 2 || f.fwdAddition != "" || f.revAddition != "" {
+				continue
+			}
+			for _, a := range additions {
+				if a.pattern != nil && !a.pattern.MatchString(f.ID) {
+					continue
+				}
+				f.addPrimerAddition(a)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// addPrimerAddition adds an addition to the 5' ends of a PCR fragment's primers
+func (f *Frag) addPrimerAddition(a primerAddition) {
+	f.fwdAddition, f.revAddition = a.fwd, a.rev
+	for i, addition := range []string{a.fwd, a.rev} {
+		if addition == "" {
+			continue
+		}
+		f.Primers[i].Seq = addition + f.Primers[i].Seq
+		f.Primers[i].Notes = addNote(f.Primers[i].Notes, fmt.Sprintf("5' addition %s", addition))
+	}
+	f.PCRSeq = a.fwd + f.PCRSeq + reverseComplement(a.rev)
+}
+
+// primerAdditionWarnings returns a warning for each PCR fragment of a solution whose primers,
+// with their 5' additions, have hairpins or dimers that compete with their annealing or
+// bind off-target in the fragment's template
+func primerAdditionWarnings(frags []*Frag, conf *config.Config) (warnings []string) {
+	for _, f := range frags {
+		if f.fragType != pcr || len(f.Primers) < 2 || (f.fwdAddition == "" && f.revAddition == "") {
+			continue
+		}
+		if issues := extendedPrimerIssues(f, conf); len(issues) > 0 {
+			warnings = append(warnings, fmt.Sprintf("primers of %s with their 5' additions may fail: %s", f.ID, strings.Join(issues, ", ")))
+		}
+	}
+	return warnings
+}
+
+// extendedPrimerIssues re-screens the primers of a PCR fragment after their 5' additions
+func extendedPrimerIssues(f *Frag, conf *config.Config) (issues []string) {
+	fwd, rev := f.Primers[0], f.Primers[1]
+	minTm := fwd.Tm
+	if rev.Tm < minTm {
+		minTm = rev.Tm
+	}
+
+	for _, p := range []struct {
+		name   string
+		primer Primer
+	}{{"forward", fwd}, {"reverse", rev}} {
+		if melt := hairpin(p.primer.Seq, conf); p.primer.Tm > 0 && melt > p.primer.Tm-extendedPrimerMargin {
+			issues = append(issues, fmt.Sprintf("a %.0fC hairpin in the %s primer, vs its %.0fC Tm", melt, p.name, p.primer.Tm))
+		}
+	}
+
+	for _, pair := range []struct {
+		name       string
+		seq1, seq2 string
+	}{{"forward self-dimer", fwd.Seq, fwd.Seq}, {"reverse self-dimer", rev.Seq, rev.Seq}, {"primer dimer", fwd.Seq, rev.Seq}} {
+		if melt := dimer(pair.seq1, pair.seq2, conf); minTm > 0 && melt > minTm-extendedPrimerMargin {
+			issues = append(issues, fmt.Sprintf("a %.0fC %s, vs the primers' %.0fC Tm", melt, pair.name, minTm))
+		}
+	}
+
+	if err := f.checkOffTargets(conf); err != nil {
+		issues = append(issues, err.Error())
+	}
+	return issues
+}
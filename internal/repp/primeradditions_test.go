@@ -0,0 +1,70 @@
+package repp
+
+import (
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_addPrimerAdditions(t *testing.T) {
+	newFrag := func(id string) *Frag {
+		return &Frag{
+			ID:       id,
+			fragType: pcr,
+			PCRSeq:   "ATGCATGCAAAAAAAAGGCCTTGG",
+			Primers:  []Primer{{Seq: "ATGCATGC", Strand: true}, {Seq: "CCAAGGCC"}},
+		}
+	}
+	addgene, igem, synth := newFrag("gnl|addgene|107006"), newFrag("BBa_K123"), &Frag{ID: "synth", fragType: synthetic, Seq: "ATGC"}
+
+	conf := &config.Config{PrimerAdditions: []config.PrimerAddition{
+		{Fragments: `gnl\|addgene\|.*`, Fwd: "ggtctca", Rev: "GGTCTCG"},
+		{Fwd: "TCGTCGGCAGCGTC"},
+	}}
+	if err := addPrimerAdditions([][]*Frag{{addgene, igem, synth}, {addgene}}, conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if addgene.Primers[0].Seq != "GGTCTCAATGCATGC" || addgene.Primers[1].Seq != "GGTCTCGCCAAGGCC" {
+		t.Errorf("addgene primers = %s, %s, want the first addition once", addgene.Primers[0].Seq, addgene.Primers[1].Seq)
+	}
+	if addgene.PCRSeq != "GGTCTCAATGCATGCAAAAAAAAGGCCTTGGCGAGACC" {
+		t.Errorf("addgene PCRSeq = %s, want it with both additions", addgene.PCRSeq)
+	}
+	if igem.Primers[0].Seq != "TCGTCGGCAGCGTCATGCATGC" || igem.Primers[1].Seq != "CCAAGGCC" {
+		t.Errorf("igem primers = %s, %s, want the second addition on the forward primer", igem.Primers[0].Seq, igem.Primers[1].Seq)
+	}
+	if igem.Primers[0].Notes != "5' addition TCGTCGGCAGCGTC" {
+		t.Errorf("igem forward primer notes = %q, want the addition", igem.Primers[0].Notes)
+	}
+	if synth.Seq != "ATGC" {
+		t.Errorf("synthetic fragment = %s, want it unchanged", synth.Seq)
+	}
+
+	// the additions aren't part of the assembled sequence
+	for _, f := range []*Frag{addgene, igem} {
+		if got := f.getFragSeq(); got != "ATGCATGCAAAAAAAAGGCCTTGG" {
+			t.Errorf("getFragSeq() of %s = %s, want it without the additions", f.ID, got)
+		}
+	}
+}
+
+func Test_newPrimerAdditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings []config.PrimerAddition
+		wantErr  bool
+	}{
+		{"valid", []config.PrimerAddition{{Fragments: "pAB.*", Fwd: "GGTCTCA", Rev: "ggtctca"}}, false},
+		{"one side", []config.PrimerAddition{{Rev: "GGTCTCA"}}, false},
+		{"invalid pattern", []config.PrimerAddition{{Fragments: "pAB(", Fwd: "GGTCTCA"}}, true},
+		{"non-ACGT bases", []config.PrimerAddition{{Fwd: "GGTCTCN"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newPrimerAdditions(tt.settings); (err != nil) != tt.wantErr {
+				t.Errorf("newPrimerAdditions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
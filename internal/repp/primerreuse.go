@@ -0,0 +1,186 @@
+package repp
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// PickListEntry is a primer to pick from a plate, or the stock, the lab already has rather than order.
+type PickListEntry struct {
+	// ID of the primer in the primer manifest
+	ID string `json:"id"`
+
+	// Seq of the primer
+	Seq string `json:"seq"`
+
+	// Plate the primer is on
+	Plate string `json:"plate"`
+
+	// Well of the primer on its plate
+	Well string `json:"well"`
+
+	// Location the primer is stored at, ex: a freezer and box, if the manifest has one
+	Location string `json:"location,omitempty"`
+
+	// Validated is "yes" or "no" if the manifest says whether the primer was validated at the bench
+	Validated string `json:"validated,omitempty"`
+
+	// Ordered is the date the primer was ordered, YYYY-MM-DD, if the manifest has one
+	Ordered string `json:"ordered,omitempty"`
+}
+
+// newPickListEntry returns the pick list entry of a primer from the primer manifests
+func newPickListEntry(o oligo, seq string) PickListEntry {
+	entry := PickListEntry{ID: o.id, Seq: seq, Plate: o.plate, Well: o.well, Location: o.location}
+	switch o.validation {
+	case validated:
+		entry.Validated = "yes"
+	case unvalidated:
+		entry.Validated = "no"
+	}
+	if !o.ordered.IsZero() {
+		entry.Ordered = o.ordered.Format("2006-01-02")
+	}
+	return entry
+}
+
+// setPrimerReuseBonus sets the reuse bonus of the fragments that will be PCR'ed with primers likely
+// to be ones already on plates, or at known locations, in the primer manifests: those whose 3' ends
+// anneal where primer3 picks the fragment's primers, within the buffer and max primer length of its
+// ends. It's reuseBonus for each such end, half if the primer wasn't validated, and it's subtracted from
+// the fragment's estimated adjusted cost, so assemblies that can reuse primers on hand are created and
+// ranked as cheaper before their primers are designed.
+func setPrimerReuseBonus(frags []*Frag, target string, primersDB *oligosDB, reuseBonus float64, conf *config.Config) {
+	k := conf.PcrPrimerMinLength
+	if reuseBonus <= 0 || primersDB == nil || len(primersDB.indexedOligos) == 0 || k < 1 || len(target) < k {
+		return
+	}
+
+	// the bonus of the stocked primers by the target sequence their 3' ends anneal to, on either strand
+	fwdBonus, revBonus := make(map[string]float64), make(map[string]float64)
+	for seq, o := range primersDB.indexedOligos {
+		if !(o.onPlate() || o.location != "") || len(seq) < k {
+			continue
+		}
+		bonus := reuseBonus
+		if o.validation == unvalidated {
+			bonus = reuseBonus / 2
+		}
+		end := seq[len(seq)-k:]
+		fwdBonus[end] = math.Max(fwdBonus[end], bonus)
+		revBonus[reverseComplement(end)] = math.Max(revBonus[reverseComplement(end)], bonus)
+	}
+
+	circ := newCircularSeq(strings.ToUpper(target))
+	window := conf.PcrBufferLength + conf.PcrPrimerMaxLength
+	for _, f := range frags {
+		f.reuseBonus = 0
+		if f.fragType == synthetic || f.end-f.start+1 < window {
+			continue
+		}
+
+		fwd, rev := 0.0, 0.0
+		for i := f.start; i+k <= f.start+window; i++ {
+			fwd = math.Max(fwd, fwdBonus[circ.get(i, i+k)])
+		}
+		for i := f.end - window + 1; i+k <= f.end+1; i++ {
+			rev = math.Max(rev, revBonus[circ.get(i, i+k)])
+		}
+		f.reuseBonus = fwd + rev
+	}
+}
+
+// applyPrimerReuse finds the primers of each solution that are already on plates, or at known
+// locations, in the primer manifests and lists them in the solution's pick list. Each reused primer
+// lowers the solution's adjusted cost by reuseBonus, so among solutions with the same number of
+// fragments, those that reuse primers on hand are preferred. It settles, with the designed primers, the
+// estimate of setPrimerReuseBonus that the assemblies were ranked by. Primers the manifests say weren't
+// validated only get half the bonus, and a warning if they were ordered more than maxUnvalidatedAge ago.
+func applyPrimerReuse(solutions []Solution, primersDB *oligosDB, reuseBonus float64, maxUnvalidatedAge time.Duration) {
+	if primersDB == nil || len(primersDB.indexedOligos) == 0 {
+		return
+	}
+
+	reused := false
+	for i := range solutions {
+		s := &solutions[i]
+		picked := make(map[string]bool)
+		bonus := 0.0
+		for _, f := range s.Fragments {
+			for _, p := range f.Primers {
+				o := searchOligoDBs(p.Seq, []*oligosDB{primersDB})
+				if !(o.onPlate() || o.location != "") || picked[strings.ToUpper(p.Seq)] {
+					continue
+				}
+				picked[strings.ToUpper(p.Seq)] = true
+				s.PickList = append(s.PickList, newPickListEntry(o, p.Seq))
+
+				if o.validation != unvalidated {
+					bonus += reuseBonus
+					continue
+				}
+				bonus += reuseBonus / 2
+				if maxUnvalidatedAge > 0 && !o.ordered.IsZero() && time.Since(o.ordered) > maxUnvalidatedAge {
+					s.Warnings = append(s.Warnings, fmt.Sprintf(
+						"reused primer %s was ordered %s and never validated, check it before use",
+						o.id, o.ordered.Format("2006-01-02")))
+				}
+			}
+		}
+
+		s.ReusedPrimers = len(s.PickList)
+		if s.ReusedPrimers > 0 {
+			s.AdjustedCost = roundCost(s.AdjustedCost - bonus)
+			reused = true
+		}
+	}
+
+	if reused && reuseBonus > 0 {
+		sort.SliceStable(solutions, func(i, j int) bool {
+			if solutions[i].Count != solutions[j].Count {
+				return solutions[i].Count < solutions[j].Count
+			}
+			return solutions[i].AdjustedCost < solutions[j].AdjustedCost
+		})
+	}
+}
+
+// writePickList writes the plate, well and location of every reused primer, by solution, to a CSV file,
+// with the primers renamed by the naming map. Nothing is written if no solution reuses primers.
+func writePickList(filename string, out *Output, names namingMap) (err error) {
+	hasPicks := false
+	for _, s := range out.Solutions {
+		hasPicks = hasPicks || len(s.PickList) > 0
+	}
+	if !hasPicks {
+		return nil
+	}
+
+	pickListFile, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer pickListFile.close(&err)
+
+	w := csv.NewWriter(pickListFile)
+	if err = w.Write([]string{"Solution", "Primer ID", "Seq", "Plate", "Well", "Location", "Validated", "Ordered"}); err != nil {
+		return err
+	}
+	for si, s := range out.Solutions {
+		for _, p := range s.PickList {
+			if err = w.Write([]string{strconv.Itoa(si + 1), names.rename(p.ID), p.Seq, p.Plate, p.Well, p.Location, p.Validated, p.Ordered}); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
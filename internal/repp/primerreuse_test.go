@@ -0,0 +1,99 @@
+package repp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_applyPrimerReuse(t *testing.T) {
+	primersDB := newOligosDB(primerIDPrefix, false)
+	primersDB.addOligo(oligo{id: "oS1", seq: "ACGTACGTAC", plate: "P1", well: "B2"})
+	primersDB.addOligo(oligo{id: "oS2", seq: "GGGCCCAAAT"}) // in stock but not on a plate
+
+	withPrimers := func(seqs ...string) []*Frag {
+		f := &Frag{}
+		for _, s := range seqs {
+			f.Primers = append(f.Primers, Primer{Seq: s})
+		}
+		return []*Frag{f}
+	}
+	solutions := []Solution{
+		{Count: 2, AdjustedCost: 10, Fragments: withPrimers("TTTTAAAACC", "GGGCCCAAAT")},
+		{Count: 2, AdjustedCost: 10.5, Fragments: withPrimers("acgtacgtac", "CCCCGGGGAA")},
+		{Count: 3, AdjustedCost: 5, Fragments: withPrimers("ACGTACGTAC", "ACGTACGTAC")},
+	}
+
+	applyPrimerReuse(solutions, primersDB, 1.0, 0)
+
+	var gotCosts []float64
+	var gotReused []int
+	for _, s := range solutions {
+		gotCosts = append(gotCosts, s.AdjustedCost)
+		gotReused = append(gotReused, s.ReusedPrimers)
+	}
+	if want := []float64{9.5, 10, 4}; !reflect.DeepEqual(gotCosts, want) {
+		t.Errorf("applyPrimerReuse() adjusted costs = %v, want %v", gotCosts, want)
+	}
+	if want := []int{1, 0, 1}; !reflect.DeepEqual(gotReused, want) {
+		t.Errorf("applyPrimerReuse() reused primers = %v, want %v", gotReused, want)
+	}
+	wantPick := []PickListEntry{{ID: "oS1", Seq: "acgtacgtac", Plate: "P1", Well: "B2"}}
+	if !reflect.DeepEqual(solutions[0].PickList, wantPick) {
+		t.Errorf("applyPrimerReuse() pick list = %v, want %v", solutions[0].PickList, wantPick)
+	}
+}
+
+func Test_applyPrimerReuse_validation(t *testing.T) {
+	primersDB := newOligosDB(primerIDPrefix, false)
+	old := time.Now().AddDate(-2, 0, 0)
+	primersDB.addOligo(oligo{id: "oS1", seq: "ACGTACGTAC", location: "freezer A", validation: validated, ordered: old})
+	primersDB.addOligo(oligo{id: "oS2", seq: "GGGCCCAAAT", location: "freezer B", validation: unvalidated, ordered: old})
+
+	solutions := []Solution{{Count: 1, AdjustedCost: 10, Fragments: []*Frag{{Primers: []Primer{{Seq: "ACGTACGTAC"}, {Seq: "GGGCCCAAAT"}}}}}}
+	applyPrimerReuse(solutions, primersDB, 1.0, 365*24*time.Hour)
+
+	s := solutions[0]
+	if s.AdjustedCost != 8.5 || s.ReusedPrimers != 2 {
+		t.Errorf("applyPrimerReuse() adjusted cost = %v, reused = %d, want 8.5, 2", s.AdjustedCost, s.ReusedPrimers)
+	}
+	wantPick := []PickListEntry{
+		{ID: "oS1", Seq: "ACGTACGTAC", Location: "freezer A", Validated: "yes", Ordered: old.Format("2006-01-02")},
+		{ID: "oS2", Seq: "GGGCCCAAAT", Location: "freezer B", Validated: "no", Ordered: old.Format("2006-01-02")},
+	}
+	if !reflect.DeepEqual(s.PickList, wantPick) {
+		t.Errorf("applyPrimerReuse() pick list = %v, want %v", s.PickList, wantPick)
+	}
+	if len(s.Warnings) != 1 || !strings.Contains(s.Warnings[0], "oS2") {
+		t.Errorf("applyPrimerReuse() warnings = %v, want one about oS2", s.Warnings)
+	}
+}
+
+func Test_setPrimerReuseBonus(t *testing.T) {
+	conf := &config.Config{PcrPrimerMinLength: 10, PcrPrimerMaxLength: 12, PcrBufferLength: 2, PcrBpCost: 0.1, PcrRxnCost: 1}
+	target := "ACGTACGTACGGATTACAGATTACAGATTACAGATTACATTTTCCCCGGGGAAAA"
+
+	primersDB := newOligosDB(primerIDPrefix, false)
+	primersDB.addOligo(oligo{id: "oS1", seq: "TTACGTACGTACG", plate: "P1", well: "B2"})                // anneals at the start
+	primersDB.addOligo(oligo{id: "oS2", seq: reverseComplement("TCCCCGGGGAA"), location: "freezer A"}) // at the end, reverse
+	primersDB.addOligo(oligo{id: "oS3", seq: "GATTACAGATTAC", plate: "P1", well: "B3"})                // in the middle
+
+	both := &Frag{start: 0, end: len(target) - 1, fragType: pcr, conf: conf}
+	mid := &Frag{start: 11, end: 40, fragType: pcr, conf: conf}
+	synth := &Frag{start: 0, end: len(target) - 1, fragType: synthetic, conf: conf}
+	setPrimerReuseBonus([]*Frag{both, mid, synth}, target, primersDB, 1.0, conf)
+
+	if both.reuseBonus != 2 || mid.reuseBonus != 1 || synth.reuseBonus != 0 {
+		t.Errorf("setPrimerReuseBonus() bonuses = %v, %v, %v, want 2, 1, 0", both.reuseBonus, mid.reuseBonus, synth.reuseBonus)
+	}
+
+	_, adjusted := both.cost(false)
+	both.reuseBonus = 0
+	_, wantAdjusted := both.cost(false)
+	if adjusted != wantAdjusted-2 {
+		t.Errorf("Frag.cost() adjusted = %v, want %v", adjusted, wantAdjusted-2)
+	}
+}
@@ -0,0 +1,178 @@
+package repp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// priorPlan is the fragments and primers of a previous output. A re-design of a slightly
+// changed target reuses them wherever they're still valid so fewer new reagents are ordered.
+type priorPlan struct {
+	// name of the previous output file
+	name string
+
+	// templates of the previous PCR fragments
+	templates map[string]bool
+
+	// primers of the previous PCR fragments, as pairs, by template
+	primers map[string][][2]string
+
+	// reagents are the sequences of the previous primers and synthetic fragments
+	reagents map[string]bool
+}
+
+// readPriorPlan reads the fragments and primers of a previous output's solutions
+func readPriorPlan(filename string) (*priorPlan, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	out := Output{}
+	if err = json.Unmarshal(contents, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", filename, err)
+	}
+
+	plan := &priorPlan{
+		name:      filepath.Base(filename),
+		templates: make(map[string]bool),
+		primers:   make(map[string][][2]string),
+		reagents:  make(map[string]bool),
+	}
+	for _, s := range out.Solutions {
+		for _, f := range s.Fragments {
+			switch {
+			case f.Type == synthetic.String():
+				plan.reagents[strings.ToUpper(f.Seq)] = true
+			case len(f.Primers) == 2:
+				pair := [2]string{strings.ToUpper(f.Primers[0].Seq), strings.ToUpper(f.Primers[1].Seq)}
+				plan.templates[f.ID] = true
+				plan.primers[f.ID] = append(plan.primers[f.ID], pair)
+				plan.reagents[pair[0]], plan.reagents[pair[1]] = true, true
+			}
+		}
+	}
+	if len(plan.reagents) == 0 {
+		return nil, fmt.Errorf("no fragments or primers found in %s", filename)
+	}
+	return plan, nil
+}
+
+// reusedTemplates returns the number of an assembly's fragments whose templates were in the plan
+func (plan *priorPlan) reusedTemplates(a assembly) (reused int) {
+	for _, f := range a.frags {
+		if plan.templates[f.ID] {
+			reused++
+		}
+	}
+	return
+}
+
+// preferReused sorts assemblies by their number of fragments and synthetic fragments, then
+// by how many of the plan's templates they reuse. Otherwise their order is unchanged.
+func (plan *priorPlan) preferReused(assemblies []assembly) {
+	sort.SliceStable(assemblies, func(i, j int) bool {
+		a, b := assemblies[i], assemblies[j]
+		if a.len() != b.len() {
+			return a.len() < b.len()
+		}
+		if a.synths != b.synths {
+			return a.synths < b.synths
+		}
+		return plan.reusedTemplates(a) > plan.reusedTemplates(b)
+	})
+}
+
+// reusedReagents returns the number of a solution's primers and synthetic fragments that
+// were in the plan, and so don't have to be ordered again
+func (plan *priorPlan) reusedReagents(frags []*Frag) (reused int) {
+	for _, f := range frags {
+		if f.fragType == synthetic && plan.reagents[strings.ToUpper(f.Seq)] {
+			reused++
+		}
+		for _, p := range f.Primers {
+			if plan.reagents[strings.ToUpper(p.Seq)] {
+				reused++
+			}
+		}
+	}
+	return
+}
+
+// reusePrimers sets a Frag's primers to a pair from the plan that's still valid for the
+// target. A pair is valid if, with their 5' tails, the primers are still in the target, they
+// reach as far into the neighboring fragments as new primers would, their 3' ends anneal to
+// the fragment's template, and their Tms are within the max allowed difference. It returns
+// false if no pair is valid. The pair still has to be checked for off-targets.
+func (plan *priorPlan) reusePrimers(f, prev, next *Frag, seq string, conf *config.Config) bool {
+	p := &primer3{seq: strings.ToUpper(seq), templateMismatches: f.mismatchIndexes, config: conf}
+	addLeft, addRight := p.bpToAdd(prev, f), p.bpToAdd(f, next)
+
+	for _, pair := range plan.primers[f.ID] {
+		primers, tailLeft, tailRight, ok := p.priorPrimers(pair, f.start-addLeft, f.end+addRight, f.start, f.end)
+		if !ok {
+			continue
+		}
+		if maxDiff := conf.PcrMaxFwdRevPrimerTmDiff; maxDiff > 0 && math.Abs(primers[0].Tm-primers[1].Tm) > maxDiff {
+			continue
+		}
+
+		for i := range primers {
+			primers[i].Notes = addNote(primers[i].Notes, "reused from "+plan.name)
+		}
+		f.Primers = primers
+		mutatePrimers(f, seq, tailLeft, tailRight)
+		return true
+	}
+	return false
+}
+
+// priorPrimers finds where a pair of primers from a previous plan are in the target. Each
+// primer has to reach past its side of [reachStart, reachEnd] and its 3' end has to anneal
+// within [start, end], the range of its template. It returns the primers' priming regions
+// and the lengths of their 5' tails outside the template.
+func (p *primer3) priorPrimers(pair [2]string, reachStart, reachEnd, start, end int) (primers []Primer, tailLeft, tailRight int, ok bool) {
+	k := p.config.PcrPrimerMinLength
+	template := newCircularSeq(p.seq)
+
+	// left primer's bases are [start, end), its 3' end at end-1
+	leftEnd := -1
+	for i := template.indexFrom(pair[0], 0); i >= 0 && i <= reachStart; i = template.indexFrom(pair[0], i+1) {
+		if e := i + len(pair[0]); e-k >= start && e <= end && len(p.mismatchesIn(e-k, e)) == 0 {
+			tailLeft, leftEnd = start-i, e
+			break
+		}
+	}
+
+	// right primer's bases are (start, end], its 3' end at start+1
+	rightStart := -1
+	rc := reverseComplement(pair[1])
+	for i := template.indexFrom(rc, 0); i >= 0; i = template.indexFrom(rc, i+1) {
+		if e := i + len(rc) - 1; e >= reachEnd && i >= leftEnd && i+k-1 <= end && len(p.mismatchesIn(i, i+k)) == 0 {
+			tailRight, rightStart = e-end, i
+			break
+		}
+	}
+
+	if leftEnd < 0 || rightStart < 0 {
+		return nil, 0, 0, false
+	}
+
+	leftRegion := template.get(leftEnd-len(pair[0])+tailLeft, leftEnd)
+	rightRegion := reverseComplement(template.get(rightStart, rightStart+len(rc)-tailRight))
+	primers = []Primer{
+		{Seq: leftRegion, Strand: true, PrimingRegion: leftRegion, Range: ranged{leftEnd - len(leftRegion), leftEnd}},
+		{Seq: rightRegion, Strand: false, PrimingRegion: rightRegion, Range: ranged{rightStart - 1, rightStart + len(rightRegion) - 1}},
+	}
+	for i, region := range []string{leftRegion, rightRegion} {
+		primers[i].Tm = primerTm(region)
+		primers[i].GC = 100 * float64(strings.Count(region, "G")+strings.Count(region, "C")) / float64(len(region))
+	}
+	return primers, tailLeft, tailRight, true
+}
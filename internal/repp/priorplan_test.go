@@ -0,0 +1,111 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_readPriorPlan(t *testing.T) {
+	previous := `{"solutions": [{"fragments": [
+		{"id": "pSB1A3", "type": "pcr", "primers": [{"seq": "acgtacgtacgtacgtac"}, {"seq": "TTGGCCAATTGGCCAATT"}]},
+		{"id": "target-synthesis-1", "type": "synthetic", "seq": "GGGCCCAAATTT"}
+	]}]}`
+	filename := filepath.Join(t.TempDir(), "previous.json")
+	if err := os.WriteFile(filename, []byte(previous), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := readPriorPlan(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.templates["pSB1A3"] || len(plan.primers["pSB1A3"]) != 1 {
+		t.Errorf("readPriorPlan() templates = %v, primers = %v, want the pSB1A3 pair", plan.templates, plan.primers)
+	}
+	for _, seq := range []string{"ACGTACGTACGTACGTAC", "TTGGCCAATTGGCCAATT", "GGGCCCAAATTT"} {
+		if !plan.reagents[seq] {
+			t.Errorf("readPriorPlan() reagents missing %s", seq)
+		}
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(empty, []byte(`{"solutions": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readPriorPlan(empty); err == nil {
+		t.Error("readPriorPlan() of an output without fragments should fail")
+	}
+}
+
+func Test_primer3_priorPrimers(t *testing.T) {
+	target := "TGAAAAGCCACATGATTATAGGTACTAAGATCATAGCGAAGAGGTACAGCCTGTTGGGACTGTCAGCAACACGCTGTTAATCGTTGATACTGCCGTAGGCGGTTGGATCACCGTGTAGGACGAGGGCGCTTCGCCGCATCGCCGGGCGTCTAAACATTATGCCCGGGCAGCAGTGTCGATGGCGTCTGAAAGAACACAGT"
+
+	// previous primers with 10bp and 14bp tails past a template at [50, 150]
+	left, right := target[40:70], reverseComplement(target[130:165])
+
+	tests := []struct {
+		name          string
+		pair          [2]string
+		reachStart    int
+		mismatches    []int
+		wantOK        bool
+		wantTailLeft  int
+		wantTailRight int
+	}{
+		{"primers still in the target", [2]string{left, right}, 45, nil, true, 10, 14},
+		{"left primer changed", [2]string{"AAAAAAAAAA" + left[10:], right}, 45, nil, false, 0, 0},
+		{"mismatch at the left primer's 3' end", [2]string{left, right}, 45, []int{65}, false, 0, 0},
+		{"left primer doesn't reach the neighbor", [2]string{left, right}, 35, nil, false, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.New()
+			c.PcrPrimerMinLength = 18
+			p := &primer3{seq: target, templateMismatches: tt.mismatches, config: c}
+
+			primers, tailLeft, tailRight, ok := p.priorPrimers(tt.pair, tt.reachStart, 155, 50, 150)
+			if ok != tt.wantOK {
+				t.Fatalf("priorPrimers() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if tailLeft != tt.wantTailLeft || tailRight != tt.wantTailRight {
+				t.Errorf("priorPrimers() tails = %d, %d, want %d, %d", tailLeft, tailRight, tt.wantTailLeft, tt.wantTailRight)
+			}
+			if want := target[50:70]; primers[0].Seq != want || primers[0].Range != (ranged{50, 70}) {
+				t.Errorf("priorPrimers() left = %s %v, want %s [50, 70)", primers[0].Seq, primers[0].Range, want)
+			}
+			if want := reverseComplement(target[130:151]); primers[1].Seq != want || primers[1].Range != (ranged{129, 150}) {
+				t.Errorf("priorPrimers() right = %s %v, want %s (129, 150]", primers[1].Seq, primers[1].Range, want)
+			}
+		})
+	}
+}
+
+func Test_priorPlan_preferReused(t *testing.T) {
+	plan := &priorPlan{templates: map[string]bool{"pSB1A3": true, "pUC19": true}}
+	assemblies := []assembly{
+		{frags: []*Frag{{ID: "a"}, {ID: "b"}}},
+		{frags: []*Frag{{ID: "pSB1A3"}, {ID: "b"}}},
+		{frags: []*Frag{{ID: "pSB1A3"}, {ID: "pUC19"}}},
+		{frags: []*Frag{{ID: "pSB1A3"}, {ID: "pUC19"}}, synths: 1}, // more synthetic fragments
+	}
+
+	plan.preferReused(assemblies)
+
+	var got []int
+	for _, a := range assemblies {
+		got = append(got, plan.reusedTemplates(a))
+	}
+	if want := []int{2, 1, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("preferReused() reused templates = %v, want %v", got, want)
+	}
+	if assemblies[3].synths != 1 {
+		t.Error("preferReused() moved an assembly ahead of one with fewer synthetic fragments")
+	}
+}
@@ -0,0 +1,288 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// colonyPCRFlank is the distance (bp) of the colony PCR screening primers
+// from the ends of the linearized backbone, on either side of the insert
+const colonyPCRFlank = 100
+
+// PredictedProduct is a circular product that may be recovered from an assembly into
+// an enzyme-linearized backbone: the intended product or a common failure product.
+type PredictedProduct struct {
+	// Name of the product, ex: "backbone self-religation"
+	Name string `json:"name"`
+
+	// Length of the circular product in bp
+	Length int `json:"length"`
+
+	// ColonyPCRBand is the expected band (bp) of a colony PCR with primers
+	// that bind the backbone 100bp outside of the insert
+	ColonyPCRBand int `json:"colonyPCRBand"`
+
+	// DigestBands are the bands (bp) after digestion with the diagnostic enzyme
+	DigestBands []int `json:"digestBands,omitempty"`
+
+	// seq of the circular product
+	seq string
+}
+
+// Screening is how to tell a solution's intended product apart from its likely failure products
+type Screening struct {
+	// DiagnosticEnzyme digests the intended product into bands that differ from the failure products'
+	DiagnosticEnzyme string `json:"diagnosticEnzyme,omitempty"`
+
+	// Products that may be recovered, the intended product first
+	Products []PredictedProduct `json:"products"`
+}
+
+// String returns the product as it's written to the strategy CSV
+func (p PredictedProduct) String() string {
+	product := fmt.Sprintf("%s, %dbp, colony PCR %dbp", p.Name, p.Length, p.ColonyPCRBand)
+	if len(p.DigestBands) > 0 {
+		bands := make([]string, len(p.DigestBands))
+		for i, b := range p.DigestBands {
+			bands[i] = fmt.Sprint(b)
+		}
+		product += fmt.Sprintf(", digest %sbp", strings.Join(bands, "+"))
+	}
+	return product
+}
+
+// addScreening predicts the failure products of each solution with a backbone that was
+// linearized by enzymes, and picks a diagnostic enzyme to tell them apart.
+func addScreening(solutions []Solution, productSeq string, backbone *Backbone) {
+	if backbone == nil || len(backbone.Enzymes) == 0 {
+		return
+	}
+
+	enzymes, err := getValidEnzymes(backbone.Enzymes)
+	if err != nil {
+		rlog.Warnf("failed to predict failure products: %v", err)
+		return
+	}
+	candidates := diagnosticCandidates()
+
+	for i := range solutions {
+		products := predictProducts(productSeq, backbone, enzymes, solutions[i].Fragments)
+		solutions[i].Screening = &Screening{
+			DiagnosticEnzyme: diagnosticDigest(products, candidates),
+			Products:         products,
+		}
+	}
+}
+
+// predictProducts returns the intended product followed by the likely failure products
+// of an assembly into an enzyme-linearized backbone: the uncut backbone, the backbone
+// religated to itself if its ends are compatible, and the backbone closed around each
+// single insert fragment. Single insertions are approximate: the fragment's span up to
+// the next fragment in the assembly is inserted.
+func predictProducts(productSeq string, backbone *Backbone, enzymes []enzyme, frags []*Frag) (products []PredictedProduct) {
+	productSeq = strings.ToUpper(productSeq)
+	original := strings.ToUpper(backbone.Seq)
+	linearized := linearizedBackbone(original, backbone.Cutsites)
+	dropout := len(original) - len(linearized)
+
+	products = append(products, PredictedProduct{
+		Name:          "intended product",
+		Length:        len(productSeq),
+		ColonyPCRBand: len(productSeq) - len(linearized) + 2*colonyPCRFlank,
+		seq:           productSeq,
+	})
+
+	if dropout == 0 {
+		// a single cut: the religated backbone is the same as the uncut one
+		products = append(products, PredictedProduct{
+			Name:          "uncut or self-religated backbone",
+			Length:        len(original),
+			ColonyPCRBand: 2 * colonyPCRFlank,
+			seq:           original,
+		})
+	} else {
+		products = append(products, PredictedProduct{
+			Name:          "uncut backbone",
+			Length:        len(original),
+			ColonyPCRBand: dropout + 2*colonyPCRFlank,
+			seq:           original,
+		})
+
+		startEnzyme, endEnzyme := backboneEndEnzymes(backbone, enzymes)
+		if startEnzyme.overhang() == endEnzyme.overhang() {
+			products = append(products, PredictedProduct{
+				Name:          "backbone self-religation",
+				Length:        len(linearized),
+				ColonyPCRBand: 2 * colonyPCRFlank,
+				seq:           linearized,
+			})
+		}
+	}
+
+	return append(products, singleInsertions(productSeq, len(linearized), frags)...)
+}
+
+// linearizedBackbone returns the backbone between its first and last cutsites
+func linearizedBackbone(seq string, cutsites []int) string {
+	if len(seq) == 0 || len(cutsites) == 0 {
+		return seq
+	}
+
+	start := cutsites[0] % len(seq)
+	end := start + len(seq)
+	if len(cutsites) > 1 && cutsites[len(cutsites)-1] > cutsites[0] {
+		end = start + cutsites[len(cutsites)-1] - cutsites[0]
+	}
+	return circularSpan(seq, start, end-start)
+}
+
+// singleInsertions returns the products of the backbone closed around a single insert fragment.
+// There are none if the assembly has fewer than two insert fragments.
+func singleInsertions(productSeq string, backboneLength int, frags []*Frag) (products []PredictedProduct) {
+	backboneIndex := -1
+	for i, f := range frags {
+		if strings.HasPrefix(f.uniqueID, "backbone") {
+			backboneIndex = i
+		}
+	}
+	if backboneIndex < 0 || len(frags) < 3 || len(productSeq) == 0 {
+		return nil
+	}
+
+	L := len(productSeq)
+	backboneSeq := circularSpan(productSeq, frags[backboneIndex].start, backboneLength)
+	for i, f := range frags {
+		if i == backboneIndex {
+			continue
+		}
+
+		nextStart := frags[(i+1)%len(frags)].start
+		if i+1 == len(frags) {
+			nextStart += L
+		}
+		span := nextStart - f.start
+		if span <= 0 || span >= L {
+			continue
+		}
+
+		seq := backboneSeq + circularSpan(productSeq, f.start, span)
+		products = append(products, PredictedProduct{
+			Name:          fmt.Sprintf("single insertion of fragment %d (%s)", i+1, f.ID),
+			Length:        len(seq),
+			ColonyPCRBand: span + 2*colonyPCRFlank,
+			seq:           seq,
+		})
+	}
+
+	return products
+}
+
+// circularSpan returns length bp of a circular sequence starting at start
+func circularSpan(seq string, start, length int) string {
+	if len(seq) == 0 || length <= 0 {
+		return ""
+	}
+
+	start = ((start % len(seq)) + len(seq)) % len(seq)
+	var span strings.Builder
+	for span.Len() < length {
+		end := start + length - span.Len()
+		if end > len(seq) {
+			end = len(seq)
+		}
+		span.WriteString(seq[start:end])
+		start = 0
+	}
+	return span.String()
+}
+
+// diagnosticCandidates returns the enzymes in the enzyme database, by name
+func diagnosticCandidates() (candidates []enzyme) {
+	enzymeDB := NewEnzymeDB()
+	for name, recog := range enzymeDB.contents {
+		if e := newEnzyme(name, recog); e.name != "" {
+			candidates = append(candidates, e)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].name < candidates[j].name
+	})
+	return candidates
+}
+
+// diagnosticDigest picks the enzyme whose digest of the intended product is most easily
+// told apart from the digests of the failure products on a gel, and sets the bands of each
+// product. An empty name is returned if no enzyme distinguishes all the products.
+func diagnosticDigest(products []PredictedProduct, candidates []enzyme) (name string) {
+	if len(products) < 2 {
+		return ""
+	}
+
+	var best [][]int
+	bestScore := 0
+	for _, e := range candidates {
+		intended := digestBands(products[0].seq, e)
+		if len(intended) < 2 || len(intended) > 5 {
+			continue // a few bands are easiest to read
+		}
+
+		bands := [][]int{intended}
+		distinct := true
+		for _, p := range products[1:] {
+			pBands := digestBands(p.seq, e)
+			if !bandsDistinguishable(intended, pBands) {
+				distinct = false
+				break
+			}
+			bands = append(bands, pBands)
+		}
+		if !distinct {
+			continue
+		}
+
+		// prefer three bands, then the largest smallest band
+		bandCountScore := 3 - (len(intended) - 3)
+		if len(intended) < 3 {
+			bandCountScore = len(intended)
+		}
+		score := 100000*bandCountScore + intended[len(intended)-1]
+		if score > bestScore {
+			name, best, bestScore = e.name, bands, score
+		}
+	}
+
+	for i := range best {
+		products[i].DigestBands = best[i]
+	}
+	return name
+}
+
+// digestBands returns the band lengths of a circular sequence digested by an
+// enzyme, longest first. An uncut sequence has no bands.
+func digestBands(seq string, e enzyme) []int {
+	_, bands := cutsites(seq, []enzyme{e})
+	if len(bands) == 1 {
+		bands[0] = len(seq) // linearized by a single cut
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(bands)))
+	return bands
+}
+
+// bandsDistinguishable returns whether two digests can be told apart on a gel: they have
+// a different number of bands or a band that differs by more than 10% in length
+func bandsDistinguishable(a, b []int) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for i := range a {
+		larger, smaller := a[i], b[i]
+		if larger < smaller {
+			larger, smaller = smaller, larger
+		}
+		if (larger-smaller)*10 > larger {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package repp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_predictProducts(t *testing.T) {
+	ecoRI := newEnzyme("EcoRI", "G^AATT_C")
+	backboneSeq := strings.Repeat("A", 100) + "GAATTC" + strings.Repeat("T", 200) + "GAATTC" + strings.Repeat("C", 88)
+
+	type product struct {
+		name          string
+		length        int
+		colonyPCRBand int
+	}
+	tests := []struct {
+		name       string
+		backbone   *Backbone
+		productSeq string
+		want       []product
+	}{
+		{
+			"single cut",
+			&Backbone{Seq: backboneSeq, Enzymes: []string{"EcoRI"}, Cutsites: []int{101}},
+			backboneSeq + strings.Repeat("G", 300),
+			[]product{
+				{"intended product", 700, 500},
+				{"uncut or self-religated backbone", 400, 200},
+			},
+		},
+		{
+			"two compatible cuts",
+			&Backbone{Seq: backboneSeq, Enzymes: []string{"EcoRI", "EcoRI"}, Cutsites: []int{307, 501}},
+			strings.Repeat("G", 194) + strings.Repeat("G", 300),
+			[]product{
+				{"intended product", 494, 500},
+				{"uncut backbone", 400, 406},
+				{"backbone self-religation", 194, 200},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []product
+			for _, p := range predictProducts(tt.productSeq, tt.backbone, []enzyme{ecoRI}, nil) {
+				if p.Length != len(p.seq) {
+					t.Errorf("predictProducts() %s length = %d, seq is %dbp", p.Name, p.Length, len(p.seq))
+				}
+				got = append(got, product{p.Name, p.Length, p.ColonyPCRBand})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("predictProducts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_singleInsertions(t *testing.T) {
+	productSeq := strings.Repeat("A", 100) + strings.Repeat("C", 40) + strings.Repeat("G", 60)
+	frags := []*Frag{
+		{ID: "backbone", uniqueID: "backbone0", start: 0, end: 109},
+		{ID: "frag1", start: 90, end: 149},
+		{ID: "frag2", start: 140, end: 209},
+	}
+
+	got := singleInsertions(productSeq, 100, frags)
+	if len(got) != 2 {
+		t.Fatalf("singleInsertions() returned %d products, want 2", len(got))
+	}
+	if got[0].Length != 150 || got[0].seq != strings.Repeat("A", 100)+strings.Repeat("A", 10)+strings.Repeat("C", 40) {
+		t.Errorf("singleInsertions() first product = %d bp %s", got[0].Length, got[0].seq)
+	}
+	if got[1].Length != 160 || got[1].ColonyPCRBand != 260 {
+		t.Errorf("singleInsertions() second product = %d bp, colony PCR %d bp", got[1].Length, got[1].ColonyPCRBand)
+	}
+
+	if got := singleInsertions(productSeq, 100, frags[:2]); got != nil {
+		t.Errorf("singleInsertions() with a single insert fragment = %v, want none", got)
+	}
+}
+
+func Test_diagnosticDigest(t *testing.T) {
+	products := []PredictedProduct{
+		{Name: "intended product", seq: "GAATTC" + strings.Repeat("A", 300) + "GAATTC" + strings.Repeat("A", 500)},
+		{Name: "uncut backbone", seq: "GAATTC" + strings.Repeat("A", 400)},
+	}
+	candidates := []enzyme{newEnzyme("BamHI", "G^GATC_C"), newEnzyme("EcoRI", "G^AATT_C")}
+
+	if got := diagnosticDigest(products, candidates); got != "EcoRI" {
+		t.Errorf("diagnosticDigest() = %q, want EcoRI", got)
+	}
+	if want := []int{506, 306}; !reflect.DeepEqual(products[0].DigestBands, want) {
+		t.Errorf("diagnosticDigest() intended bands = %v, want %v", products[0].DigestBands, want)
+	}
+	if want := []int{406}; !reflect.DeepEqual(products[1].DigestBands, want) {
+		t.Errorf("diagnosticDigest() uncut backbone bands = %v, want %v", products[1].DigestBands, want)
+	}
+}
+
+func Test_bandsDistinguishable(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []int
+		want bool
+	}{
+		{"different band counts", []int{500, 300}, []int{800}, true},
+		{"similar bands", []int{1000, 500}, []int{950, 480}, false},
+		{"a band differs by more than 10%", []int{1000, 500}, []int{1000, 400}, true},
+		{"both uncut", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bandsDistinguishable(tt.a, tt.b); got != tt.want {
+				t.Errorf("bandsDistinguishable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
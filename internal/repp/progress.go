@@ -0,0 +1,40 @@
+package repp
+
+import (
+	"sync"
+	"time"
+)
+
+// progressInterval is the least time between the logs of a step's progress
+const progressInterval = 5 * time.Second
+
+// progress logs the progress of a long step, ex: reading thousands of sequence files. It's
+// safe to use from several goroutines. Steps that finish within progressInterval aren't logged
+type progress struct {
+	label string
+	total int
+
+	mu       sync.Mutex
+	done     int
+	logged   time.Time
+	reported bool
+}
+
+// newProgress returns the progress of a step of total items
+func newProgress(label string, total int) *progress {
+	return &progress{label: label, total: total, logged: time.Now()}
+}
+
+// add counts n more of the step's items as done
+func (p *progress) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	if p.total <= 0 {
+		return
+	}
+	if time.Since(p.logged) >= progressInterval || p.done >= p.total && p.reported {
+		p.logged, p.reported = time.Now(), true
+		rlog.Infof("%s: %d/%d (%d%%)", p.label, p.done, p.total, 100*p.done/p.total)
+	}
+}
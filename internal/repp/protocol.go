@@ -0,0 +1,210 @@
+package repp
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// protocolStep is a numbered step of a bench protocol
+type protocolStep struct {
+	title string
+	lines []string
+}
+
+// writeProtocols writes a Markdown bench protocol of each solution, next to the output
+// file: the PCRs, DpnI digestion of plasmid templates, cleanup, assembly and transformation.
+// The volumes and timings are the protocol parameters of the config
+func writeProtocols(filename string, out *Output, names namingMap, existingPrimers, existingSynthFrags *oligosDB, conf *config.Config) error {
+	for si := range out.Solutions {
+		if err := writeProtocol(solutionFilename(filename, si+1, "-protocol.md"), out, si, names, existingPrimers, existingSynthFrags, conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProtocol writes the bench protocol of a solution to a file
+func writeProtocol(filename string, out *Output, si int, names namingMap, existingPrimers, existingSynthFrags *oligosDB, conf *config.Config) (err error) {
+	protocolFile, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer protocolFile.close(&err)
+
+	reagentIDs := newReagentIDs(existingPrimers, existingSynthFrags, names)
+	return printProtocol(protocolFile, out, si, reagentIDs, conf)
+}
+
+// printProtocol writes the bench protocol of the solution at index si as Markdown
+func printProtocol(w io.Writer, out *Output, si int, reagentIDs *reagentIDs, conf *config.Config) error {
+	s := out.Solutions[si]
+	fmt.Fprintf(w, "# %s, solution %d\n\n", out.Target, si+1)
+	fmt.Fprintf(w, "%d fragments, %d PCR and %d synthetic. Assembled by %s.\n", s.Count, s.pcrFragsCount, s.synthFragsCount, assemblyMethodName(s))
+
+	var steps []protocolStep
+	if out.Backbone != nil && len(out.Backbone.Enzymes) > 0 {
+		steps = append(steps, protocolStep{
+			title: "Backbone digestion",
+			lines: []string{fmt.Sprintf("Digest the backbone with %s and gel purify the linearized band.", strings.Join(out.Backbone.Enzymes, " and "))},
+		})
+	}
+	steps = append(steps, pcrSteps(s, reagentIDs, conf)...)
+	steps = append(steps, assemblyStep(s, reagentIDs, conf), protocolStep{
+		title: "Transformation",
+		lines: []string{fmt.Sprintf(
+			"Transform %s ul of the assembly into competent E. coli. Recover for %s min at 37C and plate on selective media.",
+			formatProtocolValue(conf.ProtocolParameter("transformation-volume")),
+			formatProtocolValue(conf.ProtocolParameter("recovery-minutes")),
+		)},
+	})
+
+	for i, step := range steps {
+		fmt.Fprintf(w, "\n## %d. %s\n\n", i+1, step.title)
+		for _, line := range step.lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pcrSteps returns the PCR, DpnI and cleanup steps of a solution, none if it has no PCR fragments.
+// Plasmid templates are methylated, DpnI digests them so they aren't transformed with the assembly
+func pcrSteps(s Solution, reagentIDs *reagentIDs, conf *config.Config) (steps []protocolStep) {
+	var rows, plasmidTemplates []string
+	for i, f := range s.Fragments {
+		if f.fragType != pcr || len(f.Primers) < 2 {
+			continue
+		}
+		fwd, rev := f.getPrimers()
+		length := protocolFragLength(f)
+		extension := math.Ceil(float64(length) / 1000 * conf.ProtocolParameter("pcr-extension-seconds-per-kb"))
+		rows = append(rows, fmt.Sprintf("| %d | %s | %s | %s | %.1f | %.0f | %d |",
+			i+1, f.ID, reagentIDs.primer(fwd.Seq).id, reagentIDs.primer(rev.Seq).id, annealingTemp(f.Primers), extension, length))
+		if !f.linearTopology {
+			plasmidTemplates = append(plasmidTemplates, fmt.Sprintf("%d (%s)", i+1, f.ID))
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	amplify := protocolStep{title: "PCR"}
+	amplify.lines = append(amplify.lines,
+		fmt.Sprintf("Amplify each fragment in a %s ul reaction with %s ng of template, %s cycles.",
+			formatProtocolValue(conf.ProtocolParameter("pcr-volume")),
+			formatProtocolValue(conf.ProtocolParameter("pcr-template-ng")),
+			formatProtocolValue(conf.ProtocolParameter("pcr-cycles")),
+		),
+	)
+	if s.AnnealingTemp > 0 {
+		amplify.lines = append(amplify.lines, fmt.Sprintf("All fragments anneal at %.1fC.", s.AnnealingTemp))
+	}
+	amplify.lines = append(amplify.lines, "",
+		"| Fragment | Template | Forward primer | Reverse primer | Annealing (C) | Extension (s) | Product (bp) |",
+		"| --- | --- | --- | --- | --- | --- | --- |",
+	)
+	amplify.lines = append(amplify.lines, rows...)
+	steps = append(steps, amplify)
+
+	if len(plasmidTemplates) > 0 {
+		steps = append(steps, protocolStep{
+			title: "DpnI digestion",
+			lines: []string{fmt.Sprintf(
+				"Add %s ul of DpnI to the PCRs of plasmid templates, fragments %s, and incubate for %s min at 37C to digest the methylated templates.",
+				formatProtocolValue(conf.ProtocolParameter("dpni-volume")),
+				strings.Join(plasmidTemplates, ", "),
+				formatProtocolValue(conf.ProtocolParameter("dpni-minutes")),
+			)},
+		})
+	}
+
+	return append(steps, protocolStep{
+		title: "Cleanup",
+		lines: []string{fmt.Sprintf(
+			"Column purify each PCR, or gel purify those with more than one band, and elute in %s ul. Measure their concentrations.",
+			formatProtocolValue(conf.ProtocolParameter("cleanup-elution-volume")),
+		)},
+	})
+}
+
+// assemblyStep returns the assembly step of a solution with the amount of each fragment in it.
+// The vector is the backbone, or the largest fragment, and every other fragment is added at
+// the insert to vector molar ratio
+func assemblyStep(s Solution, reagentIDs *reagentIDs, conf *config.Config) protocolStep {
+	vector := 0
+	for i, f := range s.Fragments {
+		if f.uniqueID == "backbone" {
+			vector = i
+			break
+		}
+		if protocolFragLength(f) > protocolFragLength(s.Fragments[vector]) {
+			vector = i
+		}
+	}
+
+	step := protocolStep{title: "Assembly"}
+	step.lines = append(step.lines,
+		fmt.Sprintf("Mix the fragments in a %s ul %s reaction and incubate for %s min at %s.",
+			formatProtocolValue(conf.ProtocolParameter("assembly-volume")),
+			assemblyMethodName(s),
+			formatProtocolValue(conf.ProtocolParameter("assembly-minutes")),
+			assemblyTemp(s, conf),
+		),
+		"",
+		"| Fragment | Name | Length (bp) | Amount (ng) |",
+		"| --- | --- | --- | --- |",
+	)
+	if len(s.Fragments) == 0 {
+		return step
+	}
+
+	vectorNg := conf.ProtocolParameter("assembly-vector-ng")
+	vectorLength := protocolFragLength(s.Fragments[vector])
+	for i, f := range s.Fragments {
+		name := f.ID
+		if f.fragType == synthetic {
+			name = reagentIDs.synthFrag(f.Seq).id
+		}
+		ng := vectorNg
+		if i != vector && vectorLength > 0 {
+			ng = vectorNg * conf.ProtocolParameter("assembly-insert-ratio") * float64(protocolFragLength(f)) / float64(vectorLength)
+		}
+		step.lines = append(step.lines, fmt.Sprintf("| %d | %s | %d | %.1f |", i+1, name, protocolFragLength(f), ng))
+	}
+	return step
+}
+
+// protocolFragLength is the length of a fragment as it's made: its PCR product, if it's PCR'ed
+func protocolFragLength(f *Frag) int {
+	if f.PCRSeq != "" {
+		return len(f.PCRSeq)
+	}
+	return len(f.Seq)
+}
+
+// assemblyMethodName is the name of the assembly reaction of a solution
+func assemblyMethodName(s Solution) string {
+	if s.GoldenGate != nil {
+		return "Golden Gate assembly"
+	}
+	return "Gibson Assembly"
+}
+
+// assemblyTemp is the incubation temperature of the assembly reaction
+func assemblyTemp(s Solution, conf *config.Config) string {
+	if s.GoldenGate != nil {
+		return "cycles of 37C and 16C"
+	}
+	return formatProtocolValue(conf.GetAssemblyTemp()) + "C"
+}
+
+// formatProtocolValue formats a protocol parameter without trailing zeros, ex: 2 or 0.5
+func formatProtocolValue(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
@@ -0,0 +1,65 @@
+package repp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_printProtocol(t *testing.T) {
+	out := &Output{
+		Target:   "target",
+		Backbone: &Backbone{Enzymes: []string{"PstI", "EcoRI"}},
+		Solutions: []Solution{{
+			Count:           3,
+			pcrFragsCount:   2,
+			synthFragsCount: 1,
+			AnnealingTemp:   58.2,
+			Fragments: []*Frag{
+				{ID: "pSB1A3", uniqueID: "backbone", fragType: linear, Seq: strings.Repeat("A", 2000)},
+				{
+					ID:       "pUC19",
+					fragType: pcr,
+					PCRSeq:   strings.Repeat("C", 1500),
+					Primers:  []Primer{{Seq: "ACGTACGT", Strand: true, Tm: 60}, {Seq: "TTTTGGGG", Strand: false, Tm: 58.2}},
+				},
+				{
+					ID:             "chr1",
+					fragType:       pcr,
+					linearTopology: true,
+					PCRSeq:         strings.Repeat("G", 500),
+					Primers:        []Primer{{Seq: "GGGGTTTT", Strand: true, Tm: 61}, {Seq: "CCCCAAAA", Strand: false, Tm: 60}},
+				},
+				{fragType: synthetic, Seq: strings.Repeat("T", 400)},
+			},
+		}},
+	}
+
+	var protocol bytes.Buffer
+	conf := config.New()
+	conf.Protocol = map[string]float64{"dpni-minutes": 15}
+	if err := printProtocol(&protocol, out, 0, newReagentIDs(newOligosDB(primerIDPrefix, false), newOligosDB(synthFragIDPrefix, true), nil), conf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"## 1. Backbone digestion",
+		"Digest the backbone with PstI and EcoRI",
+		"## 2. PCR",
+		"| 2 | pUC19 | oS1 | oS2 | 58.2 | 45 | 1500 |",
+		"## 3. DpnI digestion",
+		"fragments 2 (pUC19), and incubate for 15 min", // the chr1 template isn't a plasmid
+		"## 4. Cleanup",
+		"## 5. Assembly",
+		"| 1 | pSB1A3 | 2000 | 50.0 |",
+		"| 2 | pUC19 | 1500 | 75.0 |",
+		"| 4 | syn1 | 400 | 20.0 |",
+		"## 6. Transformation",
+	} {
+		if !strings.Contains(protocol.String(), want) {
+			t.Errorf("printProtocol() is missing %q:\n%s", want, protocol.String())
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package repp
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// provContext are the JSON-LD prefixes of a provenance export
+var provContext = map[string]string{
+	"prov": "http://www.w3.org/ns/prov#",
+	"sbol": "http://sbols.org/v3#",
+	"rdfs": "http://www.w3.org/2000/01/rdf-schema#",
+	"repp": "https://github.com/Lattice-Automation/repp#",
+}
+
+// provDocument is a PROV-O graph as JSON-LD
+type provDocument struct {
+	Context map[string]string `json:"@context"`
+	Graph   []provNode        `json:"@graph"`
+}
+
+// provRef is a reference to a node of the graph
+type provRef struct {
+	ID string `json:"@id"`
+}
+
+// provNode is an entity, activity or agent of the graph
+type provNode struct {
+	ID   string   `json:"@id"`
+	Type []string `json:"@type"`
+
+	// Label is the name of the node, ex: a fragment's ID
+	Label string `json:"rdfs:label,omitempty"`
+
+	// Elements is the sequence of an sbol:Sequence
+	Elements string `json:"sbol:elements,omitempty"`
+
+	// Checksum is the SHA256 of a database file or of a sequence
+	Checksum string `json:"repp:sha256,omitempty"`
+
+	// Version of the software, or of a database entry
+	Version string `json:"repp:version,omitempty"`
+
+	// Location is the path of a database
+	Location string `json:"prov:atLocation,omitempty"`
+
+	// EndedAt is when the design ended, as an xsd:dateTime
+	EndedAt string `json:"prov:endedAtTime,omitempty"`
+
+	// AssociatedWith is the agent of an activity
+	AssociatedWith *provRef `json:"prov:wasAssociatedWith,omitempty"`
+
+	// Used are the entities an activity used
+	Used []provRef `json:"prov:used,omitempty"`
+
+	// GeneratedBy is the activity that generated an entity
+	GeneratedBy *provRef `json:"prov:wasGeneratedBy,omitempty"`
+
+	// DerivedFrom are the entities an entity was derived from, ex: a fragment's template
+	DerivedFrom []provRef `json:"prov:wasDerivedFrom,omitempty"`
+
+	// Members are the entities of a collection, ex: a solution's fragments
+	Members []provRef `json:"prov:hadMember,omitempty"`
+
+	// FragmentType is how a fragment is made, ex: pcr or synthetic
+	FragmentType string `json:"repp:fragmentType,omitempty"`
+
+	// Cost of a solution
+	Cost *float64 `json:"repp:cost,omitempty"`
+
+	// Value is the command line or the parameters of a design
+	Value interface{} `json:"prov:value,omitempty"`
+}
+
+// ExportProv writes the design history of a JSON output as PROV-O provenance in JSON-LD:
+// the design activity and the software that ran it, the target, databases, templates and
+// parameters it used, and the solutions and fragments it generated. It's written to
+// provFilename, or next to the output if it's empty.
+func ExportProv(filename, provFilename string) {
+	out, err := readOutput(filename)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if provFilename == "" {
+		provFilename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".prov.jsonld"
+	}
+
+	contents, err := json.MarshalIndent(newProvDocument(out), "", "  ")
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if err = writeFileAtomic(provFilename, contents); err != nil {
+		rlog.Fatal(err)
+	}
+	rlog.Infof("wrote %s", provFilename)
+}
+
+// newProvDocument returns the provenance graph of an output. Node IDs are URNs of the
+// output's plan hash, so exports of the same plan have the same IDs
+func newProvDocument(out *Output) *provDocument {
+	meta := out.Metadata
+	if meta == nil {
+		meta = &RunMetadata{}
+	}
+	plan := meta.PlanHash
+	if plan == "" {
+		plan = planHash(out)
+	}
+	id := func(parts ...string) string {
+		return "urn:repp:" + plan + ":" + strings.Join(parts, ":")
+	}
+
+	activity := provNode{
+		ID:             id("design"),
+		Type:           []string{"prov:Activity"},
+		Label:          "repp design of " + out.Target,
+		AssociatedWith: &provRef{id("software")},
+		EndedAt:        provTime(out.Time),
+	}
+	if len(meta.CommandLine) > 0 {
+		activity.Value = strings.Join(meta.CommandLine, " ")
+	}
+	nodes := []provNode{{
+		ID:      id("software"),
+		Type:    []string{"prov:SoftwareAgent"},
+		Label:   "repp",
+		Version: strings.TrimSpace(meta.Version + " " + meta.Commit),
+	}}
+
+	target := provNode{
+		ID:       id("target"),
+		Type:     []string{"prov:Entity", "sbol:Sequence"},
+		Label:    out.Target,
+		Elements: out.TargetSeq,
+		Checksum: seqChecksum(out.TargetSeq),
+	}
+	nodes = append(nodes, target)
+	activity.Used = append(activity.Used, provRef{target.ID})
+
+	if len(meta.Config) > 0 {
+		params := provNode{ID: id("parameters"), Type: []string{"prov:Entity"}, Label: "config", Value: meta.Config}
+		nodes = append(nodes, params)
+		activity.Used = append(activity.Used, provRef{params.ID})
+	}
+
+	for _, db := range meta.Databases {
+		dbNode := provNode{
+			ID:       id("database", db.Name),
+			Type:     []string{"prov:Entity", "prov:Collection"},
+			Label:    db.Name,
+			Location: db.Path,
+			Checksum: db.SHA256,
+		}
+		nodes = append(nodes, dbNode)
+		activity.Used = append(activity.Used, provRef{dbNode.ID})
+	}
+
+	templates := make(map[string]string) // template IDs by entry ID
+	for _, t := range meta.Templates {
+		templateNode := provNode{
+			ID:          id("template", t.Database, t.ID),
+			Type:        []string{"prov:Entity", "sbol:Sequence"},
+			Label:       t.ID,
+			Checksum:    t.Checksum,
+			DerivedFrom: []provRef{{id("database", t.Database)}},
+		}
+		if t.Version > 0 {
+			templateNode.Version = fmt.Sprint(t.Version)
+		}
+		templates[t.ID] = templateNode.ID
+		nodes = append(nodes, templateNode)
+		activity.Used = append(activity.Used, provRef{templateNode.ID})
+	}
+
+	for si, s := range out.Solutions {
+		solutionID := id("solution", fmt.Sprint(si+1))
+		cost := s.Cost
+		solution := provNode{
+			ID:          solutionID,
+			Type:        []string{"prov:Entity", "prov:Collection"},
+			Label:       fmt.Sprintf("solution %d", si+1),
+			GeneratedBy: &provRef{activity.ID},
+			DerivedFrom: []provRef{{target.ID}},
+			Cost:        &cost,
+		}
+		for fi, f := range s.Fragments {
+			seq := f.Seq
+			if f.PCRSeq != "" {
+				seq = f.PCRSeq
+			}
+			fragment := provNode{
+				ID:           id("solution", fmt.Sprint(si+1), "fragment", fmt.Sprint(fi+1)),
+				Type:         []string{"prov:Entity", "sbol:Sequence"},
+				Label:        f.ID,
+				Elements:     seq,
+				Checksum:     seqChecksum(seq),
+				FragmentType: f.Type,
+				GeneratedBy:  &provRef{activity.ID},
+			}
+			if template, ok := templates[f.ID]; ok {
+				fragment.DerivedFrom = []provRef{{template}}
+			}
+			solution.Members = append(solution.Members, provRef{fragment.ID})
+			nodes = append(nodes, fragment)
+		}
+		nodes = append(nodes, solution)
+	}
+
+	return &provDocument{Context: provContext, Graph: append([]provNode{activity}, nodes...)}
+}
+
+// provTime converts the time of an output to an xsd:dateTime, empty if it can't be parsed
+func provTime(outTime string) string {
+	t, err := time.ParseInLocation("2006/01/02 15:04:05", outTime, time.Local)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
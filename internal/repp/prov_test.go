@@ -0,0 +1,56 @@
+package repp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_newProvDocument(t *testing.T) {
+	out := &Output{
+		Time:      "2023/09/21 10:30:00",
+		Target:    "target",
+		TargetSeq: "ACGTACGTAC",
+		Solutions: []Solution{{
+			Cost: 12.5,
+			Fragments: []*Frag{
+				{ID: "pSB1A3", Type: "pcr", PCRSeq: "ACGTAC"},
+				{Type: "synthetic", Seq: "GTAC"},
+			},
+		}},
+		Metadata: &RunMetadata{
+			Version:     "1.2.0",
+			CommandLine: []string{"repp", "make", "sequence"},
+			PlanHash:    "abc",
+			Databases:   []DatabaseChecksum{{Name: "igem", Path: "/dbs/igem", SHA256: "f00"}},
+			Templates:   []TemplateVersion{{Database: "igem", ID: "pSB1A3", Version: 2}},
+		},
+	}
+
+	doc := newProvDocument(out)
+	nodes := make(map[string]provNode)
+	for _, n := range doc.Graph {
+		nodes[n.ID] = n
+	}
+
+	design, ok := nodes["urn:repp:abc:design"]
+	if !ok || design.Value != "repp make sequence" || len(design.Used) != 3 {
+		t.Fatalf("design activity = %+v, want one that used the target, database and template", design)
+	}
+	if fragment := nodes["urn:repp:abc:solution:1:fragment:1"]; len(fragment.DerivedFrom) != 1 || fragment.DerivedFrom[0].ID != "urn:repp:abc:template:igem:pSB1A3" {
+		t.Errorf("fragment = %+v, want it derived from its template", fragment)
+	}
+	if solution := nodes["urn:repp:abc:solution:1"]; len(solution.Members) != 2 || solution.GeneratedBy == nil {
+		t.Errorf("solution = %+v, want 2 fragments generated by the design", solution)
+	}
+
+	contents, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"@context"`, `"prov:wasGeneratedBy"`, `"sbol:elements":"ACGTAC"`, `"repp:version":"2"`} {
+		if !strings.Contains(string(contents), want) {
+			t.Errorf("newProvDocument() JSON is missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package repp
+
+import (
+	"sort"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+const (
+	// primersVendor is the vendor name of primer orders in the purchase terms of the config
+	primersVendor = "primers"
+
+	// synthesisVendor is the vendor name of synthesis orders in the purchase terms of the config
+	synthesisVendor = "synthesis"
+)
+
+// vendorPurchases is the amount a solution purchases from each vendor. The vendors
+// are the sequence databases fragments are procured from, primers and synthesis.
+type vendorPurchases map[string]float64
+
+// add accumulates the purchases in a fragment's cost breakdown and returns the breakdown
+func (p vendorPurchases) add(f *Frag, b CostBreakdown) CostBreakdown {
+	if b.Procurement > 0 {
+		p[f.db.Name] += b.Procurement
+	}
+	if b.Primers > 0 {
+		p[primersVendor] += b.Primers
+	}
+	if b.Synthesis > 0 {
+		p[synthesisVendor] += b.Synthesis
+	}
+	return b
+}
+
+// applyPurchaseTerms adds the vendors' discounts and shipping, and the tax on the purchases,
+// to the solution's cost and breakdown. The solution's and its fragments' costs are
+// then converted to the currency of the config.
+func applyPurchaseTerms(s *Solution, purchases vendorPurchases, conf *config.Config) {
+	vendors := make([]string, 0, len(purchases))
+	for vendor := range purchases {
+		vendors = append(vendors, vendor)
+	}
+	sort.Strings(vendors) // for a stable sum
+
+	var terms CostBreakdown
+	taxable := 0.0
+	for _, vendor := range vendors {
+		amount := purchases[vendor]
+		discounted := amount * conf.VendorDiscount(vendor)
+		shipping := conf.VendorShippingCost(vendor)
+
+		terms.Discount += discounted - amount
+		terms.Shipping += shipping
+		taxable += discounted + shipping
+	}
+	terms.Tax = taxable * conf.PurchaseTaxRate
+
+	delta := terms.Discount + terms.Shipping + terms.Tax
+	s.Cost = roundCost(conf.ToCurrency(s.Cost + delta))
+	s.AdjustedCost = roundCost(conf.ToCurrency(s.AdjustedCost + delta))
+	s.CostBreakdown.add(terms)
+	s.CostBreakdown = s.CostBreakdown.scaled(conf.ToCurrency(1)).rounded()
+
+	for _, f := range s.Fragments {
+		f.Cost = roundCost(conf.ToCurrency(f.Cost))
+		f.AdjustedCost = roundCost(conf.ToCurrency(f.AdjustedCost))
+	}
+}
@@ -0,0 +1,94 @@
+package repp
+
+import (
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_applyPurchaseTerms(t *testing.T) {
+	purchases := vendorPurchases{"igem": 50, primersVendor: 20, synthesisVendor: 30}
+	breakdown := CostBreakdown{Procurement: 50, Primers: 20, Synthesis: 30}
+
+	tests := []struct {
+		name             string
+		conf             *config.Config
+		wantCost         float64
+		wantAdjustedCost float64
+		wantBreakdown    CostBreakdown
+		wantFragCost     float64
+	}{
+		{
+			"no purchase terms",
+			&config.Config{CurrencyExchangeRate: 1},
+			100,
+			110,
+			breakdown,
+			10,
+		},
+		{
+			"unset exchange rate is ignored",
+			&config.Config{},
+			100,
+			110,
+			breakdown,
+			10,
+		},
+		{
+			"discount, shipping and tax",
+			&config.Config{
+				CurrencyExchangeRate: 1,
+				VendorDiscounts:      map[string]float64{"primers": 0.5},
+				VendorShippingCosts:  map[string]float64{"synthesis": 15},
+				PurchaseTaxRate:      0.1,
+			},
+			115.5,
+			125.5,
+			CostBreakdown{Procurement: 50, Primers: 20, Synthesis: 30, Discount: -10, Shipping: 15, Tax: 10.5},
+			10,
+		},
+		{
+			"shipping from a sequence database",
+			&config.Config{
+				CurrencyExchangeRate: 1,
+				VendorShippingCosts:  map[string]float64{"igem": 5},
+			},
+			105,
+			115,
+			CostBreakdown{Procurement: 50, Primers: 20, Synthesis: 30, Shipping: 5},
+			10,
+		},
+		{
+			"converted to another currency",
+			&config.Config{
+				CurrencyExchangeRate: 2,
+				VendorShippingCosts:  map[string]float64{"synthesis": 15},
+			},
+			230,
+			250,
+			CostBreakdown{Procurement: 100, Primers: 40, Synthesis: 60, Shipping: 30},
+			20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frag := &Frag{Cost: 10, AdjustedCost: 10}
+			s := Solution{Cost: 100, AdjustedCost: 110, CostBreakdown: breakdown, Fragments: []*Frag{frag}}
+
+			applyPurchaseTerms(&s, purchases, tt.conf)
+
+			if s.Cost != tt.wantCost {
+				t.Errorf("applyPurchaseTerms() cost = %v, want %v", s.Cost, tt.wantCost)
+			}
+			if s.AdjustedCost != tt.wantAdjustedCost {
+				t.Errorf("applyPurchaseTerms() adjusted cost = %v, want %v", s.AdjustedCost, tt.wantAdjustedCost)
+			}
+			if s.CostBreakdown != tt.wantBreakdown {
+				t.Errorf("applyPurchaseTerms() cost breakdown = %+v, want %+v", s.CostBreakdown, tt.wantBreakdown)
+			}
+			if frag.Cost != tt.wantFragCost {
+				t.Errorf("applyPurchaseTerms() fragment cost = %v, want %v", frag.Cost, tt.wantFragCost)
+			}
+		})
+	}
+}
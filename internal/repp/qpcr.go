@@ -0,0 +1,212 @@
+package repp
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// qPCR assays of the qpcr-assays setting
+const (
+	// noQPCRAssays doesn't design qPCR assays
+	noQPCRAssays = "none"
+
+	// junctionQPCRAssays designs an assay across each junction of a solution
+	junctionQPCRAssays = "junction"
+
+	// constructQPCRAssays designs one assay in the assembled construct
+	constructQPCRAssays = "construct"
+)
+
+// qpcrMinAmplicon and qpcrMaxAmplicon bound the length (bp) of a qPCR assay's amplicon
+const (
+	qpcrMinAmplicon = 70
+	qpcrMaxAmplicon = 150
+)
+
+// QPCRAssay is a qPCR primer pair and internal hydrolysis probe that verify an assembly:
+// across a junction, where it only amplifies if the fragments were joined, or in the construct
+type QPCRAssay struct {
+	// Name of the assay, ex: "junction 2" or "construct"
+	Name string `json:"name"`
+
+	// Fragment is the fragment (1-based) the junction is at the end of, 0 for a construct's assay
+	Fragment int `json:"fragment,omitempty"`
+
+	// Forward primer of the assay
+	Forward Primer `json:"forward"`
+
+	// Reverse primer of the assay
+	Reverse Primer `json:"reverse"`
+
+	// Probe is the internal oligo, ordered with a 5' reporter and a 3' quencher
+	Probe Primer `json:"probe"`
+
+	// Amplicon is the length (bp) of the assay's product
+	Amplicon int `json:"amplicon"`
+}
+
+// qpcrTemplate is a sequence to design a qPCR assay in and the region, if any, its amplicon must span
+type qpcrTemplate struct {
+	name     string
+	fragment int
+	seq      string
+
+	// targetStart and targetLength are the region the amplicon must span, none if targetLength is 0
+	targetStart, targetLength int
+}
+
+// addQPCRAssays designs the qPCR assays of the qpcr-assays setting for each solution. Assays
+// that primer3 can't design are reported in the solution's warnings. The same junction in
+// several solutions is only designed once
+func addQPCRAssays(solutions []Solution, targetSeq string, conf *config.Config) {
+	mode := conf.GetQPCRAssays()
+	switch mode {
+	case noQPCRAssays:
+		return
+	case junctionQPCRAssays, constructQPCRAssays:
+	default:
+		rlog.Warnf("invalid qpcr-assays %q; valid values [none, junction, construct]", mode)
+		return
+	}
+
+	type designed struct {
+		assay QPCRAssay
+		err   error
+	}
+	cache := make(map[string]designed)
+	for i := range solutions {
+		templates := []qpcrTemplate{{name: "construct", seq: targetSeq}}
+		if mode == junctionQPCRAssays {
+			templates = junctionQPCRTemplates(solutions[i].Fragments, conf.FragmentsMinHomology, conf.FragmentsMaxHomology+1)
+		}
+		for _, t := range templates {
+			key := fmt.Sprintf("%s:%d:%d", t.seq, t.targetStart, t.targetLength)
+			d, ok := cache[key]
+			if !ok {
+				d.assay, d.err = designQPCRAssay(t, conf)
+				cache[key] = d
+			}
+			if d.err != nil {
+				solutions[i].Warnings = append(solutions[i].Warnings, fmt.Sprintf("failed to design a qPCR assay of the %s: %v", t.name, d.err))
+				continue
+			}
+			assay := d.assay
+			assay.Name, assay.Fragment = t.name, t.fragment
+			solutions[i].QPCR = append(solutions[i].QPCR, assay)
+		}
+	}
+}
+
+// junctionQPCRTemplates returns the sequence around each junction of the fragments, with the
+// junction's homology, and a base of each fragment beyond it, as the region the amplicon must
+// span. Junctions without homology, like the ends of a linear product, are skipped
+func junctionQPCRTemplates(frags []*Frag, minHomology, maxHomology int) (templates []qpcrTemplate) {
+	if len(frags) < 2 {
+		return nil
+	}
+	for i, f := range frags {
+		next := frags[(i+1)%len(frags)]
+		homology := f.junction(next, minHomology, maxHomology)
+		if homology == "" {
+			continue
+		}
+
+		left, right := f.getFragSeq(), next.getFragSeq()[len(homology):]
+		if len(left) > qpcrMaxAmplicon {
+			left = left[len(left)-qpcrMaxAmplicon:]
+		}
+		if len(right) > qpcrMaxAmplicon-len(homology) {
+			right = right[:qpcrMaxAmplicon-len(homology)]
+		}
+		seq := left + right
+		start, end := len(left)-len(homology)-1, len(left)+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(seq) {
+			end = len(seq)
+		}
+		templates = append(templates, qpcrTemplate{
+			name:         fmt.Sprintf("junction %d", i+1),
+			fragment:     i + 1,
+			seq:          seq,
+			targetStart:  start,
+			targetLength: end - start,
+		})
+	}
+	return templates
+}
+
+// designQPCRAssay picks a qPCR primer pair and internal probe in the template with primer3.
+// The probe's Tm is ~8C above the primers' so it binds before they extend, and it doesn't
+// start with a G, which would quench the 5' reporter
+func designQPCRAssay(t qpcrTemplate, conf *config.Config) (assay QPCRAssay, err error) {
+	if len(t.seq) < qpcrMinAmplicon {
+		return assay, fmt.Errorf("%dbp is shorter than the %dbp amplicon", len(t.seq), qpcrMinAmplicon)
+	}
+
+	p := newPrimer3(t.seq, conf)
+	defer p.close()
+	if err = p.createFiles(); err != nil {
+		return
+	}
+	if err = p.writeSettings(p.qpcrSettings(t)); err != nil {
+		return
+	}
+	if err = p.run(); err != nil {
+		return
+	}
+	results, _, err := p.results()
+	if err != nil {
+		return
+	}
+	if results["PRIMER_PAIR_NUM_RETURNED"] == "0" {
+		return assay, fmt.Errorf("no primers and probe meet the constraints: %s", results["PRIMER_PAIR_EXPLAIN"])
+	}
+
+	assay.Forward = primer3Oligo(results, "LEFT", 0)
+	assay.Reverse = primer3Oligo(results, "RIGHT", 0)
+	assay.Probe = primer3Oligo(results, "INTERNAL", 0)
+	assay.Amplicon, _ = strconv.Atoi(results["PRIMER_PAIR_0_PRODUCT_SIZE"])
+	return assay, nil
+}
+
+// qpcrSettings returns the primer3 settings of a qPCR assay in the template
+func (p *primer3) qpcrSettings(t qpcrTemplate) map[string]string {
+	settings := map[string]string{
+		"SEQUENCE_ID":                           t.name,
+		"SEQUENCE_TEMPLATE":                     p.seq,
+		"PRIMER_THERMODYNAMIC_PARAMETERS_PATH":  p.primer3ConfDir,
+		"PRIMER_TASK":                           "generic",
+		"PRIMER_PICK_LEFT_PRIMER":               "1",
+		"PRIMER_PICK_INTERNAL_OLIGO":            "1",
+		"PRIMER_PICK_RIGHT_PRIMER":              "1",
+		"PRIMER_NUM_RETURN":                     "1",
+		"PRIMER_EXPLAIN_FLAG":                   "1",
+		"PRIMER_PRODUCT_SIZE_RANGE":             fmt.Sprintf("%d-%d", qpcrMinAmplicon, qpcrMaxAmplicon),
+		"PRIMER_MIN_SIZE":                       "18",
+		"PRIMER_OPT_SIZE":                       "20",
+		"PRIMER_MAX_SIZE":                       "24",
+		"PRIMER_MIN_TM":                         "58.0",
+		"PRIMER_OPT_TM":                         "60.0",
+		"PRIMER_MAX_TM":                         "62.0",
+		"PRIMER_INTERNAL_MIN_SIZE":              "18",
+		"PRIMER_INTERNAL_OPT_SIZE":              "24",
+		"PRIMER_INTERNAL_MAX_SIZE":              "30",
+		"PRIMER_INTERNAL_MIN_TM":                "66.0",
+		"PRIMER_INTERNAL_OPT_TM":                "68.0",
+		"PRIMER_INTERNAL_MAX_TM":                "70.0",
+		"PRIMER_INTERNAL_MUST_MATCH_FIVE_PRIME": "hnnnn",
+	}
+	if t.targetLength > 0 {
+		settings["SEQUENCE_TARGET"] = fmt.Sprintf("%d,%d", t.targetStart, t.targetLength)
+	}
+	return settings
+}
+
+// qpcrNotes are the notes of an assay's oligo in the reagents
+func qpcrNotes(assay QPCRAssay, oligo string) string {
+	return fmt.Sprintf("qPCR %s %s, %dbp amplicon", assay.Name, oligo, assay.Amplicon)
+}
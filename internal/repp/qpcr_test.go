@@ -0,0 +1,79 @@
+package repp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_junctionQPCRTemplates(t *testing.T) {
+	homology := "GATTACAGATTACAGATTAC"
+	a := &Frag{fragType: synthetic, Seq: strings.Repeat("A", 200) + homology}
+	b := &Frag{fragType: synthetic, Seq: homology + strings.Repeat("C", 50)}
+
+	templates := junctionQPCRTemplates([]*Frag{a, b}, 15, 40)
+	if len(templates) != 1 {
+		t.Fatalf("junctionQPCRTemplates() = %+v, want the junction of the linear product", templates)
+	}
+	got := templates[0]
+	if got.name != "junction 1" || got.fragment != 1 {
+		t.Errorf("junctionQPCRTemplates() name = %q, fragment = %d", got.name, got.fragment)
+	}
+	if wantSeq := strings.Repeat("A", 130) + homology + strings.Repeat("C", 50); got.seq != wantSeq {
+		t.Errorf("junctionQPCRTemplates() seq = %s, want %s", got.seq, wantSeq)
+	}
+	// the amplicon spans the homology and a base of each fragment
+	if target := got.seq[got.targetStart : got.targetStart+got.targetLength]; target != "A"+homology+"C" {
+		t.Errorf("junctionQPCRTemplates() target = %s", target)
+	}
+
+	if templates := junctionQPCRTemplates([]*Frag{a}, 15, 40); len(templates) != 0 {
+		t.Errorf("junctionQPCRTemplates() = %+v for a single fragment, want none", templates)
+	}
+}
+
+func Test_primer3_qpcrSettings(t *testing.T) {
+	p := newPrimer3("acgt", &config.Config{})
+
+	settings := p.qpcrSettings(qpcrTemplate{name: "junction 1", targetStart: 10, targetLength: 22})
+	if settings["SEQUENCE_TEMPLATE"] != "ACGT" || settings["PRIMER_PICK_INTERNAL_OLIGO"] != "1" || settings["SEQUENCE_TARGET"] != "10,22" {
+		t.Errorf("qpcrSettings() = %v", settings)
+	}
+
+	settings = p.qpcrSettings(qpcrTemplate{name: "construct"})
+	if _, ok := settings["SEQUENCE_TARGET"]; ok {
+		t.Errorf("qpcrSettings() of a construct has a target: %v", settings)
+	}
+}
+
+func Test_newSolutionOrder_qpcr(t *testing.T) {
+	s := Solution{
+		Fragments: []*Frag{{
+			fragType: pcr,
+			Primers: []Primer{
+				{Seq: "ACGTACGT", Strand: true},
+				{Seq: "TTTTGGGG", Strand: false},
+			},
+		}},
+		QPCR: []QPCRAssay{{
+			Name:     "junction 1",
+			Forward:  Primer{Seq: "GGGGCCCC"},
+			Reverse:  Primer{Seq: "ACGTACGT"}, // an assembly primer
+			Probe:    Primer{Seq: "CATCATCATCAT"},
+			Amplicon: 100,
+		}},
+	}
+
+	order := newSolutionOrder(s, nil, newOligosDB(primerIDPrefix, false), newOligosDB(synthFragIDPrefix, true))
+	var primers []string
+	for _, o := range order.primers {
+		primers = append(primers, o.id+"="+o.seq)
+	}
+	if got, want := strings.Join(primers, " "), "oS1=ACGTACGT oS2=TTTTGGGG oS3=GGGGCCCC"; got != want {
+		t.Errorf("newSolutionOrder() primers = %s, want %s", got, want)
+	}
+	if len(order.probes) != 1 || order.probes[0].id != "oS4" || order.probes[0].seq != "CATCATCATCAT" {
+		t.Errorf("newSolutionOrder() probes = %+v, want oS4", order.probes)
+	}
+}
@@ -0,0 +1,298 @@
+package repp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// redactionPrefixes are the prefixes of the tokens of each kind of redacted identifier.
+// They have no separators, so they're one component of the fragment IDs in the strategy
+var redactionPrefixes = map[string]string{
+	"target":   "target",
+	"template": "tmpl",
+	"database": "db",
+	"reagent":  "rgt",
+	"plate":    "plate",
+	"location": "loc",
+}
+
+// redactions are the anonymous tokens of the identifiers in redacted outputs. They're kept
+// locally, in the REPP data directory, so an identifier gets the same token in every
+// redacted output and the tokens of an output can be looked up later
+type redactions struct {
+	path string
+
+	// tokens of the identifiers, by kind and then identifier
+	tokens map[string]map[string]string
+
+	// count of the tokens of each kind, to number new ones
+	count map[string]int
+
+	// added is whether a new token was added since the redactions were read
+	added bool
+}
+
+// readRedactions reads the tokens of previously redacted identifiers, none if the file doesn't exist
+func readRedactions(path string) (*redactions, error) {
+	r := &redactions{path: path, tokens: make(map[string]map[string]string), count: make(map[string]int)}
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(contents)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the redactions in %s: %v", path, err)
+	}
+	for i, row := range rows {
+		if i == 0 || len(row) != 3 {
+			continue // the header
+		}
+		token, kind, original := row[0], row[1], row[2]
+		if r.tokens[kind] == nil {
+			r.tokens[kind] = make(map[string]string)
+		}
+		r.tokens[kind][original] = token
+		r.count[kind]++
+	}
+	return r, nil
+}
+
+// token returns the token of an identifier, a new one if it wasn't redacted before.
+// Empty identifiers stay empty
+func (r *redactions) token(kind, original string) string {
+	if original == "" {
+		return ""
+	}
+	if token, ok := r.tokens[kind][original]; ok {
+		return token
+	}
+	if r.tokens[kind] == nil {
+		r.tokens[kind] = make(map[string]string)
+	}
+	r.count[kind]++
+	token := fmt.Sprintf("%s%d", redactionPrefixes[kind], r.count[kind])
+	r.tokens[kind][original] = token
+	r.added = true
+	return token
+}
+
+// save writes the tokens as a "Token,Kind,Original" CSV, if any were added
+func (r *redactions) save() error {
+	if !r.added {
+		return nil
+	}
+	var rows [][]string
+	for kind, tokens := range r.tokens {
+		for original, token := range tokens {
+			rows = append(rows, []string{token, kind, original})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i][1] != rows[j][1] {
+			return rows[i][1] < rows[j][1]
+		}
+		return compOligoIDs(oligo{id: rows[i][0]}, oligo{id: rows[j][0]}) < 0
+	})
+
+	var contents bytes.Buffer
+	w := csv.NewWriter(&contents)
+	if err := w.Write([]string{"Token", "Kind", "Original"}); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, contents.Bytes())
+}
+
+// redactOutput returns a copy of an output with its database entry IDs and lab-specific
+// identifiers replaced by tokens: the target's name, the templates and databases, the IDs,
+// plates and locations of the reagents on hand, and the names that appear in its warnings
+// and notes. The command line, hostname and paths of the run metadata are dropped
+func redactOutput(out *Output, r *redactions) (*Output, error) {
+	contents, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	redacted := &Output{}
+	if err = json.Unmarshal(contents, redacted); err != nil {
+		return nil, err
+	}
+
+	redacted.Target = r.token("target", out.Target)
+	if redacted.Backbone != nil {
+		redacted.Backbone.URL = ""
+	}
+	for si, s := range redacted.Solutions {
+		for i, f := range s.Fragments {
+			f.fragType = out.Solutions[si].Fragments[i].fragType // unexported, so not in the copy
+			if f.fragType != synthetic {
+				f.ID = r.token("template", f.ID)
+			}
+		}
+		for i := range s.PickList {
+			e := &s.PickList[i]
+			e.ID, e.Plate, e.Location = r.token("reagent", e.ID), r.token("plate", e.Plate), r.token("location", e.Location)
+		}
+		for i := range s.Features {
+			s.Features[i].Template = r.token("template", s.Features[i].Template)
+		}
+	}
+	if meta := redacted.Metadata; meta != nil {
+		meta.CommandLine, meta.Hostname = nil, ""
+		for i := range meta.Databases {
+			meta.Databases[i].Name, meta.Databases[i].Path = r.token("database", meta.Databases[i].Name), ""
+		}
+		for i := range meta.Templates {
+			meta.Templates[i].Database = r.token("database", meta.Templates[i].Database)
+			meta.Templates[i].ID = r.token("template", meta.Templates[i].ID)
+		}
+		for key, value := range meta.Config {
+			if s, ok := value.(string); key == "project" || key == "naming-map" || ok && strings.ContainsAny(s, `/\`) {
+				delete(meta.Config, key)
+			}
+		}
+	}
+
+	// and the identifiers mentioned in free text
+	replace := redactText(string(contents), r)
+	for si := range redacted.Solutions {
+		s := &redacted.Solutions[si]
+		for i := range s.Warnings {
+			s.Warnings[i] = replace(s.Warnings[i])
+		}
+		for i := range s.Explanation {
+			s.Explanation[i] = replace(s.Explanation[i])
+		}
+		for _, f := range s.Fragments {
+			for i := range f.Warnings {
+				f.Warnings[i] = replace(f.Warnings[i])
+			}
+			for i := range f.Primers {
+				f.Primers[i].Notes = replace(f.Primers[i].Notes)
+			}
+		}
+	}
+	return redacted, nil
+}
+
+// redactionKinds are the kinds of redacted identifiers, in the order their tokens are
+// preferred when the same identifier is of several kinds
+var redactionKinds = []string{"target", "template", "database", "reagent", "plate", "location"}
+
+// redactText returns a function that replaces the redacted identifiers in text, of those
+// that are in contents. Identifiers are only replaced where they're whole words, not part
+// of a longer name, and the longest are replaced first
+func redactText(contents string, r *redactions) func(string) string {
+	type redaction struct{ original, token string }
+	var redacted []redaction
+	seen := make(map[string]bool)
+	for _, kind := range redactionKinds {
+		originals := make([]string, 0, len(r.tokens[kind]))
+		for original := range r.tokens[kind] {
+			originals = append(originals, original)
+		}
+		sort.Strings(originals)
+		for _, original := range originals {
+			if !seen[original] && strings.Contains(contents, original) {
+				redacted = append(redacted, redaction{original, r.tokens[kind][original]})
+				seen[original] = true
+			}
+		}
+	}
+	sort.SliceStable(redacted, func(i, j int) bool { return len(redacted[i].original) > len(redacted[j].original) })
+
+	isWordChar := func(c byte) bool {
+		return c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= utf8.RuneSelf
+	}
+	return func(text string) string {
+		var b strings.Builder
+		for i := 0; i < len(text); {
+			replaced := false
+			if i == 0 || !isWordChar(text[i-1]) {
+				for _, red := range redacted {
+					end := i + len(red.original)
+					if strings.HasPrefix(text[i:], red.original) && (end == len(text) || !isWordChar(text[end])) {
+						b.WriteString(red.token)
+						i, replaced = end, true
+						break
+					}
+				}
+			}
+			if !replaced {
+				b.WriteByte(text[i])
+				i++
+			}
+		}
+		return b.String()
+	}
+}
+
+// writeRedacted writes a redacted copy of an output next to it, in the same format, for
+// sharing with vendors and collaborators. Its reagents are numbered without the manifests,
+// and its fragments named without the naming map, so the lab's inventory names aren't in it.
+// The tokens are kept in the REPP data directory
+func writeRedacted(filename, format string, out *Output, conf *config.Config) error {
+	r, err := readRedactions(config.RedactionMap)
+	if err != nil {
+		return err
+	}
+	redacted, err := redactOutput(out, r)
+	if err != nil {
+		return fmt.Errorf("failed to redact the output: %v", err)
+	}
+	redactedFilename := resultFilename(filename, "redacted")
+
+	switch format {
+	case "CSV", "XLSX":
+		for i := range redacted.Solutions {
+			redacted.Solutions[i] = restoreSolution(redacted.Solutions[i], redacted.TargetSeq)
+		}
+		primersDB, synthFragsDB := newOligosDB(primerIDPrefix, false), newOligosDB(synthFragIDPrefix, true)
+		fragIDs, err := newFragIDNamer(conf.FragmentIDTemplate, "", redacted.Target, fragmentBase(redactedFilename), synthFragsDB)
+		if err != nil {
+			return err
+		}
+		if format == "CSV" {
+			err = writeCSV(redactedFilename, fragIDs, nil, primersDB, synthFragsDB, conf.IncludeFragLocationInStrategyOutput, conf.Strict, redacted)
+		} else {
+			err = writeXLSXOutput(redactedFilename, fragIDs, nil, primersDB, synthFragsDB, conf.IncludeFragLocationInStrategyOutput, conf.Strict, redacted)
+		}
+		if err == nil && redacted.Metadata != nil {
+			err = writeMetadata(metadataFilename(redactedFilename), redacted.Metadata)
+		}
+		if err != nil {
+			return err
+		}
+	case "TSV-KV":
+		if err = writeTSVKV(redactedFilename, redacted); err != nil {
+			return err
+		}
+	case "JSON-V1":
+		if err = writeLegacyJSON(redactedFilename, redacted); err != nil {
+			return err
+		}
+	default:
+		if err = writeJSON(redactedFilename, redacted); err != nil {
+			return err
+		}
+	}
+
+	if err = r.save(); err != nil {
+		return fmt.Errorf("failed to save the redaction tokens to %s: %v", r.path, err)
+	}
+	rlog.Infof("wrote the redacted output %s, its tokens are in %s", redactedFilename, r.path)
+	return nil
+}
@@ -0,0 +1,107 @@
+package repp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_redactOutput(t *testing.T) {
+	out := &Output{
+		Target: "lab-plasmid-7",
+		Solutions: []Solution{{
+			Fragments: []*Frag{
+				{ID: "pLAB123", fragType: pcr, Seq: "ACGT"},
+				{ID: "lab-plasmid-7-synth-1", fragType: synthetic, Seq: "TTTT"},
+			},
+			PickList: []PickListEntry{{ID: "JD-0042", Seq: "ACGTACGT", Plate: "P3", Well: "A1", Location: "freezer 2"}},
+			Warnings: []string{"pLAB123 has a mismatch in the primer"},
+		}},
+		Metadata: &RunMetadata{
+			CommandLine: []string{"repp", "make", "sequence"},
+			Hostname:    "bench-1",
+			Databases:   []DatabaseChecksum{{Name: "lab-db", Path: "/home/lab/db.fa", SHA256: "abc"}},
+			Templates:   []TemplateVersion{{ID: "pLAB123", Database: "lab-db"}},
+			Config:      map[string]interface{}{"project": "secret", "fragments-min-homology": 20},
+		},
+	}
+
+	r, err := readRedactions(filepath.Join(t.TempDir(), "redactions.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redacted, err := redactOutput(out, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if redacted.Target != "target1" {
+		t.Errorf("Target = %s, want target1", redacted.Target)
+	}
+	s := redacted.Solutions[0]
+	if s.Fragments[0].ID != "tmpl1" || s.Fragments[0].fragType != pcr {
+		t.Errorf("PCR fragment = %s %s, want tmpl1 PCR", s.Fragments[0].ID, s.Fragments[0].fragType)
+	}
+	if s.Fragments[1].fragType != synthetic {
+		t.Errorf("synthetic fragment type = %s", s.Fragments[1].fragType)
+	}
+	if e := s.PickList[0]; e.ID != "rgt1" || e.Plate != "plate1" || e.Well != "A1" || e.Location != "loc1" {
+		t.Errorf("PickList = %+v", e)
+	}
+	if s.Warnings[0] != "tmpl1 has a mismatch in the primer" {
+		t.Errorf("Warnings = %v", s.Warnings)
+	}
+
+	meta := redacted.Metadata
+	if meta.CommandLine != nil || meta.Hostname != "" || meta.Databases[0].Name != "db1" || meta.Databases[0].Path != "" {
+		t.Errorf("Metadata = %+v", meta)
+	}
+	if meta.Templates[0].ID != "tmpl1" || meta.Templates[0].Database != "db1" {
+		t.Errorf("Templates = %+v", meta.Templates)
+	}
+	if _, ok := meta.Config["project"]; ok || meta.Config["fragments-min-homology"] == nil {
+		t.Errorf("Config = %v", meta.Config)
+	}
+
+	// the original isn't changed
+	if out.Target != "lab-plasmid-7" || out.Solutions[0].Fragments[0].ID != "pLAB123" || out.Metadata.Hostname != "bench-1" {
+		t.Errorf("redactOutput() changed the output: %+v", out)
+	}
+
+	// tokens are the same in later runs
+	if err = r.save(); err != nil {
+		t.Fatal(err)
+	}
+	r, err = readRedactions(r.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token := r.token("template", "pLAB123"); token != "tmpl1" {
+		t.Errorf("token() = %s after reading the redactions, want tmpl1", token)
+	}
+	if token := r.token("template", "pLAB456"); token != "tmpl2" {
+		t.Errorf("token() = %s of a new template, want tmpl2", token)
+	}
+	for _, token := range redactionPrefixes {
+		if strings.ContainsAny(token, " ,_-.;:") {
+			t.Errorf("token prefix %q has a separator", token)
+		}
+	}
+}
+
+func Test_redactText(t *testing.T) {
+	r, err := readRedactions(filepath.Join(t.TempDir(), "redactions.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.token("template", "pLAB12")
+	r.token("template", "pLAB12-v2")
+	r.token("database", "lab")
+	r.token("reagent", "lab")
+
+	text := "pLAB12-v2 replaces pLAB12, not pLAB123, in lab (lab-db)"
+	got := redactText(text, r)(text)
+	if want := "tmpl2 replaces tmpl1, not pLAB123, in db1 (lab-db)"; got != want {
+		t.Errorf("redactText() = %s, want %s", got, want)
+	}
+}
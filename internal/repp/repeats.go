@@ -0,0 +1,110 @@
+package repp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// repeatRegion is a stretch of the target in which every junction-length window
+// also occurs elsewhere in the target (on either strand)
+type repeatRegion struct {
+	// start of the region on the target (0-indexed)
+	start int
+
+	// end of the region on the target (exclusive). May be past the
+	// end of the target if the region crosses the zero index
+	end int
+}
+
+func (r repeatRegion) length() int {
+	return r.end - r.start
+}
+
+// findRepeatRegions self-aligns the circular sequence by its k-mers and
+// returns the regions covered by k-mers that occur more than once.
+func findRepeatRegions(seq string, k int) (regions []repeatRegion) {
+	seq = strings.ToUpper(seq)
+	if k < 1 || len(seq) <= k {
+		return nil
+	}
+
+	wrapped := seq + seq[:k-1]
+	counts := kmerCounts(seq, k)
+	repeated := func(i int) bool {
+		return repeatedKmer(counts, wrapped[i:i+k])
+	}
+
+	for i := 0; i < len(seq); i++ {
+		if !repeated(i) {
+			continue
+		}
+		if n := len(regions); n > 0 && regions[n-1].end >= i {
+			regions[n-1].end = i + k
+		} else {
+			regions = append(regions, repeatRegion{start: i, end: i + k})
+		}
+	}
+
+	// merge the last region with the first if it crosses the zero index
+	if n := len(regions); n > 1 && regions[0].start == 0 && regions[n-1].end >= len(seq) {
+		regions[n-1].end = len(seq) + regions[0].end
+		regions = regions[1:]
+	}
+
+	return regions
+}
+
+// kmerCounts counts each k-mer of the circular sequence, including those
+// crossing the zero index, on both strands
+func kmerCounts(seq string, k int) map[string]int {
+	seq = strings.ToUpper(seq)
+	counts := make(map[string]int)
+	if k < 1 || len(seq) < k {
+		return counts
+	}
+
+	wrapped := seq + seq[:k-1]
+	for i := 0; i < len(seq); i++ {
+		counts[wrapped[i:i+k]]++
+	}
+	revComp := reverseComplement(wrapped)
+	for i := 0; i < len(seq); i++ {
+		counts[revComp[i:i+k]]++
+	}
+	return counts
+}
+
+// repeatedKmer returns whether the k-mer occurs more than once in the counts of kmerCounts
+func repeatedKmer(counts map[string]int, kmer string) bool {
+	if kmer == reverseComplement(kmer) {
+		// palindromes are counted once on each strand
+		return counts[kmer] > 2
+	}
+	return counts[kmer] > 1
+}
+
+// repeatWarnings returns a warning for each repeat region in the target that's too long
+// to fit a unique junction within the homology limits. Those regions have to be within a single
+// fragment, and synthesis is suggested when a PCR template is unlikely to be found for them.
+func repeatWarnings(seq string, conf *config.Config) (warnings []string) {
+	minHomology, maxHomology := conf.FragmentsMinHomology, conf.FragmentsMaxHomology
+	for _, r := range findRepeatRegions(seq, minHomology) {
+		if r.length() < maxHomology {
+			continue // a junction can still include unique bp next to the repeat
+		}
+
+		warning := fmt.Sprintf(
+			"%dbp repeat at %d-%d: no unique junction of %d-%dbp fits inside it",
+			r.length(), r.start, r.end%len(seq), minHomology, maxHomology,
+		)
+		if synthLength := r.length() + 2*minHomology; synthLength <= conf.SyntheticMaxLength {
+			warning += fmt.Sprintf(", consider synthesizing it as a single %dbp fragment", synthLength)
+		} else {
+			warning += fmt.Sprintf(", and it's too long to synthesize as a single fragment (>%dbp)", conf.SyntheticMaxLength)
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
@@ -0,0 +1,67 @@
+package repp
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_findRepeatRegions(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	randSeq := func(n int) string {
+		seq := make([]byte, n)
+		for i := range seq {
+			seq[i] = "ACGT"[r.Intn(4)]
+		}
+		return string(seq)
+	}
+	unique1, unique2, unique3, repeat := randSeq(300), randSeq(300), randSeq(300), randSeq(150)
+
+	tests := []struct {
+		name string
+		seq  string
+		want []repeatRegion
+	}{
+		{
+			"no repeats",
+			unique1,
+			nil,
+		},
+		{
+			"direct repeat",
+			unique1 + repeat + unique2 + repeat + unique3,
+			[]repeatRegion{{300, 450}, {750, 900}},
+		},
+		{
+			"inverted repeat",
+			unique1 + repeat + unique2 + reverseComplement(repeat) + unique3,
+			[]repeatRegion{{299, 450}, {750, 901}}, // the flanking bp happen to be complementary
+
+		},
+		{
+			"homopolymer tract",
+			unique1 + strings.Repeat("A", 40) + unique2,
+			[]repeatRegion{{300, 340}},
+		},
+		{
+			"repeat across the zero index",
+			repeat[75:] + unique1 + repeat + unique2 + repeat[:75],
+			[]repeatRegion{{375, 525}, {825, 975}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findRepeatRegions(tt.seq, 20); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findRepeatRegions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	c := config.New()
+	if got := repeatWarnings(unique1+repeat+unique2+repeat+unique3, c); len(got) != 2 {
+		t.Errorf("repeatWarnings() = %v, want 2 warnings", got)
+	}
+}
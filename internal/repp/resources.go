@@ -0,0 +1,107 @@
+package repp
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// partialAssemblyBytes is about the memory of a partial assembly in the assembly search:
+// its copies of the fragments, without their sequences, which are shared
+const partialAssemblyBytes = 4 << 10
+
+// maxCPUs is the most CPUs a run uses, all of them if 0
+var maxCPUs int
+
+// maxRAM is the memory limit of a run in bytes, none if 0
+var maxRAM int64
+
+// SetMaxCPUs bounds the CPUs of a run, ex: so runs on a shared server don't starve each
+// other. It bounds the threads of repp itself and of BLAST, and the subprocesses that run
+// at once unless they're limited on their own. All CPUs are used if n isn't positive.
+// It must be called before SetMaxSubprocesses
+func SetMaxCPUs(n int) {
+	if n <= 0 || n >= runtime.NumCPU() {
+		maxCPUs = 0
+		return
+	}
+	maxCPUs = n
+	runtime.GOMAXPROCS(n)
+}
+
+// availableCPUs returns the CPUs a run may use
+func availableCPUs() int {
+	if maxCPUs > 0 {
+		return maxCPUs
+	}
+	return runtime.NumCPU()
+}
+
+// blastThreads returns the threads of each BLAST search: all but one of the CPUs or, if
+// they're bounded, the CPUs split between the subprocesses that may run at once, so
+// threads × subprocesses stays within the bound
+func blastThreads() int {
+	threads := runtime.NumCPU() - 1
+	if maxCPUs > 0 {
+		threads = maxCPUs / commands.maxSubprocesses()
+	}
+	if threads < 1 {
+		threads = 1
+	}
+	return threads
+}
+
+// SetMaxRAM bounds the memory of a run to a size like 500MB or 4GB. The Go runtime collects
+// garbage more often near the limit, and the assembly search keeps fewer partial assemblies.
+// Memory isn't bounded if size is empty
+func SetMaxRAM(size string) error {
+	if size == "" {
+		maxRAM = 0
+		return nil
+	}
+	n, ok := parseBytes(size)
+	if !ok || n <= 0 {
+		return fmt.Errorf("invalid memory limit %q, ex: 500MB or 4GB", size)
+	}
+	maxRAM = n
+	debug.SetMemoryLimit(n)
+	return nil
+}
+
+// maxPartialAssemblies returns the most partial assemblies the assembly search keeps in
+// memory, up to half of the memory limit. It's 0, unbounded, if memory isn't limited
+func maxPartialAssemblies() int {
+	if maxRAM <= 0 {
+		return 0
+	}
+	limit := maxRAM / 2 / partialAssemblyBytes
+	if limit < 1 {
+		limit = 1
+	}
+	return int(limit)
+}
+
+// parallelFor calls fn with each of [0, n) on the available CPUs and waits for them to return
+func parallelFor(n int, fn func(i int)) {
+	workers := availableCPUs()
+	if workers > n {
+		workers = n
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
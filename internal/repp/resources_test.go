@@ -0,0 +1,58 @@
+package repp
+
+import (
+	"runtime"
+	"testing"
+)
+
+func Test_resourceLimits(t *testing.T) {
+	defer func() { maxCPUs, maxRAM = 0, 0 }()
+
+	maxCPUs = 2
+	if got := availableCPUs(); got != 2 {
+		t.Errorf("availableCPUs() = %d, want 2", got)
+	}
+	maxCPUs = 0
+	if got := availableCPUs(); got != runtime.NumCPU() {
+		t.Errorf("availableCPUs() = %d, want %d", got, runtime.NumCPU())
+	}
+
+	if got := maxPartialAssemblies(); got != 0 {
+		t.Errorf("maxPartialAssemblies() = %d, want 0 without a memory limit", got)
+	}
+	maxRAM = 1 << 30
+	if got := maxPartialAssemblies(); got != (1<<29)/partialAssemblyBytes {
+		t.Errorf("maxPartialAssemblies() = %d, want %d", got, (1<<29)/partialAssemblyBytes)
+	}
+
+	for _, size := range []string{"lots", "0GB", "-1G"} {
+		if err := SetMaxRAM(size); err == nil {
+			t.Errorf("SetMaxRAM(%q) = nil, want an error", size)
+		}
+	}
+}
+
+func Test_blastThreads(t *testing.T) {
+	defer func(runner *commandRunner) { maxCPUs, commands = 0, runner }(commands)
+
+	tests := []struct {
+		cpus, subprocesses, want int
+	}{
+		{8, 8, 1},
+		{8, 2, 4},
+		{8, 3, 2},
+		{2, 1, 2},
+		{2, 4, 1}, // more subprocesses than CPUs still get a thread each
+	}
+	for _, tt := range tests {
+		maxCPUs = tt.cpus
+		commands = newCommandRunner(tt.subprocesses)
+		got := blastThreads()
+		if got != tt.want {
+			t.Errorf("blastThreads() with %d CPUs and %d subprocesses = %d, want %d", tt.cpus, tt.subprocesses, got, tt.want)
+		}
+		if tt.subprocesses <= tt.cpus && got*tt.subprocesses > tt.cpus {
+			t.Errorf("blastThreads() × subprocesses = %d, more than the %d CPUs", got*tt.subprocesses, tt.cpus)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package repp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reconstruct joins the fragments of a Gibson Assembly at their junctions, the homology
+// between each fragment's end and the next one's start, and returns the assembled product.
+// If the last fragment also shares a junction with the first, the product is circular and
+// the junction is trimmed from the end so the product is exactly one copy of the plasmid
+func reconstruct(frags []*Frag, minHomology, maxHomology int) (product string, circularized bool, err error) {
+	if len(frags) == 0 {
+		return "", false, fmt.Errorf("no fragments")
+	}
+	for _, f := range frags {
+		if f.getFragSeq() == "" {
+			return "", false, fmt.Errorf("%s has no sequence", f.ID)
+		}
+	}
+
+	// a whole plasmid, used as is
+	if len(frags) == 1 && frags[0].fragType == circular {
+		return frags[0].getFragSeq(), true, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(frags[0].getFragSeq())
+	for i, f := range frags[1:] {
+		prev := frags[i]
+		j := prev.junction(f, minHomology, maxHomology)
+		if j == "" {
+			return "", false, fmt.Errorf("no junction between %s and %s", prev.ID, f.ID)
+		}
+		b.WriteString(f.getFragSeq()[len(j):])
+	}
+	product = b.String()
+
+	last := frags[len(frags)-1]
+	if j := last.junction(frags[0], minHomology, maxHomology); j != "" && len(j) < len(product) {
+		return product[:len(product)-len(j)], true, nil
+	}
+	return product, false, nil
+}
+
+// checkReconstruction returns an error if a solution's fragments, joined at their junctions,
+// don't reproduce the target. The target includes the backbone if there is one, and a
+// circular product may start anywhere in it
+func checkReconstruction(frags []*Frag, targetSeq string, minHomology, maxHomology int) error {
+	product, circularized, err := reconstruct(frags, minHomology, maxHomology)
+	if err != nil {
+		return fmt.Errorf("the fragments don't reconstruct the target: %v", err)
+	}
+
+	product, targetSeq = strings.ToUpper(product), strings.ToUpper(targetSeq)
+	if len(product) != len(targetSeq) {
+		return fmt.Errorf("the fragments reconstruct a %d bp product, not the %d bp target", len(product), len(targetSeq))
+	}
+	if circularized && !strings.Contains(targetSeq+targetSeq, product) {
+		return fmt.Errorf("the fragments reconstruct a %d bp plasmid that differs from the target", len(product))
+	}
+	if !circularized && product != targetSeq {
+		return fmt.Errorf("the fragments reconstruct a %d bp product that differs from the target", len(product))
+	}
+	return nil
+}
+
+// selfCheck returns an error for the first solution whose fragments don't reconstruct the target
+func selfCheck(solutions []Solution) error {
+	for i, s := range solutions {
+		if s.reconstructionErr != nil {
+			return fmt.Errorf("self-check failed for solution %d: %v", i+1, s.reconstructionErr)
+		}
+	}
+	return nil
+}
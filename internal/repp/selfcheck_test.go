@@ -0,0 +1,85 @@
+package repp
+
+import (
+	"testing"
+)
+
+func Test_checkReconstruction(t *testing.T) {
+	target := randomSeq(1000, 1)
+	rotated := target[300:] + target[:300]
+
+	tests := []struct {
+		name    string
+		frags   []*Frag
+		target  string
+		wantErr bool
+	}{
+		{
+			"circular",
+			[]*Frag{
+				{ID: "pcr", PCRSeq: target[0:520], fragType: pcr},
+				{ID: "synth", Seq: target[500:] + target[:20], fragType: synthetic},
+			},
+			target,
+			false,
+		},
+		{
+			"circular from another start",
+			[]*Frag{
+				{ID: "pcr", PCRSeq: target[0:520], fragType: pcr},
+				{ID: "synth", Seq: target[500:] + target[:20], fragType: synthetic},
+			},
+			rotated,
+			false,
+		},
+		{
+			"whole plasmid",
+			[]*Frag{{ID: "plasmid", Seq: rotated, fragType: circular}},
+			target,
+			false,
+		},
+		{
+			"linear",
+			[]*Frag{
+				{ID: "pcr", PCRSeq: target[0:520], fragType: pcr},
+				{ID: "synth", Seq: target[500:], fragType: synthetic},
+			},
+			target,
+			false,
+		},
+		{
+			"shifted fragment",
+			[]*Frag{
+				{ID: "pcr", PCRSeq: target[0:520], fragType: pcr},
+				{ID: "synth", Seq: target[501:] + target[:20], fragType: synthetic},
+			},
+			target,
+			true,
+		},
+		{
+			"missing junction",
+			[]*Frag{
+				{ID: "pcr", PCRSeq: target[0:500], fragType: pcr},
+				{ID: "synth", Seq: target[500:] + target[:20], fragType: synthetic},
+			},
+			target,
+			true,
+		},
+		{
+			"different bases",
+			[]*Frag{
+				{ID: "pcr", PCRSeq: target[0:520], fragType: pcr},
+				{ID: "synth", Seq: target[500:700] + reverseComplement(target[700:800]) + target[800:] + target[:20], fragType: synthetic},
+			},
+			target,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkReconstruction(tt.frags, tt.target, 20, 120); (err != nil) != tt.wantErr {
+				t.Errorf("checkReconstruction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -19,14 +19,15 @@ func SequenceList(
 	identity int,
 	ungapped bool,
 	leftMargin int,
-	dbNames []string) {
+	dbNames []string,
+	strict bool) {
 
 	dbs, err := getRegisteredDBs(dbNames)
 	if err != nil {
 		rlog.Fatal(err)
 	}
 
-	matches, err := blast("find_cmd", seq, true, leftMargin, dbs, filters, identity, ungapped)
+	matches, err := blast("find_cmd", seq, true, leftMargin, dbs, filters, nil, identity, ungapped, nil)
 	if err != nil {
 		rlog.Fatal(err)
 	}
@@ -45,9 +46,19 @@ func SequenceList(
 		return m.entry + strconv.Itoa(m.subjectStart) + strconv.Itoa(m.subjectEnd)
 	}
 
+	// entries renamed, and the bases stripped from them, when their database was built, by database name
+	renamedIDs := make(map[string]map[string]string)
+	offsets := make(map[string]map[string]entryOffsets)
+	for _, db := range dbs {
+		renamedIDs[db.Name] = db.renamedIDs()
+		if offsets[db.Name], err = db.entryOffsets(strict); err != nil {
+			rlog.Fatal(err)
+		}
+	}
+
 	seenIds := make(map[string]bool)
 	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
-	fmt.Fprintf(writer, "entry\tqstart\tqend\tsstart\tsend\tdatabase\t\n")
+	fmt.Fprintf(writer, "entry\tqstart\tqend\tsstart\tsend\tdatabase\toriginal\t\n")
 	for _, m := range matches {
 		if _, seen := seenIds[key(m)]; seen {
 			continue
@@ -57,7 +68,9 @@ func SequenceList(
 			continue
 		}
 
-		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\t%s\n", m.entry, m.queryStart, m.queryEnd, m.subjectStart, m.subjectEnd, m.db.Name)
+		subjectOffsets := offsets[m.db.Name][m.entry]
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\t%s\t%s\n", m.entry, m.queryStart, m.queryEnd,
+			subjectOffsets.originalIndex(m.subjectStart), subjectOffsets.originalIndex(m.subjectEnd), m.db.Name, renamedIDs[m.db.Name][m.entry])
 		seenIds[key(m)] = true
 	}
 	writer.Flush()
@@ -66,11 +79,21 @@ func SequenceList(
 // Sequence is for running an end to end plasmid design using a target sequence.
 func Sequence(assemblyParams AssemblyParams, maxSolutions int, conf *config.Config) (solutions [][]*Frag) {
 	start := time.Now()
-	// get registered blast databases
-	dbs, err := assemblyParams.getDBs()
-	if err != nil {
-		// error getting the DBs
-		rlog.Fatal(err)
+	// read the previous plan to reuse fragments and primers from
+	var plan *priorPlan
+	var err error
+	if reuseFrom := assemblyParams.GetReuseFrom(); reuseFrom != "" {
+		if plan, err = readPriorPlan(reuseFrom); err != nil {
+			rlog.Fatal(err)
+		}
+	}
+	// get registered blast databases, none are searched in synthesis only mode
+	var dbs []DB
+	if !assemblyParams.GetSynthOnly() {
+		if dbs, err = assemblyParams.getDBs(); err != nil {
+			// error getting the DBs
+			rlog.Fatal(err)
+		}
 	}
 	// get registered enzymes
 	enzymes, err := assemblyParams.getEnzymes()
@@ -79,40 +102,41 @@ func Sequence(assemblyParams AssemblyParams, maxSolutions int, conf *config.Conf
 		rlog.Fatal(err)
 	}
 	// prepare backbone if needed
-	backboneFrag, backboneMeta, err := prepareBackbone(assemblyParams.GetBackboneName(), enzymes, dbs)
+	backboneFrag, backboneMeta, err := prepareBackbone(assemblyParams.GetBackboneName(), enzymes, dbs, conf.Strict)
 	if err != nil {
 		// error getting the backbone
 		rlog.Fatal(err)
 	}
+	// homology arms have to span the ends left after chew-back
+	backboneFrag = homologyBackbone(backboneFrag, backboneMeta)
+	// read the primer and synthetic fragment manifests, the primers on hand discount assemblies that can reuse them
+	primersDB, synthFragsDB, err := readOligoDBs(assemblyParams.GetPrimersDBLocations(), assemblyParams.GetSynthFragsDBLocations(), conf)
+	if err != nil {
+		rlog.Fatal(err)
+	}
 	// build up the assemblies that make the sequence
-	target, solutions, err := sequence(
-		assemblyParams.GetIn(),
-		assemblyParams.GetFilters(),
-		assemblyParams.GetIdentity(),
-		assemblyParams.GetUngapped(),
-		assemblyParams.GetLeftMargin(),
-		backboneFrag,
-		dbs,
-		maxSolutions,
-		conf)
+	target, solutions, err := sequence(assemblyParams, backboneFrag, plan, primersDB, synthFragsDB, dbs, maxSolutions, conf)
 	if err != nil {
 		rlog.Fatal(err)
 	}
 
-	primersDB := readOligos(assemblyParams.GetPrimersDBLocations(), primerIDPrefix, false)
-	synthFragsDB := readOligos(assemblyParams.GetSynthFragsDBLocations(), synthFragIDPrefix, true)
+	// add the fixed 5' sequences of the primer-additions setting to the designed primers
+	if err := addPrimerAdditions(solutions, conf); err != nil {
+		rlog.Fatal(err)
+	}
 
 	// write the results to a file
 	elapsed := time.Since(start)
-	_, err = writeResult(
-		assemblyParams.GetOut(),
-		assemblyParams.GetOutputFormat(),
+	out, err := writeResult(
+		newOutputOptions(assemblyParams),
 		target.ID,
 		target.Seq,
 		solutions,
 		primersDB,
 		synthFragsDB,
 		backboneMeta,
+		plan,
+		dbs,
 		elapsed.Seconds(),
 		conf,
 	)
@@ -120,11 +144,28 @@ func Sequence(assemblyParams AssemblyParams, maxSolutions int, conf *config.Conf
 		rlog.Fatal(err)
 	}
 
+	if len(out.ParetoFront) > 1 {
+		rlog.Infof("%s", paretoChart(out.ParetoFront, out.Currency))
+	}
+
 	rlog.Debugw("execution time", "execution", elapsed)
 
 	return solutions
 }
 
+// isCircularTarget returns whether the target is treated as circular. The topology
+// overrides the input's own. Otherwise targets are circular unless their GenBank LOCUS
+// line says they're linear (FASTA targets are circular whether their header says so or not).
+func isCircularTarget(target *Frag, topology string) bool {
+	switch strings.ToLower(topology) {
+	case "circular":
+		return true
+	case "linear":
+		return false
+	}
+	return target.fragType == circular || !target.linearTopology
+}
+
 // sequence builds a plasmid cost optimization
 //
 // The goal is to find an "optimal" assembly sequence with:
@@ -150,16 +191,36 @@ func Sequence(assemblyParams AssemblyParams, maxSolutions int, conf *config.Conf
 // or create a sequence to be synthesized if it's a synthetic fragment.
 // Error out and repeat the build stage if a Frag fails to be filled
 func sequence(
-	input string,
-	filters []string,
-	identity int,
-	ungapped bool,
-	leftMargin int,
+	assemblyParams AssemblyParams,
 	backboneFrag *Frag,
+	plan *priorPlan,
+	primersDB, synthFragsDB *oligosDB,
 	dbs []DB,
 	keepNSolutions int,
 	conf *config.Config) (target *Frag, solutions [][]*Frag, err error) {
 
+	input := assemblyParams.GetIn()
+	filters := assemblyParams.GetFilters()
+	identity := assemblyParams.GetIdentity()
+	ungapped := assemblyParams.GetUngapped()
+	leftMargin := assemblyParams.GetLeftMargin()
+	topology := assemblyParams.GetTopology()
+	synthOnly := assemblyParams.GetSynthOnly()
+	pareto := assemblyParams.GetPareto()
+	landingPadNames := assemblyParams.GetLandingPads()
+	diffAgainst := assemblyParams.GetDiffAgainst()
+	fromGenbankFeatures := assemblyParams.GetFromGenbankFeatures()
+	monomer := assemblyParams.GetMonomer()
+	synthesizeRanges := assemblyParams.GetSynthesizeRegions()
+	identityRegionsFile := assemblyParams.GetIdentityRegions()
+	dumpMatchesPrefix := assemblyParams.GetDumpMatches()
+
+	// parse the expression of the database entries to keep
+	filter, err := parseFilter(assemblyParams.GetFilterExpr())
+	if err != nil {
+		return &Frag{}, nil, err
+	}
+
 	// read the target sequence (the first in the slice is used)
 	fragments, err := read(input, false, false)
 	if err != nil {
@@ -176,8 +237,77 @@ func sequence(
 	}
 
 	target = fragments[0]
+	if unit, copies := tandemUnit(strings.ToUpper(target.Seq)); monomer && copies > 1 {
+		rlog.Infof("%s is %d copies of a %dbp sequence, designing one copy", target.ID, copies, unit)
+		target.Seq = target.Seq[:unit]
+	}
 	targetSeqLen := len(target.Seq)
-	rlog.Debugw("building plasmid", "targetID", target.ID, "targetLen", targetSeqLen)
+	setRunTarget(targetSeqLen)
+	circularTarget := isCircularTarget(target, topology)
+	rlog.Debugw("building plasmid", "targetID", target.ID, "targetLen", targetSeqLen, "circular", circularTarget)
+	if !circularTarget {
+		rlog.Infof("%s is linear, matches across its zero index are ignored", target.ID)
+	}
+
+	// warn up front about targets that may not be what was meant to be built
+	for _, w := range targetWarnings(target, circularTarget, conf) {
+		rlog.Warnf("%s: %s", target.ID, w)
+	}
+
+	// warn up front about repeats that can't be split by unique junctions
+	for _, w := range repeatWarnings(target.Seq, conf) {
+		rlog.Warnf("%s: %s", target.ID, w)
+	}
+
+	// warn up front about motifs that are unstable in E. coli or hard to synthesize
+	for _, w := range unstableWarnings(target.Seq) {
+		rlog.Warnf("%s: %s", target.ID, w)
+	}
+	unstableRegions = findUnstableRegions(target.Seq)
+
+	// find the regions of the target that have to be synthesized or PCR'ed from specific dbs
+	var sourcingRegions []sourcingRegion
+	if fromGenbankFeatures {
+		if sourcingRegions, err = readSourcingRegions(input, targetSeqLen); err != nil {
+			return &Frag{}, nil, fmt.Errorf("failed to read the sourcing features of %s: %v", input, err)
+		}
+		if monomer {
+			sourcingRegions = monomerRegions(sourcingRegions, targetSeqLen)
+		}
+		if err = checkSourcingDBs(sourcingRegions, dbs); err != nil {
+			return &Frag{}, nil, err
+		}
+		rlog.Infof("%d features of %s constrain where their sequence comes from", len(sourcingRegions), target.ID)
+	}
+	if len(synthesizeRanges) > 0 {
+		synthesized, err := parseSynthesizeRegions(synthesizeRanges, targetSeqLen)
+		if err != nil {
+			return &Frag{}, nil, fmt.Errorf("failed to read the regions of %s to synthesize: %v", target.ID, err)
+		}
+		sourcingRegions = append(sourcingRegions, synthesized...)
+	}
+
+	// find the regions of the target with a higher %-identity threshold
+	var identityRegions []identityRegion
+	if identityRegionsFile != "" {
+		if identityRegions, err = readIdentityRegions(identityRegionsFile, targetSeqLen); err != nil {
+			return &Frag{}, nil, fmt.Errorf("failed to read the identity regions of %s: %v", target.ID, err)
+		}
+		for _, r := range identityRegions {
+			if r.identity <= identity {
+				rlog.Warnf("the %d%% identity of region %s isn't above the %d%% of --identity, it has no effect", r.identity, r.name, identity)
+			}
+		}
+	}
+
+	// split the target into synthetic fragments, without BLAST
+	if synthOnly {
+		frags, err := synthesizeTarget(target, circularTarget, conf)
+		if err != nil {
+			return &Frag{}, nil, fmt.Errorf("failed to split %s into synthetic fragments: %v", target.ID, err)
+		}
+		return target, [][]*Frag{frags}, nil
+	}
 
 	var bbFragInsert *Frag
 	if backboneFrag.ID != "" {
@@ -230,35 +360,133 @@ func sequence(
 		bbFragInsert = nil
 	}
 
-	// get all the matches against the target plasmid
-	matches, err := blast(
-		target.ID,
-		target.Seq,
-		true,
-		leftMargin,
-		dbs,
-		filters,
-		identity,
-		ungapped,
-	)
+	// check how matches are culled before they're found
+	grouping, err := parseCullGrouping(conf.GetCullGrouping())
 	if err != nil {
-		dbMessage := strings.Join(dbNames(dbs), ", ")
-		return &Frag{}, nil, fmt.Errorf("failed to blast %s against the dbs %s: %v", target.ID, dbMessage, err)
+		return &Frag{}, nil, err
+	}
+
+	// find the landing pads that junctions are limited to, after the backbone is in the target
+	var landingPads []landingPad
+	if len(landingPadNames) > 0 {
+		if landingPads, err = resolveLandingPads(landingPadNames, NewFeatureDB().contents, target.Seq); err != nil {
+			return &Frag{}, nil, err
+		}
+	}
+
+	// get all the matches against the target plasmid. Try exact matches first
+	// and skip BLAST if they're enough to cover the target
+	var matches []match
+	if conf.ExactMatchFastPath {
+		leftMarginHandling, err := parseLeftMarginHandling(conf.GetMatchLeftMarginHandling())
+		if err != nil {
+			return &Frag{}, nil, err
+		}
+		exact, err := exactMatches(target.Seq, circularTarget, leftMargin, leftMarginHandling, dbs, filters, filter, conf.PcrMinFragLength)
+		if err != nil {
+			return &Frag{}, nil, fmt.Errorf("failed to find exact matches for %s: %v", target.ID, err)
+		}
+		exact, _ = splitNoPCR(exact)
+		maxGap := 2*conf.PcrPrimerMaxEmbedLength - conf.FragmentsMinHomology
+		if exactMatchesCover(exact, len(target.Seq), maxGap) {
+			rlog.Infof("%d exact matches cover %s, skipping BLAST", len(exact), target.ID)
+			matches = exact
+		}
+	}
+	if matches == nil && diffAgainst != "" {
+		// BLAST only the region that changed since the previous design
+		if diffed, ok, err := diffMatches(diffAgainst, target, circularTarget, leftMargin, dbs, filters, filter, identity, ungapped, conf); err != nil {
+			return &Frag{}, nil, fmt.Errorf("failed to find the matches of %s that changed since %s: %v", target.ID, diffAgainst, err)
+		} else if ok {
+			if err = extendIdenticalEnds(diffed, target.Seq, circularTarget, leftMargin); err != nil {
+				return &Frag{}, nil, fmt.Errorf("failed to extend matches for %s: %v", target.ID, err)
+			}
+			matches = diffed
+		}
+	}
+	if matches == nil {
+		matches, err = blast(
+			target.ID,
+			target.Seq,
+			circularTarget,
+			leftMargin,
+			dbs,
+			filters,
+			filter,
+			identity,
+			ungapped,
+			conf,
+		)
+		if err != nil {
+			dbMessage := strings.Join(dbNames(dbs), ", ")
+			return &Frag{}, nil, fmt.Errorf("failed to blast %s against the dbs %s: %v", target.ID, dbMessage, err)
+		}
+
+		// recover identical ends that BLAST left off the matches
+		if err = extendIdenticalEnds(matches, target.Seq, circularTarget, leftMargin); err != nil {
+			return &Frag{}, nil, fmt.Errorf("failed to extend matches for %s: %v", target.ID, err)
+		}
 	}
 
+	// record the matches considered, and why they're removed, to audit the search
+	var dump *matchDump
+	if dumpMatchesPrefix != "" {
+		dump = &matchDump{raw: append([]match(nil), matches...)}
+	}
+
+	// drop the matches of do-not-PCR templates, they're only sequence references
+	matches, referenceMatches = splitNoPCR(matches)
+	if len(referenceMatches) > 0 {
+		rlog.Infof("%d matches of %s are in do-not-PCR templates and won't be PCR'ed", len(referenceMatches), target.ID)
+	}
+	dump.drop(referenceMatches, nil, "in a do-not-PCR template")
+
+	// trim the matches off the features they aren't allowed to source
+	unsourced := matches
+	if matches, err = applySourcing(matches, sourcingRegions, targetSeqLen); err != nil {
+		return &Frag{}, nil, fmt.Errorf("failed to source the features of %s: %v", target.ID, err)
+	}
+	dump.drop(unsourced, matches, "trimmed off a feature its database may not source")
+
+	// trim the matches off the regions they match with too low a %-identity
+	unidentical := matches
+	matches = applyIdentityRegions(matches, identityRegions, targetSeqLen)
+	dump.drop(unidentical, matches, "trimmed off a region it matches below the region's %-identity")
+
+	// drop matches too long to PCR, before they cull the shorter ones they contain
+	unamplified := matches
+	matches = amplifiableMatches(matches, len(target.Seq), conf)
+	dump.drop(unamplified, matches, fmt.Sprintf("longer than the %dbp PCR limit", maxPCRLength(conf)))
+
 	// keep only "proper" arcs (non-self-contained)
-	matches = cull(matches, conf.PcrMinFragLength, 1)
-	rlog.Debugw("culled matches", "remaining", len(matches)/2)
+	setRunStage(assemblyStage)
+	matches, culled := cullGrouped(matches, conf.GetCullMinLength(), conf.GetCullLimit(), grouping)
+	rlog.Debugw("culled matches", "remaining", len(matches)/2, "culled", len(culled))
+	if isVerboseLogging() {
+		dumpCulledMatches(culled)
+	}
+	if dump != nil {
+		dump.cull(culled)
+		if err = dump.write(dumpMatchesPrefix, matches); err != nil {
+			return &Frag{}, nil, fmt.Errorf("failed to dump the matches of %s: %v", target.ID, err)
+		}
+	}
 
 	// map fragment Matches to nodes
 	frags := newFrags(matches, conf)
 
+	// discount the fragments whose primers are likely on hand, before the backbone that's cut rather than PCR'ed is added
+	setPrimerReuseBonus(frags, target.Seq, primersDB, conf.ToCurrency(conf.PrimerReuseBonus), conf)
+
 	if bbFragInsert != nil {
-		copiedBB := bbFragInsert.copy()
-		copiedBB.start += len(target.Seq)
-		copiedBB.end += len(target.Seq)
-		// add the backbone in as fragment (copy twice across zero index)
-		frags = append(frags, bbFragInsert, copiedBB)
+		// add the backbone in as fragment (copy twice across zero index, unless the target is linear)
+		frags = append(frags, bbFragInsert)
+		if circularTarget {
+			copiedBB := bbFragInsert.copy()
+			copiedBB.start += len(target.Seq)
+			copiedBB.end += len(target.Seq)
+			frags = append(frags, copiedBB)
+		}
 		sort.Slice(frags, func(i, j int) bool {
 			return frags[i].start < frags[j].start
 		})
@@ -266,7 +494,7 @@ func sequence(
 
 	// build up a slice of assemblies that could, within the upper-limit on
 	// fragment count, be assembled to make the target plasmid
-	assemblies := createAssemblies(frags, target.Seq, len(target.Seq), false, conf)
+	assemblies := createAssemblies(frags, target.Seq, len(target.Seq), false, !circularTarget, conf)
 
 	rlog.Debugf("Sort %d found assemblies\n", len(assemblies))
 	// sort assemblies
@@ -278,6 +506,17 @@ func sequence(
 			rlog.Debugf("Prelim solution %d: %v", i+1, a)
 		}
 	}
+	if plan != nil {
+		// among equally good assemblies, fill those that reuse the previous plan's fragments first
+		plan.preferReused(assemblies)
+	}
+	if pareto {
+		// fill the best assembly of each fragment count, rather than the top ones, and
+		// keep those on the pareto front of their filled costs. keepNSolutions is ignored
+		assemblies = paretoCandidates(assemblies)
+		keepNSolutions = len(assemblies)
+		rlog.Infof("filling %d assemblies for the pareto front", len(assemblies))
+	}
 	var maxSolutions int
 	if keepNSolutions > 0 {
 		if keepNSolutions < len(assemblies) {
@@ -291,7 +530,7 @@ func sequence(
 	}
 	maxInspectedSolutions := maxSolutions + int(0.2*float32(len(assemblies)))
 
-	var filledAssemblies []*assembly
+	var filledAssemblies, templateLimitDiscarded []*assembly
 
 	rlog.Infof("Start filling PCR primers for %d assemblies out of %d\n", maxSolutions, len(assemblies))
 	// try to fill as many solutions as requested (if there are enough assemblies)
@@ -307,7 +546,21 @@ func sequence(
 			selectedAssemblies = assemblies[searchSolutionFromIndex:]
 		}
 		// fill in only top best assemblies
-		solutions := fillAssemblies(target.Seq, selectedAssemblies, searchSolutionFromIndex, conf)
+		solutions := fillAssemblies(target.Seq, selectedAssemblies, searchSolutionFromIndex, plan, synthFragsDB, conf)
+		if len(landingPads) > 0 {
+			solutions = atLandingPads(solutions, landingPads, conf)
+		}
+		if len(sourcingRegions) > 0 {
+			solutions = sourcedAssemblies(solutions, sourcingRegions, targetSeqLen)
+		}
+		if len(identityRegions) > 0 {
+			solutions = identicalAssemblies(solutions, identityRegions, targetSeqLen)
+		}
+		if conf.FragmentsMaxPerTemplate > 0 {
+			var discarded []*assembly
+			solutions, discarded = templateLimited(solutions, conf.FragmentsMaxPerTemplate)
+			templateLimitDiscarded = append(templateLimitDiscarded, discarded...)
+		}
 		filledAssemblies = append(filledAssemblies, solutions...)
 		if len(filledAssemblies) >= maxSolutions {
 			break
@@ -322,12 +575,25 @@ func sequence(
 			}
 		}
 	}
+	if pareto {
+		filledAssemblies = paretoAssemblies(filledAssemblies)
+		rlog.Infof("%d filled assemblies are on the pareto front", len(filledAssemblies))
+	}
 	// final sort after filling the assemblies
 	// but this time sort by the number of fragments
 	sort.Slice(filledAssemblies, func(i, j int) bool {
 		return filledAssemblies[i].len() < filledAssemblies[j].len()
 	})
 	rlog.Infof("Finished filling %d assemblies", len(filledAssemblies))
+	if len(templateLimitDiscarded) > 0 {
+		var top *assembly
+		if len(filledAssemblies) > 0 {
+			top = filledAssemblies[0]
+		}
+		if change := templateLimitChange(top, templateLimitDiscarded, conf.FragmentsMaxPerTemplate); change != "" {
+			rlog.Warnf("%s: %s", target.ID, change)
+		}
+	}
 	var nfinalSolutions int
 	if len(filledAssemblies) < maxSolutions {
 		nfinalSolutions = len(filledAssemblies)
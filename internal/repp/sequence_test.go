@@ -0,0 +1,25 @@
+package repp
+
+import "testing"
+
+func Test_isCircularTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   *Frag
+		topology string
+		want     bool
+	}{
+		{"FASTA target", &Frag{fragType: linear}, "", true},
+		{"circular GenBank target", &Frag{fragType: circular}, "", true},
+		{"linear GenBank target", &Frag{fragType: linear, linearTopology: true}, "", false},
+		{"linear override", &Frag{fragType: circular}, "linear", false},
+		{"circular override", &Frag{fragType: linear, linearTopology: true}, "circular", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCircularTarget(tt.target, tt.topology); got != tt.want {
+				t.Errorf("isCircularTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
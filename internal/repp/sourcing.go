@@ -0,0 +1,310 @@
+package repp
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sourcingRegion is a region of the target, annotated by a feature of its GenBank file, that
+// has to be synthesized or PCR'ed from a template in one of a list of databases
+type sourcingRegion struct {
+	// name of the feature, its label if it has one
+	name string
+
+	// start of the region on the target (0-indexed)
+	start int
+
+	// end of the region on the target (exclusive). Past the target's end if it crosses the zero-index
+	end int
+
+	// synthesize is whether the region has to be synthesized, from a /repp_synthesize qualifier
+	synthesize bool
+
+	// dbs are the names of the databases the region's template has to be in, from a /repp_source qualifier
+	dbs []string
+}
+
+// allows returns whether a match of a database may cover the region
+func (r sourcingRegion) allows(db string) bool {
+	if r.synthesize {
+		return false
+	}
+	for _, name := range r.dbs {
+		if name == db {
+			return true
+		}
+	}
+	return false
+}
+
+// genbankFeatureRegex matches the first line of a GenBank feature: its key and location
+var genbankFeatureRegex = regexp.MustCompile(`^ {5}(\S+)\s+(\S.*)$`)
+
+// genbankQualifierRegex matches the first line of a GenBank feature's qualifier
+var genbankQualifierRegex = regexp.MustCompile(`^ {21}/([^=\s]+)(?:=(.*))?$`)
+
+// genbankRangeRegex matches the ranges of a GenBank feature's location
+var genbankRangeRegex = regexp.MustCompile(`<?(\d+)\.\.>?(\d+)`)
+
+// monomerRegions returns the sourcing regions in the first copy of a target that's
+// designed as one copy of a tandem repeat, ex: the features of a doubled plasmid
+func monomerRegions(regions []sourcingRegion, unit int) (kept []sourcingRegion) {
+	for _, r := range regions {
+		if r.start < unit {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// readSourcingRegions returns the regions of a GenBank target whose features have a
+// /repp_synthesize or /repp_source="db1,db2" qualifier. Features without either are ignored
+func readSourcingRegions(path string, seqLen int) (regions []sourcingRegion, err error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	record := splitGenbankRecords(string(contents))[0]
+	featuresStart, originStart := strings.Index(record, "\nFEATURES"), strings.Index(record, "\nORIGIN")
+	if featuresStart < 0 || originStart < featuresStart {
+		return nil, fmt.Errorf("%s isn't an annotated GenBank file", path)
+	}
+
+	var region *sourcingRegion
+	var qualifier string // the qualifier being read, it may wrap onto the next lines
+	flush := func() {
+		if region != nil && (region.synthesize || len(region.dbs) > 0) {
+			regions = append(regions, *region)
+		}
+	}
+	for _, line := range strings.Split(record[featuresStart+1:originStart], "\n")[1:] {
+		line = strings.TrimRight(line, "\r")
+		if m := genbankFeatureRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			region, qualifier = nil, ""
+			ranges := genbankRangeRegex.FindAllStringSubmatch(m[2], -1)
+			if len(ranges) == 0 {
+				continue
+			}
+			start, _ := strconv.Atoi(ranges[0][1])
+			end, _ := strconv.Atoi(ranges[len(ranges)-1][2])
+			if end < start {
+				end += seqLen // joined across the zero-index
+			}
+			region = &sourcingRegion{name: m[1] + " " + m[2], start: start - 1, end: end}
+			continue
+		}
+		if region == nil {
+			continue
+		}
+
+		value := ""
+		if m := genbankQualifierRegex.FindStringSubmatch(line); m != nil {
+			qualifier, value = m[1], m[2]
+		} else if qualifier == "repp_source" {
+			value = strings.TrimSpace(line) // a wrapped list of databases
+		}
+		value = strings.Trim(value, `"`)
+
+		switch qualifier {
+		case "label":
+			region.name = value
+			qualifier = ""
+		case "repp_synthesize":
+			region.synthesize = value == "" || strings.EqualFold(value, "true") || strings.EqualFold(value, "yes")
+			qualifier = ""
+		case "repp_source":
+			for _, db := range strings.Split(value, ",") {
+				if db = strings.TrimSpace(db); db != "" {
+					region.dbs = append(region.dbs, db)
+				}
+			}
+		}
+	}
+	flush()
+
+	for _, r := range regions {
+		if r.synthesize && len(r.dbs) > 0 {
+			return nil, fmt.Errorf("feature %s is both /repp_synthesize and /repp_source", r.name)
+		}
+		if r.start < 0 || r.end > 2*seqLen || r.start >= r.end {
+			return nil, fmt.Errorf("feature %s is outside the target", r.name)
+		}
+	}
+	return regions, nil
+}
+
+// parseSynthesizeRegions returns the regions to synthesize of 1-based, inclusive ranges of
+// the target, ex: 120-480. A range that ends before it starts crosses the zero-index
+func parseSynthesizeRegions(ranges []string, seqLen int) (regions []sourcingRegion, err error) {
+	for _, r := range ranges {
+		bounds := strings.Split(r, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid range %q, ex: 120-480", r)
+		}
+		start, startErr := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		end, endErr := strconv.Atoi(strings.TrimSpace(bounds[1]))
+		if startErr != nil || endErr != nil {
+			return nil, fmt.Errorf("invalid range %q, ex: 120-480", r)
+		}
+		if start < 1 || end < 1 || start > seqLen || end > seqLen {
+			return nil, fmt.Errorf("range %s is outside the %dbp target", r, seqLen)
+		}
+		if end < start {
+			end += seqLen // across the zero-index
+		}
+		regions = append(regions, sourcingRegion{name: r, start: start - 1, end: end, synthesize: true})
+	}
+	return regions, nil
+}
+
+// checkSourcingDBs returns an error if a region's database isn't one of those searched
+func checkSourcingDBs(regions []sourcingRegion, dbs []DB) error {
+	searched := make(map[string]bool)
+	for _, db := range dbs {
+		searched[db.Name] = true
+	}
+	for _, r := range regions {
+		for _, db := range r.dbs {
+			if !searched[db] {
+				return fmt.Errorf("feature %s must come from %s, which isn't one of the databases searched: %s", r.name, db, strings.Join(dbNames(dbs), ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// applySourcing trims the matches off the regions they aren't allowed to cover: regions
+// to synthesize and those that must come from another database. Matches with gaps,
+// that can't be trimmed base for base, are dropped instead. It fails if no match is
+// left to cover a region that has to come from a database
+func applySourcing(matches []match, regions []sourcingRegion, seqLen int) ([]match, error) {
+	if len(regions) == 0 {
+		return matches, nil
+	}
+
+	var kept []match
+	for _, m := range matches {
+		pieces := []match{m}
+		for _, r := range regions {
+			if r.allows(m.db.Name) {
+				continue
+			}
+			var trimmed []match
+			for _, p := range pieces {
+				trimmed = append(trimmed, trimOffRegion(p, r, seqLen)...)
+			}
+			pieces = trimmed
+		}
+		kept = append(kept, pieces...)
+	}
+
+	for _, r := range regions {
+		if r.synthesize {
+			continue
+		}
+		covered := false
+		for _, m := range kept {
+			if r.allows(m.db.Name) && regionInRange(r, m.queryStart, m.queryEnd, seqLen) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return nil, fmt.Errorf("no match in %s covers feature %s (%d-%d)", strings.Join(r.dbs, ", "), r.name, r.start+1, r.end)
+		}
+	}
+	return kept, nil
+}
+
+// trimOffRegion returns the pieces of a match on either side of a region, in any copy of the
+// region on the doubled target. Pieces are only cut from ungapped matches on the forward strand
+// of the target, the others are dropped if they overlap the region
+func trimOffRegion(m match, r sourcingRegion, seqLen int) (pieces []match) {
+	for _, offset := range []int{-seqLen, 0, seqLen, 2 * seqLen} {
+		start, end := r.start+offset, r.end+offset
+		if m.queryEnd < start || m.queryStart >= end {
+			continue
+		}
+		if m.gaps > 0 || m.queryRevCompMatch || len(m.querySeq) != len(m.seq) {
+			return nil
+		}
+		if start > m.queryStart {
+			pieces = append(pieces, trimMatch(m, m.queryStart, start-1, seqLen))
+		}
+		if end <= m.queryEnd {
+			pieces = append(pieces, trimOffRegion(trimMatch(m, end, m.queryEnd, seqLen), r, seqLen)...)
+		}
+		return pieces
+	}
+	return []match{m}
+}
+
+// trimMatch returns an ungapped match trimmed to [queryStart, queryEnd] of the target
+func trimMatch(m match, queryStart, queryEnd, seqLen int) match {
+	left, right := queryStart-m.queryStart, m.queryEnd-queryEnd
+	m.querySeq = m.querySeq[left : len(m.querySeq)-right]
+	m.seq = m.seq[left : len(m.seq)-right]
+	m.queryStart, m.queryEnd = queryStart, queryEnd
+	if m.subjectRevCompMatch {
+		m.subjectStart, m.subjectEnd = m.subjectStart+right, m.subjectEnd-left
+	} else {
+		m.subjectStart, m.subjectEnd = m.subjectStart+left, m.subjectEnd-right
+	}
+
+	m.mismatching = 0
+	for i := range m.querySeq {
+		if !strings.EqualFold(m.querySeq[i:i+1], m.seq[i:i+1]) {
+			m.mismatching++
+		}
+	}
+	m.uniqueID = m.matchID(seqLen)
+	return m
+}
+
+// regionInRange returns whether a region, in any copy of it on the doubled target, is within [start, end]
+func regionInRange(r sourcingRegion, start, end, seqLen int) bool {
+	for _, offset := range []int{-seqLen, 0, seqLen} {
+		if r.start+offset >= start && r.end+offset-1 <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// offSourceRegion returns an error for the first region that has to come from a database
+// and isn't within a PCR fragment of a template in one of them
+func offSourceRegion(frags []*Frag, regions []sourcingRegion, seqLen int) error {
+	for _, r := range regions {
+		if r.synthesize {
+			continue
+		}
+		sourced := false
+		for _, f := range frags {
+			if f.fragType == pcr && r.allows(f.db.Name) && regionInRange(r, f.start, f.end, seqLen) {
+				sourced = true
+				break
+			}
+		}
+		if !sourced {
+			return fmt.Errorf("feature %s isn't PCR'ed from %s", r.name, strings.Join(r.dbs, ", "))
+		}
+	}
+	return nil
+}
+
+// sourcedAssemblies returns the filled assemblies whose regions that have to come from
+// a database are PCR'ed from one of its templates
+func sourcedAssemblies(assemblies []*assembly, regions []sourcingRegion, seqLen int) (kept []*assembly) {
+	for _, a := range assemblies {
+		if err := offSourceRegion(a.frags, regions, seqLen); err != nil {
+			rlog.Debugf("Discard %v: %v", a, err)
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
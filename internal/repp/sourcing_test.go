@@ -0,0 +1,115 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_readSourcingRegions(t *testing.T) {
+	gb := `LOCUS       target                  1000 bp    DNA     circular SYN 01-JAN-2020
+FEATURES             Location/Qualifiers
+     source          1..1000
+                     /organism="synthetic DNA construct"
+     CDS             complement(101..400)
+                     /label="GFP"
+                     /repp_source="igem,
+                     addgene"
+     promoter        join(951..1000,1..20)
+                     /label="pLac"
+                     /repp_synthesize
+     rep_origin      501..700
+                     /label="ori"
+ORIGIN
+        1 atgc
+//
+`
+	path := filepath.Join(t.TempDir(), "target.gb")
+	if err := os.WriteFile(path, []byte(gb), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readSourcingRegions(path, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sourcingRegion{
+		{name: "GFP", start: 100, end: 400, dbs: []string{"igem", "addgene"}},
+		{name: "pLac", start: 950, end: 1020, synthesize: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readSourcingRegions() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_applySourcing(t *testing.T) {
+	target := randomSeq(1000, 3)
+	seq := target + target
+	newMatch := func(db string, start, end int) match {
+		return match{
+			entry:       db + "-template",
+			db:          DB{Name: db},
+			querySeq:    seq[start : end+1],
+			seq:         seq[start : end+1],
+			queryStart:  start,
+			queryEnd:    end,
+			subjectEnd:  end - start,
+			mismatching: 0,
+		}
+	}
+	regions := []sourcingRegion{
+		{name: "GFP", start: 100, end: 400, dbs: []string{"igem"}},
+		{name: "pLac", start: 950, end: 1020, synthesize: true},
+	}
+
+	got, err := applySourcing([]match{
+		newMatch("addgene", 0, 599),
+		newMatch("igem", 50, 450),
+		newMatch("addgene", 1900, 1999),
+	}, regions, len(target))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRanges := [][2]int{{20, 99}, {400, 599}, {50, 450}, {1900, 1949}}
+	if len(got) != len(wantRanges) {
+		t.Fatalf("applySourcing() = %v, want %v", got, wantRanges)
+	}
+	for i, m := range got {
+		if m.queryStart != wantRanges[i][0] || m.queryEnd != wantRanges[i][1] {
+			t.Errorf("applySourcing()[%d] = [%d:%d], want %v", i, m.queryStart, m.queryEnd, wantRanges[i])
+		}
+		if m.querySeq != seq[m.queryStart:m.queryEnd+1] || m.seq != m.querySeq {
+			t.Errorf("applySourcing()[%d] sequences weren't trimmed with the match", i)
+		}
+	}
+	if got[1].subjectStart != 400 || got[1].subjectEnd != 599 {
+		t.Errorf("applySourcing()[1] subject = [%d:%d], want [400:599]", got[1].subjectStart, got[1].subjectEnd)
+	}
+
+	// a region no match of its database covers
+	if _, err := applySourcing([]match{newMatch("addgene", 0, 599)}, regions, len(target)); err == nil {
+		t.Error("applySourcing() without an igem match covering GFP, want an error")
+	}
+}
+
+func Test_parseSynthesizeRegions(t *testing.T) {
+	got, err := parseSynthesizeRegions([]string{"120-480", "950-30"}, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sourcingRegion{
+		{name: "120-480", start: 119, end: 480, synthesize: true},
+		{name: "950-30", start: 949, end: 1030, synthesize: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSynthesizeRegions() = %+v, want %+v", got, want)
+	}
+
+	for _, invalid := range []string{"120", "a-480", "0-10", "900-1001"} {
+		if _, err := parseSynthesizeRegions([]string{invalid}, 1000); err == nil {
+			t.Errorf("parseSynthesizeRegions(%q) = nil, want an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package repp
+
+import "fmt"
+
+// strictErrorf returns an error for a data inconsistency in strict mode, set by the strict
+// setting or the --strict flag, for users who prefer failing fast over designs made from
+// degraded data. Outside strict mode the inconsistency is logged as a warning and tolerated.
+// The message should locate the inconsistency, ex: the file and record
+func strictErrorf(strict bool, format string, v ...interface{}) error {
+	if !strict {
+		rlog.Warnf(format, v...)
+		return nil
+	}
+	return fmt.Errorf("strict mode: "+format, v...)
+}
+
+// coordinateInconsistency returns how a match's coordinates on its template are inconsistent
+// with its sequence or its coordinates on the target, empty if they're consistent. Gapped
+// matches are only checked against the template's length
+func (m match) coordinateInconsistency() string {
+	if m.subjectLength > 0 && m.subjectEnd >= m.subjectLength {
+		return fmt.Sprintf("template end %d is past the end of the %dbp template", m.subjectEnd+1, m.subjectLength)
+	}
+	if m.gaps > 0 {
+		return ""
+	}
+	if subjectSpan, querySpan := m.subjectEnd-m.subjectStart+1, m.queryEnd-m.queryStart+1; subjectSpan != querySpan {
+		return fmt.Sprintf("template span %d-%d is %dbp, the target span %d-%d is %dbp", m.subjectStart+1, m.subjectEnd+1, subjectSpan, m.queryStart+1, m.queryEnd+1, querySpan)
+	}
+	if subjectSpan := m.subjectEnd - m.subjectStart + 1; len(m.seq) != subjectSpan {
+		return fmt.Sprintf("template span %d-%d is %dbp, its sequence is %dbp", m.subjectStart+1, m.subjectEnd+1, subjectSpan, len(m.seq))
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package repp
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_coordinateInconsistency(t *testing.T) {
+	tests := []struct {
+		name string
+		m    match
+		want string
+	}{
+		{
+			"consistent",
+			match{queryStart: 10, queryEnd: 13, subjectStart: 0, subjectEnd: 3, seq: "ACGT", subjectLength: 20},
+			"",
+		},
+		{
+			"past the template's end",
+			match{queryStart: 10, queryEnd: 13, subjectStart: 18, subjectEnd: 21, seq: "ACGT", subjectLength: 20},
+			"past the end",
+		},
+		{
+			"spans differ",
+			match{queryStart: 10, queryEnd: 15, subjectStart: 0, subjectEnd: 3, seq: "ACGT"},
+			"the target span",
+		},
+		{
+			"sequence differs from the span",
+			match{queryStart: 10, queryEnd: 13, subjectStart: 0, subjectEnd: 3, seq: "ACG"},
+			"its sequence is 3bp",
+		},
+		{
+			"gapped spans may differ",
+			match{queryStart: 10, queryEnd: 15, subjectStart: 0, subjectEnd: 3, seq: "ACGT", gaps: 2},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.m.coordinateInconsistency()
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("coordinateInconsistency() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_addOligoRecords_strict(t *testing.T) {
+	records := [][]string{
+		{"id", "sequence"},
+		{"oligo1", "ACGTACGTACGT"},
+		{"oligo2", ""},
+		{"oligo3"},
+	}
+
+	if err := addOligoRecords(records, newOligosDB("oligo", false), false); err != nil {
+		t.Errorf("addOligoRecords() = %v, want the rows skipped outside strict mode", err)
+	}
+
+	err := addOligoRecords(records, newOligosDB("oligo", false), true)
+	if err == nil || !strings.Contains(err.Error(), "row 3") || !strings.Contains(err.Error(), "row 4") {
+		t.Errorf("addOligoRecords() = %v, want errors locating rows 3 and 4", err)
+	}
+}
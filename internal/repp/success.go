@@ -0,0 +1,108 @@
+package repp
+
+import (
+	"math"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+const (
+	// minJunctionTm is the Tm below which a junction's homology is penalized
+	minJunctionTm = 50.0
+
+	// minJunctionGC and maxJunctionGC are the GC% range of a junction that isn't penalized
+	minJunctionGC, maxJunctionGC = 40.0, 60.0
+
+	// minSuccessFragLength is the length below which fragments are penalized, short
+	// fragments are harder to purify and are lost in Gibson's exonuclease chew-back
+	minSuccessFragLength = 250
+
+	// minSynthGC and maxSynthGC are the GC% range of a synthetic fragment's 50bp windows
+	// that isn't penalized
+	minSynthGC, maxSynthGC = 25.0, 65.0
+
+	// maxSynthHomopolymer is the longest homopolymer in a synthetic fragment that isn't penalized
+	maxSynthHomopolymer = 8
+)
+
+// successScore returns a score, from 0 to 1, of how likely a solution's assembly is to work
+// the first time. It's a heuristic, its factors and weights aren't fit to bench outcomes. Each
+// factor contributes a penalty that's scaled by its configured weight. The score is
+// exp(-sum(weight * penalty)), times the success rate of each long-range PCR.
+func successScore(frags []*Frag, conf *config.Config) float64 {
+	if len(frags) == 0 {
+		return 0
+	}
+
+	penalties := successPenalties(frags, conf)
+	total := 0.0
+	for factor, penalty := range penalties {
+		total += conf.SuccessWeight(factor) * penalty
+	}
+
+	score := math.Exp(-total)
+	for _, f := range frags {
+		if f.longRange() && conf.PcrLongRangeSuccessRate > 0 {
+			score *= math.Min(conf.PcrLongRangeSuccessRate, 1)
+		}
+	}
+	return math.Round(score*100) / 100
+}
+
+// successPenalties returns the unweighted penalties of a solution, by factor of the success model
+func successPenalties(frags []*Frag, conf *config.Config) map[string]float64 {
+	penalties := make(map[string]float64)
+	if len(frags) > 2 {
+		penalties["fragments"] = float64(len(frags) - 2)
+	}
+
+	for i, f := range frags {
+		if len(frags) > 1 {
+			next := frags[(i+1)%len(frags)]
+			if j := strings.ToUpper(f.junction(next, conf.FragmentsMinHomology, conf.FragmentsMaxHomology+1)); j != "" {
+				penalties["junction-tm"] += math.Max(minJunctionTm-primerTm(j), 0)
+				gc := 100 * float64(strings.Count(j, "G")+strings.Count(j, "C")) / float64(len(j))
+				penalties["junction-gc"] += math.Max(minJunctionGC-gc, 0) + math.Max(gc-maxJunctionGC, 0)
+			}
+		}
+
+		length := len(f.Seq)
+		if f.PCRSeq != "" {
+			length = len(f.PCRSeq)
+		}
+		if length > 0 && length < minSuccessFragLength {
+			penalties["fragment-length"] += float64(minSuccessFragLength-length) / minSuccessFragLength
+		}
+
+		if len(f.Primers) > 0 && conf.PcrPrimerMaxPairPenalty > 0 {
+			penalties["primers"] += f.Primers[0].PairPenalty / conf.PcrPrimerMaxPairPenalty
+		}
+
+		if f.offTargetTm > 0 {
+			// how far into the margin below the max off-target Tm the primers' worst off-target is
+			into := f.offTargetTm - (conf.PcrPrimerMaxOfftargetTm - offTargetTmMargin)
+			penalties["off-target-margin"] += math.Min(math.Max(into, 0), offTargetTmMargin) / offTargetTmMargin
+		}
+
+		if f.fragType == synthetic {
+			penalties["synthesis"] += synthesisPenalty(f.Seq)
+		}
+	}
+	return penalties
+}
+
+// synthesisPenalty returns the penalty of a synthetic fragment: the percent its 50bp
+// GC windows are outside the range vendors synthesize reliably, plus the bp of its
+// longest homopolymer past the max
+func synthesisPenalty(seq string) (penalty float64) {
+	scores := fragSeqQualityChecks(strings.ToUpper(seq))
+	if len(seq) >= 50 {
+		penalty += math.Max(minSynthGC-scores.min50WindowGCContent*100, 0)
+		penalty += math.Max(scores.max50WindowGCContent*100-maxSynthGC, 0)
+	}
+	if scores.longestHomopolymer > maxSynthHomopolymer {
+		penalty += float64(scores.longestHomopolymer - maxSynthHomopolymer)
+	}
+	return penalty
+}
@@ -0,0 +1,88 @@
+package repp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_successPenalties(t *testing.T) {
+	ac, gt := strings.Repeat("AC", 150), strings.Repeat("GT", 150) // no junctions between them
+
+	tests := []struct {
+		name  string
+		frags []*Frag
+		want  map[string]float64
+	}{
+		{
+			"two fragments",
+			[]*Frag{{Seq: ac}, {Seq: gt}},
+			map[string]float64{},
+		},
+		{
+			"four fragments",
+			[]*Frag{{Seq: ac}, {Seq: gt}, {Seq: ac}, {Seq: gt}},
+			map[string]float64{"fragments": 2},
+		},
+		{
+			"short fragment",
+			[]*Frag{{Seq: ac}, {Seq: gt[:125]}},
+			map[string]float64{"fragment-length": 0.5},
+		},
+		{
+			"primer pair penalty",
+			[]*Frag{{Seq: ac, Primers: []Primer{{PairPenalty: 15}, {PairPenalty: 15}}}, {Seq: gt}},
+			map[string]float64{"primers": 0.5},
+		},
+		{
+			"off-target near the max Tm",
+			[]*Frag{{Seq: ac, offTargetTm: 52.5}, {Seq: gt}},
+			map[string]float64{"off-target-margin": 0.5},
+		},
+		{
+			"synthetic homopolymer",
+			[]*Frag{{Seq: ac}, {Seq: gt[:199] + strings.Repeat("T", 12) + gt[:100], fragType: synthetic}},
+			map[string]float64{"synthesis": 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.New()
+			c.PcrPrimerMaxPairPenalty = 30
+			c.PcrPrimerMaxOfftargetTm = 55
+
+			if got := successPenalties(tt.frags, c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("successPenalties() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_successScore(t *testing.T) {
+	c := config.New()
+	c.SuccessWeights = map[string]float64{"fragments": 0.1}
+	c.PcrMaxFragLength = 200
+	c.PcrLongRangeSuccessRate = 0.5
+
+	ac, gt := strings.Repeat("AC", 150), strings.Repeat("GT", 150)
+	frags := []*Frag{{Seq: ac}, {Seq: gt}, {Seq: ac}}
+	if got := successScore(frags, c); got != 0.9 {
+		t.Errorf("successScore() = %v, want 0.9", got)
+	}
+
+	frags[0].fragType, frags[0].PCRSeq, frags[0].conf = pcr, ac, c // a long-range PCR
+	if got := successScore(frags, c); got != 0.45 {
+		t.Errorf("successScore() with a long-range PCR = %v, want 0.45", got)
+	}
+
+	// a junction with a balanced GC% and high Tm scores higher than an AT-rich one
+	good, poor := "ACGTGCATGCACGTAGCTAGCATGCAGTCA", "ATATTATAATTATATAATTATTAATATATA"
+	score := func(j string) float64 {
+		return successScore([]*Frag{{Seq: ac + j}, {Seq: j + gt}}, c)
+	}
+	if score(good) <= score(poor) {
+		t.Errorf("successScore() of a good junction = %v, not above a poor junction's %v", score(good), score(poor))
+	}
+}
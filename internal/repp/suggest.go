@@ -0,0 +1,236 @@
+package repp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// suggestModuleMaxGap is the most bp between features of an entry that are PCR'ed together as a module
+const suggestModuleMaxGap = 200
+
+// suggestSeedLength is the longest prefix of a feature that's looked up at each index of an entry
+const suggestSeedLength = 20
+
+// featurePattern is a feature of the feature database, on one strand
+type featurePattern struct {
+	name string
+	seq  string
+	rev  bool
+}
+
+// featureHit is a feature found in an entry of a database
+type featureHit struct {
+	pattern    featurePattern
+	start, end int // end is exclusive
+}
+
+// inventory is what's in the entries of the databases: the features of the feature
+// database that are in them and the modules, runs of neighboring features, they have
+type inventory struct {
+	// features are the entries with each feature, by feature name
+	features map[string][]string
+
+	// modules are the entries with each module, by module, ex: "lac promoter,lacZ alpha"
+	modules map[string][]string
+
+	// searched is the number of features searched for
+	searched int
+}
+
+// Suggest prints what can be built from the databases without synthesis: the features of the
+// feature database that are in their entries, each PCR'able from them, and the modules of neighboring
+// features in the entries that are PCR'ed as one fragment. Any combination of them is buildable by
+// Gibson Assembly with the junctions' homology in the primers' tails
+func Suggest(names []string, minFeatureLength, maxModules int) {
+	dbs, err := getRegisteredDBs(names)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if len(dbs) == 0 {
+		rlog.Fatal("no databases to suggest constructs from, pass them with --dbs")
+	}
+
+	inv, err := newInventory(NewFeatureDB().contents, dbs, minFeatureLength)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if err = inv.print(os.Stdout, strings.Join(dbNames(dbs), ", "), maxModules); err != nil {
+		rlog.Fatal(err)
+	}
+}
+
+// newInventory finds the features, at least minFeatureLength long, in the entries of the databases
+func newInventory(features map[string]string, dbs []DB, minFeatureLength int) (*inventory, error) {
+	// index the features, on both strands, by their prefixes
+	seeds := make(map[int]map[string][]featurePattern)
+	inv := &inventory{features: make(map[string][]string), modules: make(map[string][]string)}
+	for name, seq := range features {
+		seq = strings.ToUpper(seq)
+		if len(seq) < minFeatureLength || len(seq) == 0 {
+			continue
+		}
+		inv.searched++
+		for _, p := range []featurePattern{{name: name, seq: seq}, {name: name, seq: reverseComplement(seq), rev: true}} {
+			k := suggestSeedLength
+			if len(p.seq) < k {
+				k = len(p.seq)
+			}
+			if seeds[k] == nil {
+				seeds[k] = make(map[string][]featurePattern)
+			}
+			seeds[k][p.seq[:k]] = append(seeds[k][p.seq[:k]], p)
+		}
+	}
+
+	for _, db := range dbs {
+		err := scanFasta(db.Path, func(header, seq string) {
+			entry := header
+			if headerCols := strings.Fields(header); len(headerCols) > 0 {
+				entry = headerCols[0]
+			}
+			inv.add(entry, findFeatures(strings.ToUpper(seq), strings.Contains(strings.ToUpper(header), "CIRCULAR"), seeds))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read database %s: %v", db.Path, err)
+		}
+	}
+	return inv, nil
+}
+
+// findFeatures returns the features in an entry, sorted by where they start on its top strand.
+// Features in others on the same strand, ex: a promoter's operator, are dropped
+func findFeatures(seq string, circular bool, seeds map[int]map[string][]featurePattern) (hits []featureHit) {
+	starts := len(seq)
+	if half := len(seq) / 2; circular && seq[:half] == seq[half:] {
+		starts = half // circular entries are doubled in the database
+	}
+	for i := 0; i < starts; i++ {
+		for k, patterns := range seeds {
+			if i+k > len(seq) {
+				continue
+			}
+			for _, p := range patterns[seq[i:i+k]] {
+				if strings.HasPrefix(seq[i:], p.seq) {
+					hits = append(hits, featureHit{pattern: p, start: i, end: i + len(p.seq)})
+				}
+			}
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].start != hits[j].start {
+			return hits[i].start < hits[j].start
+		}
+		return hits[i].end > hits[j].end
+	})
+	var outer []featureHit
+	for _, h := range hits {
+		contained := false
+		for _, o := range outer {
+			if o.pattern.rev == h.pattern.rev && o.start <= h.start && h.end <= o.end {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			outer = append(outer, h)
+		}
+	}
+	return outer
+}
+
+// add records the features of an entry, and its modules of features at most suggestModuleMaxGap apart
+func (inv *inventory) add(entry string, hits []featureHit) {
+	seen := make(map[string]bool)
+	for _, h := range hits {
+		if !seen[h.pattern.name] {
+			seen[h.pattern.name] = true
+			inv.features[h.pattern.name] = append(inv.features[h.pattern.name], entry)
+		}
+	}
+
+	seen = make(map[string]bool)
+	addModule := func(run []featureHit) {
+		if len(run) < 2 {
+			return
+		}
+		if module := moduleName(run); !seen[module] {
+			seen[module] = true
+			inv.modules[module] = append(inv.modules[module], entry)
+		}
+	}
+	var run []featureHit
+	for _, h := range hits {
+		if len(run) > 0 && h.start-run[len(run)-1].end > suggestModuleMaxGap {
+			addModule(run)
+			run = nil
+		}
+		run = append(run, h)
+	}
+	addModule(run)
+}
+
+// moduleName returns the name of a run of features in the syntax of 'repp make features', ex:
+// "lac promoter,lacZ alpha:rev". A run and its reverse complement have the same name
+func moduleName(run []featureHit) string {
+	var fwd, rev []string
+	for i, h := range run {
+		fwd = append(fwd, featureName(h.pattern.name, h.pattern.rev))
+		r := run[len(run)-1-i]
+		rev = append(rev, featureName(r.pattern.name, !r.pattern.rev))
+	}
+	fwdName, revName := strings.Join(fwd, ","), strings.Join(rev, ",")
+	if revName < fwdName {
+		return revName
+	}
+	return fwdName
+}
+
+// featureName returns a feature's name with the ":rev" suffix if it's reversed
+func featureName(name string, rev bool) string {
+	if rev {
+		return name + ":rev"
+	}
+	return name
+}
+
+// print writes the features in the databases and their most common modules
+func (inv *inventory) print(out io.Writer, dbs string, maxModules int) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(w, "%d of %d features are in %s, each PCR'able without synthesis\n\n", len(inv.features), inv.searched, dbs)
+	fmt.Fprintf(w, "feature\tentries\texample\n")
+	for _, name := range sortByEntries(inv.features) {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", name, len(inv.features[name]), inv.features[name][0])
+	}
+
+	modules := sortByEntries(inv.modules)
+	if maxModules >= 0 && len(modules) > maxModules {
+		modules = modules[:maxModules]
+	}
+	if len(modules) > 0 {
+		fmt.Fprintf(w, "\nmodules of neighboring features, PCR'ed as one fragment\n\n")
+		fmt.Fprintf(w, "module\tentries\texample\n")
+		for _, module := range modules {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", module, len(inv.modules[module]), inv.modules[module][0])
+		}
+	}
+	return w.Flush()
+}
+
+// sortByEntries returns the keys sorted by their number of entries, then name
+func sortByEntries(entries map[string][]string) (keys []string) {
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(entries[keys[i]]) != len(entries[keys[j]]) {
+			return len(entries[keys[i]]) > len(entries[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,63 @@
+package repp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_newInventory(t *testing.T) {
+	promoter, gene, terminator, operator := randomSeq(100, 1), randomSeq(300, 2), randomSeq(50, 3), randomSeq(10, 4)
+	promoter = promoter[:40] + operator + promoter[50:]
+	features := map[string]string{
+		"promoter":   promoter,
+		"gene":       gene,
+		"terminator": terminator,
+		"operator":   operator,
+		"absent":     randomSeq(200, 5),
+	}
+
+	// pA has promoter,gene on its top strand, pB has them on its bottom strand (circular, doubled)
+	// and a terminator over 200bp away from them, pC has just the gene
+	pA := randomSeq(30, 6) + promoter + randomSeq(20, 7) + gene + randomSeq(30, 8)
+	pB := reverseComplement(promoter+randomSeq(20, 7)+gene) + randomSeq(500, 9) + terminator
+	pC := gene
+	fasta := ">pA\n" + pA + "\n>pB circular\n" + pB + pB + "\n>pC\n" + pC + "\n"
+
+	path := filepath.Join(t.TempDir(), "freezer")
+	if err := os.WriteFile(path, []byte(fasta), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := newInventory(features, []DB{{Name: "freezer", Path: path}}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFeatures := map[string][]string{
+		"gene":       {"pA", "pB", "pC"},
+		"promoter":   {"pA", "pB"},
+		"terminator": {"pB"},
+	}
+	if !reflect.DeepEqual(inv.features, wantFeatures) {
+		t.Errorf("newInventory() features = %v, want %v", inv.features, wantFeatures)
+	}
+	wantModules := map[string][]string{"gene:rev,promoter:rev": {"pA", "pB"}}
+	if !reflect.DeepEqual(inv.modules, wantModules) {
+		t.Errorf("newInventory() modules = %v, want %v", inv.modules, wantModules)
+	}
+	if inv.searched != 4 {
+		t.Errorf("newInventory() searched = %d, want 4, without the short operator", inv.searched)
+	}
+
+	var out bytes.Buffer
+	if err := inv.print(&out, "freezer", 20); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "3 of 4 features are in freezer") || !strings.Contains(out.String(), "gene:rev,promoter:rev") {
+		t.Errorf("print() = %s, want the features and modules", out.String())
+	}
+}
@@ -0,0 +1,135 @@
+package repp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// synthesizeTarget splits a target into synthetic fragments without looking for database
+// fragments. It's for fully novel sequences, and for a baseline cost to compare plans
+// that reuse database fragments against.
+//
+// The target is split into the fewest fragments within the vendor's max length, with
+// room to move each junction up to a homology length from its evenly spaced position.
+// Each junction is moved to the position whose homology is unique in the target, closest
+// to 50% GC, and free of hairpins.
+func synthesizeTarget(target *Frag, circular bool, conf *config.Config) ([]*Frag, error) {
+	seq := strings.ToUpper(target.Seq)
+	homology := conf.FragmentsMinHomology
+	step := conf.SyntheticMaxLength - 3*homology // each fragment's unique sequence, with room to move its junctions
+	if step <= 0 {
+		return nil, fmt.Errorf("synthetic-max-length of %d is too short for junctions with %dbp homology", conf.SyntheticMaxLength, homology)
+	}
+
+	count := int(math.Ceil(float64(len(seq)) / float64(step)))
+	if circular && count < 2 {
+		count = 2 // a circular target's fragments have to anneal to one another
+	}
+
+	// the junctions are the start of each fragment's homology with the fragment before it
+	junctions := []int{0} // a linear target's first fragment starts at its first base
+	first := 1
+	if circular {
+		junctions, first = nil, 0
+	}
+	for i := first; i < count; i++ {
+		ideal := i * len(seq) / count
+		candidates := rankJunctions(seq, circular, ideal, homology, homology)
+		junctions = append(junctions, pickJunction(seq, ideal, candidates, homology, conf))
+	}
+
+	frags := synthFrags(target.ID, seq, circular, junctions, homology, conf)
+	for _, f := range frags {
+		if len(f.Seq) < conf.SyntheticMinLength {
+			rlog.Warnf("%s is %dbp, shorter than the min synthetic length of %dbp", f.ID, len(f.Seq), conf.SyntheticMinLength)
+		}
+	}
+	return frags, nil
+}
+
+// rankJunctions returns the junctions within window bp of the ideal one whose homology
+// occurs once in the target, on either strand. They're ordered by how close the homology
+// is to 50% GC, then how close the junction is to the ideal one.
+func rankJunctions(seq string, circular bool, ideal, window, homology int) []int {
+	circ := newCircularSeq(seq)
+	searched := seq
+	if circular {
+		searched = circ.get(0, len(seq)+homology-1) // homology across the zero index
+	}
+
+	var junctions []int
+	for j := ideal - window; j <= ideal+window; j++ {
+		if !circular && (j < 1 || j+homology > len(seq)) {
+			continue
+		}
+		junction := circ.get(j, j+homology)
+		if strings.Count(searched, junction)+strings.Count(searched, reverseComplement(junction)) == 1 {
+			junctions = append(junctions, j)
+		}
+	}
+
+	gcDiff := func(j int) float64 {
+		junction := circ.get(j, j+homology)
+		gc := float64(strings.Count(junction, "G")+strings.Count(junction, "C")) / float64(homology)
+		return math.Abs(gc - 0.5)
+	}
+	sort.SliceStable(junctions, func(a, b int) bool {
+		if da, db := gcDiff(junctions[a]), gcDiff(junctions[b]); da != db {
+			return da < db
+		}
+		return math.Abs(float64(junctions[a]-ideal)) < math.Abs(float64(junctions[b]-ideal))
+	})
+	return junctions
+}
+
+// pickJunction returns the first ranked junction without a hairpin in its homology.
+// It falls back to the best ranked junction, or the ideal one if none are unique.
+func pickJunction(seq string, ideal int, ranked []int, homology int, conf *config.Config) int {
+	circ := newCircularSeq(seq)
+	for _, j := range ranked {
+		if hairpin(circ.get(j, j+homology), conf) <= conf.JunctionMaxHairpinMelt() {
+			return j
+		}
+	}
+	if len(ranked) > 0 {
+		position := (ranked[0]%len(seq)+len(seq))%len(seq) + 1
+		rlog.Warnf("the junction at %d has a hairpin above %.1f°C", position, conf.JunctionMaxHairpinMelt())
+		return ranked[0]
+	}
+	rlog.Warnf("no junction near %d has unique homology", ideal+1)
+	return ideal
+}
+
+// synthFrags returns the synthetic fragments starting at each junction. Each fragment
+// overlaps the next by the homology. A linear target's last fragment ends with the
+// target; a circular target's last fragment overlaps its first. Like other fragments,
+// their ends are inclusive
+func synthFrags(targetID, seq string, circular bool, junctions []int, homology int, conf *config.Config) (frags []*Frag) {
+	circ := newCircularSeq(seq) // fragments may cross the zero index
+	for i, start := range junctions {
+		end := len(seq)
+		if i+1 < len(junctions) {
+			end = junctions[i+1] + homology
+		} else if circular {
+			end = junctions[0] + len(seq) + homology
+		}
+		if start < 0 {
+			start, end = start+len(seq), end+len(seq)
+		}
+
+		frags = append(frags, &Frag{
+			ID:          fmt.Sprintf("%s-synthesis-%d", targetID, len(frags)+1),
+			Seq:         circ.get(start, end),
+			start:       start,
+			end:         end - 1,
+			fragType:    synthetic,
+			synthReason: "--synth-only splits the target into synthetic fragments",
+			conf:        conf,
+		})
+	}
+	return frags
+}
@@ -0,0 +1,110 @@
+package repp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_rankJunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		seq      string
+		circular bool
+		ideal    int
+		window   int
+		homology int
+		want     []int
+	}{
+		{
+			"closest to 50% GC first",
+			"AAAAAAGCAAAAAAAAAA",
+			false,
+			6,
+			2,
+			4,
+			[]int{6, 5, 4, 7},
+		},
+		{
+			"repeated homology skipped",
+			"ACGTTTACGTCCCC",
+			false,
+			6,
+			1,
+			4,
+			[]int{5, 7},
+		},
+		{
+			"across the zero index of a circular target",
+			"GCAAAAAAAAAC",
+			true,
+			0,
+			1,
+			4,
+			[]int{0, -1, 1},
+		},
+		{
+			"outside a linear target",
+			"GCAAAAAAAAAC",
+			false,
+			0,
+			1,
+			4,
+			[]int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rankJunctions(tt.seq, tt.circular, tt.ideal, tt.window, tt.homology); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankJunctions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_synthFrags(t *testing.T) {
+	c := config.New()
+	seq := strings.Repeat("ACGT", 10) // 40bp
+
+	tests := []struct {
+		name      string
+		circular  bool
+		junctions []int
+		want      []string
+	}{
+		{
+			"linear",
+			false,
+			[]int{0, 18},
+			[]string{seq[0:22], seq[18:40]},
+		},
+		{
+			"circular, crossing the zero index",
+			true,
+			[]int{-2, 20},
+			[]string{seq[38:40] + seq[0:24], seq[20:40] + seq[0:2]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frags := synthFrags("target", seq, tt.circular, tt.junctions, 4, c)
+			target := newCircularSeq(seq)
+			var got []string
+			for _, f := range frags {
+				got = append(got, f.Seq)
+				if f.fragType != synthetic {
+					t.Errorf("synthFrags() fragment %s isn't synthetic", f.ID)
+				}
+				// the ends are inclusive, as when fill positions a fragment by its primers
+				if f.end-f.start+1 != len(f.Seq) || target.get(f.start, f.end+1) != f.Seq {
+					t.Errorf("synthFrags() fragment %s has range %d-%d, not inclusive of its sequence", f.ID, f.start, f.end)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("synthFrags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
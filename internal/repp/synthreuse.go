@@ -0,0 +1,84 @@
+package repp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// containing returns the synthetic fragment in the manifest that contains the sequence,
+// on either strand, with the fewest extra bp. Ties go to the lowest ID. Fragments that
+// are the sequence exactly aren't returned, they're reused as they are.
+func (oligos *oligosDB) containing(seq string) (o oligo, found bool) {
+	if oligos == nil || seq == "" {
+		return oligo{}, false
+	}
+	seq = strings.ToUpper(seq)
+	revSeq := reverseComplement(seq)
+	for indexed, candidate := range oligos.indexedOligos {
+		if len(indexed) <= len(seq) || !strings.Contains(indexed, seq) && !strings.Contains(indexed, revSeq) {
+			continue
+		}
+		if !found || len(indexed) < len(o.seq) || len(indexed) == len(o.seq) && candidate.id < o.id {
+			o, found = candidate, true
+		}
+	}
+	return o, found
+}
+
+// reuseSynthFrags replaces a filled solution's synthetic fragments that are contained in a
+// larger synthetic fragment already in the manifest with a PCR of the larger one, with
+// primers that trim it to the needed region. A fragment is only replaced if primers can
+// be made and the PCR costs less than synthesizing the fragment again. It's called as
+// assemblies are filled, so the solutions are ranked by their cost with the reuse.
+func reuseSynthFrags(target string, solution []*Frag, synthFragsDB *oligosDB, linear bool, conf *config.Config) {
+	if synthFragsDB == nil || len(synthFragsDB.indexedOligos) == 0 {
+		return
+	}
+
+	// synthetic fragments past the zero-index keep their offset so their distances to their
+	// neighbors hold. setPrimers reads the target as a circularSeq, so primers can cross it
+	circ := newCircularSeq(target)
+	for i, f := range solution {
+		if f.fragType != synthetic || searchOligoDBs(f.Seq, []*oligosDB{synthFragsDB}).hasID() {
+			continue
+		}
+		o, found := synthFragsDB.containing(f.Seq)
+		if !found {
+			continue
+		}
+
+		trimmed := &Frag{
+			ID:         o.id,
+			Seq:        strings.ToUpper(f.Seq),
+			uniqueID:   o.id + "-" + strconv.Itoa(circ.index(f.start)),
+			fullSeq:    strings.ToUpper(o.seq),
+			start:      f.start,
+			end:        f.start + len(f.Seq) - 1,
+			fragType:   pcr,
+			matchRatio: 1,
+			conf:       conf,
+		}
+		prev := prevFragment(solution, i, target, linear, conf)
+		next := nextFragment(solution, i, target, linear, conf)
+		if err := trimmed.setPrimers(prev, next, target, nil, conf); err != nil || len(trimmed.Primers) < 2 {
+			rlog.Debugf("failed to trim %s to %s: %v", o.id, f.ID, err)
+			continue
+		}
+
+		synthCost, _ := f.cost(true)
+		pcrCost, _ := trimmed.cost(true)
+		if pcrCost >= synthCost {
+			continue
+		}
+
+		primers := []Primer{trimmed.Primers[0], trimmed.Primers[1]} // the primer cache is unchanged
+		for side := range primers {
+			primers[side].Notes = addNote(primers[side].Notes, "trims "+o.id+" from the synthetic fragment manifest")
+		}
+		trimmed.Primers = primers
+		rlog.Infof("reusing %s from the synthetic fragment manifest for %s, trimmed by PCR", o.id, f.ID)
+		solution[i] = trimmed
+	}
+}
@@ -0,0 +1,31 @@
+package repp
+
+import "testing"
+
+func Test_oligosDB_containing(t *testing.T) {
+	synthFrags := newOligosDB(synthFragIDPrefix, true)
+	synthFrags.addOligo(oligo{id: "S1", seq: "aaaaGATTACAGATTACAcccc", synth: true})
+	synthFrags.addOligo(oligo{id: "S2", seq: "GATTACAGATTACAcc", synth: true})
+	synthFrags.addOligo(oligo{id: "S3", seq: "TTTTTGGGGGACGTCAAAAA", synth: true})
+	synthFrags.addOligo(oligo{id: "S4", seq: "GATTACAGATTACAGG", synth: true})
+
+	tests := []struct {
+		name    string
+		seq     string
+		wantID  string
+		wantHit bool
+	}{
+		{"shortest containing fragment, ties to the lowest ID", "GATTACAGATTACA", "S2", true},
+		{"reverse complement", "tcccccaaaaa", "S3", true},
+		{"exact fragments aren't partial matches", "TTTTTGGGGGACGTCAAAAA", "", false},
+		{"not contained", "GATTACATTTT", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := synthFrags.containing(tt.seq)
+			if found != tt.wantHit || got.id != tt.wantID {
+				t.Errorf("containing() = %s, %v, want %s, %v", got.id, found, tt.wantID, tt.wantHit)
+			}
+		})
+	}
+}
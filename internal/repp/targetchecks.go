@@ -0,0 +1,165 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// contaminantMinMatch is the length of the shortest stretch of a contaminant in a target that's reported
+const contaminantMinMatch = 20
+
+// stopCodons are the stop codons of the standard genetic code
+var stopCodons = map[string]bool{"TAA": true, "TAG": true, "TGA": true}
+
+// targetWarnings returns warnings about a target, before it's designed: whether it's too short
+// or long, a tandem repeat of a smaller sequence, missing its start or stop codon if it looks like a CDS, or
+// has stretches of the sequencing adapters or vectors of the contaminants database
+func targetWarnings(target *Frag, circular bool, conf *config.Config) (warnings []string) {
+	seq := strings.ToUpper(target.Seq)
+
+	if minLength := conf.GetTargetMinLength(); len(seq) < minLength {
+		warnings = append(warnings, fmt.Sprintf("target is %dbp, shorter than the target-min-length of %dbp. "+
+			"It may be cheaper to order as one synthetic fragment", len(seq), minLength))
+	}
+	if maxLength := conf.GetTargetMaxLength(); len(seq) > maxLength {
+		warnings = append(warnings, fmt.Sprintf("target is %dbp, longer than the target-max-length of %dbp. "+
+			"Check it's the right sequence, or build it in parts", len(seq), maxLength))
+	}
+
+	if unit, copies := tandemUnit(seq); copies > 1 {
+		times := "twice"
+		if copies > 2 {
+			times = fmt.Sprintf("%d times", copies)
+		}
+		warnings = append(warnings, fmt.Sprintf("target is the same %dbp sequence %s. "+
+			"If it was exported or pasted as a multimer, design one copy with --monomer", unit, times))
+	}
+
+	if !circular {
+		warnings = append(warnings, cdsWarnings(seq)...)
+	}
+
+	contaminantWarns, err := contaminantWarnings(seq, circular, conf.GetTargetContaminantsDB())
+	if err != nil {
+		rlog.Debugf("not screening %s for contaminants: %v", target.ID, err)
+	}
+	return append(warnings, contaminantWarns...)
+}
+
+// tandemUnit returns the length of the shortest sequence that the target is a tandem
+// repeat of, and its number of copies, ex: 1500 and 2 for a doubled 1500bp plasmid. It's
+// the target's length and 1 if the target isn't a repeat. The period is the target's
+// length less its longest border, a prefix that's also a suffix
+func tandemUnit(seq string) (unit, copies int) {
+	if len(seq) == 0 {
+		return 0, 0
+	}
+	// KMP failure function, border[i] is the length of the longest border of seq[:i+1]
+	border := make([]int, len(seq))
+	for i := 1; i < len(seq); i++ {
+		k := border[i-1]
+		for k > 0 && seq[i] != seq[k] {
+			k = border[k-1]
+		}
+		if seq[i] == seq[k] {
+			k++
+		}
+		border[i] = k
+	}
+	if period := len(seq) - border[len(seq)-1]; period < len(seq) && len(seq)%period == 0 {
+		return period, len(seq) / period
+	}
+	return len(seq), 1
+}
+
+// cdsWarnings returns warnings about a linear target that looks like a CDS, a whole number
+// of codons that starts with a start codon or ends with a stop codon, but lacks the other
+// or has a stop codon in frame before its end
+func cdsWarnings(seq string) (warnings []string) {
+	if len(seq) < 6 || len(seq)%3 != 0 {
+		return nil
+	}
+	hasStart := strings.HasPrefix(seq, "ATG")
+	hasStop := stopCodons[seq[len(seq)-3:]]
+	if !hasStart && !hasStop {
+		return nil
+	}
+
+	if !hasStart {
+		warnings = append(warnings, "target looks like a CDS that ends with a stop codon but doesn't start with ATG. "+
+			"Add the start codon if it's missing")
+	}
+	if !hasStop {
+		warnings = append(warnings, "target looks like a CDS that starts with ATG but doesn't end with a stop codon. "+
+			"Add the stop codon if it's missing")
+	}
+	for i := 3; i+3 < len(seq); i += 3 {
+		if stopCodons[seq[i:i+3]] {
+			warnings = append(warnings, fmt.Sprintf("target looks like a CDS but has an in-frame stop codon at %d. "+
+				"Check the reading frame", i+1))
+			break
+		}
+	}
+	return warnings
+}
+
+// contaminantWarnings returns a warning for each stretch of the target, of at least
+// contaminantMinMatch bp, that's in a sequence of the contaminants database on either strand
+func contaminantWarnings(seq string, circular bool, contaminantsDB string) (warnings []string, err error) {
+	k := contaminantMinMatch
+	if len(seq) < k {
+		return nil, nil
+	}
+
+	// index the target's k-mers, across the zero-index if it's circular
+	template := seq
+	if circular {
+		template = seq + seq[:k-1]
+	}
+	kmers := make(map[string][]int)
+	for i := 0; i+k <= len(template); i++ {
+		kmers[template[i:i+k]] = append(kmers[template[i:i+k]], i)
+	}
+
+	err = scanFasta(contaminantsDB, func(header, contaminant string) {
+		covered := make(map[int]bool)
+		for _, strand := range []string{contaminant, reverseComplement(contaminant)} {
+			for i := 0; i+k <= len(strand); i++ {
+				for _, start := range kmers[strand[i:i+k]] {
+					for j := start; j < start+k; j++ {
+						covered[j%len(seq)] = true
+					}
+				}
+			}
+		}
+		if len(covered) == 0 {
+			return
+		}
+
+		var indexes []int
+		for i := range covered {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		for _, r := range mergeIndexes(indexes) {
+			warnings = append(warnings, fmt.Sprintf("target bases %d-%d match the contaminant %s. "+
+				"Trim them if they're left over from sequencing or cloning", r[0]+1, r[1], header))
+		}
+	})
+	return warnings, err
+}
+
+// mergeIndexes merges sorted indexes into [start, end) ranges of consecutive indexes
+func mergeIndexes(indexes []int) (ranges [][2]int) {
+	for _, i := range indexes {
+		if n := len(ranges); n > 0 && ranges[n-1][1] == i {
+			ranges[n-1][1]++
+		} else {
+			ranges = append(ranges, [2]int{i, i + 1})
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,78 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_targetWarnings(t *testing.T) {
+	contaminants := filepath.Join(t.TempDir(), "contaminants.fasta")
+	adapter := "AGATCGGAAGAGCACACGTCTGAACTCCAGTCAC"
+	if err := os.WriteFile(contaminants, []byte(">adapter TruSeq\n"+adapter+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := &config.Config{TargetContaminantsDB: contaminants}
+	plasmid := randomSeq(3000, 1)
+
+	tests := []struct {
+		name     string
+		seq      string
+		circular bool
+		want     []string
+	}{
+		{"plasmid", plasmid, true, nil},
+		{"too short", plasmid[:150], true, []string{"shorter than the target-min-length of 200bp"}},
+		{"too long", randomSeq(50002, 2), true, []string{"longer than the target-max-length of 50000bp"}},
+		{"doubled", plasmid[:1500] + plasmid[:1500], true, []string{"the same 1500bp sequence twice"}},
+		{"tripled", plasmid[:1000] + plasmid[:1000] + plasmid[:1000], true, []string{"the same 1000bp sequence 3 times"}},
+		{"repeat with a partial copy", plasmid[:1000] + plasmid[:1000] + plasmid[:500], true, nil},
+		{"adapter", plasmid[:1000] + adapter + plasmid[1000:], true, []string{"target bases 1001-1034 match the contaminant adapter TruSeq"}},
+		{"adapter across the zero-index", adapter[10:] + plasmid + adapter[:10], true, []string{"1-24 match", "3025-3034 match"}},
+		{"reverse complement adapter", plasmid + reverseComplement(adapter), false, []string{"target bases 3001-3034 match"}},
+		{"CDS", "ATG" + strings.Repeat("GCC", 100) + "TAA", false, nil},
+		{"CDS without a stop codon", "ATG" + strings.Repeat("GCC", 100), false, []string{"doesn't end with a stop codon"}},
+		{"CDS without a start codon", strings.Repeat("GCC", 100) + "TGA", false, []string{"doesn't start with ATG"}},
+		{"CDS with a stop codon", "ATG" + strings.Repeat("GCC", 50) + "TAG" + strings.Repeat("GCC", 50) + "TAA", false, []string{"in-frame stop codon at 154"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := targetWarnings(&Frag{ID: "target", Seq: tt.seq}, tt.circular, conf)
+			if len(got) != len(tt.want) {
+				t.Fatalf("targetWarnings() = %q, want %d warnings", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("targetWarnings() = %q, want %q", got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func Test_tandemUnit(t *testing.T) {
+	monomer := randomSeq(1000, 3)
+	tests := []struct {
+		name       string
+		seq        string
+		wantUnit   int
+		wantCopies int
+	}{
+		{"monomer", monomer, 1000, 1},
+		{"dimer", monomer + monomer, 1000, 2},
+		{"tetramer is a dimer of dimers", strings.Repeat(monomer, 4), 1000, 4},
+		{"homopolymer", strings.Repeat("A", 30), 1, 30},
+		{"dimer with a point mutation", monomer + monomer[:999] + "N", 2000, 1},
+		{"empty", "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if unit, copies := tandemUnit(tt.seq); unit != tt.wantUnit || copies != tt.wantCopies {
+				t.Errorf("tandemUnit() = %d, %d, want %d, %d", unit, copies, tt.wantUnit, tt.wantCopies)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package repp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// diffFlankLength is the bp on either side of a target's changed region that are BLASTed
+// with it, so matches reaching from the unchanged target into the changed region are found
+const diffFlankLength = 500
+
+// targetDiff is the region of a target that changed since a previous design:
+// [start, oldEnd) of the old target was replaced by [start, newEnd) of the new one
+type targetDiff struct {
+	start, oldEnd, newEnd int
+
+	// lengths of the old and new targets
+	oldLen, newLen int
+}
+
+// diffTargets returns the changed region of a target, between the longest common
+// prefix and suffix of its old and new sequences
+func diffTargets(oldSeq, newSeq string) targetDiff {
+	oldSeq, newSeq = strings.ToUpper(oldSeq), strings.ToUpper(newSeq)
+	shortest := len(oldSeq)
+	if len(newSeq) < shortest {
+		shortest = len(newSeq)
+	}
+
+	prefix := 0
+	for prefix < shortest && oldSeq[prefix] == newSeq[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < shortest-prefix && oldSeq[len(oldSeq)-1-suffix] == newSeq[len(newSeq)-1-suffix] {
+		suffix++
+	}
+
+	return targetDiff{
+		start:  prefix,
+		oldEnd: len(oldSeq) - suffix,
+		newEnd: len(newSeq) - suffix,
+		oldLen: len(oldSeq),
+		newLen: len(newSeq),
+	}
+}
+
+// unchanged returns whether the old and new targets are the same
+func (d targetDiff) unchanged() bool {
+	return d.start == d.oldEnd && d.start == d.newEnd
+}
+
+// targetCopies returns the number of copies of the target in BLAST queries, 2 if it's circular
+func targetCopies(circular bool) int {
+	if circular {
+		return 2
+	}
+	return 1
+}
+
+// window returns the range of the new target, with flanks, to BLAST for matches in its
+// changed region. It's in the doubled target if circular. It's false if the range
+// is the whole target, so there's nothing to save by BLASTing only the range
+func (d targetDiff) window(circular bool) (start, end int, ok bool) {
+	start, end = d.start-diffFlankLength, d.newEnd+diffFlankLength
+	if circular {
+		if start < 0 {
+			start, end = start+d.newLen, end+d.newLen
+		}
+		if end > 2*d.newLen {
+			end = 2 * d.newLen
+		}
+	} else {
+		if start < 0 {
+			start = 0
+		}
+		if end > d.newLen {
+			end = d.newLen
+		}
+	}
+	return start, end, end-start < d.newLen
+}
+
+// reuseMatches returns the matches of the old target outside its changed region, moved to
+// where they are in the new target
+func (d targetDiff) reuseMatches(matches []match, newSeq string, circular bool, leftMargin int) (reused []match) {
+	shift := d.newLen - d.oldLen
+	for _, m := range matches {
+		changed, offset := false, 0
+		for c := 0; c < targetCopies(circular); c++ {
+			start, end := d.start+c*d.oldLen, d.oldEnd+c*d.oldLen
+			if m.queryStart < end && m.queryEnd >= start {
+				changed = true
+				break
+			}
+			if end <= m.queryStart {
+				offset += shift
+			}
+		}
+		if changed {
+			continue
+		}
+
+		m.queryStart += offset
+		m.queryEnd += offset
+		if m, ok := d.placeMatch(m, newSeq, circular, leftMargin); ok {
+			reused = append(reused, m)
+		}
+	}
+	return reused
+}
+
+// windowMatches returns the matches, from a BLAST of the window starting at windowStart,
+// that reach into the changed region of the new target. The others are reused from the old
+// target. If the target is circular, they're in both copies of the doubled target like the
+// matches of a BLAST of the whole target
+func (d targetDiff) windowMatches(matches []match, windowStart int, newSeq string, circular bool, leftMargin int) (changed []match) {
+	for _, m := range matches {
+		m.queryStart += windowStart
+		m.queryEnd += windowStart
+		if circular && m.queryStart >= d.newLen {
+			m.queryStart -= d.newLen
+			m.queryEnd -= d.newLen
+		}
+
+		inChange := false
+		for c := 0; c < targetCopies(circular); c++ {
+			if m.queryStart < d.newEnd+c*d.newLen && m.queryEnd >= d.start+c*d.newLen {
+				inChange = true
+			}
+		}
+		if !inChange {
+			continue
+		}
+
+		for c := 0; c < targetCopies(circular); c++ {
+			cp := m
+			cp.queryStart += c * d.newLen
+			cp.queryEnd += c * d.newLen
+			if cp, ok := d.placeMatch(cp, newSeq, circular, leftMargin); ok {
+				changed = append(changed, cp)
+			}
+		}
+	}
+	return changed
+}
+
+// placeMatch updates the query sequence and unique ID of a match moved in the new target.
+// It's false if the match is outside the target or, as when parsing BLAST's output,
+// starts in the left margin of a circular target
+func (d targetDiff) placeMatch(m match, newSeq string, circular bool, leftMargin int) (match, bool) {
+	if m.queryStart < 0 || m.queryEnd >= targetCopies(circular)*d.newLen {
+		return m, false
+	}
+	if circular && m.queryStart < leftMargin {
+		return m, false
+	}
+	m.querySeq = (newSeq + newSeq)[m.queryStart : m.queryEnd+1]
+	m.uniqueID = m.matchID(d.newLen)
+	return m, true
+}
+
+// diffMatches returns the matches of a target that changed since a previous design against
+// oldTarget: the cached matches of oldTarget outside the changed region, and the matches of
+// a BLAST of just the changed region and its flanks. It's false if oldTarget's BLAST output
+// isn't cached or the changed region is most of the target, so it has to be BLASTed whole.
+// Only BLAST is limited to the changed region. The assemblies and their primers are still
+// made across the whole target, and reused from a previous plan only with --reuse-from
+func diffMatches(
+	oldTarget string,
+	target *Frag,
+	circular bool,
+	leftMargin int,
+	dbs []DB,
+	filters []string,
+	filter filterExpr,
+	identity int,
+	ungapped bool,
+	conf *config.Config,
+) ([]match, bool, error) {
+	oldFrags, err := read(oldTarget, false, false)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read the previous target from %s: %v", oldTarget, err)
+	}
+	old := oldFrags[0]
+
+	diff := diffTargets(old.Seq, target.Seq)
+	windowStart, windowEnd, ok := diff.window(circular)
+	if !ok && !diff.unchanged() {
+		rlog.Infof("most of %s changed since %s, BLASTing all of it", target.ID, oldTarget)
+		return nil, false, nil
+	}
+
+	oldMatches, err := blastDBs(old.ID, old.Seq, circular, leftMargin, dbs, filters, filter, identity, ungapped, conf, true)
+	if err == errNotCached {
+		rlog.Infof("no cached BLAST output of %s from %s, BLASTing all of %s", old.ID, oldTarget, target.ID)
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	matches := diff.reuseMatches(oldMatches, target.Seq, circular, leftMargin)
+
+	if diff.unchanged() {
+		rlog.Infof("%s is unchanged since %s, reusing its %d matches", target.ID, oldTarget, len(matches))
+		return matches, true, nil
+	}
+
+	windowSeq := (target.Seq + target.Seq)[windowStart:windowEnd]
+	windowMatches, err := blast(target.ID+"-changed", windowSeq, false, 0, dbs, filters, filter, identity, ungapped, conf)
+	if err != nil {
+		return nil, false, err
+	}
+	rlog.Infof("%s changed at %d-%d since %s, BLASTed %dbp of its %dbp and reused %d matches",
+		target.ID, diff.start+1, diff.newEnd, oldTarget, windowEnd-windowStart, diff.newLen, len(matches))
+	matches = append(matches, diff.windowMatches(windowMatches, windowStart, target.Seq, circular, leftMargin)...)
+	return matches, true, nil
+}
@@ -0,0 +1,82 @@
+package repp
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_diffTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     targetDiff
+	}{
+		{"unchanged", "ATGCATGC", "atgcatgc", targetDiff{8, 8, 8, 8, 8}},
+		{"substitution", "AAAACCCCGGGG", "AAAACTCCGGGG", targetDiff{5, 6, 6, 12, 12}},
+		{"insertion", "AAAAGGGG", "AAAATTGGGG", targetDiff{4, 4, 6, 8, 10}},
+		{"deletion", "AAAATTGGGG", "AAAAGGGG", targetDiff{4, 6, 4, 10, 8}},
+		{"repeated bases", "AAAAAAAA", "AAAAAAAAA", targetDiff{8, 8, 9, 8, 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffTargets(tt.old, tt.new); got != tt.want {
+				t.Errorf("diffTargets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_targetDiff_reuseMatches(t *testing.T) {
+	left, right := randomSeq(1000, 1), randomSeq(1000, 2)
+	oldSeq := left + "ACGT" + right
+	newSeq := left + "TTTTTTTT" + right
+	diff := diffTargets(oldSeq, newSeq)
+
+	matches := []match{
+		{entry: "before", queryStart: 100, queryEnd: 899},
+		{entry: "spanning", queryStart: 900, queryEnd: 1100},
+		{entry: "after", queryStart: 1200, queryEnd: 1899},
+		{entry: "across-zero", queryStart: 1500, queryEnd: 2300},
+		{entry: "second-copy", queryStart: 3100, queryEnd: 3800},
+	}
+	got := map[string]match{}
+	for _, m := range diff.reuseMatches(matches, newSeq, true, 100) {
+		got[m.entry] = m
+	}
+
+	want := map[string]int{"before": 100, "after": 1204, "across-zero": 1504, "second-copy": 3108}
+	if len(got) != len(want) {
+		t.Fatalf("reuseMatches() = %v, want %v", got, want)
+	}
+	for entry, start := range want {
+		m, ok := got[entry]
+		if !ok || m.queryStart != start {
+			t.Errorf("reuseMatches() %s starts at %d, want %d", entry, m.queryStart, start)
+			continue
+		}
+		if !strings.EqualFold(m.querySeq, (newSeq + newSeq)[m.queryStart:m.queryEnd+1]) {
+			t.Errorf("reuseMatches() %s has the wrong query sequence", entry)
+		}
+	}
+}
+
+func Test_targetDiff_windowMatches(t *testing.T) {
+	newSeq := randomSeq(3000, 1) + "TTTT" + randomSeq(3000, 2)
+	diff := diffTargets(randomSeq(3000, 1)+randomSeq(3000, 2), newSeq)
+	start, end, ok := diff.window(true)
+	if !ok || start != 2500 || end != 3504 {
+		t.Fatalf("window() = %d, %d, %t, want 2500, 3504, true", start, end, ok)
+	}
+
+	matches := []match{
+		{entry: "in-change", queryStart: 400, queryEnd: 700},
+		{entry: "in-flank", queryStart: 0, queryEnd: 300},
+	}
+	got := diff.windowMatches(matches, start, newSeq, true, 100)
+	if len(got) != 2 || got[0].queryStart != 2900 || got[1].queryStart != 2900+diff.newLen {
+		t.Errorf("windowMatches() = %v, want in-change in both copies of the target", got)
+	}
+	if got[0].uniqueID != "in-change-2900+0" {
+		t.Errorf("windowMatches() uniqueID = %s, want in-change-2900+0", got[0].uniqueID)
+	}
+}
@@ -0,0 +1,72 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// overusedTemplate returns the template that more than maxPerTemplate of a solution's
+// fragments come from, and how many come from it. One bad prep of a template sinks
+// every fragment PCR'ed from it. Synthetic fragments have no template and are skipped
+func overusedTemplate(frags []*Frag, maxPerTemplate int) (template string, count int) {
+	counts := make(map[string]int)
+	for _, f := range frags {
+		if f.fragType == synthetic || f.ID == "" {
+			continue
+		}
+		counts[f.ID]++
+	}
+
+	var templates []string
+	for t := range counts {
+		templates = append(templates, t)
+	}
+	sort.Strings(templates)
+	for _, t := range templates {
+		if counts[t] > count {
+			template, count = t, counts[t]
+		}
+	}
+	if count <= maxPerTemplate {
+		return "", 0
+	}
+	return template, count
+}
+
+// templateLimited returns the filled assemblies with at most maxPerTemplate fragments
+// from any one template, and those discarded for having more
+func templateLimited(assemblies []*assembly, maxPerTemplate int) (kept, discarded []*assembly) {
+	for _, a := range assemblies {
+		if template, count := overusedTemplate(a.frags, maxPerTemplate); count > 0 {
+			rlog.Debugf("Discard %v: %d fragments are from %s", a, count, template)
+			discarded = append(discarded, a)
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept, discarded
+}
+
+// templateLimitChange returns how the limit of fragments per template changed the top
+// solution: the best of the discarded assemblies that would have been picked instead,
+// and the cost of avoiding it. It's empty if the top solution would've been the same
+func templateLimitChange(top *assembly, discarded []*assembly, maxPerTemplate int) string {
+	var best *assembly
+	for _, a := range discarded {
+		if best == nil || a.isBetterThan(*best) {
+			best = a
+		}
+	}
+	if best == nil || top != nil && !best.isBetterThan(*top) {
+		return ""
+	}
+
+	template, count := overusedTemplate(best.frags, maxPerTemplate)
+	if top == nil {
+		return fmt.Sprintf("no solution has at most %d fragments per template. The best has %d fragments from %s",
+			maxPerTemplate, count, template)
+	}
+	return fmt.Sprintf("the top solution has at most %d fragments per template: %d fragments for $%.2f, $%.2f more "+
+		"than the %d fragments for $%.2f with %d fragments from %s",
+		maxPerTemplate, top.len(), top.cost, top.cost-best.cost, best.len(), best.cost, count, template)
+}
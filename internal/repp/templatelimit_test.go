@@ -0,0 +1,54 @@
+package repp
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_templateLimited(t *testing.T) {
+	shared := &assembly{
+		frags: []*Frag{
+			{ID: "pUC19", fragType: pcr},
+			{ID: "pUC19", fragType: pcr},
+			{ID: "pUC19", fragType: pcr},
+		},
+		cost: 100, adjustedCost: 100,
+	}
+	spread := &assembly{
+		frags: []*Frag{
+			{ID: "pUC19", fragType: pcr},
+			{ID: "pUC19", fragType: pcr},
+			{ID: "pSB1C3", fragType: pcr},
+		},
+		cost: 130, adjustedCost: 130,
+	}
+	synthesized := &assembly{
+		frags: []*Frag{
+			{ID: "pUC19", fragType: pcr},
+			{ID: "synth-1", fragType: synthetic},
+			{ID: "synth-2", fragType: synthetic},
+		},
+		cost: 400, adjustedCost: 400, synths: 2,
+	}
+
+	kept, discarded := templateLimited([]*assembly{shared, spread, synthesized}, 2)
+	if len(kept) != 2 || kept[0] != spread || kept[1] != synthesized {
+		t.Errorf("templateLimited() kept %v, want the spread and synthesized assemblies", kept)
+	}
+	if len(discarded) != 1 || discarded[0] != shared {
+		t.Fatalf("templateLimited() discarded %v, want the shared assembly", discarded)
+	}
+
+	change := templateLimitChange(spread, discarded, 2)
+	for _, want := range []string{"$130.00", "$30.00 more", "3 fragments from pUC19"} {
+		if !strings.Contains(change, want) {
+			t.Errorf("templateLimitChange() = %q, want %q", change, want)
+		}
+	}
+	if change := templateLimitChange(spread, []*assembly{synthesized}, 2); change != "" {
+		t.Errorf("templateLimitChange() = %q for a worse discarded assembly, want none", change)
+	}
+	if change := templateLimitChange(nil, discarded, 2); !strings.Contains(change, "no solution") {
+		t.Errorf("templateLimitChange() = %q without a solution", change)
+	}
+}
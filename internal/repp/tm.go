@@ -0,0 +1,79 @@
+package repp
+
+import (
+	"math"
+	"strings"
+)
+
+// nearestNeighbor is the enthalpy (kcal/mol) and entropy (cal/K/mol) of a
+// dinucleotide stack, from SantaLucia's 1998 unified parameters
+type nearestNeighbor struct {
+	dh, ds float64
+}
+
+// nearestNeighbors are the stacks of each top strand dinucleotide
+var nearestNeighbors = map[string]nearestNeighbor{
+	"AA": {-7.9, -22.2}, "TT": {-7.9, -22.2},
+	"AT": {-7.2, -20.4},
+	"TA": {-7.2, -21.3},
+	"CA": {-8.5, -22.7}, "TG": {-8.5, -22.7},
+	"GT": {-8.4, -22.4}, "AC": {-8.4, -22.4},
+	"CT": {-7.8, -21.0}, "AG": {-7.8, -21.0},
+	"GA": {-8.2, -22.2}, "TC": {-8.2, -22.2},
+	"CG": {-10.6, -27.2},
+	"GC": {-9.8, -24.4},
+	"GG": {-8.0, -19.9}, "CC": {-8.0, -19.9},
+}
+
+const (
+	// tmMonovalent is the molar concentration of monovalent cations, primer3's default
+	tmMonovalent = 0.05
+
+	// tmDivalent is the molar concentration of divalent cations, primer3's default
+	tmDivalent = 0.0015
+
+	// tmDNTP is the molar concentration of dNTPs, primer3's default
+	tmDNTP = 0.0006
+
+	// tmOligo is the molar concentration of the primer, primer3's default
+	tmOligo = 50e-9
+)
+
+// primerTm returns the melting temperature of a primer against its perfect complement
+// with the nearest neighbor method and primer3's default salt and oligo concentrations.
+// It's for comparing primers' Tms without shelling out to primer3.
+func primerTm(seq string) float64 {
+	seq = strings.ToUpper(seq)
+	if len(seq) < 2 {
+		return 0
+	}
+
+	// initiation at each terminal pair
+	dh, ds := 0.0, 0.0
+	for _, end := range []byte{seq[0], seq[len(seq)-1]} {
+		if end == 'G' || end == 'C' {
+			dh, ds = dh+0.1, ds-2.8
+		} else {
+			dh, ds = dh+2.3, ds+4.1
+		}
+	}
+
+	for i := 0; i+1 < len(seq); i++ {
+		nn, ok := nearestNeighbors[seq[i:i+2]]
+		if !ok {
+			continue // ambiguous base
+		}
+		dh += nn.dh
+		ds += nn.ds
+	}
+
+	// divalent cations as equivalent monovalent ones (von Ahsen et al., 2001)
+	sodium := tmMonovalent
+	if tmDivalent > tmDNTP {
+		sodium += 120 * math.Sqrt((tmDivalent-tmDNTP)*1000) / 1000
+	}
+	ds += 0.368 * float64(len(seq)-1) * math.Log(sodium)
+
+	const gasConstant = 1.987 // cal/K/mol
+	return dh*1000/(ds+gasConstant*math.Log(tmOligo/4)) - 273.15
+}
@@ -0,0 +1,27 @@
+package repp
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_primerTm(t *testing.T) {
+	tests := []struct {
+		name string
+		seq  string
+		want float64
+	}{
+		{"M13 reverse", "AGCGGATAACAATTTCACACAGGA", 60.8},
+		{"M13 forward", "GTAAAACGACGGCCAGT", 54.7},
+		{"T7 promoter", "TAATACGACTCACTATAGGG", 50.3},
+		{"lower case", "taatacgactcactataggg", 50.3},
+		{"too short", "A", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := primerTm(tt.seq); math.Abs(got-tt.want) > 0.1 {
+				t.Errorf("primerTm() = %.2f, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
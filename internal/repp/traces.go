@@ -0,0 +1,511 @@
+package repp
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// traceMinAlignment is the shortest alignment (bp) of a read against the expected sequence
+// that's used to verify it. Shorter ones are more likely to be spurious
+const traceMinAlignment = 50
+
+// traceMinIdentity is the lowest identity of a read's alignment against the expected
+// sequence that's used to verify it. Lower ones are reads of something else, ex: a template
+const traceMinIdentity = 0.9
+
+// Verification is how a solution's build was checked against its sequencing reads
+type Verification struct {
+	// Time of the verification, ex: "2018-01-01 20:41:00"
+	Time string `json:"time"`
+
+	// Reads that aligned to the expected sequence
+	Reads []string `json:"reads"`
+
+	// Coverage is the fraction, from 0 to 1, of the expected sequence covered by the reads
+	Coverage float64 `json:"coverage"`
+
+	// Junctions of the solution's fragments and the reads that span them
+	Junctions []JunctionCoverage `json:"junctions,omitempty"`
+
+	// Differences between the reads and the expected sequence
+	Differences []SeqDifference `json:"differences,omitempty"`
+
+	// Verified is whether the reads span every junction without differences from the expected sequence
+	Verified bool `json:"verified"`
+}
+
+// JunctionCoverage is a junction of a fragment and the next one, and the reads that span it
+type JunctionCoverage struct {
+	// Fragment is the fragment (1-based) the junction is at the end of
+	Fragment int `json:"fragment"`
+
+	// Start of the junction on the target (1-based)
+	Start int `json:"start"`
+
+	// End of the junction on the target (1-based)
+	End int `json:"end"`
+
+	// Reads that span the junction
+	Reads []string `json:"reads,omitempty"`
+}
+
+// SeqDifference is a difference between the reads of a build and its expected sequence
+type SeqDifference struct {
+	// Position of the difference on the target (1-based). Insertions are before it
+	Position int `json:"position"`
+
+	// Type of the difference: SNV, insertion or deletion
+	Type string `json:"type"`
+
+	// Expected bases, empty for insertions
+	Expected string `json:"expected,omitempty"`
+
+	// Observed bases, empty for deletions
+	Observed string `json:"observed,omitempty"`
+
+	// Reads with the difference
+	Reads []string `json:"reads"`
+
+	// Depth is the number of reads that cover the position
+	Depth int `json:"depth"`
+
+	// Fragment (1-based) the difference is in, 0 if it's in none of them
+	Fragment int `json:"fragment,omitempty"`
+
+	// Region of the design the difference is in: junction, primer tail, primer, template or synthetic
+	Region string `json:"region,omitempty"`
+}
+
+// regions of a design that differences can be in, from the most to the least specific
+const (
+	junctionRegion   = "junction"
+	primerTailRegion = "primer tail"
+	primerRegion     = "primer"
+	templateRegion   = "template"
+	syntheticRegion  = "synthetic"
+)
+
+// designRegion is a stretch [start, end] of the target made by one part of a fragment
+type designRegion struct {
+	start, end int
+	fragment   int
+	kind       string
+}
+
+// contains returns whether the region contains a position of a circular target of a length
+func (r designRegion) contains(pos, length int) bool {
+	return ((pos-r.start)%length+length)%length <= r.end-r.start
+}
+
+// traceRead is a sequencing read of a build, without its low quality ends
+type traceRead struct {
+	name string
+	seq  string
+}
+
+// alignedRead is a read aligned against a build's expected sequence
+type alignedRead struct {
+	name      string
+	alignment readAlignment
+}
+
+// traceExtensions are the extensions of the files of reads, by whether they're ABIF traces
+var traceExtensions = map[string]bool{
+	".ab1":   true,
+	".abi":   true,
+	".fa":    false,
+	".fasta": false,
+	".fas":   false,
+	".fna":   false,
+	".seq":   false,
+}
+
+// readTraces reads the Sanger reads in a directory: ABIF traces, quality trimmed, and FASTA or
+// plain sequence files. Reads are named after their file, or their FASTA header if a file has several
+func readTraces(dir string) (reads []traceRead, err error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		abif, ok := traceExtensions[ext]
+		if file.IsDir() || !ok {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		name := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+
+		if abif {
+			seq, quals, err := readAB1(path)
+			if err != nil {
+				return nil, err
+			}
+			start, end := mottTrim(quals, len(seq))
+			reads = append(reads, traceRead{name: name, seq: strings.ToUpper(seq[start:end])})
+			continue
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(strings.TrimSpace(string(contents)), ">") {
+			reads = append(reads, traceRead{name: name, seq: strings.ToUpper(strings.Join(strings.Fields(string(contents)), ""))})
+			continue
+		}
+		var fileReads []traceRead
+		if err = scanFasta(path, func(header, seq string) {
+			fileReads = append(fileReads, traceRead{name: strings.Fields(header + " " + name)[0], seq: seq})
+		}); err != nil {
+			return nil, err
+		}
+		if len(fileReads) == 1 {
+			fileReads[0].name = name
+		}
+		reads = append(reads, fileReads...)
+	}
+	return reads, nil
+}
+
+// alignReads aligns reads against a build's expected, circular, sequence. Reads without a
+// long enough alignment at traceMinIdentity are left out with a warning
+func alignReads(reads []traceRead, targetSeq string) (aligned []alignedRead) {
+	aligner := newSeqAligner(targetSeq, true)
+	for _, r := range reads {
+		a, ok := aligner.align(r.seq)
+		if !ok || a.end-a.start < traceMinAlignment || a.identity() < traceMinIdentity {
+			rlog.Warnf("%s doesn't align to the expected sequence, it's left out", r.name)
+			continue
+		}
+		aligned = append(aligned, alignedRead{name: r.name, alignment: a})
+	}
+	return aligned
+}
+
+// alignConsensus aligns whole-plasmid consensus sequences, ex: from nanopore sequencing,
+// against a build's expected, circular, sequence. Each is rotated to start at the
+// expected sequence's zero-index, and oriented like it, before it's aligned end to end
+func alignConsensus(consensus []traceRead, targetSeq string) (aligned []alignedRead) {
+	length := len(targetSeq)
+	aligner := newSeqAligner(targetSeq, true)
+	for _, c := range consensus {
+		placed, ok := aligner.align(c.seq)
+		if !ok || placed.identity() < traceMinIdentity {
+			rlog.Warnf("%s doesn't align to the expected sequence, it's left out", c.name)
+			continue
+		}
+		seq := c.seq
+		if placed.revComp {
+			seq = reverseComplement(seq)
+		}
+		offset := (placed.start - placed.readStart) % length // where the consensus starts on the target
+		origin := ((length-offset)%len(seq) + len(seq)) % len(seq)
+		seq = seq[origin:] + seq[:origin]
+
+		// against the target padded with its other end, so the ends of a consensus rotated
+		// a few bp off the zero-index still align
+		pad := alignBand
+		if diff := len(seq) - length; diff > 0 {
+			pad += diff
+		} else {
+			pad -= diff
+		}
+		if pad > length {
+			pad = length
+		}
+		padded := strings.ToUpper(targetSeq[length-pad:] + targetSeq + targetSeq[:pad])
+		a := bandedLocalAlign(seq, padded, pad, pad)
+		a.revComp = placed.revComp
+
+		// back to the coordinates of the doubled target
+		shift := length - pad
+		a.start, a.end = a.start+shift, a.end+shift
+		for i := range a.diffs {
+			a.diffs[i].pos += shift
+		}
+		rlog.Debugf("%s rotated by %dbp, aligned to %d-%d", c.name, origin, a.start%length+1, (a.end-1)%length+1)
+		aligned = append(aligned, alignedRead{name: c.name, alignment: a})
+	}
+	return aligned
+}
+
+// productSpans returns where on the target, doubled, each fragment of a solution read
+// from a JSON output makes: its PCR product or, if that isn't in the target, its sequence.
+// Each span starts at or after the previous one. Fragments that aren't in the target
+// have an empty span
+func productSpans(s Solution, targetSeq string) (spans []ranged) {
+	template := newCircularSeq(targetSeq)
+	prevStart := 0
+	for _, f := range s.Fragments {
+		span := ranged{start: -1, end: -1}
+		for _, seq := range []string{f.PCRSeq, f.Seq} {
+			seq = strings.ToUpper(seq)
+			if seq == "" || len(seq) > len(targetSeq) {
+				continue
+			}
+			i := template.indexFrom(seq, 0)
+			if i < 0 {
+				i = template.indexFrom(reverseComplement(seq), 0)
+			}
+			if i >= 0 {
+				for i < prevStart {
+					i += len(targetSeq)
+				}
+				span = ranged{start: i, end: i + len(seq) - 1}
+				prevStart = i
+				break
+			}
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// solutionJunctions returns the junctions of a solution's fragments: the overlaps of
+// neighboring fragments' products. The last fragment overlaps the first one shifted
+// by the target's length. Their coordinates are 0-based, on the doubled target
+func solutionJunctions(s Solution, targetSeq string) (junctions []JunctionCoverage) {
+	spans := productSpans(s, targetSeq)
+	if len(spans) < 2 {
+		return nil
+	}
+	for i, span := range spans {
+		next := spans[(i+1)%len(spans)]
+		if span.start < 0 || next.start < 0 {
+			continue
+		}
+		for next.start < span.start {
+			next.start += len(targetSeq)
+		}
+		if span.end >= next.start {
+			junctions = append(junctions, JunctionCoverage{Fragment: i + 1, Start: next.start, End: span.end})
+		}
+	}
+	return junctions
+}
+
+// spans returns whether an aligned read spans a stretch [start, end] of a circular
+// sequence of a length, in the coordinates of the doubled sequence
+func (r alignedRead) spans(start, end, length int) bool {
+	for _, shift := range []int{-length, 0, length} {
+		if r.alignment.start <= start+shift && end+shift < r.alignment.end {
+			return true
+		}
+	}
+	return false
+}
+
+// solutionRegions returns the regions of the target made by each part of a solution's
+// fragments: their junctions, the primer tails and primers of PCR fragments, and the
+// templates and synthetic fragments they're made from. Most specific first
+func solutionRegions(s Solution, targetSeq string) (regions []designRegion) {
+	for _, j := range solutionJunctions(s, targetSeq) {
+		regions = append(regions, designRegion{start: j.Start, end: j.End, fragment: j.Fragment, kind: junctionRegion})
+	}
+
+	located := make(map[string]*Frag)
+	for _, f := range locateFragments(s, targetSeq) {
+		located[f.ID+f.Seq] = f
+	}
+	var primers, templates []designRegion
+	for i, span := range productSpans(s, targetSeq) {
+		f := s.Fragments[i]
+		if span.start < 0 {
+			continue
+		}
+		lf, ok := located[f.ID+f.Seq]
+		if f.fragType == synthetic {
+			templates = append(templates, designRegion{start: span.start, end: span.end, fragment: i + 1, kind: syntheticRegion})
+			continue
+		} else if !ok {
+			templates = append(templates, designRegion{start: span.start, end: span.end, fragment: i + 1, kind: templateRegion})
+			continue
+		}
+		// the product past the template's ends is from the primers' tails
+		template := designRegion{start: lf.start, end: lf.end, fragment: i + 1, kind: templateRegion}
+		for template.start < span.start {
+			template.start, template.end = template.start+len(targetSeq), template.end+len(targetSeq)
+		}
+		if template.start > span.start {
+			regions = append(regions, designRegion{start: span.start, end: template.start - 1, fragment: i + 1, kind: primerTailRegion})
+		}
+		if template.end < span.end {
+			regions = append(regions, designRegion{start: template.end + 1, end: span.end, fragment: i + 1, kind: primerTailRegion})
+		}
+		for _, p := range lf.Primers {
+			if p.Strand {
+				primers = append(primers, designRegion{start: p.Range.start, end: p.Range.start + len(p.Seq) - 1, fragment: i + 1, kind: primerRegion})
+			} else {
+				primers = append(primers, designRegion{start: p.Range.end - len(p.Seq) + 1, end: p.Range.end, fragment: i + 1, kind: primerRegion})
+			}
+		}
+		templates = append(templates, template)
+	}
+	regions = append(regions, primers...)
+	return append(regions, templates...)
+}
+
+// verifySolution checks the reads of a build of a solution against the target: how much of
+// the target they cover, whether they span the solution's junctions, and how they differ
+func verifySolution(s Solution, targetSeq string, reads []alignedRead) *Verification {
+	length := len(targetSeq)
+	v := &Verification{Time: outputTime(time.Now())}
+	depth := make([]int, length)
+	diffs := make(map[string]*SeqDifference)
+	for _, r := range reads {
+		v.Reads = append(v.Reads, r.name)
+		a := r.alignment
+		for p := a.start; p < a.end && p < a.start+length; p++ {
+			depth[p%length]++
+		}
+		for _, d := range a.diffs {
+			key := fmt.Sprintf("%d %s %s", d.pos%length, d.ref, d.read)
+			if diff, ok := diffs[key]; ok {
+				diff.Reads = append(diff.Reads, r.name)
+				continue
+			}
+			diffs[key] = &SeqDifference{
+				Position: d.pos%length + 1,
+				Type:     d.kind(),
+				Expected: d.ref,
+				Observed: d.read,
+				Reads:    []string{r.name},
+			}
+		}
+	}
+
+	covered := 0
+	for _, d := range depth {
+		if d > 0 {
+			covered++
+		}
+	}
+	if length > 0 {
+		v.Coverage = math.Round(1000*float64(covered)/float64(length)) / 1000
+	}
+
+	regions := solutionRegions(s, targetSeq)
+	for _, d := range diffs {
+		d.Depth = depth[d.Position-1]
+		for _, r := range regions {
+			if r.contains(d.Position-1, length) {
+				d.Fragment, d.Region = r.fragment, r.kind
+				break
+			}
+		}
+		v.Differences = append(v.Differences, *d)
+	}
+	sort.Slice(v.Differences, func(i, j int) bool {
+		return v.Differences[i].Position < v.Differences[j].Position
+	})
+
+	spanned := true
+	for _, j := range solutionJunctions(s, targetSeq) {
+		for _, r := range reads {
+			if r.spans(j.Start, j.End, length) {
+				j.Reads = append(j.Reads, r.name)
+			}
+		}
+		spanned = spanned && len(j.Reads) > 0
+		j.Start, j.End = j.Start%length+1, j.End%length+1
+		v.Junctions = append(v.Junctions, j)
+	}
+	v.Verified = len(reads) > 0 && spanned && len(v.Differences) == 0
+	return v
+}
+
+// VerifyBuild checks the sequencing of builds against the expected sequence of a JSON
+// output's solutions: Sanger reads, ABIF traces or FASTA files in a directory, and
+// whole-plasmid consensus sequences in a FASTA file, ex: from nanopore sequencing. Either
+// can be empty. Each solution's verification, its junctions' coverage and the differences
+// from its sequence, are printed and written to the output. solution is the solution
+// (1-based) that was built, 0 for all of them. It exits with an error if none of the
+// verified solutions are confirmed by the reads
+func VerifyBuild(tracesDir, consensusFilename, planFilename string, solution int) {
+	out, err := readOutput(planFilename)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if out.TargetSeq == "" || len(out.Solutions) == 0 {
+		rlog.Fatalf("%s has no target sequence or solutions to verify", planFilename)
+	}
+	if solution < 0 || solution > len(out.Solutions) {
+		rlog.Fatalf("%s has %d solutions, there's no solution %d", planFilename, len(out.Solutions), solution)
+	}
+
+	var aligned []alignedRead
+	if tracesDir != "" {
+		reads, err := readTraces(tracesDir)
+		if err != nil {
+			rlog.Fatalf("failed to read the traces in %s: %v", tracesDir, err)
+		}
+		if len(reads) == 0 {
+			rlog.Fatalf("no .ab1 or FASTA reads in %s", tracesDir)
+		}
+		aligned = alignReads(reads, out.TargetSeq)
+	}
+	if consensusFilename != "" {
+		var consensus []traceRead
+		if err = scanFasta(consensusFilename, func(header, seq string) {
+			consensus = append(consensus, traceRead{name: strings.Fields(header + " consensus")[0], seq: seq})
+		}); err != nil {
+			rlog.Fatalf("failed to read the consensus in %s: %v", consensusFilename, err)
+		}
+		if len(consensus) == 0 {
+			rlog.Fatalf("no consensus sequences in %s", consensusFilename)
+		}
+		aligned = append(aligned, alignConsensus(consensus, out.TargetSeq)...)
+	}
+
+	verified := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	for i := range out.Solutions {
+		if solution != 0 && i+1 != solution {
+			continue
+		}
+		v := verifySolution(out.Solutions[i], out.TargetSeq, aligned)
+		out.Solutions[i].Verification = v
+		if v.Verified {
+			verified++
+		}
+
+		fmt.Fprintf(w, "solution %d\treads: %d\tcoverage: %.1f%%\tverified: %t\n", i+1, len(v.Reads), 100*v.Coverage, v.Verified)
+		for _, j := range v.Junctions {
+			spanning := strings.Join(j.Reads, ",")
+			if spanning == "" {
+				spanning = "not spanned"
+			}
+			fmt.Fprintf(w, "  junction %d\t%d-%d\t%s\t\n", j.Fragment, j.Start, j.End, spanning)
+		}
+		for _, d := range v.Differences {
+			region := d.Region
+			if d.Fragment > 0 {
+				region = fmt.Sprintf("fragment %d %s", d.Fragment, d.Region)
+			}
+			fmt.Fprintf(w, "  %s\t%d\t%s>%s\t%s\t%s (%d/%d reads)\n", d.Type, d.Position, orDash(d.Expected), orDash(d.Observed), orDash(region), strings.Join(d.Reads, ","), len(d.Reads), d.Depth)
+		}
+	}
+	w.Flush()
+
+	if err = writeJSON(planFilename, out); err != nil {
+		rlog.Fatal(err)
+	}
+	if verified == 0 {
+		rlog.Fatalf("the sequencing doesn't verify %s", planFilename)
+	}
+}
+
+// orDash returns the bases, or a dash if there are none
+func orDash(bases string) string {
+	if bases == "" {
+		return "-"
+	}
+	return bases
+}
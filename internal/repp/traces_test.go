@@ -0,0 +1,109 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_verifySolution(t *testing.T) {
+	target := randomSeq(3000, 5)
+	// two fragments, with 30bp junctions at 1000-1029 and across the zero-index at 2990-19
+	s := Solution{Fragments: []*Frag{
+		{ID: "frag1", PCRSeq: target[:1030]},
+		{ID: "frag2", PCRSeq: reverseComplement(target[1000:] + target[:20])},
+	}}
+
+	dir := t.TempDir()
+	quals := make([]int, 700)
+	for i := range quals {
+		quals[i] = 40
+	}
+	for i := 0; i < 20; i++ {
+		quals[i], quals[len(quals)-1-i] = 5, 5 // low quality ends are trimmed
+	}
+	writeTestAB1(t, filepath.Join(dir, "junction1.ab1"), target[650:1350], quals)
+	mutant := target[2700:] + target[:400]
+	mutant = mutant[:500] + map[byte]string{'A': "C", 'C': "G", 'G': "T", 'T': "A"}[mutant[500]] + mutant[501:] // target[200]
+	if err := os.WriteFile(filepath.Join(dir, "junction2.fasta"), []byte(">junction2\n"+reverseComplement(mutant)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "unrelated.seq"), []byte(randomSeq(600, 6)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a read"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reads, err := readTraces(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reads) != 3 || reads[0].name != "junction1" || len(reads[0].seq) != 660 {
+		t.Fatalf("readTraces() = %d reads, want the 3 reads with the trace trimmed", len(reads))
+	}
+	aligned := alignReads(reads, target)
+	if len(aligned) != 2 {
+		t.Fatalf("alignReads() aligned %d reads, want 2", len(aligned))
+	}
+
+	v := verifySolution(s, target, aligned)
+	wantJunctions := []JunctionCoverage{
+		{Fragment: 1, Start: 1001, End: 1030, Reads: []string{"junction1"}},
+		{Fragment: 2, Start: 1, End: 20, Reads: []string{"junction2"}},
+	}
+	if !reflect.DeepEqual(v.Junctions, wantJunctions) {
+		t.Errorf("verifySolution() junctions = %+v, want %+v", v.Junctions, wantJunctions)
+	}
+	if len(v.Differences) != 1 || v.Differences[0].Position != 201 || v.Differences[0].Type != "SNV" || v.Differences[0].Depth != 1 {
+		t.Errorf("verifySolution() differences = %+v, want the SNV at 201", v.Differences)
+	}
+	if v.Verified {
+		t.Errorf("verifySolution() verified a build with an SNV")
+	}
+	if v.Coverage != 0.453 {
+		t.Errorf("verifySolution() coverage = %v, want 0.453", v.Coverage)
+	}
+
+	// without the mutant read, the second junction isn't spanned
+	if v = verifySolution(s, target, aligned[:1]); v.Verified || len(v.Junctions[1].Reads) != 0 {
+		t.Errorf("verifySolution() = %+v, want the second junction not spanned", v)
+	}
+}
+
+func Test_alignConsensus(t *testing.T) {
+	target := randomSeq(3000, 7)
+	fwd := target[:25]
+	rev := reverseComplement(target[1005:1030])
+	s := Solution{Fragments: []*Frag{
+		// PCR'ed from a template of 30-1004, with 30bp and 25bp primer tails
+		{ID: "pcr", fragType: pcr, Seq: target[30:1005], PCRSeq: target[:1030], Primers: []Primer{{Seq: fwd, Strand: true}, {Seq: rev}}},
+		{ID: "synth", fragType: synthetic, Seq: target[1000:] + target[:20], PCRSeq: target[1000:] + target[:20]},
+	}}
+
+	// the consensus starts 700bp into the target, on the bottom strand, with a deletion of
+	// target[11] in the junction, an insertion before target[26] in the primer tail and an
+	// SNV at target[500] in the template
+	mutant := []byte(target)
+	mutant[500] = map[byte]byte{'A': 'C', 'C': 'G', 'G': 'T', 'T': 'A'}[mutant[500]]
+	edited := string(mutant[:11]) + string(mutant[12:26]) + "TTT" + string(mutant[26:])
+	consensus := reverseComplement(edited[698:] + edited[:698])
+
+	aligned := alignConsensus([]traceRead{{name: "clone1", seq: consensus}}, target)
+	if len(aligned) != 1 {
+		t.Fatalf("alignConsensus() aligned %d sequences, want 1", len(aligned))
+	}
+	v := verifySolution(s, target, aligned)
+	if v.Coverage != 1 {
+		t.Errorf("verifySolution() coverage = %v, want 1", v.Coverage)
+	}
+	want := []SeqDifference{
+		{Position: 12, Type: "deletion", Expected: target[11:12], Reads: []string{"clone1"}, Depth: 1, Fragment: 2, Region: junctionRegion},
+		{Position: 27, Type: "insertion", Observed: "TTT", Reads: []string{"clone1"}, Depth: 1, Fragment: 1, Region: primerTailRegion},
+		{Position: 501, Type: "SNV", Expected: target[500:501], Observed: string(mutant[500]), Reads: []string{"clone1"}, Depth: 1, Fragment: 1, Region: templateRegion},
+	}
+	if !reflect.DeepEqual(v.Differences, want) {
+		t.Errorf("verifySolution() differences = %+v, want %+v", v.Differences, want)
+	}
+}
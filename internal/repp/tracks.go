@@ -0,0 +1,195 @@
+package repp
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// trackFeature is a stretch of a solution positioned on the target, for BED and GFF3 tracks
+type trackFeature struct {
+	// name of the feature in the track
+	name string
+
+	// kind of feature: a fragment type (pcr, synthetic, linear, plasmid), primer or junction
+	kind string
+
+	// start of the feature on the target (0-indexed). May be negative or past
+	// the end of the target for features that cross the zero index
+	start int
+
+	// end of the feature on the target (inclusive)
+	end int
+
+	// strand of the feature: '+', '-' or '.'
+	strand byte
+}
+
+// gffType maps the kinds of track features to Sequence Ontology terms
+var gffType = map[string]string{
+	"pcr":       "PCR_product",
+	"synthetic": "synthetic_sequence",
+	"primer":    "primer_binding_site",
+	"junction":  "region",
+	"linear":    "region",
+	"plasmid":   "region",
+}
+
+// writeTracks writes a BED or GFF3 track per solution with the solution's fragments,
+// primer binding sites and junctions positioned on the target sequence.
+func writeTracks(filename, format string, out *Output) error {
+	for si, s := range out.Solutions {
+		features := solutionTrackFeatures(s, len(out.TargetSeq))
+		trackName := fmt.Sprintf("%s solution %d", out.Target, si+1)
+
+		var err error
+		switch format {
+		case "BED":
+			err = writeBED(solutionFilename(filename, si+1, ".bed"), trackName, out.Target, len(out.TargetSeq), features)
+		case "GFF":
+			err = writeGFF(solutionFilename(filename, si+1, ".gff3"), out.Target, len(out.TargetSeq), features)
+		default:
+			return fmt.Errorf("unknown track format %s; valid values [BED, GFF]", format)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// solutionFilename returns the name of a per solution file, next to the output file
+func solutionFilename(filename string, solution int, ext string) string {
+	noExt := filename[0 : len(filename)-len(filepath.Ext(filename))]
+	return fmt.Sprintf("%s-solution%d%s", noExt, solution, ext)
+}
+
+// solutionTrackFeatures returns the fragments, primer binding sites and
+// junctions of a solution in the order they're assembled
+func solutionTrackFeatures(s Solution, targetLength int) (features []trackFeature) {
+	for i, f := range s.Fragments {
+		strand := byte('+')
+		if f.revCompFlag {
+			strand = '-'
+		}
+		features = append(features, trackFeature{
+			name:   fmt.Sprintf("fragment-%d %s", i+1, f.ID),
+			kind:   f.fragType.String(),
+			start:  f.start,
+			end:    f.end,
+			strand: strand,
+		})
+
+		for _, p := range f.Primers {
+			if p.Strand {
+				features = append(features, trackFeature{
+					name:   fmt.Sprintf("fragment-%d fwd primer", i+1),
+					kind:   "primer",
+					start:  p.Range.start,
+					end:    p.Range.start + len(p.Seq) - 1,
+					strand: '+',
+				})
+			} else {
+				features = append(features, trackFeature{
+					name:   fmt.Sprintf("fragment-%d rev primer", i+1),
+					kind:   "primer",
+					start:  p.Range.end - len(p.Seq) + 1,
+					end:    p.Range.end,
+					strand: '-',
+				})
+			}
+		}
+	}
+
+	// junctions are the overlaps between neighboring fragments. The last fragment
+	// of a circular assembly overlaps the first one shifted by the target's length
+	for i := 0; i+1 < len(s.Fragments); i++ {
+		features = appendJunction(features, i+1, s.Fragments[i].end, s.Fragments[i+1].start)
+	}
+	if n := len(s.Fragments); n > 1 {
+		features = appendJunction(features, n, s.Fragments[n-1].end, s.Fragments[0].start+targetLength)
+	}
+
+	return features
+}
+
+// appendJunction adds a junction for the overlap of two fragments, if they overlap
+func appendJunction(features []trackFeature, index, prevEnd, nextStart int) []trackFeature {
+	if prevEnd < nextStart {
+		return features
+	}
+	return append(features, trackFeature{
+		name:   fmt.Sprintf("junction-%d", index),
+		kind:   "junction",
+		start:  nextStart,
+		end:    prevEnd,
+		strand: '.',
+	})
+}
+
+// trackSpans splits a feature into the 0-indexed, end exclusive, spans it covers on a
+// circular target of length targetLength, splitting it in two if it crosses the zero index
+func trackSpans(start, end, targetLength int) (spans [][2]int) {
+	if targetLength == 0 || end < start {
+		return nil
+	}
+	if end-start+1 >= targetLength {
+		return [][2]int{{0, targetLength}}
+	}
+
+	length := end - start + 1
+	start = ((start % targetLength) + targetLength) % targetLength
+	if start+length <= targetLength {
+		return [][2]int{{start, start + length}}
+	}
+	return [][2]int{{start, targetLength}, {0, start + length - targetLength}}
+}
+
+// writeBED writes the features to a BED6 track
+func writeBED(filename, trackName, target string, targetLength int, features []trackFeature) (err error) {
+	bedFile, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer bedFile.close(&err)
+
+	w := bufio.NewWriter(bedFile)
+	fmt.Fprintf(w, "track name=\"%s\" description=\"repp fragments, primers and junctions\"\n", trackName)
+	for _, f := range features {
+		name := strings.ReplaceAll(f.name, " ", "_")
+		for _, span := range trackSpans(f.start, f.end, targetLength) {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s:%s\t0\t%c\n", target, span[0], span[1], f.kind, name, f.strand)
+		}
+	}
+
+	err = w.Flush()
+	return err
+}
+
+// writeGFF writes the features to a GFF3 track
+func writeGFF(filename, target string, targetLength int, features []trackFeature) (err error) {
+	gffFile, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer gffFile.close(&err)
+
+	w := bufio.NewWriter(gffFile)
+	fmt.Fprintf(w, "##gff-version 3\n##sequence-region %s 1 %d\n", target, targetLength)
+	for i, f := range features {
+		for _, span := range trackSpans(f.start, f.end, targetLength) {
+			// GFF3 is 1-indexed with inclusive ends. Parts of a split feature share its ID
+			fmt.Fprintf(w, "%s\trepp\t%s\t%d\t%d\t.\t%c\t.\tID=%s-%d;Name=%s;Note=%s\n",
+				target, gffType[f.kind], span[0]+1, span[1], f.strand, f.kind, i+1, gffEscape(f.name), f.kind)
+		}
+	}
+
+	err = w.Flush()
+	return err
+}
+
+// gffEscape escapes the characters reserved in GFF3 attribute values
+func gffEscape(value string) string {
+	return strings.NewReplacer(";", "%3B", "=", "%3D", "&", "%26", ",", "%2C", "\t", "%09").Replace(value)
+}
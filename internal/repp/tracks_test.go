@@ -0,0 +1,56 @@
+package repp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_trackSpans(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end int
+		want       [][2]int
+	}{
+		{"within the target", 10, 19, [][2]int{{10, 20}}},
+		{"past the end of the target", 95, 104, [][2]int{{95, 100}, {0, 5}}},
+		{"before the start of the target", -5, 4, [][2]int{{95, 100}, {0, 5}}},
+		{"shifted by the target length", 110, 119, [][2]int{{10, 20}}},
+		{"whole target", -10, 95, [][2]int{{0, 100}}},
+		{"empty", 10, 9, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trackSpans(tt.start, tt.end, 100); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trackSpans() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_solutionTrackFeatures(t *testing.T) {
+	s := Solution{Fragments: []*Frag{
+		{
+			ID:       "pcr-1",
+			fragType: pcr,
+			start:    -20,
+			end:      60,
+			Primers: []Primer{
+				{Seq: "ACGTACGTACGTACGTACGT", Strand: true, Range: ranged{start: -20, end: 0}},
+				{Seq: "TTTTTGGGGG", Strand: false, Range: ranged{start: 50, end: 60}},
+			},
+		},
+		{ID: "syn-1", fragType: synthetic, start: 40, end: 90},
+	}}
+
+	want := []trackFeature{
+		{name: "fragment-1 pcr-1", kind: "pcr", start: -20, end: 60, strand: '+'},
+		{name: "fragment-1 fwd primer", kind: "primer", start: -20, end: -1, strand: '+'},
+		{name: "fragment-1 rev primer", kind: "primer", start: 51, end: 60, strand: '-'},
+		{name: "fragment-2 syn-1", kind: "synthetic", start: 40, end: 90, strand: '+'},
+		{name: "junction-1", kind: "junction", start: 40, end: 60, strand: '.'},
+		{name: "junction-2", kind: "junction", start: 80, end: 90, strand: '.'},
+	}
+	if got := solutionTrackFeatures(s, 100); !reflect.DeepEqual(got, want) {
+		t.Errorf("solutionTrackFeatures() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package repp
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tsvKVHeader is the header of the flat key-value output
+var tsvKVHeader = []string{"target", "solution", "fragment", "attribute", "value"}
+
+// writeTSVKV writes the solutions as a flat key-value TSV, for LIMS that only ingest
+// key-value records: one row per solution, fragment and attribute. The attribute names
+// are stable and every fragment has all of them, empty if they don't apply. Attributes
+// of the solution itself are in the rows of fragment 0
+func writeTSVKV(filename string, out *Output) error {
+	var contents bytes.Buffer
+	contents.WriteString(strings.Join(tsvKVHeader, "\t") + "\n")
+	write := func(solution, fragment int, attribute, value string) {
+		// values can't break the rows or columns
+		value = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(value)
+		fmt.Fprintf(&contents, "%s\t%d\t%d\t%s\t%s\n", out.Target, solution, fragment, attribute, value)
+	}
+
+	for si, s := range out.Solutions {
+		snumber := si + 1
+		for _, attr := range solutionKVs(s, out.Currency) {
+			write(snumber, 0, attr[0], attr[1])
+		}
+		for fi, f := range s.Fragments {
+			for _, attr := range fragKVs(f) {
+				write(snumber, fi+1, attr[0], attr[1])
+			}
+		}
+	}
+
+	if err := writeFileAtomic(filename, contents.Bytes()); err != nil {
+		return fmt.Errorf("failed to write the output: %v", err)
+	}
+	return nil
+}
+
+// solutionKVs returns the attributes of a solution, in the order they're written
+func solutionKVs(s Solution, currency string) [][2]string {
+	return [][2]string{
+		{"fragment_count", strconv.Itoa(s.Count)},
+		{"cost", strconv.FormatFloat(s.Cost, 'f', 2, 64)},
+		{"adjusted_cost", strconv.FormatFloat(s.AdjustedCost, 'f', 2, 64)},
+		{"currency", currency},
+		{"method", s.Method},
+		{"annealing_temp", formatKVFloat(s.AnnealingTemp, 1)},
+		{"success_score", formatKVFloat(s.SuccessScore, 2)},
+		{"warnings", strings.Join(s.Warnings, "; ")},
+	}
+}
+
+// fragKVs returns the attributes of a fragment, in the order they're written
+func fragKVs(f *Frag) [][2]string {
+	fwd, rev := f.getPrimers()
+	var mismatches []string
+	for _, m := range f.Mismatches {
+		mismatches = append(mismatches, strconv.Itoa(m))
+	}
+	if f.MismatchesUnknown {
+		mismatches = []string{"unknown"}
+	}
+	return [][2]string{
+		{"id", f.ID},
+		{"type", f.Type},
+		{"cost", strconv.FormatFloat(f.Cost, 'f', 2, 64)},
+		{"adjusted_cost", strconv.FormatFloat(f.AdjustedCost, 'f', 2, 64)},
+		{"seq", f.Seq},
+		{"pcr_seq", f.PCRSeq},
+		{"fwd_primer", fwd.Seq},
+		{"fwd_primer_tm", formatKVFloat(fwd.Tm, 2)},
+		{"rev_primer", rev.Seq},
+		{"rev_primer_tm", formatKVFloat(rev.Tm, 2)},
+		{"long_range", strconv.FormatBool(f.LongRange)},
+		{"mismatches", strings.Join(mismatches, ",")},
+		{"warnings", strings.Join(f.Warnings, "; ")},
+	}
+}
+
+// formatKVFloat formats an optional value with a fixed precision, empty if it's not set
+func formatKVFloat(value float64, precision int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', precision, 64)
+}
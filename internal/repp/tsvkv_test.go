@@ -0,0 +1,68 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_writeTSVKV(t *testing.T) {
+	out := &Output{
+		Target:   "target",
+		Currency: "USD",
+		Solutions: []Solution{{
+			Count: 2,
+			Cost:  120.5,
+			Fragments: []*Frag{
+				{
+					ID:       "pTEMPLATE",
+					Type:     "pcr",
+					Cost:     20,
+					Primers:  []Primer{{Seq: "ACGT", Strand: true, Tm: 60}, {Seq: "TTGG", Tm: 61.25}},
+					Warnings: []string{"a primer has a 50.0C off-target\twith a tab"},
+				},
+				{Type: "synthetic", Cost: 100.5, Seq: "ACGTACGT"},
+			},
+		}},
+	}
+
+	filename := filepath.Join(t.TempDir(), "out.tsv")
+	if err := writeTSVKV(filename, out); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+
+	// every fragment has all the attributes
+	wantRows := 1 + len(solutionKVs(Solution{}, "")) + 2*len(fragKVs(&Frag{}))
+	if len(rows) != wantRows {
+		t.Fatalf("writeTSVKV() wrote %d rows, want %d", len(rows), wantRows)
+	}
+	for _, row := range rows {
+		if cols := strings.Split(row, "\t"); len(cols) != len(tsvKVHeader) {
+			t.Errorf("writeTSVKV() row %q has %d columns, want %d", row, len(cols), len(tsvKVHeader))
+		}
+	}
+
+	for _, want := range []string{
+		"target\t1\t0\tcost\t120.50",
+		"target\t1\t0\tcurrency\tUSD",
+		"target\t1\t1\tid\tpTEMPLATE",
+		"target\t1\t1\trev_primer_tm\t61.25",
+		"target\t1\t1\twarnings\ta primer has a 50.0C off-target with a tab",
+		"target\t1\t2\tfwd_primer\t",
+		"target\t1\t2\tseq\tACGTACGT",
+	} {
+		found := false
+		for _, row := range rows {
+			found = found || row == want
+		}
+		if !found {
+			t.Errorf("writeTSVKV() has no row %q", want)
+		}
+	}
+}
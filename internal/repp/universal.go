@@ -0,0 +1,136 @@
+package repp
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+// anchorUniversalPrimers swaps the designed primers of a fragment for universal primers,
+// ex: M13F or T7, so stock primers can be used instead of ordering new ones. A universal
+// primer is used if its binding site is in the template, off template mismatches, where
+// primer3 was free to put that side's primer, and the side needs no 5' tail for homology
+// with its neighbor. Both sides are swapped if they can be, otherwise one is, as long as
+// the pair's Tms are still within the max allowed difference.
+//
+// start and end are the template's range in the target, and addLeft and addRight are the
+// bps of 5' tails the primers need.
+func (p *primer3) anchorUniversalPrimers(primers []Primer, start, end, addLeft, addRight int) {
+	universals := p.config.GetUniversalPrimers()
+	if len(universals) == 0 || len(primers) < 2 {
+		return
+	}
+
+	var left, right *Primer
+	if addLeft == 0 {
+		left = p.universalPrimer(universals, true, start, start+p.leftBuffer, end)
+	}
+	if addRight == 0 {
+		right = p.universalPrimer(universals, false, end-p.rightBuffer, end, start)
+	}
+
+	for _, pair := range [][2]*Primer{{left, right}, {left, nil}, {nil, right}} {
+		if pair[0] == nil && pair[1] == nil {
+			continue
+		}
+
+		swapped := []Primer{primers[0], primers[1]}
+		for side, u := range pair {
+			if u != nil {
+				u.PairPenalty = primers[side].PairPenalty
+				swapped[side] = *u
+			}
+		}
+		if swapped[0].Range.end > swapped[1].Range.start+1 {
+			continue // they overlap
+		}
+		if maxDiff := p.config.PcrMaxFwdRevPrimerTmDiff; maxDiff > 0 && math.Abs(swapped[0].Tm-swapped[1].Tm) > maxDiff {
+			continue
+		}
+		copy(primers, swapped)
+		return
+	}
+}
+
+// universalPrimer returns a universal primer whose binding site is on the target, off the
+// template's mismatches, with its 5' end in [from, to] and its 3' end before limit (after it
+// for right primers). The closest to the template's end is used. It's nil if there's none.
+func (p *primer3) universalPrimer(universals []config.UniversalPrimer, left bool, from, to, limit int) *Primer {
+	template := p.seq + p.seq
+	var best *Primer
+	for _, u := range universals {
+		seq := strings.ToUpper(u.Seq)
+		site := seq
+		if !left {
+			site = reverseComplement(seq)
+		}
+		if site == "" {
+			continue
+		}
+
+		for i := strings.Index(template, site); i >= 0; {
+			first, last := i, i+len(site)-1 // the site's bases, [first, last]
+			var ok bool
+			var r ranged
+			if left {
+				ok = first >= from && first <= to && last <= limit
+				r = ranged{first, last + 1}
+			} else {
+				ok = last >= from && last <= to && first >= limit
+				r = ranged{first - 1, last}
+			}
+			if ok && len(p.mismatchesIn(first, last+1)) == 0 {
+				closer := best == nil || left && r.start < best.Range.start || !left && r.end > best.Range.end
+				if closer {
+					best = &Primer{
+						Seq:           seq,
+						Strand:        left,
+						Tm:            primerTm(seq),
+						GC:            100 * float64(strings.Count(seq, "G")+strings.Count(seq, "C")) / float64(len(seq)),
+						Range:         r,
+						PrimingRegion: seq,
+						Notes:         "universal primer " + u.Name,
+					}
+				}
+			}
+
+			next := strings.Index(template[i+1:], site)
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+	}
+	return best
+}
+
+// universalPrimersUsed returns the names of the universal primers of a solution's fragments
+func universalPrimersUsed(frags []*Frag, conf *config.Config) (names []string) {
+	bySeq := make(map[string]string)
+	for _, u := range conf.GetUniversalPrimers() {
+		bySeq[strings.ToUpper(u.Seq)] = u.Name
+	}
+
+	used := make(map[string]bool)
+	for _, f := range frags {
+		for _, primer := range f.Primers {
+			if name, ok := bySeq[strings.ToUpper(primer.Seq)]; ok && !used[name] {
+				used[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reportUniversalPrimers logs the universal primers each solution uses
+func reportUniversalPrimers(out *Output, conf *config.Config) {
+	for i, s := range out.Solutions {
+		if names := universalPrimersUsed(s.Fragments, conf); len(names) > 0 {
+			rlog.Infof("solution %d uses the universal primers %s", i+1, strings.Join(names, ", "))
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package repp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_primer3_anchorUniversalPrimers(t *testing.T) {
+	m13f, m13r := "GTAAAACGACGGCCAGT", "CAGGAAACAGCTATGAC"
+	target := "AC" + m13f + strings.Repeat("ACGTTGCA", 15) + reverseComplement(m13r) + "GT"
+	end := len(target) - 1
+
+	primers := func() []Primer {
+		left, right := target[0:20], reverseComplement(target[end-19:end+1])
+		return []Primer{
+			{Seq: left, PrimingRegion: left, Strand: true, Range: ranged{0, 20}},
+			{Seq: right, PrimingRegion: right, Range: ranged{end - 20, end}},
+		}
+	}
+
+	tests := []struct {
+		name                string
+		universals          []config.UniversalPrimer
+		buffer              int
+		addLeft, addRight   int
+		mismatches          []int
+		wantLeft, wantRight string
+	}{
+		{"both sides", nil, 5, 0, 0, nil, m13f, m13r},
+		{"left primer needs a tail", nil, 5, 10, 0, nil, target[0:20], m13r},
+		{"sites outside the buffers", nil, 0, 0, 0, nil, target[0:20], reverseComplement(target[end-19 : end+1])},
+		{"template mismatch in a site", nil, 5, 0, 0, []int{10}, target[0:20], m13r},
+		{"universal primers turned off", []config.UniversalPrimer{}, 5, 0, 0, nil, target[0:20], reverseComplement(target[end-19 : end+1])},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.New()
+			c.PcrMaxFwdRevPrimerTmDiff = 0
+			c.UniversalPrimers = tt.universals
+			p := &primer3{seq: target, config: c, templateMismatches: tt.mismatches, leftBuffer: tt.buffer, rightBuffer: tt.buffer}
+
+			got := primers()
+			p.anchorUniversalPrimers(got, 0, end, tt.addLeft, tt.addRight)
+			if got[0].Seq != tt.wantLeft || got[1].Seq != tt.wantRight {
+				t.Errorf("anchorUniversalPrimers() = %s, %s, want %s, %s", got[0].Seq, got[1].Seq, tt.wantLeft, tt.wantRight)
+			}
+
+			if got[0].Seq == m13f {
+				if want := target[got[0].Range.start:got[0].Range.end]; want != m13f || got[0].Notes != "universal primer M13F" {
+					t.Errorf("anchorUniversalPrimers() left primer = %+v, want M13F at %s", got[0], want)
+				}
+			}
+			if got[1].Seq == m13r {
+				if want := reverseComplement(target[got[1].Range.start+1 : got[1].Range.end+1]); want != m13r || got[1].Notes != "universal primer M13R" {
+					t.Errorf("anchorUniversalPrimers() right primer = %+v, want M13R at %s", got[1], want)
+				}
+			}
+		})
+	}
+}
+
+func Test_universalPrimersUsed(t *testing.T) {
+	frags := []*Frag{
+		{Primers: []Primer{{Seq: "TAATACGACTCACTATAGGG"}, {Seq: "ACGTACGTACGTACGTAC"}}},
+		{Primers: []Primer{{Seq: "gtaaaacgacggccagt"}, {Seq: "TAATACGACTCACTATAGGG"}}},
+	}
+	got := universalPrimersUsed(frags, config.New())
+	if want := []string{"M13F", "T7"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("universalPrimersUsed() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,234 @@
+package repp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// polyATMinLength is the shortest poly-A or poly-T tract that's flagged
+	polyATMinLength = 12
+
+	// hairpinMinStem and hairpinMaxLoop are the shortest stem, and longest loop, of the
+	// stem-loops that are flagged as strong secondary structure
+	hairpinMinStem, hairpinMaxLoop = 12, 20
+
+	// invertedRepeatMinStem and invertedRepeatMaxLoop are the shortest arms, and longest
+	// spacer, of the inverted repeats that are flagged. E. coli deletes long ones
+	invertedRepeatMinStem, invertedRepeatMaxLoop = 25, 300
+
+	// ecoliMinus35 and ecoliMinus10 are the consensus boxes of E. coli sigma70 promoters,
+	// ecoliMinSpacer and ecoliMaxSpacer the range of bp between them
+	ecoliMinus35, ecoliMinus10     = "TTGACA", "TATAAT"
+	ecoliMinSpacer, ecoliMaxSpacer = 15, 19
+
+	// ecoliPromoterMaxMismatches is the most mismatches, over both boxes, of a flagged promoter
+	ecoliPromoterMaxMismatches = 1
+)
+
+// kinds of unstable motifs
+const (
+	polyATTract      = "poly-A/T tract"
+	hairpinStem      = "hairpin"
+	invertedRepeat   = "inverted repeat"
+	ecoliPromoterBox = "E. coli promoter"
+)
+
+// unstableMotifRisks are why each kind of unstable motif is flagged
+var unstableMotifRisks = map[string]string{
+	polyATTract:      "polymerases slip on it and synthesis often fails",
+	hairpinStem:      "its secondary structure stalls synthesis and sequencing",
+	invertedRepeat:   "it may be deleted or rearranged in E. coli",
+	ecoliPromoterBox: "transcription from it may be toxic to, or destabilize the plasmid in, E. coli",
+}
+
+// unstableRegions are the unstable motifs of the last design's target, that synthetic fragments'
+// junctions are moved out of if synthetic-avoid-unstable is set in the config
+var unstableRegions []unstableRegion
+
+// unstableRegion is a motif in the target that's known to be unstable or hard to make
+type unstableRegion struct {
+	// start of the region on the target (0-indexed)
+	start int
+
+	// end of the region on the target (exclusive)
+	end int
+
+	// kind of motif, ex: "hairpin"
+	kind string
+}
+
+// structural returns whether the motif is in the DNA's structure, and so a problem for synthesis
+// and junctions, rather than for the cells the plasmid is grown in
+func (r unstableRegion) structural() bool {
+	return r.kind != ecoliPromoterBox
+}
+
+// findUnstableRegions returns the unstable motifs of a sequence, sorted by where they start:
+// poly-A/T tracts, stem-loops, long inverted repeats and E. coli promoters
+func findUnstableRegions(seq string) (regions []unstableRegion) {
+	seq = strings.ToUpper(seq)
+
+	for i := 0; i < len(seq); {
+		j := i
+		for j < len(seq) && seq[j] == seq[i] {
+			j++
+		}
+		if (seq[i] == 'A' || seq[i] == 'T') && j-i >= polyATMinLength {
+			regions = append(regions, unstableRegion{start: i, end: j, kind: polyATTract})
+		}
+		i = j
+	}
+
+	for _, r := range invertedRepeats(seq, hairpinMinStem, hairpinMaxLoop) {
+		regions = append(regions, unstableRegion{start: r[0], end: r[1], kind: hairpinStem})
+	}
+	for _, r := range invertedRepeats(seq, invertedRepeatMinStem, invertedRepeatMaxLoop) {
+		regions = append(regions, unstableRegion{start: r[0], end: r[1], kind: invertedRepeat})
+	}
+
+	for _, r := range ecoliPromoters(seq) {
+		regions = append(regions, unstableRegion{start: r[0], end: r[1], kind: ecoliPromoterBox})
+	}
+	revComp := reverseComplement(seq)
+	for _, r := range ecoliPromoters(revComp) {
+		regions = append(regions, unstableRegion{start: len(seq) - r[1], end: len(seq) - r[0], kind: ecoliPromoterBox})
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].start < regions[j].start
+	})
+	return regions
+}
+
+// invertedRepeats returns the ranges of a sequence with a stem whose reverse complement follows
+// it within maxLoop bp. Overlapping ranges are merged
+func invertedRepeats(seq string, stem, maxLoop int) (ranges [][2]int) {
+	starts := make(map[string][]int)
+	for i := 0; i+stem <= len(seq); i++ {
+		starts[seq[i:i+stem]] = append(starts[seq[i:i+stem]], i)
+	}
+
+	for i := 0; i+stem <= len(seq); i++ {
+		for _, j := range starts[reverseComplement(seq[i:i+stem])] {
+			if loop := j - i - stem; loop >= 0 && loop <= maxLoop {
+				if n := len(ranges); n > 0 && ranges[n-1][1] > i {
+					if j+stem > ranges[n-1][1] {
+						ranges[n-1][1] = j + stem
+					}
+				} else {
+					ranges = append(ranges, [2]int{i, j + stem})
+				}
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+// ecoliPromoters returns the ranges of a sequence's top strand that match the -35 and -10 boxes
+// of E. coli promoters, with ecoliPromoterMaxMismatches between them
+func ecoliPromoters(seq string) (ranges [][2]int) {
+	boxLen := len(ecoliMinus35)
+	for i := 0; i+boxLen <= len(seq); i++ {
+		m35 := mismatchCount(seq[i:i+boxLen], ecoliMinus35)
+		if m35 > ecoliPromoterMaxMismatches {
+			continue
+		}
+		for spacer := ecoliMinSpacer; spacer <= ecoliMaxSpacer; spacer++ {
+			j := i + boxLen + spacer
+			if j+boxLen > len(seq) {
+				break
+			}
+			if m35+mismatchCount(seq[j:j+boxLen], ecoliMinus10) <= ecoliPromoterMaxMismatches {
+				ranges = append(ranges, [2]int{i, j + boxLen})
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+// mismatchCount returns the number of positions at which two equal length sequences differ
+func mismatchCount(a, b string) (mismatches int) {
+	for i := range a {
+		if a[i] != b[i] {
+			mismatches++
+		}
+	}
+	return mismatches
+}
+
+// unstableWarnings returns a warning for each unstable motif in the target
+func unstableWarnings(seq string) (warnings []string) {
+	for _, r := range findUnstableRegions(seq) {
+		warnings = append(warnings, fmt.Sprintf("%dbp %s at %d-%d: %s", r.end-r.start, r.kind, r.start, r.end, unstableMotifRisks[r.kind]))
+	}
+	return warnings
+}
+
+// stabilityWarnings returns a warning for each synthetic fragment of a solution that has a
+// structural unstable motif, which vendors may fail to make, and each junction in one, which may misanneal
+func stabilityWarnings(frags []*Frag, regions []unstableRegion, seqLen int) (warnings []string) {
+	overlaps := func(start, end int) (r unstableRegion, ok bool) {
+		for _, r := range regions {
+			if !r.structural() {
+				continue
+			}
+			for _, offset := range []int{-seqLen, 0, seqLen} {
+				if r.start+offset < end && start < r.end+offset {
+					return r, true
+				}
+			}
+		}
+		return unstableRegion{}, false
+	}
+
+	for _, f := range frags {
+		if f.fragType != synthetic {
+			continue
+		}
+		if r, ok := overlaps(f.start, f.end+1); ok {
+			warnings = append(warnings, fmt.Sprintf("%s is synthesized through the %s at %d-%d, vendors may fail to make it", f.ID, r.kind, r.start, r.end))
+		}
+	}
+
+	if len(frags) < 2 {
+		return warnings
+	}
+	for i, f := range frags {
+		next := frags[(i+1)%len(frags)]
+		junctionStart, junctionEnd := next.start, f.end+1
+		if i == len(frags)-1 {
+			junctionStart += seqLen // the last fragment's junction with the first, across the zero index
+		}
+		if junctionStart >= junctionEnd {
+			continue
+		}
+		if r, ok := overlaps(junctionStart, junctionEnd); ok {
+			warnings = append(warnings, fmt.Sprintf("the junction of %s and %s is in the %s at %d-%d, it may misanneal", f.ID, next.ID, r.kind, r.start, r.end))
+		}
+	}
+	return warnings
+}
+
+// inUnstableRegion returns whether a stretch of the target, ex: a junction, overlaps a structural
+// unstable motif of the last design's target
+func inUnstableRegion(start, end, seqLen int) bool {
+	start, end = start%seqLen, end%seqLen
+	if end < start {
+		end += seqLen
+	}
+	for _, r := range unstableRegions {
+		if !r.structural() {
+			continue
+		}
+		for _, offset := range []int{0, seqLen} {
+			if r.start+offset < end && start < r.end+offset {
+				return true
+			}
+		}
+	}
+	return false
+}
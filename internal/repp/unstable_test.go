@@ -0,0 +1,99 @@
+package repp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_findUnstableRegions(t *testing.T) {
+	stem := randomSeq(14, 2)
+	arm := randomSeq(30, 3)
+	seq := randomSeq(50, 1) + // 0
+		strings.Repeat("A", 15) + // 50
+		randomSeq(50, 4) + // 65
+		stem + "GAAA" + reverseComplement(stem) + // 115
+		randomSeq(50, 5) + // 147
+		arm + randomSeq(100, 6) + reverseComplement(arm) + // 197
+		randomSeq(50, 7) + // 357
+		"TTGACA" + randomSeq(17, 8) + "TATAAT" + // 407
+		randomSeq(50, 9) // 436
+
+	var got []unstableRegion
+	for _, r := range findUnstableRegions(seq) {
+		if r.end-r.start > 8 { // ignore short chance matches of the random sequence
+			got = append(got, r)
+		}
+	}
+	want := []unstableRegion{
+		{start: 50, end: 65, kind: polyATTract},
+		{start: 115, end: 147, kind: hairpinStem},
+		{start: 197, end: 357, kind: invertedRepeat},
+		{start: 407, end: 436, kind: ecoliPromoterBox},
+	}
+	for _, w := range want {
+		found := false
+		for _, r := range got {
+			if r.kind == w.kind && r.start <= w.start && w.end <= r.end+2 && r.end-r.start <= w.end-w.start+4 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("findUnstableRegions() = %+v, missing %+v", got, w)
+		}
+	}
+
+	// a promoter on the bottom strand
+	rev := findUnstableRegions(reverseComplement("TTGACA" + randomSeq(17, 8) + "TATAAT"))
+	if len(rev) == 0 || !reflect.DeepEqual(rev[len(rev)-1], unstableRegion{start: 0, end: 29, kind: ecoliPromoterBox}) {
+		t.Errorf("findUnstableRegions() of a bottom strand promoter = %+v", rev)
+	}
+}
+
+func Test_stabilityWarnings(t *testing.T) {
+	regions := []unstableRegion{
+		{start: 100, end: 115, kind: polyATTract},
+		{start: 690, end: 720, kind: ecoliPromoterBox},
+		{start: 990, end: 1010, kind: hairpinStem},
+	}
+	frags := []*Frag{
+		{ID: "a", fragType: pcr, start: 0, end: 120},
+		{ID: "b", fragType: synthetic, start: 110, end: 700},
+		{ID: "c", fragType: pcr, start: 680, end: 1030},
+	}
+
+	// b is synthesized through the poly-A tract, at its junction with a. The junction of b
+	// and c is in a promoter, which isn't structural, and that of c and a, across the zero
+	// index, is in the hairpin
+	want := []string{
+		"b is synthesized through the poly-A/T tract at 100-115, vendors may fail to make it",
+		"the junction of a and b is in the poly-A/T tract at 100-115, it may misanneal",
+		"the junction of c and a is in the hairpin at 990-1010, it may misanneal",
+	}
+	if got := stabilityWarnings(frags, regions, 1000); !reflect.DeepEqual(got, want) {
+		t.Errorf("stabilityWarnings() = %v, want %v", got, want)
+	}
+}
+
+func Test_inUnstableRegion(t *testing.T) {
+	defer func() { unstableRegions = nil }()
+	unstableRegions = []unstableRegion{{start: 10, end: 20, kind: polyATTract}, {start: 50, end: 80, kind: ecoliPromoterBox}}
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       bool
+	}{
+		{"in the motif", 15, 35, true},
+		{"past the zero index", 1015, 1035, true},
+		{"before the motif", 0, 10, false},
+		{"in a promoter", 55, 75, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inUnstableRegion(tt.start, tt.end, 1000); got != tt.want {
+				t.Errorf("inUnstableRegion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
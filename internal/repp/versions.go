@@ -0,0 +1,258 @@
+package repp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// entryVersion is the version of a database entry's sequence. Versions are tracked
+// across rebuilds of the database so outputs that used an entry can be checked
+// against its current sequence. Entries that were removed are kept as tombstones.
+type entryVersion struct {
+	// Checksum is the hex encoded SHA256 of the entry's sequence
+	Checksum string `json:"checksum"`
+
+	// Version starts at 1 and is incremented each rebuild that changes the sequence
+	Version int `json:"version"`
+
+	// Updated is when the entry was added or its sequence last changed
+	Updated time.Time `json:"updated"`
+
+	// Removed is when the entry was removed from the database, nil if it's in it
+	Removed *time.Time `json:"removed,omitempty"`
+}
+
+// TemplateVersion is the version of a database entry that an output's solutions used
+type TemplateVersion struct {
+	// Database the entry is in
+	Database string `json:"database"`
+
+	// ID of the entry
+	ID string `json:"id"`
+
+	// Checksum of the entry's sequence when the output was generated
+	Checksum string `json:"checksum"`
+
+	// Version of the entry when the output was generated
+	Version int `json:"version"`
+
+	// Solutions that use the entry, 1-based
+	Solutions []int `json:"solutions"`
+
+	// Flipped is whether the entry was reverse complemented, relative to its sequence file,
+	// when it was added to the database. Its coordinates are on the reverse complement
+	Flipped bool `json:"flipped,omitempty"`
+}
+
+// dbVersionsPath returns the path to the entry versions of a database
+func dbVersionsPath(dbPath string) string {
+	return dbPath + ".versions.json"
+}
+
+// seqChecksum returns the hex encoded SHA256 of an upper cased sequence
+func seqChecksum(seq string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(seq)))
+	return hex.EncodeToString(sum[:])
+}
+
+// readEntryVersions reads the entry versions of a database, by entry ID
+func readEntryVersions(dbPath string) (map[string]*entryVersion, error) {
+	contents, err := os.ReadFile(dbVersionsPath(dbPath))
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]*entryVersion)
+	if err = json.Unmarshal(contents, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", dbVersionsPath(dbPath), err)
+	}
+	return versions, nil
+}
+
+// updateEntryVersions updates the entry versions of a database after it was built. Entries
+// with a new sequence get a new version and entries that are no longer in the database are
+// marked removed. It returns the number of entries that were changed and removed.
+func updateEntryVersions(dbPath string, now time.Time) (changed, removed int, err error) {
+	versions, err := readEntryVersions(dbPath)
+	if errors.Is(err, os.ErrNotExist) {
+		versions, err = make(map[string]*entryVersion), nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	seen := make(map[string]bool)
+	err = scanFasta(dbPath, func(header, seq string) {
+		id := entryID(header)
+		seen[id] = true
+		checksum := seqChecksum(seq)
+
+		v, ok := versions[id]
+		switch {
+		case !ok:
+			versions[id] = &entryVersion{Checksum: checksum, Version: 1, Updated: now}
+		case v.Checksum != checksum || v.Removed != nil:
+			v.Checksum, v.Updated, v.Removed = checksum, now, nil
+			v.Version++
+			changed++
+		}
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for id, v := range versions {
+		if !seen[id] && v.Removed == nil {
+			removedAt := now
+			v.Removed = &removedAt
+			removed++
+		}
+	}
+
+	contents, err := json.Marshal(versions)
+	if err != nil {
+		return 0, 0, err
+	}
+	return changed, removed, writeFileAtomic(dbVersionsPath(dbPath), contents)
+}
+
+// entryVersions returns the entry versions of the database. They're updated if they're
+// missing or older than the database, ex: for databases added before they were tracked.
+func (db DB) entryVersions() (map[string]*entryVersion, error) {
+	if isNewer(dbVersionsPath(db.Path), db.Path) {
+		if versions, err := readEntryVersions(db.Path); err == nil {
+			return versions, nil
+		}
+	}
+	if _, _, err := updateEntryVersions(db.Path, time.Now()); err != nil {
+		return nil, err
+	}
+	return readEntryVersions(db.Path)
+}
+
+// templateVersions returns the versions of the database entries used by the solutions
+func templateVersions(solutions []Solution) (templates []TemplateVersion) {
+	dbVersions := make(map[string]map[string]*entryVersion)
+	byTemplate := make(map[string]int)
+	for si, s := range solutions {
+		for _, f := range s.Fragments {
+			if f.db.Path == "" || f.fragType == synthetic {
+				continue
+			}
+
+			id := entryID(f.ID)
+			key := f.db.Name + ":" + id
+			if i, ok := byTemplate[key]; ok {
+				if solutions := templates[i].Solutions; solutions[len(solutions)-1] != si+1 {
+					templates[i].Solutions = append(solutions, si+1)
+				}
+				continue
+			}
+
+			versions, ok := dbVersions[f.db.Path]
+			if !ok {
+				var err error
+				if versions, err = f.db.entryVersions(); err != nil {
+					rlog.Warnf("failed to read the entry versions of %s: %v", f.db.Name, err)
+				}
+				dbVersions[f.db.Path] = versions
+			}
+			v, ok := versions[id]
+			if !ok {
+				continue
+			}
+
+			byTemplate[key] = len(templates)
+			templates = append(templates, TemplateVersion{
+				Database:  f.db.Name,
+				ID:        id,
+				Checksum:  v.Checksum,
+				Version:   v.Version,
+				Solutions: []int{si + 1},
+				Flipped:   slices.Contains(f.db.FlippedEntries, id),
+			})
+		}
+	}
+
+	sort.SliceStable(templates, func(i, j int) bool {
+		if templates[i].Database != templates[j].Database {
+			return templates[i].Database < templates[j].Database
+		}
+		return templates[i].ID < templates[j].ID
+	})
+	return templates
+}
+
+// templateStatus returns whether a template used by an output is still current in its
+// database, and if not, how it changed
+func templateStatus(t TemplateVersion, versions map[string]*entryVersion) (status string, current bool) {
+	v, ok := versions[t.ID]
+	switch {
+	case !ok:
+		return "not in the database", false
+	case v.Removed != nil:
+		return fmt.Sprintf("removed %s", outputTime(*v.Removed)), false
+	case v.Checksum != t.Checksum:
+		return fmt.Sprintf("changed %s, version %d -> %d", outputTime(v.Updated), t.Version, v.Version), false
+	}
+	return "current", true
+}
+
+// VerifyOutput checks whether the database entries used by an output's solutions have
+// changed or been removed since the output was generated. It exits with an error if any have.
+func VerifyOutput(filename string) {
+	meta, err := readMetadata(filename)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if len(meta.Templates) == 0 {
+		fmt.Printf("no database templates are recorded in %s\n", filename)
+		return
+	}
+
+	m, err := newManifest()
+	if err != nil {
+		rlog.Fatal(err)
+	}
+
+	stale := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+	fmt.Fprintf(w, "solutions\tdatabase\tid\tstatus\n")
+	dbVersions := make(map[string]map[string]*entryVersion)
+	for _, t := range meta.Templates {
+		status, current := "database not registered", false
+		if db, ok := m.DBs[t.Database]; ok {
+			versions, ok := dbVersions[t.Database]
+			if !ok {
+				if versions, err = db.entryVersions(); err != nil {
+					rlog.Warnf("failed to read the entry versions of %s: %v", t.Database, err)
+				}
+				dbVersions[t.Database] = versions
+			}
+			status, current = templateStatus(t, versions)
+		}
+		if !current {
+			stale++
+		}
+
+		var solutions []string
+		for _, s := range t.Solutions {
+			solutions = append(solutions, fmt.Sprint(s))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.Join(solutions, ","), t.Database, t.ID, status)
+	}
+	w.Flush()
+
+	if stale > 0 {
+		rlog.Fatalf("%d of the %d templates used by %s changed or were removed", stale, len(meta.Templates), filename)
+	}
+}
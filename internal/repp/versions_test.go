@@ -0,0 +1,114 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_updateEntryVersions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+	build := func(contents string, now time.Time) (changed, removed int) {
+		if err := os.WriteFile(dbPath, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		changed, removed, err := updateEntryVersions(dbPath, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return changed, removed
+	}
+
+	first := time.Date(2023, 9, 21, 0, 0, 0, 0, time.UTC)
+	if changed, removed := build(">a\nAAAA\n>b desc\nCCCC\n>c\nGGGG\n", first); changed != 0 || removed != 0 {
+		t.Errorf("updateEntryVersions() of the first build = %d changed, %d removed, want 0, 0", changed, removed)
+	}
+
+	second := first.Add(24 * time.Hour)
+	if changed, removed := build(">a\nAAAA\n>b desc\nCCCT\n", second); changed != 1 || removed != 1 {
+		t.Errorf("updateEntryVersions() of the second build = %d changed, %d removed, want 1, 1", changed, removed)
+	}
+
+	versions, err := readEntryVersions(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := versions["a"]; v.Version != 1 || v.Checksum != seqChecksum("AAAA") || !v.Updated.Equal(first) {
+		t.Errorf("unchanged entry version = %+v", v)
+	}
+	if v := versions["b"]; v.Version != 2 || v.Checksum != seqChecksum("CCCT") || !v.Updated.Equal(second) {
+		t.Errorf("changed entry version = %+v", v)
+	}
+	if v := versions["c"]; v.Removed == nil || !v.Removed.Equal(second) || v.Checksum != seqChecksum("GGGG") {
+		t.Errorf("removed entry version = %+v, want a tombstone", v)
+	}
+
+	// a removed entry that's added back is a new version
+	if changed, removed := build(">a\nAAAA\n>b desc\nCCCT\n>c\nGGGG\n", second.Add(time.Hour)); changed != 1 || removed != 0 {
+		t.Errorf("updateEntryVersions() of the third build = %d changed, %d removed, want 1, 0", changed, removed)
+	}
+	if versions, _ = readEntryVersions(dbPath); versions["c"].Removed != nil || versions["c"].Version != 2 {
+		t.Errorf("re-added entry version = %+v", versions["c"])
+	}
+
+	// the versions outlive the database, so a database that's deleted and added again continues them
+	if err := os.Remove(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	build(">a\nAAAA\n>b desc\nCCCT\n>c\nGGGA\n", second.Add(2*time.Hour))
+	if versions, _ = readEntryVersions(dbPath); versions["c"].Version != 3 || versions["a"].Version != 1 {
+		t.Errorf("entry versions of the database added again = %+v, %+v", versions["a"], versions["c"])
+	}
+}
+
+func Test_templateStatus(t *testing.T) {
+	removed := time.Date(2023, 9, 22, 0, 0, 0, 0, time.UTC)
+	versions := map[string]*entryVersion{
+		"a": {Checksum: "1", Version: 1},
+		"b": {Checksum: "2", Version: 2, Updated: removed},
+		"c": {Checksum: "3", Version: 1, Removed: &removed},
+	}
+
+	tests := []struct {
+		name        string
+		template    TemplateVersion
+		wantStatus  string
+		wantCurrent bool
+	}{
+		{"current", TemplateVersion{ID: "a", Checksum: "1", Version: 1}, "current", true},
+		{"changed", TemplateVersion{ID: "b", Checksum: "1", Version: 1}, "changed 2023/09/22 00:00:00, version 1 -> 2", false},
+		{"removed", TemplateVersion{ID: "c", Checksum: "3", Version: 1}, "removed 2023/09/22 00:00:00", false},
+		{"unknown", TemplateVersion{ID: "d", Checksum: "4", Version: 1}, "not in the database", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, current := templateStatus(tt.template, versions)
+			if status != tt.wantStatus || current != tt.wantCurrent {
+				t.Errorf("templateStatus() = %q, %t, want %q, %t", status, current, tt.wantStatus, tt.wantCurrent)
+			}
+		})
+	}
+}
+
+func Test_templateVersions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+	if err := os.WriteFile(dbPath, []byte(">a desc\nAAAA\n>b\nCCCC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db := DB{Name: "parts", Path: dbPath}
+
+	solutions := []Solution{
+		{Fragments: []*Frag{{ID: "b", db: db, fragType: pcr}, {ID: "syn", fragType: synthetic}, {ID: "a", db: db, fragType: pcr}}},
+		{Fragments: []*Frag{{ID: "a", db: db, fragType: circular}}},
+	}
+
+	want := []TemplateVersion{
+		{Database: "parts", ID: "a", Checksum: seqChecksum("AAAA"), Version: 1, Solutions: []int{1, 2}},
+		{Database: "parts", ID: "b", Checksum: seqChecksum("CCCC"), Version: 1, Solutions: []int{1}},
+	}
+	if got := templateVersions(solutions); !reflect.DeepEqual(got, want) {
+		t.Errorf("templateVersions() = %+v, want %+v", got, want)
+	}
+}
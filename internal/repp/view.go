@@ -0,0 +1,282 @@
+package repp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// viewModel is the state of the interactive browser of an output's solutions
+type viewModel struct {
+	// filename of the output, order files are exported next to it
+	filename string
+
+	// out is the output being browsed
+	out *Output
+
+	// manifests of the existing primers and synthetic fragments, reused in the order files
+	primersDB, synthFragsDB *oligosDB
+
+	// names renames the reagents of the order files to the lab's conventions
+	names namingMap
+
+	// minHomology and maxHomology are the junction lengths looked for between fragments
+	minHomology, maxHomology int
+
+	// solution is the index of the selected solution
+	solution int
+
+	// opened is whether the selected solution's fragments are shown, instead of the list of solutions
+	opened bool
+
+	// fragment is the index of the selected fragment of the opened solution
+	fragment int
+
+	// expanded are the fragments of the opened solution whose primers are shown
+	expanded map[int]bool
+
+	// junctions is whether the opened solution's junctions are shown, instead of its fragments
+	junctions bool
+
+	// status is the result of the last export
+	status string
+}
+
+// View opens an interactive browser of the solutions of a JSON output. Solutions can be
+// opened to see their fragments, primers and junctions, and the order files of the
+// selected solution can be exported.
+func View(filename string, primersDBLocations, synthFragsDBLocations []string) {
+	out, err := readOutput(filename)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	if len(out.Solutions) == 0 {
+		rlog.Fatalf("no solutions in %s", filename)
+	}
+
+	conf := config.New()
+	names, err := newNamingMap(conf.NamingMap)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	primersDB, synthFragsDB, err := readOligoDBs(primersDBLocations, synthFragsDBLocations, conf)
+	if err != nil {
+		rlog.Fatal(err)
+	}
+	m := &viewModel{
+		filename:     filename,
+		out:          out,
+		primersDB:    primersDB,
+		synthFragsDB: synthFragsDB,
+		names:        names,
+		minHomology:  conf.FragmentsMinHomology,
+		maxHomology:  conf.FragmentsMaxHomology + 1,
+		expanded:     make(map[int]bool),
+	}
+	if _, err = tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		rlog.Fatal(err)
+	}
+}
+
+// readOutput reads a JSON output. The fragments' types are restored from their names
+func readOutput(filename string) (*Output, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	out := &Output{}
+	if err = json.Unmarshal(contents, out); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", filename, err)
+	}
+	for _, s := range out.Solutions {
+		for _, f := range s.Fragments {
+			for t := linear; t <= synthetic; t++ {
+				if f.Type == t.String() {
+					f.fragType = t
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *viewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *viewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		if m.opened {
+			m.fragment = (m.fragment + len(m.selected().Fragments) - 1) % len(m.selected().Fragments)
+		} else {
+			m.solution = (m.solution + len(m.out.Solutions) - 1) % len(m.out.Solutions)
+		}
+	case "down", "j":
+		if m.opened {
+			m.fragment = (m.fragment + 1) % len(m.selected().Fragments)
+		} else {
+			m.solution = (m.solution + 1) % len(m.out.Solutions)
+		}
+	case "enter", " ":
+		if !m.opened {
+			m.opened, m.fragment, m.junctions = true, 0, false
+			m.expanded = make(map[int]bool)
+		} else {
+			m.expanded[m.fragment] = !m.expanded[m.fragment]
+		}
+	case "esc", "backspace", "left":
+		m.opened = false
+	case "tab":
+		m.junctions = m.opened && !m.junctions
+	case "e":
+		m.status = m.export()
+	}
+	return m, nil
+}
+
+func (m *viewModel) View() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s (%s)\n\n", m.out.Target, m.filename)
+	switch {
+	case !m.opened:
+		m.viewSolutions(b)
+		fmt.Fprint(b, "\n↑/↓ select • enter open • e export orders • q quit\n")
+	case m.junctions:
+		m.viewJunctions(b)
+		fmt.Fprint(b, "\ntab fragments • esc back • e export orders • q quit\n")
+	default:
+		m.viewFragments(b)
+		fmt.Fprint(b, "\n↑/↓ select • enter primers • tab junctions • esc back • e export orders • q quit\n")
+	}
+	if m.status != "" {
+		fmt.Fprintf(b, "\n%s\n", m.status)
+	}
+	return b.String()
+}
+
+// selected returns the selected solution
+func (m *viewModel) selected() Solution {
+	return m.out.Solutions[m.solution]
+}
+
+// viewSolutions writes the list of solutions
+func (m *viewModel) viewSolutions(b *strings.Builder) {
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "  #\tfragments\tpcr\tsynthetic\tcost\tadjusted cost\tsuccess\n")
+	for i, s := range m.out.Solutions {
+		pcrs := 0
+		for _, f := range s.Fragments {
+			if f.fragType == pcr {
+				pcrs++
+			}
+		}
+		success := "-"
+		if s.SuccessScore > 0 {
+			success = fmt.Sprintf("%.2f", s.SuccessScore)
+		}
+		pareto := ""
+		if s.Pareto {
+			pareto = "\tpareto"
+		}
+		fmt.Fprintf(w, "%s %d\t%d\t%d\t%d\t%.2f\t%.2f\t%s%s\n",
+			cursor(i == m.solution), i+1, s.Count, pcrs, s.Count-pcrs, s.Cost, s.AdjustedCost, success, pareto)
+	}
+	w.Flush()
+}
+
+// viewFragments writes the fragments of the opened solution, with the primers of the expanded ones
+func (m *viewModel) viewFragments(b *strings.Builder) {
+	s := m.selected()
+	fmt.Fprintf(b, "Solution %d: %d fragments, cost %.2f, adjusted cost %.2f\n\n", m.solution+1, s.Count, s.Cost, s.AdjustedCost)
+	for i, f := range s.Fragments {
+		fmt.Fprintf(b, "%s %d %s %s %dbp %.2f\n", cursor(i == m.fragment), i+1, f.Type, f.ID, len(f.getFragSeq()), f.Cost)
+		if !m.expanded[i] {
+			continue
+		}
+		if len(f.Primers) == 0 {
+			fmt.Fprintf(b, "      %s\n", f.Seq)
+			continue
+		}
+		fwd, rev := f.getPrimers()
+		for _, p := range []struct {
+			name   string
+			primer Primer
+		}{{"FWD", fwd}, {"REV", rev}} {
+			fmt.Fprintf(b, "      %s %s Tm %.1f", p.name, p.primer.Seq, p.primer.Tm)
+			if p.primer.Notes != "" {
+				fmt.Fprintf(b, " (%s)", p.primer.Notes)
+			}
+			fmt.Fprintln(b)
+		}
+	}
+}
+
+// viewJunctions writes the junctions between the opened solution's neighboring fragments
+func (m *viewModel) viewJunctions(b *strings.Builder) {
+	s := m.selected()
+	fmt.Fprintf(b, "Solution %d junctions\n\n", m.solution+1)
+	if len(s.Fragments) < 2 {
+		fmt.Fprint(b, "  none, the solution has one fragment\n")
+		return
+	}
+
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "  fragments\tlength\tTm\tGC%%\tsequence\n")
+	for i, f := range s.Fragments {
+		next := (i + 1) % len(s.Fragments)
+		j := f.junction(s.Fragments[next], m.minHomology, m.maxHomology)
+		if j == "" {
+			fmt.Fprintf(w, "  %d-%d\t-\t-\t-\tno junction\n", i+1, next+1)
+			continue
+		}
+		gc := 100 * float64(strings.Count(j, "G")+strings.Count(j, "C")) / float64(len(j))
+		fmt.Fprintf(w, "  %d-%d\t%d\t%.1f\t%.0f\t%s\n", i+1, next+1, len(j), primerTm(j), gc, j)
+	}
+	w.Flush()
+}
+
+// export writes the order files of the selected solution, next to the output, and
+// returns what was written
+func (m *viewModel) export() string {
+	order := newSolutionOrder(m.selected(), m.names, m.primersDB, m.synthFragsDB)
+	var written []string
+	if len(order.primers) > 0 {
+		filename := solutionFilename(m.filename, m.solution+1, "-primers.csv")
+		if err := writePrimerOrder(filename, order.primers); err != nil {
+			return fmt.Sprintf("failed to export the primers: %v", err)
+		}
+		written = append(written, filename)
+	}
+	if len(order.synthFrags) > 0 {
+		filename := solutionFilename(m.filename, m.solution+1, "-synth-frags.fasta")
+		if err := writeSynthFragOrder(filename, order.synthFrags); err != nil {
+			return fmt.Sprintf("failed to export the synthetic fragments: %v", err)
+		}
+		written = append(written, filename)
+	}
+	if len(written) == 0 {
+		return fmt.Sprintf("solution %d has nothing to order", m.solution+1)
+	}
+	return "wrote " + strings.Join(written, ", ")
+}
+
+// cursor returns the marker of the selected row
+func cursor(selected bool) string {
+	if selected {
+		return ">"
+	}
+	return " "
+}
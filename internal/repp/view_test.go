@@ -0,0 +1,75 @@
+package repp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func Test_viewModel(t *testing.T) {
+	junction := "ACGTGCATGCACGTAGCTAGCATGCAGTCA"
+	output := `{"target": "target", "solutions": [
+		{"count": 1, "cost": 10, "fragments": [{"id": "pSB1A3", "type": "plasmid", "seq": "ACGT"}]},
+		{"count": 2, "cost": 20, "successScore": 0.9, "fragments": [
+			{"id": "pSB1A3", "type": "pcr", "pcrSeq": "GGGGGGGGGG` + junction + `", "primers": [
+				{"seq": "GGGGGGGGGGGGGGGGGG", "strand": true, "tm": 60}, {"seq": "TGACTGCATGCTAGCTAC", "tm": 61}]},
+			{"type": "synthetic", "seq": "` + junction + `TTTTTTTTTT"}
+		]}
+	]}`
+	filename := filepath.Join(t.TempDir(), "out.json")
+	if err := os.WriteFile(filename, []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := readOutput(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Solutions[1].Fragments[1].fragType; got != synthetic {
+		t.Fatalf("readOutput() fragment type = %v, want synthetic", got)
+	}
+
+	m := &viewModel{
+		filename:     filename,
+		out:          out,
+		primersDB:    newOligosDB(primerIDPrefix, false),
+		synthFragsDB: newOligosDB(synthFragIDPrefix, true),
+		minHomology:  20,
+		maxHomology:  121,
+		expanded:     make(map[int]bool),
+	}
+	keys := func(keys ...string) {
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			switch k {
+			case "down":
+				msg = tea.KeyMsg{Type: tea.KeyDown}
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "tab":
+				msg = tea.KeyMsg{Type: tea.KeyTab}
+			}
+			m.Update(msg)
+		}
+	}
+
+	keys("down", "enter", "enter")
+	if view := m.View(); !m.opened || m.solution != 1 || !strings.Contains(view, "FWD GGGGGGGGGGGGGGGGGG Tm 60.0") {
+		t.Errorf("View() of the second solution with its first fragment expanded = \n%s", view)
+	}
+
+	keys("tab")
+	if view := m.View(); !strings.Contains(view, junction) || !strings.Contains(view, "no junction") {
+		t.Errorf("View() of the junctions = \n%s", view)
+	}
+
+	keys("e")
+	for _, suffix := range []string{"-primers.csv", "-synth-frags.fasta"} {
+		if _, err := os.Stat(solutionFilename(filename, 2, suffix)); err != nil {
+			t.Errorf("export didn't write the %s order file: %v", suffix, err)
+		}
+	}
+}
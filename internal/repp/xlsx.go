@@ -0,0 +1,261 @@
+package repp
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// xlsxSheet is a named sheet of a workbook, as rows of cells
+type xlsxSheet struct {
+	name string
+	rows [][]string
+}
+
+// xlsxCellRefRegex matches a cell reference, ex: "AB12", capturing its column
+var xlsxCellRefRegex = regexp.MustCompile(`^([A-Z]+)\d+$`)
+
+// xlsxColumnIndex returns the 0-based index of a column's letters, ex: "A" is 0 and "AA" is 26.
+// It's -1 if they aren't letters.
+func xlsxColumnIndex(letters string) int {
+	letters = strings.ToUpper(strings.TrimSpace(letters))
+	if letters == "" {
+		return -1
+	}
+	index := 0
+	for _, c := range letters {
+		if c < 'A' || c > 'Z' {
+			return -1
+		}
+		index = index*26 + int(c-'A'+1)
+	}
+	return index - 1
+}
+
+// xlsxColumnName returns the letters of a 0-based column index
+func xlsxColumnName(index int) (name string) {
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// readXLSX reads the rows of a sheet of a workbook, the first sheet if the name is empty.
+// Missing cells are empty strings.
+func readXLSX(filename, sheetName string) ([][]string, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	files := make(map[string]*zip.File)
+	for _, f := range r.File {
+		files[f.Name] = f
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err = decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err = decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	sheetPart := ""
+	for _, s := range workbook.Sheets {
+		if sheetName != "" && !strings.EqualFold(s.Name, sheetName) {
+			continue
+		}
+		for _, rel := range rels.Relationships {
+			if rel.ID == s.ID {
+				sheetPart = strings.TrimPrefix(rel.Target, "/")
+				if !strings.HasPrefix(sheetPart, "xl/") {
+					sheetPart = path.Join("xl", sheetPart)
+				}
+			}
+		}
+		break
+	}
+	if sheetPart == "" {
+		return nil, fmt.Errorf("no sheet %q in %s", sheetName, filename)
+	}
+
+	var sharedStrings struct {
+		Items []struct {
+			Text string   `xml:"t"`
+			Runs []string `xml:"r>t"`
+		} `xml:"si"`
+	}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err = decodeXLSXPart(files, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err = decodeXLSXPart(files, sheetPart, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, sheetRow := range sheet.Rows {
+		var row []string
+		for _, c := range sheetRow.Cells {
+			col := len(row)
+			if m := xlsxCellRefRegex.FindStringSubmatch(c.Ref); m != nil {
+				col = xlsxColumnIndex(m[1])
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+
+			switch c.Type {
+			case "s":
+				i, err := strconv.Atoi(c.Value)
+				if err != nil || i < 0 || i >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("bad shared string %q in cell %s of %s", c.Value, c.Ref, filename)
+				}
+				item := sharedStrings.Items[i]
+				row[col] = item.Text + strings.Join(item.Runs, "")
+			case "inlineStr":
+				row[col] = c.Inline
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeXLSXPart decodes an XML part of a workbook
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("not an xlsx workbook, %s is missing", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err = xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", name, err)
+	}
+	return nil
+}
+
+// xlsxNumberRegex matches cells that are written as numbers. Others, like IDs with
+// leading zeros, are written as text
+var xlsxNumberRegex = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?$`)
+
+// writeXLSX writes sheets to a workbook. Cells are written as numbers if they look like
+// numbers, otherwise as inline strings.
+func writeXLSX(filename string, sheets []xlsxSheet) (err error) {
+	file, err := createAtomic(filename)
+	if err != nil {
+		return err
+	}
+	defer file.close(&err)
+
+	w := zip.NewWriter(file)
+	part := func(name, contents string) error {
+		pw, err := w.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(pw, xml.Header+contents)
+		return err
+	}
+
+	var overrides, sheetEntries, sheetRels strings.Builder
+	for i := range sheets {
+		n := i + 1
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&sheetEntries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheets[i].name), n, n)
+		fmt.Fprintf(&sheetRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+
+	if err = part("[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`+
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`+
+		`<Default Extension="xml" ContentType="application/xml"/>`+
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`+
+		overrides.String()+`</Types>`); err != nil {
+		return err
+	}
+	if err = part("_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>`+
+		`</Relationships>`); err != nil {
+		return err
+	}
+	if err = part("xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" `+
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`+
+		sheetEntries.String()+`</sheets></workbook>`); err != nil {
+		return err
+	}
+	if err = part("xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		sheetRels.String()+`</Relationships>`); err != nil {
+		return err
+	}
+
+	for i, s := range sheets {
+		var data strings.Builder
+		for r, row := range s.rows {
+			fmt.Fprintf(&data, `<row r="%d">`, r+1)
+			for c, cell := range row {
+				if cell == "" {
+					continue
+				}
+				ref := xlsxColumnName(c) + strconv.Itoa(r+1)
+				if xlsxNumberRegex.MatchString(cell) {
+					fmt.Fprintf(&data, `<c r="%s"><v>%s</v></c>`, ref, cell)
+				} else {
+					fmt.Fprintf(&data, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(cell))
+				}
+			}
+			data.WriteString(`</row>`)
+		}
+		if err = part(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+
+			data.String()+`</sheetData></worksheet>`); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// xmlEscape escapes text for an XML element or attribute
+func xmlEscape(s string) string {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return s
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package repp
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Lattice-Automation/repp/internal/config"
+)
+
+func Test_writeXLSX_readXLSX(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "oligos.xlsx")
+	sheets := []xlsxSheet{
+		{name: "Notes", rows: [][]string{{"# inventory"}}},
+		{name: "Primers", rows: [][]string{
+			{"Plate", "Name", "Sequence 5'-3'"},
+			{"P1", "oS001", "acgtacgtacgtacgtac"},
+			{"", "oS002", "/5Phos/TTGG<CC>&AA"},
+			{"0012", "oS003", "12.5"},
+		}},
+	}
+	if err := writeXLSX(filename, sheets); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := readXLSX(filename, "primers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, sheets[1].rows) {
+		t.Errorf("readXLSX() = %v, want %v", rows, sheets[1].rows)
+	}
+	if _, err = readXLSX(filename, "missing"); err == nil {
+		t.Error("readXLSX() of a missing sheet should fail")
+	}
+
+	// columns mapped by header name and by letter
+	c := config.New()
+	c.OligosXlsxSheet = "Primers"
+	c.OligosXlsxColumns = map[string]string{"id": "name", "sequence": "sequence 5'-3'", "plate": "A", "well": "D"}
+	oligos := newOligosDB(primerIDPrefix, false)
+	if err = readOligosFromXLSX(filename, oligos, c); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]oligo{
+		"ACGTACGTACGTACGTAC": {id: "oS001", seq: "acgtacgtacgtacgtac", plate: "P1"},
+		"TTGG<CC>&AA":        {id: "oS002", seq: "TTGG<CC>&AA"},
+		"12.5":               {id: "oS003", seq: "12.5", plate: "0012"},
+	}
+	if !reflect.DeepEqual(oligos.indexedOligos, want) {
+		t.Errorf("readOligosFromXLSX() = %v, want %v", oligos.indexedOligos, want)
+	}
+}
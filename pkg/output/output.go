@@ -0,0 +1,398 @@
+// Package output is for reading the JSON plasmid designs written by repp.
+//
+// The JSON output follows a versioned schema (see Schema). Within a major version
+// changes are additive only: fields may be added, but none are removed, renamed or
+// change type. Decoding ignores fields it doesn't know about, so integrations built
+// against one minor version keep working with outputs from later minor versions.
+package output
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the version of the output JSON schema written by this version of repp.
+// Its minor version is bumped with each additive change, ex: a new field, so readers can
+// tell whether an output has it, and its major version with each breaking change.
+const SchemaVersion = "1.19.0"
+
+var (
+	//go:embed output.schema.json
+	schema []byte
+)
+
+// Schema returns the JSON schema of the output, for SchemaVersion.
+func Schema() []byte {
+	return append([]byte(nil), schema...)
+}
+
+// Output is a plasmid design written by repp.
+type Output struct {
+	// SchemaVersion of the output, ex: "1.19.0". Empty in outputs that predate the schema
+	SchemaVersion string `json:"schemaVersion,omitempty"`
+
+	// Target's name. In >example_CDS FASTA its "example_CDS"
+	Target string `json:"target"`
+
+	// Target's sequence
+	TargetSeq string `json:"seq"`
+
+	// Time, ex: "2018-01-01 20:41:00"
+	Time string `json:"time"`
+
+	// Execution is the number of seconds it took to execute the command
+	Execution float64 `json:"execution"`
+
+	// Currency of the solutions' costs, ex: "USD"
+	Currency string `json:"currency,omitempty"`
+
+	// Solutions that build the target
+	Solutions []Solution `json:"solutions"`
+
+	// Backbone is the linearized backbone, if one was used
+	Backbone *Backbone `json:"backbone,omitempty"`
+
+	// ParetoFront is the solutions' pareto front of fragment count, synthetic fragment count and cost
+	ParetoFront []ParetoPoint `json:"paretoFront,omitempty"`
+
+	// Metadata of the run that produced this output
+	Metadata *RunMetadata `json:"metadata,omitempty"`
+}
+
+// Solution is a single assembly that builds the target.
+type Solution struct {
+	// Count is the number of fragments in this solution
+	Count int `json:"count"`
+
+	// Cost estimated from the primer and sequence lengths
+	Cost float64 `json:"cost"`
+
+	// AdjustedCost for synthetic fragments
+	AdjustedCost float64 `json:"adjustedCost"`
+
+	// CostBreakdown is the cost of the solution by category
+	CostBreakdown CostBreakdown `json:"costBreakdown"`
+
+	// Fragments used to build this solution
+	Fragments []Fragment `json:"fragments"`
+
+	// Method is how the fragments are joined if not by Gibson Assembly (eg: sticky, blunt, topo)
+	Method string `json:"method,omitempty"`
+
+	// Warnings about the solution that may need extra screening at the bench
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Explanation of why the solution's fragments were chosen and how it compares to the other solutions
+	Explanation []string `json:"explanation,omitempty"`
+
+	// ReusedPrimers is the number of the solution's primers that are already on plates
+	ReusedPrimers int `json:"reusedPrimers,omitempty"`
+
+	// PickList is where to pick the reused primers from
+	PickList []PickListEntry `json:"pickList,omitempty"`
+
+	// Screening tells the intended product apart from failure products
+	Screening *Screening `json:"screening,omitempty"`
+
+	// Pareto is whether the solution is on the pareto front
+	Pareto bool `json:"pareto,omitempty"`
+
+	// AnnealingTemp is the annealing temperature to amplify all the PCR fragments at
+	AnnealingTemp float64 `json:"annealingTemp,omitempty"`
+
+	// PriorReagents is the number of primers and synthetic fragments reused from a previous plan
+	PriorReagents int `json:"priorReagents,omitempty"`
+
+	// SuccessScore is a heuristic score, from 0 to 1, of how likely the assembly is to work the first time
+	SuccessScore float64 `json:"successScore,omitempty"`
+
+	// Features are how each feature of a features mode target is made
+	Features []FeatureSource `json:"features,omitempty"`
+
+	// FeatureCoverage is the fraction, from 0 to 1, of the features' bp that come from templates
+	FeatureCoverage *float64 `json:"featureCoverage,omitempty"`
+
+	// GoldenGate is the set of overhangs to join the fragments by Golden Gate Assembly
+	GoldenGate *GoldenGate `json:"goldenGate,omitempty"`
+
+	// QPCR are the qPCR assays that verify the solution
+	QPCR []QPCRAssay `json:"qpcr,omitempty"`
+
+	// Junctions are the homologies at which the fragments are joined
+	Junctions []Junction `json:"junctions,omitempty"`
+
+	// Verification is how the solution's build was checked against its Sanger reads
+	Verification *Verification `json:"verification,omitempty"`
+}
+
+// Junction is the homology at which a fragment is joined to the next one.
+type Junction struct {
+	Left   int     `json:"left"`
+	Right  int     `json:"right"`
+	Seq    string  `json:"seq"`
+	Length int     `json:"length"`
+	Tm     float64 `json:"tm"`
+	Start  int     `json:"start"`
+	End    int     `json:"end"`
+}
+
+// Verification is how a solution's build was checked against its sequencing reads.
+type Verification struct {
+	Time        string             `json:"time"`
+	Reads       []string           `json:"reads"`
+	Coverage    float64            `json:"coverage"`
+	Junctions   []JunctionCoverage `json:"junctions,omitempty"`
+	Differences []SeqDifference    `json:"differences,omitempty"`
+	Verified    bool               `json:"verified"`
+}
+
+// JunctionCoverage is a junction of a fragment and the next one, and the reads that span it.
+type JunctionCoverage struct {
+	Fragment int      `json:"fragment"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+	Reads    []string `json:"reads,omitempty"`
+}
+
+// SeqDifference is a difference between the reads of a build and its expected sequence.
+type SeqDifference struct {
+	Position int      `json:"position"`
+	Type     string   `json:"type"`
+	Expected string   `json:"expected,omitempty"`
+	Observed string   `json:"observed,omitempty"`
+	Reads    []string `json:"reads"`
+	Depth    int      `json:"depth"`
+	Fragment int      `json:"fragment,omitempty"`
+	Region   string   `json:"region,omitempty"`
+}
+
+// QPCRAssay is a qPCR primer pair and internal hydrolysis probe that verify an assembly.
+type QPCRAssay struct {
+	Name     string `json:"name"`
+	Fragment int    `json:"fragment,omitempty"`
+	Forward  Primer `json:"forward"`
+	Reverse  Primer `json:"reverse"`
+	Probe    Primer `json:"probe"`
+	Amplicon int    `json:"amplicon"`
+}
+
+// GoldenGate is the set of overhangs at a solution's junctions and its predicted ligation fidelity.
+type GoldenGate struct {
+	Overhangs []Overhang `json:"overhangs"`
+	Fidelity  float64    `json:"fidelity"`
+}
+
+// Overhang is the overhang at the junction of a fragment and the next one.
+type Overhang struct {
+	Fragment int     `json:"fragment"`
+	Overhang string  `json:"overhang"`
+	Start    int     `json:"start"`
+	Fidelity float64 `json:"fidelity"`
+}
+
+// FeatureSource is how a solution makes one of the features of a features mode target.
+type FeatureSource struct {
+	Feature       string  `json:"feature"`
+	Type          string  `json:"type"`
+	Template      string  `json:"template,omitempty"`
+	Identity      float64 `json:"identity,omitempty"`
+	TemplateStart int     `json:"templateStart,omitempty"`
+	TemplateEnd   int     `json:"templateEnd,omitempty"`
+}
+
+// ParetoPoint is a point on the pareto front of the solutions.
+type ParetoPoint struct {
+	Count      int     `json:"count"`
+	SynthCount int     `json:"synthCount"`
+	Cost       float64 `json:"cost"`
+	Solutions  []int   `json:"solutions"`
+}
+
+// CostBreakdown is the cost of a solution by category.
+type CostBreakdown struct {
+	Procurement      float64 `json:"procurement"`
+	Primers          float64 `json:"primers"`
+	PCRReactions     float64 `json:"pcrReactions"`
+	Synthesis        float64 `json:"synthesis"`
+	AssemblyReaction float64 `json:"assemblyReaction"`
+	Time             float64 `json:"time"`
+	Discount         float64 `json:"discount,omitempty"`
+	Shipping         float64 `json:"shipping,omitempty"`
+	Tax              float64 `json:"tax,omitempty"`
+}
+
+// Fragment is a single building block of a solution.
+type Fragment struct {
+	// ID of the fragment
+	ID string `json:"id,omitempty"`
+
+	// Type of the fragment: linear, plasmid, pcr or synthetic
+	Type string `json:"type"`
+
+	// Cost to make the fragment
+	Cost float64 `json:"cost"`
+
+	// AdjustedCost for synthetic fragments
+	AdjustedCost float64 `json:"adjustedCost"`
+
+	// Seq of the fragment
+	Seq string `json:"seq,omitempty"`
+
+	// PCRSeq is the sequence of a PCR fragment after the primers add bp
+	PCRSeq string `json:"pcrSeq,omitempty"`
+
+	// Primers to create a PCR fragment
+	Primers []Primer `json:"primers,omitempty"`
+
+	// LongRange is whether the PCR product needs long-range PCR
+	LongRange bool `json:"longRange,omitempty"`
+
+	// Mismatches are the 1-based positions on the target where the fragment's template differs from it
+	Mismatches []int `json:"mismatches,omitempty"`
+
+	// MismatchesUnknown is whether the template's alignment had gaps, so its mismatches aren't known
+	MismatchesUnknown bool `json:"mismatchesUnknown,omitempty"`
+
+	// Warnings about the fragment's design, ex: primers picked despite their constraints
+	Warnings []string `json:"warnings,omitempty"`
+
+	// URL of the template's Addgene, iGEM or DNASU entry, only in JSON-V1 outputs and outputs that predate the schema
+	URL string `json:"url,omitempty"`
+}
+
+// Primer is a primer of a PCR fragment.
+type Primer struct {
+	Seq           string  `json:"seq"`
+	Strand        bool    `json:"strand"`
+	Penalty       float64 `json:"penalty"`
+	PairPenalty   float64 `json:"pairPenalty"`
+	Tm            float64 `json:"tm"`
+	GC            float64 `json:"gc"`
+	PrimingRegion string  `json:"primingRegion"`
+	Notes         string  `json:"notes"`
+}
+
+// PickListEntry is a primer to pick from a plate, or the stock, rather than order.
+type PickListEntry struct {
+	ID        string `json:"id"`
+	Seq       string `json:"seq"`
+	Plate     string `json:"plate"`
+	Well      string `json:"well"`
+	Location  string `json:"location,omitempty"`
+	Validated string `json:"validated,omitempty"`
+	Ordered   string `json:"ordered,omitempty"`
+}
+
+// Screening is how to tell a solution's intended product apart from its likely failure products.
+type Screening struct {
+	DiagnosticEnzyme string             `json:"diagnosticEnzyme,omitempty"`
+	Products         []PredictedProduct `json:"products"`
+}
+
+// PredictedProduct is the intended product or a failure product of an assembly.
+type PredictedProduct struct {
+	Name          string `json:"name"`
+	Length        int    `json:"length"`
+	ColonyPCRBand int    `json:"colonyPCRBand"`
+	DigestBands   []int  `json:"digestBands,omitempty"`
+}
+
+// Backbone is a backbone linearized by enzymes.
+type Backbone struct {
+	URL      string   `json:"url"`
+	Seq      string   `json:"seq"`
+	Enzymes  []string `json:"enzymes"`
+	Cutsites []int    `json:"recognitionIndex"`
+	Strands  []bool   `json:"strands"`
+}
+
+// RunMetadata describes the run that produced an output.
+type RunMetadata struct {
+	Version     string                 `json:"version"`
+	Commit      string                 `json:"commit,omitempty"`
+	CommandLine []string               `json:"commandLine"`
+	Hostname    string                 `json:"hostname,omitempty"`
+	Config      map[string]interface{} `json:"config"`
+	Databases   []DatabaseChecksum     `json:"databases,omitempty"`
+	Templates   []TemplateVersion      `json:"templates,omitempty"`
+	PlanHash    string                 `json:"planHash"`
+	Commands    []CommandStats         `json:"commands,omitempty"`
+}
+
+// CommandStats are the counters of the runs of an external command, ex: blastn.
+type CommandStats struct {
+	Command     string  `json:"command"`
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	Seconds     float64 `json:"seconds"`
+	MaxSeconds  float64 `json:"maxSeconds"`
+	WaitSeconds float64 `json:"waitSeconds"`
+}
+
+// DatabaseChecksum is the checksum of a sequence database's FASTA file.
+type DatabaseChecksum struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// TemplateVersion is the version of a database entry that an output's solutions used.
+type TemplateVersion struct {
+	Database  string `json:"database"`
+	ID        string `json:"id"`
+	Checksum  string `json:"checksum"`
+	Version   int    `json:"version"`
+	Solutions []int  `json:"solutions"`
+	Flipped   bool   `json:"flipped,omitempty"`
+}
+
+// Decode reads an output from JSON. It fails if the output's schema has a
+// different major version than SchemaVersion. Outputs without a schema
+// version, written before it was added, are read as the first version.
+func Decode(r io.Reader) (*Output, error) {
+	out := &Output{}
+	if err := json.NewDecoder(r).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to parse repp output: %v", err)
+	}
+
+	if out.SchemaVersion != "" {
+		major, err := majorVersion(out.SchemaVersion)
+		if err != nil {
+			return nil, err
+		}
+		if supported, _ := majorVersion(SchemaVersion); major != supported {
+			return nil, fmt.Errorf("unsupported repp output schema version %s, expected %d.x", out.SchemaVersion, supported)
+		}
+	}
+
+	return out, nil
+}
+
+// DecodeFile reads an output from a JSON file.
+func DecodeFile(filename string) (*Output, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return out, nil
+}
+
+// majorVersion returns the major version of a semantic version, ex: 1 for "1.2.0"
+func majorVersion(version string) (int, error) {
+	major, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q", version)
+	}
+	return major, nil
+}
@@ -0,0 +1,80 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		wantTarget string
+		wantErr    bool
+	}{
+		{
+			"current schema version",
+			`{"schemaVersion": "1.0.0", "target": "GFP", "solutions": [{"count": 1, "fragments": [{"type": "pcr"}]}]}`,
+			"GFP",
+			false,
+		},
+		{
+			"later minor version with an unknown field",
+			`{"schemaVersion": "1.3.0", "target": "GFP", "newField": true, "solutions": []}`,
+			"GFP",
+			false,
+		},
+		{
+			"output written before the schema version",
+			`{"target": "GFP", "solutions": []}`,
+			"GFP",
+			false,
+		},
+		{
+			"unsupported major version",
+			`{"schemaVersion": "2.0.0", "target": "GFP", "solutions": []}`,
+			"",
+			true,
+		},
+		{
+			"invalid schema version",
+			`{"schemaVersion": "latest", "target": "GFP", "solutions": []}`,
+			"",
+			true,
+		},
+		{
+			"malformed JSON",
+			`{"target": `,
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(strings.NewReader(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Target != tt.wantTarget {
+				t.Errorf("Decode() target = %q, want %q", got.Target, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	s := struct {
+		ID         string                 `json:"$id"`
+		Properties map[string]interface{} `json:"properties"`
+	}{}
+	if err := json.Unmarshal(Schema(), &s); err != nil {
+		t.Fatalf("Schema() is not valid JSON: %v", err)
+	}
+	if !strings.HasSuffix(s.ID, "/"+SchemaVersion) {
+		t.Errorf("Schema() $id = %s, want it to end with the schema version %s", s.ID, SchemaVersion)
+	}
+	if _, ok := s.Properties["schemaVersion"]; !ok {
+		t.Error("Schema() is missing the schemaVersion property")
+	}
+}